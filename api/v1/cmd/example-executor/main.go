@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/url"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/mesos/mesos-go/api/v1/lib"
@@ -35,10 +39,27 @@ func main() {
 		log.Fatal("failed to load configuration: " + err.Error())
 	}
 	log.Printf("configuration loaded: %+v", cfg)
-	run(cfg)
+	if err := run(cfg); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
 	os.Exit(0)
 }
 
+// handleSignals returns a context that's canceled upon SIGINT or SIGTERM.
+func handleSignals() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sigCh)
+		sig := <-sigCh
+		log.Printf("received %v, shutting down", sig)
+		cancel()
+	}()
+	return ctx
+}
+
 func maybeReconnect(cfg config.Config) <-chan struct{} {
 	if cfg.Checkpoint {
 		return backoff.Notifier(1*time.Second, cfg.SubscriptionBackoffMax*3/4, nil)
@@ -46,8 +67,15 @@ func maybeReconnect(cfg config.Config) <-chan struct{} {
 	return nil
 }
 
-func run(cfg config.Config) {
+// run subscribes to the agent and processes events until the executor is told to shut down, or is
+// interrupted by a signal. An error is returned if the executor fails to (re-)establish a subscription.
+func run(cfg config.Config) error {
+	codec, ok := codecs.ByName(cfg.Codec)
+	if !ok {
+		return fmt.Errorf("unsupported codec %q", cfg.Codec)
+	}
 	var (
+		ctx    = handleSignals()
 		apiURL = url.URL{
 			Scheme: "http", // TODO(jdef) make this configurable
 			Host:   cfg.AgentEndpoint,
@@ -55,7 +83,7 @@ func run(cfg config.Config) {
 		}
 		http = httpcli.New(
 			httpcli.Endpoint(apiURL.String()),
-			httpcli.Codec(codecs.ByMediaType[codecs.MediaTypeProtobuf]),
+			httpcli.Codec(codec),
 			httpcli.Do(httpcli.With(httpcli.Timeout(httpTimeout))),
 		)
 		callOptions = executor.CallOptions{
@@ -70,6 +98,7 @@ func run(cfg config.Config) {
 			unackedTasks:   make(map[mesos.TaskID]mesos.TaskInfo),
 			unackedUpdates: make(map[string]executor.Call_Update),
 			failedTasks:    make(map[mesos.TaskID]mesos.TaskStatus),
+			running:        make(map[mesos.TaskID]*runningTask),
 		}
 		subscriber = calls.SenderWith(
 			httpexec.NewSender(http.Send, httpcli.Close(true)),
@@ -84,35 +113,46 @@ func run(cfg config.Config) {
 			subscribe := calls.Subscribe(unacknowledgedTasks(state), unacknowledgedUpdates(state))
 
 			log.Println("subscribing to agent for events..")
-			resp, err := subscriber.Send(context.TODO(), calls.NonStreaming(subscribe))
+			resp, err := subscriber.Send(ctx, calls.NonStreaming(subscribe))
 			if resp != nil {
 				defer resp.Close()
 			}
 			if err == nil {
 				// we're officially connected, start decoding events
-				err = eventLoop(state, resp, handler)
+				err = eventLoop(ctx, state, resp, handler)
 				disconnected = time.Now()
 			}
-			if err != nil && err != io.EOF {
+			if err != nil && err != io.EOF && ctx.Err() == nil {
 				log.Println(err)
 			} else {
 				log.Println("disconnected")
 			}
 		}()
+		if ctx.Err() != nil {
+			// drain: kill running tasks and report the status of failed tasks while we still can
+			killAll(state)
+			sendFailedTasks(state)
+			log.Println("gracefully shutting down because we were interrupted")
+			return nil
+		}
 		if state.shouldQuit {
+			killAll(state)
+			sendFailedTasks(state)
 			log.Println("gracefully shutting down because we were told to")
-			return
+			return nil
 		}
 		if !cfg.Checkpoint {
 			log.Println("gracefully exiting because framework checkpointing is NOT enabled")
-			return
+			return nil
 		}
 		if time.Now().Sub(disconnected) > cfg.RecoveryTimeout {
-			log.Printf("failed to re-establish subscription with agent within %v, aborting", cfg.RecoveryTimeout)
-			return
+			return fmt.Errorf("failed to re-establish subscription with agent within %v, aborting", cfg.RecoveryTimeout)
 		}
 		log.Println("waiting for reconnect timeout")
-		<-shouldReconnect // wait for some amount of time before retrying subscription
+		select {
+		case <-shouldReconnect: // wait for some amount of time before retrying subscription
+		case <-ctx.Done():
+		}
 	}
 }
 
@@ -129,6 +169,8 @@ func unacknowledgedTasks(state *internalState) (result []mesos.TaskInfo) {
 
 // unacknowledgedUpdates generates the value of the UnacknowledgedUpdates field of a Subscribe call.
 func unacknowledgedUpdates(state *internalState) (result []executor.Call_Update) {
+	state.m.Lock()
+	defer state.m.Unlock()
 	if n := len(state.unackedUpdates); n > 0 {
 		result = make([]executor.Call_Update, 0, n)
 		for k := range state.unackedUpdates {
@@ -138,16 +180,17 @@ func unacknowledgedUpdates(state *internalState) (result []executor.Call_Update)
 	return
 }
 
-func eventLoop(state *internalState, decoder encoding.Decoder, h events.Handler) (err error) {
+func eventLoop(ctx context.Context, state *internalState, decoder encoding.Decoder, h events.Handler) (err error) {
 	log.Println("listening for events from agent...")
-	ctx := context.TODO()
-	for err == nil && !state.shouldQuit {
+	for err == nil && !state.shouldQuit && ctx.Err() == nil {
 		// housekeeping
 		sendFailedTasks(state)
 
 		var e executor.Event
 		if err = decoder.Decode(&e); err == nil {
 			err = h.HandleEvent(ctx, &e)
+		} else if _, ok := encoding.IsUnknownEvent(err); ok {
+			err = nil // e.g. an event of a type that was added by a newer version of Mesos
 		}
 	}
 	return err
@@ -167,12 +210,20 @@ func buildEventHandler(state *internalState) events.Handler {
 			return nil
 		},
 		executor.Event_KILL: func(_ context.Context, e *executor.Event) error {
-			log.Println("warning: KILL not implemented")
+			var grace time.Duration
+			if gp := e.Kill.GetKillPolicy().GetGracePeriod(); gp != nil {
+				grace = time.Duration(gp.Nanoseconds)
+			}
+			if !kill(state, e.Kill.TaskID, grace) {
+				log.Printf("KILL: task %s is not running", e.Kill.TaskID.Value)
+			}
 			return nil
 		},
 		executor.Event_ACKNOWLEDGED: func(_ context.Context, e *executor.Event) error {
 			delete(state.unackedTasks, e.Acknowledged.TaskID)
+			state.m.Lock()
 			delete(state.unackedUpdates, string(e.Acknowledged.UUID))
+			state.m.Unlock()
 			return nil
 		},
 		executor.Event_MESSAGE: func(_ context.Context, e *executor.Event) error {
@@ -195,12 +246,21 @@ func buildEventHandler(state *internalState) events.Handler {
 }
 
 func sendFailedTasks(state *internalState) {
+	state.m.Lock()
+	failed := make(map[mesos.TaskID]mesos.TaskStatus, len(state.failedTasks))
 	for taskID, status := range state.failedTasks {
+		failed[taskID] = status
+	}
+	state.m.Unlock()
+
+	for taskID, status := range failed {
 		updateErr := update(state, status)
 		if updateErr != nil {
 			log.Printf("failed to send status update for task %s: %+v", taskID.Value, updateErr)
 		} else {
+			state.m.Lock()
 			delete(state.failedTasks, taskID)
+			state.m.Unlock()
 		}
 	}
 }
@@ -208,28 +268,31 @@ func sendFailedTasks(state *internalState) {
 func launch(state *internalState, task mesos.TaskInfo) {
 	state.unackedTasks[task.TaskID] = task
 
+	sim, err := newSimulation(task, state.framework)
+	if err != nil {
+		log.Printf("invalid task %s: %+v", task.TaskID.Value, err)
+		status := terminalStatus(state, task.TaskID, mesos.TASK_FAILED, err.Error())
+		status.Reason = mesos.REASON_TASK_INVALID.Enum()
+		terminate(state, status)
+		return
+	}
+
 	// send RUNNING
 	status := newStatus(state, task.TaskID)
 	status.State = mesos.TASK_RUNNING.Enum()
-	err := update(state, status)
+	err = update(state, status)
 	if err != nil {
 		log.Printf("failed to send TASK_RUNNING for task %s: %+v", task.TaskID.Value, err)
 		status.State = mesos.TASK_FAILED.Enum()
 		status.Message = protoString(err.Error())
+		state.m.Lock()
 		state.failedTasks[task.TaskID] = status
+		state.m.Unlock()
 		return
 	}
 
-	// send FINISHED
-	status = newStatus(state, task.TaskID)
-	status.State = mesos.TASK_FINISHED.Enum()
-	err = update(state, status)
-	if err != nil {
-		log.Printf("failed to send TASK_FINISHED for task %s: %+v", task.TaskID.Value, err)
-		status.State = mesos.TASK_FAILED.Enum()
-		status.Message = protoString(err.Error())
-		state.failedTasks[task.TaskID] = status
-	}
+	// the simulation sends FINISHED, or some other terminal state
+	simulate(state, task.TaskID, sim)
 }
 
 // helper func to package strings up nicely for protobuf
@@ -247,7 +310,9 @@ func update(state *internalState, status mesos.TaskStatus) error {
 		log.Printf("failed to send update: %+v", err)
 		debugJSON(upd)
 	} else {
+		state.m.Lock()
 		state.unackedUpdates[string(status.UUID)] = *upd.Update
+		state.m.Unlock()
 	}
 	return err
 }
@@ -270,5 +335,9 @@ type internalState struct {
 	unackedTasks   map[mesos.TaskID]mesos.TaskInfo
 	unackedUpdates map[string]executor.Call_Update
 	failedTasks    map[mesos.TaskID]mesos.TaskStatus // send updates for these as we can
+	running        map[mesos.TaskID]*runningTask
 	shouldQuit     bool
+
+	m     sync.Mutex     // guards unackedUpdates, failedTasks, and running
+	tasks sync.WaitGroup // tracks the goroutines of running tasks
 }
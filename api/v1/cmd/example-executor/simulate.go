@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// Task labels that select the simulated behavior of a task, so that the example scheduler and executor
+// may be used as a cluster smoke-test suite; e.g. the scheduler's -task.label=executor.mode=fail flag.
+// Durations are expressed as per time.ParseDuration.
+const (
+	// labelMode selects what happens to a task once it's running: it finishes (modeFinish, the
+	// default), fails (modeFail), or else runs until it's killed (modeRun).
+	labelMode = "executor.mode"
+	// labelDuration is how long a task runs before it finishes or fails; defaults to zero. For modeRun
+	// tasks it's optional, and if specified then the task finishes once it elapses.
+	labelDuration = "executor.duration"
+	// labelKillDelay is how long a task takes to terminate once it's killed; if the task's kill policy
+	// specifies a shorter grace period then the task is "forcibly" killed once the grace period elapses.
+	labelKillDelay = "executor.killDelay"
+	// labelHealthFlap is the interval upon which the health of a running task flips between healthy and
+	// unhealthy.
+	labelHealthFlap = "executor.healthFlap"
+
+	modeFinish = "finish"
+	modeFail   = "fail"
+	modeRun    = "run"
+)
+
+// simulation describes the behavior of a task, as configured by its labels.
+type simulation struct {
+	mode         string
+	duration     time.Duration
+	killDelay    time.Duration
+	healthFlap   time.Duration
+	gracePeriod  time.Duration // zero if the task has no kill policy
+	killingState bool          // true if the framework understands TASK_KILLING
+}
+
+// runningTask is a task whose simulation is in progress.
+type runningTask struct {
+	kill chan time.Duration // yields the grace period of a KILL event, if any
+}
+
+func newSimulation(task mesos.TaskInfo, framework mesos.FrameworkInfo) (sim simulation, err error) {
+	sim.mode = modeFinish
+	for _, l := range task.GetLabels().GetLabels() {
+		var d *time.Duration
+		switch l.Key {
+		case labelMode:
+			switch v := l.GetValue(); v {
+			case modeFinish, modeFail, modeRun:
+				sim.mode = v
+			default:
+				return sim, fmt.Errorf("unsupported %s %q", labelMode, v)
+			}
+			continue
+		case labelDuration:
+			d = &sim.duration
+		case labelKillDelay:
+			d = &sim.killDelay
+		case labelHealthFlap:
+			d = &sim.healthFlap
+		default:
+			continue
+		}
+		if *d, err = time.ParseDuration(l.GetValue()); err != nil || *d < 0 {
+			return sim, fmt.Errorf("illegal %s %q", l.Key, l.GetValue())
+		}
+	}
+	if gp := task.GetKillPolicy().GetGracePeriod(); gp != nil {
+		sim.gracePeriod = time.Duration(gp.Nanoseconds)
+	}
+	for _, c := range framework.Capabilities {
+		if c.Type == mesos.FrameworkInfo_Capability_TASK_KILLING_STATE {
+			sim.killingState = true
+		}
+	}
+	return sim, nil
+}
+
+// simulate starts a goroutine that reports the status of a running task, as directed by its simulation,
+// until the task terminates.
+func simulate(state *internalState, taskID mesos.TaskID, sim simulation) {
+	rt := &runningTask{kill: make(chan time.Duration, 1)}
+	state.m.Lock()
+	state.running[taskID] = rt
+	state.m.Unlock()
+
+	state.tasks.Add(1)
+	go func() {
+		defer func() {
+			state.m.Lock()
+			delete(state.running, taskID)
+			state.m.Unlock()
+			state.tasks.Done()
+		}()
+		var (
+			deadline <-chan time.Time
+			flap     <-chan time.Time
+			healthy  = true
+		)
+		if sim.mode != modeRun || sim.duration > 0 {
+			t := time.NewTimer(sim.duration)
+			defer t.Stop()
+			deadline = t.C
+		}
+		if sim.healthFlap > 0 {
+			t := time.NewTicker(sim.healthFlap)
+			defer t.Stop()
+			flap = t.C
+		}
+		for {
+			select {
+			case <-deadline:
+				if sim.mode == modeFail {
+					terminate(state, terminalStatus(state, taskID, mesos.TASK_FAILED, "simulated failure"))
+				} else {
+					terminate(state, terminalStatus(state, taskID, mesos.TASK_FINISHED, ""))
+				}
+				return
+			case <-flap:
+				healthy = !healthy
+				status := newStatus(state, taskID)
+				status.State = mesos.TASK_RUNNING.Enum()
+				status.Healthy = &healthy
+				status.Reason = mesos.REASON_TASK_HEALTH_CHECK_STATUS_UPDATED.Enum()
+				if err := update(state, status); err != nil {
+					log.Printf("failed to send health of task %s: %+v", taskID.Value, err)
+				}
+			case grace := <-rt.kill:
+				killTask(state, taskID, sim, grace)
+				return
+			}
+		}
+	}()
+}
+
+// killTask waits for the simulated kill delay, or else the grace period (if shorter), before reporting the
+// task as killed.
+func killTask(state *internalState, taskID mesos.TaskID, sim simulation, grace time.Duration) {
+	if grace <= 0 {
+		grace = sim.gracePeriod
+	}
+	delay, message := sim.killDelay, ""
+	if grace > 0 && grace < delay {
+		delay, message = grace, "killed forcibly after grace period of "+grace.String()
+	}
+	if delay > 0 {
+		if sim.killingState {
+			status := newStatus(state, taskID)
+			status.State = mesos.TASK_KILLING.Enum()
+			if err := update(state, status); err != nil {
+				log.Printf("failed to send TASK_KILLING for task %s: %+v", taskID.Value, err)
+			}
+		}
+		time.Sleep(delay)
+	}
+	terminate(state, terminalStatus(state, taskID, mesos.TASK_KILLED, message))
+}
+
+// kill requests that the task is killed, returning false if the task isn't running.
+func kill(state *internalState, taskID mesos.TaskID, grace time.Duration) bool {
+	state.m.Lock()
+	rt, ok := state.running[taskID]
+	state.m.Unlock()
+	if ok {
+		select {
+		case rt.kill <- grace:
+		default: // already being killed
+		}
+	}
+	return ok
+}
+
+// killAll kills all running tasks, waiting for them to terminate.
+func killAll(state *internalState) {
+	state.m.Lock()
+	taskIDs := make([]mesos.TaskID, 0, len(state.running))
+	for id := range state.running {
+		taskIDs = append(taskIDs, id)
+	}
+	state.m.Unlock()
+	for _, id := range taskIDs {
+		kill(state, id, 0)
+	}
+	state.tasks.Wait()
+}
+
+// terminalStatus returns a status that reports that the task reached the given terminal state.
+func terminalStatus(state *internalState, taskID mesos.TaskID, s mesos.TaskState, message string) mesos.TaskStatus {
+	status := newStatus(state, taskID)
+	status.State = s.Enum()
+	if message != "" {
+		status.Message = protoString(message)
+	}
+	return status
+}
+
+// terminate sends the terminal status of a task; upon failure to do so the task is recorded as failed, so
+// that it may be reported later on.
+func terminate(state *internalState, status mesos.TaskStatus) {
+	if err := update(state, status); err != nil {
+		log.Printf("failed to send %v for task %s: %+v", status.GetState(), status.TaskID.Value, err)
+		status.State = mesos.TASK_FAILED.Enum()
+		status.Message = protoString(err.Error())
+		state.m.Lock()
+		state.failedTasks[status.TaskID] = status
+		state.m.Unlock()
+	}
+}
@@ -2,8 +2,6 @@ package app
 
 import (
 	"context"
-	"errors"
-	"io"
 	"log"
 	"strconv"
 	"time"
@@ -14,6 +12,7 @@ import (
 	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
 	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/controller"
 	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	xtasks "github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
 	"github.com/mesos/mesos-go/api/v1/lib/extras/store"
 	"github.com/mesos/mesos-go/api/v1/lib/resources"
 	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
@@ -32,6 +31,11 @@ type StateError string
 
 func (err StateError) Error() string { return string(err) }
 
+// TaskError is returned when a task reaches an unexpected state.
+type TaskError string
+
+func (err TaskError) Error() string { return string(err) }
+
 func Run(cfg Config) error {
 	log.Printf("scheduler running with configuration: %+v", cfg)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -45,8 +49,17 @@ func Run(cfg Config) error {
 	// probably tolerate X number of subsequent subscribe failures before bailing. we'll need
 	// to track the lastCallAttempted along with subsequentSubscribeTimeouts.
 
+	baseStore, stateStore, err := newStores(cfg)
+	if err != nil {
+		return err
+	}
+	state.store = stateStore
+	if err = state.restoreState(); err != nil {
+		return err
+	}
+
 	fidStore := store.DecorateSingleton(
-		store.NewInMemorySingleton(),
+		baseStore,
 		store.DoSet().AndThen(func(_ store.Setter, v string, _ error) error {
 			log.Println("FrameworkID", v)
 			return nil
@@ -58,6 +71,11 @@ func Run(cfg Config) error {
 		callMetrics(state.metricsAPI, time.Now, state.config.summaryMetrics),
 	).Caller(state.cli)
 
+	if state.config.apiPath != "" {
+		initControlAPI(ctx, state, state.config.apiPath)
+	}
+	handleSignals(ctx, state, fidStore)
+
 	err = controller.Run(
 		ctx,
 		buildFrameworkInfo(state.config),
@@ -69,9 +87,7 @@ func Run(cfg Config) error {
 		),
 		controller.WithSubscriptionTerminated(func(err error) {
 			if err != nil {
-				if err != io.EOF {
-					log.Println(err)
-				}
+				log.Println(err) // e.g. a *controller.DisconnectedError
 				if _, ok := err.(StateError); ok {
 					state.shutdown()
 				}
@@ -80,8 +96,17 @@ func Run(cfg Config) error {
 			log.Println("disconnected")
 		}),
 	)
+
+	// in-flight work has drained; save the final state of the scheduler.
+	state.m.Lock()
+	defer state.m.Unlock()
+	state.saveState()
 	if state.err != nil {
-		err = state.err
+		return state.err
+	}
+	if ctx.Err() != nil {
+		// the scheduler was shut down deliberately
+		return nil
 	}
 	return err
 }
@@ -97,10 +122,15 @@ func buildEventHandler(state *internalState, fidStore store.Singleton) events.Ha
 	).Handle(events.Handlers{
 		scheduler.Event_FAILURE: logger.HandleF(failure),
 		scheduler.Event_OFFERS:  trackOffersReceived(state).HandleF(resourceOffers(state)),
-		scheduler.Event_UPDATE:  controller.AckStatusUpdates(state.cli).AndThen().HandleF(statusUpdate(state)),
+		scheduler.Event_UPDATE:  controller.AckStatusUpdates(state.cli).AndThen(xtasks.Track(state.registry)).HandleF(statusUpdate(state)),
 		scheduler.Event_SUBSCRIBED: eventrules.New(
 			logger,
 			controller.TrackSubscription(fidStore, state.config.failoverTimeout),
+			eventrules.DropOnError(),
+			eventrules.HandleF(func(ctx context.Context, _ *scheduler.Event) error {
+				reconcile(ctx, state)
+				return nil
+			}),
 		),
 	}.Otherwise(logger.HandleEvent))
 }
@@ -138,6 +168,9 @@ func failure(_ context.Context, e *scheduler.Event) error {
 
 func resourceOffers(state *internalState) events.HandlerFunc {
 	return func(ctx context.Context, e *scheduler.Event) error {
+		state.m.Lock()
+		defer state.m.Unlock()
+
 		var (
 			offers                 = e.GetOffers().GetOffers()
 			callOption             = calls.RefuseSecondsWithJitter(state.random, state.config.maxRefuseSeconds)
@@ -155,11 +188,6 @@ func resourceOffers(state *internalState) events.HandlerFunc {
 					"' with resources " + remaining.String())
 			}
 
-			var wantsExecutorResources mesos.Resources
-			if len(offers[i].ExecutorIDs) == 0 {
-				wantsExecutorResources = mesos.Resources(state.executor.Resources)
-			}
-
 			flattened := remaining.ToUnreserved()
 
 			// avoid the expense of computing these if we can...
@@ -172,41 +200,45 @@ func resourceOffers(state *internalState) events.HandlerFunc {
 				}
 			}
 
-			taskWantsResources := state.wantsTaskResources.Plus(wantsExecutorResources...)
-			for state.tasksLaunched < state.totalTasks && resources.ContainsAll(flattened, taskWantsResources) {
-				found := func() mesos.Resources {
-					if state.config.role == "*" {
-						return resources.Find(state.wantsTaskResources, remaining...)
-					}
-					reservation := mesos.Resource_ReservationInfo{
-						Type: mesos.Resource_ReservationInfo_STATIC.Enum(),
-						Role: &state.config.role,
-					}
-					return resources.Find(state.wantsTaskResources.PushReservation(reservation))
-				}()
+			for _, j := range state.jobs {
+				if j.done() || !j.accepts(&offers[i]) {
+					continue
+				}
 
-				if len(found) == 0 {
-					panic("illegal state: failed to find the resources that were supposedly contained")
+				var wantsExecutorResources mesos.Resources
+				if j.executor != nil && len(offers[i].ExecutorIDs) == 0 {
+					wantsExecutorResources = mesos.Resources(j.executor.Resources)
 				}
 
-				state.tasksLaunched++
-				taskID := state.tasksLaunched
+				taskWantsResources := j.wants.Plus(wantsExecutorResources...)
+				for !j.done() && resources.ContainsAll(flattened, taskWantsResources) {
+					found := func() mesos.Resources {
+						if state.config.role == "*" {
+							return resources.Find(j.wants, remaining...)
+						}
+						reservation := mesos.Resource_ReservationInfo{
+							Type: mesos.Resource_ReservationInfo_STATIC.Enum(),
+							Role: &state.config.role,
+						}
+						return resources.Find(j.wants.PushReservation(reservation))
+					}()
+
+					if len(found) == 0 {
+						panic("illegal state: failed to find the resources that were supposedly contained")
+					}
 
-				if state.config.verbose {
-					log.Println("launching task " + strconv.Itoa(taskID) + " using offer " + offers[i].ID.Value)
-				}
+					state.tasksLaunched++
+					task := j.newTask(state.tasksLaunched, offers[i].AgentID, found)
 
-				task := mesos.TaskInfo{
-					TaskID:    mesos.TaskID{Value: strconv.Itoa(taskID)},
-					AgentID:   offers[i].AgentID,
-					Executor:  state.executor,
-					Resources: found,
-				}
-				task.Name = "Task " + task.TaskID.Value
-				tasks = append(tasks, task)
+					if state.config.verbose {
+						log.Println("launching task " + task.TaskID.Value + " using offer " + offers[i].ID.Value)
+					}
 
-				remaining.Subtract(task.Resources...)
-				flattened = remaining.ToUnreserved()
+					tasks = append(tasks, task)
+
+					remaining.Subtract(task.Resources...)
+					flattened = remaining.ToUnreserved()
+				}
 			}
 
 			// build Accept call to launch all of the tasks we've assembled
@@ -220,6 +252,8 @@ func resourceOffers(state *internalState) events.HandlerFunc {
 				log.Printf("failed to launch tasks: %+v", err)
 			} else {
 				if n := len(tasks); n > 0 {
+					state.registry.Launched(tasks...)
+					state.saveState()
 					tasksLaunchedThisCycle += n
 				} else {
 					offersDeclined++
@@ -246,33 +280,63 @@ func statusUpdate(state *internalState) events.HandlerFunc {
 			if m := s.GetMessage(); m != "" {
 				msg += " with message '" + m + "'"
 			}
+			if s.Healthy != nil {
+				msg += " (healthy=" + strconv.FormatBool(*s.Healthy) + ")"
+			}
 			log.Println(msg)
 		}
 
+		state.m.Lock()
+		defer state.m.Unlock()
+
 		switch st := s.GetState(); st {
 		case mesos.TASK_FINISHED:
 			state.tasksFinished++
 			state.metricsAPI.tasksFinished()
+			state.checkDone(ctx)
 
-			if state.tasksFinished == state.totalTasks {
-				log.Println("mission accomplished, terminating")
-				state.shutdown()
-			} else {
-				tryReviveOffers(ctx, state)
+		case mesos.TASK_KILLED:
+			if _, ok := state.killRequested[s.TaskID]; !ok {
+				state.fail(s)
+				break
 			}
+			// killed upon request via the control API: don't replace it
+			delete(state.killRequested, s.TaskID)
+			state.totalTasks--
+			state.checkDone(ctx)
 
-		case mesos.TASK_LOST, mesos.TASK_KILLED, mesos.TASK_FAILED, mesos.TASK_ERROR:
-			state.err = errors.New("Exiting because task " + s.GetTaskID().Value +
-				" is in an unexpected state " + st.String() +
-				" with reason " + s.GetReason().String() +
-				" from source " + s.GetSource().String() +
-				" with message '" + s.GetMessage() + "'")
-			state.shutdown()
+		case mesos.TASK_LOST, mesos.TASK_FAILED, mesos.TASK_ERROR:
+			state.fail(s)
 		}
+		state.saveState()
 		return nil
 	}
 }
 
+// checkDone terminates the scheduler once all tasks have finished, unless the control API is enabled
+// (in which case more jobs may yet be submitted); otherwise it attempts to revive offers.
+// The caller must hold the state lock.
+func (state *internalState) checkDone(ctx context.Context) {
+	if state.tasksFinished < state.totalTasks {
+		tryReviveOffers(ctx, state)
+	} else if state.config.apiPath != "" {
+		log.Println("all tasks finished, waiting for more jobs")
+	} else {
+		log.Println("mission accomplished, terminating")
+		state.shutdown()
+	}
+}
+
+// fail records a fatal task status and terminates the scheduler. The caller must hold the state lock.
+func (state *internalState) fail(s mesos.TaskStatus) {
+	state.err = TaskError("Exiting because task " + s.GetTaskID().Value +
+		" is in an unexpected state " + s.GetState().String() +
+		" with reason " + s.GetReason().String() +
+		" from source " + s.GetSource().String() +
+		" with message '" + s.GetMessage() + "'")
+	state.shutdown()
+}
+
 func tryReviveOffers(ctx context.Context, state *internalState) {
 	// limit the rate at which we request offer revival
 	select {
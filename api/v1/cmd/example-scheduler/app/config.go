@@ -20,6 +20,7 @@ type Config struct {
 	principal           string
 	hostname            string
 	labels              Labels
+	taskLabels          Labels
 	server              server
 	executor            string
 	tasks               int
@@ -36,10 +37,17 @@ type Config struct {
 	jobRestartDelay     time.Duration
 	summaryMetrics      bool
 	execImage           string
+	execOS              string
 	compression         bool
 	credentials         credentials
 	authMode            string
 	gpuClusterCompat    bool
+	jobs                string
+	apiPath             string
+	stateDir            string
+	commandTask         commandTask
+	teardown            bool
+	shutdownTimeout     time.Duration
 }
 
 func (cfg *Config) AddFlags(fs *flag.FlagSet) {
@@ -54,8 +62,11 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&cfg.principal, "principal", cfg.principal, "Framework principal with which to authenticate")
 	fs.StringVar(&cfg.hostname, "hostname", cfg.hostname, "Framework hostname that is advertised to the master")
 	fs.Var(&cfg.labels, "label", "Framework label, may be specified multiple times")
+	fs.Var(&cfg.taskLabels, "task.label", "Label of example executor tasks, may be specified multiple times; e.g. executor.mode=run selects a simulated behavior of the example executor")
 	fs.StringVar(&cfg.server.address, "server.address", cfg.server.address, "IP of artifact server")
 	fs.IntVar(&cfg.server.port, "server.port", cfg.server.port, "Port of artifact server")
+	fs.StringVar(&cfg.server.certFile, "server.tlsCert", cfg.server.certFile, "TLS certificate file of artifact server; artifacts are served via HTTPS when specified")
+	fs.StringVar(&cfg.server.keyFile, "server.tlsKey", cfg.server.keyFile, "TLS key file of artifact server")
 	fs.StringVar(&cfg.executor, "executor", cfg.executor, "Full path to executor binary")
 	fs.IntVar(&cfg.tasks, "tasks", cfg.tasks, "Number of tasks to spawn")
 	fs.BoolVar(&cfg.verbose, "verbose", cfg.verbose, "Verbose logging")
@@ -72,11 +83,23 @@ func (cfg *Config) AddFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&cfg.jobRestartDelay, "jobRestartDelay", cfg.jobRestartDelay, "Duration between job (internal service) restarts between failures")
 	fs.BoolVar(&cfg.summaryMetrics, "summaryMetrics", cfg.summaryMetrics, "Collect summary metrics for tasks launched per-offer-cycle, offer processing time, etc.")
 	fs.StringVar(&cfg.execImage, "exec.image", cfg.execImage, "Name of the docker image to run the executor")
+	fs.StringVar(&cfg.execOS, "exec.os", cfg.execOS, "Operating system of the agents that run the executor binary, as per GOOS; e.g. windows")
 	fs.BoolVar(&cfg.compression, "compression", cfg.compression, "When true attempt to use compression for HTTP streams.")
 	fs.StringVar(&cfg.credentials.username, "credentials.username", cfg.credentials.username, "Username for Mesos authentication")
 	fs.StringVar(&cfg.credentials.password, "credentials.passwordFile", cfg.credentials.password, "Path to file that contains the password for Mesos authentication")
 	fs.StringVar(&cfg.authMode, "authmode", cfg.authMode, "Method to use for Mesos authentication; specify '"+AuthModeBasic+"' for simple HTTP authentication")
 	fs.BoolVar(&cfg.gpuClusterCompat, "gpuClusterCompat", cfg.gpuClusterCompat, "When true the framework will receive offers from agents w/ GPU resources.")
+	fs.StringVar(&cfg.jobs, "jobs", cfg.jobs, "Path to a JSON file of job specs; when specified, command tasks are launched instead of the example executor workload")
+	fs.StringVar(&cfg.apiPath, "api.path", cfg.apiPath, "URI path prefix of the control API (served by the metrics server); the API is disabled when empty")
+	fs.StringVar(&cfg.stateDir, "state.dir", cfg.stateDir, "Directory in which to persist the framework ID and task state, allowing a restarted scheduler to fail over; state is not persisted when empty")
+	fs.StringVar(&cfg.commandTask.command, "task.command", cfg.commandTask.command, "Shell command of command tasks to launch instead of the example executor workload; respects -tasks, -cpu, and -memory")
+	fs.StringVar(&cfg.commandTask.image, "task.image", cfg.commandTask.image, "Name of the docker image in which to run command tasks")
+	fs.StringVar(&cfg.commandTask.containerizer, "task.containerizer", cfg.commandTask.containerizer, "Containerizer to run command tasks with an image [mesos, docker]")
+	fs.StringVar(&cfg.commandTask.network, "task.network", cfg.commandTask.network, "Network of command task containers; see JobSpec for supported values")
+	fs.UintVar(&cfg.commandTask.gpus, "task.gpus", cfg.commandTask.gpus, "Number of GPUs to allocate to each command task; requires -gpuClusterCompat")
+	fs.StringVar(&cfg.commandTask.healthCheck, "task.healthCheck", cfg.commandTask.healthCheck, "Shell command that Mesos executes to check the health of command tasks")
+	fs.BoolVar(&cfg.teardown, "teardown", cfg.teardown, "When true, tear down the framework (killing its tasks) upon SIGINT or SIGTERM; otherwise the framework may fail over to a restarted scheduler")
+	fs.DurationVar(&cfg.shutdownTimeout, "shutdownTimeout", cfg.shutdownTimeout, "Max length of time to wait for in-flight work (and teardown) to complete upon SIGINT or SIGTERM")
 }
 
 const AuthModeBasic = "basic"
@@ -101,8 +124,10 @@ func NewConfig() Config {
 		reviveWait:       envDuration("REVIVE_WAIT", "1s"),
 		maxRefuseSeconds: envDuration("MAX_REFUSE_SECONDS", "5s"),
 		jobRestartDelay:  envDuration("JOB_RESTART_DELAY", "5s"),
+		shutdownTimeout:  envDuration("SHUTDOWN_TIMEOUT", "10s"),
 		execImage:        env("EXEC_IMAGE", cmd.DockerImageTag),
 		executor:         env("EXEC_BINARY", "/opt/example-executor"),
+		execOS:           env("EXEC_OS", "linux"),
 		metrics: metrics{
 			port: envInt("PORT0", "64009"),
 			path: env("METRICS_API_PATH", "/metrics"),
@@ -112,12 +137,17 @@ func NewConfig() Config {
 			password: env("AUTH_PASSWORD_FILE", ""),
 		},
 		authMode: env("AUTH_MODE", ""),
+		jobs:     env("JOBS_FILE", ""),
+		apiPath:  env("CONTROL_API_PATH", ""),
+		stateDir: env("SCHEDULER_STATE_DIR", ""),
 	}
 }
 
 type server struct {
-	address string
-	port    int
+	address  string
+	port     int
+	certFile string
+	keyFile  string
 }
 
 type metrics struct {
@@ -125,6 +155,15 @@ type metrics struct {
 	path string
 }
 
+type commandTask struct {
+	command       string
+	image         string
+	containerizer string
+	network       string
+	healthCheck   string
+	gpus          uint
+}
+
 type credentials struct {
 	username string
 	password string
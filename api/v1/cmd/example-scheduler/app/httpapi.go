@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+type (
+	jobView struct {
+		Name      string `json:"name"`
+		Instances int    `json:"instances"`
+		Launched  int    `json:"launched"`
+	}
+
+	taskView struct {
+		ID      string `json:"id"`
+		State   string `json:"state"`
+		AgentID string `json:"agent_id,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// initControlAPI registers the handlers of the control API with the default HTTP mux, which is served
+// by the metrics server. The API supports:
+//
+//	GET  {path}/jobs          list jobs and their launch progress
+//	POST {path}/jobs          submit a new job; the request body is a JSON encoded JobSpec
+//	GET  {path}/tasks         list the state of all active tasks
+//	POST {path}/tasks/kill    kill the task identified by the "id" query parameter
+func initControlAPI(ctx context.Context, state *internalState, path string) {
+	http.HandleFunc(path+"/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, state.listJobs())
+		case http.MethodPost:
+			var spec JobSpec
+			if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+				http.Error(w, fmt.Sprintf("failed to decode job spec: %+v", err), http.StatusBadRequest)
+				return
+			}
+			if err := state.submitJob(ctx, spec); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	http.HandleFunc(path+"/tasks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		statuses := state.registry.Select(nil)
+		result := make([]taskView, 0, len(statuses))
+		for i := range statuses {
+			result = append(result, taskView{
+				ID:      statuses[i].TaskID.Value,
+				State:   statuses[i].GetState().String(),
+				AgentID: statuses[i].GetAgentID().GetValue(),
+				Message: statuses[i].GetMessage(),
+			})
+		}
+		writeJSON(w, result)
+	})
+	http.HandleFunc(path+"/tasks/kill", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "missing task id", http.StatusBadRequest)
+			return
+		}
+		if err := state.killTask(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	log.Println("control API available at " + path)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write API response: %+v", err)
+	}
+}
+
+func (state *internalState) listJobs() []jobView {
+	state.m.Lock()
+	defer state.m.Unlock()
+	result := make([]jobView, 0, len(state.jobs))
+	for _, j := range state.jobs {
+		result = append(result, jobView{Name: j.name, Instances: j.instances, Launched: j.launched})
+	}
+	return result
+}
+
+func (state *internalState) submitJob(ctx context.Context, spec JobSpec) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	state.m.Lock()
+	defer state.m.Unlock()
+	for _, j := range state.jobs {
+		if j.name == spec.Name {
+			return fmt.Errorf("duplicate job name %q", spec.Name)
+		}
+	}
+	j := newCommandJob(spec)
+	if err := j.validateGPUs(buildFrameworkInfo(state.config)); err != nil {
+		return err
+	}
+	state.jobs = append(state.jobs, j)
+	state.totalTasks += spec.Instances
+	log.Printf("submitted job spec: %+v", spec)
+
+	// we may have previously suppressed, or declined offers for some time; ask for more.
+	tryReviveOffers(ctx, state)
+	return nil
+}
+
+func (state *internalState) killTask(ctx context.Context, id string) error {
+	taskID := mesos.TaskID{Value: id}
+	status, ok := state.registry.Get(taskID)
+	if !ok {
+		return fmt.Errorf("unknown task %q", id)
+	}
+
+	state.m.Lock()
+	state.killRequested[taskID] = struct{}{}
+	state.m.Unlock()
+
+	err := calls.CallNoData(ctx, state.cli, calls.Kill(id, status.GetAgentID().GetValue()))
+	if err != nil {
+		state.m.Lock()
+		delete(state.killRequested, taskID)
+		state.m.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,391 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// JobSpec describes a set of identical command tasks that the scheduler should launch. Specs are loaded
+// from a JSON file via the -jobs flag, for example:
+//
+//	[{"name": "sleeper", "command": "sleep 60", "instances": 2, "cpus": 0.1, "mem": 32,
+//	  "env": {"FOO": "bar"},
+//	  "constraints": [{"field": "hostname", "operator": "LIKE", "value": "agent-.*"}]}]
+//
+// Command tasks are executed by the Mesos built-in command executor; when an image is specified the
+// task runs inside of a container created by the Mesos containerizer (UCR), or else by the Docker
+// containerizer if so requested.
+//
+// GPUs is optional; jobs that request GPUs require the framework to be configured with the
+// -gpuClusterCompat flag, which advertises the GPU_RESOURCES capability.
+//
+// Network is optional and selects the network that the container joins. For Docker containers it's one
+// of "host" (the default), "bridge", "none", or else the name of a user-defined network; for UCR
+// containers it's the name of a CNI network (by default containers share the network of the agent).
+type JobSpec struct {
+	Name          string            `json:"name"`
+	Command       string            `json:"command"`
+	Image         string            `json:"image,omitempty"`
+	Containerizer string            `json:"containerizer,omitempty"`
+	Network       string            `json:"network,omitempty"`
+	Instances     int               `json:"instances"`
+	CPUs          float64           `json:"cpus"`
+	Memory        float64           `json:"mem"`
+	Disk          float64           `json:"disk,omitempty"`
+	GPUs          uint              `json:"gpus,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+	Constraints   []Constraint      `json:"constraints,omitempty"`
+	HealthCheck   *HealthCheckSpec  `json:"health_check,omitempty"`
+}
+
+// HealthCheckSpec describes a health check that Mesos performs on behalf of the scheduler. Exactly one of
+// Command, HTTPPort, or TCPPort must be specified. Durations are expressed in seconds and are optional;
+// unspecified values default to those of Mesos.
+type HealthCheckSpec struct {
+	Command             string  `json:"command,omitempty"`
+	HTTPPort            uint32  `json:"http_port,omitempty"`
+	Path                string  `json:"path,omitempty"`
+	TCPPort             uint32  `json:"tcp_port,omitempty"`
+	Interval            float64 `json:"interval_seconds,omitempty"`
+	Timeout             float64 `json:"timeout_seconds,omitempty"`
+	GracePeriod         float64 `json:"grace_period_seconds,omitempty"`
+	ConsecutiveFailures uint32  `json:"consecutive_failures,omitempty"`
+}
+
+// Constraint restricts the set of offers that a job's tasks may be launched upon. Field is either
+// "hostname" or else the name of an agent attribute. Supported operators are EQUALS, LIKE, and UNLIKE;
+// the latter two interpret Value as a regular expression that must match the entire field value.
+type Constraint struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+const (
+	ConstraintEquals = "EQUALS"
+	ConstraintLike   = "LIKE"
+	ConstraintUnlike = "UNLIKE"
+
+	constraintFieldHostname = "hostname"
+
+	ContainerizerMesos  = "mesos"
+	ContainerizerDocker = "docker"
+
+	dockerNetworkHost   = "host"
+	dockerNetworkBridge = "bridge"
+	dockerNetworkNone   = "none"
+)
+
+var (
+	errJobMissingName    = errors.New("job spec is missing a name")
+	errJobMissingCommand = errors.New("job spec is missing a command")
+)
+
+func loadJobs(filename string) ([]JobSpec, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var specs []JobSpec
+	if err = json.Unmarshal(b, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse job specs from %q: %+v", filename, err)
+	}
+	names := make(map[string]struct{}, len(specs))
+	for i := range specs {
+		if err = specs[i].validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := names[specs[i].Name]; ok {
+			return nil, fmt.Errorf("duplicate job name %q", specs[i].Name)
+		}
+		names[specs[i].Name] = struct{}{}
+	}
+	return specs, nil
+}
+
+func (spec *JobSpec) validate() error {
+	if spec.Name == "" {
+		return errJobMissingName
+	}
+	if spec.Command == "" {
+		return errJobMissingCommand
+	}
+	if spec.Instances < 0 {
+		return fmt.Errorf("job %q: illegal number of instances: %d", spec.Name, spec.Instances)
+	}
+	if spec.CPUs <= 0 || spec.Memory <= 0 || spec.Disk < 0 {
+		return fmt.Errorf("job %q: cpus and mem must be greater than zero, disk must not be negative", spec.Name)
+	}
+	switch spec.Containerizer {
+	case "", ContainerizerMesos:
+	case ContainerizerDocker:
+		if spec.Image == "" {
+			return fmt.Errorf("job %q: the docker containerizer requires an image", spec.Name)
+		}
+	default:
+		return fmt.Errorf("job %q: unsupported containerizer %q", spec.Name, spec.Containerizer)
+	}
+	if spec.Network != "" && spec.Image == "" {
+		return fmt.Errorf("job %q: a network may only be specified for containers with an image", spec.Name)
+	}
+	for _, c := range spec.Constraints {
+		if _, err := c.filter(); err != nil {
+			return fmt.Errorf("job %q: %+v", spec.Name, err)
+		}
+	}
+	if hc := spec.HealthCheck; hc != nil {
+		n := 0
+		for _, b := range []bool{hc.Command != "", hc.HTTPPort != 0, hc.TCPPort != 0} {
+			if b {
+				n++
+			}
+		}
+		if n != 1 {
+			return fmt.Errorf("job %q: health check requires exactly one of command, http_port, or tcp_port", spec.Name)
+		}
+		if hc.Interval < 0 || hc.Timeout < 0 || hc.GracePeriod < 0 {
+			return fmt.Errorf("job %q: health check durations must not be negative", spec.Name)
+		}
+	}
+	return nil
+}
+
+// filter returns an offers.Filter that implements the constraint.
+func (c Constraint) filter() (offers.Filter, error) {
+	var match func(string) bool
+	switch c.Operator {
+	case ConstraintEquals:
+		match = func(s string) bool { return s == c.Value }
+	case ConstraintLike, ConstraintUnlike:
+		re, err := regexp.Compile("^(?:" + c.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("bad constraint expression %q: %+v", c.Value, err)
+		}
+		match = re.MatchString
+		if c.Operator == ConstraintUnlike {
+			match = func(s string) bool { return !re.MatchString(s) }
+		}
+	default:
+		return nil, fmt.Errorf("unsupported constraint operator %q", c.Operator)
+	}
+	if c.Field == constraintFieldHostname {
+		return offers.FilterFunc(func(o *mesos.Offer) bool { return match(o.Hostname) }), nil
+	}
+	return offers.ByAttributes(func(attrs []mesos.Attribute) bool {
+		for i := range attrs {
+			if attrs[i].Name == c.Field {
+				v, ok := attributeValue(&attrs[i])
+				return ok && match(v)
+			}
+		}
+		// missing attributes only satisfy negative constraints
+		return c.Operator == ConstraintUnlike
+	}), nil
+}
+
+func attributeValue(a *mesos.Attribute) (string, bool) {
+	switch a.Type {
+	case mesos.TEXT:
+		return a.GetText().GetValue(), true
+	case mesos.SCALAR:
+		return strconv.FormatFloat(a.GetScalar().GetValue(), 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// job tracks the launch progress of a set of identical tasks.
+type job struct {
+	name        string
+	instances   int
+	launched    int
+	wants       mesos.Resources
+	filters     []offers.Filter
+	executor    *mesos.ExecutorInfo
+	command     *mesos.CommandInfo
+	container   *mesos.ContainerInfo
+	healthCheck *mesos.HealthCheck
+	labels      *mesos.Labels
+}
+
+// newExecutorJob returns a job that launches tasks via the custom example executor; the labels of the
+// tasks, if any, select the behavior that the executor simulates.
+func newExecutorJob(instances int, wants mesos.Resources, executor *mesos.ExecutorInfo, labels []mesos.Label) *job {
+	j := &job{
+		instances: instances,
+		wants:     wants,
+		executor:  executor,
+	}
+	if len(labels) > 0 {
+		j.labels = &mesos.Labels{Labels: labels}
+	}
+	return j
+}
+
+// newCommandJob returns a job that launches command tasks as described by the spec; the spec is
+// expected to have been validated.
+func newCommandJob(spec JobSpec) *job {
+	j := &job{
+		name:      spec.Name,
+		instances: spec.Instances,
+		command: &mesos.CommandInfo{
+			Value: proto.String(spec.Command),
+			Shell: proto.Bool(true),
+		},
+	}
+	j.wants.Add(
+		resources.NewCPUs(spec.CPUs).Resource,
+		resources.NewMemory(spec.Memory).Resource,
+	)
+	if spec.Disk > 0 {
+		j.wants.Add(resources.NewDisk(spec.Disk).Resource)
+	}
+	if spec.GPUs > 0 {
+		j.wants.Add(resources.NewGPUs(spec.GPUs).Resource)
+	}
+	if len(spec.Env) > 0 {
+		names := make([]string, 0, len(spec.Env))
+		for k := range spec.Env {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		env := &mesos.Environment{}
+		for _, k := range names {
+			env.Variables = append(env.Variables, mesos.Environment_Variable{
+				Name:  k,
+				Value: proto.String(spec.Env[k]),
+			})
+		}
+		j.command.Environment = env
+	}
+	if spec.Image != "" {
+		j.container = newContainerInfo(spec)
+	}
+	if spec.HealthCheck != nil {
+		j.healthCheck = spec.HealthCheck.healthCheck()
+	}
+	for _, c := range spec.Constraints {
+		f, _ := c.filter() // already validated
+		j.filters = append(j.filters, f)
+	}
+	return j
+}
+
+func newContainerInfo(spec JobSpec) *mesos.ContainerInfo {
+	if spec.Containerizer == ContainerizerDocker {
+		ci := &mesos.ContainerInfo{
+			Type:   mesos.ContainerInfo_DOCKER.Enum(),
+			Docker: &mesos.ContainerInfo_DockerInfo{Image: spec.Image},
+		}
+		switch spec.Network {
+		case "", dockerNetworkHost:
+			ci.Docker.Network = mesos.ContainerInfo_DockerInfo_HOST.Enum()
+		case dockerNetworkBridge:
+			ci.Docker.Network = mesos.ContainerInfo_DockerInfo_BRIDGE.Enum()
+		case dockerNetworkNone:
+			ci.Docker.Network = mesos.ContainerInfo_DockerInfo_NONE.Enum()
+		default:
+			ci.Docker.Network = mesos.ContainerInfo_DockerInfo_USER.Enum()
+			ci.NetworkInfos = []mesos.NetworkInfo{{Name: proto.String(spec.Network)}}
+		}
+		return ci
+	}
+	ci := &mesos.ContainerInfo{
+		Type: mesos.ContainerInfo_MESOS.Enum(),
+		Mesos: &mesos.ContainerInfo_MesosInfo{
+			Image: &mesos.Image{
+				Type:   mesos.Image_DOCKER.Enum(),
+				Docker: &mesos.Image_Docker{Name: spec.Image},
+			},
+		},
+	}
+	if spec.Network != "" {
+		ci.NetworkInfos = []mesos.NetworkInfo{{Name: proto.String(spec.Network)}}
+	}
+	return ci
+}
+
+// healthCheck returns the HealthCheck described by the spec; the spec is expected to have been validated.
+func (spec *HealthCheckSpec) healthCheck() *mesos.HealthCheck {
+	hc := &mesos.HealthCheck{}
+	switch {
+	case spec.Command != "":
+		hc.Type = mesos.HealthCheck_COMMAND
+		hc.Command = &mesos.CommandInfo{Value: proto.String(spec.Command), Shell: proto.Bool(true)}
+	case spec.HTTPPort != 0:
+		hc.Type = mesos.HealthCheck_HTTP
+		hc.HTTP = &mesos.HealthCheck_HTTPCheckInfo{Port: spec.HTTPPort}
+		if spec.Path != "" {
+			hc.HTTP.Path = proto.String(spec.Path)
+		}
+	default:
+		hc.Type = mesos.HealthCheck_TCP
+		hc.TCP = &mesos.HealthCheck_TCPCheckInfo{Port: spec.TCPPort}
+	}
+	if spec.Interval > 0 {
+		hc.IntervalSeconds = proto.Float64(spec.Interval)
+	}
+	if spec.Timeout > 0 {
+		hc.TimeoutSeconds = proto.Float64(spec.Timeout)
+	}
+	if spec.GracePeriod > 0 {
+		hc.GracePeriodSeconds = proto.Float64(spec.GracePeriod)
+	}
+	if spec.ConsecutiveFailures > 0 {
+		hc.ConsecutiveFailures = proto.Uint32(spec.ConsecutiveFailures)
+	}
+	return hc
+}
+
+func (j *job) done() bool { return j.launched >= j.instances }
+
+// accepts returns true if the offer satisfies all of the job's constraints.
+func (j *job) accepts(o *mesos.Offer) bool {
+	for _, f := range j.filters {
+		if !f.Accept(o) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateGPUs returns an error if Mesos would reject the job's tasks because of the GPUs that they
+// request, given that they're launched by a framework with the given info.
+func (j *job) validateGPUs(info *mesos.FrameworkInfo) error {
+	return calls.ValidateGPUTasks(info, mesos.TaskInfo{
+		TaskID:    mesos.TaskID{Value: j.name},
+		Executor:  j.executor,
+		Resources: j.wants,
+	})
+}
+
+// newTask generates a task for the job; the numeric task ID is unique across all jobs.
+func (j *job) newTask(taskID int, agentID mesos.AgentID, rs mesos.Resources) mesos.TaskInfo {
+	id := strconv.Itoa(taskID)
+	if j.name != "" {
+		id = j.name + "-" + id
+	}
+	j.launched++
+	return mesos.TaskInfo{
+		TaskID:      mesos.TaskID{Value: id},
+		Name:        "Task " + id,
+		AgentID:     agentID,
+		Executor:    j.executor,
+		Command:     j.command,
+		Container:   j.container,
+		HealthCheck: j.healthCheck,
+		Labels:      j.labels,
+		Resources:   rs,
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/store"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// persistentState is the scheduler state that's saved to the state store, so that a restarted scheduler
+// may fail over to its previous framework ID and resume from where it left off. Jobs submitted via the
+// control API are not persisted.
+type persistentState struct {
+	TasksLaunched int              `json:"tasks_launched"`
+	TasksFinished int              `json:"tasks_finished"`
+	Launched      map[string]int   `json:"launched"` // per job name
+	Tasks         []persistentTask `json:"tasks,omitempty"`
+}
+
+type persistentTask struct {
+	ID      string `json:"id"`
+	AgentID string `json:"agent_id,omitempty"`
+	State   string `json:"state"`
+}
+
+// newStores returns the stores for the framework ID and scheduler state. When no state directory is
+// configured the framework ID is kept in memory and scheduler state isn't persisted at all.
+func newStores(cfg Config) (fidStore, stateStore store.Singleton, err error) {
+	if cfg.stateDir == "" {
+		return store.NewInMemorySingleton(), nil, nil
+	}
+	if err = os.MkdirAll(cfg.stateDir, 0700); err != nil {
+		return
+	}
+	fidStore = store.NewFileSingleton(filepath.Join(cfg.stateDir, "framework-id"))
+	stateStore = store.NewFileSingleton(filepath.Join(cfg.stateDir, "state.json"))
+	return
+}
+
+// removeStores removes the persisted framework ID and scheduler state, if any.
+func removeStores(cfg Config) {
+	if cfg.stateDir == "" {
+		return
+	}
+	for _, name := range []string{"framework-id", "state.json"} {
+		if err := os.Remove(filepath.Join(cfg.stateDir, name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove persisted state: %+v", err)
+		}
+	}
+}
+
+// restoreState loads previously persisted scheduler state, if any.
+func (state *internalState) restoreState() error {
+	if state.store == nil {
+		return nil
+	}
+	v, err := state.store.Get()
+	if err == store.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var ps persistentState
+	if err = json.Unmarshal([]byte(v), &ps); err != nil {
+		return err
+	}
+	state.tasksLaunched = ps.TasksLaunched
+	state.tasksFinished = ps.TasksFinished
+	for _, j := range state.jobs {
+		j.launched = ps.Launched[j.name]
+	}
+	for _, t := range ps.Tasks {
+		st := mesos.TaskState(mesos.TaskState_value[t.State])
+		s := mesos.TaskStatus{TaskID: mesos.TaskID{Value: t.ID}, State: &st}
+		if t.AgentID != "" {
+			s.AgentID = &mesos.AgentID{Value: t.AgentID}
+		}
+		state.registry.Update(s)
+	}
+	log.Printf("restored scheduler state: %d tasks launched, %d finished, %d active",
+		ps.TasksLaunched, ps.TasksFinished, len(ps.Tasks))
+	return nil
+}
+
+// saveState persists the scheduler state; errors are logged but otherwise ignored since a failure to save
+// state only matters should the scheduler restart. The caller must hold the state lock.
+func (state *internalState) saveState() {
+	if state.store == nil {
+		return
+	}
+	ps := persistentState{
+		TasksLaunched: state.tasksLaunched,
+		TasksFinished: state.tasksFinished,
+		Launched:      make(map[string]int, len(state.jobs)),
+	}
+	for _, j := range state.jobs {
+		ps.Launched[j.name] = j.launched
+	}
+	for _, s := range state.registry.Select(nil) {
+		ps.Tasks = append(ps.Tasks, persistentTask{
+			ID:      s.TaskID.Value,
+			AgentID: s.GetAgentID().GetValue(),
+			State:   s.GetState().String(),
+		})
+	}
+	b, err := json.Marshal(&ps)
+	if err == nil {
+		err = state.store.Set(string(b))
+	}
+	if err != nil {
+		log.Printf("failed to save scheduler state: %+v", err)
+	}
+}
+
+// reconcile requests explicit reconciliation of every task that the scheduler knows about, followed by
+// implicit reconciliation so that Mesos also reports upon any tasks that the scheduler has forgotten.
+// Reconciliation is recommended upon each (re-)subscription since status updates may have been missed
+// while the scheduler was disconnected.
+func reconcile(ctx context.Context, state *internalState) {
+	if state.registry.Len() > 0 {
+		if err := calls.CallNoData(ctx, state.cli, calls.Reconcile(state.registry.ReconcileTasks())); err != nil {
+			log.Printf("failed to reconcile tasks: %+v", err)
+			return
+		}
+	}
+	if err := calls.CallNoData(ctx, state.cli, calls.Reconcile()); err != nil {
+		log.Printf("failed to reconcile tasks: %+v", err)
+	}
+}
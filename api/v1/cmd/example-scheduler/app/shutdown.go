@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/extras/store"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// Exit codes of the example scheduler.
+const (
+	ExitOK         = 0 // all tasks finished, or the scheduler shut down gracefully upon a signal
+	ExitError      = 1 // the scheduler failed
+	ExitTaskFailed = 3 // a task reached an unexpected state
+)
+
+// ExitCode returns the exit code of the process for the given error, as returned by Run.
+func ExitCode(err error) int {
+	switch err.(type) {
+	case nil:
+		return ExitOK
+	case TaskError:
+		return ExitTaskFailed
+	default:
+		return ExitError
+	}
+}
+
+// handleSignals shuts down the scheduler upon SIGINT or SIGTERM: the framework is torn down, if so
+// configured, and then the scheduler's context is canceled so that in-flight work may drain. The process
+// exits forcibly if it hasn't shut down within the configured timeout, or upon a second signal.
+func handleSignals(ctx context.Context, state *internalState, fidStore store.Singleton) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(sigCh)
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigCh:
+			log.Printf("received %v, shutting down", sig)
+		}
+		time.AfterFunc(state.config.shutdownTimeout, func() {
+			log.Printf("failed to shut down within %v, exiting", state.config.shutdownTimeout)
+			os.Exit(ExitError)
+		})
+		go func() {
+			sig := <-sigCh
+			log.Printf("received %v again, exiting", sig)
+			os.Exit(ExitError)
+		}()
+		if state.config.teardown {
+			teardown(state, fidStore)
+		}
+		state.shutdown()
+	}()
+}
+
+// teardown removes the framework from Mesos, killing all of its tasks, and then removes any persisted
+// scheduler state because a restarted scheduler can't fail over to a framework that's been torn down.
+func teardown(state *internalState, fidStore store.Singleton) {
+	if store.GetIgnoreErrors(fidStore)() == "" {
+		log.Println("not subscribed, skipping teardown")
+		return
+	}
+	log.Println("tearing down framework")
+	ctx, cancel := context.WithTimeout(context.Background(), state.config.shutdownTimeout)
+	defer cancel()
+	if err := calls.CallNoData(ctx, state.cli, calls.Teardown()); err != nil {
+		state.m.Lock()
+		state.err = err
+		state.m.Unlock()
+		log.Printf("failed to tear down framework: %+v", err)
+		return
+	}
+	state.m.Lock()
+	state.store = nil // don't persist state once it's been removed
+	state.m.Unlock()
+	removeStores(state.config)
+}
@@ -2,17 +2,23 @@ package app
 
 import (
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	proto "github.com/gogo/protobuf/proto"
 	"github.com/mesos/mesos-go/api/v1/lib"
 	"github.com/mesos/mesos-go/api/v1/lib/backoff"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/artifacts"
+	xtasks "github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/windows"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/store"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli/httpsched"
 	"github.com/mesos/mesos-go/api/v1/lib/resources"
@@ -20,7 +26,7 @@ import (
 )
 
 func prepareExecutorInfo(
-	execBinary, execImage string,
+	execBinary, execImage, execOS string,
 	server server,
 	wantsResources mesos.Resources,
 	jobRestartDelay time.Duration,
@@ -49,39 +55,41 @@ func prepareExecutorInfo(
 		}, nil
 	} else if execBinary != "" {
 		log.Println("No executor image specified, will serve executor binary from built-in HTTP server")
-		listener, iport, err := newListener(server)
-		if err != nil {
+		artifactServer := artifacts.New(
+			artifacts.Address(net.JoinHostPort(server.address, strconv.Itoa(server.port))),
+			artifacts.Decorate(func(h http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					metricsAPI.artifactDownloads()
+					h.ServeHTTP(w, r)
+				})
+			}),
+			artifacts.TLS(server.certFile, server.keyFile),
+		)
+		if err := artifactServer.Listen(); err != nil {
 			return nil, err
 		}
-		server.port = iport // we're just working with a copy of server, so this is OK
-		var (
-			mux                    = http.NewServeMux()
-			executorUris           = []mesos.CommandInfo_URI{}
-			uri, executorCmd, err2 = serveExecutorArtifact(server, execBinary, mux)
-			executorCommand        = fmt.Sprintf("./%s", executorCmd)
-		)
-		if err2 != nil {
-			return nil, err2
+		artifact, err := artifactServer.Add(execBinary)
+		if err != nil {
+			return nil, err
 		}
-		wrapper := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			metricsAPI.artifactDownloads()
-			mux.ServeHTTP(w, r)
-		})
-		executorUris = append(executorUris, mesos.CommandInfo_URI{Value: uri, Executable: proto.Bool(true)})
+		log.Println("Hosting artifact '" + execBinary + "' at '" + artifact.URL + "'")
 
-		go forever("artifact-server", jobRestartDelay, metricsAPI.jobStartCount, func() error { return http.Serve(listener, wrapper) })
+		go forever("artifact-server", jobRestartDelay, metricsAPI.jobStartCount, artifactServer.Serve)
 		log.Println("Serving executor artifacts...")
 
 		// Create mesos custom executor
+		command := &mesos.CommandInfo{Value: proto.String(artifact.Command(execOS))}
+		if execOS == windows.OS {
+			// shell commands are interpreted by cmd.exe; execute the binary directly instead
+			command = windows.Command(artifact.Command(execOS))
+		}
+		command.URIs = []mesos.CommandInfo_URI{artifact.URI(artifacts.Executable())}
 		return &mesos.ExecutorInfo{
 			Type:       mesos.ExecutorInfo_CUSTOM,
 			ExecutorID: mesos.ExecutorID{Value: "default"},
 			Name:       proto.String("Test Executor"),
-			Command: &mesos.CommandInfo{
-				Value: proto.String(executorCommand),
-				URIs:  executorUris,
-			},
-			Resources: wantsResources,
+			Command:    command,
+			Resources:  wantsResources,
 		}, nil
 	}
 	return nil, errors.New("must specify an executor binary or image")
@@ -142,6 +150,7 @@ func buildFrameworkInfo(cfg Config) *mesos.FrameworkInfo {
 		Checkpoint: &cfg.checkpoint,
 		Capabilities: []mesos.FrameworkInfo_Capability{
 			{Type: mesos.FrameworkInfo_Capability_RESERVATION_REFINEMENT},
+			{Type: mesos.FrameworkInfo_Capability_TASK_KILLING_STATE},
 		},
 	}
 	if cfg.failoverTimeout > 0 {
@@ -194,9 +203,79 @@ func loadCredentials(userConfig credentials) (result credentials, err error) {
 
 func newInternalState(cfg Config, shutdown func()) (*internalState, error) {
 	metricsAPI := initMetrics(cfg)
+	jobs, err := buildJobs(cfg, metricsAPI)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := loadCredentials(cfg.credentials)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		frameworkInfo = buildFrameworkInfo(cfg)
+		totalTasks    = 0
+	)
+	for _, j := range jobs {
+		if err := j.validateGPUs(frameworkInfo); err != nil {
+			return nil, err
+		}
+		totalTasks += j.instances
+	}
+	state := &internalState{
+		config:        cfg,
+		totalTasks:    totalTasks,
+		reviveTokens:  backoff.BurstNotifier(cfg.reviveBurst, cfg.reviveWait, cfg.reviveWait, nil),
+		jobs:          jobs,
+		registry:      xtasks.NewRegistry(frameworkInfo),
+		killRequested: make(map[mesos.TaskID]struct{}),
+		metricsAPI:    metricsAPI,
+		cli:           buildHTTPSched(cfg, creds),
+		random:        rand.New(rand.NewSource(time.Now().Unix())),
+		shutdown:      shutdown,
+	}
+	return state, nil
+}
+
+// buildJobs returns the jobs loaded from the configured job spec file, if any; otherwise a single job
+// that launches either the configured command tasks, or else tasks via the example executor.
+func buildJobs(cfg Config, metricsAPI *metricsAPI) ([]*job, error) {
+	if cfg.jobs != "" {
+		specs, err := loadJobs(cfg.jobs)
+		if err != nil {
+			return nil, err
+		}
+		jobs := make([]*job, 0, len(specs))
+		for _, spec := range specs {
+			log.Printf("loaded job spec: %+v", spec)
+			jobs = append(jobs, newCommandJob(spec))
+		}
+		return jobs, nil
+	}
+	if cfg.commandTask.command != "" {
+		spec := JobSpec{
+			Name:          "task",
+			Command:       cfg.commandTask.command,
+			Image:         cfg.commandTask.image,
+			Containerizer: cfg.commandTask.containerizer,
+			Network:       cfg.commandTask.network,
+			Instances:     cfg.tasks,
+			CPUs:          cfg.taskCPU,
+			Memory:        cfg.taskMemory,
+			GPUs:          cfg.commandTask.gpus,
+		}
+		if cfg.commandTask.healthCheck != "" {
+			spec.HealthCheck = &HealthCheckSpec{Command: cfg.commandTask.healthCheck}
+		}
+		if err := spec.validate(); err != nil {
+			return nil, err
+		}
+		log.Printf("command task spec: %+v", spec)
+		return []*job{newCommandJob(spec)}, nil
+	}
 	executorInfo, err := prepareExecutorInfo(
 		cfg.executor,
 		cfg.execImage,
+		cfg.execOS,
 		cfg.server,
 		buildWantsExecutorResources(cfg),
 		cfg.jobRestartDelay,
@@ -205,36 +284,25 @@ func newInternalState(cfg Config, shutdown func()) (*internalState, error) {
 	if err != nil {
 		return nil, err
 	}
-	creds, err := loadCredentials(cfg.credentials)
-	if err != nil {
-		return nil, err
-	}
-	state := &internalState{
-		config:             cfg,
-		totalTasks:         cfg.tasks,
-		reviveTokens:       backoff.BurstNotifier(cfg.reviveBurst, cfg.reviveWait, cfg.reviveWait, nil),
-		wantsTaskResources: buildWantsTaskResources(cfg),
-		executor:           executorInfo,
-		metricsAPI:         metricsAPI,
-		cli:                buildHTTPSched(cfg, creds),
-		random:             rand.New(rand.NewSource(time.Now().Unix())),
-		shutdown:           shutdown,
-	}
-	return state, nil
+	return []*job{newExecutorJob(cfg.tasks, buildWantsTaskResources(cfg), executorInfo, cfg.taskLabels)}, nil
 }
 
 type internalState struct {
-	tasksLaunched      int
-	tasksFinished      int
-	totalTasks         int
-	role               string
-	executor           *mesos.ExecutorInfo
-	cli                calls.Caller
-	config             Config
-	wantsTaskResources mesos.Resources
-	reviveTokens       <-chan struct{}
-	metricsAPI         *metricsAPI
-	err                error
-	shutdown           func()
-	random             *rand.Rand
+	// m guards the mutable fields below, which are accessed by both event handlers and the control API
+	m             sync.Mutex
+	tasksLaunched int
+	tasksFinished int
+	totalTasks    int
+	role          string
+	jobs          []*job
+	registry      *xtasks.Registry
+	killRequested map[mesos.TaskID]struct{} // tasks killed via the control API
+	store         store.Singleton           // persists scheduler state; nil if state isn't persisted
+	cli           calls.Caller
+	config        Config
+	reviveTokens  <-chan struct{}
+	metricsAPI    *metricsAPI
+	err           error
+	shutdown      func()
+	random        *rand.Rand
 }
@@ -15,6 +15,7 @@ func main() {
 	fs.Parse(os.Args[1:])
 
 	if err := app.Run(cfg); err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		os.Exit(app.ExitCode(err))
 	}
 }
@@ -2,20 +2,16 @@
 package app
 
 import (
-	"bytes"
 	"context"
 	"flag"
 	"fmt"
-	"io"
 	"log"
-	"net"
 	"os"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/mesos/mesos-go/api/v1/lib"
-	"github.com/mesos/mesos-go/api/v1/lib/agent"
-	agentcalls "github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/agent/containers"
 	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
 	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/controller"
 	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
@@ -92,7 +88,7 @@ type App struct {
 	taskPrototype          mesos.TaskInfo
 	executorPrototype      mesos.ExecutorInfo
 	wantsExecutorResources mesos.Resources
-	agentDirectory         map[mesos.AgentID]string
+	agentDirectory         map[mesos.AgentID]string // operator API endpoints of agents
 	uponExit               *cleanups
 }
 
@@ -210,8 +206,8 @@ func (app *App) Run(ctx context.Context) error {
 		controller.WithFrameworkID(store.GetIgnoreErrors(app.fidStore)),
 		controller.WithSubscriptionTerminated(func(err error) {
 			cancel()
-			if err == io.EOF {
-				app.Log("disconnected")
+			if controller.IsDisconnected(err) {
+				app.Log(err.Error())
 			}
 		}),
 	)
@@ -271,7 +267,9 @@ func (app *App) trackAgents(ctx context.Context, e *scheduler.Event, err error,
 	off := e.GetOffers().GetOffers()
 	for i := range off {
 		// TODO(jdef) eventually implement an algorithm to purge agents that are gone
-		app.agentDirectory[off[i].GetAgentID()] = off[i].GetHostname()
+		if endpoint, err := httpagent.OfferEndpoint(&off[i]); err == nil {
+			app.agentDirectory[off[i].GetAgentID()] = endpoint
+		}
 	}
 	return chain(ctx, e, err)
 }
@@ -441,7 +439,7 @@ func IsErrSuccess(err error) bool {
 	return ok && exitErr == 0
 }
 
-func (app *App) tryInteractive(ctx context.Context, agentHost string, cid mesos.ContainerID) (err error) {
+func (app *App) tryInteractive(ctx context.Context, agentEndpoint string, cid mesos.ContainerID) (err error) {
 	// TODO(jdef) only re-attach if we're disconnected (guard against redundant TASK_RUNNING)
 	ctx, cancel := context.WithCancel(ctx)
 	var winCh <-chan mesos.TTYInfo_WindowSize
@@ -463,138 +461,28 @@ func (app *App) tryInteractive(ctx context.Context, agentHost string, cid mesos.
 		winCh = ttyd.winch
 	}
 
-	var (
-		cli = httpagent.NewSender(
-			httpcli.New(
-				httpcli.Endpoint(fmt.Sprintf("http://%s/api/v1", net.JoinHostPort(agentHost, "5051"))),
-			).Send,
-		)
-		aciCh = make(chan *agent.Call, 1) // must be buffered to avoid blocking below
+	cli := httpagent.NewSender(
+		httpcli.New(
+			httpcli.Endpoint(agentEndpoint),
+		).Send,
 	)
-	aciCh <- agentcalls.AttachContainerInput(cid) // very first input message MUST be this
-	go func() {
-		defer cancel()
-		acif := agentcalls.FromChan(aciCh)
-
-		// blocking call, hence the goroutine; Send only returns when the input stream is severed
-		err2 := agentcalls.SendNoData(ctx, cli, acif)
-		if err2 != nil && err2 != io.EOF {
-			app.Log("attached input stream error %v", err2)
-		}
-	}()
-
-	// attach to container stdout, stderr; Send returns immediately with a Response from which output
-	// may be decoded.
-	output, err := cli.Send(ctx, agentcalls.NonStreaming(agentcalls.AttachContainerOutput(cid)))
+	// the session heartbeats its input (see containers.KeepAlive), so that idle sessions aren't reaped
+	s, err := containers.Attach(ctx, cli, cid)
 	if err != nil {
-		app.Log("attach output stream error: %v", err)
-		if output != nil {
-			output.Close()
-		}
+		app.Log("attach session error: %v", err)
 		cancel()
 		return
 	}
-
 	go func() {
 		defer cancel()
-		attachContainerOutput(output, os.Stdout, os.Stderr)
+		s.Output(os.Stdout, os.Stderr)
 	}()
-
-	go attachContainerInput(ctx, os.Stdin, winCh, aciCh)
-
-	return nil
-}
-
-func attachContainerInput(ctx context.Context, stdin io.Reader, winCh <-chan mesos.TTYInfo_WindowSize, aciCh chan<- *agent.Call) {
-	defer close(aciCh)
-
-	input := make(chan []byte)
 	go func() {
-		defer close(input)
-		escape := []byte{0x10, 0x11} // CTRL-P, CTRL-Q
-		var last byte
-		for {
-			buf := make([]byte, 512) // not efficient to always do this
-			n, err := stdin.Read(buf)
-			if n > 0 {
-				if (last == escape[0] && buf[0] == escape[1]) || bytes.Index(buf, escape) > -1 {
-					//println("escape sequence detected")
-					return
-				}
-				buf = buf[:n]
-				last = buf[n-1]
-				select {
-				case input <- buf:
-				case <-ctx.Done():
-					return
-				}
-			}
-			// TODO(jdef) check for temporary error?
-			if err != nil {
-				return
-			}
+		defer cancel()
+		err := s.Input(ctx, os.Stdin, winCh, containers.DefaultDetachSequence)
+		if err != nil && err != containers.ErrDetached && err != context.Canceled {
+			app.Log("attached input stream error %v", err)
 		}
 	}()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		// TODO(jdef) send a heartbeat message every so often
-		// attach_container_input process_io heartbeats may act as keepalive's, `interval` field is ignored:
-		// https://github.com/apache/mesos/blob/4e200e55d8ed282b892f650983ebdf516680d90d/src/slave/containerizer/mesos/io/switchboard.cpp#L1608
-		case data, ok := <-input:
-			if !ok {
-				return
-			}
-			c := agentcalls.AttachContainerInputData(data)
-			select {
-			case aciCh <- c:
-			case <-ctx.Done():
-				return
-			}
-		case ws := <-winCh:
-			c := agentcalls.AttachContainerInputTTY(&mesos.TTYInfo{WindowSize: &ws})
-			select {
-			case aciCh <- c:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}
-}
-
-func attachContainerOutput(resp mesos.Response, stdout, stderr io.Writer) error {
-	defer resp.Close()
-	forward := func(b []byte, out io.Writer) error {
-		n, err := out.Write(b)
-		if err == nil && len(b) != n {
-			err = io.ErrShortWrite
-		}
-		return err
-	}
-	for {
-		var pio agent.ProcessIO
-		err := resp.Decode(&pio)
-		if err != nil {
-			return err
-		}
-		switch pio.GetType() {
-		case agent.ProcessIO_DATA:
-			data := pio.GetData()
-			switch data.GetType() {
-			case agent.ProcessIO_Data_STDOUT:
-				if err := forward(data.GetData(), stdout); err != nil {
-					return err
-				}
-			case agent.ProcessIO_Data_STDERR:
-				if err := forward(data.GetData(), stderr); err != nil {
-					return err
-				}
-			default:
-				// ignore
-			}
-		default:
-			// ignore
-		}
-	}
+	return nil
 }
@@ -11,6 +11,7 @@ import (
 	"text/template"
 
 	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli/httpmaster"
 	"github.com/mesos/mesos-go/api/v1/lib/master"
@@ -71,12 +72,15 @@ func watch(taskTemp, frameworkTemp, agentTemp *template.Template) func(mesos.Res
 		}()
 		for err == nil {
 			var e master.Event
-			if err := resp.Decode(&e); err != nil {
+			if err = resp.Decode(&e); err != nil {
+				if _, ok := encoding.IsUnknownEvent(err); ok {
+					err = nil // e.g. an event of a type that was added by a newer version of Mesos
+					continue
+				}
 				if err == io.EOF {
 					err = nil
-					break
 				}
-				continue
+				break
 			}
 			switch t := e.GetType(); t {
 			case master.Event_TASK_ADDED:
@@ -0,0 +1,38 @@
+package calls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+)
+
+// SendGetOperations is a convenience func that executes a GET_OPERATIONS call using the provided Sender,
+// and returns the decoded response.
+func SendGetOperations(ctx context.Context, sender Sender) (*agent.Response_GetOperations, error) {
+	r, err := decodeResponse(ctx, sender, GetOperations())
+	if err != nil {
+		return nil, err
+	}
+	if r.GetGetOperations() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", agent.Call_GET_OPERATIONS, r.GetType())
+	}
+	return r.GetGetOperations(), nil
+}
+
+// decodeResponse executes the given (non-streaming) call, and decodes its response; which is closed
+// before returning.
+func decodeResponse(ctx context.Context, sender Sender, c *agent.Call) (*agent.Response, error) {
+	resp, err := sender.Send(ctx, NonStreaming(c))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r agent.Response
+	if err = resp.Decode(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,29 @@
+package calls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+func TestSendGetOperations(t *testing.T) {
+	sender := SenderFunc(func(_ context.Context, r Request) (mesos.Response, error) {
+		if r.Call().GetType() != agent.Call_GET_OPERATIONS {
+			t.Fatalf("unexpected call %v", r.Call())
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*u.(*agent.Response) = agent.Response{
+				Type:          agent.Response_GET_OPERATIONS,
+				GetOperations: &agent.Response_GetOperations{Operations: make([]mesos.Operation, 1)},
+			}
+			return nil
+		})}, nil
+	})
+	ops, err := SendGetOperations(context.Background(), sender)
+	if err != nil || len(ops.Operations) != 1 {
+		t.Fatalf("unexpected response (%v, %v)", ops, err)
+	}
+}
@@ -0,0 +1,13 @@
+package agent
+
+// The following implement encoding.Typed, so that decoders recognize messages of types that are unknown
+// to this version of the library.
+
+func (m *Call) TypeNames() map[int32]string { return Call_Type_name }
+func (m *Call) TypeValue() int32            { return int32(m.GetType()) }
+
+func (m *Response) TypeNames() map[int32]string { return Response_Type_name }
+func (m *Response) TypeValue() int32            { return int32(m.GetType()) }
+
+func (m *ProcessIO) TypeNames() map[int32]string { return ProcessIO_Type_name }
+func (m *ProcessIO) TypeValue() int32            { return int32(m.GetType()) }
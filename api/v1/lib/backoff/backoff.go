@@ -4,20 +4,31 @@ import (
 	"fmt"
 	"reflect"
 	"time"
+
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
 )
 
+// BurstNotifier returns a chan that yields tokens from burst Notifiers; a nil chan is returned (no limit)
+// if burst is less than one. The underlying goroutines only exit once until is closed: see Limiter for a
+// context-aware alternative that may also be reconfigured at runtime.
 func BurstNotifier(burst int, minWait, maxWait time.Duration, until <-chan struct{}) <-chan struct{} {
+	return BurstNotifierWith(mesostime.SystemClock, burst, minWait, maxWait, until)
+}
+
+// BurstNotifierWith is like BurstNotifier, except that wait periods are timed by the given clock; e.g. a
+// mesostime.FakeClock, in tests.
+func BurstNotifierWith(clock mesostime.Clock, burst int, minWait, maxWait time.Duration, until <-chan struct{}) <-chan struct{} {
 	if burst < 1 {
 		return nil // no limit
 	}
 	if burst == 1 {
-		return Notifier(minWait, maxWait, until)
+		return NotifierWith(clock, minWait, maxWait, until)
 	}
 
 	// build a synamic select/case statement based on burst size
 	cases := make([]reflect.SelectCase, burst+1)
 	for i := 0; i < burst; i++ {
-		ch := Notifier(minWait, maxWait, until)
+		ch := NotifierWith(clock, minWait, maxWait, until)
 		cases[i].Dir = reflect.SelectRecv
 		cases[i].Chan = reflect.ValueOf(ch)
 	}
@@ -51,6 +62,12 @@ func BurstNotifier(burst int, minWait, maxWait time.Duration, until <-chan struc
 //
 // Note: this func panics if minWait is a non-positive value to avoid busy-looping.
 func Notifier(minWait, maxWait time.Duration, until <-chan struct{}) <-chan struct{} {
+	return NotifierWith(mesostime.SystemClock, minWait, maxWait, until)
+}
+
+// NotifierWith is like Notifier, except that wait periods are timed by the given clock; e.g. a
+// mesostime.FakeClock, in tests.
+func NotifierWith(clock mesostime.Clock, minWait, maxWait time.Duration, until <-chan struct{}) <-chan struct{} {
 	// TODO(jdef) add jitter to this func
 	if maxWait < minWait {
 		maxWait, minWait = minWait, maxWait
@@ -62,7 +79,7 @@ func Notifier(minWait, maxWait time.Duration, until <-chan struct{}) <-chan stru
 	limiter := tokens
 	go func() {
 		d := 0 * time.Second
-		t := time.NewTimer(d)
+		t := clock.NewTimer(d)
 		defer t.Stop()
 		for {
 			select {
@@ -74,9 +91,9 @@ func Notifier(minWait, maxWait time.Duration, until <-chan struct{}) <-chan stru
 				limiter = nil
 				// drain the timer to avoid Reset problems
 				if !t.Stop() {
-					<-t.C
+					<-t.C()
 				}
-			case <-t.C:
+			case <-t.C():
 				if limiter != nil {
 					d /= 2
 				} else {
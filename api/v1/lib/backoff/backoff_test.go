@@ -0,0 +1,39 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestNotifierWith(t *testing.T) {
+	var (
+		clock  = mesostime.NewFakeClock(time.Unix(0, 0))
+		until  = make(chan struct{})
+		tokens = NotifierWith(clock, time.Second, 4*time.Second, until)
+		token  = func() bool {
+			select {
+			case <-tokens:
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}
+	)
+	defer close(until)
+	if !token() {
+		t.Fatal("expected an initial token")
+	}
+	// tokens only follow the wait period, as timed by the clock
+	for i := 0; i < 2; i++ {
+		if token() {
+			t.Fatal("unexpected token before the wait period has elapsed")
+		}
+		clock.WaitForTimers(1)
+		clock.Advance(4 * time.Second)
+		if !token() {
+			t.Fatalf("expected token %d after the wait period", i+1)
+		}
+	}
+}
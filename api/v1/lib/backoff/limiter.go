@@ -0,0 +1,248 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// ErrStopped is returned by the funcs of a Limiter whose context is done.
+var ErrStopped = errors.New("limiter stopped")
+
+type (
+	// LimiterOption is a functional option for a Limiter; it returns an "undo" option when applied.
+	LimiterOption func(*Limiter) LimiterOption
+
+	// Limiter is a context-aware alternative to BurstNotifier: it yields tokens from a bucket of burst
+	// slots, each of which behaves like a Notifier; i.e. the wait period of a slot doubles (up to maxWait)
+	// whenever its token is consumed, and halves (down to minWait) whenever its token isn't consumed for a
+	// wait period. All slots are managed by a single goroutine, which exits once the Limiter's context is
+	// done. The burst and wait periods may be reconfigured at runtime.
+	Limiter struct {
+		tokens    chan struct{}
+		reconf    chan limiterConfig
+		done      chan struct{}
+		available int32 // atomic: the number of tokens that may be consumed without waiting
+		burst     int32 // atomic
+		clock     mesostime.Clock
+	}
+
+	limiterConfig struct {
+		burst            int
+		minWait, maxWait time.Duration
+	}
+
+	slot struct {
+		d         time.Duration // wait period
+		next      time.Time     // when the slot next changes state
+		available bool
+	}
+)
+
+func (c *limiterConfig) validate() error {
+	if c.maxWait < c.minWait {
+		c.maxWait, c.minWait = c.minWait, c.maxWait
+	}
+	if c.burst < 0 {
+		return fmt.Errorf("illegal value for burst: %d", c.burst)
+	}
+	if c.burst > 0 && c.minWait <= 0 {
+		return fmt.Errorf("illegal value for minWait: %v", c.minWait)
+	}
+	return nil
+}
+
+// LimiterClock configures the clock that times the wait periods of a Limiter; defaults to
+// mesostime.SystemClock. Tests may use a mesostime.FakeClock to avoid sleeping.
+func LimiterClock(c mesostime.Clock) LimiterOption {
+	return func(lim *Limiter) LimiterOption {
+		old := lim.clock
+		lim.clock = c
+		return LimiterClock(old)
+	}
+}
+
+// NewLimiter returns a Limiter that yields tokens until the context is done. Returns an error if burst is
+// less than one, or if minWait isn't positive (which would otherwise busy-loop).
+func NewLimiter(ctx context.Context, burst int, minWait, maxWait time.Duration, opts ...LimiterOption) (*Limiter, error) {
+	cfg := limiterConfig{burst: burst, minWait: minWait, maxWait: maxWait}
+	if burst < 1 {
+		return nil, fmt.Errorf("illegal value for burst: %d", burst)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	lim := &Limiter{
+		tokens: make(chan struct{}),
+		reconf: make(chan limiterConfig),
+		done:   make(chan struct{}),
+		clock:  mesostime.SystemClock,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(lim)
+		}
+	}
+	go lim.run(ctx, cfg)
+	return lim, nil
+}
+
+// C returns a chan that yields a token whenever one is available; it's closed once the context of the
+// Limiter is done.
+func (lim *Limiter) C() <-chan struct{} { return lim.tokens }
+
+// Done returns a chan that's closed once the goroutine of the Limiter has exited.
+func (lim *Limiter) Done() <-chan struct{} { return lim.done }
+
+// Wait blocks until a token is consumed, or else the given context is done (in which case the error of
+// the context is returned), or else the Limiter is stopped (in which case ErrStopped is returned).
+func (lim *Limiter) Wait(ctx context.Context) error {
+	select {
+	case _, ok := <-lim.tokens:
+		if !ok {
+			return ErrStopped
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of tokens that may currently be consumed without waiting; e.g. for metrics.
+func (lim *Limiter) Tokens() int { return int(atomic.LoadInt32(&lim.available)) }
+
+// Burst returns the number of slots of the Limiter.
+func (lim *Limiter) Burst() int { return int(atomic.LoadInt32(&lim.burst)) }
+
+// Reconfigure changes the burst and wait periods of the Limiter. Slots that are added by an increased
+// burst start out with an available token; the wait periods of existing slots are clamped to the new
+// bounds. A burst of zero disables rate limiting: tokens are then yielded without waiting (and the wait
+// periods are ignored) until the Limiter is reconfigured with a positive burst. Returns ErrStopped if the
+// Limiter is stopped, or an error if the configuration is illegal (as per NewLimiter).
+func (lim *Limiter) Reconfigure(burst int, minWait, maxWait time.Duration) error {
+	cfg := limiterConfig{burst: burst, minWait: minWait, maxWait: maxWait}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	select {
+	case lim.reconf <- cfg:
+		return nil
+	case <-lim.done:
+		return ErrStopped
+	}
+}
+
+func (lim *Limiter) run(ctx context.Context, cfg limiterConfig) {
+	defer close(lim.done)
+	defer close(lim.tokens)
+
+	var (
+		clock = lim.clock
+		slots = resize(nil, cfg, clock.Now())
+		t     = clock.NewTimer(0)
+	)
+	defer t.Stop()
+	if !t.Stop() {
+		<-t.C()
+	}
+	for {
+		var (
+			now       = clock.Now()
+			next      time.Time
+			available int
+		)
+		for i := range slots {
+			s := &slots[i]
+			if !now.Before(s.next) {
+				s.tick(now, cfg)
+			}
+			if s.available {
+				available++
+			}
+			if next.IsZero() || s.next.Before(next) {
+				next = s.next
+			}
+		}
+		atomic.StoreInt32(&lim.available, int32(available))
+		atomic.StoreInt32(&lim.burst, int32(len(slots)))
+
+		var tokens chan struct{}
+		if available > 0 || cfg.burst == 0 {
+			tokens = lim.tokens
+		}
+		armed := len(slots) > 0 // there's nothing to wait for if rate limiting is disabled
+		if armed {
+			t.Reset(next.Sub(now))
+		}
+		fired := false
+		select {
+		case tokens <- struct{}{}:
+			consume(slots, clock.Now(), cfg)
+		case <-t.C():
+			fired = true
+		case cfg = <-lim.reconf:
+			slots = resize(slots, cfg, clock.Now())
+		case <-ctx.Done():
+			atomic.StoreInt32(&lim.available, 0)
+			return
+		}
+		// drain the timer to avoid Reset problems
+		if armed && !fired && !t.Stop() {
+			<-t.C()
+		}
+	}
+}
+
+// tick changes the state of a slot whose wait period has elapsed: an unavailable token becomes available,
+// and the wait period of a token that's remained available is halved.
+func (s *slot) tick(now time.Time, cfg limiterConfig) {
+	if s.available {
+		s.d /= 2
+	}
+	s.available = true
+	s.clamp(cfg)
+	s.next = now.Add(s.d)
+}
+
+func (s *slot) clamp(cfg limiterConfig) {
+	if s.d > cfg.maxWait {
+		s.d = cfg.maxWait
+	}
+	// important to have non-zero minWait otherwise we busy-loop
+	if s.d < cfg.minWait {
+		s.d = cfg.minWait
+	}
+}
+
+// consume takes the token of the first available slot, doubling its wait period.
+func consume(slots []slot, now time.Time, cfg limiterConfig) {
+	for i := range slots {
+		s := &slots[i]
+		if !s.available {
+			continue
+		}
+		s.available = false
+		s.d *= 2
+		s.clamp(cfg)
+		s.next = now.Add(s.d)
+		return
+	}
+}
+
+// resize returns slots that conform to the given configuration.
+func resize(slots []slot, cfg limiterConfig, now time.Time) []slot {
+	if len(slots) > cfg.burst {
+		slots = slots[:cfg.burst]
+	}
+	for i := range slots {
+		slots[i].clamp(cfg)
+	}
+	for len(slots) < cfg.burst {
+		slots = append(slots, slot{d: cfg.minWait, next: now.Add(cfg.minWait), available: true})
+	}
+	return slots
+}
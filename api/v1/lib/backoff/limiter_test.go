@@ -0,0 +1,138 @@
+package backoff
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestLimiter(t *testing.T) {
+	if _, err := NewLimiter(context.Background(), 0, time.Millisecond, time.Second); err == nil {
+		t.Fatal("expected an error for a zero burst")
+	}
+	if _, err := NewLimiter(context.Background(), 1, 0, time.Second); err == nil {
+		t.Fatal("expected an error for a zero minWait")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lim, err := NewLimiter(ctx, 3, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-lim.C():
+		case <-time.After(time.Second):
+			t.Fatalf("expected token %d of the burst", i)
+		}
+	}
+	select {
+	case <-lim.C():
+		t.Fatal("unexpected token beyond the burst")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if n := lim.Tokens(); n != 0 {
+		t.Fatalf("expected zero tokens instead of %d", n)
+	}
+
+	// an increased burst yields more tokens
+	if err = lim.Reconfigure(4, time.Hour, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err = lim.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := lim.Burst(); n != 4 {
+		t.Fatalf("expected a burst of 4 instead of %d", n)
+	}
+
+	// a zero burst disables rate limiting, until a positive burst is configured
+	if err = lim.Reconfigure(0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case <-lim.C():
+		case <-time.After(time.Second):
+			t.Fatalf("expected unlimited token %d", i)
+		}
+	}
+	if n := lim.Burst(); n != 0 {
+		t.Fatalf("expected a burst of 0 instead of %d", n)
+	}
+	if err = lim.Reconfigure(1, time.Hour, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	<-lim.C()
+	select {
+	case <-lim.C():
+		t.Fatal("unexpected token beyond the burst")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if err = lim.Reconfigure(-1, time.Hour, time.Hour); err == nil {
+		t.Fatal("expected an error for a negative burst")
+	}
+
+	cancel()
+	select {
+	case <-lim.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the limiter to stop")
+	}
+	if err = lim.Wait(context.Background()); err != ErrStopped {
+		t.Fatalf("expected ErrStopped instead of %v", err)
+	}
+	if err = lim.Reconfigure(1, time.Second, time.Second); err != ErrStopped {
+		t.Fatalf("expected ErrStopped instead of %v", err)
+	}
+}
+
+func TestLimiterBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := mesostime.NewFakeClock(time.Unix(0, 0))
+	lim, err := NewLimiter(ctx, 1, 10*time.Millisecond, 40*time.Millisecond, LimiterClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// greedy consumption: the wait periods increase, up to maxWait
+	var (
+		times = make(chan time.Time, 4)
+		done  = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		for i := 0; i < 4; i++ {
+			if err := lim.Wait(ctx); err != nil {
+				t.Error(err)
+				return
+			}
+			times <- clock.Now()
+		}
+	}()
+	for stop := false; !stop; {
+		select {
+		case <-done:
+			stop = true
+		default:
+			clock.Advance(time.Millisecond)
+			runtime.Gosched()
+		}
+	}
+	close(times)
+	// tokens at ~0, 20ms, 60ms, 100ms
+	i := 0
+	for tm := range times {
+		if d, want := tm.Sub(time.Unix(0, 0)), []time.Duration{0, 20, 60, 100}[i]*time.Millisecond; d < want {
+			t.Fatalf("expected token %d no sooner than %v instead of %v", i, want, d)
+		}
+		i++
+	}
+	if i != 4 {
+		t.Fatalf("expected 4 tokens instead of %d", i)
+	}
+}
@@ -0,0 +1,200 @@
+package mesos
+
+// go generate -type AgentInfo:AgentInfo -type Attribute:Attribute -type CheckInfo:CheckInfo -type CommandInfo:CommandInfo -type ContainerInfo:ContainerInfo -type DiscoveryInfo:DiscoveryInfo -type Environment:Environment -type ExecutorInfo:ExecutorInfo -type Filters:Filters -type FrameworkInfo:FrameworkInfo -type HealthCheck:HealthCheck -type InverseOffer:InverseOffer -type KillPolicy:KillPolicy -type Labels:Labels -type Offer:Offer -type Offer_Operation:Offer_Operation -type Resource:Resource -type Task:Task -type TaskGroupInfo:TaskGroupInfo -type TaskInfo:TaskInfo -type TaskStatus:TaskStatus -type URL:URL -type Value:Value -type Volume:Volume -output clone_generated.go
+// GENERATED CODE FOLLOWS; DO NOT EDIT.
+
+import (
+	"github.com/gogo/protobuf/proto"
+)
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *AgentInfo) Clone() *AgentInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*AgentInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Attribute) Clone() *Attribute {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Attribute)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *CheckInfo) Clone() *CheckInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*CheckInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *CommandInfo) Clone() *CommandInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*CommandInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *ContainerInfo) Clone() *ContainerInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*ContainerInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *DiscoveryInfo) Clone() *DiscoveryInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*DiscoveryInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Environment) Clone() *Environment {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Environment)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *ExecutorInfo) Clone() *ExecutorInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*ExecutorInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Filters) Clone() *Filters {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Filters)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *FrameworkInfo) Clone() *FrameworkInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*FrameworkInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *HealthCheck) Clone() *HealthCheck {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*HealthCheck)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *InverseOffer) Clone() *InverseOffer {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*InverseOffer)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *KillPolicy) Clone() *KillPolicy {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*KillPolicy)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Labels) Clone() *Labels {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Labels)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Offer) Clone() *Offer {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Offer)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Offer_Operation) Clone() *Offer_Operation {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Offer_Operation)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Resource) Clone() *Resource {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Resource)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Task) Clone() *Task {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Task)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *TaskGroupInfo) Clone() *TaskGroupInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*TaskGroupInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *TaskInfo) Clone() *TaskInfo {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*TaskInfo)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *TaskStatus) Clone() *TaskStatus {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*TaskStatus)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *URL) Clone() *URL {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*URL)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Value) Clone() *Value {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Value)
+}
+
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *Volume) Clone() *Volume {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*Volume)
+}
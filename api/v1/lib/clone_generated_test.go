@@ -0,0 +1,370 @@
+package mesos
+
+// go generate -type AgentInfo:AgentInfo -type Attribute:Attribute -type CheckInfo:CheckInfo -type CommandInfo:CommandInfo -type ContainerInfo:ContainerInfo -type DiscoveryInfo:DiscoveryInfo -type Environment:Environment -type ExecutorInfo:ExecutorInfo -type Filters:Filters -type FrameworkInfo:FrameworkInfo -type HealthCheck:HealthCheck -type InverseOffer:InverseOffer -type KillPolicy:KillPolicy -type Labels:Labels -type Offer:Offer -type Offer_Operation:Offer_Operation -type Resource:Resource -type Task:Task -type TaskGroupInfo:TaskGroupInfo -type TaskInfo:TaskInfo -type TaskStatus:TaskStatus -type URL:URL -type Value:Value -type Volume:Volume -output clone_generated.go
+// GENERATED CODE FOLLOWS; DO NOT EDIT.
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAgentInfoClone(t *testing.T) {
+	var none *AgentInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedAgentInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestAttributeClone(t *testing.T) {
+	var none *Attribute
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedAttribute(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestCheckInfoClone(t *testing.T) {
+	var none *CheckInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedCheckInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestCommandInfoClone(t *testing.T) {
+	var none *CommandInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedCommandInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestContainerInfoClone(t *testing.T) {
+	var none *ContainerInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedContainerInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestDiscoveryInfoClone(t *testing.T) {
+	var none *DiscoveryInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedDiscoveryInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestEnvironmentClone(t *testing.T) {
+	var none *Environment
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedEnvironment(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestExecutorInfoClone(t *testing.T) {
+	var none *ExecutorInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedExecutorInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestFiltersClone(t *testing.T) {
+	var none *Filters
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedFilters(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestFrameworkInfoClone(t *testing.T) {
+	var none *FrameworkInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedFrameworkInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestHealthCheckClone(t *testing.T) {
+	var none *HealthCheck
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedHealthCheck(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestInverseOfferClone(t *testing.T) {
+	var none *InverseOffer
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedInverseOffer(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestKillPolicyClone(t *testing.T) {
+	var none *KillPolicy
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedKillPolicy(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestLabelsClone(t *testing.T) {
+	var none *Labels
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedLabels(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestOfferClone(t *testing.T) {
+	var none *Offer
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedOffer(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestOffer_OperationClone(t *testing.T) {
+	var none *Offer_Operation
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedOffer_Operation(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestResourceClone(t *testing.T) {
+	var none *Resource
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedResource(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestTaskClone(t *testing.T) {
+	var none *Task
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedTask(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestTaskGroupInfoClone(t *testing.T) {
+	var none *TaskGroupInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedTaskGroupInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestTaskInfoClone(t *testing.T) {
+	var none *TaskInfo
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedTaskInfo(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestTaskStatusClone(t *testing.T) {
+	var none *TaskStatus
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedTaskStatus(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestURLClone(t *testing.T) {
+	var none *URL
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedURL(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestValueClone(t *testing.T) {
+	var none *Value
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedValue(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+
+func TestVolumeClone(t *testing.T) {
+	var none *Volume
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulatedVolume(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
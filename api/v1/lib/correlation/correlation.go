@@ -0,0 +1,107 @@
+// Package correlation associates an ID with each logical API call, via its context, so that every attempt
+// (e.g. following a redirect) at executing the call may be correlated; across client debug logs, errors,
+// and (optionally) the request headers that are observed by Mesos.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// DefaultHeader is the conventional name of the HTTP header that carries a correlation ID.
+const DefaultHeader = "X-Request-Id"
+
+// ID identifies a logical call.
+type ID string
+
+// Generator generates IDs, each of which must be unique; e.g. NewID, or a Sequence for reproducible tests.
+type Generator func() ID
+
+type key struct{}
+
+// NewID returns a new, random, ID.
+func NewID() ID {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return ID(hex.EncodeToString(b))
+}
+
+// Sequence returns a Generator of the IDs "<prefix>1", "<prefix>2", and so on; e.g. a prefix may identify
+// the shard of a framework that generates the IDs. Sequences are safe to invoke concurrently.
+func Sequence(prefix string) Generator {
+	var n uint64
+	return func() ID {
+		return ID(prefix + strconv.FormatUint(atomic.AddUint64(&n, 1), 10))
+	}
+}
+
+// WithID returns a context that carries the given ID.
+func WithID(ctx context.Context, id ID) context.Context { return context.WithValue(ctx, key{}, id) }
+
+// FromContext returns the ID carried by the context, if any.
+func FromContext(ctx context.Context) (ID, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(key{}).(ID)
+	return id, ok && id != ""
+}
+
+// Ensure returns a context that carries an ID: either the given context, if it already carries one, or
+// else a derived context that carries a NewID.
+func Ensure(ctx context.Context) (context.Context, ID) { return EnsureWith(ctx, NewID) }
+
+// EnsureWith is like Ensure, except that a missing ID is generated by gen (NewID, if nil).
+func EnsureWith(ctx context.Context, gen Generator) (context.Context, ID) {
+	if id, ok := FromContext(ctx); ok {
+		return ctx, id
+	}
+	if gen == nil {
+		gen = NewID
+	}
+	id := gen()
+	return WithID(ctx, id), id
+}
+
+// Error annotates the error of a logical call with the call's correlation ID.
+type Error struct {
+	ID  ID
+	Err error
+}
+
+func (err *Error) Error() string { return fmt.Sprintf("%v (correlation ID %s)", err.Err, err.ID) }
+
+// Cause returns the annotated error.
+func (err *Error) Cause() error { return err.Err }
+
+// Unwrap returns the annotated error.
+func (err *Error) Unwrap() error { return err.Err }
+
+// Wrap annotates err with the ID carried by ctx, if any; nil errors, and errors that are already
+// annotated, are returned as-is.
+func Wrap(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	if id, ok := FromContext(ctx); ok {
+		return &Error{ID: id, Err: err}
+	}
+	return err
+}
+
+// Cause returns the error that's annotated by err, if it's an *Error; otherwise err.
+func Cause(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Err
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package correlation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCorrelation(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := FromContext(ctx); ok {
+		t.Fatal("unexpected correlation ID")
+	}
+	ctx, id := Ensure(ctx)
+	if id == "" || id == NewID() {
+		t.Fatalf("unexpected ID %q", id)
+	}
+	if ctx2, id2 := Ensure(ctx); ctx2 != ctx || id2 != id {
+		t.Fatal("expected the existing ID to be retained")
+	}
+
+	gen := Sequence("a-")
+	if _, id := EnsureWith(context.Background(), gen); id != "a-1" {
+		t.Fatalf("unexpected ID %q", id)
+	}
+	if _, id2 := EnsureWith(ctx, gen); id2 != id || gen() != "a-2" {
+		t.Fatalf("expected the existing ID to be retained instead of %q", id2)
+	}
+
+	cause := errors.New("boom")
+	err := Wrap(ctx, cause)
+	if !strings.Contains(err.Error(), string(id)) || Cause(err) != cause {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if Wrap(ctx, err) != err {
+		t.Fatal("expected an annotated error to be returned as-is")
+	}
+	if Wrap(context.Background(), cause) != cause || Wrap(ctx, nil) != nil {
+		t.Fatal("unexpected annotation")
+	}
+}
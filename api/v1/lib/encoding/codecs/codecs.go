@@ -31,3 +31,13 @@ var ByMediaType = map[encoding.MediaType]encoding.Codec{
 		NewDecoder: json.NewDecoder,
 	},
 }
+
+// ByName returns the pre-configured Codec (see ByMediaType) of the given name, e.g. NameJSON.
+func ByName(name string) (encoding.Codec, bool) {
+	for _, codec := range ByMediaType {
+		if codec.Name == name {
+			return codec, true
+		}
+	}
+	return encoding.Codec{}, false
+}
@@ -0,0 +1,158 @@
+package framing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sort"
+	"sync"
+)
+
+// DefaultMaxDecompressedSize is the default limit on the size of a decompressed frame; it's the same as
+// the default max frame size of package recordio.
+const DefaultMaxDecompressedSize = 1 << 22
+
+type (
+	// Compressor compresses and decompresses individual frames. A Compressor is used by a single Reader
+	// or Writer, so implementations needn't be safe for concurrent use (and may retain buffers).
+	Compressor interface {
+		// Compress returns the compressed form of src, overwriting dst (which may be nil) if it has
+		// sufficient capacity.
+		Compress(dst, src []byte) ([]byte, error)
+		// Decompress returns the decompressed form of src, overwriting dst (which may be nil) if it has
+		// sufficient capacity. Returns ErrorOversizedFrame if the decompressed frame would exceed max
+		// bytes (if max > 0).
+		Decompress(dst, src []byte, max int) ([]byte, error)
+	}
+
+	// Compression is a named frame compression algorithm; the name identifies the algorithm in headers,
+	// such as Message-Content-Encoding, that negotiate compression. Algorithms that aren't provided
+	// by this package (e.g. snappy, or zstd) may be added via RegisterCompression.
+	Compression struct {
+		Name          string
+		NewCompressor func() Compressor
+	}
+)
+
+// Gzip compresses frames using compress/gzip; it's registered by default.
+var Gzip = Compression{
+	Name:          "gzip",
+	NewCompressor: func() Compressor { return new(gzipCompressor) },
+}
+
+var (
+	compressionsLock sync.RWMutex
+	compressions     = map[string]Compression{}
+)
+
+func init() { RegisterCompression(Gzip) }
+
+// RegisterCompression makes the given compression available by name, replacing any that's already
+// registered by the same name. Panics if either the name or the constructor of the compression is empty.
+func RegisterCompression(c Compression) {
+	if c.Name == "" || c.NewCompressor == nil {
+		panic("illegal compression: name and compressor constructor are required")
+	}
+	compressionsLock.Lock()
+	defer compressionsLock.Unlock()
+	compressions[c.Name] = c
+}
+
+// LookupCompression returns the registered compression with the given name.
+func LookupCompression(name string) (Compression, bool) {
+	compressionsLock.RLock()
+	defer compressionsLock.RUnlock()
+	c, ok := compressions[name]
+	return c, ok
+}
+
+// Compressions returns the names of the registered compressions, ordered.
+func Compressions() []string {
+	compressionsLock.RLock()
+	defer compressionsLock.RUnlock()
+	names := make([]string, 0, len(compressions))
+	for name := range compressions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DecompressReader returns a Reader that decompresses the frames read from r; frames that would
+// decompress to more than max bytes (if max > 0) yield ErrorOversizedFrame. The returned frames are
+// reused, as per the frames of most Readers.
+func DecompressReader(r Reader, c Compressor, max int) ReaderFunc {
+	var buf []byte
+	return func() ([]byte, error) {
+		frame, err := r.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		buf, err = c.Decompress(buf, frame, max)
+		if err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+}
+
+// CompressWriter returns a Writer that compresses frames before writing them to w.
+func CompressWriter(w Writer, c Compressor) WriterFunc {
+	var buf []byte
+	return func(frame []byte) (err error) {
+		if buf, err = c.Compress(buf, frame); err != nil {
+			return err
+		}
+		return w.WriteFrame(buf)
+	}
+}
+
+type gzipCompressor struct {
+	w *gzip.Writer
+	r *gzip.Reader
+}
+
+func (g *gzipCompressor) Compress(dst, src []byte) ([]byte, error) {
+	b := bytes.NewBuffer(dst[:0])
+	if g.w == nil {
+		g.w = gzip.NewWriter(b)
+	} else {
+		g.w.Reset(b)
+	}
+	if _, err := g.w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := g.w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (g *gzipCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	var (
+		br  = bytes.NewReader(src)
+		err error
+	)
+	if g.r == nil {
+		g.r, err = gzip.NewReader(br)
+	} else {
+		err = g.r.Reset(br)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var (
+		b = bytes.NewBuffer(dst[:0])
+		r = io.Reader(g.r)
+	)
+	if max > 0 {
+		r = io.LimitReader(r, int64(max)+1)
+	}
+	if _, err = b.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	if max > 0 && b.Len() > max {
+		return nil, ErrorOversizedFrame
+	}
+	return b.Bytes(), nil
+}
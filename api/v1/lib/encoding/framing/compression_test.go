@@ -0,0 +1,61 @@
+package framing_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	. "github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+)
+
+func TestCompression(t *testing.T) {
+	if _, ok := LookupCompression("gzip"); !ok {
+		t.Fatal("expected gzip to be registered")
+	}
+	var (
+		want = frames("", "james", string(bytes.Repeat([]byte("foo"), 1000)), "bar")
+		sent [][]byte
+		w    = CompressWriter(WriterFunc(func(b []byte) error {
+			sent = append(sent, append([]byte(nil), b...))
+			return nil
+		}), Gzip.NewCompressor())
+	)
+	for _, f := range want {
+		if err := w.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sent[2]) >= len(want[2]) {
+		t.Fatalf("expected a compressed frame, %d bytes instead of %d", len(want[2]), len(sent[2]))
+	}
+	var (
+		i   int
+		got [][]byte
+		r   = DecompressReader(ReaderFunc(func() ([]byte, error) {
+			if i == len(sent) {
+				return nil, io.EOF
+			}
+			i++
+			return sent[i-1], nil
+		}), Gzip.NewCompressor(), 0)
+	)
+	for {
+		f, err := r.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, append([]byte(nil), f...))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q instead of %q", want, got)
+	}
+
+	r = DecompressReader(ReaderFunc(func() ([]byte, error) { return sent[2], nil }), Gzip.NewCompressor(), 100)
+	if _, err := r.ReadFrame(); err != ErrorOversizedFrame {
+		t.Fatalf("expected ErrorOversizedFrame instead of %v", err)
+	}
+}
@@ -1,14 +1,20 @@
 package json
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"reflect"
+	"strings"
 
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	fflib "github.com/pquerna/ffjson/fflib/v1"
 )
 
 // NewEncoder returns a new Encoder of Calls to JSON messages written to
-// the given io.Writer.
+// the given io.Writer. Bytes fields are encoded as padded, standard, base64
+// strings; the same as the Mesos master.
 func NewEncoder(s encoding.Sink) encoding.Encoder {
 	w := s()
 	return encoding.EncoderFunc(func(m encoding.Marshaler) error {
@@ -21,8 +27,140 @@ func NewEncoder(s encoding.Sink) encoding.Encoder {
 }
 
 // NewDecoder returns a new Decoder of JSON messages read from the given source.
+// See Unmarshal. Messages of unknown types are reported as an *encoding.UnknownEvent.
 func NewDecoder(s encoding.Source) encoding.Decoder {
 	r := s()
-	dec := framing.NewDecoder(r, json.Unmarshal)
+	dec := framing.NewDecoder(r, unmarshalTyped)
 	return encoding.DecoderFunc(func(u encoding.Unmarshaler) error { return dec.Decode(u) })
 }
+
+// unmarshalTyped decodes the JSON message b into v, as per Unmarshal. If v is an encoding.Typed message
+// that fails to decode because the name of its type is unknown then an *encoding.UnknownEvent is returned.
+func unmarshalTyped(b []byte, v interface{}) error {
+	err := Unmarshal(b, v)
+	if m, ok := v.(encoding.Typed); ok && err != nil {
+		var (
+			typed struct {
+				Type json.RawMessage `json:"type"`
+			}
+			name string
+		)
+		if json.Unmarshal(b, &typed) == nil && json.Unmarshal(typed.Type, &name) == nil && !encoding.KnownTypeName(m, name) {
+			return encoding.NewUnknownEvent(v, name, b)
+		}
+	}
+	return err
+}
+
+// Unmarshal decodes the JSON message b into v. Bytes fields are expected to be
+// base64 encoded, as generated by the Mesos master; unpadded and URL-safe
+// variants of base64 (as generated by some other tools) are also accepted.
+func Unmarshal(b []byte, v interface{}) error {
+	err := json.Unmarshal(b, v)
+	if !isBase64Error(err) {
+		return err
+	}
+	// slow path: rewrite the bytes fields of the message using standard base64, and try again.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var tree interface{}
+	if dec.Decode(&tree) != nil {
+		return err
+	}
+	b, err2 := json.Marshal(normalize(tree, reflect.TypeOf(v)))
+	if err2 != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// isBase64Error returns true if err reports illegal base64 input, either directly or
+// as wrapped by the ffjson generated unmarshalers.
+func isBase64Error(err error) bool {
+	switch err.(type) {
+	case base64.CorruptInputError:
+		return true
+	case *fflib.LexerError:
+		return strings.Contains(err.Error(), "(base64.CorruptInputError)")
+	}
+	return false
+}
+
+// base64Encodings are attempted, in order, when decoding bytes fields.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// normalize walks the tree of a generically decoded JSON message alongside the
+// type that it's meant to be decoded into, re-encoding the base64 strings of
+// bytes fields with standard, padded, base64.
+func normalize(tree interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch x := tree.(type) {
+	case string:
+		if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+			return x
+		}
+		for _, enc := range base64Encodings {
+			if data, err := enc.DecodeString(x); err == nil {
+				return base64.StdEncoding.EncodeToString(data)
+			}
+		}
+		return x
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i := range x {
+				x[i] = normalize(x[i], t.Elem())
+			}
+		}
+		return x
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for k := range x {
+				x[k] = normalize(x[k], t.Elem())
+			}
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				name := jsonName(f)
+				if name == "" {
+					continue
+				}
+				for k := range x {
+					// encoding/json matches field names case-insensitively
+					if strings.EqualFold(k, name) {
+						x[k] = normalize(x[k], f.Type)
+					}
+				}
+			}
+		}
+		return x
+	default:
+		return x
+	}
+}
+
+// jsonName returns the name of the JSON property that corresponds to the given
+// struct field, or else "" if the field isn't (un)marshaled.
+func jsonName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return "" // unexported
+	}
+	name := f.Tag.Get("json")
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
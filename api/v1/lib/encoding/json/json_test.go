@@ -0,0 +1,121 @@
+package json_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	. "github.com/mesos/mesos-go/api/v1/lib/encoding/json"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// capturedUpdate is an UPDATE event, as streamed (sans RecordIO framing) by a Mesos master.
+const capturedUpdate = `{"type":"UPDATE","update":{"status":{"agent_id":{"value":"4d1ec1a9-1b1d-4ad4-94c4-dd20ab5117a6-S0"},` +
+	`"container_status":{"container_id":{"value":"a7e6d5a0-6722-44ab-9d1c-4b0b4cb1b7af"},"network_infos":[{"ip_addresses":` +
+	`[{"ip_address":"10.0.2.15","protocol":"IPv4"}]}],"executor_pid":23906},"data":"AAEC//57Ig==","executor_id":{"value":"task-0"},` +
+	`"healthy":true,"source":"SOURCE_EXECUTOR","state":"TASK_RUNNING","task_id":{"value":"task-0"},"timestamp":1541633168.18421,` +
+	`"uuid":"++++TxKcSn+//xAgMEBQ/g=="}}}`
+
+var (
+	wantUUID = []byte{0xfb, 0xef, 0xbe, 0x4f, 0x12, 0x9c, 0x4a, 0x7f, 0xbf, 0xff, 0x10, 0x20, 0x30, 0x40, 0x50, 0xfe}
+	wantData = []byte{0x00, 0x01, 0x02, 0xff, 0xfe, '{', '"'}
+)
+
+func decodeEvent(t *testing.T, s string) *scheduler.Event {
+	var e scheduler.Event
+	dec := NewDecoder(encoding.SourceReader(strings.NewReader(s)))
+	if err := dec.Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+	return &e
+}
+
+func checkStatus(t *testing.T, e *scheduler.Event) {
+	status := e.GetUpdate().GetStatus()
+	if !bytes.Equal(status.GetUUID(), wantUUID) {
+		t.Errorf("expected uuid %x instead of %x", wantUUID, status.GetUUID())
+	}
+	if !bytes.Equal(status.GetData(), wantData) {
+		t.Errorf("expected data %x instead of %x", wantData, status.GetData())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	e := decodeEvent(t, capturedUpdate)
+	checkStatus(t, e)
+
+	var buf bytes.Buffer
+	if err := NewEncoder(encoding.SinkWriter(&buf)).Encode(e); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"uuid":"++++TxKcSn+//xAgMEBQ/g=="`, `"data":"AAEC//57Ig=="`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in encoded event: %s", want, buf.String())
+		}
+	}
+	checkStatus(t, decodeEvent(t, buf.String()))
+}
+
+func TestUnmarshalBase64Variants(t *testing.T) {
+	for ti, tc := range []struct{ uuid, data string }{
+		{`++++TxKcSn+\/\/xAgMEBQ\/g==`, `AAEC\/\/57Ig==`}, // escaped solidus
+		{"++++TxKcSn+//xAgMEBQ/g", "AAEC//57Ig"},          // unpadded
+		{"----TxKcSn-__xAgMEBQ_g==", "AAEC__57Ig=="},      // URL-safe
+		{"----TxKcSn-__xAgMEBQ_g", "AAEC__57Ig"},          // URL-safe, unpadded
+	} {
+		s := strings.Replace(capturedUpdate, "++++TxKcSn+//xAgMEBQ/g==", tc.uuid, 1)
+		s = strings.Replace(s, "AAEC//57Ig==", tc.data, 1)
+		var e scheduler.Event
+		if err := Unmarshal([]byte(s), &e); err != nil {
+			t.Fatalf("test case %d failed: %v", ti, err)
+		}
+		checkStatus(t, &e)
+	}
+
+	var e scheduler.Event
+	s := strings.Replace(capturedUpdate, "AAEC//57Ig==", "not base64!", 1)
+	if err := Unmarshal([]byte(s), &e); err == nil {
+		t.Fatal("expected an error for illegal base64")
+	}
+}
+
+// frames returns a Source of the given frames.
+func frames(fs ...string) encoding.Source {
+	return func() framing.Reader {
+		return framing.ReaderFunc(func() ([]byte, error) {
+			if len(fs) == 0 {
+				return nil, io.EOF
+			}
+			f := fs[0]
+			fs = fs[1:]
+			return []byte(f), nil
+		})
+	}
+}
+
+func TestDecodeUnknownType(t *testing.T) {
+	const future = `{"type":"FUTURE","future":{"value":1}}`
+	var (
+		dec = NewDecoder(frames(future, `{"type":"HEARTBEAT"}`, `{"type":"HEARTBEAT","offers":1}`))
+		e   = scheduler.Event{Update: &scheduler.Event_Update{}}
+	)
+	err := dec.Decode(&e)
+	unknown, ok := encoding.IsUnknownEvent(err)
+	if !ok || unknown.Type != "FUTURE" || string(unknown.Raw) != future {
+		t.Fatalf("expected an unknown event, got %v", err)
+	}
+	if e.Update != nil {
+		t.Fatalf("expected the event to be reset: %v", e)
+	}
+	if err = dec.Decode(&e); err != nil || e.GetType() != scheduler.Event_HEARTBEAT {
+		t.Fatalf("expected the stream to continue, got %v, %v", e, err)
+	}
+	if err = dec.Decode(&e); err == nil {
+		t.Fatal("expected an error for a malformed event of a known type")
+	} else if _, ok := encoding.IsUnknownEvent(err); ok {
+		t.Fatalf("unexpected unknown event: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package encoding
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ParseMediaType parses the media type of the given HTTP Content-Type header value; parameters (such as
+// charset) are discarded and the returned media type is always lower-case.
+func ParseMediaType(contentType string) (MediaType, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	return MediaType(mt), nil
+}
+
+// Matches returns true if the given HTTP Content-Type header value names this media type. The comparison
+// is case-insensitive and disregards parameters; for example "application/json" matches
+// "Application/JSON; charset=utf-8".
+func (m MediaType) Matches(contentType string) bool {
+	mt, err := ParseMediaType(contentType)
+	return err == nil && strings.EqualFold(string(mt), string(m))
+}
+
+// Accept returns an HTTP Accept header value that lists the given media types in order of preference:
+// the first media type is preferred (q=1) and each that follows is assigned a successively lower quality
+// value, down to a minimum of q=0.1. Duplicate and empty media types are skipped.
+func Accept(types ...MediaType) string {
+	var (
+		values = make([]string, 0, len(types))
+		seen   = make(map[MediaType]struct{}, len(types))
+	)
+	for _, t := range types {
+		if t == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		q := 10 - len(values)
+		switch {
+		case q >= 10:
+			values = append(values, t.ContentType())
+		case q > 1:
+			values = append(values, t.ContentType()+";q=0."+strconv.Itoa(q))
+		default:
+			values = append(values, t.ContentType()+";q=0.1")
+		}
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,45 @@
+package encoding_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+func TestMediaTypeMatches(t *testing.T) {
+	mt := encoding.MediaType("application/json")
+	for ti, tc := range []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"Application/JSON;charset=UTF-8", true},
+		{"application/x-protobuf", false},
+		{"application/json-seq", false},
+		{"", false},
+		{";charset=utf-8", false},
+	} {
+		if got := mt.Matches(tc.contentType); got != tc.want {
+			t.Errorf("test case %d failed: expected %v for %q", ti, tc.want, tc.contentType)
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	for ti, tc := range []struct {
+		types []encoding.MediaType
+		want  string
+	}{
+		{nil, ""},
+		{[]encoding.MediaType{"application/x-protobuf"}, "application/x-protobuf"},
+		{[]encoding.MediaType{"application/x-protobuf", "application/json"}, "application/x-protobuf, application/json;q=0.9"},
+		{[]encoding.MediaType{"a/a", "", "b/b", "a/a", "c/c"}, "a/a, b/b;q=0.9, c/c;q=0.8"},
+		{[]encoding.MediaType{"a/0", "a/1", "a/2", "a/3", "a/4", "a/5", "a/6", "a/7", "a/8", "a/9", "a/10"},
+			"a/0, a/1;q=0.9, a/2;q=0.8, a/3;q=0.7, a/4;q=0.6, a/5;q=0.5, a/6;q=0.4, a/7;q=0.3, a/8;q=0.2, a/9;q=0.1, a/10;q=0.1"},
+	} {
+		if got := encoding.Accept(tc.types...); got != tc.want {
+			t.Errorf("test case %d failed: expected %q instead of %q", ti, tc.want, got)
+		}
+	}
+}
@@ -19,11 +19,17 @@ func NewEncoder(s encoding.Sink) encoding.Encoder {
 	})
 }
 
-// NewDecoder returns a new Decoder of Protobuf messages read from the given Source.
+// NewDecoder returns a new Decoder of Protobuf messages read from the given Source. Messages of unknown
+// types are reported as an *encoding.UnknownEvent.
 func NewDecoder(s encoding.Source) encoding.Decoder {
 	r := s()
 	var (
-		uf  = func(b []byte, m interface{}) error { return proto.Unmarshal(b, m.(proto.Message)) }
+		uf = func(b []byte, m interface{}) error {
+			if err := proto.Unmarshal(b, m.(proto.Message)); err != nil {
+				return err
+			}
+			return encoding.CheckType(m, b)
+		}
 		dec = framing.NewDecoder(r, uf)
 	)
 	return encoding.DecoderFunc(func(u encoding.Unmarshaler) error { return dec.Decode(u) })
@@ -6,7 +6,10 @@ import (
 
 	"github.com/mesos/mesos-go/api/v1/lib"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
 	. "github.com/mesos/mesos-go/api/v1/lib/encoding/proto"
+	"github.com/mesos/mesos-go/api/v1/lib/recordio"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
 )
 
 type FakeMessage string
@@ -47,3 +50,32 @@ func TestEncoder(t *testing.T) {
 		t.Fatal("Encode failed to complete normally, but we didn't see a panic? should never happen")
 	}
 }
+
+func TestDecodeUnknownType(t *testing.T) {
+	var buf bytes.Buffer
+	w := recordio.NewWriter(&buf)
+	enc := NewEncoder(func() framing.Writer { return w })
+	for _, e := range []*scheduler.Event{
+		{Type: scheduler.Event_Type(1000), Error: &scheduler.Event_Error{Message: "future"}},
+		{Type: scheduler.Event_HEARTBEAT},
+	} {
+		if err := enc.Encode(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var (
+		r   = recordio.NewReader(&buf)
+		dec = NewDecoder(func() framing.Reader { return r })
+		e   scheduler.Event
+	)
+	err := dec.Decode(&e)
+	if unknown, ok := encoding.IsUnknownEvent(err); !ok || unknown.Type != "1000" || len(unknown.Raw) == 0 {
+		t.Fatalf("expected an unknown event, got %v", err)
+	}
+	if e.GetType() != scheduler.Event_UNKNOWN || e.Error != nil {
+		t.Fatalf("expected the event to be reset: %v", e)
+	}
+	if err = dec.Decode(&e); err != nil || e.GetType() != scheduler.Event_HEARTBEAT {
+		t.Fatalf("expected the stream to continue, got %v, %v", e, err)
+	}
+}
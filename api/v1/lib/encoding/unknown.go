@@ -0,0 +1,76 @@
+package encoding
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type (
+	// Typed is implemented by messages that are distinguished by an enum "type" field (e.g. events, calls,
+	// and responses) so that decoders may recognize messages of types that were added by a newer version
+	// of Mesos; see UnknownEvent.
+	Typed interface {
+		// TypeNames returns the names of the values of the enum of the type field, by value; e.g.
+		// scheduler.Event_Type_name.
+		TypeNames() map[int32]string
+		// TypeValue returns the value of the type field.
+		TypeValue() int32
+	}
+
+	// UnknownEvent is returned by the decoders of codecs for a message (usually an event of a stream) of a
+	// type that's unknown to this library, e.g. because it was added by a newer version of Mesos: rather
+	// than decoding the message partially, or failing to decode it, the encoded message is surfaced. The
+	// error isn't fatal: the messages that follow may still be decoded from the same stream, so that
+	// consumers may skip messages that they can't understand and survive upgrades of Mesos. The message
+	// that was decoded into is reset.
+	UnknownEvent struct {
+		Type string // the type of the message, as encoded: e.g. a name (JSON), or a number (protobuf)
+		Raw  []byte // the encoded message, as per the codec
+	}
+)
+
+func (e *UnknownEvent) Error() string {
+	return fmt.Sprintf("message of unknown type %q (%d bytes)", e.Type, len(e.Raw))
+}
+
+// IsUnknownEvent returns the UnknownEvent of the given error, if any.
+func IsUnknownEvent(err error) (*UnknownEvent, bool) {
+	e, ok := err.(*UnknownEvent)
+	return e, ok
+}
+
+// NewUnknownEvent returns an UnknownEvent for a message of the given type, that's encoded in the given
+// frame (which is copied, since frames are reused by readers); the message that was decoded into is
+// reset.
+func NewUnknownEvent(u interface{}, t string, frame []byte) *UnknownEvent {
+	if r, ok := u.(interface{ Reset() }); ok {
+		r.Reset()
+	}
+	return &UnknownEvent{Type: t, Raw: append([]byte(nil), frame...)}
+}
+
+// KnownTypeName returns true if the given name is that of a value of the enum of the type field of the
+// given message.
+func KnownTypeName(m Typed, name string) bool {
+	for _, n := range m.TypeNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckType returns an UnknownEvent if the given message, that was decoded from the given frame, is Typed
+// and the value of its type field is unknown; as decoded from protobuf, which preserves unknown enum
+// values. Otherwise it returns nil.
+func CheckType(u interface{}, frame []byte) error {
+	m, ok := u.(Typed)
+	if !ok {
+		return nil
+	}
+	v := m.TypeValue()
+	if _, ok := m.TypeNames()[v]; ok {
+		return nil
+	}
+	return NewUnknownEvent(u, strconv.Itoa(int(v)), frame)
+}
@@ -0,0 +1,50 @@
+package calls
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync/atomic"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+)
+
+// UUIDs generates the UUIDs, in binary (16 byte) form, that identify the status updates of an executor;
+// each must be unique. See RandomUUID, and SequentialUUIDs for reproducible tests.
+type UUIDs func() []byte
+
+// RandomUUID returns a randomly generated (version 4) UUID, in binary form.
+func RandomUUID() []byte {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return b
+}
+
+// SequentialUUIDs returns a generator of UUIDs whose first 8 bytes are the given prefix (e.g. identifying
+// a shard, or a test) and whose last 8 bytes are a counter, starting at 1: so that the UUIDs of status
+// updates are reproducible. The generator is safe to invoke concurrently.
+func SequentialUUIDs(prefix uint64) UUIDs {
+	var n uint64
+	return func() []byte {
+		b := make([]byte, 16)
+		binary.BigEndian.PutUint64(b, prefix)
+		binary.BigEndian.PutUint64(b[8:], atomic.AddUint64(&n, 1))
+		return b
+	}
+}
+
+// Update returns an UPDATE call for the given status; a status without a UUID is assigned one that's
+// generated by uuids (or else RandomUUID, if nil).
+func (uuids UUIDs) Update(status mesos.TaskStatus) *executor.Call {
+	if len(status.UUID) == 0 {
+		if uuids == nil {
+			uuids = RandomUUID
+		}
+		status.UUID = uuids()
+	}
+	return Update(status)
+}
@@ -0,0 +1,32 @@
+package calls
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+func TestUUIDs(t *testing.T) {
+	if a, b := RandomUUID(), RandomUUID(); len(a) != 16 || bytes.Equal(a, b) || a[6]>>4 != 4 {
+		t.Fatalf("unexpected random UUIDs %x, %x", a, b)
+	}
+
+	uuids := SequentialUUIDs(7)
+	c := uuids.Update(mesos.TaskStatus{TaskID: mesos.TaskID{Value: "t"}})
+	if u := c.GetUpdate().Status.UUID; !bytes.Equal(u, []byte{0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 1}) {
+		t.Fatalf("unexpected UUID %x", u)
+	}
+	if u := uuids(); u[15] != 2 {
+		t.Fatalf("unexpected UUID %x", u)
+	}
+
+	// an existing UUID is retained
+	c = uuids.Update(mesos.TaskStatus{UUID: []byte("existing")})
+	if u := c.GetUpdate().Status.UUID; string(u) != "existing" {
+		t.Fatalf("expected the existing UUID instead of %x", u)
+	}
+	if c = UUIDs(nil).Update(mesos.TaskStatus{}); len(c.GetUpdate().Status.UUID) != 16 {
+		t.Fatal("expected a random UUID")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
 	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
 )
 
@@ -38,6 +39,12 @@ type Config struct {
 	// The maximum backoff duration to be used by the executor between two retries when
 	// disconnected (e.g., 250ms, 1mins etc.)
 	SubscriptionBackoffMax time.Duration
+
+	// Codec is the name of the codec (see codecs.ByName) with which the executor should encode calls
+	// and decode events: "protobuf" (the default) or "json", e.g. for proxies and debugging tools that
+	// only inspect JSON. It's read from the optional EXECUTOR_CODEC variable, which mesos doesn't set
+	// (but which may be set via the environment of the executor's command).
+	Codec string
 }
 
 type EnvError struct {
@@ -79,6 +86,12 @@ func fromEnv(getter func(string) string) (Config, error) {
 		AgentEndpoint:               required("MESOS_AGENT_ENDPOINT"),
 		ExecutorShutdownGracePeriod: requiredDuration("MESOS_EXECUTOR_SHUTDOWN_GRACE_PERIOD"),
 	}
+	c.Codec = getter("EXECUTOR_CODEC")
+	if c.Codec == "" {
+		c.Codec = codecs.NameProtobuf
+	} else if _, ok := codecs.ByName(c.Codec); !ok {
+		ee.Reasons = append(ee.Reasons, errors.New("unsupported codec: EXECUTOR_CODEC="+c.Codec))
+	}
 	checkpoint, err := envBool("MESOS_CHECKPOINT", getter)
 	if err != nil {
 		ee.Reasons = append(ee.Reasons, err)
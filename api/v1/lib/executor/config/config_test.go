@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestFromEnvCodec(t *testing.T) {
+	env := map[string]string{
+		"MESOS_FRAMEWORK_ID":                   "f",
+		"MESOS_EXECUTOR_ID":                    "e",
+		"MESOS_DIRECTORY":                      "/tmp",
+		"MESOS_SANDBOX":                        "/mnt/mesos/sandbox",
+		"MESOS_AGENT_ENDPOINT":                 "127.0.0.1:5051",
+		"MESOS_EXECUTOR_SHUTDOWN_GRACE_PERIOD": "5secs",
+	}
+	getter := func(name string) string { return env[name] }
+
+	c, err := fromEnv(getter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Codec != "protobuf" {
+		t.Fatalf("expected the protobuf codec by default instead of %q", c.Codec)
+	}
+
+	env["EXECUTOR_CODEC"] = "json"
+	if c, err = fromEnv(getter); err != nil || c.Codec != "json" {
+		t.Fatalf("expected the json codec instead of %q: %v", c.Codec, err)
+	}
+
+	env["EXECUTOR_CODEC"] = "xml"
+	if _, err = fromEnv(getter); err == nil {
+		t.Fatal("expected an error for an unsupported codec")
+	}
+}
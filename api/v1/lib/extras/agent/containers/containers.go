@@ -0,0 +1,171 @@
+// Package containers models the containers reported by an agent's GET_CONTAINERS response as a tree,
+// in which nested containers are the children of their parent containers, for use by debug tooling and
+// by executors that supervise nested containers. It also supports waiting for containers to terminate, and
+// interactive sessions with the processes of containers (see Session).
+package containers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+)
+
+// SkipChildren may be returned by a WalkFunc to skip the children of the visited node.
+var SkipChildren = errors.New("skip children")
+
+type (
+	// Node is a container of the tree. The Container of a node is nil if the agent didn't report the
+	// container itself, but did report a nested container of it.
+	Node struct {
+		ID        mesos.ContainerID
+		Container *agent.Response_GetContainers_Container
+		Parent    *Node
+		Children  []*Node // ordered by ID
+	}
+
+	// Tree is the hierarchy of the containers reported by an agent; top-level containers are its Roots.
+	Tree struct {
+		Roots []*Node // ordered by ID
+		nodes map[string]*Node
+	}
+
+	// WalkFunc is invoked for every node visited by a walk; a non-nil error (other than SkipChildren)
+	// terminates the walk.
+	WalkFunc func(*Node) error
+)
+
+// Key returns the canonical string form of a container ID: the values of the container's ancestry, from
+// the top-level container down, separated by ".". This is the same form that's used by the agent.
+func Key(id mesos.ContainerID) string {
+	var values []string
+	for p := &id; p != nil; p = p.Parent {
+		values = append(values, p.Value)
+	}
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	return strings.Join(values, ".")
+}
+
+// New returns a Tree of the given containers.
+func New(containers ...agent.Response_GetContainers_Container) *Tree {
+	t := &Tree{nodes: make(map[string]*Node, len(containers))}
+	for i := range containers {
+		c := &containers[i]
+		t.node(c.ContainerID).Container = c
+	}
+	t.Roots = sortNodes(t.Roots)
+	for _, n := range t.nodes {
+		n.Children = sortNodes(n.Children)
+	}
+	return t
+}
+
+// node returns the node of the given container, adding it (and its ancestors) to the tree as needed.
+func (t *Tree) node(id mesos.ContainerID) *Node {
+	k := Key(id)
+	if n, ok := t.nodes[k]; ok {
+		return n
+	}
+	n := &Node{ID: id}
+	t.nodes[k] = n
+	if id.Parent == nil {
+		t.Roots = append(t.Roots, n)
+	} else {
+		n.Parent = t.node(*id.Parent)
+		n.Parent.Children = append(n.Parent.Children, n)
+	}
+	return n
+}
+
+func sortNodes(nodes []*Node) []*Node {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID.Value < nodes[j].ID.Value })
+	return nodes
+}
+
+// Len returns the number of nodes of the tree.
+func (t *Tree) Len() int { return len(t.nodes) }
+
+// Lookup returns the node of the given container, or else nil if the tree doesn't contain it.
+func (t *Tree) Lookup(id mesos.ContainerID) *Node { return t.nodes[Key(id)] }
+
+// Children returns the nodes of the containers that are nested (immediately) within the given parent.
+func (t *Tree) Children(parent mesos.ContainerID) []*Node {
+	if n := t.Lookup(parent); n != nil {
+		return n.Children
+	}
+	return nil
+}
+
+// Walk visits every node of the tree, depth first, parents before children.
+func (t *Tree) Walk(f WalkFunc) error {
+	for _, n := range t.Roots {
+		if err := n.Walk(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk visits the node and its descendants, depth first, parents before children.
+func (n *Node) Walk(f WalkFunc) error {
+	err := f(n)
+	if err == SkipChildren {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range n.Children {
+		if err = c.Walk(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Depth returns the nesting level of the node; top-level containers have a depth of zero.
+func (n *Node) Depth() (d int) {
+	for p := n.Parent; p != nil; p = p.Parent {
+		d++
+	}
+	return
+}
+
+// Root returns the top-level ancestor of the node (or the node itself).
+func (n *Node) Root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// Fetch issues a GET_CONTAINERS call, that includes nested containers, via the given sender and returns
+// the Tree of the containers reported by the agent.
+func Fetch(ctx context.Context, sender calls.Sender) (*Tree, error) {
+	c := calls.GetContainers()
+	c.GetContainers = &agent.Call_GetContainers{ShowNested: proto.Bool(true)}
+
+	resp, err := sender.Send(ctx, calls.NonStreaming(c))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r agent.Response
+	if err = resp.Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.GetGetContainers() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", agent.Call_GET_CONTAINERS, r.GetType())
+	}
+	return New(r.GetGetContainers().Containers...), nil
+}
@@ -0,0 +1,99 @@
+package containers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+func cid(values ...string) (id mesos.ContainerID) {
+	for i, v := range values {
+		if i == 0 {
+			id = mesos.ContainerID{Value: v}
+			continue
+		}
+		parent := id
+		id = mesos.ContainerID{Value: v, Parent: &parent}
+	}
+	return
+}
+
+func fixture() []agent.Response_GetContainers_Container {
+	return []agent.Response_GetContainers_Container{
+		{ContainerID: cid("b")},
+		{ContainerID: cid("a", "y")},
+		{ContainerID: cid("a")},
+		{ContainerID: cid("a", "x")},
+		{ContainerID: cid("a", "x", "1")},
+		{ContainerID: cid("c", "z")}, // parent "c" isn't reported
+	}
+}
+
+func TestTree(t *testing.T) {
+	tree := New(fixture()...)
+	if n := tree.Len(); n != 7 {
+		t.Fatalf("expected 7 nodes instead of %d", n)
+	}
+	var visited []string
+	tree.Walk(func(n *Node) error {
+		visited = append(visited, Key(n.ID))
+		return nil
+	})
+	if want := []string{"a", "a.x", "a.x.1", "a.y", "b", "c", "c.z"}; !reflect.DeepEqual(visited, want) {
+		t.Fatalf("expected %v instead of %v", want, visited)
+	}
+
+	n := tree.Lookup(cid("a", "x", "1"))
+	if n == nil || n.Container == nil || n.Depth() != 2 || n.Root() != tree.Lookup(cid("a")) {
+		t.Fatalf("unexpected node %+v", n)
+	}
+	if n := tree.Lookup(cid("c")); n == nil || n.Container != nil || len(n.Children) != 1 {
+		t.Fatalf("expected an unreported parent node instead of %+v", n)
+	}
+	if n := tree.Lookup(cid("x")); n != nil {
+		t.Fatalf("unexpected node %+v", n)
+	}
+	if children := tree.Children(cid("a")); len(children) != 2 || children[0].ID.Value != "x" {
+		t.Fatalf("unexpected children %v", children)
+	}
+
+	visited = nil
+	tree.Walk(func(n *Node) error {
+		visited = append(visited, Key(n.ID))
+		if n.ID.Value == "a" {
+			return SkipChildren
+		}
+		return nil
+	})
+	if want := []string{"a", "b", "c", "c.z"}; !reflect.DeepEqual(visited, want) {
+		t.Fatalf("expected %v instead of %v", want, visited)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	sender := calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+		if !r.Call().GetGetContainers().GetShowNested() {
+			t.Errorf("expected a call that shows nested containers")
+		}
+		resp := agent.Response{
+			Type:          agent.Response_GET_CONTAINERS,
+			GetContainers: &agent.Response_GetContainers{Containers: fixture()},
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*agent.Response)) = resp
+			return nil
+		})}, nil
+	})
+	tree, err := Fetch(context.Background(), sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Len() != 7 {
+		t.Fatalf("expected 7 nodes instead of %d", tree.Len())
+	}
+}
@@ -0,0 +1,336 @@
+package containers
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+)
+
+// CopyError is returned when the tar process of a copy fails.
+type CopyError struct {
+	Op     string // "upload" or "download"
+	Status *ExitStatus
+	Stderr string // the (possibly truncated) STDERR of the tar process
+}
+
+func (err *CopyError) Error() string {
+	msg := err.Op + " failed: tar " + err.Status.String()
+	if err.Stderr != "" {
+		msg += ": " + strings.TrimSpace(err.Stderr)
+	}
+	return msg
+}
+
+// maxStderr bounds the amount of the STDERR of a tar process that's reported by a CopyError.
+const maxStderr = 4096
+
+// Upload extracts the given tar archive into the directory (which must exist) of the given container; the
+// archive is streamed, as it's read, to a `tar` process that runs in a nested container (of the given
+// parent container) via LAUNCH_NESTED_CONTAINER_SESSION. Relative directories are relative to the sandbox
+// of the container. The outcome of the tar process is determined via waiter (see Wait); if waiter is nil
+// then sender is used to wait. A CopyError is returned if tar fails. Upload is like `kubectl cp`, and
+// requires that the image of the container provides a tar binary.
+func Upload(ctx context.Context, sender, waiter calls.Sender, parent mesos.ContainerID, dir string, archive io.Reader) error {
+	return copyOp(ctx, sender, waiter, parent, "upload", tarCommand("-xf", "-", "-C", dir), archive, nil)
+}
+
+// Download writes a tar archive of the given path (a file or directory) of the given container to w; the
+// archive is streamed, as it's written by a `tar` process, see Upload. The entries of the archive are
+// named relative to the parent directory of the path.
+func Download(ctx context.Context, sender, waiter calls.Sender, parent mesos.ContainerID, p string, w io.Writer) error {
+	dir, base := path.Split(path.Clean(p))
+	if dir == "" {
+		dir = "."
+	}
+	return copyOp(ctx, sender, waiter, parent, "download", tarCommand("-cf", "-", "-C", dir, base), nil, w)
+}
+
+// CopyIn uploads the local file or directory src into the directory dir of the given container, see Upload.
+func CopyIn(ctx context.Context, sender, waiter calls.Sender, parent mesos.ContainerID, src, dir string) error {
+	pr, pw := io.Pipe()
+	go func() { pw.CloseWithError(Archive(pw, src)) }()
+	err := Upload(ctx, sender, waiter, parent, dir, pr)
+	pr.CloseWithError(io.ErrClosedPipe) // unblock Archive, if it's still writing
+	return err
+}
+
+// CopyOut downloads the file or directory p of the given container into the local directory dst, see
+// Download and Extract.
+func CopyOut(ctx context.Context, sender, waiter calls.Sender, parent mesos.ContainerID, p, dst string) error {
+	pr, pw := io.Pipe()
+	extracted := make(chan error, 1)
+	go func() {
+		err := Extract(pr, dst)
+		pr.CloseWithError(io.ErrClosedPipe) // fail the download, if the archive wasn't consumed
+		extracted <- err
+	}()
+	err := Download(ctx, sender, waiter, parent, p, pw)
+	pw.CloseWithError(err)
+	if xerr := <-extracted; err == nil {
+		err = xerr
+	}
+	return err
+}
+
+func tarCommand(args ...string) *mesos.CommandInfo {
+	return &mesos.CommandInfo{
+		Shell:     proto.Bool(false),
+		Value:     proto.String("tar"),
+		Arguments: append([]string{"tar"}, args...),
+	}
+}
+
+func copyOp(ctx context.Context, sender, waiter calls.Sender, parent mesos.ContainerID, op string, cmd *mesos.CommandInfo, stdin io.Reader, stdout io.Writer) error {
+	if waiter == nil {
+		waiter = sender
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	id := NewID(op + "-")
+	id.Parent = &parent
+	s, err := LaunchSession(ctx, sender, id, cmd, nil)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	type waitResult struct {
+		status *ExitStatus
+		err    error
+	}
+	waited := make(chan waitResult, 1)
+	go func() {
+		status, err := WaitNested(ctx, waiter, id)
+		waited <- waitResult{status, err}
+	}()
+	inputDone := make(chan error, 1)
+	go func() {
+		if stdin == nil {
+			s.CloseInput()
+			inputDone <- nil
+			return
+		}
+		r := &errReader{r: stdin}
+		s.Input(ctx, r, nil, nil)
+		err := r.error()
+		if err != nil && err != io.EOF {
+			s.Close() // abort: tar would otherwise wait for the remainder of the archive
+		}
+		inputDone <- err
+	}()
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+	stderr := &limitedBuffer{max: maxStderr}
+	if err = s.Output(stdout, stderr); err != nil {
+		s.Close()
+		if rerr := <-inputDone; rerr != nil && rerr != io.EOF {
+			return rerr
+		}
+		return err // e.g. stdout failed: the container is destroyed once the session is closed
+	}
+	w := <-waited
+	s.Close()
+	if rerr := <-inputDone; rerr != nil && rerr != io.EOF {
+		return rerr
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if !w.status.Success() {
+		return &CopyError{Op: op, Status: w.status, Stderr: stderr.String()}
+	}
+	return nil
+}
+
+// errReader records the error of the most recent read.
+type errReader struct {
+	r   io.Reader
+	m   sync.Mutex
+	err error
+}
+
+func (r *errReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.m.Lock()
+	r.err = err
+	r.m.Unlock()
+	return
+}
+
+func (r *errReader) error() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.err
+}
+
+// limitedBuffer retains the first max bytes that are written to it, discarding the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.Buffer.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// Archive writes a tar archive of the given local file or directory (recursively) to w; the entries are
+// named relative to the parent directory of src, as per Download. Only regular files, directories, and
+// symbolic links are archived.
+func Archive(w io.Writer, src string) error {
+	src = filepath.Clean(src)
+	root := filepath.Dir(src)
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		var link string
+		switch mode := fi.Mode(); {
+		case mode&os.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case !mode.IsRegular() && !mode.IsDir():
+			return nil
+		}
+		h, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		h.Name = filepath.ToSlash(name)
+		if fi.IsDir() {
+			h.Name += "/"
+		}
+		if err = tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Extract extracts the given tar archive into the local directory dst, which is created if necessary.
+// Entries that would be extracted outside of dst are rejected, as are entries other than regular files,
+// directories, and symbolic links. Symbolic links that were already extracted are resolved before every
+// write, so that no chain of them may lead outside of dst.
+func Extract(r io.Reader, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(dst)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(path.Clean("/" + h.Name))[1:]
+		if name == "" {
+			continue
+		}
+		target := filepath.Join(dst, name)
+		if parent, err := resolve(filepath.Dir(target)); err != nil {
+			return err
+		} else if !within(root, parent) {
+			return fmt.Errorf("refusing to extract %q outside of %q", h.Name, dst)
+		}
+		mode := os.FileMode(h.Mode).Perm()
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, mode|0700)
+		case tar.TypeReg, tar.TypeRegA:
+			err = extractFile(tr, target, mode)
+		case tar.TypeSymlink:
+			if filepath.IsAbs(h.Linkname) || !within(dst, filepath.Join(filepath.Dir(target), h.Linkname)) {
+				return fmt.Errorf("refusing to extract symbolic link %q to %q", h.Name, h.Linkname)
+			}
+			err = os.Symlink(h.Linkname, target)
+		default:
+			return fmt.Errorf("refusing to extract %q: unsupported type %q", h.Name, h.Typeflag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|oNoFollow, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// resolve returns the path of p once its symbolic links are evaluated; p need not exist, though only its
+// missing components may be anything but directories or symbolic links to them. Dangling symbolic links
+// are rejected, since writing through one would create its (unchecked) destination.
+func resolve(p string) (string, error) {
+	real, err := filepath.EvalSymlinks(p)
+	if err == nil || !os.IsNotExist(err) {
+		return real, err
+	}
+	if _, err = os.Lstat(p); err == nil {
+		return "", fmt.Errorf("refusing to extract beneath dangling symbolic link %q", p)
+	}
+	if parent := filepath.Dir(p); parent != p {
+		real, err = resolve(parent)
+		return filepath.Join(real, filepath.Base(p)), err
+	}
+	return p, nil
+}
+
+// within returns true if p is dir, or is beneath it.
+func within(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,210 @@
+package containers
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+// tarAgent emulates `tar` sessions in a sandbox directory, via Archive and Extract.
+type tarAgent struct {
+	sandbox string
+
+	m      sync.Mutex
+	args   []string
+	output chan agent.ProcessIO
+	exit   chan int32
+}
+
+func (a *tarAgent) finish(stdout []byte, stderr string, status int32) {
+	for len(stdout) > 0 {
+		n := 1000
+		if n > len(stdout) {
+			n = len(stdout)
+		}
+		a.output <- agent.ProcessIO{Type: agent.ProcessIO_DATA, Data: &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDOUT, Data: stdout[:n]}}
+		stdout = stdout[n:]
+	}
+	if stderr != "" {
+		a.output <- agent.ProcessIO{Type: agent.ProcessIO_DATA, Data: &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDERR, Data: []byte(stderr)}}
+	}
+	close(a.output)
+	a.exit <- status
+}
+
+func (a *tarAgent) Send(ctx context.Context, r calls.Request) (mesos.Response, error) {
+	c := r.Call()
+	switch c.GetType() {
+	case agent.Call_LAUNCH_NESTED_CONTAINER_SESSION:
+		a.m.Lock()
+		a.args = c.GetLaunchNestedContainerSession().GetCommand().GetArguments()
+		a.output, a.exit = make(chan agent.ProcessIO), make(chan int32, 1)
+		output := a.output
+		a.m.Unlock()
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			pio, ok := <-output
+			if !ok {
+				return io.EOF
+			}
+			*(u.(*agent.ProcessIO)) = pio
+			return nil
+		})}, nil
+	case agent.Call_ATTACH_CONTAINER_INPUT:
+		var stdin bytes.Buffer
+		for c = r.Call(); c != nil; c = r.Call() {
+			data := c.GetAttachContainerInput().GetProcessIO().GetData().GetData()
+			if len(data) == 0 {
+				break
+			}
+			stdin.Write(data)
+		}
+		args := a.args // tar -xf - -C dir | tar -cf - -C dir base
+		go func() {
+			switch dir := filepath.Join(a.sandbox, args[4]); args[1] {
+			case "-xf":
+				if err := Extract(&stdin, dir); err != nil {
+					a.finish(nil, err.Error(), 2<<8)
+					return
+				}
+				a.finish(nil, "", 0)
+			case "-cf":
+				var buf bytes.Buffer
+				if _, err := os.Stat(filepath.Join(dir, args[5])); err != nil {
+					a.finish(nil, "tar: "+args[5]+": Cannot stat: No such file or directory", 2<<8)
+					return
+				}
+				if err := Archive(&buf, filepath.Join(dir, args[5])); err != nil {
+					a.finish(nil, err.Error(), 2<<8)
+					return
+				}
+				a.finish(buf.Bytes(), "", 0)
+			}
+		}()
+		return nil, io.EOF
+	case agent.Call_WAIT_NESTED_CONTAINER:
+		a.m.Lock()
+		exit := a.exit
+		a.m.Unlock()
+		status := <-exit
+		resp := agent.Response{Type: agent.Response_WAIT_NESTED_CONTAINER,
+			WaitNestedContainer: &agent.Response_WaitNestedContainer{ExitStatus: &status}}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*agent.Response)) = resp
+			return nil
+		})}, nil
+	}
+	return nil, nil
+}
+
+func TestCopy(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "containers-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	var (
+		local   = filepath.Join(tmp, "local")
+		sandbox = filepath.Join(tmp, "sandbox")
+		a       = &tarAgent{sandbox: sandbox}
+		ctx     = context.Background()
+		parent  = mesos.ContainerID{Value: "task"}
+		content = bytes.Repeat([]byte("0123456789"), 1000) // spans many chunks
+	)
+	if err = os.MkdirAll(filepath.Join(local, "app", "conf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(local, "app", "conf", "app.yaml"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(sandbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = CopyIn(ctx, a, nil, parent, filepath.Join(local, "app"), "."); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(sandbox, "app", "conf", "app.yaml")); err != nil || !bytes.Equal(b, content) {
+		t.Fatalf("unexpected upload: %v", err)
+	}
+	if a.args[0] != "tar" || a.args[1] != "-xf" || a.args[4] != "." {
+		t.Fatalf("unexpected tar command %v", a.args)
+	}
+
+	out := filepath.Join(tmp, "out")
+	if err = CopyOut(ctx, a, nil, parent, "app/conf", out); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(out, "conf", "app.yaml")); err != nil || !bytes.Equal(b, content) {
+		t.Fatalf("unexpected download: %v", err)
+	}
+
+	err = CopyOut(ctx, a, nil, parent, "missing", out)
+	if cerr, ok := err.(*CopyError); !ok || cerr.Op != "download" || cerr.Status.ExitCode() != 2 {
+		t.Fatalf("expected a download CopyError instead of %v", err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "containers-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if err = os.Symlink("../../etc/passwd", filepath.Join(tmp, "evil")); err != nil {
+		t.Skip(err)
+	}
+	var buf bytes.Buffer
+	if err = Archive(&buf, filepath.Join(tmp, "evil")); err != nil {
+		t.Fatal(err)
+	}
+	if err = Extract(&buf, filepath.Join(tmp, "dst")); err == nil {
+		t.Fatal("expected an escaping symbolic link to be rejected")
+	}
+}
+
+func TestExtractSymlinkChain(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "containers-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// every link is within dst, as written, though esc resolves to its parent
+	var (
+		buf bytes.Buffer
+		tw  = tar.NewWriter(&buf)
+	)
+	for _, h := range []*tar.Header{
+		{Name: "d1/d2/d3/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "d1/d2/d3/up", Typeflag: tar.TypeSymlink, Linkname: "../../.."},
+		{Name: "esc", Typeflag: tar.TypeSymlink, Linkname: "d1/d2/d3/up/.."},
+		{Name: "esc/evil", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+	} {
+		if err = tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = tw.Write([]byte("evil")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = Extract(&buf, filepath.Join(tmp, "dst")); err == nil {
+		t.Fatal("expected a write through an escaping chain of symbolic links to be rejected")
+	}
+	if _, err = os.Lstat(filepath.Join(tmp, "evil")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be extracted outside of dst: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package containers
+
+import "syscall"
+
+// oNoFollow keeps Extract from writing files through symbolic links.
+const oNoFollow = syscall.O_NOFOLLOW
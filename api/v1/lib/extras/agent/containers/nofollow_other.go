@@ -0,0 +1,6 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package containers
+
+const oNoFollow = 0
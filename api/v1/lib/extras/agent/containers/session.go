@@ -0,0 +1,383 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+var (
+	// ErrDetached is returned by Session.Input once the detach sequence has been read from the input.
+	ErrDetached = errors.New("detached from the container session")
+
+	// ErrInputClosed is returned when writing to the input of a session after it's been closed.
+	ErrInputClosed = errors.New("the input of the container session is closed")
+
+	// ErrSessionDead is returned by Session.Output if nothing (not even a heartbeat) was received from the
+	// agent within the timeout of the session; see DeadAfter.
+	ErrSessionDead = errors.New("the container session is dead: the agent stopped responding")
+)
+
+// DefaultHeartbeatInterval is the default interval at which heartbeats are sent to the input of a session;
+// it's also the interval at which agents heartbeat the output of sessions.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// DefaultDetachSequence is CTRL-P, CTRL-Q: the same sequence that docker uses.
+var DefaultDetachSequence = []byte{0x10, 0x11}
+
+// Session is an interactive session with the processes of a container: its input is attached via an
+// ATTACH_CONTAINER_INPUT call, which streams data (and control messages, such as changes to the size of
+// the TTY) to the container, and its output is streamed back by the agent. Input funcs are safe to invoke
+// concurrently.
+type Session struct {
+	ID mesos.ContainerID
+
+	output    mesos.Response
+	cancel    context.CancelFunc
+	keepAlive time.Duration
+	deadAfter time.Duration
+	dead      int32 // atomic; set once the session has timed out
+
+	m        sync.Mutex
+	input    chan *agent.Call
+	closed   bool
+	inputErr chan error // receives the result of the ATTACH_CONTAINER_INPUT call, then closes
+}
+
+// SessionOpt is a functional option for a Session.
+type SessionOpt func(*Session)
+
+// KeepAlive configures the interval at which heartbeats are sent to the input of a session, for as long as
+// the input remains open; so that the agent (and any proxies in between) doesn't time out the connection
+// of an idle session, which would otherwise surface as an unexpected EOF of its output. Defaults to
+// DefaultHeartbeatInterval; zero disables heartbeats.
+func KeepAlive(interval time.Duration) SessionOpt {
+	return func(s *Session) { s.keepAlive = interval }
+}
+
+// DeadAfter configures the timeout after which a session, of which no output (not even a heartbeat) has
+// been received, is considered dead: it's closed, and Output returns ErrSessionDead. Since agents
+// heartbeat the output of sessions, a timeout of a few heartbeat intervals (e.g. 3 times
+// DefaultHeartbeatInterval) detects agents that crashed, or became partitioned, without severing the
+// connection. Defaults to zero, which disables the timeout.
+func DeadAfter(timeout time.Duration) SessionOpt {
+	return func(s *Session) { s.deadAfter = timeout }
+}
+
+// WithTTY returns a copy of the given container info (which may be nil) that allocates a TTY of the given
+// window size (if any) to the container. Processes that run with a TTY report all of their output as
+// STDOUT, and the input that's sent to them should be "raw": i.e. sent as it's read, without any line
+// buffering or echo, since the TTY of the container implements line discipline.
+func WithTTY(ci *mesos.ContainerInfo, ws *mesos.TTYInfo_WindowSize) *mesos.ContainerInfo {
+	if ci == nil {
+		ci = &mesos.ContainerInfo{Type: mesos.ContainerInfo_MESOS.Enum()}
+	} else {
+		ci = proto.Clone(ci).(*mesos.ContainerInfo)
+	}
+	ci.TTYInfo = &mesos.TTYInfo{WindowSize: ws}
+	return ci
+}
+
+// LaunchSession issues a LAUNCH_NESTED_CONTAINER_SESSION call via the given sender, launching a nested
+// container (see WithTTY) whose output is streamed by the returned session, and attaches to its input.
+// The agent destroys the container once the output stream of the session is closed. The session is
+// closed once ctx is done.
+func LaunchSession(ctx context.Context, sender calls.Sender, id mesos.ContainerID, cmd *mesos.CommandInfo, ci *mesos.ContainerInfo, opts ...SessionOpt) (*Session, error) {
+	return session(ctx, sender, id, calls.LaunchNestedContainerSession(id, cmd, ci), opts)
+}
+
+// Attach attaches a session to a running container, via ATTACH_CONTAINER_OUTPUT and ATTACH_CONTAINER_INPUT
+// calls. The container must have been launched with a TTY, or else its input must not have been attached
+// already. The session is closed once ctx is done.
+func Attach(ctx context.Context, sender calls.Sender, id mesos.ContainerID, opts ...SessionOpt) (*Session, error) {
+	return session(ctx, sender, id, calls.AttachContainerOutput(id), opts)
+}
+
+func session(ctx context.Context, sender calls.Sender, id mesos.ContainerID, c *agent.Call, opts []SessionOpt) (*Session, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	output, err := sender.Send(ctx, calls.NonStreaming(c))
+	if err != nil {
+		if output != nil {
+			output.Close()
+		}
+		cancel()
+		return nil, err
+	}
+	s := &Session{
+		ID:        id,
+		output:    output,
+		cancel:    cancel,
+		keepAlive: DefaultHeartbeatInterval,
+		input:     make(chan *agent.Call, 1),
+		inputErr:  make(chan error, 1),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	s.input <- calls.AttachContainerInput(id) // the first message of the stream must identify the container
+	go func() {
+		defer close(s.inputErr)
+		// blocks until the input chan closes, or the input stream is severed
+		err := calls.SendNoData(ctx, sender, calls.FromChan(s.input))
+		if err == io.EOF {
+			err = nil
+		}
+		s.inputErr <- err
+		cancel() // the output of the session isn't useful without its input
+	}()
+	if s.keepAlive > 0 {
+		go s.heartbeats(ctx)
+	}
+	return s, nil
+}
+
+// heartbeats sends heartbeats to the input of the session until the input is closed, or ctx is done.
+func (s *Session) heartbeats(ctx context.Context) {
+	t := time.NewTicker(s.keepAlive)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.Heartbeat(s.keepAlive); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Output decodes the output of the session, writing STDOUT data to stdout and STDERR data to stderr, until
+// the output stream ends (e.g. because the processes of the container terminated), which isn't reported
+// as an error. If the session times out (see DeadAfter) then it's closed, and ErrSessionDead is returned.
+// Messages of types that are unknown to this library (see encoding.UnknownEvent) are skipped. Output must
+// not be invoked concurrently.
+func (s *Session) Output(stdout, stderr io.Writer) error {
+	var timeout *time.Timer
+	if s.deadAfter > 0 {
+		timeout = time.AfterFunc(s.deadAfter, func() {
+			atomic.StoreInt32(&s.dead, 1)
+			s.Close() // unblocks Decode
+		})
+		defer timeout.Stop()
+	}
+	for {
+		var pio agent.ProcessIO
+		if err := s.output.Decode(&pio); err != nil && !isUnknownEvent(err) {
+			if atomic.LoadInt32(&s.dead) == 1 {
+				return ErrSessionDead
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if timeout != nil {
+			timeout.Reset(s.deadAfter)
+		}
+		if pio.GetType() != agent.ProcessIO_DATA {
+			continue
+		}
+		var w io.Writer
+		switch d := pio.GetData(); d.GetType() {
+		case agent.ProcessIO_Data_STDOUT:
+			w = stdout
+		case agent.ProcessIO_Data_STDERR:
+			w = stderr
+		}
+		if w == nil {
+			continue
+		}
+		b := pio.GetData().GetData()
+		n, err := w.Write(b)
+		if err == nil && n != len(b) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Write sends the given data to the STDIN of the session.
+func (s *Session) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil // empty data signals EOF, see CloseInput
+	}
+	if err := s.send(calls.AttachContainerInputData(append([]byte(nil), p...))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Resize changes the window size of the TTY of the session.
+func (s *Session) Resize(ws mesos.TTYInfo_WindowSize) error {
+	return s.send(calls.AttachContainerInputTTY(&mesos.TTYInfo{WindowSize: &ws}))
+}
+
+// Heartbeat sends a heartbeat control message, which keeps an otherwise idle input stream alive (e.g.
+// through proxies that time out idle connections).
+func (s *Session) Heartbeat(interval time.Duration) error {
+	return s.send(calls.AttachContainerInputHeartbeat(&agent.ProcessIO_Control_Heartbeat{
+		Interval: &mesos.DurationInfo{Nanoseconds: int64(interval)},
+	}))
+}
+
+// CloseInput signals EOF to the STDIN of the session and closes the input stream, then returns the
+// result of the ATTACH_CONTAINER_INPUT call. The output of the session continues until the processes of
+// the container terminate.
+func (s *Session) CloseInput() error {
+	s.m.Lock()
+	if !s.closed {
+		s.closed = true
+		select {
+		case s.input <- calls.AttachContainerInputData(nil):
+		case err := <-s.inputErr:
+			s.m.Unlock()
+			return err
+		}
+		close(s.input)
+	}
+	s.m.Unlock()
+	return <-s.inputErr
+}
+
+// Close closes the session: the input and output streams are severed.
+func (s *Session) Close() error {
+	s.cancel()
+	s.m.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.input)
+	}
+	s.m.Unlock()
+	return s.output.Close()
+}
+
+func (s *Session) send(c *agent.Call) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.closed {
+		return ErrInputClosed
+	}
+	select {
+	case s.input <- c:
+		return nil
+	case err, ok := <-s.inputErr:
+		if !ok || err == nil {
+			err = ErrInputClosed
+		}
+		return err
+	}
+}
+
+// Input copies the given reader to the STDIN of the session, and changes to the window size of the TTY
+// from the given chan (which may be nil), until the reader is exhausted (at which point the input of the
+// session is closed, see CloseInput), the detach sequence (if any; see DefaultDetachSequence) is read
+// (ErrDetached is returned, and the input of the session remains open), or ctx is done. Data is sent as
+// soon as it's read, which is the behavior that processes with a TTY expect of a terminal in raw mode.
+// Heartbeats are sent by the session itself, see KeepAlive.
+func (s *Session) Input(ctx context.Context, r io.Reader, winch <-chan mesos.TTYInfo_WindowSize, detach []byte) error {
+	type chunk struct {
+		data []byte
+		err  error
+	}
+	var (
+		chunks = make(chan chunk)
+		done   = make(chan struct{})
+	)
+	defer close(done)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			c := chunk{data: append([]byte(nil), buf[:n]...), err: err}
+			select {
+			case chunks <- c:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	var held []byte // the trailing bytes of the input, which may begin the detach sequence, yet to be sent
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ws, ok := <-winch:
+			if !ok {
+				winch = nil
+				continue
+			}
+			if err := s.Resize(ws); err != nil {
+				return err
+			}
+		case c := <-chunks:
+			data := c.data
+			if len(detach) > 0 {
+				data = append(held, data...)
+				if i := bytes.Index(data, detach); i >= 0 {
+					// forward the input that precedes the detach sequence
+					if i > 0 {
+						if _, err := s.Write(data[:i]); err != nil {
+							return err
+						}
+					}
+					return ErrDetached
+				}
+				// hold back a partial detach sequence, until the following chunk completes (or breaks) it;
+				// unless the input ends
+				held = nil
+				if c.err == nil {
+					n := partialSuffix(data, detach)
+					data, held = data[:len(data)-n], append([]byte(nil), data[len(data)-n:]...)
+				}
+			}
+			if len(data) > 0 {
+				if _, err := s.Write(data); err != nil {
+					return err
+				}
+			}
+			if c.err == io.EOF {
+				return s.CloseInput()
+			}
+			if c.err != nil {
+				return c.err
+			}
+		}
+	}
+}
+
+// partialSuffix returns the length of the longest suffix of b that's a proper prefix of seq.
+func partialSuffix(b, seq []byte) int {
+	n := len(seq) - 1
+	if n > len(b) {
+		n = len(b)
+	}
+	for ; n > 0; n-- {
+		if bytes.HasPrefix(seq, b[len(b)-n:]) {
+			return n
+		}
+	}
+	return 0
+}
+
+func isUnknownEvent(err error) bool {
+	_, ok := encoding.IsUnknownEvent(err)
+	return ok
+}
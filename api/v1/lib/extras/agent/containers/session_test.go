@@ -0,0 +1,204 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+// sessionAgent echoes the STDIN of a session to its STDOUT, and reports the control messages it receives
+// to its STDERR; the output ends once the input does.
+func sessionAgent(t *testing.T, launched *agent.Call_LaunchNestedContainerSession) calls.Sender {
+	output := make(chan agent.ProcessIO, 16)
+	return calls.SenderFunc(func(ctx context.Context, r calls.Request) (mesos.Response, error) {
+		c := r.Call()
+		switch c.GetType() {
+		case agent.Call_LAUNCH_NESTED_CONTAINER_SESSION:
+			*launched = *c.GetLaunchNestedContainerSession()
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				pio, ok := <-output
+				if !ok {
+					return io.EOF
+				}
+				*(u.(*agent.ProcessIO)) = pio
+				return nil
+			})}, nil
+		case agent.Call_ATTACH_CONTAINER_INPUT:
+			defer close(output)
+			if c.GetAttachContainerInput().GetContainerID().GetValue() != launched.ContainerID.Value {
+				t.Errorf("unexpected first input message %v", c)
+			}
+			for c = r.Call(); c != nil; c = r.Call() {
+				pio := c.GetAttachContainerInput().GetProcessIO()
+				out := agent.ProcessIO{Type: agent.ProcessIO_DATA, Data: &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDOUT}}
+				switch pio.GetType() {
+				case agent.ProcessIO_DATA:
+					if len(pio.GetData().GetData()) == 0 {
+						out.Data = &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDERR, Data: []byte("[eof]")}
+					} else {
+						out.Data.Data = pio.GetData().GetData()
+					}
+				case agent.ProcessIO_CONTROL:
+					out.Data = &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDERR, Data: []byte("[" + pio.GetControl().GetType().String() + "]")}
+				}
+				output <- out
+			}
+			return nil, io.EOF
+		}
+		t.Fatalf("unexpected call %v", c)
+		return nil, nil
+	})
+}
+
+func TestSession(t *testing.T) {
+	var (
+		launched agent.Call_LaunchNestedContainerSession
+		sender   = sessionAgent(t, &launched)
+		id       = mesos.ContainerID{Value: "exec", Parent: &mesos.ContainerID{Value: "task"}}
+		ctx      = context.Background()
+		ws       = &mesos.TTYInfo_WindowSize{Rows: 24, Columns: 80}
+	)
+	s, err := LaunchSession(ctx, sender, id, &mesos.CommandInfo{Value: proto.String("sh")}, WithTTY(nil, ws))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if launched.Container.GetTTYInfo().GetWindowSize().GetColumns() != 80 || launched.Container.GetType() != mesos.ContainerInfo_MESOS {
+		t.Fatalf("unexpected container info %v", launched.Container)
+	}
+
+	winch := make(chan mesos.TTYInfo_WindowSize, 1)
+	winch <- mesos.TTYInfo_WindowSize{Rows: 50, Columns: 100}
+	var stdout, stderr bytes.Buffer
+	outputDone := make(chan error, 1)
+	go func() { outputDone <- s.Output(&stdout, &stderr) }()
+
+	// the detach sequence, which spans reads, isn't forwarded; a partial sequence is
+	if err := s.Input(ctx, io.MultiReader(strings.NewReader("ls\x10"), strings.NewReader("\r\x10"), strings.NewReader("\x11pwd")), nil, DefaultDetachSequence); err != ErrDetached {
+		t.Fatalf("expected %v instead of %v", ErrDetached, err)
+	}
+	close(winch) // a closed chan doesn't end the input, though changes are no longer sent
+	if err := s.Input(ctx, strings.NewReader("exit\r"), winch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-outputDone; err != nil {
+		t.Fatal(err)
+	}
+	if out := stdout.String(); out != "ls\x10\rexit\r" {
+		t.Errorf("unexpected stdout %q", out)
+	}
+	if e := stderr.String(); e != "[eof]" && e != "[TTY_INFO][eof]" {
+		t.Errorf("unexpected stderr %q", e)
+	}
+	if _, err := s.Write([]byte("x")); err != ErrInputClosed {
+		t.Errorf("expected %v instead of %v", ErrInputClosed, err)
+	}
+}
+
+func TestSessionKeepAlive(t *testing.T) {
+	var (
+		launched agent.Call_LaunchNestedContainerSession
+		sender   = sessionAgent(t, &launched)
+		id       = mesos.ContainerID{Value: "exec", Parent: &mesos.ContainerID{Value: "task"}}
+	)
+	s, err := LaunchSession(context.Background(), sender, id, &mesos.CommandInfo{Value: proto.String("sh")}, nil, KeepAlive(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var stdout, stderr bytes.Buffer
+	outputDone := make(chan error, 1)
+	go func() { outputDone <- s.Output(&stdout, &stderr) }()
+
+	time.Sleep(20 * time.Millisecond) // an idle session
+	if err = s.CloseInput(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-outputDone; err != nil {
+		t.Fatal(err)
+	}
+	if e := stderr.String(); !strings.HasPrefix(e, "[HEARTBEAT]") || !strings.HasSuffix(e, "[eof]") {
+		t.Fatalf("expected heartbeats to precede the eof of the input: %q", e)
+	}
+}
+
+func TestSessionDead(t *testing.T) {
+	var (
+		closed = make(chan struct{})
+		once   sync.Once
+		sender = calls.SenderFunc(func(ctx context.Context, r calls.Request) (mesos.Response, error) {
+			if r.Call().GetType() == agent.Call_ATTACH_CONTAINER_INPUT {
+				for c := r.Call(); c != nil; c = r.Call() {
+				}
+				return nil, io.EOF
+			}
+			// the agent never responds, yet the connection remains open
+			return &mesos.ResponseWrapper{
+				Closer: mesos.CloseFunc(func() error { once.Do(func() { close(closed) }); return nil }),
+				Decoder: encoding.DecoderFunc(func(encoding.Unmarshaler) error {
+					<-closed
+					return io.ErrUnexpectedEOF
+				}),
+			}, nil
+		})
+	)
+	s, err := Attach(context.Background(), sender, mesos.ContainerID{Value: "c"}, KeepAlive(0), DeadAfter(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Output(ioutil.Discard, ioutil.Discard); err != ErrSessionDead {
+		t.Fatalf("expected %v instead of %v", ErrSessionDead, err)
+	}
+	if _, err = s.Write([]byte("x")); err != ErrInputClosed {
+		t.Fatalf("expected a dead session to be closed: %v", err)
+	}
+}
+
+func TestSessionUnknownOutput(t *testing.T) {
+	output := []agent.ProcessIO{
+		{Type: agent.ProcessIO_DATA, Data: &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDOUT, Data: []byte("a")}},
+		{Type: agent.ProcessIO_Type(100)},
+		{Type: agent.ProcessIO_DATA, Data: &agent.ProcessIO_Data{Type: agent.ProcessIO_Data_STDOUT, Data: []byte("b")}},
+	}
+	sender := calls.SenderFunc(func(ctx context.Context, r calls.Request) (mesos.Response, error) {
+		if r.Call().GetType() == agent.Call_ATTACH_CONTAINER_INPUT {
+			for r.Call() != nil {
+			}
+			return nil, io.EOF
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			if len(output) == 0 {
+				return io.EOF
+			}
+			pio := output[0]
+			output = output[1:]
+			*(u.(*agent.ProcessIO)) = pio
+			return encoding.CheckType(u, nil) // as decoded from protobuf
+		})}, nil
+	})
+	s, err := LaunchSession(context.Background(), sender, mesos.ContainerID{Value: "c"}, nil, nil, KeepAlive(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// messages of unknown types, e.g. added by a newer agent, are skipped
+	var stdout bytes.Buffer
+	if err = s.Output(&stdout, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if out := stdout.String(); out != "ab" {
+		t.Fatalf("unexpected stdout %q", out)
+	}
+}
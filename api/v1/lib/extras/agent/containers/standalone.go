@@ -0,0 +1,87 @@
+package containers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+)
+
+// ErrNoResources is returned when an attempt is made to launch a standalone container without resources.
+var ErrNoResources = errors.New("standalone containers must specify resources")
+
+// CleanupTimeout bounds the time that Run spends killing and removing a container once its context is done.
+var CleanupTimeout = 30 * time.Second
+
+// NewID returns a new, random, ID for a standalone container; the value of the ID begins with prefix.
+func NewID(prefix string) mesos.ContainerID {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return mesos.ContainerID{Value: prefix + hex.EncodeToString(b)}
+}
+
+// Launch issues a LAUNCH_CONTAINER call via the given sender, launching a standalone container (that
+// doesn't belong to any framework) on the agent.
+func Launch(ctx context.Context, sender calls.Sender, id mesos.ContainerID, cmd *mesos.CommandInfo, ci *mesos.ContainerInfo, rs ...mesos.Resource) error {
+	if id.Parent == nil && len(rs) == 0 {
+		return ErrNoResources
+	}
+	return send(ctx, sender, calls.LaunchContainer(id, cmd, ci, rs))
+}
+
+// Kill issues a KILL_CONTAINER call via the given sender. If signal is zero then the agent sends SIGKILL.
+func Kill(ctx context.Context, sender calls.Sender, id mesos.ContainerID, signal int32) error {
+	c := calls.KillContainer(id)
+	if signal != 0 {
+		c.KillContainer.Signal = &signal
+	}
+	return send(ctx, sender, c)
+}
+
+// Remove issues a REMOVE_CONTAINER call via the given sender, cleaning up the runtime directories (and
+// sandbox) of a terminated container.
+func Remove(ctx context.Context, sender calls.Sender, id mesos.ContainerID) error {
+	return send(ctx, sender, calls.RemoveContainer(id))
+}
+
+// Run launches a standalone container via sender, waits for it to terminate via waiter (see Wait), and then
+// removes it. If waiter is nil then sender is used to wait. If ctx is done before the container terminates
+// then the container is killed (and removed, see CleanupTimeout) and ctx.Err() is returned.
+func Run(ctx context.Context, sender, waiter calls.Sender, id mesos.ContainerID, cmd *mesos.CommandInfo, ci *mesos.ContainerInfo, rs ...mesos.Resource) (*ExitStatus, error) {
+	if waiter == nil {
+		waiter = sender
+	}
+	if err := Launch(ctx, sender, id, cmd, ci, rs...); err != nil {
+		return nil, err
+	}
+	status, err := Wait(ctx, waiter, id)
+	if err != nil && ctx.Err() != nil {
+		// ctx is done: clean up regardless.
+		cleanup, cancel := context.WithTimeout(context.Background(), CleanupTimeout)
+		defer cancel()
+		if kerr := Kill(cleanup, sender, id, 0); kerr == nil {
+			Wait(cleanup, waiter, id)
+		}
+		Remove(cleanup, sender, id)
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return status, Remove(ctx, sender, id)
+}
+
+func send(ctx context.Context, sender calls.Sender, c *agent.Call) error {
+	resp, err := sender.Send(ctx, calls.NonStreaming(c))
+	if resp != nil {
+		resp.Close()
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package containers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		id     = NewID("diag-")
+		issued []agent.Call_Type
+		code   = int32(0)
+		sender = calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+			c := r.Call()
+			issued = append(issued, c.GetType())
+			var resp agent.Response
+			switch c.GetType() {
+			case agent.Call_LAUNCH_CONTAINER:
+				if lc := c.GetLaunchContainer(); lc.ContainerID.Value != id.Value || len(lc.Resources) == 0 {
+					t.Errorf("unexpected launch %v", lc)
+				}
+			case agent.Call_WAIT_CONTAINER:
+				resp = agent.Response{Type: agent.Response_WAIT_CONTAINER, WaitContainer: &agent.Response_WaitContainer{ExitStatus: &code}}
+			}
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				*(u.(*agent.Response)) = resp
+				return nil
+			})}, nil
+		})
+		rs  = mesos.Resources{resources.NewCPUs(0.1).Resource, resources.NewMemory(32).Resource}
+		cmd = &mesos.CommandInfo{Value: proto.String("ls")}
+	)
+	if !strings.HasPrefix(id.Value, "diag-") || id.Value == NewID("diag-").Value {
+		t.Fatalf("unexpected container ID %v", id.Value)
+	}
+	if err := Launch(context.Background(), sender, id, cmd, nil); err != ErrNoResources {
+		t.Fatalf("expected %v instead of %v", ErrNoResources, err)
+	}
+	status, err := Run(context.Background(), sender, nil, id, cmd, nil, rs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Success() {
+		t.Fatalf("unexpected exit status %v", status)
+	}
+	want := []agent.Call_Type{agent.Call_LAUNCH_CONTAINER, agent.Call_WAIT_CONTAINER, agent.Call_REMOVE_CONTAINER}
+	if !reflect.DeepEqual(issued, want) {
+		t.Fatalf("expected calls %v instead of %v", want, issued)
+	}
+}
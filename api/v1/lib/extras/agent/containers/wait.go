@@ -0,0 +1,150 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+)
+
+// ExitStatus is the outcome of a container, as reported by the agent in response to a WAIT_CONTAINER or
+// WAIT_NESTED_CONTAINER call.
+type ExitStatus struct {
+	// Status is the wait(2) status of the lead process of the container, or nil if it's unknown.
+	Status *int32
+	// State, Reason, Limitation, and Message may be populated if the agent terminated the container.
+	State      *mesos.TaskState
+	Reason     *mesos.TaskStatus_Reason
+	Limitation *mesos.TaskResourceLimitation
+	Message    string
+}
+
+// Exited returns true if the lead process of the container terminated normally, i.e. not by a signal.
+func (s *ExitStatus) Exited() bool { return s.Status != nil && *s.Status&0x7f == 0 }
+
+// ExitCode returns the exit code of the lead process if it Exited, otherwise -1.
+func (s *ExitStatus) ExitCode() int {
+	if !s.Exited() {
+		return -1
+	}
+	return int(*s.Status>>8) & 0xff
+}
+
+// Signaled returns true if the lead process of the container was terminated by a signal.
+func (s *ExitStatus) Signaled() bool {
+	if s.Status == nil {
+		return false
+	}
+	sig := *s.Status & 0x7f
+	return sig != 0 && sig != 0x7f
+}
+
+// Signal returns the number of the signal that terminated the lead process if it was Signaled,
+// otherwise -1.
+func (s *ExitStatus) Signal() int {
+	if !s.Signaled() {
+		return -1
+	}
+	return int(*s.Status & 0x7f)
+}
+
+// Success returns true if the lead process of the container exited with a zero exit code.
+func (s *ExitStatus) Success() bool { return s.ExitCode() == 0 }
+
+func (s *ExitStatus) String() string {
+	switch {
+	case s.Exited():
+		return fmt.Sprintf("exited with code %d", s.ExitCode())
+	case s.Signaled():
+		return fmt.Sprintf("terminated by signal %d", s.Signal())
+	case s.State != nil:
+		return fmt.Sprintf("%v (%v): %s", s.GetState(), s.Reason, s.Message)
+	default:
+		return "unknown exit status"
+	}
+}
+
+// GetState returns the state reported by the agent, or else TASK_UNKNOWN.
+func (s *ExitStatus) GetState() mesos.TaskState {
+	if s.State != nil {
+		return *s.State
+	}
+	return mesos.TASK_UNKNOWN
+}
+
+// Wait issues a WAIT_CONTAINER call via the given sender and blocks until the agent reports that the
+// (nested or standalone) container has terminated, or until ctx is done.
+//
+// WAIT calls are long-polls: the agent doesn't respond until the container terminates. The sender should
+// therefore be backed by an HTTP client that doesn't impose a response header timeout, see
+// httpcli.ResponseHeaderTimeout; a very long deadline may be imposed via ctx instead.
+func Wait(ctx context.Context, sender calls.Sender, id mesos.ContainerID) (*ExitStatus, error) {
+	r, err := wait(ctx, sender, calls.WaitContainer(id))
+	if err != nil {
+		return nil, err
+	}
+	wc := r.GetWaitContainer()
+	if wc == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", agent.Call_WAIT_CONTAINER, r.GetType())
+	}
+	return &ExitStatus{
+		Status:     wc.ExitStatus,
+		State:      wc.State,
+		Reason:     wc.Reason,
+		Limitation: wc.Limitation,
+		Message:    wc.GetMessage(),
+	}, nil
+}
+
+// WaitNested is like Wait, but issues a WAIT_NESTED_CONTAINER call; it's intended for agents that predate
+// WAIT_CONTAINER (Mesos 1.5).
+func WaitNested(ctx context.Context, sender calls.Sender, id mesos.ContainerID) (*ExitStatus, error) {
+	r, err := wait(ctx, sender, calls.WaitNestedContainer(id))
+	if err != nil {
+		return nil, err
+	}
+	wc := r.GetWaitNestedContainer()
+	if wc == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", agent.Call_WAIT_NESTED_CONTAINER, r.GetType())
+	}
+	return &ExitStatus{
+		Status:     wc.ExitStatus,
+		State:      wc.State,
+		Reason:     wc.Reason,
+		Limitation: wc.Limitation,
+		Message:    wc.GetMessage(),
+	}, nil
+}
+
+func wait(ctx context.Context, sender calls.Sender, c *agent.Call) (*agent.Response, error) {
+	type result struct {
+		r   *agent.Response
+		err error
+	}
+	// the response is decoded by a separate goroutine so that a cancelled ctx is honored even if the
+	// sender blocks regardless of it.
+	ch := make(chan result, 1)
+	go func() {
+		var res result
+		resp, err := sender.Send(ctx, calls.NonStreaming(c))
+		if resp != nil {
+			defer resp.Close()
+		}
+		if err == nil {
+			var r agent.Response
+			if err = resp.Decode(&r); err == nil {
+				res.r = &r
+			}
+		}
+		res.err = err
+		ch <- res
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		return res.r, res.err
+	}
+}
@@ -0,0 +1,95 @@
+package containers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+)
+
+func TestExitStatus(t *testing.T) {
+	status := func(i int32) *int32 { return &i }
+	for i, tc := range []struct {
+		status           *int32
+		exited, signaled bool
+		code, signal     int
+	}{
+		{nil, false, false, -1, -1},
+		{status(0), true, false, 0, -1},
+		{status(3 << 8), true, false, 3, -1},
+		{status(9), false, true, -1, 9},
+		{status(15 | 0x80), false, true, -1, 15}, // core dumped
+	} {
+		s := &ExitStatus{Status: tc.status}
+		if s.Exited() != tc.exited || s.Signaled() != tc.signaled || s.ExitCode() != tc.code || s.Signal() != tc.signal {
+			t.Errorf("test case %d failed: unexpected %v", i, s)
+		}
+		if s.Success() != (tc.exited && tc.code == 0) {
+			t.Errorf("test case %d failed: unexpected success %v", i, s.Success())
+		}
+	}
+}
+
+func TestWait(t *testing.T) {
+	var (
+		id     = cid("a", "x")
+		code   = int32(1 << 8)
+		state  = mesos.TASK_FAILED
+		sender = calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+			c := r.Call()
+			var resp agent.Response
+			switch c.GetType() {
+			case agent.Call_WAIT_CONTAINER:
+				if Key(c.GetWaitContainer().ContainerID) != Key(id) {
+					t.Errorf("unexpected container %v", c.GetWaitContainer().ContainerID)
+				}
+				resp = agent.Response{Type: agent.Response_WAIT_CONTAINER, WaitContainer: &agent.Response_WaitContainer{
+					ExitStatus: &code,
+					State:      &state,
+				}}
+			case agent.Call_WAIT_NESTED_CONTAINER:
+				resp = agent.Response{Type: agent.Response_WAIT_NESTED_CONTAINER, WaitNestedContainer: &agent.Response_WaitNestedContainer{
+					ExitStatus: &code,
+				}}
+			}
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				*(u.(*agent.Response)) = resp
+				return nil
+			})}, nil
+		})
+	)
+	s, err := Wait(context.Background(), sender, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ExitCode() != 1 || s.GetState() != state {
+		t.Fatalf("unexpected exit status %v", s)
+	}
+	s, err = WaitNested(context.Background(), sender, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ExitCode() != 1 || s.State != nil {
+		t.Fatalf("unexpected exit status %v", s)
+	}
+}
+
+func TestWaitCancel(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		sender  = calls.SenderFunc(func(context.Context, calls.Request) (mesos.Response, error) {
+			<-release // a sender that doesn't honor ctx
+			return nil, context.Canceled
+		})
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	)
+	defer close(release)
+	defer cancel()
+	if _, err := Wait(ctx, sender, cid("a")); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v instead of %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package fleet fans operator API calls (e.g. PRUNE_IMAGES, or GET_METRICS) out across many agents
+// concurrently, by way of a bounded pool of workers, and aggregates the responses and errors of the
+// agents: the pattern of every fleet-management script. The agents of a cluster may be discovered via the
+// operator API of the master, see Discover.
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+// DefaultConcurrency is the default number of calls that Run issues concurrently.
+const DefaultConcurrency = 16
+
+type (
+	// Target is an agent to which Run sends a call.
+	Target struct {
+		AgentID mesos.AgentID
+		Name    string       // identifies the agent in results and errors; e.g. its hostname
+		Sender  calls.Sender // e.g. an httpagent.Sender of the agent
+	}
+
+	// CallFunc returns the call to send to the given target; a nil call skips the target.
+	CallFunc func(Target) *agent.Call
+
+	// Result is the outcome of the call of a target.
+	Result struct {
+		Target Target
+		Call   *agent.Call
+		// Response is the decoded response of the agent, if the call yields one (see Responds).
+		Response *agent.Response
+		// Err reports the failure of the call; or the cancelation of the batch (i.e. the error of the
+		// context) for calls that weren't sent.
+		Err      error
+		Duration time.Duration
+	}
+
+	// Error aggregates the failed calls of a batch.
+	Error struct {
+		Total    int      // the number of calls of the batch
+		Failures []Result // ordered as the targets
+	}
+
+	// Option is a functional option for Run; it returns an "undo" option when applied.
+	Option func(*config) Option
+
+	config struct {
+		concurrency int
+		timeout     time.Duration
+		deadline    time.Duration
+	}
+)
+
+// Each returns a CallFunc that sends the given call to every target.
+func Each(c *agent.Call) CallFunc {
+	return func(Target) *agent.Call { return c }
+}
+
+// Concurrency bounds the number of calls that are in flight at any time; defaults to DefaultConcurrency.
+func Concurrency(n int) Option {
+	return func(c *config) Option {
+		old := c.concurrency
+		c.concurrency = n
+		return Concurrency(old)
+	}
+}
+
+// Timeout bounds the duration of each call, including the decoding of its response; zero (the default)
+// leaves calls unbounded, but for the deadline of the batch.
+func Timeout(d time.Duration) Option {
+	return func(c *config) Option {
+		old := c.timeout
+		c.timeout = d
+		return Timeout(old)
+	}
+}
+
+// Deadline bounds the duration of the batch: calls that haven't completed by then are canceled, and
+// calls that haven't been sent aren't; zero (the default) leaves the batch bounded only by its context.
+func Deadline(d time.Duration) Option {
+	return func(c *config) Option {
+		old := c.deadline
+		c.deadline = d
+		return Deadline(old)
+	}
+}
+
+// Responds returns true if agents respond to calls of the given type with a Response, rather than an
+// empty "202 Accepted" response.
+func Responds(t agent.Call_Type) bool {
+	_, ok := agent.Response_Type_value[t.String()]
+	return ok && t != agent.Call_UNKNOWN
+}
+
+// Run sends the calls generated by the CallFunc to the given targets, concurrently, and returns a result
+// per target that's called, ordered as the targets. Streaming calls (e.g. ATTACH_CONTAINER_OUTPUT) aren't
+// supported. If any call fails then the returned error is an *Error that reports the failed calls.
+func Run(ctx context.Context, targets []Target, f CallFunc, opts ...Option) ([]Result, error) {
+	cfg := config{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = 1
+	}
+	if cfg.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.deadline)
+		defer cancel()
+	}
+
+	var results []Result
+	for _, t := range targets {
+		if c := f(t); c != nil {
+			results = append(results, Result{Target: t, Call: c})
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		pending = make(chan *Result)
+		workers = cfg.concurrency
+	)
+	if workers > len(results) {
+		workers = len(results)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range pending {
+				cfg.send(ctx, r)
+			}
+		}()
+	}
+enqueue:
+	for i := range results {
+		select {
+		case pending <- &results[i]:
+		case <-ctx.Done():
+			for j := i; j < len(results); j++ {
+				results[j].Err = ctx.Err()
+			}
+			break enqueue
+		}
+	}
+	close(pending)
+	wg.Wait()
+
+	var failures []Result
+	for _, r := range results {
+		if r.Err != nil {
+			failures = append(failures, r)
+		}
+	}
+	if failures != nil {
+		return results, &Error{Total: len(results), Failures: failures}
+	}
+	return results, nil
+}
+
+func (cfg *config) send(ctx context.Context, r *Result) {
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	defer func() { r.Duration = time.Since(start) }()
+
+	if err := ctx.Err(); err != nil {
+		r.Err = err
+		return
+	}
+	resp, err := r.Target.Sender.Send(ctx, calls.NonStreaming(r.Call))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		r.Err = err
+		return
+	}
+	if !Responds(r.Call.GetType()) {
+		return
+	}
+	var x agent.Response
+	if err = resp.Decode(&x); err != nil {
+		r.Err = err
+		return
+	}
+	r.Response = &x
+}
+
+// Error implements error; it reports the number of failed calls, and the first few failures.
+func (e *Error) Error() string {
+	const max = 3
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d of %d calls failed", len(e.Failures), e.Total)
+	for i, r := range e.Failures {
+		if i == max {
+			fmt.Fprintf(&buf, "; and %d more", len(e.Failures)-max)
+			break
+		}
+		fmt.Fprintf(&buf, "; %s: %v", r.Target.Name, r.Err)
+	}
+	return buf.String()
+}
+
+// Discover issues a GET_AGENTS call via the given sender of the operator API of the master, and returns a
+// Target for every active agent, named after its hostname. The connect func returns the Sender of an
+// agent; e.g. an httpagent.Sender of the agent's AgentEndpoint.
+func Discover(ctx context.Context, sender mastercalls.Sender, connect func(*mesos.AgentInfo) (calls.Sender, error)) ([]Target, error) {
+	agents, err := mastercalls.SendGetAgents(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	for i := range agents.Agents {
+		a := &agents.Agents[i]
+		if !a.Active {
+			continue
+		}
+		s, err := connect(&a.AgentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s (%s): %v", a.AgentInfo.GetID().GetValue(), a.AgentInfo.GetHostname(), err)
+		}
+		t := Target{Name: a.AgentInfo.GetHostname(), Sender: s}
+		if id := a.AgentInfo.GetID(); id != nil {
+			t.AgentID = *id
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
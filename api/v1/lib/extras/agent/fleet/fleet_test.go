@@ -0,0 +1,170 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+// fakeAgents records the concurrency of the calls of its agents.
+type fakeAgents struct {
+	m        sync.Mutex
+	inflight int
+	max      int
+}
+
+// agent returns the Sender of an agent that responds after the given delay, or fails if broken.
+func (f *fakeAgents) agent(name string, delay time.Duration, broken bool) Target {
+	return Target{Name: name, AgentID: mesos.AgentID{Value: name}, Sender: calls.SenderFunc(func(ctx context.Context, r calls.Request) (mesos.Response, error) {
+		f.m.Lock()
+		if f.inflight++; f.inflight > f.max {
+			f.max = f.inflight
+		}
+		f.m.Unlock()
+		defer func() {
+			f.m.Lock()
+			f.inflight--
+			f.m.Unlock()
+		}()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if broken {
+			return nil, errors.New("broken")
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			if r.Call().GetType() != agent.Call_GET_VERSION {
+				return errors.New("unexpected decode")
+			}
+			*(u.(*agent.Response)) = agent.Response{
+				Type:       agent.Response_GET_VERSION,
+				GetVersion: &agent.Response_GetVersion{VersionInfo: mesos.VersionInfo{Version: name}},
+			}
+			return nil
+		})}, nil
+	})}
+}
+
+func TestRun(t *testing.T) {
+	var (
+		f       fakeAgents
+		targets []Target
+	)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		targets = append(targets, f.agent(name, 10*time.Millisecond, name == "c"))
+	}
+	results, err := Run(context.Background(), targets, Each(calls.GetVersion()), Concurrency(2))
+	if len(results) != 6 {
+		t.Fatalf("expected a result per target: %+v", results)
+	}
+	if f.max != 2 {
+		t.Errorf("expected 2 concurrent calls instead of %d", f.max)
+	}
+	e, ok := err.(*Error)
+	if !ok || e.Total != 6 || len(e.Failures) != 1 || e.Failures[0].Target.Name != "c" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := e.Error(); s != "1 of 6 calls failed; c: broken" {
+		t.Errorf("unexpected error string %q", s)
+	}
+	for _, r := range results {
+		if r.Target.Name == "c" {
+			continue
+		}
+		if r.Err != nil || r.Response.GetGetVersion().GetVersionInfo().Version != r.Target.Name || r.Duration == 0 {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	}
+
+	// calls without responses aren't decoded; targets may be skipped
+	results, err = Run(context.Background(), targets, func(t Target) *agent.Call {
+		if t.Name == "c" {
+			return nil
+		}
+		return calls.PruneImages(nil)
+	})
+	if err != nil || len(results) != 5 {
+		t.Fatalf("unexpected results: %+v, %v", results, err)
+	}
+	for _, r := range results {
+		if r.Response != nil {
+			t.Errorf("unexpected response: %+v", r)
+		}
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	var (
+		f       fakeAgents
+		targets = []Target{
+			f.agent("fast", 0, false),
+			f.agent("slow", time.Second, false),
+			f.agent("queued", 0, false),
+		}
+	)
+	results, err := Run(context.Background(), targets, Each(calls.GetVersion()), Concurrency(1), Deadline(50*time.Millisecond))
+	e, ok := err.(*Error)
+	if !ok || len(e.Failures) != 2 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Err != nil || results[1].Err != context.DeadlineExceeded || results[2].Err != context.DeadlineExceeded {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	// per-call timeouts don't cancel the batch
+	results, err = Run(context.Background(), targets, Each(calls.GetVersion()), Timeout(50*time.Millisecond))
+	if e, ok := err.(*Error); !ok || len(e.Failures) != 1 || results[1].Err != context.DeadlineExceeded {
+		t.Fatalf("unexpected results: %+v, %v", results, err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	info := func(id string) mesos.AgentInfo {
+		return mesos.AgentInfo{ID: &mesos.AgentID{Value: id}, Hostname: id + ".example.com"}
+	}
+	sender := mastercalls.SenderFunc(func(_ context.Context, r mastercalls.Request) (mesos.Response, error) {
+		if r.Call().GetType() != master.Call_GET_AGENTS {
+			t.Fatalf("unexpected call: %v", r.Call())
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = master.Response{
+				Type: master.Response_GET_AGENTS,
+				GetAgents: &master.Response_GetAgents{Agents: []master.Response_GetAgents_Agent{
+					{AgentInfo: info("a1"), Active: true},
+					{AgentInfo: info("a2")},
+					{AgentInfo: info("a3"), Active: true},
+				}},
+			}
+			return nil
+		})}, nil
+	})
+	var f fakeAgents
+	targets, err := Discover(context.Background(), sender, func(a *mesos.AgentInfo) (calls.Sender, error) {
+		return f.agent(a.Hostname, 0, false).Sender, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].AgentID.Value != "a1" || targets[1].Name != "a3.example.com" {
+		t.Fatalf("expected the active agents: %+v", targets)
+	}
+
+	_, err = Discover(context.Background(), sender, func(a *mesos.AgentInfo) (calls.Sender, error) {
+		return nil, errors.New("unreachable")
+	})
+	if err == nil || !strings.Contains(err.Error(), "a1") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+// Package artifacts implements an HTTP server that frameworks may use to serve executor binaries (and
+// other task artifacts) to the Mesos fetcher.
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+var (
+	ErrNotListening     = errors.New("artifact server is not listening")
+	ErrAlreadyListening = errors.New("artifact server is already listening")
+)
+
+type (
+	// Option is a functional configuration option for a Server; it returns an Option that acts as an
+	// "undo" if applied to the same Server.
+	Option func(*Server) Option
+
+	// Server serves files over HTTP(S). Server funcs are safe to invoke concurrently.
+	Server struct {
+		address        string
+		advertisedHost string
+		certFile       string
+		keyFile        string
+		decorator      func(http.Handler) http.Handler
+
+		m         sync.Mutex
+		mux       *http.ServeMux
+		listener  net.Listener
+		srv       *http.Server
+		baseURL   string
+		artifacts map[string]*Artifact
+	}
+
+	// Artifact is a file that's served by a Server.
+	Artifact struct {
+		// Name is the base name of the file, and the path at which it's served.
+		Name string
+		// Path is the location of the file on the local file system.
+		Path string
+		// Checksum is the hex-encoded SHA-256 digest of the file contents, computed when the artifact
+		// was added to the server.
+		Checksum string
+		// URL is the location from which the artifact may be downloaded. The URL embeds the checksum so
+		// that it changes along with the contents of the file.
+		URL string
+	}
+
+	// URIOpt is a functional option for a CommandInfo_URI.
+	URIOpt func(*mesos.CommandInfo_URI)
+)
+
+// Address sets the host:port on which the server listens. Defaults to ":0", in which case the server
+// listens on all interfaces using a system-assigned port.
+func Address(addr string) Option {
+	return func(s *Server) Option {
+		old := s.address
+		s.address = addr
+		return Address(old)
+	}
+}
+
+// AdvertisedHost sets the host name (or IP) used in artifact URLs. Defaults to the host of the listen
+// address or, if that's unspecified, the host name reported by the kernel.
+func AdvertisedHost(host string) Option {
+	return func(s *Server) Option {
+		old := s.advertisedHost
+		s.advertisedHost = host
+		return AdvertisedHost(old)
+	}
+}
+
+// TLS configures the server to serve HTTPS using the given certificate and key files.
+func TLS(certFile, keyFile string) Option {
+	return func(s *Server) Option {
+		oldCert, oldKey := s.certFile, s.keyFile
+		s.certFile, s.keyFile = certFile, keyFile
+		return TLS(oldCert, oldKey)
+	}
+}
+
+// Decorate sets a func that decorates the handler of the server; useful for, e.g. counting downloads.
+func Decorate(f func(http.Handler) http.Handler) Option {
+	return func(s *Server) Option {
+		old := s.decorator
+		s.decorator = f
+		return Decorate(old)
+	}
+}
+
+// New returns a new, unstarted, Server.
+func New(opts ...Option) *Server {
+	s := &Server{
+		address:   ":0",
+		mux:       http.NewServeMux(),
+		artifacts: make(map[string]*Artifact),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	return s
+}
+
+// Listen binds the listener of the server, such that the URLs of the artifacts that are subsequently
+// added are known. Serve must be invoked to actually serve requests.
+func (s *Server) Listen() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.listener != nil {
+		return ErrAlreadyListening
+	}
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	if s.advertisedHost != "" {
+		host = s.advertisedHost
+	} else if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		if host, err = os.Hostname(); err != nil {
+			listener.Close()
+			return err
+		}
+	}
+	scheme := "http"
+	if s.certFile != "" {
+		scheme = "https"
+	}
+	var h http.Handler = s.mux
+	if s.decorator != nil {
+		h = s.decorator(h)
+	}
+	s.listener = listener
+	s.srv = &http.Server{Handler: h}
+	s.baseURL = scheme + "://" + net.JoinHostPort(host, port)
+	return nil
+}
+
+// Port returns the port that the server is listening on, or else zero if the server isn't listening.
+func (s *Server) Port() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.listener == nil {
+		return 0
+	}
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Add computes the checksum of the file at the given path and serves it at a path derived from the base
+// name of the file. The server must be listening.
+func (s *Server) Add(path string) (*Artifact, error) {
+	checksum, err := Checksum(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate artifact: %+v", err)
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.listener == nil {
+		return nil, ErrNotListening
+	}
+	name := filepath.Base(path)
+	if _, ok := s.artifacts[name]; ok {
+		return nil, fmt.Errorf("an artifact named %q is already being served", name)
+	}
+	a := &Artifact{
+		Name:     name,
+		Path:     path,
+		Checksum: checksum,
+		URL:      s.baseURL + "/" + url.PathEscape(name) + "?sha256=" + checksum,
+	}
+	s.mux.Handle("/"+name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", strconv.Quote(checksum))
+		http.ServeFile(w, r, path)
+	}))
+	s.artifacts[name] = a
+	return a, nil
+}
+
+// Serve serves requests until the server is shut down, in which case http.ErrServerClosed is returned.
+// The server must be listening.
+func (s *Server) Serve() error {
+	s.m.Lock()
+	var (
+		srv      = s.srv
+		listener = s.listener
+	)
+	s.m.Unlock()
+	if srv == nil {
+		return ErrNotListening
+	}
+	if s.certFile != "" {
+		return srv.ServeTLS(listener, s.certFile, s.keyFile)
+	}
+	return srv.Serve(listener)
+}
+
+// Shutdown gracefully shuts down the server, waiting (until the context is cancelled) for in-flight
+// downloads to complete.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.m.Lock()
+	srv := s.srv
+	s.m.Unlock()
+	if srv == nil {
+		return ErrNotListening
+	}
+	return srv.Shutdown(ctx)
+}
+
+// Checksum returns the hex-encoded SHA-256 digest of the contents of the file at the given path.
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// URI returns a CommandInfo_URI for the artifact. The URI is cacheable, since the artifact URL changes
+// along with the checksum, and is fetched into a file named after the artifact.
+func (a *Artifact) URI(opts ...URIOpt) mesos.CommandInfo_URI {
+	u := mesos.CommandInfo_URI{
+		Value:      a.URL,
+		Cache:      proto.Bool(true),
+		OutputFile: proto.String(a.Name),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&u)
+		}
+	}
+	return u
+}
+
+// Command returns the command by which an agent of the given operating system (as per runtime.GOOS)
+// executes the fetched artifact, without a shell: i.e. the path of the artifact relative to the sandbox,
+// with a separator of that operating system. Artifacts for Windows agents must have an extension (e.g.
+// "executor.exe").
+func (a *Artifact) Command(goos string) string {
+	if goos == "windows" {
+		return `.\` + a.Name
+	}
+	return "./" + a.Name
+}
+
+// Executable marks the fetched artifact as executable.
+func Executable() URIOpt {
+	return func(u *mesos.CommandInfo_URI) { u.Executable = proto.Bool(true) }
+}
+
+// Extract determines whether the fetcher extracts the artifact, if it's an archive.
+func Extract(b bool) URIOpt {
+	return func(u *mesos.CommandInfo_URI) { u.Extract = proto.Bool(b) }
+}
+
+// Cache determines whether the fetcher caches the artifact.
+func Cache(b bool) URIOpt {
+	return func(u *mesos.CommandInfo_URI) { u.Cache = proto.Bool(b) }
+}
@@ -0,0 +1,99 @@
+package artifacts
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "executor")
+	if err = ioutil.WriteFile(path, []byte("hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(Address("127.0.0.1:0"))
+	if _, err = s.Add(path); err != ErrNotListening {
+		t.Fatalf("expected ErrNotListening instead of %v", err)
+	}
+	if err = s.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Port() == 0 {
+		t.Fatal("expected a non-zero port")
+	}
+	a, err := s.Add(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const sum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if a.Name != "executor" || a.Checksum != sum {
+		t.Fatalf("unexpected artifact %+v", a)
+	}
+	if _, err = s.Add(path); err == nil {
+		t.Fatal("expected an error for a duplicate artifact")
+	}
+	u := a.URI(Executable())
+	if u.Value != a.URL || !u.GetExecutable() || !u.GetCache() || u.GetOutputFile() != "executor" {
+		t.Fatalf("unexpected URI %+v", u)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Serve() }()
+
+	resp, err := http.Get(a.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Fatalf("unexpected artifact contents %q", b)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"`+sum+`"` {
+		t.Fatalf("unexpected ETag %q", etag)
+	}
+
+	// names that aren't valid in URLs, e.g. of windows executables, are escaped
+	winPath := filepath.Join(dir, "my executor.exe")
+	if err = ioutil.WriteFile(winPath, []byte("hello"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := s.Add(winPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd := w.Command("windows"); cmd != `.\my executor.exe` {
+		t.Fatalf("unexpected command %q", cmd)
+	}
+	if cmd := a.Command("linux"); cmd != "./executor" {
+		t.Fatalf("unexpected command %q", cmd)
+	}
+	resp, err = http.Get(w.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %q of %q", resp.Status, w.URL)
+	}
+
+	if err = s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-errCh; err != http.ErrServerClosed {
+		t.Fatalf("expected ErrServerClosed instead of %v", err)
+	}
+}
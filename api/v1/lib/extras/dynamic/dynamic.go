@@ -0,0 +1,348 @@
+// Package dynamic gives access, by field number, to the fields of encoded protobuf messages: in particular
+// to fields that were added by a newer version of Mesos, which the types of this library don't (yet)
+// declare. Such fields aren't retained by the generated types when a message is decoded (the protos are
+// compiled without unrecognized field support) and so they're read from the encoded message instead; see
+// Codec, which captures the encoded messages of a stream, and encoding.UnknownEvent. Unknown reports the
+// fields of an encoded message that aren't declared by the descriptor of a generated type.
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+)
+
+var (
+	// ErrMalformed is returned for encoded messages that can't be parsed.
+	ErrMalformed = errors.New("dynamic: malformed protobuf message")
+
+	// ErrWireType is returned when the value of a field is read as a type that's incompatible with the
+	// wire type of the field.
+	ErrWireType = errors.New("dynamic: incompatible wire type")
+)
+
+type (
+	// Message is an encoded protobuf message.
+	Message []byte
+
+	// Field is an occurrence of a field within an encoded message. Fields that are repeated occur
+	// multiple times (unless packed, see Packed); as per protobuf, the last occurrence of a singular field
+	// wins.
+	Field struct {
+		Number   int32
+		WireType int // e.g. proto.WireVarint, or proto.WireBytes
+		value    []byte
+		x        uint64 // the value of varint and fixed fields
+	}
+
+	// UnknownField is a field of an encoded message that isn't declared by the descriptor of a type.
+	UnknownField struct {
+		// Path locates the field: the names of the (declared) fields of the enclosing messages, followed
+		// by the number of the field; e.g. "update.status.42".
+		Path string
+		Field
+	}
+)
+
+// Fields returns the fields of the message, in order.
+func (m Message) Fields() ([]Field, error) {
+	var fields []Field
+	for b := []byte(m); len(b) > 0; {
+		key, n := proto.DecodeVarint(b)
+		if n == 0 || key>>3 == 0 || key>>3 > math.MaxInt32 {
+			return nil, ErrMalformed
+		}
+		b = b[n:]
+		f := Field{Number: int32(key >> 3), WireType: int(key & 7)}
+		switch f.WireType {
+		case proto.WireVarint:
+			if f.x, n = proto.DecodeVarint(b); n == 0 {
+				return nil, ErrMalformed
+			}
+		case proto.WireFixed64, proto.WireFixed32:
+			n = 8
+			if f.WireType == proto.WireFixed32 {
+				n = 4
+			}
+			if len(b) < n {
+				return nil, ErrMalformed
+			}
+			for i := n - 1; i >= 0; i-- {
+				f.x = f.x<<8 | uint64(b[i])
+			}
+		case proto.WireBytes:
+			size, k := proto.DecodeVarint(b)
+			if k == 0 || size > uint64(len(b)-k) {
+				return nil, ErrMalformed
+			}
+			f.value = b[k : k+int(size)]
+			n = k + int(size)
+		default:
+			// groups are deprecated, and aren't used by Mesos
+			return nil, ErrMalformed
+		}
+		b = b[n:]
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Lookup returns the occurrences of the field that's located by the given path of field numbers: all but
+// the last number identify (singular) fields of nested messages, whose last occurrences are descended
+// into. Returns nil if the field doesn't occur.
+func (m Message) Lookup(path ...int32) ([]Field, error) {
+	if len(path) == 0 {
+		return nil, errors.New("dynamic: empty path")
+	}
+	fields, err := m.Fields()
+	if err != nil {
+		return nil, err
+	}
+	var found []Field
+	for _, f := range fields {
+		if f.Number == path[0] {
+			found = append(found, f)
+		}
+	}
+	if len(path) == 1 || len(found) == 0 {
+		return found, nil
+	}
+	nested, err := found[len(found)-1].Message()
+	if err != nil {
+		return nil, err
+	}
+	return nested.Lookup(path[1:]...)
+}
+
+// Get returns the last occurrence of the field that's located by the given path, see Lookup; ok is false
+// if the field doesn't occur.
+func (m Message) Get(path ...int32) (f Field, ok bool, err error) {
+	found, err := m.Lookup(path...)
+	if err != nil || len(found) == 0 {
+		return f, false, err
+	}
+	return found[len(found)-1], true, nil
+}
+
+func (f Field) varint() (uint64, error) {
+	if f.WireType != proto.WireVarint {
+		return 0, ErrWireType
+	}
+	return f.x, nil
+}
+
+// Uint64 returns the value of a uint64, uint32, or enum field.
+func (f Field) Uint64() (uint64, error) { return f.varint() }
+
+// Int64 returns the value of an int64, or int32, field.
+func (f Field) Int64() (int64, error) {
+	x, err := f.varint()
+	return int64(x), err
+}
+
+// Sint64 returns the value of a sint64, or sint32, (zigzag encoded) field.
+func (f Field) Sint64() (int64, error) {
+	x, err := f.varint()
+	return int64(x>>1) ^ -int64(x&1), err
+}
+
+// Bool returns the value of a bool field.
+func (f Field) Bool() (bool, error) {
+	x, err := f.varint()
+	return x != 0, err
+}
+
+// Fixed64 returns the value of a fixed64, or sfixed64, field.
+func (f Field) Fixed64() (uint64, error) {
+	if f.WireType != proto.WireFixed64 {
+		return 0, ErrWireType
+	}
+	return f.x, nil
+}
+
+// Fixed32 returns the value of a fixed32, or sfixed32, field.
+func (f Field) Fixed32() (uint32, error) {
+	if f.WireType != proto.WireFixed32 {
+		return 0, ErrWireType
+	}
+	return uint32(f.x), nil
+}
+
+// Double returns the value of a double field.
+func (f Field) Double() (float64, error) {
+	x, err := f.Fixed64()
+	return math.Float64frombits(x), err
+}
+
+// Float returns the value of a float field.
+func (f Field) Float() (float32, error) {
+	x, err := f.Fixed32()
+	return math.Float32frombits(x), err
+}
+
+// Bytes returns the value of a bytes field; the value references the encoded message.
+func (f Field) Bytes() ([]byte, error) {
+	if f.WireType != proto.WireBytes {
+		return nil, ErrWireType
+	}
+	return f.value, nil
+}
+
+// Text returns the value of a string field.
+func (f Field) Text() (string, error) {
+	b, err := f.Bytes()
+	return string(b), err
+}
+
+// Message returns the value of an embedded message field.
+func (f Field) Message() (Message, error) {
+	b, err := f.Bytes()
+	return Message(b), err
+}
+
+// Packed returns the values of a packed, repeated, varint field (e.g. of enums); an unpacked occurrence
+// of the field yields its single value.
+func (f Field) Packed() ([]uint64, error) {
+	if f.WireType == proto.WireVarint {
+		return []uint64{f.x}, nil
+	}
+	b, err := f.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	var values []uint64
+	for len(b) > 0 {
+		x, n := proto.DecodeVarint(b)
+		if n == 0 {
+			return nil, ErrMalformed
+		}
+		values = append(values, x)
+		b = b[n:]
+	}
+	return values, nil
+}
+
+// Unmarshal decodes the value of an embedded message field into the given message; e.g. into a type of a
+// newer version of the protos than that of the enclosing message.
+func (f Field) Unmarshal(m proto.Message) error {
+	b, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, m)
+}
+
+// Unknown returns the fields of the encoded message that aren't declared by the descriptor of the given
+// type, nor by the descriptors of the types of its (declared) embedded message fields. Embedded messages
+// whose types aren't registered with the proto package (e.g. map entries) aren't descended into.
+func Unknown(t descriptor.Message, m Message) ([]UnknownField, error) {
+	return unknown(t, "", m, nil)
+}
+
+func unknown(t descriptor.Message, prefix string, m Message, result []UnknownField) ([]UnknownField, error) {
+	fields, err := m.Fields()
+	if err != nil {
+		return nil, err
+	}
+	declared := describe(t)
+	for _, f := range fields {
+		field, ok := declared[f.Number]
+		if !ok {
+			result = append(result, UnknownField{Path: prefix + strconv.Itoa(int(f.Number)), Field: f})
+			continue
+		}
+		if field.GetType() != descriptor.FieldDescriptorProto_TYPE_MESSAGE || f.WireType != proto.WireBytes {
+			continue
+		}
+		nested := newMessage(field.GetTypeName())
+		if nested == nil {
+			continue
+		}
+		if result, err = unknown(nested, prefix+field.GetName()+".", Message(f.value), result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+var descriptors = struct {
+	sync.Mutex
+	fields map[reflect.Type]map[int32]*descriptor.FieldDescriptorProto
+}{fields: make(map[reflect.Type]map[int32]*descriptor.FieldDescriptorProto)}
+
+// describe returns the declared fields of the type, by number; descriptors are decompressed and parsed
+// once per type.
+func describe(t descriptor.Message) map[int32]*descriptor.FieldDescriptorProto {
+	rt := reflect.TypeOf(t)
+	descriptors.Lock()
+	defer descriptors.Unlock()
+	fields, ok := descriptors.fields[rt]
+	if !ok {
+		_, md := descriptor.ForMessage(t)
+		fields = make(map[int32]*descriptor.FieldDescriptorProto, len(md.GetField()))
+		for _, f := range md.GetField() {
+			fields[f.GetNumber()] = f
+		}
+		descriptors.fields[rt] = fields
+	}
+	return fields
+}
+
+// newMessage returns a new message of the registered type of the given (fully qualified) name, or else
+// nil if the type isn't registered, or has no descriptor.
+func newMessage(typeName string) descriptor.Message {
+	rt := proto.MessageType(strings.TrimPrefix(typeName, "."))
+	if rt == nil {
+		return nil
+	}
+	m, _ := reflect.New(rt.Elem()).Interface().(descriptor.Message)
+	return m
+}
+
+// Codec returns a copy of the given (protobuf) codec whose decoders invoke the given func for every
+// message that's decoded, or that's reported as an encoding.UnknownEvent, along with the message as it
+// was encoded; the encoded message may be retained.
+func Codec(c encoding.Codec, f func(u encoding.Unmarshaler, m Message)) encoding.Codec {
+	newDecoder := c.NewDecoder
+	c.NewDecoder = func(s encoding.Source) encoding.Decoder {
+		var (
+			r     = s()
+			frame []byte
+			d     = newDecoder(func() framing.Reader {
+				return framing.ReaderFunc(func() ([]byte, error) {
+					b, err := r.ReadFrame()
+					frame = b
+					return b, err
+				})
+			})
+		)
+		return encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			frame = nil
+			err := d.Decode(u)
+			if _, ok := encoding.IsUnknownEvent(err); (err == nil || ok) && frame != nil {
+				f(u, append(Message(nil), frame...))
+			}
+			return err
+		})
+	}
+	return c
+}
+
+// String returns a human-readable rendering of the field, e.g. for logging.
+func (f UnknownField) String() string {
+	switch f.WireType {
+	case proto.WireBytes:
+		return fmt.Sprintf("%s: %q", f.Path, f.value)
+	default:
+		return fmt.Sprintf("%s: %d", f.Path, f.x)
+	}
+}
@@ -0,0 +1,118 @@
+package dynamic_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/dynamic"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func appendBytes(b []byte, number int, v []byte) []byte {
+	b = append(b, proto.EncodeVarint(uint64(number<<3|proto.WireBytes))...)
+	b = append(b, proto.EncodeVarint(uint64(len(v)))...)
+	return append(b, v...)
+}
+
+func appendVarint(b []byte, number int, x uint64) []byte {
+	b = append(b, proto.EncodeVarint(uint64(number<<3|proto.WireVarint))...)
+	return append(b, proto.EncodeVarint(x)...)
+}
+
+// futureUpdate returns an UPDATE event, as encoded by a newer version of Mesos: with a string field (42)
+// of the status, and a varint field (100) of the event, that this version of the protos doesn't declare.
+func futureUpdate(t *testing.T) dynamic.Message {
+	status, err := proto.Marshal(&mesos.TaskStatus{TaskID: mesos.TaskID{Value: "task-0"}, State: mesos.TASK_RUNNING.Enum()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status = appendBytes(status, 42, []byte("future"))
+	event, err := proto.Marshal(&scheduler.Event{Type: scheduler.Event_UPDATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event = appendBytes(event, 5, appendBytes(nil, 1, status))
+	return appendVarint(event, 100, 7)
+}
+
+func TestMessage(t *testing.T) {
+	m := futureUpdate(t)
+
+	// the fields are discarded by the generated types
+	var e scheduler.Event
+	if err := proto.Unmarshal(m, &e); err != nil || e.GetUpdate().GetStatus().TaskID.Value != "task-0" {
+		t.Fatalf("unexpected event: %v, %v", e, err)
+	}
+
+	f, ok, err := m.Get(5, 1, 42)
+	if err != nil || !ok {
+		t.Fatalf("expected the nested field: %v, %v", ok, err)
+	}
+	if s, err := f.Text(); err != nil || s != "future" {
+		t.Fatalf("unexpected value: %q, %v", s, err)
+	}
+	if _, err = f.Uint64(); err != dynamic.ErrWireType {
+		t.Fatalf("expected ErrWireType instead of %v", err)
+	}
+	if f, ok, err = m.Get(100); err != nil || !ok {
+		t.Fatalf("expected the field: %v, %v", ok, err)
+	}
+	if x, err := f.Uint64(); err != nil || x != 7 {
+		t.Fatalf("unexpected value: %d, %v", x, err)
+	}
+	if _, ok, err = m.Get(5, 1, 43); err != nil || ok {
+		t.Fatalf("unexpected field: %v, %v", ok, err)
+	}
+	if _, err = dynamic.Message(m[:len(m)-1]).Fields(); err != dynamic.ErrMalformed {
+		t.Fatalf("expected ErrMalformed instead of %v", err)
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	unknown, err := dynamic.Unknown(&scheduler.Event{}, futureUpdate(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unknown) != 2 || unknown[0].Path != "update.status.42" || unknown[1].Path != "100" {
+		t.Fatalf("unexpected unknown fields: %v", unknown)
+	}
+	if s := unknown[0].String(); s != `update.status.42: "future"` {
+		t.Fatalf("unexpected rendering: %s", s)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	var (
+		frames   = []dynamic.Message{futureUpdate(t)}
+		captured []dynamic.Message
+		codec    = dynamic.Codec(codecs.ByMediaType[codecs.MediaTypeProtobuf], func(u encoding.Unmarshaler, m dynamic.Message) {
+			captured = append(captured, m)
+		})
+		dec = codec.NewDecoder(func() framing.Reader {
+			return framing.ReaderFunc(func() ([]byte, error) {
+				if len(frames) == 0 {
+					return nil, io.EOF
+				}
+				f := frames[0]
+				frames = frames[1:]
+				return f, nil
+			})
+		})
+		e scheduler.Event
+	)
+	if err := dec.Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&e); err != io.EOF {
+		t.Fatalf("expected io.EOF instead of %v", err)
+	}
+	if len(captured) != 1 || !bytes.Equal(captured[0], futureUpdate(t)) {
+		t.Fatalf("expected the encoded event to be captured: %v", captured)
+	}
+}
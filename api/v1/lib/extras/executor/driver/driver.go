@@ -0,0 +1,460 @@
+// Package driver adapts the callback-oriented Executor interface of the legacy (v0) mesos-go executor
+// driver to the v1 executor API, so that legacy executors may migrate to mesos-go v1 without a rewrite:
+// only the types of the callbacks change, from those of the v0 mesos package to those of the v1 mesos
+// package (e.g. a SlaveInfo becomes an AgentInfo). The Driver subscribes to the agent, re-subscribes with
+// the unacknowledged tasks and status updates of the executor upon disconnection (if the framework
+// checkpoints), and dispatches events to the callbacks of the Executor.
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	"github.com/mesos/mesos-go/api/v1/lib/executor/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/executor/config"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/httpexec"
+)
+
+const (
+	// Path is the path of the executor API endpoint of an agent.
+	Path = "/api/v1/executor"
+
+	// HTTPTimeout bounds the duration of the (non-streaming) calls of the driver.
+	HTTPTimeout = 10 * time.Second
+
+	// MinBackoff is the minimum period between subscription attempts, unless the framework configured a
+	// shorter SubscriptionBackoffMax.
+	MinBackoff = time.Second
+)
+
+var (
+	// ErrNotRunning is returned when invoking a driver func that requires a running driver.
+	ErrNotRunning = errors.New("executor driver is not running")
+
+	// ErrStagingUpdate is returned when sending a TASK_STAGING status update, which only Mesos may send.
+	ErrStagingUpdate = errors.New("executors may not send TASK_STAGING status updates")
+
+	// ErrRecoveryTimeout is reported by Join when the driver aborts because it failed to re-subscribe to
+	// the agent within the recovery timeout of the executor.
+	ErrRecoveryTimeout = errors.New("failed to re-subscribe to the agent within the recovery timeout")
+
+	// ErrDisconnected is reported by Join when the driver aborts because it was disconnected from the
+	// agent, and the framework doesn't checkpoint (so the agent won't recover the executor).
+	ErrDisconnected = errors.New("disconnected from the agent")
+
+	errResubscribe = errors.New("received an ERROR event, will attempt to re-subscribe")
+)
+
+// Status is the state of a driver, as per the mesos.Status of the v0 driver.
+type Status int
+
+const (
+	StatusNotStarted Status = iota
+	StatusRunning
+	StatusAborted
+	StatusStopped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusNotStarted:
+		return "DRIVER_NOT_STARTED"
+	case StatusRunning:
+		return "DRIVER_RUNNING"
+	case StatusAborted:
+		return "DRIVER_ABORTED"
+	case StatusStopped:
+		return "DRIVER_STOPPED"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+type (
+	// Executor is the callback interface of legacy executors. Callbacks are invoked serially, by the
+	// goroutine of the driver; callbacks may invoke the funcs of the driver, except for Join and Run.
+	Executor interface {
+		// Registered is invoked once the executor has subscribed to the agent, for the first time.
+		Registered(ExecutorDriver, *mesos.ExecutorInfo, *mesos.FrameworkInfo, *mesos.AgentInfo)
+		// Reregistered is invoked once the executor has re-subscribed to a (recovered) agent.
+		Reregistered(ExecutorDriver, *mesos.AgentInfo)
+		// Disconnected is invoked when the subscription of a checkpointing executor is lost; the driver
+		// attempts to re-subscribe until the recovery timeout of the executor expires.
+		Disconnected(ExecutorDriver)
+		LaunchTask(ExecutorDriver, *mesos.TaskInfo)
+		KillTask(ExecutorDriver, *mesos.TaskID)
+		FrameworkMessage(ExecutorDriver, string)
+		// Shutdown is invoked when the agent asks the executor to shut down, after which the driver stops;
+		// or when the driver gives up on re-subscribing, after which it aborts. The executor should have
+		// killed its tasks, and sent their status updates, by the time that it returns.
+		Shutdown(ExecutorDriver)
+		// Error is invoked when the agent reports an error; the driver re-subscribes, if it may.
+		Error(ExecutorDriver, string)
+	}
+
+	// ExecutorDriver is the interface of the v0 executor driver, as it's invoked by executors.
+	ExecutorDriver interface {
+		Start() (Status, error)
+		Stop() (Status, error)
+		Abort() (Status, error)
+		Join() (Status, error)
+		Run() (Status, error)
+		SendStatusUpdate(*mesos.TaskStatus) (Status, error)
+		SendFrameworkMessage(string) (Status, error)
+	}
+
+	// Option is a functional configuration option for a Driver; it returns an Option that acts as an
+	// "undo" if applied to the same Driver.
+	Option func(*Driver) Option
+
+	// Driver implements ExecutorDriver via the v1 executor API. Driver funcs are safe to invoke
+	// concurrently.
+	Driver struct {
+		executor           Executor
+		cfg                config.Config
+		sender, subscriber calls.Sender
+		uuids              calls.UUIDs
+
+		m              sync.Mutex
+		status         Status
+		err            error
+		ctx            context.Context
+		cancel         context.CancelFunc
+		done           chan struct{}
+		unackedTasks   map[mesos.TaskID]mesos.TaskInfo
+		unackedUpdates map[string]executor.Call_Update
+	}
+)
+
+var _ = ExecutorDriver(&Driver{})
+
+// Senders configures the senders of the calls of the driver, and of its (streaming) SUBSCRIBE calls;
+// by default both are httpexec senders of the agent endpoint of the configuration. The driver stamps
+// the framework and executor IDs upon calls.
+func Senders(sender, subscriber calls.Sender) Option {
+	return func(d *Driver) Option {
+		oldSender, oldSubscriber := d.sender, d.subscriber
+		d.sender, d.subscriber = sender, subscriber
+		return Senders(oldSender, oldSubscriber)
+	}
+}
+
+// UUIDs configures the generator of the UUIDs of status updates; defaults to calls.RandomUUID.
+func UUIDs(u calls.UUIDs) Option {
+	return func(d *Driver) Option {
+		old := d.uuids
+		d.uuids = u
+		return UUIDs(old)
+	}
+}
+
+// New returns a driver, that's not yet started, of the given executor; the configuration is typically
+// that of config.FromEnv.
+func New(x Executor, cfg config.Config, opts ...Option) (*Driver, error) {
+	d := &Driver{
+		executor:       x,
+		cfg:            cfg,
+		uuids:          calls.RandomUUID,
+		done:           make(chan struct{}),
+		unackedTasks:   make(map[mesos.TaskID]mesos.TaskInfo),
+		unackedUpdates: make(map[string]executor.Call_Update),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(d)
+		}
+	}
+	if d.sender == nil || d.subscriber == nil {
+		codec, ok := codecs.ByName(cfg.Codec)
+		if !ok {
+			return nil, fmt.Errorf("unsupported codec %q", cfg.Codec)
+		}
+		u := url.URL{Scheme: "http", Host: cfg.AgentEndpoint, Path: Path}
+		cli := httpcli.New(
+			httpcli.Endpoint(u.String()),
+			httpcli.Codec(codec),
+			httpcli.Do(httpcli.With(httpcli.Timeout(HTTPTimeout))),
+		)
+		if d.sender == nil {
+			d.sender = httpexec.NewSender(cli.Send)
+		}
+		if d.subscriber == nil {
+			d.subscriber = httpexec.NewSender(cli.Send, httpcli.Close(true))
+		}
+	}
+	callOptions := executor.CallOptions{calls.Framework(cfg.FrameworkID), calls.Executor(cfg.ExecutorID)}
+	d.sender = calls.SenderWith(d.sender, callOptions...)
+	d.subscriber = calls.SenderWith(d.subscriber, callOptions...)
+	return d, nil
+}
+
+// Start starts the driver, which subscribes to the agent in the background.
+func (d *Driver) Start() (Status, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.status != StatusNotStarted {
+		return d.status, fmt.Errorf("cannot start an executor driver in state %v", d.status)
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.status = StatusRunning
+	go d.run(d.ctx)
+	return d.status, nil
+}
+
+// Stop stops the driver: its subscription is closed, and no further callbacks are invoked.
+func (d *Driver) Stop() (Status, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	switch d.status {
+	case StatusRunning:
+		d.status = StatusStopped
+		d.cancel()
+		return d.status, nil
+	case StatusAborted:
+		return d.status, nil
+	}
+	return d.status, ErrNotRunning
+}
+
+// Abort aborts the driver: like Stop, but Join reports StatusAborted.
+func (d *Driver) Abort() (Status, error) {
+	return d.abort(nil)
+}
+
+func (d *Driver) abort(err error) (Status, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.status != StatusRunning {
+		return d.status, ErrNotRunning
+	}
+	d.status = StatusAborted
+	d.err = err
+	d.cancel()
+	return d.status, nil
+}
+
+// Join blocks until the driver is stopped, or aborted; the error reports the cause of an abort that
+// wasn't requested by way of Abort.
+func (d *Driver) Join() (Status, error) {
+	d.m.Lock()
+	status := d.status
+	d.m.Unlock()
+	if status == StatusNotStarted {
+		return status, ErrNotRunning
+	}
+	<-d.done
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.status, d.err
+}
+
+// Run starts the driver, and blocks until it's stopped, or aborted.
+func (d *Driver) Run() (Status, error) {
+	if status, err := d.Start(); err != nil {
+		return status, err
+	}
+	return d.Join()
+}
+
+// SendStatusUpdate sends a status update for a task of the executor. The status is assigned a UUID, and
+// the executor ID, source, and timestamp unless they're specified; until it's acknowledged by the agent,
+// the update is included in the re-subscriptions of the driver.
+func (d *Driver) SendStatusUpdate(status *mesos.TaskStatus) (Status, error) {
+	if status.GetState() == mesos.TASK_STAGING {
+		return d.Status(), ErrStagingUpdate
+	}
+	s := *status
+	if s.ExecutorID == nil {
+		s.ExecutorID = &mesos.ExecutorID{Value: d.cfg.ExecutorID}
+	}
+	if s.Source == nil {
+		s.Source = mesos.SOURCE_EXECUTOR.Enum()
+	}
+	if s.Timestamp == nil {
+		now := float64(time.Now().UnixNano()) / float64(time.Second)
+		s.Timestamp = &now
+	}
+	if len(s.UUID) == 0 {
+		s.UUID = d.uuids()
+	}
+
+	d.m.Lock()
+	st, ctx := d.status, d.ctx
+	if st == StatusRunning {
+		d.unackedUpdates[string(s.UUID)] = executor.Call_Update{Status: s}
+	}
+	d.m.Unlock()
+	if st != StatusRunning {
+		return st, ErrNotRunning
+	}
+
+	// an update that can't be sent is included in the next subscription
+	err := calls.SendNoData(ctx, d.sender, calls.NonStreaming(calls.Update(s)))
+	return d.Status(), err
+}
+
+// SendFrameworkMessage sends a message to the scheduler of the framework. Messages are delivered on a
+// best-effort basis.
+func (d *Driver) SendFrameworkMessage(data string) (Status, error) {
+	d.m.Lock()
+	st, ctx := d.status, d.ctx
+	d.m.Unlock()
+	if st != StatusRunning {
+		return st, ErrNotRunning
+	}
+
+	err := calls.SendNoData(ctx, d.sender, calls.NonStreaming(calls.Message([]byte(data))))
+	return d.Status(), err
+}
+
+// Status returns the current state of the driver.
+func (d *Driver) Status() Status {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.status
+}
+
+// run (re-)subscribes to the agent, and dispatches events, until the context is done; or until it may no
+// longer re-subscribe, in which case the executor is shut down and the driver is aborted.
+func (d *Driver) run(ctx context.Context) {
+	defer close(d.done)
+	var (
+		registered   bool
+		disconnected = time.Now()
+		max          = d.cfg.SubscriptionBackoffMax
+		min          = MinBackoff
+		wait         time.Duration
+	)
+	if max > 0 && max < min {
+		min = max
+	}
+	for {
+		connected, err := d.subscribe(ctx, &registered)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			disconnected = time.Now()
+			wait = 0
+			if d.cfg.Checkpoint {
+				d.executor.Disconnected(d)
+			}
+		}
+		if !d.cfg.Checkpoint || time.Since(disconnected) > d.cfg.RecoveryTimeout {
+			cause := ErrRecoveryTimeout
+			if !d.cfg.Checkpoint {
+				cause = ErrDisconnected
+			}
+			if err != nil {
+				cause = fmt.Errorf("%v: %v", cause, err)
+			}
+			d.executor.Shutdown(d)
+			d.abort(cause)
+			return
+		}
+		if wait *= 2; wait < min {
+			wait = min
+		} else if max > 0 && wait > max {
+			wait = max
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// subscribe subscribes to the agent, and dispatches events until the subscription ends; the result
+// reports whether the subscription was established.
+func (d *Driver) subscribe(ctx context.Context, registered *bool) (connected bool, err error) {
+	resp, err := d.subscriber.Send(ctx, calls.NonStreaming(calls.Subscribe(d.unacknowledged())))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return false, err
+	}
+	for {
+		var e executor.Event
+		if err = resp.Decode(&e); err != nil {
+			if _, ok := encoding.IsUnknownEvent(err); ok {
+				continue // e.g. an event of a type that was added by a newer version of Mesos
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			return connected, ctx.Err()
+		}
+		if e.GetType() == executor.Event_SUBSCRIBED {
+			connected = true
+		}
+		if err = d.handle(&e, registered); err != nil {
+			return
+		}
+	}
+}
+
+func (d *Driver) handle(e *executor.Event, registered *bool) error {
+	switch e.GetType() {
+	case executor.Event_SUBSCRIBED:
+		s := e.GetSubscribed()
+		if *registered {
+			d.executor.Reregistered(d, &s.AgentInfo)
+		} else {
+			*registered = true
+			d.executor.Registered(d, &s.ExecutorInfo, &s.FrameworkInfo, &s.AgentInfo)
+		}
+	case executor.Event_LAUNCH:
+		task := e.GetLaunch().GetTask()
+		d.m.Lock()
+		d.unackedTasks[task.TaskID] = task
+		d.m.Unlock()
+		d.executor.LaunchTask(d, &task)
+	case executor.Event_LAUNCH_GROUP:
+		d.executor.Error(d, "task groups aren't supported by legacy executors")
+	case executor.Event_KILL:
+		id := e.GetKill().GetTaskID()
+		d.executor.KillTask(d, &id)
+	case executor.Event_ACKNOWLEDGED:
+		ack := e.GetAcknowledged()
+		d.m.Lock()
+		delete(d.unackedTasks, ack.GetTaskID())
+		delete(d.unackedUpdates, string(ack.GetUUID()))
+		d.m.Unlock()
+	case executor.Event_MESSAGE:
+		d.executor.FrameworkMessage(d, string(e.GetMessage().GetData()))
+	case executor.Event_SHUTDOWN:
+		d.executor.Shutdown(d)
+		d.Stop()
+	case executor.Event_ERROR:
+		d.executor.Error(d, e.GetError().GetMessage())
+		return errResubscribe
+	}
+	return nil
+}
+
+// unacknowledged returns the launched tasks, and the status updates, that the agent has yet to
+// acknowledge; ordered by task ID, and by timestamp, respectively.
+func (d *Driver) unacknowledged() (tasks []mesos.TaskInfo, updates []executor.Call_Update) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	for _, t := range d.unackedTasks {
+		tasks = append(tasks, t)
+	}
+	for _, u := range d.unackedUpdates {
+		updates = append(updates, u)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskID.Value < tasks[j].TaskID.Value })
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Status.GetTimestamp() < updates[j].Status.GetTimestamp() })
+	return
+}
@@ -0,0 +1,241 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	"github.com/mesos/mesos-go/api/v1/lib/executor/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/executor/config"
+)
+
+// subscription scripts the events of a subscription; unless held, the subscription ends thereafter.
+type subscription struct {
+	events []executor.Event
+	hold   bool
+}
+
+// fakeAgent serves scripted subscriptions, and records the calls of the executor; subscriptions beyond
+// those that are scripted are refused, as are UPDATE calls while broken.
+type fakeAgent struct {
+	m             sync.Mutex
+	subscriptions []subscription
+	subscribes    []executor.Call_Subscribe
+	calls         []executor.Call
+	broken        bool
+}
+
+func (a *fakeAgent) senders() Option {
+	sender := calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+		a.m.Lock()
+		defer a.m.Unlock()
+		c := r.Call()
+		if a.broken && c.GetType() == executor.Call_UPDATE {
+			return nil, errors.New("broken")
+		}
+		a.calls = append(a.calls, *c)
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(encoding.Unmarshaler) error { return nil })}, nil
+	})
+	subscriber := calls.SenderFunc(func(ctx context.Context, r calls.Request) (mesos.Response, error) {
+		a.m.Lock()
+		defer a.m.Unlock()
+		a.subscribes = append(a.subscribes, *r.Call().GetSubscribe())
+		if len(a.subscriptions) == 0 {
+			return nil, errors.New("refused")
+		}
+		s := a.subscriptions[0]
+		a.subscriptions = a.subscriptions[1:]
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			if len(s.events) > 0 {
+				*(u.(*executor.Event)) = s.events[0]
+				s.events = s.events[1:]
+				return encoding.CheckType(u, nil) // as decoded from protobuf
+			}
+			if s.hold {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return io.EOF
+		})}, nil
+	})
+	return Senders(sender, subscriber)
+}
+
+// fakeExecutor records its callbacks; it reports launched tasks as TASK_RUNNING, and killed tasks as
+// TASK_KILLED.
+type fakeExecutor struct {
+	m         sync.Mutex
+	callbacks []string
+}
+
+func (x *fakeExecutor) record(format string, args ...interface{}) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	x.callbacks = append(x.callbacks, fmt.Sprintf(format, args...))
+}
+
+func (x *fakeExecutor) String() string {
+	x.m.Lock()
+	defer x.m.Unlock()
+	return strings.Join(x.callbacks, ",")
+}
+
+func (x *fakeExecutor) Registered(_ ExecutorDriver, e *mesos.ExecutorInfo, f *mesos.FrameworkInfo, a *mesos.AgentInfo) {
+	x.record("registered %s %s %s", e.ExecutorID.Value, f.Name, a.Hostname)
+}
+
+func (x *fakeExecutor) Reregistered(_ ExecutorDriver, a *mesos.AgentInfo) {
+	x.record("reregistered %s", a.Hostname)
+}
+
+func (x *fakeExecutor) Disconnected(ExecutorDriver) { x.record("disconnected") }
+
+func (x *fakeExecutor) LaunchTask(d ExecutorDriver, t *mesos.TaskInfo) {
+	x.record("launch %s", t.TaskID.Value)
+	d.SendStatusUpdate(&mesos.TaskStatus{TaskID: t.TaskID, State: mesos.TASK_RUNNING.Enum()})
+}
+
+func (x *fakeExecutor) KillTask(d ExecutorDriver, id *mesos.TaskID) {
+	x.record("kill %s", id.Value)
+	d.SendStatusUpdate(&mesos.TaskStatus{TaskID: *id, State: mesos.TASK_KILLED.Enum()})
+}
+
+func (x *fakeExecutor) FrameworkMessage(d ExecutorDriver, msg string) {
+	x.record("message %s", msg)
+	d.SendFrameworkMessage("re: " + msg)
+}
+
+func (x *fakeExecutor) Shutdown(ExecutorDriver) { x.record("shutdown") }
+
+func (x *fakeExecutor) Error(_ ExecutorDriver, msg string) { x.record("error %s", msg) }
+
+var (
+	subscribed = executor.Event{Type: executor.Event_SUBSCRIBED, Subscribed: &executor.Event_Subscribed{
+		ExecutorInfo:  mesos.ExecutorInfo{ExecutorID: mesos.ExecutorID{Value: "x"}},
+		FrameworkInfo: mesos.FrameworkInfo{Name: "f"},
+		AgentInfo:     mesos.AgentInfo{Hostname: "a"},
+	}}
+	launch = executor.Event{Type: executor.Event_LAUNCH, Launch: &executor.Event_Launch{
+		Task: mesos.TaskInfo{TaskID: mesos.TaskID{Value: "t1"}},
+	}}
+)
+
+func TestDriver(t *testing.T) {
+	var (
+		uuids = calls.SequentialUUIDs(1)
+		first = calls.SequentialUUIDs(1)() // the UUID of the first status update
+		agent = &fakeAgent{subscriptions: []subscription{{hold: true, events: []executor.Event{
+			subscribed,
+			launch,
+			{Type: executor.Event_Type(100)}, // added by a newer version of Mesos
+			{Type: executor.Event_ACKNOWLEDGED, Acknowledged: &executor.Event_Acknowledged{TaskID: mesos.TaskID{Value: "t1"}, UUID: first}},
+			{Type: executor.Event_MESSAGE, Message: &executor.Event_Message{Data: []byte("hi")}},
+			{Type: executor.Event_KILL, Kill: &executor.Event_Kill{TaskID: mesos.TaskID{Value: "t1"}}},
+			{Type: executor.Event_SHUTDOWN},
+		}}}}
+		x   = &fakeExecutor{}
+		cfg = config.Config{FrameworkID: "f1", ExecutorID: "x"}
+	)
+	d, err := New(x, cfg, agent.senders(), UUIDs(uuids))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.Join(); err != ErrNotRunning {
+		t.Fatalf("expected ErrNotRunning instead of %v", err)
+	}
+	if st, err := d.Run(); st != StatusStopped || err != nil {
+		t.Fatalf("unexpected result of Run: %v, %v", st, err)
+	}
+	if s, want := x.String(), "registered x f a,launch t1,message hi,kill t1,shutdown"; s != want {
+		t.Fatalf("expected callbacks %q instead of %q", want, s)
+	}
+	if st, err := d.SendStatusUpdate(&mesos.TaskStatus{State: mesos.TASK_FINISHED.Enum()}); st != StatusStopped || err != ErrNotRunning {
+		t.Fatalf("expected a stopped driver to refuse updates: %v, %v", st, err)
+	}
+
+	if len(agent.calls) != 3 {
+		t.Fatalf("unexpected calls: %v", agent.calls)
+	}
+	for i, state := range map[int]mesos.TaskState{0: mesos.TASK_RUNNING, 2: mesos.TASK_KILLED} {
+		c := agent.calls[i]
+		s := c.GetUpdate().GetStatus()
+		if c.GetType() != executor.Call_UPDATE || c.FrameworkID.Value != "f1" || c.ExecutorID.Value != "x" ||
+			s.GetState() != state || s.GetExecutorID().GetValue() != "x" || s.GetSource() != mesos.SOURCE_EXECUTOR ||
+			s.Timestamp == nil || len(s.UUID) != 16 {
+			t.Errorf("unexpected update: %+v", c)
+		}
+	}
+	if c := agent.calls[1]; c.GetType() != executor.Call_MESSAGE || string(c.GetMessage().GetData()) != "re: hi" {
+		t.Errorf("unexpected message: %+v", c)
+	}
+	tasks, updates := d.unacknowledged()
+	if len(tasks) != 0 || len(updates) != 1 || updates[0].Status.GetState() != mesos.TASK_KILLED {
+		t.Errorf("expected the TASK_KILLED update to remain unacknowledged: %v, %v", tasks, updates)
+	}
+	if _, err := d.SendStatusUpdate(&mesos.TaskStatus{State: mesos.TASK_STAGING.Enum()}); err != ErrStagingUpdate {
+		t.Errorf("expected ErrStagingUpdate instead of %v", err)
+	}
+}
+
+func TestDriverReconnects(t *testing.T) {
+	var (
+		agent = &fakeAgent{broken: true, subscriptions: []subscription{
+			{events: []executor.Event{subscribed, launch}},
+			{events: []executor.Event{subscribed}},
+		}}
+		x   = &fakeExecutor{}
+		cfg = config.Config{
+			Checkpoint:             true,
+			RecoveryTimeout:        100 * time.Millisecond,
+			SubscriptionBackoffMax: 10 * time.Millisecond,
+		}
+	)
+	d, err := New(x, cfg, agent.senders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := d.Run()
+	if st != StatusAborted || err == nil || !strings.HasPrefix(err.Error(), ErrRecoveryTimeout.Error()) {
+		t.Fatalf("expected the driver to abort: %v, %v", st, err)
+	}
+	if s, want := x.String(), "registered x f a,launch t1,disconnected,reregistered a,disconnected,shutdown"; s != want {
+		t.Fatalf("expected callbacks %q instead of %q", want, s)
+	}
+	if len(agent.subscribes) < 3 {
+		t.Fatalf("expected subscription attempts until the recovery timeout: %v", agent.subscribes)
+	}
+	for _, s := range agent.subscribes[1:] {
+		if len(s.UnacknowledgedTasks) != 1 || len(s.UnacknowledgedUpdates) != 1 ||
+			s.UnacknowledgedUpdates[0].Status.GetState() != mesos.TASK_RUNNING {
+			t.Fatalf("expected re-subscriptions to include the unacknowledged task, and update: %+v", s)
+		}
+	}
+}
+
+func TestDriverWithoutCheckpointing(t *testing.T) {
+	var (
+		agent = &fakeAgent{subscriptions: []subscription{{events: []executor.Event{subscribed}}}}
+		x     = &fakeExecutor{}
+	)
+	d, err := New(x, config.Config{}, agent.senders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, err := d.Run(); st != StatusAborted || err == nil || !strings.HasPrefix(err.Error(), ErrDisconnected.Error()) {
+		t.Fatalf("expected the driver to abort: %v, %v", st, err)
+	}
+	if s, want := x.String(), "registered x f a,shutdown"; s != want {
+		t.Fatalf("expected callbacks %q instead of %q", want, s)
+	}
+	if _, err := d.Start(); err == nil {
+		t.Fatalf("expected an aborted driver not to restart")
+	}
+}
@@ -0,0 +1,133 @@
+// Package kill implements the graceful kill escalation that Mesos expects of executors: a task is first
+// asked to terminate (SIGTERM) and, if it has not done so by the end of its grace period, is forcibly
+// killed (SIGKILL). Executors that support it report TASK_KILLING when the graceful kill begins.
+package kill
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// DefaultGracePeriod is the grace period that Mesos applies to tasks that don't specify a kill policy.
+const DefaultGracePeriod = 3 * time.Second
+
+type (
+	// Process is the subset of os.Process that's needed to kill a task.
+	Process interface {
+		Signal(os.Signal) error
+	}
+
+	// Option is a functional configuration option for Escalate; it returns an Option that acts as an
+	// "undo" if applied to the same Config.
+	Option func(*Config) Option
+
+	// Config is an opaque escalation configuration. Properties are configured by applying Option funcs.
+	Config struct {
+		gracePeriod time.Duration
+		killing     func(context.Context) error
+	}
+)
+
+// GracePeriod configures the amount of time that the process is given to terminate after it's been
+// signaled with SIGTERM. Defaults to DefaultGracePeriod.
+func GracePeriod(d time.Duration) Option {
+	return func(c *Config) Option {
+		old := c.gracePeriod
+		c.gracePeriod = d
+		return GracePeriod(old)
+	}
+}
+
+// Killing configures a func that's invoked prior to signaling the process; it is intended to send a
+// TASK_KILLING status update. It's only invoked if the framework, as described by the given info, has
+// the TASK_KILLING_STATE capability. An error returned by the func is reported by Escalate, after the
+// process has been killed.
+func Killing(info *mesos.FrameworkInfo, f func(context.Context) error) Option {
+	return func(c *Config) Option {
+		old := c.killing
+		if KillingStateEnabled(info) {
+			c.killing = f
+		} else {
+			c.killing = nil
+		}
+		return func(c *Config) Option {
+			c.killing = old
+			return Killing(info, f)
+		}
+	}
+}
+
+// KillingStateEnabled returns true if the framework, as described by the given info, has the
+// TASK_KILLING_STATE capability.
+func KillingStateEnabled(info *mesos.FrameworkInfo) bool {
+	for _, c := range info.GetCapabilities() {
+		if c.GetType() == mesos.FrameworkInfo_Capability_TASK_KILLING_STATE {
+			return true
+		}
+	}
+	return false
+}
+
+// GracePeriodFor returns the grace period that applies to a task, given its kill policies in order of
+// precedence: e.g. that of the KILL event, followed by that of the TaskInfo. The first policy that
+// specifies a grace period wins; otherwise DefaultGracePeriod applies. The result is capped by the
+// executor shutdown grace period (MESOS_EXECUTOR_SHUTDOWN_GRACE_PERIOD), if greater than zero, since the
+// agent forcibly destroys the executor once that period expires.
+func GracePeriodFor(shutdownGracePeriod time.Duration, policies ...*mesos.KillPolicy) time.Duration {
+	d := DefaultGracePeriod
+	for _, p := range policies {
+		if gp := p.GetGracePeriod(); gp != nil {
+			d = time.Duration(gp.GetNanoseconds())
+			break
+		}
+	}
+	if d < 0 {
+		d = 0
+	}
+	if shutdownGracePeriod > 0 && d > shutdownGracePeriod {
+		d = shutdownGracePeriod
+	}
+	return d
+}
+
+// Escalate kills the process: it's signaled with SIGTERM and then, if it has not exited (as indicated by
+// the closing of the exited chan) by the end of the grace period, with SIGKILL. Escalate returns once the
+// process has exited, or else the context is canceled; in the latter case the process is not forcibly
+// killed and the context error is returned.
+func Escalate(ctx context.Context, p Process, exited <-chan struct{}, opts ...Option) error {
+	c := Config{gracePeriod: DefaultGracePeriod}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&c)
+		}
+	}
+	var killingErr error
+	if c.killing != nil {
+		killingErr = c.killing(ctx)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+	t := time.NewTimer(c.gracePeriod)
+	defer t.Stop()
+	select {
+	case <-exited:
+		return killingErr
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+	}
+	if err := p.Signal(os.Kill); err != nil {
+		return err
+	}
+	select {
+	case <-exited:
+		return killingErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
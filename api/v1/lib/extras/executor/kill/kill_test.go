@@ -0,0 +1,105 @@
+package kill
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+type fakeProcess struct {
+	m       sync.Mutex
+	signals []os.Signal
+	onTerm  bool // exit upon SIGTERM
+	exited  chan struct{}
+}
+
+func (p *fakeProcess) Signal(s os.Signal) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.signals = append(p.signals, s)
+	if s == os.Kill || (p.onTerm && s == syscall.SIGTERM) {
+		close(p.exited)
+	}
+	return nil
+}
+
+func TestEscalate(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		capable   = &mesos.FrameworkInfo{Capabilities: []mesos.FrameworkInfo_Capability{{Type: mesos.FrameworkInfo_Capability_TASK_KILLING_STATE}}}
+		incapable = &mesos.FrameworkInfo{}
+		errSend   = errors.New("send failed")
+	)
+	for ti, tc := range []struct {
+		onTerm      bool
+		info        *mesos.FrameworkInfo
+		sendErr     error
+		wantSignals []os.Signal
+		wantKilling bool
+		wantErr     error
+	}{
+		{true, capable, nil, []os.Signal{syscall.SIGTERM}, true, nil},
+		{false, capable, nil, []os.Signal{syscall.SIGTERM, os.Kill}, true, nil},
+		{true, incapable, nil, []os.Signal{syscall.SIGTERM}, false, nil},
+		{true, capable, errSend, []os.Signal{syscall.SIGTERM}, true, errSend},
+	} {
+		var (
+			p       = &fakeProcess{onTerm: tc.onTerm, exited: make(chan struct{})}
+			killing bool
+		)
+		err := Escalate(ctx, p, p.exited,
+			GracePeriod(10*time.Millisecond),
+			Killing(tc.info, func(context.Context) error {
+				killing = true
+				return tc.sendErr
+			}),
+		)
+		if err != tc.wantErr {
+			t.Errorf("test case %d failed: expected error %v instead of %v", ti, tc.wantErr, err)
+		}
+		if !reflect.DeepEqual(p.signals, tc.wantSignals) {
+			t.Errorf("test case %d failed: expected signals %v instead of %v", ti, tc.wantSignals, p.signals)
+		}
+		if killing != tc.wantKilling {
+			t.Errorf("test case %d failed: expected killing=%v", ti, tc.wantKilling)
+		}
+	}
+}
+
+func TestEscalateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &fakeProcess{exited: make(chan struct{})}
+	if err := Escalate(ctx, p, p.exited, GracePeriod(time.Hour)); err != context.Canceled {
+		t.Fatalf("expected context.Canceled instead of %v", err)
+	}
+}
+
+func TestGracePeriodFor(t *testing.T) {
+	policy := func(d time.Duration) *mesos.KillPolicy {
+		return &mesos.KillPolicy{GracePeriod: &mesos.DurationInfo{Nanoseconds: int64(d)}}
+	}
+	for ti, tc := range []struct {
+		shutdown time.Duration
+		policies []*mesos.KillPolicy
+		want     time.Duration
+	}{
+		{0, nil, DefaultGracePeriod},
+		{0, []*mesos.KillPolicy{nil, {}}, DefaultGracePeriod},
+		{0, []*mesos.KillPolicy{nil, policy(time.Minute)}, time.Minute},
+		{0, []*mesos.KillPolicy{policy(time.Second), policy(time.Minute)}, time.Second},
+		{5 * time.Second, []*mesos.KillPolicy{policy(time.Minute)}, 5 * time.Second},
+		{time.Second, nil, time.Second},
+	} {
+		if d := GracePeriodFor(tc.shutdown, tc.policies...); d != tc.want {
+			t.Errorf("test case %d failed: expected %v instead of %v", ti, tc.want, d)
+		}
+	}
+}
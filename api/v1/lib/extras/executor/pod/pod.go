@@ -0,0 +1,395 @@
+// Package pod implements the behavior of the Mesos default executor for custom pod executors: each task
+// of a task group is launched as a nested container (of the executor's container) via the agent API, and
+// monitored via WAIT_NESTED_CONTAINER calls; container exits are reported as TASK_* status updates. Task
+// groups live and die together: killing any task of a group kills the whole group, as does the failure of
+// any of its tasks (see KillGroupOnFailure). Kill policies and the TASK_KILLING state are honored.
+package pod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	"github.com/mesos/mesos-go/api/v1/lib/executor/events"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/agent/containers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/executor/kill"
+)
+
+var (
+	// ErrNotSubscribed is returned when launching a task group before the executor has subscribed, or if
+	// the agent didn't report the container ID of the executor upon subscription.
+	ErrNotSubscribed = errors.New("the container ID of the executor is unknown")
+
+	// ErrUnknownTask is returned when killing a task that hasn't been launched.
+	ErrUnknownTask = errors.New("unknown task")
+)
+
+type (
+	// UpdateFunc sends a status update for a task; implementations typically assign a UUID to the status
+	// and send it via an UPDATE call of the executor API (see calls.UUIDs). The status specifies the task ID, state, and
+	// source (and, if applicable, the reason, message, and resource limitation) of the update.
+	UpdateFunc func(context.Context, mesos.TaskStatus) error
+
+	// Option is a functional configuration option for an Executor; it returns an Option that acts as an
+	// "undo" if applied to the same Executor.
+	Option func(*Executor) Option
+
+	// Executor launches and supervises the tasks of task groups. Executor funcs are safe to invoke
+	// concurrently.
+	Executor struct {
+		ctx                 context.Context
+		sender, waiter      calls.Sender
+		update              UpdateFunc
+		shutdownGracePeriod time.Duration
+		killGroupOnFailure  bool
+		onError             func(error)
+
+		m         sync.Mutex
+		parent    *mesos.ContainerID
+		framework mesos.FrameworkInfo
+		tasks     map[mesos.TaskID]*task
+		active    int
+		idle      chan struct{} // closed while there are no active tasks
+	}
+
+	task struct {
+		info      mesos.TaskInfo
+		container mesos.ContainerID
+		group     []*task
+		killed    bool
+		failure   string // if non-empty, the task fails with this message regardless of how it exits
+		exited    chan struct{}
+	}
+
+	// process adapts a nested container for kill.Escalate.
+	process struct {
+		ctx    context.Context
+		sender calls.Sender
+		id     mesos.ContainerID
+	}
+)
+
+// Waiter configures the sender of the (long-polling) WAIT_NESTED_CONTAINER calls; defaults to the sender
+// of the Executor. See containers.Wait.
+func Waiter(s calls.Sender) Option {
+	return func(x *Executor) Option {
+		old := x.waiter
+		x.waiter = s
+		return Waiter(old)
+	}
+}
+
+// ShutdownGracePeriod configures the executor shutdown grace period (see
+// config.Config.ExecutorShutdownGracePeriod), which caps the grace periods of kill policies.
+func ShutdownGracePeriod(d time.Duration) Option {
+	return func(x *Executor) Option {
+		old := x.shutdownGracePeriod
+		x.shutdownGracePeriod = d
+		return ShutdownGracePeriod(old)
+	}
+}
+
+// KillGroupOnFailure configures whether the remaining tasks of a task group are killed once any of its
+// tasks fails; defaults to true, as per the default executor.
+func KillGroupOnFailure(b bool) Option {
+	return func(x *Executor) Option {
+		old := x.killGroupOnFailure
+		x.killGroupOnFailure = b
+		return KillGroupOnFailure(old)
+	}
+}
+
+// OnError configures a func that's invoked for errors that occur asynchronously: e.g. while sending the
+// status update for an exited task, or while killing tasks in response to events. By default such errors
+// are ignored.
+func OnError(f func(error)) Option {
+	return func(x *Executor) Option {
+		old := x.onError
+		x.onError = f
+		return OnError(old)
+	}
+}
+
+// New returns an Executor that sends agent API calls via the given sender, and status updates via the
+// given func. Task containers are monitored until ctx is done.
+func New(ctx context.Context, sender calls.Sender, update UpdateFunc, opts ...Option) *Executor {
+	x := &Executor{
+		ctx:                ctx,
+		sender:             sender,
+		update:             update,
+		killGroupOnFailure: true,
+		tasks:              make(map[mesos.TaskID]*task),
+		idle:               make(chan struct{}),
+	}
+	close(x.idle)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(x)
+		}
+	}
+	if x.waiter == nil {
+		x.waiter = x.sender
+	}
+	return x
+}
+
+// Subscribed records the executor's container ID, which is the parent of the containers of tasks, and the
+// info of the framework, which determines whether TASK_KILLING updates are sent.
+func (x *Executor) Subscribed(s *executor.Event_Subscribed) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	x.parent = s.GetContainerID()
+	x.framework = s.GetFrameworkInfo()
+}
+
+// Handlers returns event handlers for SUBSCRIBED, LAUNCH_GROUP, KILL, and SHUTDOWN events. Kills (and
+// shutdowns) proceed asynchronously, so as to not block the processing of subsequent events; see Wait.
+// The handlers report errors via OnError, instead of failing the event loop.
+func (x *Executor) Handlers() events.HandlerFuncs {
+	return events.HandlerFuncs{
+		executor.Event_SUBSCRIBED: func(_ context.Context, e *executor.Event) error {
+			x.Subscribed(e.GetSubscribed())
+			return nil
+		},
+		executor.Event_LAUNCH_GROUP: func(ctx context.Context, e *executor.Event) error {
+			x.report(x.Launch(ctx, e.GetLaunchGroup().GetTaskGroup()))
+			return nil
+		},
+		executor.Event_KILL: func(_ context.Context, e *executor.Event) error {
+			k := e.GetKill()
+			go func() { x.report(x.Kill(x.ctx, k.GetTaskID(), k.GetKillPolicy())) }()
+			return nil
+		},
+		executor.Event_SHUTDOWN: func(_ context.Context, e *executor.Event) error {
+			go func() { x.report(x.Shutdown(x.ctx)) }()
+			return nil
+		},
+	}
+}
+
+// Launch launches the tasks of the given group, each as a nested container, and reports them as
+// TASK_RUNNING. If any task fails to launch then the whole group fails: tasks that were launched are
+// killed, all tasks are reported as TASK_FAILED, and the launch error is returned.
+func (x *Executor) Launch(ctx context.Context, tg mesos.TaskGroupInfo) error {
+	x.m.Lock()
+	parent := x.parent
+	x.m.Unlock()
+	if parent == nil {
+		return ErrNotSubscribed
+	}
+	group := make([]*task, len(tg.Tasks))
+	for i := range tg.Tasks {
+		id := containers.NewID("")
+		id.Parent = parent
+		group[i] = &task{info: tg.Tasks[i], container: id, group: group, exited: make(chan struct{})}
+	}
+	var (
+		err      error
+		launched int
+	)
+	for ; launched < len(group); launched++ {
+		t := group[launched]
+		err = calls.SendNoData(ctx, x.sender, calls.NonStreaming(
+			calls.LaunchNestedContainer(t.container, t.info.Command, t.info.Container)))
+		if err != nil {
+			break
+		}
+	}
+	x.track(group[:launched]...)
+
+	if err != nil {
+		msg := fmt.Sprintf("failed to launch task %q of task group: %v", group[launched].info.TaskID.Value, err)
+		for _, t := range group[launched:] {
+			close(t.exited)
+			x.report(x.update(ctx, x.status(t, mesos.TASK_FAILED, mesos.REASON_CONTAINER_LAUNCH_FAILED.Enum(), msg)))
+		}
+		x.m.Lock()
+		for _, t := range group[:launched] {
+			t.failure = msg
+		}
+		x.m.Unlock()
+		for _, t := range group[:launched] {
+			go x.wait(t)
+		}
+		go func() { x.report(x.killGroup(x.ctx, group[:launched], nil)) }()
+		return err
+	}
+	for _, t := range group {
+		x.report(x.update(ctx, x.status(t, mesos.TASK_RUNNING, nil, "")))
+	}
+	for _, t := range group {
+		go x.wait(t)
+	}
+	return nil
+}
+
+// Kill kills the task group of the given task: the tasks of the group are killed gracefully (see
+// kill.Escalate), subject to the given kill policy, if any, or else those of the tasks. Kill blocks until
+// the tasks have exited, or ctx is done.
+func (x *Executor) Kill(ctx context.Context, id mesos.TaskID, policy *mesos.KillPolicy) error {
+	x.m.Lock()
+	t, ok := x.tasks[id]
+	x.m.Unlock()
+	if !ok {
+		return ErrUnknownTask
+	}
+	return x.killGroup(ctx, t.group, policy)
+}
+
+// Shutdown kills all task groups, and blocks until their tasks have exited or ctx is done.
+func (x *Executor) Shutdown(ctx context.Context) error {
+	x.m.Lock()
+	tasks := make([]*task, 0, len(x.tasks))
+	for _, t := range x.tasks {
+		tasks = append(tasks, t)
+	}
+	x.m.Unlock()
+	if err := x.killGroup(ctx, tasks, nil); err != nil {
+		return err
+	}
+	return x.Wait(ctx)
+}
+
+// Wait blocks until no launched task remains active (i.e. each has exited, and its status has been
+// reported), or until ctx is done.
+func (x *Executor) Wait(ctx context.Context) error {
+	x.m.Lock()
+	idle := x.idle
+	x.m.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (x *Executor) track(tasks ...*task) {
+	if len(tasks) == 0 {
+		return
+	}
+	x.m.Lock()
+	defer x.m.Unlock()
+	if x.active == 0 {
+		x.idle = make(chan struct{})
+	}
+	x.active += len(tasks)
+	for _, t := range tasks {
+		x.tasks[t.info.TaskID] = t
+	}
+}
+
+// wait blocks until the container of the task exits, then reports the final status of the task.
+func (x *Executor) wait(t *task) {
+	status, err := containers.WaitNested(x.ctx, x.waiter, t.container)
+	close(t.exited)
+	defer x.done(t)
+	if x.ctx.Err() != nil {
+		return
+	}
+	x.m.Lock()
+	killed, failure := t.killed, t.failure
+	x.m.Unlock()
+
+	var s mesos.TaskStatus
+	switch {
+	case err != nil:
+		s = x.status(t, mesos.TASK_FAILED, nil, "failed to wait for the container of the task: "+err.Error())
+	case failure != "":
+		s = x.status(t, mesos.TASK_FAILED, nil, failure)
+	case killed:
+		s = x.status(t, mesos.TASK_KILLED, nil, "")
+	case status.State != nil:
+		s = x.status(t, *status.State, status.Reason, status.Message)
+		s.Limitation = status.Limitation
+	case status.Success():
+		s = x.status(t, mesos.TASK_FINISHED, nil, "")
+	default:
+		s = x.status(t, mesos.TASK_FAILED, nil, "the task "+status.String())
+	}
+	x.report(x.update(x.ctx, s))
+	if s.GetState() != mesos.TASK_FINISHED && s.GetState() != mesos.TASK_KILLED && x.killGroupOnFailure {
+		go func() { x.report(x.killGroup(x.ctx, t.group, nil)) }()
+	}
+}
+
+func (x *Executor) done(t *task) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	delete(x.tasks, t.info.TaskID)
+	x.active--
+	if x.active == 0 {
+		close(x.idle)
+	}
+}
+
+func (x *Executor) killGroup(ctx context.Context, group []*task, policy *mesos.KillPolicy) error {
+	errs := make(chan error, len(group))
+	for _, t := range group {
+		go func(t *task) { errs <- x.kill(ctx, t, policy) }(t)
+	}
+	var err error
+	for range group {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (x *Executor) kill(ctx context.Context, t *task, policy *mesos.KillPolicy) error {
+	select {
+	case <-t.exited:
+		return nil
+	default:
+	}
+	x.m.Lock()
+	killing := !t.killed && t.failure == ""
+	t.killed = true
+	framework := x.framework
+	x.m.Unlock()
+
+	opts := []kill.Option{kill.GracePeriod(kill.GracePeriodFor(x.shutdownGracePeriod, policy, t.info.KillPolicy))}
+	if killing {
+		opts = append(opts, kill.Killing(&framework, func(ctx context.Context) error {
+			return x.update(ctx, x.status(t, mesos.TASK_KILLING, nil, ""))
+		}))
+	}
+	return kill.Escalate(ctx, &process{ctx: ctx, sender: x.sender, id: t.container}, t.exited, opts...)
+}
+
+func (x *Executor) status(t *task, state mesos.TaskState, reason *mesos.TaskStatus_Reason, msg string) mesos.TaskStatus {
+	s := mesos.TaskStatus{
+		TaskID: t.info.TaskID,
+		State:  state.Enum(),
+		Source: mesos.SOURCE_EXECUTOR.Enum(),
+		Reason: reason,
+	}
+	if msg != "" {
+		s.Message = &msg
+	}
+	return s
+}
+
+func (x *Executor) report(err error) {
+	if err != nil && x.onError != nil {
+		x.onError(err)
+	}
+}
+
+// Signal issues a KILL_NESTED_CONTAINER call for the container, with the given signal.
+func (p *process) Signal(sig os.Signal) error {
+	c := calls.KillNestedContainer(p.id)
+	if s, ok := sig.(syscall.Signal); ok {
+		n := int32(s)
+		c.KillNestedContainer.Signal = &n
+	}
+	return calls.SendNoData(p.ctx, p.sender, calls.NonStreaming(c))
+}
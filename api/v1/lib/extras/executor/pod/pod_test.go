@@ -0,0 +1,207 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	"github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+)
+
+// fakeAgent runs nested containers that exit when killed (with the signal), or when told to exit.
+type fakeAgent struct {
+	sync.Mutex
+	failLaunch string // the command value of a task that fails to launch
+	exits      map[string]chan int32
+	commands   map[string]string // by command value: container ID
+	signals    []int32
+}
+
+func (a *fakeAgent) exit(cmd string, status int32) {
+	a.Lock()
+	ch := a.exits[a.commands[cmd]]
+	a.Unlock()
+	ch <- status
+}
+
+func (a *fakeAgent) Send(ctx context.Context, r calls.Request) (mesos.Response, error) {
+	c := r.Call()
+	var resp agent.Response
+	switch c.GetType() {
+	case agent.Call_LAUNCH_NESTED_CONTAINER:
+		lc := c.GetLaunchNestedContainer()
+		if lc.ContainerID.GetParent().GetValue() != "executor" {
+			return nil, errors.New("unexpected parent container")
+		}
+		if lc.Command.GetValue() == a.failLaunch {
+			return nil, errors.New("launch failed")
+		}
+		a.Lock()
+		a.exits[lc.ContainerID.Value] = make(chan int32, 1)
+		a.commands[lc.Command.GetValue()] = lc.ContainerID.Value
+		a.Unlock()
+	case agent.Call_KILL_NESTED_CONTAINER:
+		k := c.GetKillNestedContainer()
+		a.Lock()
+		a.signals = append(a.signals, k.GetSignal())
+		ch := a.exits[k.ContainerID.Value]
+		a.Unlock()
+		select {
+		case ch <- k.GetSignal():
+		default:
+		}
+	case agent.Call_WAIT_NESTED_CONTAINER:
+		a.Lock()
+		ch := a.exits[c.GetWaitNestedContainer().ContainerID.Value]
+		a.Unlock()
+		select {
+		case status := <-ch:
+			resp = agent.Response{Type: agent.Response_WAIT_NESTED_CONTAINER,
+				WaitNestedContainer: &agent.Response_WaitNestedContainer{ExitStatus: &status}}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+		*(u.(*agent.Response)) = resp
+		return nil
+	})}, nil
+}
+
+type updates struct {
+	sync.Mutex
+	states map[string][]mesos.TaskState
+}
+
+func (u *updates) update(_ context.Context, s mesos.TaskStatus) error {
+	u.Lock()
+	defer u.Unlock()
+	u.states[s.TaskID.Value] = append(u.states[s.TaskID.Value], s.GetState())
+	return nil
+}
+
+func (u *updates) expect(t *testing.T, task string, states ...mesos.TaskState) {
+	t.Helper()
+	u.Lock()
+	defer u.Unlock()
+	got := u.states[task]
+	if len(got) != len(states) {
+		t.Fatalf("expected states %v for task %q instead of %v", states, task, got)
+	}
+	for i := range got {
+		if got[i] != states[i] {
+			t.Fatalf("expected states %v for task %q instead of %v", states, task, got)
+		}
+	}
+}
+
+func group(cmds ...string) (tg mesos.TaskGroupInfo) {
+	for _, cmd := range cmds {
+		tg.Tasks = append(tg.Tasks, mesos.TaskInfo{
+			TaskID:  mesos.TaskID{Value: cmd},
+			Command: &mesos.CommandInfo{Value: proto.String(cmd)},
+		})
+	}
+	return
+}
+
+func subscribed(capabilities ...mesos.FrameworkInfo_Capability_Type) *executor.Event {
+	info := mesos.FrameworkInfo{}
+	for _, c := range capabilities {
+		info.Capabilities = append(info.Capabilities, mesos.FrameworkInfo_Capability{Type: c})
+	}
+	return &executor.Event{Type: executor.Event_SUBSCRIBED, Subscribed: &executor.Event_Subscribed{
+		FrameworkInfo: info,
+		ContainerID:   &mesos.ContainerID{Value: "executor"},
+	}}
+}
+
+func newExecutor(t *testing.T) (*Executor, *fakeAgent, *updates, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	var (
+		a = &fakeAgent{exits: make(map[string]chan int32), commands: make(map[string]string)}
+		u = &updates{states: make(map[string][]mesos.TaskState)}
+		x = New(ctx, a, u.update, OnError(func(err error) { t.Error(err) }))
+	)
+	return x, a, u, ctx, cancel
+}
+
+func TestLaunch(t *testing.T) {
+	x, a, u, ctx, cancel := newExecutor(t)
+	defer cancel()
+	if err := x.Launch(ctx, group("a")); err != ErrNotSubscribed {
+		t.Fatalf("expected %v instead of %v", ErrNotSubscribed, err)
+	}
+	x.Handlers().HandleEvent(ctx, subscribed())
+
+	// the failure of a task kills the other tasks of its group
+	if err := x.Launch(ctx, group("a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Launch(ctx, group("c")); err != nil {
+		t.Fatal(err)
+	}
+	a.exit("a", 1<<8) // exit code 1
+	a.exit("c", 0)
+	if err := x.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	u.expect(t, "a", mesos.TASK_RUNNING, mesos.TASK_FAILED)
+	u.expect(t, "b", mesos.TASK_RUNNING, mesos.TASK_KILLED)
+	u.expect(t, "c", mesos.TASK_RUNNING, mesos.TASK_FINISHED)
+
+	// a group fails as a whole if any of its tasks fails to launch
+	a.failLaunch = "e"
+	if err := x.Launch(ctx, group("d", "e", "f")); err == nil {
+		t.Fatal("expected launch error")
+	}
+	if err := x.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []string{"d", "e", "f"} {
+		u.expect(t, task, mesos.TASK_FAILED)
+	}
+}
+
+func TestKill(t *testing.T) {
+	x, a, u, ctx, cancel := newExecutor(t)
+	defer cancel()
+	x.Handlers().HandleEvent(ctx, subscribed(mesos.FrameworkInfo_Capability_TASK_KILLING_STATE))
+	if err := x.Launch(ctx, group("a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Kill(ctx, mesos.TaskID{Value: "x"}, nil); err != ErrUnknownTask {
+		t.Fatalf("expected %v instead of %v", ErrUnknownTask, err)
+	}
+	x.Handlers().HandleEvent(ctx, &executor.Event{Type: executor.Event_KILL, Kill: &executor.Event_Kill{TaskID: mesos.TaskID{Value: "a"}}})
+	if err := x.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	u.expect(t, "a", mesos.TASK_RUNNING, mesos.TASK_KILLING, mesos.TASK_KILLED)
+	u.expect(t, "b", mesos.TASK_RUNNING, mesos.TASK_KILLING, mesos.TASK_KILLED)
+	a.Lock()
+	if len(a.signals) != 2 || a.signals[0] != 15 || a.signals[1] != 15 {
+		t.Errorf("expected two SIGTERMs instead of %v", a.signals)
+	}
+	a.Unlock()
+
+	// shutdown kills all groups
+	if err := x.Launch(ctx, group("c")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Launch(ctx, group("d")); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	u.expect(t, "c", mesos.TASK_RUNNING, mesos.TASK_KILLING, mesos.TASK_KILLED)
+	u.expect(t, "d", mesos.TASK_RUNNING, mesos.TASK_KILLING, mesos.TASK_KILLED)
+}
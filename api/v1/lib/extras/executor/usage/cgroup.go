@@ -0,0 +1,223 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// DefaultCgroupRoot is the conventional mount point of the cgroup filesystem.
+const DefaultCgroupRoot = "/sys/fs/cgroup"
+
+// userHZ is the unit of the cpuacct.stat file; it's 100 on all platforms supported by Mesos.
+const userHZ = 100
+
+// SelfCgroup returns a Collector for the cgroup(s) of the current process, as listed by /proc/self/cgroup,
+// that are mounted beneath the given root (DefaultCgroupRoot if ""). Both the unified (v2) and legacy (v1)
+// hierarchies are supported.
+func SelfCgroup(root string) (Collector, error) {
+	if root == "" {
+		root = DefaultCgroupRoot
+	}
+	return selfCgroup(root, "/proc/self/cgroup")
+}
+
+func selfCgroup(root, procFile string) (Collector, error) {
+	f, err := os.Open(procFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var (
+		paths   = make(map[string]string) // v1 controller name -> cgroup path
+		unified string
+		scanner = bufio.NewScanner(f)
+	)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+			continue
+		}
+		for _, c := range strings.Split(fields[1], ",") {
+			paths[c] = fields[2]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := paths["memory"]; !ok && unified != "" {
+		return CgroupV2(filepath.Join(root, unified)), nil
+	}
+	dir := func(c string) string {
+		if p, ok := paths[c]; ok {
+			return filepath.Join(root, c, p)
+		}
+		return ""
+	}
+	return CgroupV1(dir("cpu"), dir("cpuacct"), dir("memory")), nil
+}
+
+// CgroupV1 returns a Collector that reads statistics from the given cpu, cpuacct, and memory cgroup
+// directories of the legacy (v1) hierarchy; an empty directory name skips the corresponding statistics.
+func CgroupV1(cpuDir, cpuacctDir, memoryDir string) Collector {
+	return CollectorFunc(func(context.Context) (*mesos.ResourceStatistics, error) {
+		stats := &mesos.ResourceStatistics{Timestamp: timestamp(time.Now())}
+		if cpuacctDir != "" {
+			kv, err := readKeyValues(filepath.Join(cpuacctDir, "cpuacct.stat"))
+			if err != nil {
+				return nil, err
+			}
+			stats.CPUsUserTimeSecs = ticks(kv, "user")
+			stats.CPUsSystemTimeSecs = ticks(kv, "system")
+		}
+		if cpuDir != "" {
+			quota, qerr := readInt(filepath.Join(cpuDir, "cpu.cfs_quota_us"))
+			period, perr := readInt(filepath.Join(cpuDir, "cpu.cfs_period_us"))
+			if qerr == nil && perr == nil && quota > 0 && period > 0 {
+				stats.CPUsLimit = proto.Float64(float64(quota) / float64(period))
+			}
+			if kv, err := readKeyValues(filepath.Join(cpuDir, "cpu.stat")); err == nil {
+				stats.CPUsNrPeriods = uint32Of(kv, "nr_periods")
+				stats.CPUsNrThrottled = uint32Of(kv, "nr_throttled")
+				if v, ok := kv["throttled_time"]; ok {
+					stats.CPUsThrottledTimeSecs = proto.Float64(time.Duration(v).Seconds())
+				}
+			}
+		}
+		if memoryDir != "" {
+			usage, err := readInt(filepath.Join(memoryDir, "memory.usage_in_bytes"))
+			if err != nil {
+				return nil, err
+			}
+			stats.MemTotalBytes = proto.Uint64(uint64(usage))
+			if limit, err := readInt(filepath.Join(memoryDir, "memory.limit_in_bytes")); err == nil {
+				stats.MemLimitBytes = proto.Uint64(uint64(limit))
+			}
+			if kv, err := readKeyValues(filepath.Join(memoryDir, "memory.stat")); err == nil {
+				stats.MemRSSBytes = uint64Of(kv, "rss")
+				stats.MemAnonBytes = uint64Of(kv, "rss")
+				stats.MemCacheBytes = uint64Of(kv, "cache")
+				stats.MemFileBytes = uint64Of(kv, "cache")
+				stats.MemMappedFileBytes = uint64Of(kv, "mapped_file")
+				stats.MemSwapBytes = uint64Of(kv, "swap")
+				stats.MemUnevictableBytes = uint64Of(kv, "unevictable")
+			}
+		}
+		return stats, nil
+	})
+}
+
+// CgroupV2 returns a Collector that reads statistics from the given cgroup directory of the unified (v2)
+// hierarchy.
+func CgroupV2(dir string) Collector {
+	return CollectorFunc(func(context.Context) (*mesos.ResourceStatistics, error) {
+		stats := &mesos.ResourceStatistics{Timestamp: timestamp(time.Now())}
+		kv, err := readKeyValues(filepath.Join(dir, "cpu.stat"))
+		if err != nil {
+			return nil, err
+		}
+		micros := func(k string) *float64 {
+			if v, ok := kv[k]; ok {
+				return proto.Float64((time.Duration(v) * time.Microsecond).Seconds())
+			}
+			return nil
+		}
+		stats.CPUsUserTimeSecs = micros("user_usec")
+		stats.CPUsSystemTimeSecs = micros("system_usec")
+		stats.CPUsNrPeriods = uint32Of(kv, "nr_periods")
+		stats.CPUsNrThrottled = uint32Of(kv, "nr_throttled")
+		stats.CPUsThrottledTimeSecs = micros("throttled_usec")
+
+		if b, err := ioutil.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+			// "$MAX $PERIOD", where $MAX may be "max"
+			if f := strings.Fields(string(b)); len(f) == 2 {
+				quota, qerr := strconv.ParseInt(f[0], 10, 64)
+				period, perr := strconv.ParseInt(f[1], 10, 64)
+				if qerr == nil && perr == nil && quota > 0 && period > 0 {
+					stats.CPUsLimit = proto.Float64(float64(quota) / float64(period))
+				}
+			}
+		}
+		if usage, err := readInt(filepath.Join(dir, "memory.current")); err == nil {
+			stats.MemTotalBytes = proto.Uint64(uint64(usage))
+		}
+		if limit, err := readInt(filepath.Join(dir, "memory.max")); err == nil {
+			stats.MemLimitBytes = proto.Uint64(uint64(limit))
+		}
+		if swap, err := readInt(filepath.Join(dir, "memory.swap.current")); err == nil {
+			stats.MemSwapBytes = proto.Uint64(uint64(swap))
+		}
+		if kv, err := readKeyValues(filepath.Join(dir, "memory.stat")); err == nil {
+			stats.MemAnonBytes = uint64Of(kv, "anon")
+			stats.MemRSSBytes = uint64Of(kv, "anon")
+			stats.MemFileBytes = uint64Of(kv, "file")
+			stats.MemCacheBytes = uint64Of(kv, "file")
+			stats.MemMappedFileBytes = uint64Of(kv, "file_mapped")
+			stats.MemUnevictableBytes = uint64Of(kv, "unevictable")
+		}
+		return stats, nil
+	})
+}
+
+// readInt reads a file that contains a single integer; "max" is reported as an error.
+func readInt(path string) (int64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// readKeyValues reads a file of "key value" lines, the values of which are unsigned integers; lines that
+// don't conform are skipped.
+func readKeyValues(path string) (map[string]uint64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kv := make(map[string]uint64)
+	for _, line := range strings.Split(string(b), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(f[1], 10, 64); err == nil {
+			kv[f[0]] = v
+		}
+	}
+	return kv, nil
+}
+
+func ticks(kv map[string]uint64, k string) *float64 {
+	if v, ok := kv[k]; ok {
+		return proto.Float64(float64(v) / userHZ)
+	}
+	return nil
+}
+
+func uint64Of(kv map[string]uint64, k string) *uint64 {
+	if v, ok := kv[k]; ok {
+		return proto.Uint64(v)
+	}
+	return nil
+}
+
+func uint32Of(kv map[string]uint64, k string) *uint32 {
+	if v, ok := kv[k]; ok {
+		return proto.Uint32(uint32(v))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package usage collects the resource usage of an executor (and its tasks) so that it may be reported to
+// the scheduler via framework messages, or exposed for debugging; this is useful when agents don't run
+// the isolators that would otherwise report such statistics.
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/messages"
+)
+
+// MessageKind is the kind of the framework messages that carry ResourceStatistics; see package messages.
+const MessageKind = "mesos.ResourceStatistics"
+
+// Collector samples resource usage.
+type Collector interface {
+	Collect(context.Context) (*mesos.ResourceStatistics, error)
+}
+
+// CollectorFunc is the functional adaptation of Collector.
+type CollectorFunc func(context.Context) (*mesos.ResourceStatistics, error)
+
+// Collect implements Collector for CollectorFunc.
+func (f CollectorFunc) Collect(ctx context.Context) (*mesos.ResourceStatistics, error) { return f(ctx) }
+
+var _ = Collector(CollectorFunc(nil))
+
+// Report samples resource usage every interval, until ctx is done, passing each sample to the given func.
+// Report returns the first error returned by the collector or by f, otherwise ctx.Err().
+func Report(ctx context.Context, c Collector, interval time.Duration, f func(*mesos.ResourceStatistics) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		stats, err := c.Collect(ctx)
+		if err == nil {
+			err = f(stats)
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Calls returns the executor MESSAGE calls that carry the given statistics to the scheduler.
+func Calls(enc *messages.Encoder, stats *mesos.ResourceStatistics) ([]*executor.Call, error) {
+	data, err := proto.Marshal(stats)
+	if err != nil {
+		return nil, err
+	}
+	return enc.ExecutorCalls(MessageKind, data)
+}
+
+// Decode returns the statistics carried by a (reassembled) message of kind MessageKind.
+func Decode(m *messages.Message) (*mesos.ResourceStatistics, error) {
+	var stats mesos.ResourceStatistics
+	if err := proto.Unmarshal(m.Payload, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Handler returns an http.Handler that responds to each request with a JSON-encoded sample of the
+// collector's statistics.
+func Handler(c Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats, err := c.Collect(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	})
+}
+
+func timestamp(t time.Time) float64 { return float64(t.UnixNano()) / float64(time.Second) }
@@ -0,0 +1,179 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/messages"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestCgroupV1(t *testing.T) {
+	root, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFiles(t, filepath.Join(root, "cpu", "mesos", "c1"), map[string]string{
+		"cpu.cfs_quota_us":  "150000\n",
+		"cpu.cfs_period_us": "100000\n",
+		"cpu.stat":          "nr_periods 10\nnr_throttled 2\nthrottled_time 1500000000\n",
+	})
+	writeFiles(t, filepath.Join(root, "cpuacct", "mesos", "c1"), map[string]string{
+		"cpuacct.stat": "user 250\nsystem 50\n",
+	})
+	writeFiles(t, filepath.Join(root, "memory", "mesos", "c1"), map[string]string{
+		"memory.usage_in_bytes": "4096\n",
+		"memory.limit_in_bytes": "8192\n",
+		"memory.stat":           "cache 1024\nrss 2048\nmapped_file 512\nswap 0\n",
+	})
+	proc := filepath.Join(root, "cgroup")
+	writeFiles(t, root, map[string]string{
+		"cgroup": "11:memory:/mesos/c1\n4:cpu,cpuacct:/mesos/c1\n1:name=systemd:/\n",
+	})
+
+	c, err := selfCgroup(root, proc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		got, want interface{}
+	}{
+		{stats.GetCPUsUserTimeSecs(), 2.5},
+		{stats.GetCPUsSystemTimeSecs(), 0.5},
+		{stats.GetCPUsLimit(), 1.5},
+		{stats.GetCPUsNrPeriods(), uint32(10)},
+		{stats.GetCPUsNrThrottled(), uint32(2)},
+		{stats.GetCPUsThrottledTimeSecs(), 1.5},
+		{stats.GetMemTotalBytes(), uint64(4096)},
+		{stats.GetMemLimitBytes(), uint64(8192)},
+		{stats.GetMemRSSBytes(), uint64(2048)},
+		{stats.GetMemCacheBytes(), uint64(1024)},
+		{stats.GetMemMappedFileBytes(), uint64(512)},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("test case %d failed: expected %v instead of %v", i, tc.want, tc.got)
+		}
+	}
+	if stats.Timestamp == 0 {
+		t.Error("expected a timestamp")
+	}
+}
+
+func TestCgroupV2(t *testing.T) {
+	root, cleanup := tempDir(t)
+	defer cleanup()
+
+	writeFiles(t, filepath.Join(root, "mesos", "c2"), map[string]string{
+		"cpu.stat":       "usage_usec 3000000\nuser_usec 2000000\nsystem_usec 1000000\nnr_periods 4\nnr_throttled 1\nthrottled_usec 250000\n",
+		"cpu.max":        "max 100000\n",
+		"memory.current": "4096\n",
+		"memory.max":     "max\n",
+		"memory.stat":    "anon 2048\nfile 1024\nfile_mapped 256\n",
+	})
+	proc := filepath.Join(root, "cgroup")
+	writeFiles(t, root, map[string]string{"cgroup": "0::/mesos/c2\n"})
+
+	c, err := selfCgroup(root, proc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		got, want interface{}
+	}{
+		{stats.GetCPUsUserTimeSecs(), 2.0},
+		{stats.GetCPUsSystemTimeSecs(), 1.0},
+		{stats.GetCPUsNrPeriods(), uint32(4)},
+		{stats.GetCPUsNrThrottled(), uint32(1)},
+		{stats.GetCPUsThrottledTimeSecs(), 0.25},
+		{stats.GetMemTotalBytes(), uint64(4096)},
+		{stats.GetMemAnonBytes(), uint64(2048)},
+		{stats.GetMemFileBytes(), uint64(1024)},
+		{stats.GetMemMappedFileBytes(), uint64(256)},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("test case %d failed: expected %v instead of %v", i, tc.want, tc.got)
+		}
+	}
+	// unlimited
+	if stats.CPUsLimit != nil || stats.MemLimitBytes != nil {
+		t.Errorf("expected no limits instead of %v, %v", stats.CPUsLimit, stats.MemLimitBytes)
+	}
+
+	// a missing cgroup is an error
+	if _, err = CgroupV2(filepath.Join(root, "missing")).Collect(context.Background()); err == nil {
+		t.Error("expected an error for a missing cgroup")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	var (
+		cpus = 1.5
+		in   = &mesos.ResourceStatistics{Timestamp: 1, CPUsUserTimeSecs: &cpus}
+		enc  messages.Encoder
+	)
+	calls, err := Calls(&enc, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received *mesos.ResourceStatistics
+	r := messages.Registry{}
+	r.Register(MessageKind, func(_ context.Context, _ string, m *messages.Message) (err error) {
+		received, err = Decode(m)
+		return
+	})
+	for _, c := range calls {
+		if err = r.Dispatch(context.Background(), "e1", c.GetMessage().GetData()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if received == nil || !received.Equal(in) {
+		t.Fatalf("expected %v instead of %v", in, received)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	mem := uint64(4096)
+	h := Handler(CollectorFunc(func(context.Context) (*mesos.ResourceStatistics, error) {
+		return &mesos.ResourceStatistics{Timestamp: 1, MemTotalBytes: &mem}, nil
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/usage", nil))
+	var stats mesos.ResourceStatistics
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.GetMemTotalBytes() != mem {
+		t.Fatalf("expected %d instead of %d", mem, stats.GetMemTotalBytes())
+	}
+}
@@ -0,0 +1,193 @@
+// Package flags provides typed access to the flag configuration that's reported by the GET_FLAGS calls of
+// masters and agents, and compares the configuration of multiple nodes; e.g. for tools that detect the
+// drift of configuration across a cluster's agents.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	agentcalls "github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// ErrNotFound is returned by the typed accessors of Flags for flags that aren't set.
+var ErrNotFound = errors.New("flag not found")
+
+type (
+	// Flags maps the names of flags to their values.
+	Flags map[string]string
+
+	// ParseError is returned by the typed accessors of Flags for values that can't be parsed.
+	ParseError struct {
+		Name  string
+		Value string
+		Err   error
+	}
+
+	// Difference describes a flag whose value isn't the same across all of the compared nodes.
+	Difference struct {
+		Name   string
+		Values map[string]string // values by node, for nodes that set the flag
+		Unset  []string          // nodes that don't set the flag, ordered
+	}
+)
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse flag %s=%q: %v", e.Name, e.Value, e.Err)
+}
+
+// New returns the Flags of the given flags; flags without a value map to the empty string.
+func New(flags ...mesos.Flag) Flags {
+	m := make(Flags, len(flags))
+	for _, f := range flags {
+		m[f.Name] = f.GetValue()
+	}
+	return m
+}
+
+// Lookup returns the value of the named flag, and false if it isn't set.
+func (f Flags) Lookup(name string) (string, bool) {
+	v, ok := f[name]
+	return v, ok
+}
+
+// Names returns the names of the flags, ordered.
+func (f Flags) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Duration returns the value of the named flag, parsed by mesostime.ParseDuration; durations in the form
+// supported by time.ParseDuration are also accepted.
+func (f Flags) Duration(name string) (time.Duration, error) {
+	v, ok := f[name]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	d, err := parseDuration(v)
+	if err != nil {
+		return 0, &ParseError{Name: name, Value: v, Err: err}
+	}
+	return d, nil
+}
+
+// Bool returns the value of the named flag, parsed by strconv.ParseBool.
+func (f Flags) Bool(name string) (bool, error) {
+	v, ok := f[name]
+	if !ok {
+		return false, ErrNotFound
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &ParseError{Name: name, Value: v, Err: err}
+	}
+	return b, nil
+}
+
+// parseDuration parses a duration in the form that's reported by Mesos, see mesostime.ParseDuration, or
+// else in the form that's supported by time.ParseDuration.
+func parseDuration(s string) (time.Duration, error) {
+	d, err := mesostime.ParseDuration(s)
+	if err != nil {
+		if d, err2 := time.ParseDuration(s); err2 == nil {
+			return d, nil
+		}
+	}
+	return d, err
+}
+
+// Diff compares the flags of the given nodes (e.g. agents, keyed by agent ID or hostname) and returns the
+// flags whose values differ, ordered by name. Flags with the given names are ignored; e.g. flags such as
+// "hostname" that are expected to differ from node to node.
+func Diff(nodes map[string]Flags, ignore ...string) (diffs []Difference) {
+	var (
+		ignored = make(map[string]struct{}, len(ignore))
+		names   = make(map[string]struct{})
+		ids     = make([]string, 0, len(nodes))
+	)
+	for _, name := range ignore {
+		ignored[name] = struct{}{}
+	}
+	for id, flags := range nodes {
+		ids = append(ids, id)
+		for name := range flags {
+			if _, ok := ignored[name]; !ok {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	sort.Strings(ids)
+	for name := range names {
+		var (
+			d      = Difference{Name: name, Values: make(map[string]string, len(nodes))}
+			values = make(map[string]struct{})
+		)
+		for _, id := range ids {
+			v, ok := nodes[id][name]
+			if !ok {
+				d.Unset = append(d.Unset, id)
+				continue
+			}
+			d.Values[id] = v
+			values[v] = struct{}{}
+		}
+		if len(values) > 1 || len(d.Unset) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return
+}
+
+// FetchMaster issues a GET_FLAGS call via the given sender and returns the flags of the master that
+// responds.
+func FetchMaster(ctx context.Context, sender mastercalls.Sender) (Flags, error) {
+	resp, err := sender.Send(ctx, mastercalls.NonStreaming(mastercalls.GetFlags()))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r master.Response
+	if err = resp.Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.GetGetFlags() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", master.Call_GET_FLAGS, r.GetType())
+	}
+	return New(r.GetGetFlags().Flags...), nil
+}
+
+// FetchAgent issues a GET_FLAGS call via the given sender and returns the flags of the agent that
+// responds.
+func FetchAgent(ctx context.Context, sender agentcalls.Sender) (Flags, error) {
+	resp, err := sender.Send(ctx, agentcalls.NonStreaming(agentcalls.GetFlags()))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r agent.Response
+	if err = resp.Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.GetGetFlags() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", agent.Call_GET_FLAGS, r.GetType())
+	}
+	return New(r.GetGetFlags().Flags...), nil
+}
@@ -0,0 +1,112 @@
+package flags
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	agentcalls "github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+func flag(name, value string) mesos.Flag { return mesos.Flag{Name: name, Value: &value} }
+
+func TestParseDuration(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want time.Duration
+		ok   bool
+	}{
+		{"15secs", 15 * time.Second, true},
+		{"2mins", 2 * time.Minute, true},
+		{"1.5hrs", 90 * time.Minute, true},
+		{"1days", 24 * time.Hour, true},
+		{"2weeks", 14 * 24 * time.Hour, true},
+		{"100ns", 100, true},
+		{"5us", 5 * time.Microsecond, true},
+		{"20ms", 20 * time.Millisecond, true},
+		{"1m30s", 90 * time.Second, true},
+		{"secs", 0, false},
+		{"10", 0, false},
+	} {
+		d, err := parseDuration(tc.s)
+		if ok := err == nil; ok != tc.ok || d != tc.want {
+			t.Errorf("%q: expected (%v, %v) instead of (%v, %v)", tc.s, tc.want, tc.ok, d, err)
+		}
+	}
+}
+
+func TestFlags(t *testing.T) {
+	f := New(flag("timeout", "5secs"), flag("enabled", "true"), flag("bogus", "x"), mesos.Flag{Name: "empty"})
+	if v, ok := f.Lookup("empty"); !ok || v != "" {
+		t.Fatalf("expected an empty flag instead of (%q, %v)", v, ok)
+	}
+	if d, err := f.Duration("timeout"); err != nil || d != 5*time.Second {
+		t.Fatalf("unexpected duration (%v, %v)", d, err)
+	}
+	if b, err := f.Bool("enabled"); err != nil || !b {
+		t.Fatalf("unexpected bool (%v, %v)", b, err)
+	}
+	if _, err := f.Bool("missing"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound instead of %v", err)
+	}
+	if _, err := f.Duration("bogus"); err == nil {
+		t.Fatal("expected a parse error")
+	} else if pe, ok := err.(*ParseError); !ok || pe.Name != "bogus" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if names := f.Names(); !reflect.DeepEqual(names, []string{"bogus", "empty", "enabled", "timeout"}) {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	nodes := map[string]Flags{
+		"a": {"hostname": "a", "work_dir": "/var/lib/mesos", "isolation": "posix/cpu"},
+		"b": {"hostname": "b", "work_dir": "/var/lib/mesos", "isolation": "cgroups/cpu"},
+		"c": {"hostname": "c", "work_dir": "/var/lib/mesos"},
+	}
+	want := []Difference{{
+		Name:   "isolation",
+		Values: map[string]string{"a": "posix/cpu", "b": "cgroups/cpu"},
+		Unset:  []string{"c"},
+	}}
+	if diffs := Diff(nodes, "hostname"); !reflect.DeepEqual(diffs, want) {
+		t.Fatalf("expected %+v instead of %+v", want, diffs)
+	}
+	if diffs := Diff(map[string]Flags{"a": nodes["a"], "a2": nodes["a"]}); len(diffs) != 0 {
+		t.Fatalf("unexpected differences %+v", diffs)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	flags := []mesos.Flag{flag("quorum", "1")}
+	ms := mastercalls.SenderFunc(func(_ context.Context, r mastercalls.Request) (mesos.Response, error) {
+		if r.Call().GetType() != master.Call_GET_FLAGS {
+			t.Errorf("unexpected call %v", r.Call().GetType())
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = master.Response{Type: master.Response_GET_FLAGS, GetFlags: &master.Response_GetFlags{Flags: flags}}
+			return nil
+		})}, nil
+	})
+	f, err := FetchMaster(context.Background(), ms)
+	if err != nil || f["quorum"] != "1" {
+		t.Fatalf("unexpected master flags (%v, %v)", f, err)
+	}
+
+	as := agentcalls.SenderFunc(func(_ context.Context, r agentcalls.Request) (mesos.Response, error) {
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*agent.Response)) = agent.Response{Type: agent.Response_GET_HEALTH}
+			return nil
+		})}, nil
+	})
+	if _, err := FetchAgent(context.Background(), as); err == nil {
+		t.Fatal("expected an error for an unexpected response")
+	}
+}
@@ -0,0 +1,67 @@
+// +build ignore
+
+package main
+
+import (
+	"os"
+	"text/template"
+)
+
+func main() {
+	Run(cloneTemplate, cloneTestTemplate, os.Args...)
+}
+
+// cloneTemplate generates a Clone method for each of the (protobuf message) types; the notations of the
+// types are used as the names of the generated tests.
+var cloneTemplate = template.Must(template.New("").Parse(`package {{.Package}}
+
+// go generate {{.Args}}
+// GENERATED CODE FOLLOWS; DO NOT EDIT.
+
+import (
+	"github.com/gogo/protobuf/proto"
+{{- range .Imports}}
+	{{ printf "%q" . -}}
+{{end}}
+)
+{{range .Types}}
+// Clone returns a deep copy of m, or else nil if m is nil.
+func (m *{{.Spec}}) Clone() *{{.Spec}} {
+	if m == nil {
+		return nil
+	}
+	return proto.Clone(m).(*{{.Spec}})
+}
+{{end -}}
+`))
+
+var cloneTestTemplate = template.Must(template.New("").Parse(`package {{.Package}}
+
+// go generate {{.Args}}
+// GENERATED CODE FOLLOWS; DO NOT EDIT.
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+{{- range .Imports}}
+	{{ printf "%q" . -}}
+{{end}}
+)
+{{range .Types}}
+func Test{{.Notation}}Clone(t *testing.T) {
+	var none *{{.Spec}}
+	if none.Clone() != nil {
+		t.Fatal("expected the clone of nil to be nil")
+	}
+	var (
+		r     = rand.New(rand.NewSource(time.Now().UnixNano()))
+		m     = NewPopulated{{.Spec}}(r, false)
+		clone = m.Clone()
+	)
+	if clone == m || !m.Equal(clone) {
+		t.Fatalf("expected an equal copy of %v instead of %v", m, clone)
+	}
+}
+{{end -}}
+`))
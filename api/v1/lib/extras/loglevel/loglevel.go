@@ -0,0 +1,111 @@
+// Package loglevel reads and (temporarily) raises the logging verbosity of masters and agents, via the
+// GET_LOGGING_LEVEL and SET_LOGGING_LEVEL operator calls; e.g. for debugging tools that need more verbose
+// logs of specific nodes while a problem is reproduced. Mesos reverts a verbosity level that's been set
+// to the node's original level once the requested duration elapses.
+package loglevel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	agentcalls "github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+type (
+	// Node is a master or agent whose logging verbosity may be read and set.
+	Node interface {
+		// Level returns the current verbosity level of the node.
+		Level(context.Context) (uint32, error)
+		// SetLevel sets the verbosity level of the node for the given duration.
+		SetLevel(ctx context.Context, level uint32, d time.Duration) error
+	}
+
+	// RestoreFunc reverts a verbosity level change before the duration of the change elapses.
+	RestoreFunc func(context.Context) error
+
+	masterNode struct{ sender mastercalls.Sender }
+	agentNode  struct{ sender agentcalls.Sender }
+)
+
+// Master returns the Node of the master that responds to calls via the given sender.
+func Master(sender mastercalls.Sender) Node { return masterNode{sender} }
+
+// Agent returns the Node of the agent that responds to calls via the given sender.
+func Agent(sender agentcalls.Sender) Node { return agentNode{sender} }
+
+func (n masterNode) Level(ctx context.Context) (uint32, error) {
+	resp, err := n.sender.Send(ctx, mastercalls.NonStreaming(mastercalls.GetLoggingLevel()))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	var r master.Response
+	if err = resp.Decode(&r); err != nil {
+		return 0, err
+	}
+	if r.GetGetLoggingLevel() == nil {
+		return 0, fmt.Errorf("unexpected response to %v: %v", master.Call_GET_LOGGING_LEVEL, r.GetType())
+	}
+	return r.GetGetLoggingLevel().Level, nil
+}
+
+func (n masterNode) SetLevel(ctx context.Context, level uint32, d time.Duration) error {
+	return mastercalls.SendNoData(ctx, n.sender, mastercalls.NonStreaming(mastercalls.SetLoggingLevel(level, d)))
+}
+
+func (n agentNode) Level(ctx context.Context) (uint32, error) {
+	resp, err := n.sender.Send(ctx, agentcalls.NonStreaming(agentcalls.GetLoggingLevel()))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	var r agent.Response
+	if err = resp.Decode(&r); err != nil {
+		return 0, err
+	}
+	if r.GetGetLoggingLevel() == nil {
+		return 0, fmt.Errorf("unexpected response to %v: %v", agent.Call_GET_LOGGING_LEVEL, r.GetType())
+	}
+	return r.GetGetLoggingLevel().Level, nil
+}
+
+func (n agentNode) SetLevel(ctx context.Context, level uint32, d time.Duration) error {
+	return agentcalls.SendNoData(ctx, n.sender, agentcalls.NonStreaming(agentcalls.SetLoggingLevel(level, d)))
+}
+
+// Bump raises the verbosity of the node to (at least) the given level for the given duration, after which
+// Mesos reverts it. The level isn't changed if the node is already at least as verbose. The returned func
+// restores the previous level of the node early; it's a noop if the level wasn't changed.
+func Bump(ctx context.Context, n Node, level uint32, d time.Duration) (RestoreFunc, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("illegal duration %v", d)
+	}
+	current, err := n.Level(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current >= level {
+		return func(context.Context) error { return nil }, nil
+	}
+	if err = n.SetLevel(ctx, level, d); err != nil {
+		return nil, err
+	}
+	// Mesos reverts to the original level of the node once the duration of the most recent change elapses,
+	// so setting the previous level for the remainder of the bump is as good as reverting it.
+	deadline := time.Now().Add(d)
+	return func(ctx context.Context) error {
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return nil
+		}
+		return n.SetLevel(ctx, current, remaining)
+	}, nil
+}
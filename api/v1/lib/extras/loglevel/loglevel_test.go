@@ -0,0 +1,103 @@
+package loglevel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/agent"
+	agentcalls "github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+// fakeAgent is an agent whose verbosity level is recorded, and reported, by its sender.
+type fakeAgent struct {
+	level uint32
+	sets  []agent.Call_SetLoggingLevel
+}
+
+func (f *fakeAgent) sender() agentcalls.Sender {
+	return agentcalls.SenderFunc(func(_ context.Context, r agentcalls.Request) (mesos.Response, error) {
+		c := r.Call()
+		switch c.GetType() {
+		case agent.Call_GET_LOGGING_LEVEL:
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				*(u.(*agent.Response)) = agent.Response{
+					Type:            agent.Response_GET_LOGGING_LEVEL,
+					GetLoggingLevel: &agent.Response_GetLoggingLevel{Level: f.level},
+				}
+				return nil
+			})}, nil
+		case agent.Call_SET_LOGGING_LEVEL:
+			f.level = c.GetSetLoggingLevel().Level
+			f.sets = append(f.sets, *c.GetSetLoggingLevel())
+		}
+		return &mesos.ResponseWrapper{}, nil
+	})
+}
+
+func TestBump(t *testing.T) {
+	var (
+		f   = &fakeAgent{level: 1}
+		n   = Agent(f.sender())
+		ctx = context.Background()
+	)
+	restore, err := Bump(ctx, n, 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.sets) != 1 || f.level != 3 || f.sets[0].Duration.Nanoseconds != time.Minute.Nanoseconds() {
+		t.Fatalf("unexpected level changes: %+v", f.sets)
+	}
+	if err = restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.sets) != 2 || f.level != 1 {
+		t.Fatalf("expected the previous level to be restored: %+v", f.sets)
+	}
+	if d := time.Duration(f.sets[1].Duration.Nanoseconds); d <= 0 || d > time.Minute {
+		t.Fatalf("expected the remainder of the bump instead of %v", d)
+	}
+
+	// already verbose enough
+	f = &fakeAgent{level: 3}
+	if restore, err = Bump(ctx, Agent(f.sender()), 2, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	restore(ctx)
+	if len(f.sets) != 0 {
+		t.Fatalf("unexpected level changes: %+v", f.sets)
+	}
+
+	if _, err = Bump(ctx, n, 3, 0); err == nil {
+		t.Fatal("expected an error for a zero duration")
+	}
+}
+
+func TestMaster(t *testing.T) {
+	var set *master.Call_SetLoggingLevel
+	n := Master(mastercalls.SenderFunc(func(_ context.Context, r mastercalls.Request) (mesos.Response, error) {
+		c := r.Call()
+		if c.GetType() == master.Call_SET_LOGGING_LEVEL {
+			set = c.GetSetLoggingLevel()
+			return &mesos.ResponseWrapper{}, nil
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = master.Response{
+				Type:            master.Response_GET_LOGGING_LEVEL,
+				GetLoggingLevel: &master.Response_GetLoggingLevel{Level: 2},
+			}
+			return nil
+		})}, nil
+	}))
+	ctx := context.Background()
+	if level, err := n.Level(ctx); err != nil || level != 2 {
+		t.Fatalf("unexpected level (%v, %v)", level, err)
+	}
+	if err := n.SetLevel(ctx, 4, time.Second); err != nil || set == nil || set.Level != 4 {
+		t.Fatalf("unexpected result of set (%v, %+v)", err, set)
+	}
+}
@@ -0,0 +1,332 @@
+// Package balance spreads read-only operator API calls (e.g. those of monitoring workloads) across all of
+// the masters of a cluster, rather than only the leading master, according to a pluggable Strategy (see
+// RoundRobin and Nearest); which reduces the load on the leader. Masters may be discovered via DNS SRV
+// records, see LookupSRV. Calls of other types, and calls that no master could serve, are sent to the
+// leader.
+//
+// Mesos serves only some calls (e.g. GET_HEALTH, GET_VERSION, and GET_METRICS) at every master; other
+// calls (e.g. GET_STATE) are redirected to the leader by non-leading masters, unless the masters are
+// fronted by read replicas. A Balancer learns which masters redirect which types of calls, and stops
+// sending calls of such types to those masters for a while (see RetryRedirects).
+package balance
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// DefaultTypes are the types of the calls that a Balancer spreads across masters by default.
+var DefaultTypes = []master.Call_Type{
+	master.Call_GET_HEALTH,
+	master.Call_GET_VERSION,
+	master.Call_GET_METRICS,
+	master.Call_GET_STATE,
+}
+
+// DefaultRetryRedirects is the default period for which a master that redirected a type of call isn't
+// sent calls of that type.
+const DefaultRetryRedirects = time.Minute
+
+type (
+	// Endpoint is a master to which calls may be sent.
+	Endpoint struct {
+		Name   string       // identifies the master; e.g. its host:port
+		Sender calls.Sender // e.g. an httpmaster.Sender of the master
+	}
+
+	// Stats are the observations of an endpoint upon which a Strategy bases its decisions.
+	Stats struct {
+		Name     string
+		Latency  time.Duration // moving average of successful calls; zero until a call has succeeded
+		InFlight int
+		Failures int // consecutive
+	}
+
+	// Strategy decides the order in which the endpoints that may serve a call are tried. Strategy funcs
+	// are invoked serially.
+	Strategy interface {
+		// Order returns the indexes of the endpoints, as per the given stats, in the order in which they
+		// should be tried; endpoints that are omitted aren't tried.
+		Order(stats []Stats) []int
+	}
+
+	// StrategyFunc is the functional adaptation of Strategy.
+	StrategyFunc func(stats []Stats) []int
+
+	// Option is a functional configuration option for a Balancer; it returns an Option that acts as an
+	// "undo" if applied to the same Balancer.
+	Option func(*Balancer) Option
+
+	// Balancer is a calls.Sender that spreads calls across endpoints. Balancer funcs are safe to invoke
+	// concurrently.
+	Balancer struct {
+		leader         calls.Sender
+		strategy       Strategy
+		types          map[master.Call_Type]bool
+		retryRedirects time.Duration
+		clock          mesostime.Clock
+
+		m         sync.Mutex
+		endpoints []*endpoint
+	}
+
+	endpoint struct {
+		Endpoint
+		stats     Stats
+		redirects map[master.Call_Type]time.Time // when a redirect of a type of call was last received
+	}
+)
+
+// Order implements Strategy.
+func (f StrategyFunc) Order(stats []Stats) []int { return f(stats) }
+
+// RoundRobin returns a Strategy that tries endpoints in turn: each call begins with the endpoint that
+// follows the one with which the previous call began.
+func RoundRobin() Strategy {
+	next := 0
+	return StrategyFunc(func(stats []Stats) []int {
+		order := make([]int, len(stats))
+		for i := range order {
+			order[i] = (next + i) % len(stats)
+		}
+		next++
+		return order
+	})
+}
+
+// Nearest returns a Strategy that tries endpoints in order of their latency, lowest first; endpoints whose
+// latency is yet unknown are tried first, so that it's measured. Endpoints that failed the given number of
+// consecutive calls (if positive) are tried last.
+func Nearest(maxFailures int) Strategy {
+	return StrategyFunc(func(stats []Stats) []int {
+		order := make([]int, len(stats))
+		for i := range order {
+			order[i] = i
+		}
+		rank := func(s Stats) (bool, time.Duration) {
+			return maxFailures > 0 && s.Failures >= maxFailures, s.Latency
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			fi, li := rank(stats[order[i]])
+			fj, lj := rank(stats[order[j]])
+			if fi != fj {
+				return fj
+			}
+			return li < lj
+		})
+		return order
+	})
+}
+
+// WithStrategy configures the strategy of a Balancer; defaults to RoundRobin.
+func WithStrategy(s Strategy) Option {
+	return func(b *Balancer) Option {
+		old := b.strategy
+		b.strategy = s
+		return WithStrategy(old)
+	}
+}
+
+// Types configures the types of the calls that a Balancer spreads across endpoints; defaults to
+// DefaultTypes. Only calls that don't modify the state of the cluster should be configured.
+func Types(types ...master.Call_Type) Option {
+	return func(b *Balancer) Option {
+		old := make([]master.Call_Type, 0, len(b.types))
+		for t := range b.types {
+			old = append(old, t)
+		}
+		b.types = make(map[master.Call_Type]bool, len(types))
+		for _, t := range types {
+			b.types[t] = true
+		}
+		return Types(old...)
+	}
+}
+
+// RetryRedirects configures the period for which an endpoint that redirected a type of call (i.e. a
+// non-leading master) isn't sent calls of that type; defaults to DefaultRetryRedirects.
+func RetryRedirects(d time.Duration) Option {
+	return func(b *Balancer) Option {
+		old := b.retryRedirects
+		b.retryRedirects = d
+		return RetryRedirects(old)
+	}
+}
+
+// Clock configures the clock by which latencies and redirects are measured; defaults to
+// mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(b *Balancer) Option {
+		old := b.clock
+		b.clock = clock
+		return Clock(old)
+	}
+}
+
+// New returns a Balancer that spreads calls across the given endpoints, and that sends other calls to the
+// given leader sender; e.g. an httpmaster.Sender of an httpcli.Redirector.
+func New(leader calls.Sender, endpoints []Endpoint, opts ...Option) *Balancer {
+	b := &Balancer{
+		leader:         leader,
+		strategy:       RoundRobin(),
+		retryRedirects: DefaultRetryRedirects,
+		clock:          mesostime.SystemClock,
+	}
+	Types(DefaultTypes...)(b)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(b)
+		}
+	}
+	b.SetEndpoints(endpoints)
+	return b
+}
+
+// SetEndpoints replaces the endpoints of the balancer; e.g. as rediscovered by LookupSRV. The stats of
+// endpoints that are retained (by name) are preserved.
+func (b *Balancer) SetEndpoints(endpoints []Endpoint) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	existing := make(map[string]*endpoint, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		existing[ep.Name] = ep
+	}
+	b.endpoints = make([]*endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		ep := existing[e.Name]
+		if ep == nil {
+			ep = &endpoint{stats: Stats{Name: e.Name}, redirects: make(map[master.Call_Type]time.Time)}
+		}
+		ep.Endpoint = e
+		b.endpoints = append(b.endpoints, ep)
+	}
+}
+
+// Stats returns the stats of the endpoints.
+func (b *Balancer) Stats() []Stats {
+	b.m.Lock()
+	defer b.m.Unlock()
+	result := make([]Stats, len(b.endpoints))
+	for i, ep := range b.endpoints {
+		result[i] = ep.stats
+	}
+	return result
+}
+
+// candidates returns the endpoints that may serve a call of the given type, in the order decided by the
+// strategy.
+func (b *Balancer) candidates(t master.Call_Type) []*endpoint {
+	b.m.Lock()
+	defer b.m.Unlock()
+	var (
+		now      = b.clock.Now()
+		eligible = make([]*endpoint, 0, len(b.endpoints))
+		stats    = make([]Stats, 0, len(b.endpoints))
+	)
+	for _, ep := range b.endpoints {
+		if at, ok := ep.redirects[t]; ok && now.Sub(at) < b.retryRedirects {
+			continue
+		}
+		eligible = append(eligible, ep)
+		stats = append(stats, ep.stats)
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+	order := b.strategy.Order(stats)
+	result := make([]*endpoint, 0, len(order))
+	for _, i := range order {
+		if i >= 0 && i < len(eligible) {
+			result = append(result, eligible[i])
+		}
+	}
+	return result
+}
+
+// Send implements calls.Sender. Calls of the configured types are tried at the endpoints decided by the
+// strategy, in order, until one of them succeeds; if none does (or there are no endpoints) then the call
+// is sent to the leader. Streaming requests, and calls of other types, are sent to the leader.
+func (b *Balancer) Send(ctx context.Context, r calls.Request) (mesos.Response, error) {
+	if _, ok := r.(calls.RequestStreaming); ok || !b.types[r.Call().GetType()] {
+		return b.leader.Send(ctx, r)
+	}
+	t := r.Call().GetType()
+	for _, ep := range b.candidates(t) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		resp, err := b.send(ctx, ep, t, r)
+		if err == nil {
+			return resp, nil
+		}
+		if resp != nil {
+			resp.Close()
+		}
+	}
+	return b.leader.Send(ctx, r)
+}
+
+func (b *Balancer) send(ctx context.Context, ep *endpoint, t master.Call_Type, r calls.Request) (mesos.Response, error) {
+	b.m.Lock()
+	ep.stats.InFlight++
+	b.m.Unlock()
+
+	start := b.clock.Now()
+	resp, err := ep.Sender.Send(ctx, r)
+	elapsed := b.clock.Now().Sub(start)
+
+	b.m.Lock()
+	defer b.m.Unlock()
+	ep.stats.InFlight--
+	switch {
+	case err == nil:
+		ep.stats.Failures = 0
+		if ep.stats.Latency == 0 {
+			ep.stats.Latency = elapsed
+		} else {
+			// an exponentially weighted moving average, that favors recent observations
+			ep.stats.Latency = time.Duration(0.7*float64(ep.stats.Latency) + 0.3*float64(elapsed))
+		}
+	case apierrors.CodeNotLeader.Matches(err):
+		ep.redirects[t] = b.clock.Now()
+	case ctx.Err() == nil:
+		ep.stats.Failures++
+	}
+	return resp, err
+}
+
+// LookupSRV returns the endpoints of the masters that are advertised by the SRV records of the given
+// service, protocol, and domain name (e.g. "_mesos-master._tcp.example.com", as per net.LookupSRV); the
+// sender of each endpoint is generated for its host:port. Endpoints are ordered by the priority and
+// weight of their records. If resolver is nil then net.DefaultResolver is used.
+func LookupSRV(ctx context.Context, resolver *net.Resolver, service, proto, name string, sender func(hostport string) calls.Sender) ([]Endpoint, error) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, addrs, err := resolver.LookupSRV(ctx, service, proto, name)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		host := addr.Target
+		if n := len(host); n > 0 && host[n-1] == '.' {
+			host = host[:n-1]
+		}
+		hostport := net.JoinHostPort(host, strconv.Itoa(int(addr.Port)))
+		result = append(result, Endpoint{Name: hostport, Sender: sender(hostport)})
+	}
+	return result, nil
+}
+
+var _ = calls.Sender(&Balancer{})
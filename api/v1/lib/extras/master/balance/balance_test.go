@@ -0,0 +1,120 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+type fakeMaster struct {
+	name  string
+	err   func(master.Call_Type) error
+	calls []master.Call_Type
+}
+
+func (m *fakeMaster) endpoint() Endpoint {
+	return Endpoint{Name: m.name, Sender: calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+		t := r.Call().GetType()
+		m.calls = append(m.calls, t)
+		if m.err != nil {
+			return nil, m.err(t)
+		}
+		return nil, nil
+	})}
+}
+
+func TestBalancer(t *testing.T) {
+	var (
+		clock    = mesostime.NewFakeClock(time.Unix(0, 0))
+		leader   = &fakeMaster{name: "leader"}
+		standby  = &fakeMaster{name: "standby"}
+		redirect = apierrors.CodeNotLeader.Error("")
+		b        = New(leader.endpoint().Sender, []Endpoint{leader.endpoint(), standby.endpoint()},
+			Clock(clock))
+		send = func(c *master.Call) {
+			if _, err := b.Send(context.Background(), calls.NonStreaming(c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	)
+	// non-leading masters redirect GET_STATE calls, but serve GET_METRICS calls
+	standby.err = func(t master.Call_Type) error {
+		if t == master.Call_GET_STATE {
+			return redirect
+		}
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		send(calls.GetMetrics(nil))
+	}
+	if len(leader.calls) != 1 || len(standby.calls) != 1 {
+		t.Fatalf("expected calls to be spread round-robin: %v, %v", leader.calls, standby.calls)
+	}
+
+	standby.calls, leader.calls = nil, nil
+	for i := 0; i < 4; i++ {
+		send(calls.GetState())
+	}
+	if len(standby.calls) != 1 || len(leader.calls) != 4 {
+		t.Fatalf("expected the redirecting master to be skipped: %v, %v", leader.calls, standby.calls)
+	}
+
+	// redirects are retried eventually; calls of other types go to the leader
+	standby.calls, leader.calls = nil, nil
+	clock.Advance(DefaultRetryRedirects)
+	send(calls.GetState())
+	send(calls.GetState())
+	send(calls.MarkAgentGone(mesos.AgentID{Value: "a1"}))
+	if len(standby.calls) != 1 || !reflect.DeepEqual(leader.calls, []master.Call_Type{
+		master.Call_GET_STATE, master.Call_GET_STATE, master.Call_MARK_AGENT_GONE,
+	}) {
+		t.Fatalf("unexpected calls: %v, %v", leader.calls, standby.calls)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	for _, tc := range []struct {
+		stats []Stats
+		want  []int
+	}{
+		{[]Stats{{Latency: 3}, {Latency: 1}, {Latency: 2}}, []int{1, 2, 0}},
+		{[]Stats{{Latency: 3}, {}, {Latency: 2}}, []int{1, 2, 0}},
+		{[]Stats{{Latency: 1, Failures: 2}, {Latency: 3}, {Latency: 2}}, []int{2, 1, 0}},
+	} {
+		if got := Nearest(2).Order(tc.stats); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("expected order %v instead of %v for %+v", tc.want, got, tc.stats)
+		}
+	}
+
+	// failures are counted, and latencies measured, by the balancer
+	var (
+		clock  = mesostime.NewFakeClock(time.Unix(0, 0))
+		failed = errors.New("failed")
+		a      = &fakeMaster{name: "a", err: func(master.Call_Type) error { return failed }}
+		slow   = calls.SenderFunc(func(context.Context, calls.Request) (mesos.Response, error) {
+			clock.Advance(time.Second)
+			return nil, nil
+		})
+		b = New(slow, []Endpoint{a.endpoint(), {Name: "b", Sender: slow}},
+			WithStrategy(Nearest(1)), Clock(clock))
+	)
+	for i := 0; i < 3; i++ {
+		if _, err := b.Send(context.Background(), calls.NonStreaming(calls.GetHealth())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(a.calls) != 1 {
+		t.Fatalf("expected the failing endpoint to be tried once, instead of %d times", len(a.calls))
+	}
+	if s := b.Stats(); s[0].Failures != 1 || s[1].Latency != time.Second || s[1].InFlight != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
@@ -0,0 +1,140 @@
+// Package features probes a Mesos master, via the operator API, for its version and capabilities so
+// that libraries and frameworks may gate the use of newer APIs instead of failing at call time.
+package features
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+// Version is a parsed Mesos version; pre-release and build suffixes (e.g. "-rc1") are ignored.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses a version string of the form "1.10.0" (the patch component is optional).
+func ParseVersion(s string) (v Version, err error) {
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("illegal version %q", s)
+	}
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i := range parts {
+		if *fields[i], err = strconv.Atoi(parts[i]); err != nil || *fields[i] < 0 {
+			return Version{}, fmt.Errorf("illegal version %q", s)
+		}
+	}
+	return v, nil
+}
+
+func (v Version) String() string { return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch) }
+
+// AtLeast returns true if v is the same as, or newer than, the given major.minor version.
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+// Feature names an API feature of the master.
+type Feature string
+
+const (
+	// OperationFeedback: frameworks may request, and must acknowledge, offer operation status updates.
+	OperationFeedback = Feature("operation_feedback")
+	// VolumeResize: the GROW_VOLUME and SHRINK_VOLUME operations (and operator calls).
+	VolumeResize = Feature("volume_resize")
+	// QuotaLimits: UPDATE_QUOTA calls that specify quota guarantees and limits.
+	QuotaLimits = Feature("quota_limits")
+	// AgentUpdate: agents may re-register with updated resources and attributes.
+	AgentUpdate = Feature("agent_update")
+	// AgentDraining: the DRAIN_AGENT, DEACTIVATE_AGENT, and REACTIVATE_AGENT operator calls.
+	AgentDraining = Feature("agent_draining")
+)
+
+// minVersions are the versions of Mesos that first shipped features that aren't otherwise advertised
+// via master capabilities.
+var minVersions = map[Feature]Version{
+	OperationFeedback: {Major: 1, Minor: 6},
+	VolumeResize:      {Major: 1, Minor: 6},
+	QuotaLimits:       {Major: 1, Minor: 9},
+}
+
+// masterCapabilities are the features that the master advertises via MasterInfo capabilities.
+var masterCapabilities = map[Feature]mesos.MasterInfo_Capability_Type{
+	AgentUpdate:   mesos.MasterInfo_Capability_AGENT_UPDATE,
+	AgentDraining: mesos.MasterInfo_Capability_AGENT_DRAINING,
+}
+
+// Features describes the version and the capabilities of a master.
+type Features struct {
+	Version     Version
+	VersionInfo mesos.VersionInfo
+	MasterInfo  *mesos.MasterInfo
+}
+
+// New returns the Features of a master that reports the given version info and master info (which
+// may be nil).
+func New(vi mesos.VersionInfo, mi *mesos.MasterInfo) (*Features, error) {
+	v, err := ParseVersion(vi.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &Features{Version: v, VersionInfo: vi, MasterInfo: mi}, nil
+}
+
+// Supports returns true if the master supports the given feature. Features that the master advertises
+// as capabilities are never inferred from its version. Unknown features are never supported.
+func (f *Features) Supports(feature Feature) bool {
+	if t, ok := masterCapabilities[feature]; ok {
+		for _, c := range f.MasterInfo.GetCapabilities() {
+			if c.GetType() == t {
+				return true
+			}
+		}
+		return false
+	}
+	if v, ok := minVersions[feature]; ok {
+		return f.Version.AtLeast(v.Major, v.Minor)
+	}
+	return false
+}
+
+// Probe issues GET_VERSION and GET_MASTER calls via the given sender and returns the Features of the
+// master that responds.
+func Probe(ctx context.Context, sender calls.Sender) (*Features, error) {
+	vr, err := get(ctx, sender, calls.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	if vr.GetGetVersion() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", master.Call_GET_VERSION, vr.GetType())
+	}
+	mr, err := get(ctx, sender, calls.GetMaster())
+	if err != nil {
+		return nil, err
+	}
+	return New(vr.GetGetVersion().VersionInfo, mr.GetGetMaster().GetMasterInfo())
+}
+
+func get(ctx context.Context, sender calls.Sender, c *master.Call) (*master.Response, error) {
+	resp, err := sender.Send(ctx, calls.NonStreaming(c))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r master.Response
+	if err = resp.Decode(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,72 @@
+package features
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+func TestParseVersion(t *testing.T) {
+	for ti, tc := range []struct {
+		s    string
+		want Version
+		ok   bool
+	}{
+		{"1.10.0", Version{1, 10, 0}, true},
+		{"1.9", Version{1, 9, 0}, true},
+		{"1.11.0-rc1", Version{1, 11, 0}, true},
+		{"1", Version{}, false},
+		{"1.x.0", Version{}, false},
+		{"1.2.3.4", Version{}, false},
+	} {
+		v, err := ParseVersion(tc.s)
+		if (err == nil) != tc.ok || v != tc.want {
+			t.Errorf("test case %d failed: got %v, %v", ti, v, err)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	sender := calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+		var resp master.Response
+		switch r.Call().GetType() {
+		case master.Call_GET_VERSION:
+			resp = master.Response{Type: master.Response_GET_VERSION, GetVersion: &master.Response_GetVersion{
+				VersionInfo: mesos.VersionInfo{Version: "1.8.1"},
+			}}
+		case master.Call_GET_MASTER:
+			resp = master.Response{Type: master.Response_GET_MASTER, GetMaster: &master.Response_GetMaster{
+				MasterInfo: &mesos.MasterInfo{Capabilities: []mesos.MasterInfo_Capability{
+					{Type: mesos.MasterInfo_Capability_AGENT_UPDATE},
+				}},
+			}}
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = resp
+			return nil
+		})}, nil
+	})
+	f, err := Probe(context.Background(), sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Version != (Version{1, 8, 1}) {
+		t.Fatalf("unexpected version %v", f.Version)
+	}
+	for feature, want := range map[Feature]bool{
+		OperationFeedback: true,
+		VolumeResize:      true,
+		QuotaLimits:       false,
+		AgentUpdate:       true,
+		AgentDraining:     false,
+		Feature("bogus"):  false,
+	} {
+		if got := f.Supports(feature); got != want {
+			t.Errorf("feature %q: expected %v instead of %v", feature, want, got)
+		}
+	}
+}
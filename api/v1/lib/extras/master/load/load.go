@@ -0,0 +1,320 @@
+// Package load exercises the operator API of one or more Mesos masters (GET_STATE and GET_METRICS calls,
+// and event subscriptions) concurrently, at configurable rates, and reports the resulting latencies: so
+// that operators may validate the capacity of masters, e.g. before an upgrade. See the bench package for
+// the load testing of schedulers.
+package load
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+type (
+	// Target is a master that's exercised by Run.
+	Target struct {
+		Name   string       // identifies the master in a Report; e.g. its address
+		Sender calls.Sender // e.g. an httpmaster.Sender of the master
+	}
+
+	// Load describes the calls that Run issues to each target. Rates beyond a call per nanosecond are
+	// clamped to that rate.
+	Load struct {
+		// GetStateRate is the number of GET_STATE calls per second; zero disables such calls.
+		GetStateRate float64
+		// GetMetricsRate is the number of GET_METRICS calls per second; zero disables such calls.
+		GetMetricsRate float64
+		// Subscribers is the number of concurrent event subscriptions.
+		Subscribers int
+		// MaxInFlight bounds the number of concurrent calls of each type: calls that would exceed the
+		// bound are skipped (and counted as such) so that an overloaded master isn't overwhelmed by an
+		// ever-increasing backlog. Defaults to DefaultMaxInFlight.
+		MaxInFlight int
+	}
+
+	// Latency summarizes the latencies observed for some kind of call, of some target.
+	Latency struct {
+		Count   int
+		Errors  int
+		Skipped int // calls that weren't issued because MaxInFlight calls were already in flight
+		Min     time.Duration
+		Mean    time.Duration
+		P50     time.Duration
+		P90     time.Duration
+		P99     time.Duration
+		Max     time.Duration
+	}
+
+	// Report summarizes the calls and events observed by Run, by target name.
+	Report struct {
+		Elapsed time.Duration
+		// Calls are keyed by call type; the latency of a SUBSCRIBE call is that of its SUBSCRIBED event.
+		Calls  map[string]map[master.Call_Type]Latency
+		Events map[string]map[master.Event_Type]int // the number of events received, by type
+	}
+
+	// recorder accumulates the observations of a target.
+	recorder struct {
+		m      sync.Mutex
+		calls  map[master.Call_Type]*samples
+		events map[master.Event_Type]int
+	}
+
+	samples struct {
+		durations []time.Duration
+		errors    int
+		skipped   int
+	}
+)
+
+// DefaultMaxInFlight is the default bound on the number of concurrent calls of each type, per target.
+const DefaultMaxInFlight = 16
+
+// Run exercises the given targets, as described by the load, until the context is done; it then reports
+// the calls and events that were observed.
+func Run(ctx context.Context, targets []Target, l Load) *Report {
+	if l.MaxInFlight <= 0 {
+		l.MaxInFlight = DefaultMaxInFlight
+	}
+	var (
+		start     = time.Now()
+		wg        sync.WaitGroup
+		recorders = make([]*recorder, len(targets))
+	)
+	for i, target := range targets {
+		r := &recorder{calls: make(map[master.Call_Type]*samples), events: make(map[master.Event_Type]int)}
+		recorders[i] = r
+		for _, c := range []struct {
+			rate float64
+			call func() *master.Call
+		}{
+			{l.GetStateRate, calls.GetState},
+			{l.GetMetricsRate, func() *master.Call { return calls.GetMetrics(nil) }},
+		} {
+			if c.rate > 0 {
+				wg.Add(1)
+				go func(sender calls.Sender, rate float64, call func() *master.Call) {
+					defer wg.Done()
+					r.issue(ctx, sender, rate, l.MaxInFlight, call)
+				}(target.Sender, c.rate, c.call)
+			}
+		}
+		for j := 0; j < l.Subscribers; j++ {
+			wg.Add(1)
+			go func(sender calls.Sender) {
+				defer wg.Done()
+				r.subscribe(ctx, sender)
+			}(target.Sender)
+		}
+	}
+	wg.Wait()
+
+	report := &Report{
+		Elapsed: time.Since(start),
+		Calls:   make(map[string]map[master.Call_Type]Latency, len(targets)),
+		Events:  make(map[string]map[master.Event_Type]int, len(targets)),
+	}
+	for i, target := range targets {
+		report.Calls[target.Name], report.Events[target.Name] = recorders[i].report()
+	}
+	return report
+}
+
+// issue sends the generated calls at the given rate until the context is done, and waits for the calls
+// that are in flight to complete.
+func (r *recorder) issue(ctx context.Context, sender calls.Sender, rate float64, maxInFlight int, call func() *master.Call) {
+	var (
+		t        = time.NewTicker(interval(rate))
+		inflight = make(chan struct{}, maxInFlight)
+		wg       sync.WaitGroup
+	)
+	defer wg.Wait()
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		c := call()
+		select {
+		case inflight <- struct{}{}:
+		default:
+			r.add(c.GetType(), 0, nil, true)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-inflight; wg.Done() }()
+			start := time.Now()
+			err := send(ctx, sender, c)
+			if ctx.Err() != nil {
+				return // canceled calls aren't representative
+			}
+			r.add(c.GetType(), time.Since(start), err, false)
+		}()
+	}
+}
+
+// interval returns the period of calls that are issued at the given (positive) rate; at least a
+// nanosecond, since tickers require a positive period.
+func interval(rate float64) time.Duration {
+	d := float64(time.Second) / rate
+	switch {
+	case d < 1:
+		return 1
+	case d >= math.MaxInt64:
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// send issues the call, and decodes its response: so that the latency includes the transfer of the
+// (potentially large) response.
+func send(ctx context.Context, sender calls.Sender, c *master.Call) error {
+	resp, err := sender.Send(ctx, calls.NonStreaming(c))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return err
+	}
+	var r master.Response
+	return resp.Decode(&r)
+}
+
+// subscribe maintains an event subscription until the context is done, resubscribing upon errors; the
+// failure, or loss, of a subscription is recorded as an error of a SUBSCRIBE call.
+func (r *recorder) subscribe(ctx context.Context, sender calls.Sender) {
+	for ctx.Err() == nil {
+		start := time.Now()
+		resp, err := sender.Send(ctx, calls.NonStreaming(calls.Subscribe()))
+		if err == nil {
+			for first := true; ; first = false {
+				var e master.Event
+				if err = resp.Decode(&e); err != nil {
+					if _, ok := encoding.IsUnknownEvent(err); !ok {
+						break
+					}
+					err = nil // e.g. an event of a type that was added by a newer version of Mesos
+				}
+				if first {
+					r.add(master.Call_SUBSCRIBE, time.Since(start), nil, false)
+				}
+				r.m.Lock()
+				r.events[e.GetType()]++
+				r.m.Unlock()
+			}
+		}
+		if resp != nil {
+			resp.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		r.add(master.Call_SUBSCRIBE, time.Since(start), err, false)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second): // don't hammer a master that rejects subscriptions
+		}
+	}
+}
+
+func (r *recorder) add(t master.Call_Type, d time.Duration, err error, skipped bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	s := r.calls[t]
+	if s == nil {
+		s = &samples{}
+		r.calls[t] = s
+	}
+	switch {
+	case skipped:
+		s.skipped++
+	case err != nil:
+		s.errors++
+	default:
+		s.durations = append(s.durations, d)
+	}
+}
+
+func (r *recorder) report() (map[master.Call_Type]Latency, map[master.Event_Type]int) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var (
+		latencies = make(map[master.Call_Type]Latency, len(r.calls))
+		events    = make(map[master.Event_Type]int, len(r.events))
+	)
+	for k, s := range r.calls {
+		latencies[k] = s.summarize()
+	}
+	for k, n := range r.events {
+		events[k] = n
+	}
+	return latencies, events
+}
+
+// summarize reports the latencies of successful calls; the Count includes failed calls.
+func (s *samples) summarize() Latency {
+	l := Latency{Count: len(s.durations) + s.errors, Errors: s.errors, Skipped: s.skipped}
+	if len(s.durations) == 0 {
+		return l
+	}
+	sorted := append([]time.Duration(nil), s.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p int) time.Duration { return sorted[(len(sorted)-1)*p/100] }
+	l.Min, l.Max = sorted[0], sorted[len(sorted)-1]
+	l.Mean = total / time.Duration(len(sorted))
+	l.P50, l.P90, l.P99 = percentile(50), percentile(90), percentile(99)
+	return l
+}
+
+// String returns a human-readable, tabular, rendering of the report.
+func (r *Report) String() string {
+	var (
+		buf   bytes.Buffer
+		w     = tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+		names []string
+	)
+	for name := range r.Calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "elapsed %v\n", r.Elapsed)
+	fmt.Fprintln(w, "\t\tcount\terrors\tskipped\tmin\tmean\tp50\tp90\tp99\tmax")
+	for _, name := range names {
+		var types []master.Call_Type
+		for t := range r.Calls[name] {
+			types = append(types, t)
+		}
+		sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+		for _, t := range types {
+			l := r.Calls[name][t]
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%v\t%v\t%v\t%v\t%v\t%v\n",
+				name, t, l.Count, l.Errors, l.Skipped, l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+		}
+		n := 0
+		for _, count := range r.Events[name] {
+			n += count
+		}
+		if n > 0 {
+			fmt.Fprintf(w, "%s\tevents\t%d\n", name, n)
+		}
+	}
+	w.Flush()
+	return buf.String()
+}
@@ -0,0 +1,115 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+)
+
+// fakeMaster responds to GET_STATE and GET_METRICS calls, failing the latter if broken; subscriptions
+// yield a SUBSCRIBED event followed by heartbeats until the context is done.
+func fakeMaster(broken bool) calls.Sender {
+	return calls.SenderFunc(func(ctx context.Context, r calls.Request) (mesos.Response, error) {
+		switch r.Call().GetType() {
+		case master.Call_GET_METRICS:
+			if broken {
+				return nil, errors.New("broken")
+			}
+		case master.Call_SUBSCRIBE:
+			subscribed := false
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				e := u.(*master.Event)
+				if !subscribed {
+					subscribed = true
+					*e = master.Event{Type: master.Event_SUBSCRIBED}
+					return nil
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(5 * time.Millisecond):
+					*e = master.Event{Type: master.Event_HEARTBEAT}
+					return nil
+				}
+			})}, nil
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(encoding.Unmarshaler) error { return nil })}, nil
+	})
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	report := Run(ctx, []Target{{"m1", fakeMaster(false)}, {"m2", fakeMaster(true)}}, Load{
+		GetStateRate:   200,
+		GetMetricsRate: 100,
+		Subscribers:    2,
+	})
+	if report.Elapsed < 100*time.Millisecond {
+		t.Fatalf("unexpected elapsed time %v", report.Elapsed)
+	}
+	for _, name := range []string{"m1", "m2"} {
+		c := report.Calls[name]
+		if l := c[master.Call_GET_STATE]; l.Count == 0 || l.Errors != 0 || l.Max < l.Min {
+			t.Errorf("%s: unexpected GET_STATE latency %+v", name, l)
+		}
+		if l := c[master.Call_SUBSCRIBE]; l.Count != 2 || l.Errors != 0 {
+			t.Errorf("%s: unexpected SUBSCRIBE latency %+v", name, l)
+		}
+		if e := report.Events[name]; e[master.Event_SUBSCRIBED] != 2 || e[master.Event_HEARTBEAT] == 0 {
+			t.Errorf("%s: unexpected events %v", name, e)
+		}
+	}
+	if l := report.Calls["m2"][master.Call_GET_METRICS]; l.Count == 0 || l.Errors != l.Count {
+		t.Errorf("expected GET_METRICS calls of m2 to fail: %+v", l)
+	}
+	if s := report.String(); !strings.Contains(s, "m1") || !strings.Contains(s, "GET_METRICS") {
+		t.Errorf("unexpected rendering %q", s)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	for _, tc := range []struct {
+		rate float64
+		want time.Duration
+	}{
+		{1, time.Second},
+		{200, 5 * time.Millisecond},
+		{1e9, 1},
+		{2e9, 1},
+		{math.Inf(1), 1},
+		{1e-12, math.MaxInt64},
+	} {
+		if d := interval(tc.rate); d != tc.want {
+			t.Errorf("rate %v: expected interval %v instead of %v", tc.rate, tc.want, d)
+		}
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	var (
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		release     = make(chan struct{})
+		slow        = calls.SenderFunc(func(ctx context.Context, _ calls.Request) (mesos.Response, error) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return nil, ctx.Err()
+		})
+	)
+	defer cancel()
+	report := Run(ctx, []Target{{"slow", slow}}, Load{GetStateRate: 1000, MaxInFlight: 1})
+	close(release)
+	if l := report.Calls["slow"][master.Call_GET_STATE]; l.Skipped == 0 {
+		t.Fatalf("expected calls to be skipped: %+v", l)
+	}
+}
@@ -0,0 +1,301 @@
+// Package messages layers conveniences atop the framework messages that are exchanged by schedulers and
+// executors (MESSAGE calls and events): size validation, chunking and reassembly of payloads that are too
+// large to send in a single message, and a registry of handlers that are dispatched by message kind.
+//
+// Each framework message produced by this package carries a small header that precedes the payload:
+//
+//	byte     format version (currently 1)
+//	uvarint  length of the kind, followed by the kind
+//	uvarint  message ID, unique per Encoder
+//	uvarint  chunk index
+//	uvarint  chunk count
+//	...      payload fragment
+//
+// Both the sender and the receiver must use this package (or the same format).
+package messages
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	execcalls "github.com/mesos/mesos-go/api/v1/lib/executor/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	schedcalls "github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+const (
+	// DefaultMaxSize is the default maximum size of a single framework message, including the header.
+	DefaultMaxSize = 256 << 10
+
+	// DefaultMaxAssembledSize is the default maximum size of a reassembled payload.
+	DefaultMaxAssembledSize = 16 << 20
+
+	// DefaultMaxPending is the default maximum number of partially reassembled payloads.
+	DefaultMaxPending = 64
+
+	formatVersion = 1
+)
+
+var (
+	// ErrTooLarge is returned when a message, or a reassembled payload, exceeds the configured maximum size.
+	ErrTooLarge = errors.New("message too large")
+
+	// ErrMalformed is returned when a framework message doesn't carry a valid header.
+	ErrMalformed = errors.New("malformed message")
+
+	// ErrTooManyPending is returned when the maximum number of partially reassembled payloads is reached.
+	ErrTooManyPending = errors.New("too many partially reassembled messages")
+)
+
+// Message is a (reassembled) payload of some kind.
+type Message struct {
+	Kind    string
+	Payload []byte
+}
+
+// Validate returns ErrTooLarge if data exceeds the given maximum size (DefaultMaxSize if max <= 0).
+func Validate(data []byte, max int) error {
+	if max <= 0 {
+		max = DefaultMaxSize
+	}
+	if len(data) > max {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Encoder encodes payloads as framework messages. The zero value is ready to use. Encoder funcs are safe
+// to invoke concurrently.
+type Encoder struct {
+	// MaxSize is the maximum size of each framework message; DefaultMaxSize if zero.
+	MaxSize int
+	// Chunked, if true, splits payloads that are too large for a single framework message into multiple
+	// messages; otherwise such payloads are rejected with ErrTooLarge.
+	Chunked bool
+
+	nextID uint64
+}
+
+// Encode returns the framework messages (typically one) that carry the given payload.
+func (enc *Encoder) Encode(kind string, payload []byte) ([][]byte, error) {
+	max := enc.MaxSize
+	if max <= 0 {
+		max = DefaultMaxSize
+	}
+	var (
+		id       = atomic.AddUint64(&enc.nextID, 1)
+		overhead = 1 + binary.MaxVarintLen64*4 + len(kind)
+		room     = max - overhead
+	)
+	if room <= 0 {
+		return nil, ErrTooLarge
+	}
+	count := 1
+	if len(payload) > room {
+		if !enc.Chunked {
+			return nil, ErrTooLarge
+		}
+		count = (len(payload) + room - 1) / room
+	}
+	result := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * room
+		if end > len(payload) {
+			end = len(payload)
+		}
+		result = append(result, frame(kind, id, i, count, payload[i*room:end]))
+	}
+	return result, nil
+}
+
+func frame(kind string, id uint64, index, count int, fragment []byte) []byte {
+	b := make([]byte, 0, 1+binary.MaxVarintLen64*4+len(kind)+len(fragment))
+	b = append(b, formatVersion)
+	b = appendUvarint(b, uint64(len(kind)))
+	b = append(b, kind...)
+	b = appendUvarint(b, id)
+	b = appendUvarint(b, uint64(index))
+	b = appendUvarint(b, uint64(count))
+	return append(b, fragment...)
+}
+
+func appendUvarint(b []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	return append(b, buf[:n]...)
+}
+
+type header struct {
+	kind         string
+	id           uint64
+	index, count uint64
+}
+
+func parse(data []byte) (h header, fragment []byte, err error) {
+	if len(data) == 0 || data[0] != formatVersion {
+		return h, nil, ErrMalformed
+	}
+	data = data[1:]
+	next := func() (x uint64) {
+		if err != nil {
+			return
+		}
+		var n int
+		if x, n = binary.Uvarint(data); n <= 0 {
+			err = ErrMalformed
+			return
+		}
+		data = data[n:]
+		return
+	}
+	kl := next()
+	if err == nil && kl > uint64(len(data)) {
+		err = ErrMalformed
+	}
+	if err != nil {
+		return
+	}
+	h.kind, data = string(data[:kl]), data[kl:]
+	h.id, h.index, h.count = next(), next(), next()
+	if err == nil && (h.count == 0 || h.index >= h.count) {
+		err = ErrMalformed
+	}
+	return h, data, err
+}
+
+// Assembler reassembles chunked payloads. The zero value is ready to use. Assembler funcs are safe to
+// invoke concurrently.
+type Assembler struct {
+	// MaxSize is the maximum size of a reassembled payload; DefaultMaxAssembledSize if zero.
+	MaxSize int
+	// MaxPending is the maximum number of partially reassembled payloads; DefaultMaxPending if zero.
+	MaxPending int
+
+	m       sync.Mutex
+	pending map[pendingKey]*partial
+}
+
+type (
+	pendingKey struct {
+		source string
+		id     uint64
+	}
+	partial struct {
+		kind   string
+		count  uint64
+		chunks map[uint64][]byte // by index; grown as chunks arrive, rather than by the claimed count
+		size   int
+	}
+)
+
+// Add processes a framework message that was received from the given source (e.g. an executor ID, or
+// "" for messages received by an executor), returning the reassembled message once all of its chunks
+// have been received; otherwise it returns (nil, nil).
+func (a *Assembler) Add(source string, data []byte) (*Message, error) {
+	h, fragment, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	maxSize := a.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxAssembledSize
+	}
+	if h.count == 1 {
+		if len(fragment) > maxSize {
+			return nil, ErrTooLarge
+		}
+		return &Message{Kind: h.kind, Payload: append([]byte(nil), fragment...)}, nil
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+	key := pendingKey{source, h.id}
+	p := a.pending[key]
+	if p == nil {
+		maxPending := a.MaxPending
+		if maxPending <= 0 {
+			maxPending = DefaultMaxPending
+		}
+		if len(a.pending) >= maxPending {
+			return nil, ErrTooManyPending
+		}
+		if h.count > uint64(maxSize) {
+			return nil, ErrTooLarge
+		}
+		if a.pending == nil {
+			a.pending = make(map[pendingKey]*partial)
+		}
+		p = &partial{kind: h.kind, count: h.count, chunks: make(map[uint64][]byte)}
+		a.pending[key] = p
+	}
+	if h.kind != p.kind || h.count != p.count {
+		delete(a.pending, key)
+		return nil, ErrMalformed
+	}
+	if _, ok := p.chunks[h.index]; ok {
+		return nil, nil // duplicate chunk
+	}
+	if p.size += len(fragment); p.size > maxSize {
+		delete(a.pending, key)
+		return nil, ErrTooLarge
+	}
+	p.chunks[h.index] = append([]byte{}, fragment...)
+	if uint64(len(p.chunks)) < p.count {
+		return nil, nil
+	}
+	delete(a.pending, key)
+	payload := make([]byte, 0, p.size)
+	for i := uint64(0); i < p.count; i++ {
+		payload = append(payload, p.chunks[i]...)
+	}
+	return &Message{Kind: p.kind, Payload: payload}, nil
+}
+
+// Forget discards the partially reassembled payloads received from the given source; e.g. once an
+// executor has terminated.
+func (a *Assembler) Forget(source string) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	for k := range a.pending {
+		if k.source == source {
+			delete(a.pending, k)
+		}
+	}
+}
+
+// UnknownKindError is returned by Registry.Dispatch for messages of a kind that has no handler.
+type UnknownKindError string
+
+func (e UnknownKindError) Error() string {
+	return fmt.Sprintf("no handler for message kind %q", string(e))
+}
+
+// SchedulerCalls returns the MESSAGE calls, addressed to the given executor, that carry the payload.
+func (enc *Encoder) SchedulerCalls(agentID, executorID, kind string, payload []byte) ([]*scheduler.Call, error) {
+	chunks, err := enc.Encode(kind, payload)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*scheduler.Call, 0, len(chunks))
+	for _, data := range chunks {
+		result = append(result, schedcalls.Message(agentID, executorID, data))
+	}
+	return result, nil
+}
+
+// ExecutorCalls returns the MESSAGE calls, sent by an executor to its scheduler, that carry the payload.
+func (enc *Encoder) ExecutorCalls(kind string, payload []byte) ([]*executor.Call, error) {
+	chunks, err := enc.Encode(kind, payload)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*executor.Call, 0, len(chunks))
+	for _, data := range chunks {
+		result = append(result, execcalls.Message(data))
+	}
+	return result, nil
+}
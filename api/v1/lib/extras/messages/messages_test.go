@@ -0,0 +1,142 @@
+package messages_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"runtime"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/messages"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func TestEncodeAssemble(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 100)
+
+	if _, err := (&messages.Encoder{MaxSize: 200}).Encode("k", payload); err != messages.ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge instead of %v", err)
+	}
+
+	enc := messages.Encoder{MaxSize: 200, Chunked: true}
+	chunks, err := enc.Encode("k", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks instead of %d", len(chunks))
+	}
+	for _, c := range chunks {
+		if err := messages.Validate(c, 200); err != nil {
+			t.Fatalf("chunk of %d bytes exceeds max size", len(c))
+		}
+	}
+	other, err := enc.Encode("k", []byte("other"))
+	if err != nil || len(other) != 1 {
+		t.Fatalf("unexpected result: %v, %v", other, err)
+	}
+
+	var a messages.Assembler
+	// deliver chunks in reverse, with a duplicate and an interleaved single-chunk message
+	for i := len(chunks) - 1; i >= 0; i-- {
+		m, err := a.Add("e1", chunks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == len(chunks)-1 {
+			if m, err = a.Add("e1", chunks[i]); m != nil || err != nil {
+				t.Fatalf("unexpected result for duplicate chunk: %v, %v", m, err)
+			}
+			if m, err = a.Add("e1", other[0]); err != nil || m == nil || string(m.Payload) != "other" {
+				t.Fatalf("unexpected result for single-chunk message: %v, %v", m, err)
+			}
+			continue
+		}
+		if (m != nil) != (i == 0) {
+			t.Fatalf("unexpected message after chunk %d: %v", i, m)
+		}
+		if m != nil && (m.Kind != "k" || !bytes.Equal(m.Payload, payload)) {
+			t.Fatalf("unexpected reassembled message: %q", m.Payload)
+		}
+	}
+
+	if _, err := a.Add("e1", []byte("garbage")); err != messages.ErrMalformed {
+		t.Fatalf("expected ErrMalformed instead of %v", err)
+	}
+	small := messages.Assembler{MaxSize: 500}
+	for _, c := range chunks {
+		if _, err = small.Add("e1", c); err != nil {
+			break
+		}
+	}
+	if err != messages.ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge instead of %v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	var (
+		enc      messages.Encoder
+		r        messages.Registry
+		received []string
+		ctx      = context.Background()
+	)
+	r.Register("ping", func(_ context.Context, source string, m *messages.Message) error {
+		received = append(received, source+":"+string(m.Payload))
+		return nil
+	})
+	calls, err := enc.SchedulerCalls("agent", "executor", "ping", []byte("hello"))
+	if err != nil || len(calls) != 1 {
+		t.Fatalf("unexpected result: %v, %v", calls, err)
+	}
+	if v := calls[0].GetMessage().GetExecutorID().Value; v != "executor" {
+		t.Fatalf("unexpected executor ID %q", v)
+	}
+
+	// as received by the scheduler, from the executor
+	h := r.SchedulerHandler()
+	e := &scheduler.Event{
+		Type: scheduler.Event_MESSAGE,
+		Message: &scheduler.Event_Message{
+			AgentID:    mesos.AgentID{Value: "agent"},
+			ExecutorID: mesos.ExecutorID{Value: "executor"},
+			Data:       calls[0].GetMessage().GetData(),
+		},
+	}
+	if err := h(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0] != "executor:hello" {
+		t.Fatalf("unexpected messages received: %v", received)
+	}
+
+	chunks, _ := enc.Encode("pong", nil)
+	if err := r.Dispatch(ctx, "", chunks[0]); err != messages.UnknownKindError("pong") {
+		t.Fatalf("expected UnknownKindError instead of %v", err)
+	}
+	r.Otherwise(func(context.Context, string, *messages.Message) error { return nil })
+	if err := r.Dispatch(ctx, "", chunks[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAssemblerClaimedCount(t *testing.T) {
+	// the first of 16M chunks, of a byte each: within DefaultMaxAssembledSize
+	chunk := append([]byte{1, 1, 'k', 1, 0}, make([]byte, binary.MaxVarintLen64)...)
+	chunk = append(chunk[:5+binary.PutUvarint(chunk[5:], messages.DefaultMaxAssembledSize)], 'x')
+
+	var (
+		a      messages.Assembler
+		before runtime.MemStats
+		after  runtime.MemStats
+	)
+	runtime.ReadMemStats(&before)
+	if m, err := a.Add("e1", chunk); m != nil || err != nil {
+		t.Fatalf("unexpected result: %v, %v", m, err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("expected the memory of a partial payload to be that of its chunks, instead of %d bytes", n)
+	}
+}
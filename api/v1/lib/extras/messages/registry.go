@@ -0,0 +1,85 @@
+package messages
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	execevents "github.com/mesos/mesos-go/api/v1/lib/executor/events"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	schedevents "github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+// Handler processes a (reassembled) message that was received from the given source.
+type Handler func(ctx context.Context, source string, m *Message) error
+
+// Registry dispatches framework messages to handlers, by message kind. Registry funcs are safe to invoke
+// concurrently.
+type Registry struct {
+	// Assembler reassembles chunked messages before they're dispatched.
+	Assembler Assembler
+
+	m         sync.RWMutex
+	handlers  map[string]Handler
+	otherwise Handler
+}
+
+// Register sets the handler for messages of the given kind; a nil handler unregisters the kind.
+func (r *Registry) Register(kind string, h Handler) *Registry {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if h == nil {
+		delete(r.handlers, kind)
+		return r
+	}
+	if r.handlers == nil {
+		r.handlers = make(map[string]Handler)
+	}
+	r.handlers[kind] = h
+	return r
+}
+
+// Otherwise sets the handler for messages of kinds that have no registered handler.
+func (r *Registry) Otherwise(h Handler) *Registry {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.otherwise = h
+	return r
+}
+
+// Dispatch reassembles the framework message that was received from the given source and, once it's
+// complete, invokes the handler that's registered for its kind. An UnknownKindError is returned for
+// messages that have neither a registered handler, nor an Otherwise handler.
+func (r *Registry) Dispatch(ctx context.Context, source string, data []byte) error {
+	m, err := r.Assembler.Add(source, data)
+	if err != nil || m == nil {
+		return err
+	}
+	r.m.RLock()
+	h, ok := r.handlers[m.Kind]
+	if !ok {
+		h = r.otherwise
+	}
+	r.m.RUnlock()
+	if h == nil {
+		return UnknownKindError(m.Kind)
+	}
+	return h(ctx, source, m)
+}
+
+// SchedulerHandler returns a handler of scheduler MESSAGE events that dispatches messages to the
+// registry. The source of each message is the ID of the executor that sent it.
+func (r *Registry) SchedulerHandler() schedevents.HandlerFunc {
+	return func(ctx context.Context, e *scheduler.Event) error {
+		m := e.GetMessage()
+		return r.Dispatch(ctx, m.GetExecutorID().Value, m.GetData())
+	}
+}
+
+// ExecutorHandler returns a handler of executor MESSAGE events that dispatches messages to the registry.
+// The source of each message is "".
+func (r *Registry) ExecutorHandler() execevents.HandlerFunc {
+	return func(ctx context.Context, e *executor.Event) error {
+		return r.Dispatch(ctx, "", e.GetMessage().GetData())
+	}
+}
@@ -0,0 +1,447 @@
+// Package acls pre-checks the calls of a framework against a snapshot of the ACLs of a master (i.e. the
+// JSON of its --acls flag) and of its role configuration, reporting the operations that the master would
+// reject: a master reports an authorization failure as an opaque error (or, for offer operations, drops
+// the operation), whereas a Rejection names the operation, the ACL action, and the offending principal
+// and object. Authorization is decided as per the local authorizer of mesos: the first ACL of an action
+// whose subject (principals) and object match the request decides whether it's authorized; if no ACL
+// matches then the request is authorized only if the ACLs are permissive (the default).
+package acls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// Action is an authorizable action, named for the ACLs that govern it.
+type Action string
+
+const (
+	RegisterFrameworks Action = "register_frameworks" // object: role
+	RunTasks           Action = "run_tasks"           // object: user
+	ReserveResources   Action = "reserve_resources"   // object: role
+	UnreserveResources Action = "unreserve_resources" // object: reserver principal
+	CreateVolumes      Action = "create_volumes"      // object: role
+	DestroyVolumes     Action = "destroy_volumes"     // object: creator principal
+)
+
+// EntityType determines which values an Entity matches.
+type EntityType string
+
+const (
+	Some EntityType = "SOME" // the values of the Entity
+	Any  EntityType = "ANY"  // any value
+	None EntityType = "NONE" // no value
+)
+
+type (
+	// Entity is the subject or object of an ACL.
+	Entity struct {
+		Values []string   `json:"values,omitempty"`
+		Type   EntityType `json:"type,omitempty"` // defaults to Some
+	}
+
+	// ACL is an access control rule for a subject (principals) and an object, the field of which depends
+	// upon the action of the ACL (see Action); the other object fields are ignored.
+	ACL struct {
+		Principals         *Entity `json:"principals,omitempty"`
+		Roles              *Entity `json:"roles,omitempty"`
+		Users              *Entity `json:"users,omitempty"`
+		ReserverPrincipals *Entity `json:"reserver_principals,omitempty"`
+		CreatorPrincipals  *Entity `json:"creator_principals,omitempty"`
+	}
+
+	// ACLs are the ACLs of a master, by action, as in the JSON of its --acls flag. ACLs of other actions
+	// are ignored.
+	ACLs struct {
+		Permissive         *bool `json:"permissive,omitempty"` // defaults to true
+		RegisterFrameworks []ACL `json:"register_frameworks,omitempty"`
+		RunTasks           []ACL `json:"run_tasks,omitempty"`
+		ReserveResources   []ACL `json:"reserve_resources,omitempty"`
+		UnreserveResources []ACL `json:"unreserve_resources,omitempty"`
+		CreateVolumes      []ACL `json:"create_volumes,omitempty"`
+		DestroyVolumes     []ACL `json:"destroy_volumes,omitempty"`
+	}
+)
+
+// Parse parses the JSON representation of ACLs, as accepted by the --acls flag of a master.
+func Parse(data []byte) (*ACLs, error) {
+	acls := new(ACLs)
+	if err := json.Unmarshal(data, acls); err != nil {
+		return nil, err
+	}
+	if err := acls.Validate(); err != nil {
+		return nil, err
+	}
+	return acls, nil
+}
+
+// Validate returns an error if any ACL lacks its subject or object, or an entity is malformed.
+func (a *ACLs) Validate() error {
+	for _, action := range []Action{RegisterFrameworks, RunTasks, ReserveResources, UnreserveResources, CreateVolumes, DestroyVolumes} {
+		acls := a.acls(action)
+		for i := range acls {
+			acl := &acls[i]
+			for _, e := range []struct {
+				name   string
+				entity *Entity
+			}{{"principals", acl.Principals}, {"object", acl.object(action)}} {
+				if err := e.entity.validate(); err != nil {
+					return fmt.Errorf("%s[%d]: %s: %v", action, i, e.name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (a *ACLs) acls(action Action) []ACL {
+	switch action {
+	case RegisterFrameworks:
+		return a.RegisterFrameworks
+	case RunTasks:
+		return a.RunTasks
+	case ReserveResources:
+		return a.ReserveResources
+	case UnreserveResources:
+		return a.UnreserveResources
+	case CreateVolumes:
+		return a.CreateVolumes
+	case DestroyVolumes:
+		return a.DestroyVolumes
+	}
+	return nil
+}
+
+func (acl *ACL) object(action Action) *Entity {
+	switch action {
+	case RegisterFrameworks, ReserveResources, CreateVolumes:
+		return acl.Roles
+	case RunTasks:
+		return acl.Users
+	case UnreserveResources:
+		return acl.ReserverPrincipals
+	case DestroyVolumes:
+		return acl.CreatorPrincipals
+	}
+	return nil
+}
+
+func (e *Entity) validate() error {
+	switch {
+	case e == nil:
+		return fmt.Errorf("missing")
+	case e.Type == "" || e.Type == Some:
+		return nil
+	case e.Type != Any && e.Type != None:
+		return fmt.Errorf("unknown type %q", e.Type)
+	case len(e.Values) > 0:
+		return fmt.Errorf("type %v doesn't accept values", e.Type)
+	}
+	return nil
+}
+
+func (e *Entity) typ() EntityType {
+	if e == nil {
+		return Some // matches nothing
+	}
+	if e.Type == "" {
+		return Some
+	}
+	return e.Type
+}
+
+// matches returns true if the acl entity is applicable to the requested value (any value, if empty).
+func (e *Entity) matches(value string) bool {
+	switch e.typ() {
+	case Any, None:
+		return true
+	}
+	return value != "" && e.has(value)
+}
+
+// allows returns true if the acl entity authorizes the requested value (any value, if empty).
+func (e *Entity) allows(value string) bool {
+	switch e.typ() {
+	case Any:
+		return true
+	case None:
+		return false
+	}
+	return value != "" && e.has(value)
+}
+
+func (e *Entity) has(value string) bool {
+	for _, v := range e.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorized returns true if the given principal is authorized to perform the action upon the given
+// object. An empty principal (e.g. of a framework without one) or object is a request for any principal
+// or object, which is only matched by ACLs of type Any or None.
+func (a *ACLs) Authorized(action Action, principal, object string) bool {
+	for _, acl := range a.acls(action) {
+		subject, obj := acl.Principals, acl.object(action)
+		if subject.matches(principal) && obj.matches(object) {
+			return subject.allows(principal) && obj.allows(object)
+		}
+	}
+	return a.Permissive == nil || *a.Permissive
+}
+
+type (
+	// Rejection is a call (or operation thereof) that the master is expected to reject.
+	Rejection struct {
+		Operation int                        // the index of the operation within the ACCEPT call, or -1
+		Type      mesos.Offer_Operation_Type // of the operation, if any
+		Action    Action                     // empty if the rejection isn't due to an ACL
+		Principal string
+		Object    string // e.g. a role or user, depending upon the action
+		Reason    string
+	}
+
+	// Rejections is an error that reports several Rejections.
+	Rejections []Rejection
+)
+
+func (r Rejection) Error() string {
+	var prefix string
+	if r.Operation >= 0 {
+		prefix = fmt.Sprintf("operation %d (%v): ", r.Operation, r.Type)
+	}
+	return prefix + r.Reason
+}
+
+func (rs Rejections) Error() string {
+	msgs := make([]string, len(rs))
+	for i := range rs {
+		msgs[i] = rs[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Err returns rs as an error, or nil if there are no rejections.
+func (rs Rejections) Err() error {
+	if len(rs) == 0 {
+		return nil
+	}
+	return rs
+}
+
+type (
+	// Option is a functional configuration option for a Checker; it returns an Option that acts as an
+	// "undo" if applied to the same Checker.
+	Option func(*Checker) Option
+
+	// Checker checks frameworks and their offer operations against a snapshot of the ACLs and roles of
+	// a master. Checker funcs are safe to invoke concurrently.
+	Checker struct {
+		acls  ACLs
+		roles map[string]struct{} // nil: any role is acceptable
+	}
+)
+
+// Roles restricts the acceptable roles to those given, e.g. to the roles of a master with a --roles
+// whitelist, or those reported by GET_ROLES; by default, any role is acceptable. A nil slice accepts any
+// role.
+func Roles(roles ...string) Option {
+	return func(c *Checker) Option {
+		var old []string
+		if c.roles != nil {
+			old = make([]string, 0, len(c.roles))
+			for r := range c.roles {
+				old = append(old, r)
+			}
+		}
+		c.roles = nil
+		if roles != nil {
+			c.roles = make(map[string]struct{}, len(roles))
+			for _, r := range roles {
+				c.roles[r] = struct{}{}
+			}
+		}
+		return Roles(old...)
+	}
+}
+
+// NewChecker returns a Checker of the given ACLs.
+func NewChecker(acls ACLs, opts ...Option) *Checker {
+	c := &Checker{acls: acls}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c
+}
+
+func (c *Checker) knownRole(role string) bool {
+	if c.roles == nil || role == "*" {
+		return true
+	}
+	_, ok := c.roles[role]
+	return ok
+}
+
+func frameworkRoles(info *mesos.FrameworkInfo) []string {
+	if roles := info.GetRoles(); len(roles) > 0 {
+		return roles
+	}
+	if info.Role != nil {
+		return []string{info.GetRole()}
+	}
+	return []string{"*"}
+}
+
+// CheckFramework returns the rejections of a subscription (or framework update) with the given info:
+// the principal of the framework must be authorized to register with each of its roles.
+func (c *Checker) CheckFramework(info *mesos.FrameworkInfo) (rs Rejections) {
+	principal := info.GetPrincipal()
+	for _, role := range frameworkRoles(info) {
+		if !c.knownRole(role) {
+			rs = append(rs, Rejection{Operation: -1, Object: role, Principal: principal,
+				Reason: fmt.Sprintf("unknown role %q", role)})
+			continue
+		}
+		if !c.acls.Authorized(RegisterFrameworks, principal, role) {
+			rs = append(rs, Rejection{Operation: -1, Action: RegisterFrameworks, Principal: principal, Object: role,
+				Reason: fmt.Sprintf("principal %q isn't authorized to register with role %q", principal, role)})
+		}
+	}
+	return
+}
+
+// CheckOperations returns the rejections of the given offer operations of the framework with the given
+// info; operations of types other than LAUNCH, LAUNCH_GROUP, RESERVE, UNRESERVE, CREATE, and DESTROY are
+// not checked.
+func (c *Checker) CheckOperations(info *mesos.FrameworkInfo, ops ...mesos.Offer_Operation) (rs Rejections) {
+	var (
+		principal = info.GetPrincipal()
+		roles     = make(map[string]struct{})
+	)
+	for _, role := range frameworkRoles(info) {
+		roles[role] = struct{}{}
+	}
+	for i := range ops {
+		op := &ops[i]
+		reject := func(action Action, object, reason string, args ...interface{}) {
+			rs = append(rs, Rejection{Operation: i, Type: op.GetType(), Action: action, Principal: principal,
+				Object: object, Reason: fmt.Sprintf(reason, args...)})
+		}
+		authorize := func(action Action, object, what string) {
+			if !c.acls.Authorized(action, principal, object) {
+				reject(action, object, "principal %q isn't authorized to %s", principal, what)
+			}
+		}
+		runAs := func(user string) {
+			if user == "" {
+				user = info.GetUser()
+			}
+			authorize(RunTasks, user, fmt.Sprintf("run tasks as user %q", user))
+		}
+		allocated := func(resources []mesos.Resource) {
+			for j := range resources {
+				role := resources[j].GetAllocationInfo().GetRole()
+				if _, ok := roles[role]; role != "" && !ok {
+					reject("", role, "resources are allocated to role %q, which isn't a role of the framework", role)
+				}
+			}
+		}
+		switch op.GetType() {
+		case mesos.Offer_Operation_LAUNCH:
+			for _, t := range op.GetLaunch().GetTaskInfos() {
+				user := t.GetCommand().GetUser()
+				if user == "" {
+					user = t.GetExecutor().GetCommand().GetUser()
+				}
+				runAs(user)
+				allocated(t.Resources)
+				allocated(t.GetExecutor().GetResources())
+			}
+		case mesos.Offer_Operation_LAUNCH_GROUP:
+			lg := op.GetLaunchGroup()
+			executor := lg.GetExecutor()
+			runAs(executor.GetCommand().GetUser())
+			allocated(executor.GetResources())
+			for _, t := range lg.GetTaskGroup().Tasks {
+				if user := t.GetCommand().GetUser(); user != "" {
+					runAs(user)
+				}
+				allocated(t.Resources)
+			}
+		case mesos.Offer_Operation_RESERVE:
+			for j := range op.GetReserve().GetResources() {
+				r := &op.GetReserve().Resources[j]
+				role := r.ReservationRole()
+				if !c.knownRole(role) {
+					reject("", role, "unknown role %q", role)
+					continue
+				}
+				if rp := reservationPrincipal(r); rp != "" && rp != principal {
+					reject("", role, "the reservation principal %q doesn't match the principal %q of the framework", rp, principal)
+				}
+				authorize(ReserveResources, role, fmt.Sprintf("reserve resources for role %q", role))
+			}
+		case mesos.Offer_Operation_UNRESERVE:
+			for j := range op.GetUnreserve().GetResources() {
+				rp := reservationPrincipal(&op.GetUnreserve().Resources[j])
+				authorize(UnreserveResources, rp, fmt.Sprintf("unreserve resources reserved by %q", rp))
+			}
+		case mesos.Offer_Operation_CREATE:
+			for j := range op.GetCreate().GetVolumes() {
+				v := &op.GetCreate().Volumes[j]
+				role := v.ReservationRole()
+				if cp := v.GetDisk().GetPersistence().GetPrincipal(); cp != "" && cp != principal {
+					reject("", role, "the volume principal %q doesn't match the principal %q of the framework", cp, principal)
+				}
+				authorize(CreateVolumes, role, fmt.Sprintf("create volumes for role %q", role))
+			}
+		case mesos.Offer_Operation_DESTROY:
+			for j := range op.GetDestroy().GetVolumes() {
+				cp := op.GetDestroy().Volumes[j].GetDisk().GetPersistence().GetPrincipal()
+				authorize(DestroyVolumes, cp, fmt.Sprintf("destroy volumes created by %q", cp))
+			}
+		}
+	}
+	return
+}
+
+// reservationPrincipal returns the principal of the (most refined) reservation of the given resource.
+func reservationPrincipal(r *mesos.Resource) string {
+	if rs := r.GetReservations(); len(rs) > 0 {
+		return rs[len(rs)-1].GetPrincipal()
+	}
+	return r.GetReservation().GetPrincipal()
+}
+
+// CallRule returns a Rule that fails, without sending them, the SUBSCRIBE and UPDATE_FRAMEWORK calls
+// (checked via CheckFramework) and ACCEPT calls (checked via CheckOperations against the given framework
+// info) that the master is expected to reject; the error of a failed call is a Rejections.
+func (c *Checker) CallRule(info *mesos.FrameworkInfo) callrules.Rule {
+	return func(ctx context.Context, call *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		var rs Rejections
+		switch call.GetType() {
+		case scheduler.Call_SUBSCRIBE:
+			rs = c.CheckFramework(call.GetSubscribe().GetFrameworkInfo())
+		case scheduler.Call_UPDATE_FRAMEWORK:
+			if u := call.GetUpdateFramework(); u != nil {
+				rs = c.CheckFramework(&u.FrameworkInfo)
+			}
+		case scheduler.Call_ACCEPT:
+			rs = c.CheckOperations(info, call.GetAccept().GetOperations()...)
+		}
+		if len(rs) > 0 {
+			return ctx, call, r, callrules.Error2(err, rs)
+		}
+		return ch(ctx, call, r, err)
+	}
+}
@@ -0,0 +1,157 @@
+package acls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+const testACLs = `{
+  "permissive": false,
+  "register_frameworks": [
+    {"principals": {"values": ["ops"]}, "roles": {"type": "ANY"}},
+    {"principals": {"values": ["web"]}, "roles": {"values": ["web"]}}
+  ],
+  "run_tasks": [
+    {"principals": {"values": ["ops"]}, "users": {"values": ["root"]}},
+    {"principals": {"type": "NONE"}, "users": {"values": ["root"]}},
+    {"principals": {"type": "ANY"}, "users": {"type": "ANY"}}
+  ],
+  "reserve_resources": [
+    {"principals": {"values": ["web"]}, "roles": {"values": ["web"]}}
+  ],
+  "unreserve_resources": [
+    {"principals": {"values": ["web"]}, "reserver_principals": {"values": ["web"]}}
+  ],
+  "create_volumes": [
+    {"principals": {"type": "ANY"}, "roles": {"type": "ANY"}}
+  ],
+  "destroy_volumes": [
+    {"principals": {"values": ["ops"]}, "creator_principals": {"type": "ANY"}}
+  ]
+}`
+
+func TestAuthorized(t *testing.T) {
+	acls, err := Parse([]byte(testACLs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		action            Action
+		principal, object string
+		want              bool
+	}{
+		{RegisterFrameworks, "ops", "anything", true},
+		{RegisterFrameworks, "web", "web", true},
+		{RegisterFrameworks, "web", "db", false}, // no ACL matches, and the ACLs aren't permissive
+		{RegisterFrameworks, "", "web", false},
+		{RunTasks, "ops", "root", true},
+		{RunTasks, "web", "root", false}, // only ops may run tasks as root
+		{RunTasks, "web", "nobody", true},
+		{DestroyVolumes, "web", "web", false},
+		{DestroyVolumes, "ops", "", true},
+	} {
+		if got := acls.Authorized(tc.action, tc.principal, tc.object); got != tc.want {
+			t.Errorf("test case %d: expected %t instead of %t", i, tc.want, got)
+		}
+	}
+	acls.RunTasks[2].Users.Type = None
+	if acls.Authorized(RunTasks, "web", "nobody") {
+		t.Error("expected an ACL with a NONE object to deny")
+	}
+	acls.Permissive = proto.Bool(true)
+	if !acls.Authorized(RegisterFrameworks, "web", "db") {
+		t.Error("expected permissive ACLs to authorize unmatched requests")
+	}
+
+	for _, invalid := range []string{
+		`{"run_tasks": [{"principals": {"type": "ANY"}}]}`,
+		`{"register_frameworks": [{"principals": {"type": "ALL"}, "roles": {"type": "ANY"}}]}`,
+		`{"register_frameworks": [{"principals": {"type": "NONE", "values": ["a"]}, "roles": {"type": "ANY"}}]}`,
+	} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+
+func reserved(role, principal string) mesos.Resource {
+	return mesos.Resource{
+		Name:   "cpus",
+		Type:   mesos.SCALAR.Enum(),
+		Scalar: &mesos.Value_Scalar{Value: 1},
+		Reservations: []mesos.Resource_ReservationInfo{{
+			Type: mesos.Resource_ReservationInfo_DYNAMIC.Enum(), Role: proto.String(role), Principal: proto.String(principal),
+		}},
+	}
+}
+
+func volume(role, principal string) mesos.Resource {
+	r := reserved(role, principal)
+	r.Disk = &mesos.Resource_DiskInfo{Persistence: &mesos.Resource_DiskInfo_Persistence{ID: "v", Principal: proto.String(principal)}}
+	return r
+}
+
+func TestCheckOperations(t *testing.T) {
+	acls, err := Parse([]byte(testACLs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		c    = NewChecker(*acls, Roles("web", "db"))
+		info = &mesos.FrameworkInfo{Principal: proto.String("web"), Roles: []string{"web", "db"}, User: "nobody"}
+	)
+	rs := c.CheckFramework(info)
+	if len(rs) != 1 || rs[0].Action != RegisterFrameworks || rs[0].Object != "db" {
+		t.Fatalf("unexpected rejections %v", rs)
+	}
+	if rs = c.CheckFramework(&mesos.FrameworkInfo{Principal: proto.String("ops"), Role: proto.String("qa")}); len(rs) != 1 || rs[0].Action != "" {
+		t.Fatalf("expected the rejection of an unknown role instead of %v", rs)
+	}
+
+	root := mesos.TaskInfo{Command: &mesos.CommandInfo{User: proto.String("root")}}
+	allocated := mesos.TaskInfo{Resources: []mesos.Resource{{AllocationInfo: &mesos.Resource_AllocationInfo{Role: proto.String("qa")}}}}
+	rs = c.CheckOperations(info,
+		calls.OpLaunch(mesos.TaskInfo{}, root, allocated),
+		calls.OpReserve(reserved("web", "web"), reserved("db", "web"), reserved("web", "ops")),
+		calls.OpUnreserve(reserved("web", "web"), reserved("db", "ops")),
+		calls.OpCreate(volume("db", "web")),
+		calls.OpDestroy(volume("db", "web")),
+	)
+	for i, want := range []struct {
+		op     int
+		action Action
+		object string
+	}{
+		{0, RunTasks, "root"},
+		{0, "", "qa"},
+		{1, ReserveResources, "db"},
+		{1, "", "web"}, // principal mismatch
+		{2, UnreserveResources, "ops"},
+		{4, DestroyVolumes, "web"},
+	} {
+		if i >= len(rs) || rs[i].Operation != want.op || rs[i].Action != want.action || rs[i].Object != want.object {
+			t.Fatalf("expected rejection %d to be %+v instead of %v", i, want, rs)
+		}
+	}
+	if len(rs) != 6 {
+		t.Fatalf("unexpected rejections %v", rs)
+	}
+
+	sent := 0
+	caller := c.CallRule(info).Caller(calls.CallerFunc(func(context.Context, *scheduler.Call) (mesos.Response, error) {
+		sent++
+		return nil, nil
+	}))
+	_, err = caller.Call(context.Background(), calls.Accept(calls.OfferOperations{calls.OpLaunch(root)}.WithOffers(mesos.OfferID{Value: "o"})))
+	if _, ok := err.(Rejections); !ok || sent != 0 {
+		t.Fatalf("expected the call to be rejected instead of %v", err)
+	}
+	if _, err = caller.Call(context.Background(), calls.Accept(calls.OfferOperations{calls.OpLaunch(mesos.TaskInfo{})}.WithOffers(mesos.OfferID{Value: "o"}))); err != nil || sent != 1 {
+		t.Fatalf("expected the call to be sent: %v", err)
+	}
+}
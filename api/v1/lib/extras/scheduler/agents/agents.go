@@ -0,0 +1,298 @@
+// Package agents maintains the health of the agents that run the tasks of a framework: ACTIVE, UNREACHABLE,
+// or GONE, along with the tasks that are affected. It consumes scheduler events (task status updates,
+// agent failures, and offers), operator API (master) events, and reconciliation results: frameworks may
+// trigger replacement logic upon changes to the health of an agent instead of per-task updates.
+package agents
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// Health is the health of an agent, as observed by the framework.
+type Health int
+
+const (
+	// Active agents are registered with the master.
+	Active Health = iota
+	// Unreachable agents have been removed by the master, though they may yet return.
+	Unreachable
+	// Gone agents have been marked gone (e.g. by an operator) and will never return.
+	Gone
+)
+
+var healthNames = map[Health]string{
+	Active:      "ACTIVE",
+	Unreachable: "UNREACHABLE",
+	Gone:        "GONE",
+}
+
+func (h Health) String() string {
+	if s, ok := healthNames[h]; ok {
+		return s
+	}
+	return fmt.Sprintf("Health(%d)", int(h))
+}
+
+type (
+	// Option is a functional configuration option for a Tracker; it returns an Option that acts as an
+	// "undo" if applied to the same Tracker.
+	Option func(*Tracker) Option
+
+	// Agent reports the health of an agent, and the non-terminal tasks of the framework that it runs.
+	Agent struct {
+		ID     string
+		Health Health
+		Since  time.Time // the time of the most recent change of health
+		Tasks  []mesos.TaskID
+	}
+
+	// Tracker tracks the health of agents; agents are forgotten once gone, and no longer running tasks.
+	// Tracker funcs are safe to invoke concurrently.
+	Tracker struct {
+		clock    mesostime.Clock
+		onChange func(a Agent, prev Health)
+
+		m      sync.Mutex
+		agents map[string]*agent
+	}
+
+	agent struct {
+		health Health
+		since  time.Time
+		tasks  map[mesos.TaskID]struct{}
+	}
+)
+
+// Clock configures the clock that times the observed changes of health; defaults to
+// mesostime.SystemClock.
+func Clock(c mesostime.Clock) Option {
+	return func(t *Tracker) Option {
+		old := t.clock
+		t.clock = c
+		return Clock(old)
+	}
+}
+
+// OnChange configures a func that's invoked upon every change to the health of an agent, with the previous
+// health of the agent (newly tracked agents are considered to have been Active). The func is invoked
+// synchronously by the goroutine that reports the change, after the Tracker has been updated.
+func OnChange(f func(a Agent, prev Health)) Option {
+	return func(t *Tracker) Option {
+		old := t.onChange
+		t.onChange = f
+		return OnChange(old)
+	}
+}
+
+// NewTracker returns a Tracker that doesn't track any agents.
+func NewTracker(opts ...Option) *Tracker {
+	t := &Tracker{clock: mesostime.SystemClock, agents: make(map[string]*agent)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	return t
+}
+
+// Agent returns the health of the agent with the given ID.
+func (t *Tracker) Agent(id string) (Agent, bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	a, ok := t.agents[id]
+	if !ok {
+		return Agent{}, false
+	}
+	return a.report(id), true
+}
+
+// Agents returns the health of the tracked agents that have any of the given health (or of all tracked
+// agents, if none is given), sorted by ID.
+func (t *Tracker) Agents(health ...Health) (result []Agent) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for id, a := range t.agents {
+		if len(health) == 0 || a.in(health) {
+			result = append(result, a.report(id))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return
+}
+
+// Update records the given task status: the task is associated with the agent of the status (if any),
+// and the health of the agent is derived from the state of the task and the reason for it. Statuses
+// that are sent by agents or executors indicate that the agent is Active.
+func (t *Tracker) Update(s *mesos.TaskStatus) {
+	id := s.GetAgentID().GetValue()
+	if id == "" {
+		return
+	}
+	var (
+		health Health
+		known  = true
+	)
+	switch st, reason := s.GetState(), s.GetReason(); {
+	case st == mesos.TASK_GONE, st == mesos.TASK_GONE_BY_OPERATOR, reason == mesos.REASON_AGENT_REMOVED_BY_OPERATOR:
+		health = Gone
+	case st == mesos.TASK_UNREACHABLE, reason == mesos.REASON_AGENT_REMOVED, reason == mesos.REASON_AGENT_DISCONNECTED:
+		health = Unreachable
+	case s.GetSource() == mesos.SOURCE_AGENT || s.GetSource() == mesos.SOURCE_EXECUTOR:
+		health = Active
+	default:
+		known = false
+	}
+	var terminated *mesos.TaskID
+	if tasks.IsTerminal(s.GetState()) {
+		terminated = &s.TaskID
+	}
+	t.apply(id, terminated, func(a *agent) (Health, bool) {
+		a.tasks[s.TaskID] = struct{}{}
+		return health, known
+	})
+}
+
+// Failed records the failure of the given agent, as reported by a FAILURE event (without an executor ID):
+// the agent is Unreachable, unless it's already Gone.
+func (t *Tracker) Failed(id string) {
+	t.apply(id, nil, func(a *agent) (Health, bool) { return Unreachable, a.health != Gone })
+}
+
+// Seen records that the given agent is Active, e.g. because it sent an offer.
+func (t *Tracker) Seen(id string) {
+	t.apply(id, nil, func(*agent) (Health, bool) { return Active, true })
+}
+
+// MarkedGone records that the given agent is Gone; e.g. after a successful MARK_AGENT_GONE call.
+func (t *Tracker) MarkedGone(id string) {
+	t.apply(id, nil, func(*agent) (Health, bool) { return Gone, true })
+}
+
+// apply invokes f (which returns the new health of the agent, if any) for the given agent, which is
+// tracked if it isn't already, then reports any change of health. The terminated task (if any) is
+// reported as affected by the change, and is then forgotten.
+func (t *Tracker) apply(id string, terminated *mesos.TaskID, f func(*agent) (Health, bool)) {
+	t.m.Lock()
+	a, ok := t.agents[id]
+	if !ok {
+		a = &agent{health: Active, since: t.clock.Now(), tasks: make(map[mesos.TaskID]struct{})}
+		t.agents[id] = a
+	}
+	var (
+		prev       = a.health
+		health, ch = f(a)
+	)
+	ch = ch && health != prev
+	if ch {
+		a.health, a.since = health, t.clock.Now()
+	}
+	report := a.report(id)
+	if terminated != nil {
+		delete(a.tasks, *terminated)
+	}
+	if a.health == Gone && len(a.tasks) == 0 {
+		delete(t.agents, id)
+	}
+	t.m.Unlock()
+
+	if ch && t.onChange != nil {
+		t.onChange(report, prev)
+	}
+}
+
+// EventRule returns a Rule that records the scheduler events that concern the health of agents: UPDATE,
+// FAILURE, and OFFERS.
+func (t *Tracker) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil {
+			switch e.GetType() {
+			case scheduler.Event_UPDATE:
+				s := e.GetUpdate().GetStatus()
+				t.Update(&s)
+			case scheduler.Event_FAILURE:
+				if f := e.GetFailure(); f.GetExecutorID() == nil && f.GetAgentID() != nil {
+					t.Failed(f.GetAgentID().GetValue())
+				}
+			case scheduler.Event_OFFERS:
+				for _, o := range e.GetOffers().GetOffers() {
+					t.Seen(o.AgentID.Value)
+				}
+			}
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// HandleMasterEvent records the given operator API event: AGENT_ADDED events indicate that the agent is
+// Active, AGENT_REMOVED events that it's Unreachable (unless it's already Gone), and TASK_UPDATED events
+// are recorded as per Update.
+func (t *Tracker) HandleMasterEvent(e *master.Event) {
+	switch e.GetType() {
+	case master.Event_AGENT_ADDED:
+		a := e.GetAgentAdded().GetAgent()
+		if id := a.AgentInfo.GetID().GetValue(); id != "" {
+			t.Seen(id)
+		}
+	case master.Event_AGENT_REMOVED:
+		id := e.GetAgentRemoved().GetAgentID()
+		t.Failed(id.Value)
+	case master.Event_TASK_UPDATED:
+		s := e.GetTaskUpdated().GetStatus()
+		t.Update(&s)
+	}
+}
+
+// Reconcile issues a GET_AGENTS call via the given sender: tracked agents that are registered are Active,
+// and those that aren't registered (nor recovered by the master, pending re-registration) are
+// Unreachable, unless they're already Gone.
+func (t *Tracker) Reconcile(ctx context.Context, sender mastercalls.Sender) error {
+	agents, err := mastercalls.SendGetAgents(ctx, sender)
+	if err != nil {
+		return err
+	}
+	registered := make(map[string]bool, len(agents.Agents)+len(agents.RecoveredAgents))
+	for i := range agents.Agents {
+		registered[agents.Agents[i].AgentInfo.GetID().GetValue()] = true
+	}
+	for i := range agents.RecoveredAgents {
+		registered[agents.RecoveredAgents[i].GetID().GetValue()] = true
+	}
+	for _, a := range t.Agents() {
+		if registered[a.ID] {
+			t.Seen(a.ID)
+		} else {
+			t.Failed(a.ID)
+		}
+	}
+	return nil
+}
+
+func (a *agent) in(health []Health) bool {
+	for _, h := range health {
+		if a.health == h {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *agent) report(id string) Agent {
+	r := Agent{ID: id, Health: a.health, Since: a.since, Tasks: make([]mesos.TaskID, 0, len(a.tasks))}
+	for id := range a.tasks {
+		r.Tasks = append(r.Tasks, id)
+	}
+	sort.Slice(r.Tasks, func(i, j int) bool { return r.Tasks[i].Value < r.Tasks[j].Value })
+	return r
+}
@@ -0,0 +1,89 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func status(agent, task string, st mesos.TaskState, src mesos.TaskStatus_Source) *scheduler.Event {
+	return &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: mesos.TaskStatus{
+		TaskID:  mesos.TaskID{Value: task},
+		AgentID: &mesos.AgentID{Value: agent},
+		State:   st.Enum(),
+		Source:  src.Enum(),
+	}}}
+}
+
+func TestTracker(t *testing.T) {
+	type change struct {
+		id         string
+		prev, next Health
+		tasks      int
+	}
+	var (
+		changes []change
+		tr      = NewTracker(OnChange(func(a Agent, prev Health) {
+			changes = append(changes, change{a.ID, prev, a.Health, len(a.Tasks)})
+		}))
+		ctx    = context.Background()
+		handle = func(e *scheduler.Event) {
+			if err := tr.EventRule().HandleEvent(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	)
+	handle(status("a1", "t1", mesos.TASK_RUNNING, mesos.SOURCE_EXECUTOR))
+	handle(status("a1", "t2", mesos.TASK_RUNNING, mesos.SOURCE_EXECUTOR))
+	handle(status("a2", "t3", mesos.TASK_RUNNING, mesos.SOURCE_EXECUTOR))
+	handle(status("a2", "t3", mesos.TASK_FINISHED, mesos.SOURCE_EXECUTOR))
+	if a, ok := tr.Agent("a1"); !ok || a.Health != Active || len(a.Tasks) != 2 {
+		t.Fatalf("unexpected agent %+v", a)
+	}
+	if a, _ := tr.Agent("a2"); len(a.Tasks) != 0 {
+		t.Fatalf("unexpected agent %+v", a)
+	}
+
+	handle(&scheduler.Event{Type: scheduler.Event_FAILURE, Failure: &scheduler.Event_Failure{AgentID: &mesos.AgentID{Value: "a1"}}})
+	handle(status("a1", "t1", mesos.TASK_UNREACHABLE, mesos.SOURCE_MASTER))
+	if as := tr.Agents(Unreachable); len(as) != 1 || as[0].ID != "a1" {
+		t.Fatalf("unexpected unreachable agents %+v", as)
+	}
+
+	// the agent is marked gone: the last task is reported as affected, then the agent is forgotten
+	handle(status("a1", "t1", mesos.TASK_GONE_BY_OPERATOR, mesos.SOURCE_MASTER))
+	handle(status("a1", "t2", mesos.TASK_GONE_BY_OPERATOR, mesos.SOURCE_MASTER))
+	if _, ok := tr.Agent("a1"); ok {
+		t.Fatal("expected gone agent to be forgotten")
+	}
+	want := []change{{"a1", Active, Unreachable, 2}, {"a1", Unreachable, Gone, 2}}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Fatalf("expected changes %+v instead of %+v", want, changes)
+	}
+
+	// reconciliation: a2 isn't registered, a3 returns
+	tr.HandleMasterEvent(&master.Event{Type: master.Event_AGENT_REMOVED, AgentRemoved: &master.Event_AgentRemoved{AgentID: mesos.AgentID{Value: "a3"}}})
+	if a, _ := tr.Agent("a3"); a.Health != Unreachable {
+		t.Fatalf("unexpected agent %+v", a)
+	}
+	sender := calls.SenderFunc(func(_ context.Context, _ calls.Request) (mesos.Response, error) {
+		resp := master.Response{Type: master.Response_GET_AGENTS, GetAgents: &master.Response_GetAgents{
+			Agents: []master.Response_GetAgents_Agent{{AgentInfo: mesos.AgentInfo{ID: &mesos.AgentID{Value: "a3"}}}},
+		}}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = resp
+			return nil
+		})}, nil
+	})
+	if err := tr.Reconcile(ctx, sender); err != nil {
+		t.Fatal(err)
+	}
+	if as := tr.Agents(); len(as) != 2 || as[0].ID != "a2" || as[0].Health != Unreachable || as[1].Health != Active {
+		t.Fatalf("unexpected agents %+v", as)
+	}
+}
@@ -0,0 +1,429 @@
+// Package audit appends the significant decisions of a scheduler (the launching and killing of tasks, the
+// declining of offers, the reservation of resources, and so on) and the status updates that it receives
+// to an append-only log file, for compliance and audit purposes. Unlike the recording package, which
+// captures everything for the sake of replay, and the trace package, which summarizes recent activity in
+// memory, an audit log retains the complete decisions of a scheduler for as long as its files are kept.
+// Log files are rotated once they reach a configured size.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/recordio"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// Kind identifies the type of object that's captured by an Entry.
+type Kind string
+
+const (
+	KindCall  = Kind("call")
+	KindEvent = Kind("event")
+)
+
+// Format is the encoding of the entries of a Log.
+type Format int
+
+const (
+	// FormatJSON encodes every entry as a line of JSON, with the call or event embedded as a JSON
+	// object: so that logs may be inspected, and searched, with standard tools.
+	FormatJSON Format = iota
+	// FormatProtobuf encodes every entry as a RecordIO frame of JSON, with the call or event embedded
+	// as its (base64 encoded) protobuf encoding: which is more compact, and lossless.
+	FormatProtobuf
+)
+
+// Defaults of a Log.
+const (
+	DefaultMaxSize  = 100 << 20 // bytes
+	DefaultMaxFiles = 10
+)
+
+var (
+	// DefaultCallTypes are the types of the calls that a Log audits by default.
+	DefaultCallTypes = []scheduler.Call_Type{
+		scheduler.Call_TEARDOWN,
+		scheduler.Call_ACCEPT,
+		scheduler.Call_DECLINE,
+		scheduler.Call_ACCEPT_INVERSE_OFFERS,
+		scheduler.Call_DECLINE_INVERSE_OFFERS,
+		scheduler.Call_KILL,
+		scheduler.Call_SHUTDOWN,
+		scheduler.Call_UPDATE_FRAMEWORK,
+	}
+	// DefaultEventTypes are the types of the events that a Log audits by default.
+	DefaultEventTypes = []scheduler.Event_Type{
+		scheduler.Event_SUBSCRIBED,
+		scheduler.Event_RESCIND,
+		scheduler.Event_RESCIND_INVERSE_OFFER,
+		scheduler.Event_UPDATE,
+		scheduler.Event_UPDATE_OPERATION_STATUS,
+		scheduler.Event_FAILURE,
+		scheduler.Event_ERROR,
+	}
+)
+
+// Entry is a single entry of an audit log.
+type Entry struct {
+	Time  time.Time        `json:"time"`
+	Kind  Kind             `json:"kind"`
+	Type  string           `json:"type"`            // e.g. "ACCEPT" or "UPDATE"
+	Error string           `json:"error,omitempty"` // of a call, or of the handling of an event
+	Call  *scheduler.Call  `json:"call,omitempty"`  // as per FormatJSON
+	Event *scheduler.Event `json:"event,omitempty"` // as per FormatJSON
+	// Data is the protobuf encoding of the call or event, as per FormatProtobuf.
+	Data []byte `json:"data,omitempty"`
+}
+
+// Decode decodes the call or event of the entry, whichever its format; one of the results is nil.
+func (e *Entry) Decode() (*scheduler.Call, *scheduler.Event, error) {
+	switch {
+	case e.Call != nil || e.Event != nil:
+		return e.Call, e.Event, nil
+	case e.Kind == KindCall:
+		var c scheduler.Call
+		return &c, nil, c.Unmarshal(e.Data)
+	case e.Kind == KindEvent:
+		var x scheduler.Event
+		return nil, &x, x.Unmarshal(e.Data)
+	default:
+		return nil, nil, fmt.Errorf("entry of unknown kind %q", e.Kind)
+	}
+}
+
+type (
+	// Option is a functional configuration option for a Log; it returns an Option that acts as an "undo"
+	// if applied to the same Log.
+	Option func(*Log) Option
+
+	// Log is an append-only audit log. Log funcs are safe to invoke concurrently.
+	Log struct {
+		path       string
+		format     Format
+		maxSize    int64
+		maxFiles   int
+		sync       bool
+		callTypes  map[scheduler.Call_Type]bool
+		eventTypes map[scheduler.Event_Type]bool
+		clock      mesostime.Clock
+		errorFunc  func(error)
+
+		m    sync.Mutex
+		f    *os.File
+		size int64 // of the current file
+	}
+)
+
+// WithFormat configures the encoding of entries; defaults to FormatJSON. Entries of the current file of an
+// existing log should share the same format.
+func WithFormat(f Format) Option {
+	return func(l *Log) Option {
+		old := l.format
+		l.format = f
+		return WithFormat(old)
+	}
+}
+
+// MaxSize configures the size, in bytes, beyond which the current file is rotated; defaults to
+// DefaultMaxSize. Entries are never split across files, so files may slightly exceed the size.
+func MaxSize(n int64) Option {
+	return func(l *Log) Option {
+		old := l.maxSize
+		l.maxSize = n
+		return MaxSize(old)
+	}
+}
+
+// MaxFiles configures the number of rotated files that are retained (as path.1, the most recent, through
+// path.N); older files are removed. Defaults to DefaultMaxFiles; a negative value retains all files.
+func MaxFiles(n int) Option {
+	return func(l *Log) Option {
+		old := l.maxFiles
+		l.maxFiles = n
+		return MaxFiles(old)
+	}
+}
+
+// Sync configures whether every entry is synced to disk before the rule that appends it returns; i.e.
+// before the outcome of the call, or of the handling of the event, that it captures is reported to the
+// scheduler. Which guarantees that the entries of the decisions whose outcome the scheduler has observed
+// aren't lost, at the expense of throughput. Entries are only appended once the rest of the chain has
+// executed (see CallRule and EventRule) so a crash while a call is in flight may still lose its entry.
+func Sync(sync bool) Option {
+	return func(l *Log) Option {
+		old := l.sync
+		l.sync = sync
+		return Sync(old)
+	}
+}
+
+// CallTypes configures the types of the calls that are audited by the rules of a Log; defaults to
+// DefaultCallTypes.
+func CallTypes(types ...scheduler.Call_Type) Option {
+	return func(l *Log) Option {
+		old := make([]scheduler.Call_Type, 0, len(l.callTypes))
+		for t := range l.callTypes {
+			old = append(old, t)
+		}
+		l.callTypes = make(map[scheduler.Call_Type]bool, len(types))
+		for _, t := range types {
+			l.callTypes[t] = true
+		}
+		return CallTypes(old...)
+	}
+}
+
+// EventTypes configures the types of the events that are audited by the rules of a Log; defaults to
+// DefaultEventTypes.
+func EventTypes(types ...scheduler.Event_Type) Option {
+	return func(l *Log) Option {
+		old := make([]scheduler.Event_Type, 0, len(l.eventTypes))
+		for t := range l.eventTypes {
+			old = append(old, t)
+		}
+		l.eventTypes = make(map[scheduler.Event_Type]bool, len(types))
+		for _, t := range types {
+			l.eventTypes[t] = true
+		}
+		return EventTypes(old...)
+	}
+}
+
+// Clock configures the clock that times entries; defaults to mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(l *Log) Option {
+		old := l.clock
+		l.clock = clock
+		return Clock(old)
+	}
+}
+
+// ErrorFunc configures the func to which the errors that are encountered by the rules of a Log, while
+// appending entries, are reported; such errors don't fail calls, or events.
+func ErrorFunc(f func(error)) Option {
+	return func(l *Log) Option {
+		old := l.errorFunc
+		l.errorFunc = f
+		return ErrorFunc(old)
+	}
+}
+
+// New returns a Log that appends entries to the file at the given path, which is created if it doesn't
+// exist.
+func New(path string, opts ...Option) (*Log, error) {
+	l := &Log{path: path, maxSize: DefaultMaxSize, maxFiles: DefaultMaxFiles, clock: mesostime.SystemClock}
+	CallTypes(DefaultCallTypes...)(l)
+	EventTypes(DefaultEventTypes...)(l)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(l)
+		}
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, fi.Size()
+	return nil
+}
+
+// rotate closes the current file, shifts the rotated files (removing the oldest), and opens a new file.
+func (l *Log) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	l.f = nil
+	n := l.maxFiles
+	if n < 0 {
+		// retain all files: find the first unused suffix
+		for n = 1; ; n++ {
+			if _, err := os.Stat(rotated(l.path, n)); os.IsNotExist(err) {
+				break
+			}
+		}
+	} else if err := os.Remove(rotated(l.path, n)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := n - 1; i >= 0; i-- {
+		if err := os.Rename(rotated(l.path, i), rotated(l.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if n == 0 {
+		if err := os.Remove(l.path); err != nil {
+			return err
+		}
+	}
+	return l.open()
+}
+
+// rotated returns the path of the nth rotated file; zero is the current file.
+func rotated(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Append appends the given entry; its time is assigned unless already set. The call or event of the entry
+// is encoded as per the configured format.
+func (l *Log) Append(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = l.clock.Now()
+	}
+	if l.format == FormatProtobuf {
+		var err error
+		switch {
+		case e.Call != nil:
+			e.Data, err = e.Call.Marshal()
+		case e.Event != nil:
+			e.Data, err = e.Event.Marshal()
+		}
+		if err != nil {
+			return err
+		}
+		e.Call, e.Event = nil, nil
+	}
+	b, err := json.Marshal(&e)
+	if err != nil {
+		return err
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+	if l.size > 0 && l.size+int64(len(b)) >= l.maxSize {
+		if err = l.rotate(); err != nil {
+			return err
+		}
+	}
+	cw := &countingWriter{w: l.f}
+	if l.format == FormatProtobuf {
+		err = recordio.NewWriter(cw).WriteFrame(b)
+	} else {
+		_, err = cw.Write(append(b, '\n'))
+	}
+	l.size += cw.n
+	if err == nil && l.sync {
+		err = l.f.Sync()
+	}
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// Close closes the current file of the log; entries may no longer be appended.
+func (l *Log) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// CallRule returns a Rule that appends an entry for each call of the configured types, once the rest of
+// the chain has executed: so that the entry records whether the master accepted the call.
+func (l *Log) CallRule() callrules.Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		issued := l.clock.Now()
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if l.callTypes[c.GetType()] {
+			e := Entry{Time: issued, Kind: KindCall, Type: c.GetType().String(), Call: c}
+			if err != nil {
+				e.Error = err.Error()
+			}
+			l.report(l.Append(e))
+		}
+		return ctx, c, r, err
+	}
+}
+
+// EventRule returns a Rule that appends an entry for each event of the configured types, once the rest of
+// the chain has executed: so that the entry records whether the event was handled successfully.
+func (l *Log) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		received := l.clock.Now()
+		ctx, e, err = ch(ctx, e, err)
+		if l.eventTypes[e.GetType()] {
+			x := Entry{Time: received, Kind: KindEvent, Type: e.GetType().String(), Event: e}
+			if err != nil {
+				x.Error = err.Error()
+			}
+			l.report(l.Append(x))
+		}
+		return ctx, e, err
+	}
+}
+
+func (l *Log) report(err error) {
+	if err != nil && l.errorFunc != nil {
+		l.errorFunc(err)
+	}
+}
+
+// Reader reads the entries of an audit log file.
+type Reader struct {
+	next func() ([]byte, error)
+}
+
+// NewReader returns a Reader of the entries, of the given format, that are read from r.
+func NewReader(r io.Reader, f Format) *Reader {
+	if f == FormatProtobuf {
+		return &Reader{next: recordio.NewReader(r).ReadFrame}
+	}
+	br := bufio.NewReader(r)
+	return &Reader{next: func() ([]byte, error) {
+		b, err := br.ReadBytes('\n')
+		if err == io.EOF && len(b) > 0 {
+			err = io.ErrUnexpectedEOF // a partially written entry
+		}
+		return b, err
+	}}
+}
+
+// Next returns the next entry, or else io.EOF once all entries have been read.
+func (r *Reader) Next() (e Entry, err error) {
+	b, err := r.next()
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(b, &e)
+	return
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func readAll(t *testing.T, path string, f Format) (entries []Entry) {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r := NewReader(file, f)
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+func TestRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []Format{FormatJSON, FormatProtobuf} {
+		var (
+			path     = filepath.Join(dir, "audit.log")
+			rejected = errors.New("rejected")
+			now      = time.Unix(1000, 0).UTC()
+		)
+		l, err := New(path, WithFormat(f), Sync(true), Clock(mesostime.NewFakeClock(now)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		caller := l.CallRule().CallerF(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+			if c.GetType() == scheduler.Call_KILL {
+				return nil, rejected
+			}
+			return nil, nil
+		})
+		for _, c := range []*scheduler.Call{
+			calls.Decline(mesos.OfferID{Value: "o1"}),
+			calls.Revive(), // not audited
+			calls.Kill("t1", "a1"),
+		} {
+			caller.Call(context.Background(), c)
+		}
+		handler := l.EventRule()
+		for _, e := range []*scheduler.Event{
+			{Type: scheduler.Event_HEARTBEAT}, // not audited
+			{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: mesos.TaskStatus{
+				TaskID: mesos.TaskID{Value: "t1"},
+				State:  mesos.TASK_RUNNING.Enum(),
+			}}},
+		} {
+			handler.HandleEvent(context.Background(), e)
+		}
+		if err = l.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := readAll(t, path, f)
+		if len(entries) != 3 {
+			t.Fatalf("expected 3 entries instead of %v", entries)
+		}
+		for i, expected := range []string{"DECLINE", "KILL", "UPDATE"} {
+			if entries[i].Type != expected || !entries[i].Time.Equal(now) {
+				t.Fatalf("unexpected entry %d: %+v", i, entries[i])
+			}
+		}
+		if entries[1].Error != rejected.Error() || entries[0].Error != "" {
+			t.Fatalf("expected the error of the kill to be audited: %+v", entries)
+		}
+		c, _, err := entries[1].Decode()
+		if err != nil || c.GetKill().TaskID.Value != "t1" {
+			t.Fatalf("unexpected call %v: %v", c, err)
+		}
+		_, e, err := entries[2].Decode()
+		if err != nil || e.GetUpdate().Status.GetState() != mesos.TASK_RUNNING {
+			t.Fatalf("unexpected event %v: %v", e, err)
+		}
+		os.Remove(path)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	l, err := New(path, MaxSize(1), MaxFiles(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"t1", "t2", "t3", "t4"} {
+		if err = l.Append(Entry{Kind: KindCall, Type: "KILL", Call: calls.Kill(id, "")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	// every entry exceeds the size, so each occupies its own file; the oldest was removed
+	for i, id := range []string{"t4", "t3", "t2"} {
+		entries := readAll(t, rotated(path, i), FormatJSON)
+		if len(entries) != 1 || entries[0].Call.GetKill().TaskID.Value != id {
+			t.Fatalf("unexpected entries of file %d: %+v", i, entries)
+		}
+	}
+	if _, err = os.Stat(rotated(path, 3)); !os.IsNotExist(err) {
+		t.Fatalf("expected the oldest file to be removed: %v", err)
+	}
+
+	// entries are appended to an existing file
+	l, err = New(path, MaxFiles(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(Entry{Kind: KindCall, Type: "KILL", Call: calls.Kill("t5", "")})
+	l.Close()
+	if entries := readAll(t, path, FormatJSON); len(entries) != 2 {
+		t.Fatalf("expected 2 entries instead of %+v", entries)
+	}
+	if err = l.Append(Entry{}); err != os.ErrClosed {
+		t.Fatalf("expected an error once closed instead of %v", err)
+	}
+}
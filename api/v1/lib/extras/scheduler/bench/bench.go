@@ -0,0 +1,239 @@
+// Package bench is a load-generation harness for schedulers: it drives a Caller (backed by either a Master
+// that's simulated in-process, or by a real Mesos master) with a configurable offer rate, task churn, and
+// event size, and reports the resulting throughput and latencies so that performance regressions of both
+// the library and of user frameworks may be detected.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+type (
+	// Latency summarizes the latencies observed for some kind of call or event.
+	Latency struct {
+		Count  int
+		Errors int
+		Min    time.Duration
+		Mean   time.Duration
+		P50    time.Duration
+		P90    time.Duration
+		P99    time.Duration
+		Max    time.Duration
+	}
+
+	// Report summarizes the calls and events observed by a Recorder.
+	Report struct {
+		Elapsed  time.Duration
+		Calls    map[scheduler.Call_Type]Latency  // latency of the caller, by call type
+		Events   map[scheduler.Event_Type]Latency // latency of the event handler, by event type
+		Offers   int                              // the number of offers received
+		Launched int                              // the number of tasks launched by ACCEPT calls
+		Updates  map[mesos.TaskState]int          // the number of status updates received, by state
+	}
+
+	// Recorder records the latencies of the calls and events that flow through the Callers and Handlers
+	// that it decorates. Recorder funcs are safe to invoke concurrently.
+	Recorder struct {
+		clock func() time.Time
+
+		m        sync.Mutex
+		start    time.Time
+		calls    map[scheduler.Call_Type]*samples
+		events   map[scheduler.Event_Type]*samples
+		offers   int
+		launched int
+		updates  map[mesos.TaskState]int
+	}
+
+	samples struct {
+		durations []time.Duration
+		errors    int
+	}
+)
+
+// NewRecorder returns a Recorder that measures elapsed time from now.
+func NewRecorder() *Recorder {
+	r := &Recorder{clock: time.Now}
+	r.Reset()
+	return r
+}
+
+// Reset discards everything that's been recorded, and restarts the clock; e.g. following a warm-up period.
+func (r *Recorder) Reset() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.start = r.clock()
+	r.calls = make(map[scheduler.Call_Type]*samples)
+	r.events = make(map[scheduler.Event_Type]*samples)
+	r.updates = make(map[mesos.TaskState]int)
+	r.offers, r.launched = 0, 0
+}
+
+// Caller returns a Caller that records the latency of every call that it delegates to the given Caller.
+func (r *Recorder) Caller(caller calls.Caller) calls.Caller {
+	return calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		t := r.clock()
+		resp, err := caller.Call(ctx, c)
+		d := r.clock().Sub(t)
+
+		r.m.Lock()
+		defer r.m.Unlock()
+		s := r.calls[c.GetType()]
+		if s == nil {
+			s = &samples{}
+			r.calls[c.GetType()] = s
+		}
+		s.add(d, err)
+		if err == nil && c.GetType() == scheduler.Call_ACCEPT {
+			for _, op := range c.GetAccept().GetOperations() {
+				r.launched += len(op.GetLaunch().GetTaskInfos())
+				r.launched += len(op.GetLaunchGroup().GetTaskGroup().Tasks)
+			}
+		}
+		return resp, err
+	})
+}
+
+// Handler returns a Handler that records the latency of the given Handler, for every event.
+func (r *Recorder) Handler(h events.Handler) events.Handler {
+	return events.HandlerFunc(func(ctx context.Context, e *scheduler.Event) error {
+		t := r.clock()
+		err := h.HandleEvent(ctx, e)
+		d := r.clock().Sub(t)
+
+		r.m.Lock()
+		defer r.m.Unlock()
+		s := r.events[e.GetType()]
+		if s == nil {
+			s = &samples{}
+			r.events[e.GetType()] = s
+		}
+		s.add(d, err)
+		switch e.GetType() {
+		case scheduler.Event_OFFERS:
+			r.offers += len(e.GetOffers().GetOffers())
+		case scheduler.Event_UPDATE:
+			s := e.GetUpdate().GetStatus()
+			r.updates[s.GetState()]++
+		}
+		return err
+	})
+}
+
+func (s *samples) add(d time.Duration, err error) {
+	s.durations = append(s.durations, d)
+	if err != nil {
+		s.errors++
+	}
+}
+
+// Report returns a summary of everything that's been recorded so far.
+func (r *Recorder) Report() *Report {
+	r.m.Lock()
+	defer r.m.Unlock()
+	report := &Report{
+		Elapsed:  r.clock().Sub(r.start),
+		Calls:    make(map[scheduler.Call_Type]Latency, len(r.calls)),
+		Events:   make(map[scheduler.Event_Type]Latency, len(r.events)),
+		Offers:   r.offers,
+		Launched: r.launched,
+		Updates:  make(map[mesos.TaskState]int, len(r.updates)),
+	}
+	for k, s := range r.calls {
+		report.Calls[k] = s.summarize()
+	}
+	for k, s := range r.events {
+		report.Events[k] = s.summarize()
+	}
+	for k, n := range r.updates {
+		report.Updates[k] = n
+	}
+	return report
+}
+
+func (s *samples) summarize() Latency {
+	l := Latency{Count: len(s.durations), Errors: s.errors}
+	if l.Count == 0 {
+		return l
+	}
+	sorted := append([]time.Duration(nil), s.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p int) time.Duration { return sorted[(len(sorted)-1)*p/100] }
+	l.Min, l.Max = sorted[0], sorted[len(sorted)-1]
+	l.Mean = total / time.Duration(len(sorted))
+	l.P50, l.P90, l.P99 = percentile(50), percentile(90), percentile(99)
+	return l
+}
+
+// CallRate returns the number of calls per second.
+func (r *Report) CallRate() float64 {
+	n := 0
+	for _, l := range r.Calls {
+		n += l.Count
+	}
+	return r.rate(n)
+}
+
+// EventRate returns the number of events per second.
+func (r *Report) EventRate() float64 {
+	n := 0
+	for _, l := range r.Events {
+		n += l.Count
+	}
+	return r.rate(n)
+}
+
+// LaunchRate returns the number of tasks launched per second.
+func (r *Report) LaunchRate() float64 { return r.rate(r.Launched) }
+
+func (r *Report) rate(n int) float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / r.Elapsed.Seconds()
+}
+
+// String returns a human-readable, tabular, rendering of the report.
+func (r *Report) String() string {
+	var (
+		buf bytes.Buffer
+		w   = tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	)
+	fmt.Fprintf(w, "elapsed %v: %.1f calls/s, %.1f events/s, %.1f launches/s, %d offers\n",
+		r.Elapsed, r.CallRate(), r.EventRate(), r.LaunchRate(), r.Offers)
+	fmt.Fprintln(w, "\tcount\terrors\tmin\tmean\tp50\tp90\tp99\tmax")
+
+	var rows []string
+	lines := map[string]Latency{}
+	for k, l := range r.Calls {
+		name := "call " + k.String()
+		rows, lines[name] = append(rows, name), l
+	}
+	for k, l := range r.Events {
+		name := "event " + k.String()
+		rows, lines[name] = append(rows, name), l
+	}
+	sort.Strings(rows)
+	for _, name := range rows {
+		l := lines[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%v\t%v\n", name, l.Count, l.Errors, l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	}
+	w.Flush()
+	return buf.String()
+}
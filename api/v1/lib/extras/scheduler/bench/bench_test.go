@@ -0,0 +1,52 @@
+package bench_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/bench"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		master = bench.NewMaster(
+			bench.OfferRate(1000),
+			bench.OffersPerEvent(10),
+			bench.TaskDuration(10*time.Millisecond),
+		)
+		ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	)
+	defer cancel()
+	report, err := bench.Run(ctx, master, bench.Workload{TasksPerOffer: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Offers == 0 || report.Launched == 0 || report.LaunchRate() <= 0 {
+		t.Fatalf("expected offers and launches: %v", report)
+	}
+	if report.Updates[mesos.TASK_RUNNING] == 0 || report.Updates[mesos.TASK_FINISHED] == 0 {
+		t.Fatalf("expected task churn: %v", report.Updates)
+	}
+	for _, ct := range []scheduler.Call_Type{scheduler.Call_SUBSCRIBE, scheduler.Call_ACCEPT, scheduler.Call_ACKNOWLEDGE} {
+		if l := report.Calls[ct]; l.Count == 0 || l.Errors != 0 || l.Min > l.P50 || l.P50 > l.P99 || l.P99 > l.Max {
+			t.Errorf("unexpected %v latency: %+v", ct, l)
+		}
+	}
+	if l := report.Events[scheduler.Event_OFFERS]; l.Count == 0 {
+		t.Errorf("expected OFFERS events: %+v", l)
+	}
+	if s := report.String(); !strings.Contains(s, "call ACCEPT") || !strings.Contains(s, "event OFFERS") {
+		t.Errorf("unexpected report: %s", s)
+	}
+}
+
+func TestMasterNotSubscribed(t *testing.T) {
+	if _, err := bench.NewMaster().Call(context.Background(), calls.Revive()); err != bench.ErrNotSubscribed {
+		t.Fatalf("expected ErrNotSubscribed instead of %v", err)
+	}
+}
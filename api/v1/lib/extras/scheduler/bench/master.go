@@ -0,0 +1,218 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/mesostest/gen"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// ErrNotSubscribed is returned by a Master for calls, other than SUBSCRIBE, that are issued while there's
+// no subscription.
+var ErrNotSubscribed = errors.New("bench: not subscribed")
+
+type (
+	// MasterOption is a functional option for a Master; it returns an "undo" option when applied.
+	MasterOption func(*Master) MasterOption
+
+	// Master is a Caller that simulates a Mesos master, in-process, for a single framework. Subscriptions
+	// receive OFFERS events at a configurable rate, and tasks that are launched by ACCEPT calls are
+	// reported as TASK_RUNNING and then, following a configurable duration, as TASK_FINISHED. The resources
+	// of offers are generated randomly (see gen.Generator.Resources) and aren't accounted for: the intent
+	// is load generation, not fidelity. Only the most recent subscription receives events. Master funcs are
+	// safe to invoke concurrently.
+	Master struct {
+		offerRate      float64
+		offersPerEvent int
+		taskDuration   time.Duration
+		seed           int64
+
+		m   sync.Mutex
+		gen *gen.Generator
+		sub *subscription
+	}
+
+	subscription struct {
+		events chan *scheduler.Event
+		done   chan struct{}
+		once   sync.Once
+	}
+)
+
+// OfferRate configures the number of offers, per second, that are sent to the subscribed framework;
+// defaults to 100. Offers aren't sent if the rate is zero.
+func OfferRate(perSecond float64) MasterOption {
+	return func(m *Master) MasterOption {
+		old := m.offerRate
+		m.offerRate = perSecond
+		return OfferRate(old)
+	}
+}
+
+// OffersPerEvent configures the number of offers per OFFERS event; defaults to 1.
+func OffersPerEvent(n int) MasterOption {
+	return func(m *Master) MasterOption {
+		old := m.offersPerEvent
+		m.offersPerEvent = n
+		return OffersPerEvent(old)
+	}
+}
+
+// TaskDuration configures the duration for which launched tasks "run" before they finish; defaults to one
+// second.
+func TaskDuration(d time.Duration) MasterOption {
+	return func(m *Master) MasterOption {
+		old := m.taskDuration
+		m.taskDuration = d
+		return TaskDuration(old)
+	}
+}
+
+// Seed configures the seed of the generator of offers and status updates; defaults to 1.
+func Seed(seed int64) MasterOption {
+	return func(m *Master) MasterOption {
+		old := m.seed
+		m.seed = seed
+		return Seed(old)
+	}
+}
+
+// NewMaster returns a simulated master, configured by the given options.
+func NewMaster(opts ...MasterOption) *Master {
+	m := &Master{
+		offerRate:      100,
+		offersPerEvent: 1,
+		taskDuration:   time.Second,
+		seed:           1,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	if m.offersPerEvent < 1 {
+		m.offersPerEvent = 1
+	}
+	m.gen = gen.New(m.seed)
+	return m
+}
+
+// Call implements calls.Caller for Master.
+func (m *Master) Call(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+	if c.GetType() == scheduler.Call_SUBSCRIBE {
+		return m.subscribe(ctx), nil
+	}
+	m.m.Lock()
+	sub := m.sub
+	m.m.Unlock()
+	if sub == nil {
+		return nil, ErrNotSubscribed
+	}
+	if c.GetType() == scheduler.Call_ACCEPT {
+		for _, op := range c.GetAccept().GetOperations() {
+			for _, t := range op.GetLaunch().GetTaskInfos() {
+				m.launch(sub, t)
+			}
+			for _, t := range op.GetLaunchGroup().GetTaskGroup().Tasks {
+				m.launch(sub, t)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (m *Master) subscribe(ctx context.Context) mesos.Response {
+	sub := &subscription{
+		events: make(chan *scheduler.Event, 1024),
+		done:   make(chan struct{}),
+	}
+	m.m.Lock()
+	if m.sub != nil {
+		m.sub.close()
+	}
+	m.sub = sub
+	subscribed := m.gen.Subscribed()
+	m.m.Unlock()
+
+	sub.send(subscribed)
+	if m.offerRate > 0 {
+		go m.sendOffers(ctx, sub)
+	}
+	return &mesos.ResponseWrapper{
+		Closer: closerFunc(func() error {
+			sub.close()
+			return nil
+		}),
+		Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			e, ok := u.(*scheduler.Event)
+			if !ok {
+				return errors.New("bench: unexpected unmarshaler")
+			}
+			select {
+			case x := <-sub.events:
+				*e = *x
+				return nil
+			case <-sub.done:
+				return io.EOF
+			}
+		}),
+	}
+}
+
+func (m *Master) sendOffers(ctx context.Context, sub *subscription) {
+	interval := time.Duration(float64(time.Second) * float64(m.offersPerEvent) / m.offerRate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.m.Lock()
+			e := gen.OffersEvent(m.gen.Offers(m.offersPerEvent)...)
+			m.m.Unlock()
+			if !sub.send(e) {
+				return
+			}
+		case <-ctx.Done():
+			sub.close()
+			return
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+func (m *Master) launch(sub *subscription, t mesos.TaskInfo) {
+	m.m.Lock()
+	running := gen.UpdateEvent(m.gen.TaskStatus(t.TaskID, t.AgentID, mesos.TASK_RUNNING))
+	finished := gen.UpdateEvent(m.gen.TaskStatus(t.TaskID, t.AgentID, mesos.TASK_FINISHED))
+	m.m.Unlock()
+
+	// ACCEPT calls are usually issued by event handlers: don't block them upon the delivery of events
+	go func() {
+		if sub.send(running) {
+			time.AfterFunc(m.taskDuration, func() { sub.send(finished) })
+		}
+	}()
+}
+
+// send blocks until the event is queued for delivery, returning false if the subscription is closed first.
+func (sub *subscription) send(e *scheduler.Event) bool {
+	select {
+	case sub.events <- e:
+		return true
+	case <-sub.done:
+		return false
+	}
+}
+
+func (sub *subscription) close() { sub.once.Do(func() { close(sub.done) }) }
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,146 @@
+package bench
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/controller"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+// Workload describes the behavior of the framework that's simulated by Run. The zero value is a framework
+// that launches one task per offer, each of which requires 0.1 cpus and 32MB of memory and runs "true".
+type Workload struct {
+	// Framework is the info with which the framework subscribes.
+	Framework *mesos.FrameworkInfo
+	// TasksPerOffer is the maximum number of tasks launched using each offer; offers that don't fit any
+	// task are declined. A negative value declines all offers.
+	TasksPerOffer int
+	// Resources are the resources required by each task.
+	Resources mesos.Resources
+	// Command is the shell command executed by each task.
+	Command string
+}
+
+func (w Workload) withDefaults() Workload {
+	if w.Framework == nil {
+		w.Framework = &mesos.FrameworkInfo{User: "root", Name: "mesos-go-bench"}
+	}
+	if w.TasksPerOffer == 0 {
+		w.TasksPerOffer = 1
+	}
+	if len(w.Resources) == 0 {
+		w.Resources = mesos.Resources{
+			resources.NewCPUs(0.1).Resource,
+			resources.NewMemory(32).Resource,
+		}
+	}
+	if w.Command == "" {
+		w.Command = "true"
+	}
+	return w
+}
+
+// Run subscribes a framework, that behaves as described by the given workload, via the caller (e.g. a
+// Master, or an httpsched.Caller of a real master) until the context is done; it then reports the calls
+// and events that were observed. Status updates are acknowledged. The recorder, if not nil, may be used to
+// observe the progress of the run (e.g. to Reset it following a warm-up period). An error is returned only
+// if the framework failed for some reason other than the cancelation of the context.
+func Run(ctx context.Context, caller calls.Caller, w Workload, r *Recorder) (*Report, error) {
+	if r == nil {
+		r = NewRecorder()
+	}
+	var (
+		f = &framework{
+			Workload: w.withDefaults(),
+			caller:   r.Caller(caller),
+		}
+		err = controller.Run(ctx, f.Framework, f.caller,
+			controller.WithEventHandler(r.Handler(events.HandlerFunc(f.handle))),
+			controller.WithFrameworkID(f.frameworkID),
+		)
+	)
+	if ctx.Err() != nil {
+		err = nil
+	}
+	return r.Report(), err
+}
+
+type framework struct {
+	Workload
+	caller calls.Caller
+	id     atomic.Value // string
+	tasks  uint64
+}
+
+func (f *framework) frameworkID() string {
+	id, _ := f.id.Load().(string)
+	return id
+}
+
+func (f *framework) call(ctx context.Context, c *scheduler.Call) error {
+	resp, err := f.caller.Call(ctx, c.With(calls.Framework(f.frameworkID())))
+	if resp != nil {
+		resp.Close()
+	}
+	return err
+}
+
+// handle processes events; call errors are recorded, but don't terminate the subscription.
+func (f *framework) handle(ctx context.Context, e *scheduler.Event) error {
+	switch e.GetType() {
+	case scheduler.Event_SUBSCRIBED:
+		f.id.Store(e.GetSubscribed().GetFrameworkID().GetValue())
+
+	case scheduler.Event_OFFERS:
+		var declined []mesos.OfferID
+		for _, o := range e.GetOffers().GetOffers() {
+			tasks := f.tasksFor(o)
+			if len(tasks) == 0 {
+				declined = append(declined, o.ID)
+				continue
+			}
+			if accept, err := calls.AcceptOffers([]mesos.Offer{o}, calls.OpLaunch(tasks...)); err == nil {
+				f.call(ctx, accept)
+			} else {
+				declined = append(declined, o.ID)
+			}
+		}
+		if len(declined) > 0 {
+			f.call(ctx, calls.Decline(declined...))
+		}
+
+	case scheduler.Event_UPDATE:
+		s := e.GetUpdate().GetStatus()
+		if uuid := s.GetUUID(); len(uuid) > 0 {
+			f.call(ctx, calls.Acknowledge(s.GetAgentID().GetValue(), s.TaskID.Value, uuid))
+		}
+	}
+	return nil
+}
+
+// tasksFor returns the tasks that fit within the offer, as many as the workload permits.
+func (f *framework) tasksFor(o mesos.Offer) (tasks []mesos.TaskInfo) {
+	need := f.Resources.Clone()
+	if role := o.GetAllocationInfo().GetRole(); role != "" {
+		need.Allocate(role)
+	}
+	remaining := mesos.Resources(o.Resources).Clone()
+	for len(tasks) < f.TasksPerOffer && resources.ContainsAll(remaining, need) {
+		remaining.Subtract(need...)
+		id := "bench-" + strconv.FormatUint(atomic.AddUint64(&f.tasks, 1), 10)
+		tasks = append(tasks, mesos.TaskInfo{
+			Name:      id,
+			TaskID:    mesos.TaskID{Value: id},
+			AgentID:   o.AgentID,
+			Resources: f.Resources,
+			Command:   &mesos.CommandInfo{Value: &f.Command},
+		})
+	}
+	return
+}
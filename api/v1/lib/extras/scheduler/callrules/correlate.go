@@ -0,0 +1,28 @@
+package callrules
+
+import (
+	"context"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/correlation"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// Correlate returns a Rule that associates a correlation ID with the context of every call, unless the
+// context already carries one, so that the ID is propagated through the rest of the chain: including every
+// attempt (e.g. following a redirect) at sending the call. If annotateErrors is true then errors that are
+// generated by the rest of the chain are annotated with the ID; see correlation.Wrap and correlation.Cause.
+func Correlate(annotateErrors bool) Rule { return CorrelateWith(annotateErrors, correlation.NewID) }
+
+// CorrelateWith is like Correlate, except that correlation IDs are generated by gen; e.g. to embed the
+// identity of a framework instance into the IDs, or to generate reproducible IDs in tests.
+func CorrelateWith(annotateErrors bool, gen correlation.Generator) Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		ctx, _ = correlation.EnsureWith(ctx, gen)
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if annotateErrors {
+			err = correlation.Wrap(ctx, err)
+		}
+		return ctx, c, r, err
+	}
+}
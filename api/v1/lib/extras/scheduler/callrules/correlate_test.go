@@ -0,0 +1,49 @@
+package callrules
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/correlation"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestCorrelate(t *testing.T) {
+	var (
+		seen  []correlation.ID
+		cause = errors.New("injected")
+		retry = CallF(func(ctx context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			id, _ := correlation.FromContext(ctx)
+			seen = append(seen, id)
+			return nil, cause
+		})
+		rule = New(Correlate(true), retry, retry) // two attempts of the same logical call
+	)
+	_, _, _, err := rule.Eval(context.Background(), calls.Revive(), nil, nil, ChainIdentity)
+	if len(seen) != 2 || seen[0] == "" || seen[0] != seen[1] {
+		t.Fatalf("expected the same correlation ID for every attempt instead of %q", seen)
+	}
+	if e, ok := err.(*correlation.Error); !ok || e.ID != seen[0] || correlation.Cause(err) != cause {
+		t.Fatalf("expected an annotated error instead of %v", err)
+	}
+
+	// an existing ID is retained
+	ctx := correlation.WithID(context.Background(), "existing")
+	seen = nil
+	New(Correlate(false), retry).Eval(ctx, calls.Revive(), nil, nil, ChainIdentity)
+	if len(seen) != 1 || seen[0] != "existing" {
+		t.Fatalf("expected the existing correlation ID instead of %q", seen)
+	}
+
+	// IDs may be generated by a custom generator
+	seen = nil
+	rule = New(CorrelateWith(false, correlation.Sequence("shard-7/")), retry)
+	rule.Eval(context.Background(), calls.Revive(), nil, nil, ChainIdentity)
+	rule.Eval(context.Background(), calls.Revive(), nil, nil, ChainIdentity)
+	if len(seen) != 2 || seen[0] != "shard-7/1" || seen[1] != "shard-7/2" {
+		t.Fatalf("expected generated correlation IDs instead of %q", seen)
+	}
+}
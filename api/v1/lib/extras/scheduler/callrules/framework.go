@@ -0,0 +1,55 @@
+package callrules
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// ValidateFrameworkUpdates returns a Rule that remembers the framework info of the most recent successful
+// SUBSCRIBE, or UPDATE_FRAMEWORK, call and fails (without sending them) subsequent SUBSCRIBE and
+// UPDATE_FRAMEWORK calls whose framework info changes fields that Mesos doesn't allow to change; see
+// calls.ValidateFrameworkUpdate. The rule should precede rules that retry calls.
+func ValidateFrameworkUpdates() Rule {
+	var (
+		m          sync.Mutex
+		registered *mesos.FrameworkInfo
+	)
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		var info *mesos.FrameworkInfo
+		switch c.GetType() {
+		case scheduler.Call_SUBSCRIBE:
+			info = c.GetSubscribe().GetFrameworkInfo()
+		case scheduler.Call_UPDATE_FRAMEWORK:
+			if u := c.GetUpdateFramework(); u != nil {
+				info = &u.FrameworkInfo
+			}
+		}
+		if info == nil {
+			return ch(ctx, c, r, err)
+		}
+		m.Lock()
+		prev := registered
+		m.Unlock()
+		if prev != nil {
+			if verr := calls.ValidateFrameworkUpdate(prev, info); verr != nil {
+				return ctx, c, r, Error2(err, verr)
+			}
+		}
+		snapshot := proto.Clone(info).(*mesos.FrameworkInfo) // before the rest of the chain may modify it
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if err == nil {
+			if snapshot.ID == nil && prev != nil {
+				snapshot.ID = prev.ID
+			}
+			m.Lock()
+			registered = snapshot
+			m.Unlock()
+		}
+		return ctx, c, r, err
+	}
+}
@@ -0,0 +1,52 @@
+package callrules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestValidateFrameworkUpdates(t *testing.T) {
+	var (
+		sent int
+		call = CallF(func(_ context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			sent++
+			return nil, nil
+		})
+		rule = New(ValidateFrameworkUpdates(), call)
+		eval = func(c *scheduler.Call) error {
+			_, _, _, err := rule.Eval(context.Background(), c, nil, nil, ChainIdentity)
+			return err
+		}
+		info = mesos.FrameworkInfo{User: "root", Name: "fw", Principal: proto.String("p1")}
+	)
+	if err := eval(calls.Subscribe(&info)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the ID that's assigned upon subscription, and mutable fields, may change
+	info.ID = &mesos.FrameworkID{Value: "fw-1"}
+	info.Name = "fw2"
+	if err := eval(calls.Subscribe(&info)); err != nil {
+		t.Fatal(err)
+	}
+	if err := eval(calls.UpdateFramework(info)); err != nil {
+		t.Fatal(err)
+	}
+
+	info.Principal = proto.String("p2")
+	if err := eval(calls.UpdateFramework(info)); err == nil {
+		t.Fatal("expected the change of principal to be rejected")
+	}
+	info.Principal, info.ID = proto.String("p1"), &mesos.FrameworkID{Value: "fw-2"}
+	if err := eval(calls.Subscribe(&info)); err == nil {
+		t.Fatal("expected the change of ID to be rejected")
+	}
+	if sent != 3 {
+		t.Fatalf("expected 3 calls to be sent instead of %d", sent)
+	}
+}
@@ -0,0 +1,78 @@
+package callrules
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// Mutator modifies a call in place. Mutators are always applied to a private copy of a call (see
+// Mutate) and so need not copy anything themselves.
+type Mutator func(*scheduler.Call)
+
+// Mutate returns a Rule that applies the given mutators to outgoing calls. The ordering contract is:
+//   - the call given to the rule is never modified: mutators are applied, in order, to a deep copy of it
+//     (nil mutators are skipped) and the copy is passed to the remainder of the chain;
+//   - rules that precede the returned Rule in a chain observe the original call, rules that follow it
+//     observe the mutated call (and so may veto or further mutate it);
+//   - nil calls are passed along as-is.
+func Mutate(ms ...Mutator) Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		if c != nil && len(ms) > 0 {
+			c = proto.Clone(c).(*scheduler.Call)
+			for _, m := range ms {
+				if m != nil {
+					m(c)
+				}
+			}
+		}
+		return ch(ctx, c, r, err)
+	}
+}
+
+// EachLaunchedTask returns a Mutator that applies f to every task of every LAUNCH and LAUNCH_GROUP
+// operation of ACCEPT calls; calls of other types are not modified.
+func EachLaunchedTask(f func(*mesos.TaskInfo)) Mutator {
+	return func(c *scheduler.Call) {
+		if c.GetType() != scheduler.Call_ACCEPT || c.Accept == nil {
+			return
+		}
+		ops := c.Accept.Operations
+		for i := range ops {
+			var tasks []mesos.TaskInfo
+			switch ops[i].GetType() {
+			case mesos.Offer_Operation_LAUNCH:
+				tasks = ops[i].GetLaunch().GetTaskInfos()
+			case mesos.Offer_Operation_LAUNCH_GROUP:
+				if lg := ops[i].GetLaunchGroup(); lg != nil {
+					tasks = lg.TaskGroup.Tasks
+				}
+			}
+			for j := range tasks {
+				f(&tasks[j])
+			}
+		}
+	}
+}
+
+// TaskLabel returns a Mutator that sets a label, with the given key and value, on every launched task
+// (see EachLaunchedTask). An existing label with the same key is overwritten; e.g. to stamp a deployment ID
+// upon every task:
+//
+//	callrules.Mutate(callrules.TaskLabel("deployment", id))
+func TaskLabel(key, value string) Mutator {
+	return EachLaunchedTask(func(t *mesos.TaskInfo) {
+		if t.Labels == nil {
+			t.Labels = &mesos.Labels{}
+		}
+		for i := range t.Labels.Labels {
+			if t.Labels.Labels[i].Key == key {
+				t.Labels.Labels[i].Value = &value
+				return
+			}
+		}
+		t.Labels.Labels = append(t.Labels.Labels, mesos.Label{Key: key, Value: &value})
+	})
+}
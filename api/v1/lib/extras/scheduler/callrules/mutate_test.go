@@ -0,0 +1,52 @@
+package callrules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestMutate(t *testing.T) {
+	var (
+		task = mesos.TaskInfo{
+			TaskID: mesos.TaskID{Value: "1"},
+			Labels: &mesos.Labels{Labels: []mesos.Label{{Key: "deployment", Value: proto.String("old")}}},
+		}
+		group = mesos.TaskInfo{TaskID: mesos.TaskID{Value: "2"}}
+		call  = calls.Accept(calls.OfferOperations{
+			calls.OpLaunch(task),
+			calls.OpLaunchGroup(mesos.ExecutorInfo{}, group),
+		}.WithOffers(mesos.OfferID{Value: "o"}))
+		observed []*scheduler.Call
+		observe  = Rule(func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+			observed = append(observed, c)
+			return ch(ctx, c, r, err)
+		})
+	)
+	_, c, _, _ := Rules{observe, Mutate(nil, TaskLabel("deployment", "new")), observe}.Eval(context.Background(), call, nil, nil, ChainIdentity)
+
+	if len(observed) != 2 || observed[0] != call || observed[1] != c {
+		t.Fatalf("expected the original call to precede the mutated call")
+	}
+	if v := call.Accept.Operations[0].Launch.TaskInfos[0].Labels.Labels[0].GetValue(); v != "old" {
+		t.Fatalf("expected original call to be unmodified, label = %q", v)
+	}
+	ops := c.Accept.Operations
+	for _, tk := range []mesos.TaskInfo{ops[0].Launch.TaskInfos[0], ops[1].LaunchGroup.TaskGroup.Tasks[0]} {
+		labels := tk.GetLabels().GetLabels()
+		if len(labels) != 1 || labels[0].Key != "deployment" || labels[0].GetValue() != "new" {
+			t.Errorf("task %v: unexpected labels %v", tk.TaskID.Value, labels)
+		}
+	}
+
+	// other calls are passed through unmodified
+	decline := calls.Decline(mesos.OfferID{Value: "o"})
+	_, c, _, _ = Mutate(TaskLabel("deployment", "new")).Eval(context.Background(), decline, nil, nil, ChainIdentity)
+	if c.GetType() != scheduler.Call_DECLINE {
+		t.Fatalf("unexpected call %v", c)
+	}
+}
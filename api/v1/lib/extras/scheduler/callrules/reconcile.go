@@ -0,0 +1,109 @@
+package callrules
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// reconcileBatch accumulates the RECONCILE calls that are issued within a window.
+type reconcileBatch struct {
+	calls     []*scheduler.Call
+	ctx       context.Context // of the merged calls; canceled once no coalesced call awaits them
+	cancel    context.CancelFunc
+	waiters   int           // the number of coalesced calls that await the merged calls
+	abandoned bool          // set once no coalesced call awaits the merged calls, which aren't sent
+	done      chan struct{} // closed once the merged calls have been sent
+	err       error
+}
+
+// valuesOf is a context that carries the values of another, but that's never done.
+type valuesOf struct{ context.Context }
+
+func (valuesOf) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (valuesOf) Done() <-chan struct{}       { return nil }
+func (valuesOf) Err() error                  { return nil }
+
+// CoalesceReconciles returns a Rule that coalesces the RECONCILE calls that are issued within the given
+// window (beginning with the first of such calls) into as few calls as possible, as per
+// calls.CoalesceReconciles: so that a misbehaving event handler, that requests reconciliation upon every
+// event, doesn't flood the master with redundant reconciliation requests. Every coalesced call blocks until
+// the merged call is sent by the rest of the chain, and returns a nil response and the error of the merged
+// call; or until its context is done. The merged call is sent with a context that carries the values of
+// the context of the first coalesced call, and that's canceled once the contexts of all of the coalesced
+// calls are done. Calls of other types are passed to the rest of the chain immediately.
+func CoalesceReconciles(window time.Duration) Rule {
+	return CoalesceReconcilesWith(window, mesostime.SystemClock)
+}
+
+// CoalesceReconcilesWith is like CoalesceReconciles, except that windows are timed by the given clock;
+// e.g. a mesostime.FakeClock, in tests.
+func CoalesceReconcilesWith(window time.Duration, clock mesostime.Clock) Rule {
+	var (
+		m       sync.Mutex
+		pending *reconcileBatch
+	)
+	send := func(b *reconcileBatch, r mesos.Response, err error, ch Chain) {
+		m.Lock()
+		if pending == b {
+			pending = nil
+		}
+		abandoned := b.abandoned
+		m.Unlock()
+		if abandoned {
+			return
+		}
+		for _, merged := range calls.CoalesceReconciles(b.calls...) {
+			_, _, r2, err2 := ch(b.ctx, merged, r, err)
+			if r2 != nil {
+				r2.Close()
+			}
+			if b.err == nil {
+				b.err = err2
+			}
+		}
+		b.cancel()
+		close(b.done)
+	}
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		if c.GetType() != scheduler.Call_RECONCILE {
+			return ch(ctx, c, r, err)
+		}
+		m.Lock()
+		b := pending
+		first := b == nil
+		if first {
+			bctx, cancel := context.WithCancel(valuesOf{ctx})
+			b = &reconcileBatch{ctx: bctx, cancel: cancel, done: make(chan struct{})}
+			pending = b
+		}
+		b.calls = append(b.calls, c)
+		b.waiters++
+		m.Unlock()
+
+		if first {
+			clock.AfterFunc(window, func() { send(b, r, err, ch) })
+		}
+		select {
+		case <-b.done:
+			return ctx, c, nil, b.err
+		case <-ctx.Done():
+			m.Lock()
+			if b.waiters--; b.waiters == 0 {
+				// nothing awaits the merged calls: abort them, or else don't send them at all
+				b.abandoned = true
+				if pending == b {
+					pending = nil
+				}
+				b.cancel()
+			}
+			m.Unlock()
+			return ctx, c, nil, ctx.Err()
+		}
+	}
+}
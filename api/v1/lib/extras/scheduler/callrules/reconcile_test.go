@@ -0,0 +1,119 @@
+package callrules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestCoalesceReconciles(t *testing.T) {
+	var (
+		clock  = mesostime.NewFakeClock(time.Unix(0, 0))
+		failed = errors.New("failed")
+		m      sync.Mutex
+		sent   []*scheduler.Call
+		call   = CallF(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+			m.Lock()
+			defer m.Unlock()
+			sent = append(sent, c)
+			if c.GetType() == scheduler.Call_RECONCILE {
+				return nil, failed
+			}
+			return nil, nil
+		})
+		rule = New(CoalesceReconcilesWith(time.Second, clock), call)
+		eval = func(c *scheduler.Call) error {
+			_, _, _, err := rule.Eval(context.Background(), c, nil, nil, ChainIdentity)
+			return err
+		}
+		wg   sync.WaitGroup
+		errs = make(chan error, 3)
+	)
+	for i, id := range []string{"t1", "t2", "t1"} {
+		wg.Add(1)
+		go func(c *scheduler.Call) {
+			defer wg.Done()
+			errs <- eval(c)
+		}(calls.Reconcile(calls.ReconcileTasks(map[string]string{id: ""})))
+		if i == 0 {
+			clock.WaitForTimers(1) // the first call opens the window
+		}
+	}
+	if err := eval(calls.Revive()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	time.Sleep(10 * time.Millisecond) // let the remaining calls join the window
+	clock.Advance(time.Second)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != failed {
+			t.Fatalf("expected the error of the merged call instead of %v", err)
+		}
+	}
+	if len(sent) != 2 || sent[0].GetType() != scheduler.Call_REVIVE {
+		t.Fatalf("expected a single merged call to follow the revive: %v", sent)
+	}
+	if tasks := sent[1].GetReconcile().GetTasks(); len(tasks) != 2 {
+		t.Fatalf("expected 2 reconciled tasks instead of %v", tasks)
+	}
+}
+
+func TestCoalesceReconcilesCanceled(t *testing.T) {
+	type key struct{}
+	var (
+		clock = mesostime.NewFakeClock(time.Unix(0, 0))
+		sent  = make(chan error, 1)
+		call  = CallF(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+			if ctx.Value(key{}) == nil {
+				t.Error("expected the values of the context of the first call")
+			}
+			sent <- ctx.Err()
+			return nil, nil
+		})
+		rule        = New(CoalesceReconcilesWith(time.Second, clock), call)
+		first, stop = context.WithCancel(context.WithValue(context.Background(), key{}, true))
+		errs        = make(chan error, 2)
+		eval        = func(ctx context.Context, id string) {
+			_, _, _, err := rule.Eval(ctx, calls.Reconcile(calls.ReconcileTasks(map[string]string{id: ""})), nil, nil, ChainIdentity)
+			errs <- err
+		}
+	)
+	go eval(first, "t1")
+	clock.WaitForTimers(1)
+	go eval(context.Background(), "t2")
+	time.Sleep(10 * time.Millisecond) // let the second call join the window
+
+	// the merged call is sent on behalf of the calls that are still waiting
+	stop()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expected context.Canceled instead of %v", err)
+	}
+	clock.Advance(time.Second)
+	if err := <-sent; err != nil {
+		t.Fatalf("expected the merged call to be sent with a live context, instead of %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the merged call isn't sent once none of its calls is waiting
+	canceled, cancel := context.WithCancel(context.Background())
+	go eval(canceled, "t3")
+	clock.WaitForTimers(1)
+	cancel()
+	<-errs
+	clock.Advance(time.Second)
+	select {
+	case <-sent:
+		t.Fatal("unexpected merged call")
+	default:
+	}
+}
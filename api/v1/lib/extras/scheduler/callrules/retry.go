@@ -0,0 +1,51 @@
+package callrules
+
+import (
+	"context"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/retry"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// Retry returns a Rule that re-executes the rest of the chain for calls that fail with an error for which
+// shouldRetry returns true, for as long as the budget (which may be nil) permits. The wait period between
+// attempts starts at minWait and doubles after every attempt, up to maxWait. The response of a failed
+// attempt is closed before the call is retried. If the budget doesn't permit a retry, or if the context
+// is done, then the results of the last attempt are returned.
+func Retry(b *retry.Budget, shouldRetry func(error) bool, minWait, maxWait time.Duration) Rule {
+	return RetryWith(b, shouldRetry, minWait, maxWait, mesostime.SystemClock)
+}
+
+// RetryWith is like Retry, except that the wait periods between attempts, and the time that's elapsed
+// since the first attempt, are timed by the given clock; e.g. a mesostime.FakeClock, in tests.
+func RetryWith(b *retry.Budget, shouldRetry func(error) bool, minWait, maxWait time.Duration, clock mesostime.Clock) Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		var (
+			start = clock.Now()
+			wait  = minWait
+		)
+		b.Deposit()
+		for attempts := 1; ; attempts++ {
+			ctx2, c2, r2, err2 := ch(ctx, c, r, err)
+			if err2 == nil || !shouldRetry(err2) || b.Retry(attempts, clock.Now().Sub(start)) != nil {
+				return ctx2, c2, r2, err2
+			}
+			if r2 != nil {
+				r2.Close()
+			}
+			t := clock.NewTimer(wait)
+			select {
+			case <-t.C():
+			case <-ctx.Done():
+				t.Stop()
+				return ctx2, c2, nil, err2
+			}
+			if wait *= 2; wait > maxWait {
+				wait = maxWait
+			}
+		}
+	}
+}
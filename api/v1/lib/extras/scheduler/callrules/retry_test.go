@@ -0,0 +1,88 @@
+package callrules
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/retry"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestRetry(t *testing.T) {
+	var (
+		attempts  int
+		transient = errors.New("transient")
+		permanent = errors.New("permanent")
+		failWith  error
+		failures  int
+		call      = CallF(func(_ context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			attempts++
+			if attempts <= failures {
+				return nil, failWith
+			}
+			return nil, nil
+		})
+		isTransient = func(err error) bool { return err == transient }
+		budget      = retry.New(retry.MaxAttempts(3))
+		rule        = New(Retry(budget, isTransient, 0, 0), call)
+		eval        = func() error {
+			attempts = 0
+			_, _, _, err := rule.Eval(context.Background(), calls.Revive(), nil, nil, ChainIdentity)
+			return err
+		}
+	)
+	failWith, failures = transient, 2
+	if err := eval(); err != nil || attempts != 3 {
+		t.Fatalf("expected success after 3 attempts instead of %v after %d", err, attempts)
+	}
+
+	failWith, failures = transient, 5
+	if err := eval(); err != transient || attempts != 3 {
+		t.Fatalf("expected the budget to stop retries after 3 attempts instead of %v after %d", err, attempts)
+	}
+
+	failWith = permanent
+	if err := eval(); err != permanent || attempts != 1 {
+		t.Fatalf("expected no retries of a permanent error instead of %v after %d", err, attempts)
+	}
+	if s := budget.Stats(); s.Deposits != 3 || s.Retries != 4 || s.Rejected != 1 {
+		t.Fatalf("unexpected budget stats %+v", s)
+	}
+}
+
+func TestRetryWith(t *testing.T) {
+	var (
+		start    = time.Unix(1000, 0)
+		clock    = mesostime.NewFakeClock(start)
+		attempts []time.Duration
+		call     = CallF(func(_ context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			if attempts = append(attempts, clock.Now().Sub(start)); len(attempts) <= 3 {
+				return nil, errors.New("transient")
+			}
+			return nil, nil
+		})
+		rule = New(RetryWith(nil, func(error) bool { return true }, time.Second, 3*time.Second, clock), call)
+		done = make(chan error)
+	)
+	go func() {
+		_, _, _, err := rule.Eval(context.Background(), calls.Revive(), nil, nil, ChainIdentity)
+		done <- err
+	}()
+	// the waits double, up to the maximum
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		clock.WaitForTimers(1)
+		clock.Advance(wait)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if want := []time.Duration{0, time.Second, 3 * time.Second, 6 * time.Second}; !reflect.DeepEqual(attempts, want) {
+		t.Fatalf("expected attempts at %v instead of %v", want, attempts)
+	}
+}
@@ -0,0 +1,227 @@
+// Package chaos provides fault-injecting decorators for scheduler Callers and event streams so that
+// framework authors may test the resilience of a scheduler without relying upon a flaky cluster. It's
+// intended for testing only.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+var (
+	// ErrCallFailed is returned by calls that were failed by the fault injector.
+	ErrCallFailed = errors.New("chaos: injected call failure")
+	// ErrDisconnected is returned when the fault injector terminates an event stream.
+	ErrDisconnected = errors.New("chaos: injected disconnect")
+)
+
+type (
+	// Option is a functional configuration option for fault injection; it returns an Option that acts as
+	// an "undo" if applied to the same Config.
+	Option func(*Config) Option
+
+	// Config is an opaque fault injection configuration. Properties are configured by applying Option
+	// funcs. By default no faults are injected.
+	Config struct {
+		random           *rand.Rand
+		failCalls        float64
+		disconnect       float64
+		maxDelay         time.Duration
+		duplicateUpdates float64
+		shuffleOffers    bool
+	}
+
+	injector struct {
+		Config
+		m sync.Mutex // guards random
+	}
+)
+
+// Seed configures the seed of the pseudo-random number generator that drives fault injection, so that
+// tests are reproducible. Defaults to the current time.
+func Seed(seed int64) Option {
+	return func(c *Config) Option {
+		old := c.random
+		c.random = rand.New(rand.NewSource(seed))
+		return func(c *Config) Option {
+			c.random = old
+			return Seed(seed)
+		}
+	}
+}
+
+// FailCalls configures the probability, in the range [0, 1], that a call other than SUBSCRIBE fails with
+// ErrCallFailed, without being sent to Mesos.
+func FailCalls(p float64) Option {
+	return func(c *Config) Option {
+		old := c.failCalls
+		c.failCalls = p
+		return FailCalls(old)
+	}
+}
+
+// Disconnect configures the probability, in the range [0, 1], that an attempt to decode an event instead
+// terminates the event stream with ErrDisconnected; the subscription response is closed.
+func Disconnect(p float64) Option {
+	return func(c *Config) Option {
+		old := c.disconnect
+		c.disconnect = p
+		return Disconnect(old)
+	}
+}
+
+// DelayEvents configures a maximum delay with which events are delivered; each event is delayed by a
+// random duration in the range [0, max).
+func DelayEvents(max time.Duration) Option {
+	return func(c *Config) Option {
+		old := c.maxDelay
+		c.maxDelay = max
+		return DelayEvents(old)
+	}
+}
+
+// DuplicateUpdates configures the probability, in the range [0, 1], that an UPDATE event is delivered a
+// second time, immediately following the original.
+func DuplicateUpdates(p float64) Option {
+	return func(c *Config) Option {
+		old := c.duplicateUpdates
+		c.duplicateUpdates = p
+		return DuplicateUpdates(old)
+	}
+}
+
+// ShuffleOffers configures the random reordering of the offers of each OFFERS event.
+func ShuffleOffers(b bool) Option {
+	return func(c *Config) Option {
+		old := c.shuffleOffers
+		c.shuffleOffers = b
+		return ShuffleOffers(old)
+	}
+}
+
+func newInjector(opts []Option) *injector {
+	inj := &injector{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&inj.Config)
+		}
+	}
+	if inj.random == nil {
+		inj.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return inj
+}
+
+// chance returns true with probability p.
+func (inj *injector) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	inj.m.Lock()
+	defer inj.m.Unlock()
+	return inj.random.Float64() < p
+}
+
+func (inj *injector) delay() time.Duration {
+	if inj.maxDelay <= 0 {
+		return 0
+	}
+	inj.m.Lock()
+	defer inj.m.Unlock()
+	return time.Duration(inj.random.Int63n(int64(inj.maxDelay)))
+}
+
+func (inj *injector) shuffle(offers []mesos.Offer) {
+	inj.m.Lock()
+	defer inj.m.Unlock()
+	for i := len(offers) - 1; i > 0; i-- {
+		j := inj.random.Intn(i + 1)
+		offers[i], offers[j] = offers[j], offers[i]
+	}
+}
+
+// Caller returns a Caller that injects faults, as configured by the given options, into the calls that
+// it delegates to the given Caller, as well as into the event streams of SUBSCRIBE responses.
+func Caller(caller calls.Caller, opts ...Option) calls.Caller {
+	inj := newInjector(opts)
+	return calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		if c.GetType() != scheduler.Call_SUBSCRIBE {
+			if inj.chance(inj.failCalls) {
+				return nil, ErrCallFailed
+			}
+			return caller.Call(ctx, c)
+		}
+		resp, err := caller.Call(ctx, c)
+		if resp != nil {
+			resp = &mesos.ResponseWrapper{
+				Response: resp,
+				Decoder:  inj.decoder(resp, resp),
+			}
+		}
+		return resp, err
+	})
+}
+
+// Decoder returns an event stream Decoder that injects faults, as configured by the given options, into
+// the events that are decoded by the given Decoder. The closer, if not nil, is closed upon an injected
+// disconnect. Call-related options are ignored.
+func Decoder(d encoding.Decoder, closer io.Closer, opts ...Option) encoding.Decoder {
+	return newInjector(opts).decoder(d, closer)
+}
+
+func (inj *injector) decoder(d encoding.Decoder, closer io.Closer) encoding.Decoder {
+	var (
+		m       sync.Mutex
+		pending *scheduler.Event // a duplicate UPDATE that's yet to be delivered
+		closed  bool
+	)
+	return encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+		e, ok := u.(*scheduler.Event)
+		if !ok {
+			return d.Decode(u)
+		}
+		m.Lock()
+		defer m.Unlock()
+		if closed {
+			return ErrDisconnected
+		}
+		if pending != nil {
+			*e, pending = *pending, nil
+			return nil
+		}
+		if inj.chance(inj.disconnect) {
+			closed = true
+			if closer != nil {
+				closer.Close()
+			}
+			return ErrDisconnected
+		}
+		if err := d.Decode(e); err != nil {
+			return err
+		}
+		if delay := inj.delay(); delay > 0 {
+			time.Sleep(delay)
+		}
+		switch e.GetType() {
+		case scheduler.Event_UPDATE:
+			if inj.chance(inj.duplicateUpdates) {
+				dup := *e
+				pending = &dup
+			}
+		case scheduler.Event_OFFERS:
+			if inj.shuffleOffers {
+				inj.shuffle(e.GetOffers().GetOffers())
+			}
+		}
+		return nil
+	})
+}
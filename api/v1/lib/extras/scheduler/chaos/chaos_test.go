@@ -0,0 +1,97 @@
+package chaos
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func eventSource(stream ...scheduler.Event) encoding.Decoder {
+	return encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+		if len(stream) == 0 {
+			return io.EOF
+		}
+		*(u.(*scheduler.Event)) = stream[0]
+		stream = stream[1:]
+		return nil
+	})
+}
+
+func decodeAll(d encoding.Decoder) (result []scheduler.Event, err error) {
+	for {
+		var e scheduler.Event
+		if err = d.Decode(&e); err != nil {
+			return
+		}
+		result = append(result, e)
+	}
+}
+
+func TestDuplicateUpdates(t *testing.T) {
+	update := scheduler.Event{Type: scheduler.Event_UPDATE}
+	d := Decoder(eventSource(update, scheduler.Event{Type: scheduler.Event_HEARTBEAT}), nil, DuplicateUpdates(1))
+	got, err := decodeAll(d)
+	if err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 || got[0].GetType() != scheduler.Event_UPDATE || got[1].GetType() != scheduler.Event_UPDATE {
+		t.Fatalf("expected a duplicate update, got %+v", got)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	closed := false
+	d := Decoder(eventSource(scheduler.Event{Type: scheduler.Event_HEARTBEAT}),
+		mesos.CloseFunc(func() error { closed = true; return nil }), Disconnect(1))
+	got, err := decodeAll(d)
+	if err != ErrDisconnected || len(got) != 0 || !closed {
+		t.Fatalf("expected disconnect; got events %+v, error %v, closed %v", got, err, closed)
+	}
+	// once disconnected, the stream remains so
+	if err = d.Decode(&scheduler.Event{}); err != ErrDisconnected {
+		t.Fatalf("expected ErrDisconnected instead of %v", err)
+	}
+}
+
+func TestShuffleOffers(t *testing.T) {
+	var offers []mesos.Offer
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		offers = append(offers, mesos.Offer{ID: mesos.OfferID{Value: id}})
+	}
+	e := scheduler.Event{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{
+		Offers: append([]mesos.Offer(nil), offers...),
+	}}
+	got, _ := decodeAll(Decoder(eventSource(e), nil, Seed(1), ShuffleOffers(true)))
+	if len(got) != 1 || len(got[0].GetOffers().GetOffers()) != len(offers) {
+		t.Fatalf("unexpected events %+v", got)
+	}
+	same := true
+	for i, o := range got[0].GetOffers().GetOffers() {
+		same = same && o.ID == offers[i].ID
+	}
+	if same {
+		t.Fatal("expected offers to be reordered")
+	}
+}
+
+func TestFailCalls(t *testing.T) {
+	sent := 0
+	caller := Caller(calls.CallerFunc(func(_ context.Context, _ *scheduler.Call) (mesos.Response, error) {
+		sent++
+		return nil, nil
+	}), FailCalls(1))
+	if _, err := caller.Call(context.Background(), calls.Revive()); err != ErrCallFailed {
+		t.Fatalf("expected ErrCallFailed instead of %v", err)
+	}
+	if _, err := caller.Call(context.Background(), calls.Subscribe(&mesos.FrameworkInfo{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected only SUBSCRIBE to be sent, instead of %d calls", sent)
+	}
+}
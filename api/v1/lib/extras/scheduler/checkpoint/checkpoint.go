@@ -0,0 +1,233 @@
+// Package checkpoint periodically saves the state of a scheduler (its framework ID, task registry, and
+// operation tracker) to a store, and restores it upon startup: so that a restarted scheduler resumes with
+// explicit reconciliation of the tasks and operations that it knew of, rather than waiting for implicit
+// reconciliation to rediscover them; which drastically shortens the recovery of large frameworks.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/operations"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/store"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// ErrFrameworkMismatch is returned by Restore if the checkpoint was saved by a framework other than the
+// one whose ID is stored in the configured framework ID store: the checkpoint is stale, and isn't restored.
+var ErrFrameworkMismatch = errors.New("checkpoint of another framework")
+
+type (
+	// Option is a functional configuration option for a Checkpointer; it returns an Option that acts as
+	// an "undo" if applied to the same Checkpointer.
+	Option func(*Checkpointer) Option
+
+	// Checkpointer saves, and restores, the state of the components that it's configured with.
+	// Checkpointer funcs are safe to invoke concurrently with the use of those components.
+	Checkpointer struct {
+		store       store.Singleton
+		frameworkID store.Singleton
+		tasks       *tasks.Registry
+		operations  *operations.Tracker
+		clock       mesostime.Clock
+		errorFunc   func(error)
+	}
+
+	// State is the content of a checkpoint; components that weren't configured are omitted.
+	State struct {
+		Time        time.Time              `json:"time"`
+		FrameworkID string                 `json:"framework_id,omitempty"`
+		Tasks       *tasks.Snapshot        `json:"tasks,omitempty"`
+		Operations  []operations.Operation `json:"operations,omitempty"`
+	}
+)
+
+// FrameworkID configures the store of the framework ID (as maintained by controller.TrackSubscription)
+// that's saved with, and checked against, checkpoints.
+func FrameworkID(s store.Singleton) Option {
+	return func(c *Checkpointer) Option {
+		old := c.frameworkID
+		c.frameworkID = s
+		return FrameworkID(old)
+	}
+}
+
+// Tasks configures the task registry of a Checkpointer.
+func Tasks(r *tasks.Registry) Option {
+	return func(c *Checkpointer) Option {
+		old := c.tasks
+		c.tasks = r
+		return Tasks(old)
+	}
+}
+
+// Operations configures the operation tracker of a Checkpointer.
+func Operations(t *operations.Tracker) Option {
+	return func(c *Checkpointer) Option {
+		old := c.operations
+		c.operations = t
+		return Operations(old)
+	}
+}
+
+// Clock configures the clock that times checkpoints, and the interval of Run; defaults to
+// mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(c *Checkpointer) Option {
+		old := c.clock
+		c.clock = clock
+		return Clock(old)
+	}
+}
+
+// ErrorFunc configures the func to which errors that are encountered in the background (by Run, and by
+// the reconciliation rule) are reported.
+func ErrorFunc(f func(error)) Option {
+	return func(c *Checkpointer) Option {
+		old := c.errorFunc
+		c.errorFunc = f
+		return ErrorFunc(old)
+	}
+}
+
+// New returns a Checkpointer that saves checkpoints, JSON encoded, to the given store; e.g. as returned
+// by store.NewFileSingleton.
+func New(s store.Singleton, opts ...Option) *Checkpointer {
+	c := &Checkpointer{store: s, clock: mesostime.SystemClock}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c
+}
+
+// State returns the current state of the configured components.
+func (c *Checkpointer) State() (State, error) {
+	st := State{Time: c.clock.Now()}
+	if c.frameworkID != nil {
+		id, err := c.frameworkID.Get()
+		if err != nil && err != store.ErrNotFound {
+			return State{}, err
+		}
+		st.FrameworkID = id
+	}
+	if c.tasks != nil {
+		snap := c.tasks.Snapshot()
+		st.Tasks = &snap
+	}
+	if c.operations != nil {
+		st.Operations = c.operations.Operations()
+	}
+	return st, nil
+}
+
+// Save saves a checkpoint of the current state of the configured components.
+func (c *Checkpointer) Save() error {
+	st, err := c.State()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(&st)
+	if err != nil {
+		return err
+	}
+	return c.store.Set(string(b))
+}
+
+// Restore loads the most recently saved checkpoint, if any, into the configured components; it should be
+// invoked upon startup, before the scheduler subscribes. Returns false if there's no checkpoint. If no
+// framework ID is stored then that of the checkpoint is stored; otherwise they must match, else
+// ErrFrameworkMismatch is returned.
+func (c *Checkpointer) Restore() (State, bool, error) {
+	s, err := c.store.Get()
+	if err == store.ErrNotFound {
+		return State{}, false, nil
+	}
+	if err != nil {
+		return State{}, false, err
+	}
+	var st State
+	if err = json.Unmarshal([]byte(s), &st); err != nil {
+		return State{}, false, err
+	}
+	if c.frameworkID != nil && st.FrameworkID != "" {
+		id, err := c.frameworkID.Get()
+		switch {
+		case err == store.ErrNotFound:
+			err = c.frameworkID.Set(st.FrameworkID)
+		case err == nil && id != st.FrameworkID:
+			err = ErrFrameworkMismatch
+		}
+		if err != nil {
+			return st, false, err
+		}
+	}
+	if c.tasks != nil && st.Tasks != nil {
+		c.tasks.Restore(*st.Tasks)
+	}
+	if c.operations != nil {
+		c.operations.Restore(st.Operations...)
+	}
+	return st, true, nil
+}
+
+// Run saves a checkpoint at the given interval until the context is done, upon which a final checkpoint
+// is saved and the error of the context is returned. Errors encountered while saving are reported to the
+// configured error func.
+func (c *Checkpointer) Run(ctx context.Context, interval time.Duration) error {
+	t := c.clock.NewTimer(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C():
+			c.report(c.Save())
+			t.Reset(interval)
+		case <-ctx.Done():
+			c.report(c.Save())
+			return ctx.Err()
+		}
+	}
+}
+
+// Reconcile returns a Rule that, once a SUBSCRIBED event has been handled by the rest of the chain,
+// requests explicit reconciliation (via the given caller) of the tasks and operations that are tracked by
+// the configured components; e.g. those that were restored from a checkpoint. Errors are reported to the
+// configured error func; they don't fail the event.
+func (c *Checkpointer) Reconcile(caller calls.Caller) eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		ctx, e, err = ch(ctx, e, err)
+		if e.GetType() != scheduler.Event_SUBSCRIBED {
+			return ctx, e, err
+		}
+		var reconcile []*scheduler.Call
+		if c.tasks != nil && c.tasks.Len() > 0 {
+			reconcile = append(reconcile, calls.Reconcile(c.tasks.ReconcileTasks()))
+		}
+		if c.operations != nil {
+			if call := c.operations.Reconcile(); call != nil {
+				reconcile = append(reconcile, call)
+			}
+		}
+		for _, call := range reconcile {
+			resp, cerr := caller.Call(ctx, call)
+			if resp != nil {
+				resp.Close()
+			}
+			c.report(cerr)
+		}
+		return ctx, e, err
+	}
+}
+
+func (c *Checkpointer) report(err error) {
+	if err != nil && c.errorFunc != nil {
+		c.errorFunc(err)
+	}
+}
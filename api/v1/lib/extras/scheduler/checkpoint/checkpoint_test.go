@@ -0,0 +1,114 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/operations"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/store"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestCheckpoint(t *testing.T) {
+	var (
+		checkpoints = store.NewInMemorySingleton()
+		frameworkID = store.NewInMemorySingleton()
+		registry    = tasks.NewRegistry(&mesos.FrameworkInfo{})
+		tracker     = operations.NewTracker()
+		c           = New(checkpoints, FrameworkID(frameworkID), Tasks(registry), Operations(tracker))
+	)
+	frameworkID.Set("f1")
+	registry.Launched(mesos.TaskInfo{TaskID: mesos.TaskID{Value: "t1"}, AgentID: mesos.AgentID{Value: "a1"}})
+	registry.Update(mesos.TaskStatus{TaskID: mesos.TaskID{Value: "t2"}, State: mesos.TASK_RUNNING.Enum()})
+	op := mesos.OperationID{Value: "op1"}
+	tracker.CallRule().Caller(calls.CallerFunc(func(context.Context, *scheduler.Call) (mesos.Response, error) {
+		return nil, nil
+	})).Call(context.Background(), calls.Accept(calls.OfferOperations{{Type: mesos.Offer_Operation_RESERVE, ID: &op}}.WithOffers()))
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a restarted scheduler
+	var (
+		frameworkID2 = store.NewInMemorySingleton()
+		registry2    = tasks.NewRegistry(&mesos.FrameworkInfo{})
+		tracker2     = operations.NewTracker()
+		c2           = New(checkpoints, FrameworkID(frameworkID2), Tasks(registry2), Operations(tracker2))
+	)
+	st, ok, err := c2.Restore()
+	if err != nil || !ok || st.FrameworkID != "f1" {
+		t.Fatalf("unexpected restore %+v, %t, %v", st, ok, err)
+	}
+	if id, _ := frameworkID2.Get(); id != "f1" {
+		t.Fatalf("expected the framework ID to be restored instead of %q", id)
+	}
+	if s, ok := registry2.Get(mesos.TaskID{Value: "t1"}); !ok || s.GetAgentID().GetValue() != "a1" || registry2.Len() != 2 {
+		t.Fatalf("unexpected restored tasks %v", registry2.Snapshot())
+	}
+	if ops := tracker2.Operations(); len(ops) != 1 || ops[0].ID != "op1" || ops[0].Type != mesos.Offer_Operation_RESERVE {
+		t.Fatalf("unexpected restored operations %v", ops)
+	}
+
+	// upon subscription the restored tasks and operations are reconciled
+	var sent []*scheduler.Call
+	caller := calls.CallerFunc(func(_ context.Context, call *scheduler.Call) (mesos.Response, error) {
+		sent = append(sent, call)
+		return nil, nil
+	})
+	c2.Reconcile(caller).HandleEvent(context.Background(), &scheduler.Event{Type: scheduler.Event_SUBSCRIBED})
+	if len(sent) != 2 || len(sent[0].GetReconcile().GetTasks()) != 2 || len(sent[1].GetReconcileOperations().GetOperations()) != 1 {
+		t.Fatalf("unexpected reconciliation %v", sent)
+	}
+
+	// the checkpoint of another framework isn't restored
+	frameworkID3 := store.NewInMemorySingleton()
+	frameworkID3.Set("f2")
+	registry3 := tasks.NewRegistry(&mesos.FrameworkInfo{})
+	if _, ok, err = New(checkpoints, FrameworkID(frameworkID3), Tasks(registry3)).Restore(); ok || err != ErrFrameworkMismatch || registry3.Len() != 0 {
+		t.Fatalf("expected a mismatch instead of %t, %v", ok, err)
+	}
+	if _, ok, err = New(store.NewInMemorySingleton()).Restore(); ok || err != nil {
+		t.Fatalf("expected no checkpoint instead of %t, %v", ok, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var (
+		saved       = make(chan State, 1)
+		checkpoints = store.DoSet().AndThen(func(_ store.Setter, v string, _ error) error {
+			var st State
+			if err := json.Unmarshal([]byte(v), &st); err != nil {
+				t.Error(err)
+			}
+			saved <- st
+			return nil
+		}).Decorate(store.NewInMemorySingleton())
+		clock       = mesostime.NewFakeClock(time.Unix(1000, 0).UTC())
+		c           = New(checkpoints, Clock(clock), ErrorFunc(func(err error) { t.Error(err) }))
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan error)
+	)
+	go func() { done <- c.Run(ctx, time.Hour) }()
+
+	// checkpoints are saved at every interval
+	for i := 1; i <= 2; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Hour)
+		if st := <-saved; !st.Time.Equal(time.Unix(1000, 0).Add(time.Duration(i) * time.Hour)) {
+			t.Fatalf("unexpected checkpoint time %v", st.Time)
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := checkpoints.Get(); err != nil || len(saved) != 1 {
+		t.Fatalf("expected a final checkpoint: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 
 	"github.com/mesos/mesos-go/api/v1/lib"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
@@ -22,9 +23,51 @@ type (
 		subscriptionTerminated func(error)
 		initSuppressRoles      []string
 		contextPerSubscription bool
+		reuseEvents            bool
+		unknownEventHandler    func(context.Context, *encoding.UnknownEvent) error
+	}
+
+	// DisconnectedError terminates a subscription whose event stream was closed by the master, or else
+	// broken: e.g. by a reset connection, or by an event that couldn't be decoded. It's delivered to the
+	// event handler, if that's an ErrorHandler, and then to the subscriptionTerminated func; see
+	// WithSubscriptionTerminated. Subscriptions that are terminated by the cancellation of the controller's
+	// context, or by an error of an event handler, report that error instead.
+	DisconnectedError struct {
+		Err error // the error that ended the stream; io.EOF if it was closed by the master
+	}
+
+	// ErrorHandler is optionally implemented by the event handler of a controller, e.g. by eventrules.Rules,
+	// in order to consume the *DisconnectedError of each subscription whose event stream was ended by the
+	// master. The subscription is terminated regardless.
+	ErrorHandler interface {
+		HandleError(context.Context, error)
 	}
 )
 
+// ErrDisconnected is the error of a DisconnectedError whose cause is unknown.
+var ErrDisconnected = errors.New("disconnected from the master")
+
+func (err *DisconnectedError) Error() string {
+	if err.Err == nil {
+		return ErrDisconnected.Error()
+	}
+	return ErrDisconnected.Error() + ": " + err.Err.Error()
+}
+
+// Cause implements the causer interface of github.com/pkg/errors.
+func (err *DisconnectedError) Cause() error {
+	if err.Err == nil {
+		return ErrDisconnected
+	}
+	return err.Err
+}
+
+// IsDisconnected returns true if err is a *DisconnectedError.
+func IsDisconnected(err error) bool {
+	_, ok := err.(*DisconnectedError)
+	return ok
+}
+
 // WithContextPerSubscription results in the creation of a sub-context that is passed to all event handlers
 // and is canceled when the associated subscription has termined (i.e. when the event loop exits and a re-
 // subscribe attempt is (possibly) attempted).
@@ -36,6 +79,28 @@ func WithContextPerSubscription(b bool) Option {
 	}
 }
 
+// WithReusedEvents configures whether every event of a subscription is decoded into the same Event; see
+// events.Iterator.ReuseEvents. Event handlers must not retain events, or anything that events reference,
+// beyond the invocation of the handler.
+func WithReusedEvents(b bool) Option {
+	return func(c *Config) Option {
+		old := c.reuseEvents
+		c.reuseEvents = b
+		return WithReusedEvents(old)
+	}
+}
+
+// WithUnknownEventHandler sets the consumer of the events of types that are unknown to this library, e.g.
+// those added by a newer version of Mesos; see encoding.UnknownEvent. Such events are skipped if the handler
+// is nil (the default). The event loop is aborted if the handler returns a non-nil error.
+func WithUnknownEventHandler(handler func(context.Context, *encoding.UnknownEvent) error) Option {
+	return func(c *Config) Option {
+		old := c.unknownEventHandler
+		c.unknownEventHandler = handler
+		return WithUnknownEventHandler(old)
+	}
+}
+
 // WithInitiallySuppressedRoles sets the "suppressed_roles" field of the SUBSCRIBE call
 // that's issued to Mesos for each (re-)subscription attempt.
 func WithInitiallySuppressedRoles(r []string) Option {
@@ -48,7 +113,8 @@ func WithInitiallySuppressedRoles(r []string) Option {
 
 // WithEventHandler sets the consumer of scheduler events. The controller's internal event processing
 // loop is aborted if a Handler returns a non-nil error, after which the controller may attempt
-// to re-register (subscribe) with Mesos.
+// to re-register (subscribe) with Mesos. Handlers that implement ErrorHandler are also notified of
+// disconnections.
 func WithEventHandler(handler events.Handler) Option {
 	return func(c *Config) Option {
 		old := c.handler
@@ -70,7 +136,8 @@ func WithFrameworkID(frameworkIDFunc func() string) Option {
 }
 
 // WithSubscriptionTerminated sets a handler that is invoked at the end of every subscription cycle; the
-// given error may be nil if no error occurred. subscriptionTerminated is optional; if nil then errors are
+// given error may be nil if no error occurred. Subscriptions that are ended by the master, rather than by
+// the framework, yield a *DisconnectedError. subscriptionTerminated is optional; if nil then errors are
 // swallowed.
 func WithSubscriptionTerminated(handler func(error)) Option {
 	return func(c *Config) Option {
@@ -163,25 +230,39 @@ func processSubscription(ctx context.Context, config Config, resp mesos.Response
 	return err
 }
 
-// eventLoop returns the framework ID received by mesos (if any); callers should check for a
-// framework ID regardless of whether error != nil.
+// eventLoop processes the events read from the decoder until either an error occurs, or else until the
+// context is done. If the decoder is also an io.Closer (e.g. a mesos.Response) then it's closed upon
+// cancellation, interrupting a pending read. The errors of the decoder (but for those of cancellation) are
+// reported as a *DisconnectedError, which is first delivered to the event handler if it's an ErrorHandler.
 func eventLoop(ctx context.Context, config Config, eventDecoder encoding.Decoder) (err error) {
-	for err == nil {
-		select {
-		case <-ctx.Done():
-			err = ctx.Err()
-			return
-		default:
+	var (
+		it = events.IteratorFor(eventDecoder).ReuseEvents(config.reuseEvents)
+		e  *scheduler.Event
+	)
+	defer it.Close()
+	for {
+		if e, err = it.Next(ctx); err != nil {
+			unknown, ok := encoding.IsUnknownEvent(err)
+			if !ok {
+				if ctx.Err() == nil {
+					err = &DisconnectedError{Err: err}
+					if eh, ok := config.handler.(ErrorHandler); ok {
+						eh.HandleError(ctx, err)
+					}
+				}
+				return
+			}
+			if config.unknownEventHandler != nil {
+				if err = config.unknownEventHandler(ctx, unknown); err != nil {
+					return
+				}
+			}
+			continue
 		}
-
-		var e scheduler.Event
-		if err = eventDecoder.Decode(&e); err != nil {
+		if err = config.handler.HandleEvent(ctx, e); err != nil {
 			return
 		}
-
-		err = config.handler.HandleEvent(ctx, &e)
 	}
-	return
 }
 
 // DefaultHandler is invoked when no other handlers have been defined for the controller.
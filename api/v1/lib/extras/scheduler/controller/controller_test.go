@@ -3,13 +3,16 @@ package controller
 import (
 	"context"
 	"errors"
+	"io"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/mesos/mesos-go/api/v1/lib"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
 	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
 	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
 )
 
@@ -23,16 +26,18 @@ var (
 func TestEventLoop(t *testing.T) {
 	type action func(cancel context.CancelFunc, decoder chan<- struct{})
 	for i, tc := range []struct {
-		action   action
-		wantsErr error
+		action       action
+		wantsErr     error
+		disconnected bool
 	}{
 		{
 			action:   func(cancel context.CancelFunc, _ chan<- struct{}) { cancel() },
 			wantsErr: context.Canceled,
 		},
 		{
-			action:   func(_ context.CancelFunc, d chan<- struct{}) { close(d) },
-			wantsErr: eof,
+			action:       func(_ context.CancelFunc, d chan<- struct{}) { close(d) },
+			wantsErr:     eof,
+			disconnected: true,
 		},
 		{
 			action: func(_ context.CancelFunc, d chan<- struct{}) {
@@ -88,6 +93,12 @@ func TestEventLoop(t *testing.T) {
 
 			select {
 			case err := <-ch:
+				if IsDisconnected(err) != tc.disconnected {
+					t.Fatalf("unexpected disconnection state: %v", err)
+				}
+				if tc.disconnected {
+					err = err.(*DisconnectedError).Err
+				}
 				if err != tc.wantsErr {
 					t.Fatalf("unexpected error state: %v", err)
 				}
@@ -105,6 +116,93 @@ func TestEventLoop(t *testing.T) {
 	}
 }
 
+func TestEventLoopUnknownEvents(t *testing.T) {
+	var (
+		n = 0
+		d = encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			n++
+			switch n {
+			case 1, 2:
+				return &encoding.UnknownEvent{Type: "FUTURE"}
+			case 3:
+				u.(*scheduler.Event).Type = scheduler.Event_HEARTBEAT
+				return nil
+			}
+			return eof
+		})
+		handled []string
+		config  Config
+	)
+	WithEventHandler(events.HandlerFunc(func(_ context.Context, e *scheduler.Event) error {
+		handled = append(handled, e.GetType().String())
+		return nil
+	}))(&config)
+	if err := eventLoop(context.Background(), config, d); !IsDisconnected(err) || err.(*DisconnectedError).Err != eof {
+		t.Fatalf("expected a disconnection by eof instead of %v", err)
+	}
+	if len(handled) != 1 || handled[0] != "HEARTBEAT" {
+		t.Fatalf("expected unknown events to be skipped: %v", handled)
+	}
+
+	n, handled = 1, nil
+	WithUnknownEventHandler(func(_ context.Context, e *encoding.UnknownEvent) error {
+		handled = append(handled, e.Type)
+		return tooManyEvents
+	})(&config)
+	if err := eventLoop(context.Background(), config, d); err != tooManyEvents {
+		t.Fatalf("expected the error of the unknown event handler instead of %v", err)
+	}
+	if len(handled) != 1 || handled[0] != "FUTURE" {
+		t.Fatalf("expected the unknown event to be handled: %v", handled)
+	}
+}
+
+func TestEventLoopErrorHandler(t *testing.T) {
+	var (
+		n = 0
+		d = encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			if n++; n == 1 {
+				u.(*scheduler.Event).Type = scheduler.Event_HEARTBEAT
+				return nil
+			}
+			return eof
+		})
+		handled []string
+		failed  []error
+		rules   = eventrules.Rules{
+			eventrules.HandleF(func(_ context.Context, e *scheduler.Event) error {
+				handled = append(handled, e.GetType().String())
+				return nil
+			}),
+			eventrules.Rule(nil).OnFailure(func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+				failed = append(failed, err)
+				return ch(ctx, e, err)
+			}),
+		}
+	)
+	err := eventLoop(context.Background(), Config{handler: rules}, d)
+	if !IsDisconnected(err) || err.(*DisconnectedError).Err != eof {
+		t.Fatalf("expected a disconnection by eof instead of %v", err)
+	}
+	if len(handled) != 2 || handled[0] != "HEARTBEAT" || handled[1] != "UNKNOWN" {
+		t.Fatalf("expected the disconnection to be evaluated by the rules: %v", handled)
+	}
+	if len(failed) != 1 || failed[0] != err {
+		t.Fatalf("expected the disconnection to be the error state of the rules: %v", failed)
+	}
+
+	// canceled subscriptions aren't disconnected
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, handled, failed = 1, nil, nil
+	if err = eventLoop(ctx, Config{handler: rules}, d); IsDisconnected(err) {
+		t.Fatalf("unexpected disconnection: %v", err)
+	}
+	if len(handled) != 0 || len(failed) != 0 {
+		t.Fatalf("unexpected events %v, and errors %v", handled, failed)
+	}
+}
+
 func TestProcessSubscription(t *testing.T) {
 	t.Run("default", func(t *testing.T) { testProcessSubscription(t, false) })
 	t.Run("ctxPerSub", func(t *testing.T) { testProcessSubscription(t, true) })
@@ -162,7 +260,7 @@ func testProcessSubscription(t *testing.T, contextPerSubscription bool) {
 
 	select {
 	case err := <-ch:
-		if err != eof {
+		if d, ok := err.(*DisconnectedError); !ok || d.Err != eof {
 			t.Fatalf("unexpected error state: %v", err)
 		}
 		expectedEvents := 1
@@ -187,3 +285,41 @@ func testProcessSubscription(t *testing.T, contextPerSubscription bool) {
 		}
 	}
 }
+
+func TestRunDisconnected(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		subscribed  = 0
+		terminated  []error
+		caller      = calls.CallerFunc(func(ctx context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			subscribed++
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(encoding.Unmarshaler) error {
+				if subscribed == 1 {
+					return io.EOF // closed by the master
+				}
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			})}, nil
+		})
+	)
+	defer cancel()
+	err := Run(ctx, &mesos.FrameworkInfo{}, caller, WithSubscriptionTerminated(func(err error) {
+		terminated = append(terminated, err)
+	}))
+	if err != context.Canceled {
+		t.Fatalf("expected cancellation instead of %v", err)
+	}
+	if len(terminated) != 2 {
+		t.Fatalf("expected 2 terminated subscriptions instead of %v", terminated)
+	}
+	if d, ok := terminated[0].(*DisconnectedError); !ok || d.Err != io.EOF || d.Cause() != io.EOF {
+		t.Fatalf("expected the first subscription to be disconnected: %v", terminated[0])
+	}
+	if s := terminated[0].Error(); s != "disconnected from the master: EOF" {
+		t.Fatalf("unexpected error message %q", s)
+	}
+	if IsDisconnected(terminated[1]) {
+		t.Fatalf("expected the second subscription to be canceled: %v", terminated[1])
+	}
+}
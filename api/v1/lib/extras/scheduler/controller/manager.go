@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+var (
+	// ErrDuplicateSubscription is returned when adding a subscription the name of which is already managed.
+	ErrDuplicateSubscription = errors.New("duplicate subscription")
+	// ErrUnknownSubscription is returned for a subscription name that isn't managed.
+	ErrUnknownSubscription = errors.New("unknown subscription")
+)
+
+type (
+	// Subscription is an independent framework subscription, managed by a SubscriptionManager. Each
+	// subscription should have its own Caller (i.e. its own client, and so its own credentials and
+	// stream ID) and its own event handler (see WithEventHandler); the optional rules isolate the
+	// metrics (or other cross-cutting concerns) of the subscription, e.g. the CallRule and EventRule of
+	// a schedmetrics.Metrics that's exported by a sink with a subscription-specific prefix.
+	Subscription struct {
+		Name      string // unique among the subscriptions of a manager, e.g. a shard or tenant ID
+		Framework *mesos.FrameworkInfo
+		Caller    calls.Caller
+		Options   []Option
+		CallRule  callrules.Rule  // optional, decorates Caller
+		EventRule eventrules.Rule // optional, decorates the event handler
+	}
+
+	// SubscriptionManager executes a control loop (see Run) for each of a set of subscriptions, within
+	// a single process; e.g. for a multi-tenant control plane, or a sharded scheduler. Subscriptions may
+	// be added and removed at any time, without disturbing other subscriptions. SubscriptionManager
+	// funcs are safe to invoke concurrently.
+	SubscriptionManager struct {
+		ctx context.Context
+
+		m    sync.Mutex
+		subs map[string]*managedSubscription
+		wg   sync.WaitGroup
+	}
+
+	managedSubscription struct {
+		cancel context.CancelFunc
+		done   chan struct{}
+		err    error // valid once done is closed
+	}
+
+	subscriptionNameKey struct{}
+)
+
+// NewSubscriptionManager returns a manager of subscriptions that are all terminated once the given
+// context is done.
+func NewSubscriptionManager(ctx context.Context) *SubscriptionManager {
+	return &SubscriptionManager{ctx: ctx, subs: make(map[string]*managedSubscription)}
+}
+
+// SubscriptionName returns the name of the managed subscription that the context (as given to an event
+// handler, or a caller) belongs to.
+func SubscriptionName(ctx context.Context) (name string, ok bool) {
+	name, ok = ctx.Value(subscriptionNameKey{}).(string)
+	return
+}
+
+// Add starts the control loop of the given subscription, in the background. The options of the
+// subscription are applied after those given to Add, which apply to this subscription only.
+func (m *SubscriptionManager) Add(s Subscription, options ...Option) error {
+	if s.Framework == nil || s.Caller == nil {
+		return errors.New("subscription requires a framework and a caller")
+	}
+	m.m.Lock()
+	defer m.m.Unlock()
+	if _, ok := m.subs[s.Name]; ok {
+		return ErrDuplicateSubscription
+	}
+	var (
+		ctx, cancel = context.WithCancel(context.WithValue(m.ctx, subscriptionNameKey{}, s.Name))
+		ms          = &managedSubscription{cancel: cancel, done: make(chan struct{})}
+		caller      = s.Caller
+		opts        = append(append([]Option(nil), options...), s.Options...)
+	)
+	if s.CallRule != nil {
+		caller = s.CallRule.Caller(caller)
+	}
+	if s.EventRule != nil {
+		opts = append(opts, decorateEventHandler(s.EventRule))
+	}
+	m.subs[s.Name] = ms
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer close(ms.done)
+		defer cancel()
+		ms.err = Run(ctx, s.Framework, caller, opts...)
+	}()
+	return nil
+}
+
+// Remove terminates the control loop of the named subscription, waits for it to exit, and returns the
+// last error of the loop. The framework isn't torn down: a framework with a failover timeout may be
+// re-added later.
+func (m *SubscriptionManager) Remove(name string) error {
+	m.m.Lock()
+	ms, ok := m.subs[name]
+	delete(m.subs, name)
+	m.m.Unlock()
+	if !ok {
+		return ErrUnknownSubscription
+	}
+	ms.cancel()
+	<-ms.done
+	return ms.err
+}
+
+// Done returns a chan that's closed once the control loop of the named subscription has exited (e.g.
+// because its registration tokens were closed), or else nil for an unknown subscription.
+func (m *SubscriptionManager) Done(name string) <-chan struct{} {
+	m.m.Lock()
+	defer m.m.Unlock()
+	if ms, ok := m.subs[name]; ok {
+		return ms.done
+	}
+	return nil
+}
+
+// Names returns the sorted names of the managed subscriptions.
+func (m *SubscriptionManager) Names() []string {
+	m.m.Lock()
+	defer m.m.Unlock()
+	names := make([]string, 0, len(m.subs))
+	for name := range m.subs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait blocks until the control loops of all subscriptions have exited; typically once the context of
+// the manager is done.
+func (m *SubscriptionManager) Wait() { m.wg.Wait() }
+
+// decorateEventHandler returns an Option that wraps the event handler of a Config with the given rule.
+func decorateEventHandler(r eventrules.Rule) Option {
+	return func(c *Config) Option {
+		old := c.handler
+		h := old
+		if h == nil {
+			h = DefaultHandler
+		}
+		c.handler = r.Handle(h)
+		return WithEventHandler(old)
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+func TestSubscriptionManager(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		m = NewSubscriptionManager(ctx)
+		// every subscription yields a single HEARTBEAT event, then blocks until canceled
+		caller = calls.CallerFunc(func(ctx context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			sent := false
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				if !sent {
+					sent = true
+					u.(*scheduler.Event).Type = scheduler.Event_HEARTBEAT
+					return nil
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			})}, nil
+		})
+		handled = make(chan string, 2)
+		handler = events.HandlerFunc(func(ctx context.Context, _ *scheduler.Event) error {
+			name, _ := SubscriptionName(ctx)
+			handled <- name
+			return nil
+		})
+		decorated    = make(chan string, 4)
+		subscription = func(name string) Subscription {
+			return Subscription{
+				Name:      name,
+				Framework: &mesos.FrameworkInfo{Name: name},
+				Caller:    caller,
+				Options:   []Option{WithEventHandler(handler)},
+				CallRule: func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+					if c.GetType() == scheduler.Call_SUBSCRIBE && c.GetSubscribe().GetFrameworkInfo().GetName() == name {
+						decorated <- "call:" + name
+					}
+					return ch(ctx, c, r, err)
+				},
+				EventRule: func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+					decorated <- "event:" + name
+					return ch(ctx, e, err)
+				},
+			}
+		}
+		receive = func(ch <-chan string, n int) (result []string) {
+			for i := 0; i < n; i++ {
+				select {
+				case s := <-ch:
+					result = append(result, s)
+				case <-time.After(patience):
+					t.Fatalf("timed out waiting for %d values, received %v", n, result)
+				}
+			}
+			sort.Strings(result)
+			return
+		}
+	)
+	for _, name := range []string{"b", "a"} {
+		if err := m.Add(subscription(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Add(subscription("a")); err != ErrDuplicateSubscription {
+		t.Fatalf("expected duplicate subscription error instead of %v", err)
+	}
+	if err := m.Add(Subscription{Name: "c"}); err == nil {
+		t.Fatal("expected error for a subscription without a framework or caller")
+	}
+	if names := m.Names(); !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Fatalf("unexpected subscriptions %v", names)
+	}
+
+	if got := receive(handled, 2); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected handled events %v", got)
+	}
+	if got := receive(decorated, 4); !reflect.DeepEqual(got, []string{"call:a", "call:b", "event:a", "event:b"}) {
+		t.Fatalf("unexpected decorated calls and events %v", got)
+	}
+
+	// removing a subscription doesn't disturb the others
+	done := m.Done("a")
+	if err := m.Remove("a"); err != context.Canceled {
+		t.Fatalf("expected canceled subscription instead of %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Fatal("expected the control loop of the removed subscription to have exited")
+	}
+	if err := m.Remove("a"); err != ErrUnknownSubscription {
+		t.Fatalf("expected unknown subscription error instead of %v", err)
+	}
+	select {
+	case <-m.Done("b"):
+		t.Fatal("unexpected termination of subscription b")
+	default:
+	}
+
+	cancel()
+	ch := make(chan struct{})
+	go func() {
+		m.Wait()
+		close(ch)
+	}()
+	select {
+	case <-ch:
+	case <-time.After(patience):
+		t.Fatal("timed out waiting for subscriptions to terminate")
+	}
+}
@@ -0,0 +1,325 @@
+// Package denylist temporarily excludes flapping agents, those on which the tasks of a framework fail
+// repeatedly, from the offers that the framework considers. Every failure of a task on an agent adds to
+// the failure score of the agent, which decays exponentially over time: agents whose score reaches a
+// threshold are denied for a penalty period, see DenyList.Filter.
+package denylist
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+const (
+	// DefaultThreshold is the default failure score at which agents are denied.
+	DefaultThreshold = 3.0
+	// DefaultHalfLife is the default interval over which failure scores decay by half.
+	DefaultHalfLife = 10 * time.Minute
+	// DefaultPenalty is the default period for which agents are denied.
+	DefaultPenalty = 5 * time.Minute
+
+	// negligible scores are forgotten, along with their agents, unless denied
+	negligible = 0.01
+	// the failures of tasks are forgotten once their contribution to the score of the agent is negligible
+	negligibleHalfLives = 7 // 2^-7 < negligible
+)
+
+type (
+	// Option is a functional configuration option for a DenyList; it returns an Option that acts as an
+	// "undo" if applied to the same DenyList.
+	Option func(*DenyList) Option
+
+	// Agent reports the failure score of an agent, and whether it's denied.
+	Agent struct {
+		ID          string
+		Score       float64   // decayed, as of the time of the report
+		DeniedUntil time.Time // zero unless the agent is denied
+	}
+
+	// DenyList tracks the failure scores of agents. Its rule must be added to the event processing chain
+	// of the scheduler; its filter applied to the offers that the scheduler considers. DenyList funcs are
+	// safe to invoke concurrently.
+	DenyList struct {
+		clock     mesostime.Clock
+		threshold float64
+		halfLife  time.Duration
+		penalty   time.Duration
+		isFailure func(*mesos.TaskStatus) bool
+		onDenied  func(Agent)
+
+		m      sync.Mutex
+		agents map[string]*agent
+	}
+
+	agent struct {
+		score       float64
+		at          time.Time // the time as of which score was computed
+		deniedUntil time.Time
+		failed      map[mesos.TaskID]failure // so that redelivered status updates are only counted once
+	}
+
+	// failure is the most recently counted failure of a task
+	failure struct {
+		timestamp float64   // of the status update
+		at        time.Time // the time at which the failure was counted
+	}
+)
+
+// Clock configures the clock that times failures, and denials; defaults to mesostime.SystemClock.
+func Clock(c mesostime.Clock) Option {
+	return func(d *DenyList) Option {
+		old := d.clock
+		d.clock = c
+		return Clock(old)
+	}
+}
+
+// Threshold configures the failure score at which agents are denied; defaults to DefaultThreshold.
+func Threshold(score float64) Option {
+	return func(d *DenyList) Option {
+		old := d.threshold
+		d.threshold = score
+		return Threshold(old)
+	}
+}
+
+// HalfLife configures the interval over which failure scores decay by half; defaults to DefaultHalfLife.
+// Zero disables decay.
+func HalfLife(h time.Duration) Option {
+	return func(d *DenyList) Option {
+		old := d.halfLife
+		d.halfLife = h
+		return HalfLife(old)
+	}
+}
+
+// Penalty configures the period for which agents are denied, as of their most recent failure; defaults to
+// DefaultPenalty.
+func Penalty(p time.Duration) Option {
+	return func(d *DenyList) Option {
+		old := d.penalty
+		d.penalty = p
+		return Penalty(old)
+	}
+}
+
+// Failures configures the func that determines whether a task status reports a failure that counts
+// against the agent of the task; defaults to IsFailure.
+func Failures(f func(*mesos.TaskStatus) bool) Option {
+	return func(d *DenyList) Option {
+		old := d.isFailure
+		d.isFailure = f
+		return Failures(old)
+	}
+}
+
+// OnDenied configures a func that's invoked whenever an agent that isn't denied becomes denied. The func is
+// invoked synchronously by the goroutine that reports the failure, after the DenyList has been updated.
+func OnDenied(f func(Agent)) Option {
+	return func(d *DenyList) Option {
+		old := d.onDenied
+		d.onDenied = f
+		return OnDenied(old)
+	}
+}
+
+// IsFailure returns true for TASK_FAILED statuses, unless the task was preempted (see tasks.IsPreempted):
+// the failures of tasks that the agent may be responsible for. Tasks that are lost along with their agents
+// are the concern of package agents.
+func IsFailure(s *mesos.TaskStatus) bool {
+	return s.GetState() == mesos.TASK_FAILED && !tasks.IsPreempted(s)
+}
+
+// New returns a DenyList that doesn't deny any agents.
+func New(opts ...Option) *DenyList {
+	d := &DenyList{
+		clock:     mesostime.SystemClock,
+		threshold: DefaultThreshold,
+		halfLife:  DefaultHalfLife,
+		penalty:   DefaultPenalty,
+		isFailure: IsFailure,
+		agents:    make(map[string]*agent),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(d)
+		}
+	}
+	return d
+}
+
+// Update records the given task status, if it reports a failure of a task on an agent. Failures are
+// identified by task ID and timestamp: the redelivery of a failure is ignored, whereas a task that's
+// relaunched (under the same ID) and fails again is counted again. A status that reports that the task
+// is (once again) active clears the record of its previous failure.
+func (d *DenyList) Update(s *mesos.TaskStatus) {
+	id := s.GetAgentID().GetValue()
+	switch {
+	case id == "":
+	case d.isFailure(s):
+		d.failed(id, &s.TaskID, s.GetTimestamp())
+	case !tasks.IsTerminal(s.GetState()):
+		d.active(id, s.TaskID)
+	}
+}
+
+// Failed records a failure on the given agent: e.g. of a task whose status wasn't reported to the
+// framework, but rather to some other component.
+func (d *DenyList) Failed(id string) {
+	d.failed(id, nil, 0)
+}
+
+func (d *DenyList) failed(id string, task *mesos.TaskID, timestamp float64) {
+	d.m.Lock()
+	now := d.clock.Now()
+	d.sweep(now)
+	a, ok := d.agents[id]
+	if !ok {
+		a = &agent{at: now, failed: make(map[mesos.TaskID]failure)}
+		d.agents[id] = a
+	}
+	if task != nil {
+		if f, ok := a.failed[*task]; ok && f.timestamp == timestamp {
+			d.m.Unlock()
+			return
+		}
+		a.failed[*task] = failure{timestamp: timestamp, at: now}
+	}
+	var (
+		wasDenied = now.Before(a.deniedUntil)
+		denied    bool
+	)
+	a.decay(now, d.halfLife)
+	a.score++
+	if a.score >= d.threshold {
+		a.deniedUntil = now.Add(d.penalty)
+		denied = !wasDenied
+	}
+	report := a.report(id, now)
+	d.m.Unlock()
+
+	if denied && d.onDenied != nil {
+		d.onDenied(report)
+	}
+}
+
+func (d *DenyList) active(id string, task mesos.TaskID) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if a, ok := d.agents[id]; ok {
+		delete(a.failed, task)
+	}
+}
+
+// Forgive forgets the failures of the given agent, which is no longer denied; e.g. upon the intervention
+// of an operator.
+func (d *DenyList) Forgive(id string) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	delete(d.agents, id)
+}
+
+// Denied returns true if the given agent is denied.
+func (d *DenyList) Denied(id string) bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+	now := d.clock.Now()
+	return d.prune(id, now) && now.Before(d.agents[id].deniedUntil)
+}
+
+// Agent returns the failure score of the given agent; false if the agent's failures (if any) have been
+// forgotten.
+func (d *DenyList) Agent(id string) (Agent, bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	now := d.clock.Now()
+	if !d.prune(id, now) {
+		return Agent{}, false
+	}
+	return d.agents[id].report(id, now), true
+}
+
+// Agents returns the failure scores of the agents whose failures haven't been forgotten, sorted by ID.
+func (d *DenyList) Agents() (result []Agent) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	now := d.clock.Now()
+	for id, a := range d.agents {
+		if !d.prune(id, now) {
+			continue
+		}
+		result = append(result, a.report(id, now))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return
+}
+
+// prune forgets the given agent if it isn't denied, and its score is negligible; returns true if the
+// agent is still tracked. Requires that d.m is locked.
+func (d *DenyList) prune(id string, now time.Time) bool {
+	a, ok := d.agents[id]
+	if !ok {
+		return false
+	}
+	a.decay(now, d.halfLife)
+	if a.score < negligible && !now.Before(a.deniedUntil) {
+		delete(d.agents, id)
+		return false
+	}
+	if d.halfLife > 0 {
+		for task, f := range a.failed {
+			if now.Sub(f.at) > negligibleHalfLives*d.halfLife {
+				delete(a.failed, task)
+			}
+		}
+	}
+	return true
+}
+
+// sweep prunes every agent; see prune. Requires that d.m is locked.
+func (d *DenyList) sweep(now time.Time) {
+	for id := range d.agents {
+		d.prune(id, now)
+	}
+}
+
+// Filter returns a Filter that rejects the offers of denied agents.
+func (d *DenyList) Filter() offers.Filter {
+	return offers.FilterFunc(func(o *mesos.Offer) bool { return !d.Denied(o.AgentID.Value) })
+}
+
+// EventRule returns a Rule that records the task status updates of the scheduler; see Update.
+func (d *DenyList) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil && e.GetType() == scheduler.Event_UPDATE {
+			s := e.GetUpdate().GetStatus()
+			d.Update(&s)
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// decay updates the score of the agent as of the given time.
+func (a *agent) decay(now time.Time, halfLife time.Duration) {
+	if elapsed := now.Sub(a.at); halfLife > 0 && elapsed > 0 {
+		a.score *= math.Exp2(-float64(elapsed) / float64(halfLife))
+	}
+	a.at = now
+}
+
+func (a *agent) report(id string, now time.Time) Agent {
+	r := Agent{ID: id, Score: a.score}
+	if now.Before(a.deniedUntil) {
+		r.DeniedUntil = a.deniedUntil
+	}
+	return r
+}
@@ -0,0 +1,133 @@
+package denylist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func failed(agent, task string, reason *mesos.TaskStatus_Reason) *scheduler.Event {
+	return &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: mesos.TaskStatus{
+		TaskID:  mesos.TaskID{Value: task},
+		AgentID: &mesos.AgentID{Value: agent},
+		State:   mesos.TASK_FAILED.Enum(),
+		Reason:  reason,
+	}}}
+}
+
+func TestDenyList(t *testing.T) {
+	var (
+		clock  = mesostime.NewFakeClock(time.Unix(1000, 0))
+		denied []Agent
+		d      = New(
+			Clock(clock),
+			Threshold(2),
+			HalfLife(time.Minute),
+			Penalty(5*time.Minute),
+			OnDenied(func(a Agent) { denied = append(denied, a) }),
+		)
+		handle = func(e *scheduler.Event) {
+			if err := d.EventRule().HandleEvent(context.Background(), e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		offer = func(agent string) *mesos.Offer { return &mesos.Offer{AgentID: mesos.AgentID{Value: agent}} }
+	)
+	handle(failed("a1", "t1", nil))
+	handle(failed("a1", "t1", nil)) // redelivered
+	handle(failed("a1", "t2", mesos.REASON_CONTAINER_PREEMPTED.Enum()))
+	handle(failed("a2", "t3", nil))
+	if d.Denied("a1") || len(denied) != 0 {
+		t.Fatalf("expected a1 not to be denied: %v", d.Agents())
+	}
+
+	clock.Advance(time.Minute) // the score of a1 decays to 0.5
+	if a, ok := d.Agent("a1"); !ok || a.Score != 0.5 {
+		t.Fatalf("expected the score of a1 to decay: %+v", a)
+	}
+	d.Failed("a1")
+	d.Failed("a1")
+	if !d.Denied("a1") || len(denied) != 1 || denied[0].ID != "a1" || !denied[0].DeniedUntil.Equal(clock.Now().Add(5*time.Minute)) {
+		t.Fatalf("expected a1 to be denied: %v", denied)
+	}
+	d.Failed("a1")
+	if len(denied) != 1 {
+		t.Fatalf("expected a single report of the denial: %v", denied)
+	}
+
+	if o := (offers.Slice{*offer("a1"), *offer("a2")}).Find(d.Filter()); o == nil || o.AgentID.Value != "a2" {
+		t.Fatalf("expected the offers of a1 to be rejected: %v", o)
+	}
+
+	clock.Advance(5 * time.Minute)
+	if d.Denied("a1") || !d.Filter().Accept(offer("a1")) {
+		t.Fatalf("expected the denial of a1 to expire: %v", d.Agents())
+	}
+	if agents := d.Agents(); len(agents) != 2 || agents[0].ID != "a1" || agents[1].ID != "a2" {
+		t.Fatalf("unexpected agents: %v", agents)
+	}
+
+	clock.Advance(time.Hour)
+	if agents := d.Agents(); len(agents) != 0 {
+		t.Fatalf("expected negligible scores to be forgotten: %v", agents)
+	}
+
+	d.Failed("a2")
+	d.Failed("a2")
+	d.Forgive("a2")
+	if _, ok := d.Agent("a2"); ok || d.Denied("a2") {
+		t.Fatal("expected a2 to be forgiven")
+	}
+}
+
+func TestDenyListRelaunch(t *testing.T) {
+	var (
+		clock  = mesostime.NewFakeClock(time.Unix(1000, 0))
+		d      = New(Clock(clock), Threshold(2), HalfLife(time.Minute))
+		update = func(task string, state mesos.TaskState, timestamp float64) {
+			d.Update(&mesos.TaskStatus{
+				TaskID:    mesos.TaskID{Value: task},
+				AgentID:   &mesos.AgentID{Value: "a1"},
+				State:     state.Enum(),
+				Timestamp: &timestamp,
+			})
+		}
+	)
+	// a task that's relaunched under the same ID, and that fails again, is counted again
+	update("t1", mesos.TASK_FAILED, 1)
+	update("t1", mesos.TASK_FAILED, 1) // redelivered
+	if d.Denied("a1") {
+		t.Fatalf("expected a redelivered failure to be ignored: %v", d.Agents())
+	}
+	update("t1", mesos.TASK_FAILED, 2)
+	if !d.Denied("a1") {
+		t.Fatalf("expected a1 to be denied: %v", d.Agents())
+	}
+
+	// as is a task that becomes active again, whatever the timestamp of its failure
+	d.Forgive("a1")
+	update("t2", mesos.TASK_FAILED, 0)
+	update("t2", mesos.TASK_RUNNING, 0)
+	update("t2", mesos.TASK_FAILED, 0)
+	if !d.Denied("a1") {
+		t.Fatalf("expected a1 to be denied: %v", d.Agents())
+	}
+
+	// agents, and the failures of their tasks, are forgotten even if they're only ever queried via Denied
+	// (e.g. by Filter), or not at all
+	clock.Advance(time.Hour)
+	if d.Denied("a1") || len(d.agents) != 0 {
+		t.Fatalf("expected a1 to be forgotten: %v", d.agents)
+	}
+	update("t3", mesos.TASK_FAILED, 1)
+	clock.Advance(time.Hour)
+	update("t4", mesos.TASK_FAILED, 1)
+	if a := d.agents["a1"]; len(d.agents) != 1 || len(a.failed) != 1 {
+		t.Fatalf("expected the failures of a1 to be pruned: %v", a.failed)
+	}
+}
@@ -0,0 +1,435 @@
+// Package driver adapts the callback-oriented Scheduler interface of the legacy (v0) mesos-go scheduler
+// driver to the v1 scheduler API, by way of the controller package: so that frameworks that were written
+// against the deprecated v0 bindings may migrate to mesos-go v1 without a rewrite. The callbacks, and the
+// funcs of the SchedulerDriver, retain their v0 names and shapes (e.g. SlaveLost, and slices of pointers)
+// but use the types of the v1 mesos package (e.g. an AgentID rather than a SlaveID).
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/backoff"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/controller"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+const (
+	// DefaultMinBackoff is the default minimum period between subscription attempts.
+	DefaultMinBackoff = time.Second
+	// DefaultMaxBackoff is the default maximum period between subscription attempts.
+	DefaultMaxBackoff = 15 * time.Second
+)
+
+// ErrNotRunning is returned when invoking a driver func that requires a running driver.
+var ErrNotRunning = errors.New("scheduler driver is not running")
+
+// Status is the state of a driver, as per the mesos.Status of the v0 driver.
+type Status int
+
+const (
+	StatusNotStarted Status = iota
+	StatusRunning
+	StatusAborted
+	StatusStopped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusNotStarted:
+		return "DRIVER_NOT_STARTED"
+	case StatusRunning:
+		return "DRIVER_RUNNING"
+	case StatusAborted:
+		return "DRIVER_ABORTED"
+	case StatusStopped:
+		return "DRIVER_STOPPED"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+type (
+	// Scheduler is the callback interface of legacy frameworks. Callbacks are invoked serially, by the
+	// event loop of the controller; callbacks may invoke the funcs of the driver, except for Join and Run.
+	Scheduler interface {
+		// Registered is invoked once the framework has subscribed to a master, for the first time.
+		Registered(SchedulerDriver, *mesos.FrameworkID, *mesos.MasterInfo)
+		// Reregistered is invoked once the framework has re-subscribed, e.g. to a newly elected master.
+		Reregistered(SchedulerDriver, *mesos.MasterInfo)
+		// Disconnected is invoked when the subscription is lost; the driver re-subscribes with backoff.
+		Disconnected(SchedulerDriver)
+		ResourceOffers(SchedulerDriver, []*mesos.Offer)
+		OfferRescinded(SchedulerDriver, *mesos.OfferID)
+		// StatusUpdate is invoked for every status update; updates are acknowledged once it returns,
+		// unless the driver is configured for ExplicitAcknowledgements.
+		StatusUpdate(SchedulerDriver, *mesos.TaskStatus)
+		FrameworkMessage(SchedulerDriver, *mesos.ExecutorID, *mesos.AgentID, string)
+		SlaveLost(SchedulerDriver, *mesos.AgentID)
+		ExecutorLost(SchedulerDriver, *mesos.ExecutorID, *mesos.AgentID, int)
+		// Error is invoked when the master reports an unrecoverable error (e.g. the framework has been
+		// removed), after which the driver aborts.
+		Error(SchedulerDriver, string)
+	}
+
+	// SchedulerDriver is the interface of the v0 scheduler driver, as it's invoked by frameworks.
+	SchedulerDriver interface {
+		Start() (Status, error)
+		Stop(failover bool) (Status, error)
+		Abort() (Status, error)
+		Join() (Status, error)
+		Run() (Status, error)
+		RequestResources([]*mesos.Request) (Status, error)
+		LaunchTasks([]*mesos.OfferID, []*mesos.TaskInfo, *mesos.Filters) (Status, error)
+		AcceptOffers([]*mesos.OfferID, []*mesos.Offer_Operation, *mesos.Filters) (Status, error)
+		DeclineOffer(*mesos.OfferID, *mesos.Filters) (Status, error)
+		KillTask(*mesos.TaskID) (Status, error)
+		ReviveOffers() (Status, error)
+		SuppressOffers() (Status, error)
+		SendFrameworkMessage(*mesos.ExecutorID, *mesos.AgentID, string) (Status, error)
+		ReconcileTasks([]*mesos.TaskStatus) (Status, error)
+		AcknowledgeStatusUpdate(*mesos.TaskStatus) (Status, error)
+	}
+
+	// Option is a functional configuration option for a Driver; it returns an Option that acts as an
+	// "undo" if applied to the same Driver.
+	Option func(*Driver) Option
+
+	// Driver implements SchedulerDriver via the v1 scheduler API. Driver funcs are safe to invoke
+	// concurrently.
+	Driver struct {
+		scheduler    Scheduler
+		framework    mesos.FrameworkInfo
+		caller       calls.Caller
+		explicitAcks bool
+		minBackoff   time.Duration
+		maxBackoff   time.Duration
+
+		m           sync.Mutex
+		status      Status
+		err         error
+		ctx         context.Context
+		cancel      context.CancelFunc
+		done        chan struct{}
+		frameworkID string
+		registered  bool
+		connected   bool
+	}
+)
+
+var _ = SchedulerDriver(&Driver{})
+
+// ExplicitAcknowledgements configures whether the framework acknowledges status updates itself, via
+// AcknowledgeStatusUpdate; by default the driver acknowledges every update once StatusUpdate returns.
+func ExplicitAcknowledgements(b bool) Option {
+	return func(d *Driver) Option {
+		old := d.explicitAcks
+		d.explicitAcks = b
+		return ExplicitAcknowledgements(old)
+	}
+}
+
+// RegistrationBackoff configures the bounds of the period between subscription attempts; defaults to
+// DefaultMinBackoff and DefaultMaxBackoff.
+func RegistrationBackoff(min, max time.Duration) Option {
+	return func(d *Driver) Option {
+		oldMin, oldMax := d.minBackoff, d.maxBackoff
+		d.minBackoff, d.maxBackoff = min, max
+		return RegistrationBackoff(oldMin, oldMax)
+	}
+}
+
+// New returns a driver, that's not yet started, of the given scheduler; calls are issued via the given
+// caller, e.g. an httpsched.Caller of the master. A framework whose info specifies an ID (and a failover
+// timeout) re-subscribes with that ID, as per the v0 driver.
+func New(s Scheduler, framework mesos.FrameworkInfo, caller calls.Caller, opts ...Option) *Driver {
+	d := &Driver{
+		scheduler:  s,
+		framework:  framework,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		done:       make(chan struct{}),
+	}
+	if framework.ID != nil {
+		d.frameworkID = framework.ID.Value
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(d)
+		}
+	}
+	d.caller = callrules.WithFrameworkID(d.FrameworkID).Caller(caller)
+	return d
+}
+
+// FrameworkID returns the ID of the framework, once it's been assigned by the master.
+func (d *Driver) FrameworkID() string {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.frameworkID
+}
+
+// Status returns the current state of the driver.
+func (d *Driver) Status() Status {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.status
+}
+
+// Start starts the driver, whose controller subscribes to the master in the background.
+func (d *Driver) Start() (Status, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.status != StatusNotStarted {
+		return d.status, fmt.Errorf("cannot start a scheduler driver in state %v", d.status)
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.status = StatusRunning
+	go d.run(d.ctx)
+	return d.status, nil
+}
+
+func (d *Driver) run(ctx context.Context) {
+	defer close(d.done)
+	framework := d.framework
+	err := controller.Run(ctx, &framework, d.caller,
+		controller.WithEventHandler(d.handler()),
+		controller.WithFrameworkID(d.FrameworkID),
+		controller.WithRegistrationTokens(backoff.Notifier(d.minBackoff, d.maxBackoff, ctx.Done())),
+		controller.WithSubscriptionTerminated(func(error) {
+			d.m.Lock()
+			connected := d.connected
+			d.connected = false
+			d.m.Unlock()
+			if connected && ctx.Err() == nil {
+				d.scheduler.Disconnected(d)
+			}
+		}),
+	)
+	if ctx.Err() == nil {
+		// the controller gave up, rather than being stopped (or aborted)
+		d.abort(err)
+	}
+}
+
+// Stop stops the driver. Unless failover is true, the framework is torn down: its tasks are killed, and
+// it may not re-subscribe; otherwise the master retains the framework (and its tasks) for the duration
+// of its failover timeout, so that another instance of the framework may re-subscribe with its ID.
+func (d *Driver) Stop(failover bool) (Status, error) {
+	d.m.Lock()
+	status, ctx := d.status, d.ctx
+	d.m.Unlock()
+	switch status {
+	case StatusRunning:
+	case StatusAborted:
+		return status, nil
+	default:
+		return status, ErrNotRunning
+	}
+	var err error
+	if !failover {
+		err = calls.CallNoData(ctx, d.caller, calls.Teardown())
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.status == StatusRunning {
+		d.status = StatusStopped
+		d.cancel()
+	}
+	return d.status, err
+}
+
+// Abort aborts the driver: the framework isn't torn down, and Join reports StatusAborted.
+func (d *Driver) Abort() (Status, error) {
+	return d.abort(nil)
+}
+
+func (d *Driver) abort(err error) (Status, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.status != StatusRunning {
+		return d.status, ErrNotRunning
+	}
+	d.status = StatusAborted
+	d.err = err
+	d.cancel()
+	return d.status, nil
+}
+
+// Join blocks until the driver is stopped, or aborted; the error reports the cause of an abort that
+// wasn't requested by way of Abort.
+func (d *Driver) Join() (Status, error) {
+	if d.Status() == StatusNotStarted {
+		return StatusNotStarted, ErrNotRunning
+	}
+	<-d.done
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.status, d.err
+}
+
+// Run starts the driver, and blocks until it's stopped, or aborted.
+func (d *Driver) Run() (Status, error) {
+	if status, err := d.Start(); err != nil {
+		return status, err
+	}
+	return d.Join()
+}
+
+// call issues the given call, if the driver is running.
+func (d *Driver) call(c *scheduler.Call) (Status, error) {
+	d.m.Lock()
+	status, ctx := d.status, d.ctx
+	d.m.Unlock()
+	if status != StatusRunning {
+		return status, ErrNotRunning
+	}
+	return status, calls.CallNoData(ctx, d.caller, c)
+}
+
+// RequestResources issues a REQUEST call; note that the allocator of Mesos ignores such requests.
+func (d *Driver) RequestResources(requests []*mesos.Request) (Status, error) {
+	rs := make([]mesos.Request, len(requests))
+	for i := range requests {
+		rs[i] = *requests[i]
+	}
+	return d.call(calls.Request(rs...))
+}
+
+// LaunchTasks launches the given tasks upon the resources of the given offers; the remaining resources
+// of the offers are declined, subject to the given filters (if any).
+func (d *Driver) LaunchTasks(offerIDs []*mesos.OfferID, tasks []*mesos.TaskInfo, filters *mesos.Filters) (Status, error) {
+	ts := make([]mesos.TaskInfo, len(tasks))
+	for i := range tasks {
+		ts[i] = *tasks[i]
+	}
+	return d.accept(offerIDs, []mesos.Offer_Operation{calls.OpLaunch(ts...)}, filters)
+}
+
+// AcceptOffers applies the given operations to the resources of the given offers; the remaining
+// resources of the offers are declined, subject to the given filters (if any).
+func (d *Driver) AcceptOffers(offerIDs []*mesos.OfferID, operations []*mesos.Offer_Operation, filters *mesos.Filters) (Status, error) {
+	ops := make([]mesos.Offer_Operation, len(operations))
+	for i := range operations {
+		ops[i] = *operations[i]
+	}
+	return d.accept(offerIDs, ops, filters)
+}
+
+func (d *Driver) accept(offerIDs []*mesos.OfferID, ops []mesos.Offer_Operation, filters *mesos.Filters) (Status, error) {
+	c := calls.Accept(calls.OfferOperations(ops).WithOffers(values(offerIDs)...))
+	c.Accept.Filters = filters
+	return d.call(c)
+}
+
+// DeclineOffer declines the given offer, subject to the given filters (if any).
+func (d *Driver) DeclineOffer(offerID *mesos.OfferID, filters *mesos.Filters) (Status, error) {
+	c := calls.Decline(*offerID)
+	c.Decline.Filters = filters
+	return d.call(c)
+}
+
+// KillTask kills the given task. The agent of the task isn't known to the driver, and so isn't specified.
+func (d *Driver) KillTask(id *mesos.TaskID) (Status, error) {
+	return d.call(calls.Kill(id.Value, ""))
+}
+
+// ReviveOffers removes the filters of the framework, and resumes offers (see SuppressOffers).
+func (d *Driver) ReviveOffers() (Status, error) {
+	return d.call(calls.Revive())
+}
+
+// SuppressOffers suspends offers, until ReviveOffers.
+func (d *Driver) SuppressOffers() (Status, error) {
+	return d.call(calls.Suppress())
+}
+
+// SendFrameworkMessage sends a message to the given executor; messages are delivered on a best-effort
+// basis.
+func (d *Driver) SendFrameworkMessage(executorID *mesos.ExecutorID, agentID *mesos.AgentID, data string) (Status, error) {
+	return d.call(calls.Message(agentID.Value, executorID.Value, []byte(data)))
+}
+
+// ReconcileTasks requests the explicit reconciliation of the given tasks, or the implicit reconciliation
+// of all tasks if none are given.
+func (d *Driver) ReconcileTasks(statuses []*mesos.TaskStatus) (Status, error) {
+	tasks := make(map[string]string, len(statuses))
+	for _, s := range statuses {
+		tasks[s.TaskID.Value] = s.GetAgentID().GetValue()
+	}
+	return d.call(calls.Reconcile(calls.ReconcileTasks(tasks)))
+}
+
+// AcknowledgeStatusUpdate acknowledges the given status update, see ExplicitAcknowledgements. Updates
+// without a UUID needn't be acknowledged.
+func (d *Driver) AcknowledgeStatusUpdate(s *mesos.TaskStatus) (Status, error) {
+	return d.call(calls.Acknowledge(s.GetAgentID().GetValue(), s.TaskID.Value, s.GetUUID()))
+}
+
+func values(ids []*mesos.OfferID) []mesos.OfferID {
+	result := make([]mesos.OfferID, len(ids))
+	for i := range ids {
+		result[i] = *ids[i]
+	}
+	return result
+}
+
+// handler returns the event handler of the controller, that dispatches events to the callbacks of the
+// scheduler.
+func (d *Driver) handler() events.HandlerFunc {
+	return func(ctx context.Context, e *scheduler.Event) error {
+		switch e.GetType() {
+		case scheduler.Event_SUBSCRIBED:
+			s := e.GetSubscribed()
+			d.m.Lock()
+			registered := d.registered
+			d.registered, d.connected = true, true
+			d.frameworkID = s.GetFrameworkID().GetValue()
+			d.m.Unlock()
+			if registered {
+				d.scheduler.Reregistered(d, s.GetMasterInfo())
+			} else {
+				d.scheduler.Registered(d, s.GetFrameworkID(), s.GetMasterInfo())
+			}
+		case scheduler.Event_OFFERS:
+			offers := e.GetOffers().GetOffers()
+			ps := make([]*mesos.Offer, len(offers))
+			for i := range offers {
+				ps[i] = &offers[i]
+			}
+			d.scheduler.ResourceOffers(d, ps)
+		case scheduler.Event_RESCIND:
+			id := e.GetRescind().GetOfferID()
+			d.scheduler.OfferRescinded(d, &id)
+		case scheduler.Event_UPDATE:
+			s := e.GetUpdate().GetStatus()
+			d.scheduler.StatusUpdate(d, &s)
+			if !d.explicitAcks && len(s.GetUUID()) > 0 {
+				if _, err := d.AcknowledgeStatusUpdate(&s); err != nil {
+					return &calls.AckError{Ack: calls.Acknowledge(s.GetAgentID().GetValue(), s.TaskID.Value, s.GetUUID()), Cause: err}
+				}
+			}
+		case scheduler.Event_MESSAGE:
+			m := e.GetMessage()
+			d.scheduler.FrameworkMessage(d, &m.ExecutorID, &m.AgentID, string(m.GetData()))
+		case scheduler.Event_FAILURE:
+			f := e.GetFailure()
+			if f.ExecutorID != nil {
+				d.scheduler.ExecutorLost(d, f.ExecutorID, f.AgentID, int(f.GetStatus()))
+			} else {
+				d.scheduler.SlaveLost(d, f.AgentID)
+			}
+		case scheduler.Event_ERROR:
+			msg := e.GetError().GetMessage()
+			d.scheduler.Error(d, msg)
+			d.abort(errors.New(msg))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// fakeMaster serves scripted subscriptions, and records the other calls of the framework. Subscriptions
+// end once their events are exhausted, but for the last one, which is held until the driver's done.
+type fakeMaster struct {
+	m             sync.Mutex
+	subscriptions [][]scheduler.Event
+	subscribes    []scheduler.Call
+	calls         []scheduler.Call
+}
+
+func (f *fakeMaster) Call(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if c.GetType() != scheduler.Call_SUBSCRIBE {
+		f.calls = append(f.calls, *c)
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(encoding.Unmarshaler) error { return nil })}, nil
+	}
+	f.subscribes = append(f.subscribes, *proto.Clone(c).(*scheduler.Call))
+	events := f.subscriptions[0]
+	hold := len(f.subscriptions) == 1
+	if !hold {
+		f.subscriptions = f.subscriptions[1:]
+	} else {
+		f.subscriptions[0] = nil
+	}
+	return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+		if len(events) > 0 {
+			*(u.(*scheduler.Event)) = events[0]
+			events = events[1:]
+			return nil
+		}
+		if hold {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return io.EOF
+	})}, nil
+}
+
+func (f *fakeMaster) types() (result []string) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	for _, c := range f.calls {
+		result = append(result, c.GetType().String())
+	}
+	return
+}
+
+// fakeScheduler records its callbacks; it launches a task upon every offer, and stops (with failover)
+// once re-registered.
+type fakeScheduler struct {
+	m         sync.Mutex
+	callbacks []string
+}
+
+func (s *fakeScheduler) record(format string, args ...interface{}) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.callbacks = append(s.callbacks, fmt.Sprintf(format, args...))
+}
+
+func (s *fakeScheduler) String() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return strings.Join(s.callbacks, ",")
+}
+
+func (s *fakeScheduler) Registered(_ SchedulerDriver, id *mesos.FrameworkID, m *mesos.MasterInfo) {
+	s.record("registered %s %s", id.Value, m.GetHostname())
+}
+
+func (s *fakeScheduler) Reregistered(d SchedulerDriver, m *mesos.MasterInfo) {
+	s.record("reregistered %s", m.GetHostname())
+	d.Stop(true)
+}
+
+func (s *fakeScheduler) Disconnected(SchedulerDriver) { s.record("disconnected") }
+
+func (s *fakeScheduler) ResourceOffers(d SchedulerDriver, offers []*mesos.Offer) {
+	for _, o := range offers {
+		s.record("offer %s", o.ID.Value)
+		d.LaunchTasks([]*mesos.OfferID{&o.ID}, []*mesos.TaskInfo{{TaskID: mesos.TaskID{Value: "t-" + o.ID.Value}}}, nil)
+	}
+}
+
+func (s *fakeScheduler) OfferRescinded(_ SchedulerDriver, id *mesos.OfferID) {
+	s.record("rescinded %s", id.Value)
+}
+
+func (s *fakeScheduler) StatusUpdate(_ SchedulerDriver, st *mesos.TaskStatus) {
+	s.record("update %s %v", st.TaskID.Value, st.GetState())
+}
+
+func (s *fakeScheduler) FrameworkMessage(_ SchedulerDriver, e *mesos.ExecutorID, a *mesos.AgentID, msg string) {
+	s.record("message %s %s %s", e.Value, a.Value, msg)
+}
+
+func (s *fakeScheduler) SlaveLost(_ SchedulerDriver, a *mesos.AgentID) { s.record("lost %s", a.Value) }
+
+func (s *fakeScheduler) ExecutorLost(_ SchedulerDriver, e *mesos.ExecutorID, a *mesos.AgentID, status int) {
+	s.record("lost %s %s %d", e.Value, a.Value, status)
+}
+
+func (s *fakeScheduler) Error(_ SchedulerDriver, msg string) { s.record("error %s", msg) }
+
+func subscribed(id string) scheduler.Event {
+	return scheduler.Event{Type: scheduler.Event_SUBSCRIBED, Subscribed: &scheduler.Event_Subscribed{
+		FrameworkID: &mesos.FrameworkID{Value: id},
+		MasterInfo:  &mesos.MasterInfo{Hostname: proto.String("m")},
+	}}
+}
+
+func TestDriver(t *testing.T) {
+	var (
+		agent  = mesos.AgentID{Value: "a1"}
+		master = &fakeMaster{subscriptions: [][]scheduler.Event{
+			{
+				subscribed("f1"),
+				{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{Offers: []mesos.Offer{
+					{ID: mesos.OfferID{Value: "o1"}, AgentID: agent},
+				}}},
+				{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: mesos.TaskStatus{
+					TaskID: mesos.TaskID{Value: "t-o1"}, State: mesos.TASK_RUNNING.Enum(), AgentID: &agent, UUID: []byte("u1"),
+				}}},
+				{Type: scheduler.Event_RESCIND, Rescind: &scheduler.Event_Rescind{OfferID: mesos.OfferID{Value: "o2"}}},
+				{Type: scheduler.Event_MESSAGE, Message: &scheduler.Event_Message{
+					AgentID: agent, ExecutorID: mesos.ExecutorID{Value: "e1"}, Data: []byte("hi"),
+				}},
+				{Type: scheduler.Event_FAILURE, Failure: &scheduler.Event_Failure{
+					AgentID: &agent, ExecutorID: &mesos.ExecutorID{Value: "e1"}, Status: proto.Int32(3),
+				}},
+				{Type: scheduler.Event_FAILURE, Failure: &scheduler.Event_Failure{AgentID: &agent}},
+			},
+			{subscribed("f1")},
+		}}
+		s = &fakeScheduler{}
+		d = New(s, mesos.FrameworkInfo{Name: "legacy", FailoverTimeout: proto.Float64(60)}, master,
+			RegistrationBackoff(time.Millisecond, 10*time.Millisecond))
+	)
+	if st, err := d.Run(); st != StatusStopped || err != nil {
+		t.Fatalf("unexpected result of Run: %v, %v", st, err)
+	}
+	want := strings.Join([]string{
+		"registered f1 m",
+		"offer o1",
+		"update t-o1 TASK_RUNNING",
+		"rescinded o2",
+		"message e1 a1 hi",
+		"lost e1 a1 3",
+		"lost a1",
+		"disconnected",
+		"reregistered m",
+	}, ",")
+	if got := s.String(); got != want {
+		t.Fatalf("expected callbacks %q instead of %q", want, got)
+	}
+	if types := strings.Join(master.types(), ","); types != "ACCEPT,ACKNOWLEDGE" {
+		t.Fatalf("unexpected calls: %v", types)
+	}
+	for _, c := range master.calls {
+		if c.GetFrameworkID().GetValue() != "f1" {
+			t.Errorf("expected the framework ID to be specified: %v", c)
+		}
+	}
+	if accept := master.calls[0].GetAccept(); len(accept.OfferIDs) != 1 || accept.Operations[0].GetLaunch().TaskInfos[0].TaskID.Value != "t-o1" {
+		t.Errorf("unexpected accept: %v", accept)
+	}
+	if len(master.subscribes) != 2 || master.subscribes[0].FrameworkID != nil || master.subscribes[1].GetFrameworkID().GetValue() != "f1" {
+		t.Errorf("expected the framework to re-subscribe with its ID: %v", master.subscribes)
+	}
+	if _, err := d.KillTask(&mesos.TaskID{Value: "t-o1"}); err != ErrNotRunning {
+		t.Errorf("expected ErrNotRunning instead of %v", err)
+	}
+}
+
+func TestDriverError(t *testing.T) {
+	var (
+		master = &fakeMaster{subscriptions: [][]scheduler.Event{{
+			subscribed("f1"),
+			{Type: scheduler.Event_ERROR, Error: &scheduler.Event_Error{Message: "Framework has been removed"}},
+		}}}
+		s = &fakeScheduler{}
+		d = New(s, mesos.FrameworkInfo{Name: "legacy"}, calls.CallerFunc(master.Call), ExplicitAcknowledgements(true))
+	)
+	st, err := d.Run()
+	if st != StatusAborted || err == nil || err.Error() != "Framework has been removed" {
+		t.Fatalf("expected the driver to abort: %v, %v", st, err)
+	}
+	if got, want := s.String(), "registered f1 m,error Framework has been removed"; got != want {
+		t.Fatalf("expected callbacks %q instead of %q", want, got)
+	}
+	if st, err := d.Stop(false); st != StatusAborted || err != nil {
+		t.Fatalf("unexpected result of Stop: %v, %v", st, err)
+	}
+	if _, err := d.Start(); err == nil {
+		t.Fatalf("expected an aborted driver not to restart")
+	}
+}
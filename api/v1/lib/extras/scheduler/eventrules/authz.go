@@ -0,0 +1,105 @@
+package eventrules
+
+import (
+	"context"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+type (
+	// EventPredicate returns true if the given event is authorized for further processing.
+	EventPredicate func(context.Context, *scheduler.Event) bool
+
+	// OfferPredicate returns true if the given offer is authorized for further processing.
+	OfferPredicate func(context.Context, *mesos.Offer) bool
+)
+
+// Authorize returns a Rule that drops events for which any of the given predicates returns false. The
+// counter, if not nil, is incremented for each rejected event and is labeled with the event type.
+// Authorize never changes the error state of the chain: a rejected event is not an error condition.
+func Authorize(counter metrics.Counter, predicates ...EventPredicate) Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, chain Chain) (context.Context, *scheduler.Event, error) {
+		for _, p := range predicates {
+			if p != nil && !p(ctx, e) {
+				if counter != nil {
+					counter(defaultLabels[e.GetType()]...)
+				}
+				return ctx, e, err
+			}
+		}
+		return chain(ctx, e, err)
+	}
+}
+
+// AuthorizeOffers returns a Rule that removes, from OFFERS events, the offers for which any of the given
+// predicates returns false. The remaining offers are passed along the chain via a copy of the original
+// event; if no offers remain then the event is dropped. The counter, if not nil, is incremented once for
+// each rejected offer. The rejected func, if not nil, is invoked with the rejected offers so that they may
+// be declined; otherwise they are held until rescinded by Mesos. Events of other types are not modified.
+func AuthorizeOffers(counter metrics.Counter, rejected func(context.Context, []mesos.Offer), predicates ...OfferPredicate) Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, chain Chain) (context.Context, *scheduler.Event, error) {
+		if e.GetType() != scheduler.Event_OFFERS {
+			return chain(ctx, e, err)
+		}
+		var (
+			offers   = e.GetOffers().GetOffers()
+			accepted = make([]mesos.Offer, 0, len(offers))
+			denied   []mesos.Offer
+		)
+	offerLoop:
+		for i := range offers {
+			for _, p := range predicates {
+				if p != nil && !p(ctx, &offers[i]) {
+					denied = append(denied, offers[i])
+					continue offerLoop
+				}
+			}
+			accepted = append(accepted, offers[i])
+		}
+		if len(denied) == 0 {
+			return chain(ctx, e, err)
+		}
+		if counter != nil {
+			for range denied {
+				counter(defaultLabels[scheduler.Event_OFFERS]...)
+			}
+		}
+		if rejected != nil {
+			rejected(ctx, denied)
+		}
+		if len(accepted) == 0 {
+			return ctx, e, err
+		}
+		// copy the event, don't modify the original
+		offersEvent := *e.GetOffers()
+		offersEvent.Offers = accepted
+		e2 := *e
+		e2.Offers = &offersEvent
+		return chain(ctx, &e2, err)
+	}
+}
+
+// FromAgents returns an OfferPredicate that authorizes only offers from the given agents.
+func FromAgents(ids ...mesos.AgentID) OfferPredicate {
+	allowed := make(map[mesos.AgentID]struct{}, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	return func(_ context.Context, o *mesos.Offer) bool {
+		_, ok := allowed[o.AgentID]
+		return ok
+	}
+}
+
+// FromExecutors returns an EventPredicate that authorizes MESSAGE events only if they originate from an
+// executor for which the known func returns true. Events of other types are always authorized.
+func FromExecutors(known func(mesos.ExecutorID) bool) EventPredicate {
+	return func(_ context.Context, e *scheduler.Event) bool {
+		if e.GetType() != scheduler.Event_MESSAGE {
+			return true
+		}
+		return known(e.GetMessage().ExecutorID)
+	}
+}
@@ -0,0 +1,87 @@
+package eventrules
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func TestAuthorize(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		rejected []string
+		counter  = func(labels ...string) { rejected = append(rejected, labels...) }
+		known    = func(id mesos.ExecutorID) bool { return id.Value == "a" }
+		r        = Authorize(counter, FromExecutors(known))
+		evaluate = func(e *scheduler.Event) (called bool) {
+			r.Eval(ctx, e, nil, func(ctx context.Context, e *scheduler.Event, err error) (context.Context, *scheduler.Event, error) {
+				called = true
+				return ctx, e, err
+			})
+			return
+		}
+		message = func(id string) *scheduler.Event {
+			return &scheduler.Event{
+				Type:    scheduler.Event_MESSAGE,
+				Message: &scheduler.Event_Message{ExecutorID: mesos.ExecutorID{Value: id}},
+			}
+		}
+	)
+	if !evaluate(message("a")) {
+		t.Error("expected message from known executor to be authorized")
+	}
+	if evaluate(message("b")) {
+		t.Error("expected message from unknown executor to be dropped")
+	}
+	if !evaluate(&scheduler.Event{Type: scheduler.Event_HEARTBEAT}) {
+		t.Error("expected heartbeat to be authorized")
+	}
+	if !reflect.DeepEqual(rejected, []string{"message"}) {
+		t.Errorf("unexpected rejection labels: %v", rejected)
+	}
+}
+
+func TestAuthorizeOffers(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		count    int
+		counter  = func(_ ...string) { count++ }
+		declined []mesos.Offer
+		decline  = func(_ context.Context, offers []mesos.Offer) { declined = append(declined, offers...) }
+		r        = AuthorizeOffers(counter, decline, FromAgents(mesos.AgentID{Value: "a"}))
+		offer    = func(agent string) mesos.Offer {
+			return mesos.Offer{ID: mesos.OfferID{Value: agent}, AgentID: mesos.AgentID{Value: agent}}
+		}
+		original = &scheduler.Event{
+			Type:   scheduler.Event_OFFERS,
+			Offers: &scheduler.Event_Offers{Offers: []mesos.Offer{offer("a"), offer("b")}},
+		}
+		got *scheduler.Event
+		ch  = func(ctx context.Context, e *scheduler.Event, err error) (context.Context, *scheduler.Event, error) {
+			got = e
+			return ctx, e, err
+		}
+	)
+	r.Eval(ctx, original, nil, ch)
+	if got == nil || len(got.GetOffers().GetOffers()) != 1 || got.GetOffers().GetOffers()[0].AgentID.Value != "a" {
+		t.Fatalf("unexpected event passed along the chain: %+v", got)
+	}
+	if len(original.GetOffers().GetOffers()) != 2 {
+		t.Error("original event was modified")
+	}
+	if count != 1 || len(declined) != 1 || declined[0].AgentID.Value != "b" {
+		t.Errorf("unexpected rejections: count=%d declined=%+v", count, declined)
+	}
+
+	got = nil
+	r.Eval(ctx, &scheduler.Event{
+		Type:   scheduler.Event_OFFERS,
+		Offers: &scheduler.Event_Offers{Offers: []mesos.Offer{offer("b")}},
+	}, nil, ch)
+	if got != nil {
+		t.Errorf("expected event without authorized offers to be dropped")
+	}
+}
@@ -0,0 +1,21 @@
+package eventrules
+
+import (
+	"context"
+
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// HandleError implements controller.ErrorHandler for Rule: the terminal error of a subscription, e.g. a
+// *controller.DisconnectedError, is evaluated as the error state of an empty event (of UNKNOWN type) so
+// that rules which react to errors, see OnFailure, observe the end of the event stream.
+func (r Rule) HandleError(ctx context.Context, err error) {
+	if r != nil {
+		r(ctx, &scheduler.Event{}, err, ChainIdentity)
+	}
+}
+
+// HandleError implements controller.ErrorHandler for Rules
+func (rs Rules) HandleError(ctx context.Context, err error) {
+	Rule(rs.Eval).HandleError(ctx, err)
+}
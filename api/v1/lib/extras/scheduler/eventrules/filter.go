@@ -0,0 +1,113 @@
+package eventrules
+
+import (
+	"context"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// Filter returns a Rule that drops events for which the predicate returns false: the remainder of the
+// chain isn't executed for them. The onDrop func, if not nil, is invoked with each dropped event; see
+// CountDrops. Like Authorize, Filter never changes the error state of the chain.
+func Filter(pred EventPredicate, onDrop func(context.Context, *scheduler.Event)) Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, chain Chain) (context.Context, *scheduler.Event, error) {
+		if pred != nil && !pred(ctx, e) {
+			if onDrop != nil {
+				onDrop(ctx, e)
+			}
+			return ctx, e, err
+		}
+		return chain(ctx, e, err)
+	}
+}
+
+// CountDrops returns a func, for use with Filter, that increments the counter for each dropped event; the
+// counter is labeled with the event type.
+func CountDrops(counter metrics.Counter) func(context.Context, *scheduler.Event) {
+	return func(_ context.Context, e *scheduler.Event) {
+		counter(defaultLabels[e.GetType()]...)
+	}
+}
+
+// TaskStates returns an EventPredicate that's true for UPDATE events that report one of the given task
+// states. Events of other types are always true.
+func TaskStates(states ...mesos.TaskState) EventPredicate {
+	allowed := make(map[mesos.TaskState]struct{}, len(states))
+	for _, s := range states {
+		allowed[s] = struct{}{}
+	}
+	return func(_ context.Context, e *scheduler.Event) bool {
+		if e.GetType() != scheduler.Event_UPDATE || e.Update == nil {
+			return true
+		}
+		_, ok := allowed[e.Update.Status.GetState()]
+		return ok
+	}
+}
+
+// ForAgents returns an EventPredicate that's true for UPDATE, MESSAGE, and FAILURE events that concern
+// one of the given agents. Other events, including those that don't identify an agent, are always true;
+// OFFERS events aren't examined: see FromAgents and AnyOffer.
+func ForAgents(ids ...mesos.AgentID) EventPredicate {
+	allowed := make(map[mesos.AgentID]struct{}, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	return func(_ context.Context, e *scheduler.Event) bool {
+		var id *mesos.AgentID
+		switch e.GetType() {
+		case scheduler.Event_UPDATE:
+			if u := e.GetUpdate(); u != nil {
+				id = u.Status.AgentID
+			}
+		case scheduler.Event_MESSAGE:
+			if m := e.GetMessage(); m != nil {
+				id = &m.AgentID
+			}
+		case scheduler.Event_FAILURE:
+			id = e.GetFailure().GetAgentID()
+		}
+		if id == nil {
+			return true
+		}
+		_, ok := allowed[*id]
+		return ok
+	}
+}
+
+// ForRoles returns an OfferPredicate that's true for offers that are allocated to one of the given roles.
+// Offers without allocation info (i.e. to frameworks that aren't MULTI_ROLE capable) are always true.
+func ForRoles(roles ...string) OfferPredicate {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, r := range roles {
+		allowed[r] = struct{}{}
+	}
+	return func(_ context.Context, o *mesos.Offer) bool {
+		ai := o.GetAllocationInfo()
+		if ai == nil || ai.Role == nil {
+			return true
+		}
+		_, ok := allowed[*ai.Role]
+		return ok
+	}
+}
+
+// AnyOffer returns an EventPredicate that's true for OFFERS events with at least one offer for which the
+// given predicate is true. Events of other types are always true. See AuthorizeOffers for a Rule that
+// removes individual offers from events.
+func AnyOffer(p OfferPredicate) EventPredicate {
+	return func(ctx context.Context, e *scheduler.Event) bool {
+		if e.GetType() != scheduler.Event_OFFERS {
+			return true
+		}
+		offers := e.GetOffers().GetOffers()
+		for i := range offers {
+			if p(ctx, &offers[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,68 @@
+package eventrules
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func TestFilter(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		dropped []string
+		counter = func(labels ...string) { dropped = append(dropped, labels...) }
+		agentA  = mesos.AgentID{Value: "a"}
+		update  = func(state mesos.TaskState, agent mesos.AgentID) *scheduler.Event {
+			return &scheduler.Event{
+				Type:   scheduler.Event_UPDATE,
+				Update: &scheduler.Event_Update{Status: mesos.TaskStatus{State: state.Enum(), AgentID: &agent}},
+			}
+		}
+		offers = func(roles ...string) *scheduler.Event {
+			e := &scheduler.Event{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{}}
+			for i := range roles {
+				e.Offers.Offers = append(e.Offers.Offers, mesos.Offer{
+					AllocationInfo: &mesos.Resource_AllocationInfo{Role: &roles[i]},
+				})
+			}
+			return e
+		}
+		called  bool
+		handler = Rule(func(ctx context.Context, e *scheduler.Event, err error, ch Chain) (context.Context, *scheduler.Event, error) {
+			called = true
+			return ch(ctx, e, err)
+		})
+		rule = New(
+			Filter(TaskStates(mesos.TASK_RUNNING, mesos.TASK_FAILED), CountDrops(counter)),
+			Filter(ForAgents(agentA), CountDrops(counter)),
+			Filter(AnyOffer(ForRoles("web")), CountDrops(counter)),
+			handler,
+		)
+		evaluate = func(e *scheduler.Event) bool {
+			called = false
+			rule.Eval(ctx, e, nil, ChainIdentity)
+			return called
+		}
+	)
+	for i, tc := range []struct {
+		e    *scheduler.Event
+		want bool
+	}{
+		{update(mesos.TASK_RUNNING, agentA), true},
+		{update(mesos.TASK_STAGING, agentA), false},
+		{update(mesos.TASK_FAILED, mesos.AgentID{Value: "b"}), false},
+		{offers("batch", "web"), true},
+		{offers("batch"), false},
+		{&scheduler.Event{Type: scheduler.Event_HEARTBEAT}, true},
+	} {
+		if called := evaluate(tc.e); called != tc.want {
+			t.Errorf("test case %d: expected %v instead of %v", i, tc.want, called)
+		}
+	}
+	if want := []string{"update", "update", "offers"}; !reflect.DeepEqual(dropped, want) {
+		t.Errorf("expected drops %v instead of %v", want, dropped)
+	}
+}
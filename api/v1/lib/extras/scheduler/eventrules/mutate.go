@@ -0,0 +1,57 @@
+package eventrules
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// Mutator modifies an event in place. Mutators are always applied to a private copy of an event (see
+// Mutate) and so need not copy anything themselves.
+type Mutator func(*scheduler.Event)
+
+// Mutate returns a Rule that applies the given mutators to incoming events. The ordering contract is:
+//   - the event given to the rule is never modified: mutators are applied, in order, to a deep copy of
+//     it (nil mutators are skipped) and the copy is passed to the remainder of the chain;
+//   - rules that precede the returned Rule in a chain observe the original event, rules that follow it
+//     (including handlers) observe the mutated event;
+//   - nil events are passed along as-is.
+func Mutate(ms ...Mutator) Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch Chain) (context.Context, *scheduler.Event, error) {
+		if e != nil && len(ms) > 0 {
+			e = proto.Clone(e).(*scheduler.Event)
+			for _, m := range ms {
+				if m != nil {
+					m(e)
+				}
+			}
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// EachOffer returns a Mutator that applies f to every offer of OFFERS events; events of other types are
+// not modified.
+func EachOffer(f func(*mesos.Offer)) Mutator {
+	return func(e *scheduler.Event) {
+		if e.GetType() != scheduler.Event_OFFERS || e.Offers == nil {
+			return
+		}
+		for i := range e.Offers.Offers {
+			f(&e.Offers.Offers[i])
+		}
+	}
+}
+
+// EachUpdate returns a Mutator that applies f to the status of UPDATE events; events of other types are
+// not modified.
+func EachUpdate(f func(*mesos.TaskStatus)) Mutator {
+	return func(e *scheduler.Event) {
+		if e.GetType() != scheduler.Event_UPDATE || e.Update == nil {
+			return
+		}
+		f(&e.Update.Status)
+	}
+}
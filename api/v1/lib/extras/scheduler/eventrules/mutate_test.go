@@ -0,0 +1,39 @@
+package eventrules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func TestMutate(t *testing.T) {
+	var (
+		e = &scheduler.Event{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{Offers: []mesos.Offer{
+			{Hostname: "a"}, {Hostname: "b"},
+		}}}
+		rule = Mutate(
+			EachOffer(func(o *mesos.Offer) { o.Hostname += ".example.com" }),
+			EachUpdate(func(s *mesos.TaskStatus) { t.Fatal("unexpected update mutation") }),
+		)
+	)
+	_, got, err := rule.Eval(context.Background(), e, nil, ChainIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := e.Offers.Offers[0].Hostname; h != "a" {
+		t.Fatalf("expected original event to be unmodified, hostname = %q", h)
+	}
+	for i, o := range got.GetOffers().GetOffers() {
+		if o.Hostname != e.Offers.Offers[i].Hostname+".example.com" {
+			t.Errorf("offer %d: unexpected hostname %q", i, o.Hostname)
+		}
+	}
+
+	u := &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: mesos.TaskStatus{State: mesos.TASK_RUNNING.Enum()}}}
+	_, got, _ = Mutate(EachUpdate(func(s *mesos.TaskStatus) { s.Message = &s.TaskID.Value })).Eval(context.Background(), u, nil, ChainIdentity)
+	if got.GetUpdate().GetStatus().Message == nil || u.Update.Status.Message != nil {
+		t.Fatalf("expected only the copy to be mutated")
+	}
+}
@@ -0,0 +1,229 @@
+// Package health exposes the liveness and readiness of a scheduler, as derived from the state of its
+// subscription, the age of the last heartbeat, and the backlog of unacknowledged status updates, via
+// HTTP endpoints that are suitable for standard (e.g. Kubernetes, systemd) health probes.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+const (
+	// DefaultHeartbeatAge is the maximum age of the last heartbeat if the master hasn't reported a
+	// heartbeat interval.
+	DefaultHeartbeatAge = 45 * time.Second
+	// HeartbeatIntervals is the number of (master reported) heartbeat intervals that may elapse before
+	// the last heartbeat is considered stale.
+	HeartbeatIntervals = 3
+	// DefaultAckBacklog is the default maximum number of unacknowledged status updates.
+	DefaultAckBacklog = 1000
+)
+
+type (
+	// Option is a functional configuration option for a Checker; it returns an Option that acts as an
+	// "undo" if applied to the same Checker.
+	Option func(*Checker) Option
+
+	// Checker tracks the health of a scheduler. Its rules must be added to the event and call processing
+	// chains of the scheduler. Checker funcs are safe to invoke concurrently.
+	Checker struct {
+		clock        mesostime.Clock
+		heartbeatAge time.Duration
+		ackBacklog   int
+
+		m             sync.Mutex
+		subscribed    bool
+		interval      time.Duration // heartbeat interval reported by the master
+		lastHeartbeat time.Time     // time of the last event (of any type)
+		lastErr       error         // reason that the last subscription terminated
+		unacked       map[string]struct{}
+	}
+)
+
+// HeartbeatAge configures the maximum age of the last heartbeat (or any other event) beyond which the
+// subscription is considered stale. By default the age is HeartbeatIntervals times the heartbeat interval
+// reported by the master, or else DefaultHeartbeatAge.
+func HeartbeatAge(d time.Duration) Option {
+	return func(c *Checker) Option {
+		old := c.heartbeatAge
+		c.heartbeatAge = d
+		return HeartbeatAge(old)
+	}
+}
+
+// AckBacklog configures the maximum number of unacknowledged status updates of a ready scheduler; a
+// negative value disables the check. Defaults to DefaultAckBacklog.
+func AckBacklog(n int) Option {
+	return func(c *Checker) Option {
+		old := c.ackBacklog
+		c.ackBacklog = n
+		return AckBacklog(old)
+	}
+}
+
+// Clock configures the clock that times the heartbeats observed by a Checker; defaults to
+// mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(c *Checker) Option {
+		old := c.clock
+		c.clock = clock
+		return Clock(old)
+	}
+}
+
+// NewChecker returns a Checker, configured by the given options, of a scheduler that's yet to subscribe.
+func NewChecker(opts ...Option) *Checker {
+	c := &Checker{
+		clock:      mesostime.SystemClock,
+		ackBacklog: DefaultAckBacklog,
+		unacked:    make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c
+}
+
+// EventRule returns a rule that tracks subscription, heartbeats, and status updates that require
+// acknowledgement.
+func (c *Checker) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		c.m.Lock()
+		c.lastHeartbeat = c.clock.Now()
+		switch e.GetType() {
+		case scheduler.Event_SUBSCRIBED:
+			c.subscribed = true
+			c.lastErr = nil
+			c.interval = 0
+			if s := e.GetSubscribed().GetHeartbeatIntervalSeconds(); s > 0 {
+				c.interval = time.Duration(s * float64(time.Second))
+			}
+		case scheduler.Event_UPDATE:
+			if uuid := e.GetUpdate().GetStatus().UUID; len(uuid) > 0 {
+				c.unacked[string(uuid)] = struct{}{}
+			}
+		}
+		c.m.Unlock()
+		return ch(ctx, e, err)
+	}
+}
+
+// CallRule returns a rule that tracks the status updates that are (successfully) acknowledged; it
+// observes the outcome of the rest of the chain.
+func (c *Checker) CallRule() callrules.Rule {
+	return func(ctx context.Context, call *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		if call.GetType() != scheduler.Call_ACKNOWLEDGE {
+			return ch(ctx, call, r, err)
+		}
+		uuid := string(call.GetAcknowledge().GetUUID())
+		ctx, call, r, err = ch(ctx, call, r, err)
+		if err == nil {
+			c.m.Lock()
+			delete(c.unacked, uuid)
+			c.m.Unlock()
+		}
+		return ctx, call, r, err
+	}
+}
+
+// Disconnected records the termination of the subscription; it's suitable for use with
+// controller.WithSubscriptionTerminated. Unacknowledged status updates are forgotten because the master
+// resends them to the next subscription.
+func (c *Checker) Disconnected(err error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.subscribed = false
+	c.lastErr = err
+	c.unacked = make(map[string]struct{})
+}
+
+// AckBacklog returns the number of status updates that are yet to be acknowledged.
+func (c *Checker) AckBacklog() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return len(c.unacked)
+}
+
+func (c *Checker) maxHeartbeatAge() time.Duration {
+	switch {
+	case c.heartbeatAge > 0:
+		return c.heartbeatAge
+	case c.interval > 0:
+		return HeartbeatIntervals * c.interval
+	default:
+		return DefaultHeartbeatAge
+	}
+}
+
+// stale returns an error if the scheduler is subscribed but hasn't heard from the master recently.
+// Assumes that c.m is locked.
+func (c *Checker) stale() error {
+	if !c.subscribed {
+		return nil
+	}
+	if age, max := c.clock.Now().Sub(c.lastHeartbeat), c.maxHeartbeatAge(); age > max {
+		return fmt.Errorf("last heartbeat was %v ago, exceeds %v", age, max)
+	}
+	return nil
+}
+
+// Live returns an error if the scheduler is subscribed but its subscription is stale: that suggests a
+// wedged connection, or event processing, that a restart might resolve. A scheduler that isn't subscribed is
+// assumed to be (re)connecting, and so is live.
+func (c *Checker) Live() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.stale()
+}
+
+// Ready returns an error unless the scheduler is subscribed, its subscription isn't stale, and the backlog
+// of unacknowledged status updates doesn't exceed the configured maximum.
+func (c *Checker) Ready() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if !c.subscribed {
+		if c.lastErr != nil {
+			return fmt.Errorf("not subscribed: %v", c.lastErr)
+		}
+		return fmt.Errorf("not subscribed")
+	}
+	if err := c.stale(); err != nil {
+		return err
+	}
+	if n := len(c.unacked); c.ackBacklog >= 0 && n > c.ackBacklog {
+		return fmt.Errorf("%d unacknowledged status updates, exceeds %d", n, c.ackBacklog)
+	}
+	return nil
+}
+
+// Handler returns an http.Handler that serves the "/healthz" (liveness) and "/readyz" (readiness)
+// endpoints. Healthy endpoints respond with 200 OK, otherwise 503 Service Unavailable.
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", probe(c.Live))
+	mux.Handle("/readyz", probe(c.Ready))
+	return mux
+}
+
+func probe(check func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := check(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
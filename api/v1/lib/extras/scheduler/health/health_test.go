@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestChecker(t *testing.T) {
+	var (
+		clock    = mesostime.NewFakeClock(time.Unix(1000, 0))
+		c        = NewChecker(Clock(clock), AckBacklog(1))
+		events   = c.EventRule()
+		acks     = c.CallRule()
+		ctx      = context.Background()
+		interval = 10.0
+		handler  = c.Handler()
+	)
+	status := func(path string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+	update := func(uuid string) *scheduler.Event {
+		return &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{
+			Status: mesos.TaskStatus{TaskID: mesos.TaskID{Value: "t"}, UUID: []byte(uuid)},
+		}}
+	}
+
+	if status("/healthz") != http.StatusOK || status("/readyz") != http.StatusServiceUnavailable {
+		t.Fatal("expected a live, but not ready, scheduler before subscription")
+	}
+	events.Eval(ctx, &scheduler.Event{
+		Type:       scheduler.Event_SUBSCRIBED,
+		Subscribed: &scheduler.Event_Subscribed{HeartbeatIntervalSeconds: &interval},
+	}, nil, eventrules.ChainIdentity)
+	if err := c.Ready(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// heartbeats go stale after 3 intervals
+	clock.Advance(31 * time.Second)
+	if c.Live() == nil || c.Ready() == nil || status("/healthz") != http.StatusServiceUnavailable {
+		t.Fatal("expected a stale subscription")
+	}
+	events.Eval(ctx, &scheduler.Event{Type: scheduler.Event_HEARTBEAT}, nil, eventrules.ChainIdentity)
+	if c.Live() != nil {
+		t.Fatal("expected a live scheduler following a heartbeat")
+	}
+
+	// ack backlog
+	events.Eval(ctx, update("u1"), nil, eventrules.ChainIdentity)
+	events.Eval(ctx, update("u2"), nil, eventrules.ChainIdentity)
+	if c.AckBacklog() != 2 || c.Ready() == nil {
+		t.Fatalf("expected an ack backlog of 2 instead of %d", c.AckBacklog())
+	}
+	ack := calls.Acknowledge("a", "t", []byte("u1"))
+	callrules.New(acks, callrules.Fail(errors.New("injected"))).Eval(ctx, ack, nil, nil, callrules.ChainIdentity)
+	if c.AckBacklog() != 2 {
+		t.Fatal("a failed acknowledgement shouldn't reduce the backlog")
+	}
+	acks.Eval(ctx, ack, nil, nil, callrules.ChainIdentity)
+	if c.AckBacklog() != 1 || c.Ready() != nil {
+		t.Fatalf("expected an ack backlog of 1 instead of %d", c.AckBacklog())
+	}
+
+	c.Disconnected(errors.New("eof"))
+	if c.Live() != nil || c.Ready() == nil || c.AckBacklog() != 0 {
+		t.Fatal("expected a live, but not ready, scheduler following disconnection")
+	}
+}
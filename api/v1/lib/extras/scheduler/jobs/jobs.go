@@ -0,0 +1,459 @@
+// Package jobs runs batch jobs, as per schedules: a Scheduler launches a run of a job (an instance of the
+// job's task template) upon the first offer that holds it once the run is due, kills runs that exceed the
+// job's timeout, retries runs that fail as per the job's retry policy, and reports the final status of
+// every run. Runs of a job don't overlap: an occurrence of a job's schedule that's due while a previous
+// run is still pending, or active, is skipped.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// DefaultRefuseSeconds is the period for which the resources of unused offers aren't re-offered.
+const DefaultRefuseSeconds = 5 * time.Second
+
+var (
+	// ErrDuplicateJob is returned when adding a job whose name is already that of a job.
+	ErrDuplicateJob = errors.New("jobs: duplicate job name")
+
+	// ErrUnknownJob is returned for a job name that isn't that of a job.
+	ErrUnknownJob = errors.New("jobs: unknown job")
+)
+
+type (
+	// RetryPolicy decides whether, and when, failed runs of a job are retried. Runs that fail with
+	// TASK_ERROR (i.e. an invalid task) aren't retried.
+	RetryPolicy struct {
+		// MaxRetries is the number of times a failed run is retried.
+		MaxRetries int
+		// Backoff is the delay before the first retry; it doubles for every subsequent retry.
+		Backoff time.Duration
+		// MaxBackoff, if positive, bounds the delay before a retry.
+		MaxBackoff time.Duration
+	}
+
+	// Job is a batch job. The task IDs of its runs default to the name of the job, suffixed by the time
+	// (in seconds since the Unix epoch) at which the run was scheduled and the attempt; these are
+	// available to the placeholders of the template as ${JOB}, ${SCHEDULED}, and ${ATTEMPT}.
+	Job struct {
+		Name     string
+		Schedule Schedule
+		Template *tasks.Template
+		// Timeout, if positive, is the duration for which a run may be active before it's killed; a run
+		// that's killed is considered to have failed.
+		Timeout time.Duration
+		Retry   RetryPolicy
+	}
+
+	// Result reports the final status of a run of a job.
+	Result struct {
+		Job       string
+		Scheduled time.Time
+		Attempts  int
+		// Status is the terminal status of the last attempt, if it was launched.
+		Status   mesos.TaskStatus
+		TimedOut bool
+		// Err reports a run that couldn't be launched, e.g. because its template couldn't be instantiated.
+		Err error
+	}
+
+	// Status reports the state of a job.
+	Status struct {
+		Next    time.Time // the next time at which the job is scheduled, if any
+		Pending bool      // a run is due, and awaits an offer
+		Active  bool      // a run is launched
+		Skipped int       // the occurrences of the schedule that were skipped, since a run was in progress
+		Last    *Result   // the result of the most recent run, if any
+	}
+
+	// Option is a functional option for a Scheduler; it returns an "undo" option when applied.
+	Option func(*Scheduler) Option
+
+	// Scheduler runs jobs. A Scheduler is driven by the events of a subscription, see HandleEvent;
+	// Scheduler funcs are safe to invoke concurrently.
+	Scheduler struct {
+		caller     calls.Caller
+		clock      mesostime.Clock
+		registry   *tasks.Registry
+		refuse     time.Duration
+		resultFunc func(Result)
+
+		m    sync.Mutex
+		jobs map[string]*job
+	}
+
+	job struct {
+		Job
+		next    time.Time
+		skipped int
+		pending *run // a run that's due
+		active  *run // a run that's launched
+		last    *Result
+	}
+
+	run struct {
+		scheduled time.Time
+		attempt   int
+		notBefore time.Time // retries are delayed as per the retry policy
+		taskID    mesos.TaskID
+		agentID   mesos.AgentID
+		deadline  time.Time // zero, unless the job has a timeout
+		timedOut  bool
+	}
+)
+
+// WithClock configures the clock that times the runs of jobs; defaults to mesostime.SystemClock.
+func WithClock(c mesostime.Clock) Option {
+	return func(s *Scheduler) Option {
+		old := s.clock
+		s.clock = c
+		return WithClock(old)
+	}
+}
+
+// WithRegistry configures the registry that tracks the launched tasks, e.g. one that's shared with other
+// components of the framework; by default the Scheduler has a registry of its own.
+func WithRegistry(r *tasks.Registry) Option {
+	return func(s *Scheduler) Option {
+		old := s.registry
+		s.registry = r
+		return WithRegistry(old)
+	}
+}
+
+// RefuseSeconds configures the period for which the resources of unused offers (and of the unused
+// resources of accepted offers) aren't re-offered; defaults to DefaultRefuseSeconds.
+func RefuseSeconds(d time.Duration) Option {
+	return func(s *Scheduler) Option {
+		old := s.refuse
+		s.refuse = d
+		return RefuseSeconds(old)
+	}
+}
+
+// ResultFunc configures the func to which the result of every run is reported; it's invoked while the
+// event that concluded the run is handled.
+func ResultFunc(f func(Result)) Option {
+	return func(s *Scheduler) Option {
+		old := s.resultFunc
+		s.resultFunc = f
+		return ResultFunc(old)
+	}
+}
+
+// New returns a Scheduler that launches, and kills, the tasks of jobs via the given caller.
+func New(caller calls.Caller, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		caller: caller,
+		clock:  mesostime.SystemClock,
+		refuse: DefaultRefuseSeconds,
+		jobs:   make(map[string]*job),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	if s.registry == nil {
+		s.registry = tasks.NewRegistry(nil)
+	}
+	return s
+}
+
+// Registry returns the registry of the tasks that the Scheduler has launched.
+func (s *Scheduler) Registry() *tasks.Registry { return s.registry }
+
+// Succeeded returns true if the run finished.
+func (r *Result) Succeeded() bool { return r.Err == nil && r.Status.GetState() == mesos.TASK_FINISHED }
+
+// Add adds a job, which is scheduled as of the current time: an occurrence of its schedule at the current
+// time is due.
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" || j.Schedule == nil || j.Template == nil {
+		return errors.New("jobs: a job requires a name, a schedule, and a template")
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if _, ok := s.jobs[j.Name]; ok {
+		return ErrDuplicateJob
+	}
+	s.jobs[j.Name] = &job{Job: j, next: j.Schedule.Next(s.clock.Now().Add(-time.Nanosecond))}
+	return nil
+}
+
+// Remove removes a job; its active run, if any, is killed.
+func (s *Scheduler) Remove(ctx context.Context, name string) error {
+	s.m.Lock()
+	j, ok := s.jobs[name]
+	delete(s.jobs, name)
+	var r *run
+	if ok {
+		r = j.active
+	}
+	s.m.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+	if r != nil {
+		return calls.CallNoData(ctx, s.caller, calls.Kill(r.taskID.Value, r.agentID.Value))
+	}
+	return nil
+}
+
+// Trigger schedules a run of a job as of the current time, unless a run is already in progress.
+func (s *Scheduler) Trigger(name string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	if j.pending == nil && j.active == nil {
+		now := s.clock.Now()
+		j.pending = &run{scheduled: now, attempt: 1, notBefore: now}
+	}
+	return nil
+}
+
+// Status returns the state of a job.
+func (s *Scheduler) Status(name string) (Status, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return Status{}, false
+	}
+	st := Status{Next: j.next, Pending: j.pending != nil, Active: j.active != nil, Skipped: j.skipped}
+	if j.last != nil {
+		last := *j.last
+		st.Last = &last
+	}
+	return st, true
+}
+
+// HandleEvent implements events.Handler for Scheduler: the launched tasks are reconciled upon SUBSCRIBED,
+// due runs are launched upon OFFERS, and runs conclude as per UPDATE events; every event ticks the
+// Scheduler (see Tick). Status updates aren't acknowledged; see controller.AckStatusUpdates.
+func (s *Scheduler) HandleEvent(ctx context.Context, e *scheduler.Event) (err error) {
+	if err = s.Tick(ctx); err != nil {
+		return err
+	}
+	switch e.GetType() {
+	case scheduler.Event_SUBSCRIBED:
+		err = calls.CallNoData(ctx, s.caller, calls.Reconcile(s.registry.ReconcileTasks()))
+	case scheduler.Event_OFFERS:
+		err = s.handleOffers(ctx, e.GetOffers().GetOffers())
+	case scheduler.Event_UPDATE:
+		s.handleUpdate(e.GetUpdate().GetStatus())
+	}
+	return err
+}
+
+// Tick schedules the runs of jobs that are due, and kills the active runs whose timeouts have expired.
+// Tick is invoked upon every event, and so the precision of timeouts is that of the heartbeats of the
+// master unless frameworks also Tick periodically.
+func (s *Scheduler) Tick(ctx context.Context) (err error) {
+	var expired []*run
+	s.m.Lock()
+	now := s.clock.Now()
+	for _, j := range s.jobs {
+		for !j.next.IsZero() && !j.next.After(now) {
+			if j.pending == nil && j.active == nil {
+				j.pending = &run{scheduled: j.next, attempt: 1, notBefore: j.next}
+			} else {
+				j.skipped++
+			}
+			j.next = j.Schedule.Next(j.next)
+		}
+		if r := j.active; r != nil && !r.timedOut && !r.deadline.IsZero() && !r.deadline.After(now) {
+			r.timedOut = true
+			expired = append(expired, r)
+		}
+	}
+	s.m.Unlock()
+	for _, r := range expired {
+		if cerr := calls.CallNoData(ctx, s.caller, calls.Kill(r.taskID.Value, r.agentID.Value)); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// due returns the jobs whose pending runs are due, ordered by the times at which they're due (and then
+// by name); the caller must hold the lock.
+func (s *Scheduler) due(now time.Time) []*job {
+	var due []*job
+	for _, j := range s.jobs {
+		if j.pending != nil && !j.pending.notBefore.After(now) {
+			due = append(due, j)
+		}
+	}
+	sort.Slice(due, func(i, k int) bool {
+		a, b := due[i].pending.notBefore, due[k].pending.notBefore
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return due[i].Name < due[k].Name
+	})
+	return due
+}
+
+// instantiate returns the task of the given job's pending run, upon the given offer; the caller must hold
+// the lock.
+func (j *job) instantiate(o *mesos.Offer, available mesos.Resources) (mesos.TaskInfo, mesos.Resources, error) {
+	var (
+		t         = *j.Template
+		r         = j.pending
+		scheduled = strconv.FormatInt(r.scheduled.Unix(), 10)
+		attempt   = strconv.Itoa(r.attempt)
+	)
+	t.Vars = make(map[string]string, len(j.Template.Vars)+3)
+	for k, v := range j.Template.Vars {
+		t.Vars[k] = v
+	}
+	t.Vars["JOB"] = j.Name
+	t.Vars["SCHEDULED"] = scheduled
+	t.Vars["ATTEMPT"] = attempt
+	if t.Prototype.TaskID.Value == "" {
+		t.Prototype.TaskID.Value = j.Name + "-" + scheduled + "-" + attempt
+	}
+	return t.Instantiate(o, 0, available)
+}
+
+func (s *Scheduler) handleOffers(ctx context.Context, os []mesos.Offer) (err error) {
+	type launch struct {
+		offerID mesos.OfferID
+		tasks   []mesos.TaskInfo
+	}
+	var (
+		launches []launch
+		unused   []mesos.OfferID
+		results  []Result
+	)
+	s.m.Lock()
+	var (
+		now = s.clock.Now()
+		due = s.due(now)
+	)
+	for i := range os {
+		var (
+			o         = &os[i]
+			remaining = mesos.Resources(o.Resources)
+			launched  []mesos.TaskInfo
+		)
+		for _, j := range due {
+			if j.pending == nil {
+				continue // launched upon a preceding offer
+			}
+			task, rs, ierr := j.instantiate(o, remaining)
+			if ierr == tasks.ErrInsufficientResources {
+				continue
+			}
+			if ierr != nil {
+				results = append(results, j.conclude(Result{Attempts: j.pending.attempt, Err: ierr}, j.pending))
+				j.pending = nil
+				continue
+			}
+			remaining = rs
+			launched = append(launched, task)
+			r := j.pending
+			r.taskID, r.agentID = task.TaskID, task.AgentID
+			if j.Timeout > 0 {
+				r.deadline = now.Add(j.Timeout)
+			}
+			j.active, j.pending = r, nil
+		}
+		if len(launched) == 0 {
+			unused = append(unused, o.ID)
+			continue
+		}
+		launches = append(launches, launch{offerID: o.ID, tasks: launched})
+	}
+	s.m.Unlock()
+
+	s.report(results)
+	for _, l := range launches {
+		s.registry.Launched(l.tasks...)
+		accept := calls.Accept(calls.OfferOperations{calls.OpLaunch(l.tasks...)}.WithOffers(l.offerID)).
+			With(calls.RefuseSeconds(s.refuse))
+		if cerr := calls.CallNoData(ctx, s.caller, accept); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if len(unused) > 0 {
+		decline := calls.Decline(unused...).With(calls.RefuseSeconds(s.refuse))
+		if cerr := calls.CallNoData(ctx, s.caller, decline); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *Scheduler) handleUpdate(status mesos.TaskStatus) {
+	s.registry.Update(status)
+	state := status.GetState()
+	if !tasks.IsTerminal(state) {
+		return
+	}
+	var results []Result
+	s.m.Lock()
+	for _, j := range s.jobs {
+		r := j.active
+		if r == nil || r.taskID != status.TaskID {
+			continue
+		}
+		j.active = nil
+		retry := state != mesos.TASK_FINISHED && state != mesos.TASK_ERROR && r.attempt <= j.Retry.MaxRetries
+		if retry {
+			j.pending = &run{scheduled: r.scheduled, attempt: r.attempt + 1, notBefore: s.clock.Now().Add(j.Retry.delay(r.attempt))}
+			break
+		}
+		results = append(results, j.conclude(Result{Attempts: r.attempt, Status: status, TimedOut: r.timedOut}, r))
+		break
+	}
+	s.m.Unlock()
+	s.report(results)
+}
+
+// conclude records the given result of the given run; the caller must hold the lock.
+func (j *job) conclude(result Result, r *run) Result {
+	result.Job = j.Name
+	result.Scheduled = r.scheduled
+	j.last = &result
+	return result
+}
+
+func (s *Scheduler) report(results []Result) {
+	if s.resultFunc == nil {
+		return
+	}
+	for _, r := range results {
+		s.resultFunc(r)
+	}
+}
+
+// delay returns the delay before the retry that follows the given (failed) attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,136 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/jobs"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/sim"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+// durations are the (simulated) durations of the tasks of jobs, by the name of the job
+type durations map[string]time.Duration
+
+func (d durations) of(t mesos.TaskInfo) time.Duration { return d[t.Name] }
+
+func cluster(d durations, opts ...sim.Option) *sim.Simulator {
+	var rs mesos.Resources
+	rs.Add(resources.NewCPUs(2).Resource, resources.NewMemory(2048).Resource)
+	opts = append(opts, sim.TaskDuration(d.of))
+	return sim.New([]sim.Profile{{Name: "agent", Count: 1, Resources: rs}}, opts...)
+}
+
+func template(name string, cpus float64) *tasks.Template {
+	return &tasks.Template{Prototype: mesos.TaskInfo{
+		Name:      name,
+		Resources: mesos.Resources{resources.NewCPUs(cpus).Resource},
+		Command:   &mesos.CommandInfo{Value: mesos.String("run --job=${JOB} --attempt=${ATTEMPT}")},
+	}}
+}
+
+func run(t *testing.T, s *sim.Simulator, d time.Duration, add func(*jobs.Scheduler)) (*jobs.Scheduler, []jobs.Result) {
+	var (
+		results []jobs.Result
+		js      = jobs.New(s, jobs.WithClock(s.Clock()), jobs.RefuseSeconds(0), jobs.ResultFunc(func(r jobs.Result) {
+			results = append(results, r)
+		}))
+	)
+	add(js)
+	if _, err := s.Run(context.Background(), js, d); err != nil {
+		t.Fatal(err)
+	}
+	return js, results
+}
+
+func TestSchedule(t *testing.T) {
+	s := cluster(durations{"backup": 3 * time.Second, "report": 5 * time.Second})
+	js, results := run(t, s, 25*time.Second, func(js *jobs.Scheduler) {
+		for _, j := range []jobs.Job{
+			{Name: "backup", Schedule: jobs.Every(s.Now(), 10*time.Second), Template: template("backup", 1)},
+			// runs every other second, longer than that
+			{Name: "report", Schedule: jobs.Every(s.Now(), 2*time.Second), Template: template("report", 1)},
+		} {
+			if err := js.Add(j); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := js.Add(jobs.Job{Name: "backup", Schedule: jobs.Once(s.Now()), Template: template("x", 1)}); err != jobs.ErrDuplicateJob {
+			t.Fatalf("expected ErrDuplicateJob instead of %v", err)
+		}
+	})
+	var backups []time.Time
+	for _, r := range results {
+		if !r.Succeeded() || r.Attempts != 1 {
+			t.Fatalf("unexpected result: %+v", r)
+		}
+		if r.Job == "backup" {
+			backups = append(backups, r.Scheduled)
+		}
+	}
+	if len(backups) != 3 || backups[0] != s.Now().Add(-25*time.Second) || backups[2].Sub(backups[0]) != 20*time.Second {
+		t.Fatalf("unexpected runs of backup: %v", backups)
+	}
+	st, ok := js.Status("report")
+	if !ok || st.Skipped == 0 || st.Last == nil || !st.Last.Succeeded() {
+		t.Fatalf("expected overlapping runs to be skipped: %+v", st)
+	}
+	if n := js.Registry().Len(); n > 2 {
+		t.Fatalf("unexpected tracked tasks: %d", n)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	s := cluster(durations{"slow": 10 * time.Second, "flaky": time.Second}, sim.FailureRate(1))
+	js, results := run(t, s, 30*time.Second, func(js *jobs.Scheduler) {
+		for _, j := range []jobs.Job{
+			{
+				Name:     "slow",
+				Schedule: jobs.Once(s.Now().Add(time.Second)),
+				Template: template("slow", 1),
+				Timeout:  2 * time.Second,
+				Retry:    jobs.RetryPolicy{MaxRetries: 1, Backoff: time.Second},
+			},
+			{
+				Name:     "flaky",
+				Schedule: jobs.Once(s.Now().Add(time.Second)),
+				Template: template("flaky", 1),
+				Retry:    jobs.RetryPolicy{MaxRetries: 2, Backoff: time.Second, MaxBackoff: 3 * time.Second},
+			},
+			{Name: "invalid", Schedule: jobs.Once(s.Now().Add(time.Second)), Template: template("${UNDEFINED}", 1)},
+			{Name: "oversized", Schedule: jobs.Once(s.Now().Add(time.Second)), Template: template("oversized", 4)},
+		} {
+			if err := js.Add(j); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	byJob := map[string]jobs.Result{}
+	for _, r := range results {
+		byJob[r.Job] = r
+	}
+	if r := byJob["slow"]; !r.TimedOut || r.Attempts != 2 || r.Status.GetState() != mesos.TASK_KILLED {
+		t.Fatalf("expected the slow job to time out: %+v", r)
+	}
+	if r := byJob["flaky"]; r.Succeeded() || r.Attempts != 3 || r.Status.GetState() != mesos.TASK_FAILED {
+		t.Fatalf("expected the flaky job to be retried: %+v", r)
+	}
+	if r := byJob["invalid"]; r.Err == nil || r.Attempts != 1 {
+		t.Fatalf("expected the invalid job to fail: %+v", r)
+	}
+	if _, ok := byJob["oversized"]; ok {
+		t.Fatalf("unexpected result of the oversized job")
+	}
+	if st, _ := js.Status("oversized"); !st.Pending || !st.Next.IsZero() {
+		t.Fatalf("expected the oversized job to await an offer: %+v", st)
+	}
+	if err := js.Remove(context.Background(), "oversized"); err != nil {
+		t.Fatal(err)
+	}
+	if err := js.Trigger("oversized"); err != jobs.ErrUnknownJob {
+		t.Fatalf("expected ErrUnknownJob instead of %v", err)
+	}
+}
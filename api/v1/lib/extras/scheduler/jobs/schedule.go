@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides the times at which a job runs.
+type Schedule interface {
+	// Next returns the first time, strictly after the given time, at which the job runs; or else the zero
+	// time, if the job never runs again.
+	Next(after time.Time) time.Time
+}
+
+// ScheduleFunc is the functional adaptation of Schedule.
+type ScheduleFunc func(time.Time) time.Time
+
+// Next implements Schedule for ScheduleFunc.
+func (f ScheduleFunc) Next(after time.Time) time.Time { return f(after) }
+
+// Once returns a Schedule of a job that runs at the given time; a job whose time has passed doesn't run,
+// see Scheduler.Trigger.
+func Once(at time.Time) Schedule {
+	return ScheduleFunc(func(after time.Time) time.Time {
+		if at.After(after) {
+			return at
+		}
+		return time.Time{}
+	})
+}
+
+// Every returns a Schedule of a job that runs at the given start time, and at every interval thereafter;
+// runs are aligned to the start time, so that they don't drift.
+func Every(start time.Time, interval time.Duration) Schedule {
+	return ScheduleFunc(func(after time.Time) time.Time {
+		if after.Before(start) {
+			return start
+		}
+		n := after.Sub(start)/interval + 1
+		return start.Add(n * interval)
+	})
+}
+
+// cron is a Schedule of the times that match the fields of a crontab expression.
+type cron struct {
+	minute, hour, dom, month, dow uint64 // bitsets of the matching values
+	anyDOM, anyDOW                bool
+}
+
+// cronHorizon bounds the search for the next time that matches an expression (e.g. "0 0 30 2 *" never
+// matches).
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+// Cron returns a Schedule of the times that match the given crontab expression, of five fields: minute
+// (0-59), hour (0-23), day of the month (1-31), month (1-12), and day of the week (0-6, Sunday is 0, as
+// is 7). Fields are lists (1,15) of values, ranges (1-5), and wildcards (*), each optionally with a step
+// (*/10, 0-30/5). As per cron, a time matches if its minute, hour, and month match, and so does its day of
+// the month or of the week: if both of the day fields are restricted then either may match. Times are
+// evaluated in the location of the time that's passed to Next.
+func Cron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("jobs: expected 5 fields in crontab expression %q", spec)
+	}
+	var (
+		c      cron
+		err    error
+		bounds = []struct {
+			bits     *uint64
+			min, max int
+		}{
+			{&c.minute, 0, 59},
+			{&c.hour, 0, 23},
+			{&c.dom, 1, 31},
+			{&c.month, 1, 12},
+			{&c.dow, 0, 7},
+		}
+	)
+	for i, b := range bounds {
+		if *b.bits, err = parseCronField(fields[i], b.min, b.max); err != nil {
+			return nil, fmt.Errorf("jobs: crontab expression %q: %v", spec, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.anyDOM = fields[2] == "*"
+	c.anyDOW = fields[4] == "*"
+	return &c, nil
+}
+
+func parseCronField(field string, min, max int) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var (
+			lo, hi = min, max
+			step   = 1
+			r      = part
+		)
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step of %q", part)
+			}
+			r = part[:i]
+		}
+		if r != "*" {
+			bounds := strings.SplitN(r, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value of %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid range of %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range [%d, %d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cron) matchDay(t time.Time) bool {
+	var (
+		dom = c.dom&(1<<uint(t.Day())) != 0
+		dow = c.dow&(1<<uint(t.Weekday())) != 0
+	)
+	switch {
+	case c.anyDOM && c.anyDOW:
+		return true
+	case c.anyDOM:
+		return dow
+	case c.anyDOW:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next implements Schedule for cron.
+func (c *cron) Next(after time.Time) time.Time {
+	var (
+		t   = after.Truncate(time.Minute).Add(time.Minute)
+		end = after.Add(cronHorizon)
+		loc = after.Location()
+	)
+	for t.Before(end) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	at := func(s string) time.Time {
+		x, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return x
+	}
+	for _, tc := range []struct {
+		spec, after, want string
+	}{
+		{"* * * * *", "2017-07-14T02:40:30Z", "2017-07-14T02:41:00Z"},
+		{"*/15 * * * *", "2017-07-14T02:40:00Z", "2017-07-14T02:45:00Z"},
+		{"0 3 * * *", "2017-07-14T02:40:00Z", "2017-07-14T03:00:00Z"},
+		{"0 3 * * *", "2017-07-14T03:00:00Z", "2017-07-15T03:00:00Z"},
+		{"30 8-10/2 * * 1-5", "2017-07-14T10:30:00Z", "2017-07-17T08:30:00Z"}, // Friday to Monday
+		{"0 0 1,15 * *", "2017-07-02T00:00:00Z", "2017-07-15T00:00:00Z"},
+		{"0 0 29 2 *", "2017-03-01T00:00:00Z", "2020-02-29T00:00:00Z"},
+		{"0 0 13 * 5", "2017-07-01T00:00:00Z", "2017-07-07T00:00:00Z"}, // either day field matches
+		{"0 12 * * 7", "2017-07-14T00:00:00Z", "2017-07-16T12:00:00Z"}, // Sunday
+		{"0 0 31 2 *", "2017-01-01T00:00:00Z", ""},
+	} {
+		s, err := Cron(tc.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.spec, err)
+		}
+		var want time.Time
+		if tc.want != "" {
+			want = at(tc.want)
+		}
+		if got := s.Next(at(tc.after)); !got.Equal(want) {
+			t.Errorf("%q: expected %v after %v instead of %v", tc.spec, want, tc.after, got)
+		}
+	}
+	for _, spec := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Cron(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+		s     = Every(start, time.Minute)
+	)
+	for after, want := range map[int64]int64{0: 1000, 1000: 1060, 1059: 1060, 1060: 1120} {
+		if got := s.Next(time.Unix(after, 0)); !got.Equal(time.Unix(want, 0)) {
+			t.Errorf("expected %d after %d instead of %v", want, after, got.Unix())
+		}
+	}
+	once := Once(start)
+	if got := once.Next(time.Unix(999, 0)); !got.Equal(start) {
+		t.Fatalf("unexpected time: %v", got)
+	}
+	if got := once.Next(start); !got.IsZero() {
+		t.Fatalf("expected the job not to run again: %v", got)
+	}
+}
@@ -0,0 +1,173 @@
+// Package negotiation reports the outcome of a framework's subscription: what the master granted (e.g. the
+// framework ID and heartbeat interval), which version of Mesos the master runs, and which of the requested
+// framework capabilities that version of Mesos doesn't support. Masters silently ignore capabilities that
+// they don't understand, so a framework that depends upon some capability should check the report.
+package negotiation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/master/features"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// minVersions are the versions of Mesos that first understood each framework capability.
+var minVersions = map[mesos.FrameworkInfo_Capability_Type]features.Version{
+	mesos.FrameworkInfo_Capability_REVOCABLE_RESOURCES:    {Major: 0, Minor: 23},
+	mesos.FrameworkInfo_Capability_TASK_KILLING_STATE:     {Major: 0, Minor: 28},
+	mesos.FrameworkInfo_Capability_GPU_RESOURCES:          {Major: 1, Minor: 0},
+	mesos.FrameworkInfo_Capability_SHARED_RESOURCES:       {Major: 1, Minor: 0},
+	mesos.FrameworkInfo_Capability_PARTITION_AWARE:        {Major: 1, Minor: 1},
+	mesos.FrameworkInfo_Capability_MULTI_ROLE:             {Major: 1, Minor: 2},
+	mesos.FrameworkInfo_Capability_RESERVATION_REFINEMENT: {Major: 1, Minor: 4},
+	mesos.FrameworkInfo_Capability_REGION_AWARE:           {Major: 1, Minor: 5},
+}
+
+// MinVersion returns the version of Mesos that first understood the given framework capability; false is
+// returned for unknown capabilities.
+func MinVersion(c mesos.FrameworkInfo_Capability_Type) (features.Version, bool) {
+	v, ok := minVersions[c]
+	return v, ok
+}
+
+type (
+	// Unsupported is a requested capability that the master doesn't support.
+	Unsupported struct {
+		Capability mesos.FrameworkInfo_Capability_Type
+		MinVersion features.Version
+	}
+
+	// Report describes the outcome of a subscription.
+	Report struct {
+		FrameworkID       string
+		HeartbeatInterval time.Duration     // zero if the master doesn't send heartbeats
+		MasterInfo        *mesos.MasterInfo // nil for masters older than Mesos 1.1
+		MasterVersion     *features.Version // nil if the version of the master is unknown
+		Header            http.Header       // of the SUBSCRIBE response, nil if unavailable
+		Requested         []mesos.FrameworkInfo_Capability_Type
+		Unsupported       []Unsupported
+	}
+
+	// Option is a functional option for Caller; it returns an "undo" option when applied.
+	Option func(*config) Option
+
+	config struct {
+		versionHeader string
+	}
+)
+
+// VersionHeader configures the name of a response header that reports the version of the master, which
+// is consulted if the master doesn't report its version in the SUBSCRIBED event (as masters prior to
+// Mesos 1.1 don't); e.g. a header that's injected by a proxy. Mesos itself doesn't report its version via
+// headers. Disabled by default.
+func VersionHeader(name string) Option {
+	return func(c *config) Option {
+		old := c.versionHeader
+		c.versionHeader = name
+		return VersionHeader(old)
+	}
+}
+
+// Warnings returns a human-readable warning for each unsupported capability, as well as a warning if the
+// version of the master is unknown (in which case support for capabilities can't be determined).
+func (r *Report) Warnings() (warnings []string) {
+	if r.MasterVersion == nil {
+		if len(r.Requested) > 0 {
+			warnings = append(warnings, "unable to determine the version of the master: support for requested capabilities is unknown")
+		}
+		return
+	}
+	for _, u := range r.Unsupported {
+		warnings = append(warnings, fmt.Sprintf("capability %v requires Mesos %d.%d (or newer), master version is %v",
+			u.Capability, u.MinVersion.Major, u.MinVersion.Minor, r.MasterVersion))
+	}
+	return
+}
+
+func (r *Report) String() string {
+	version := "unknown"
+	if r.MasterVersion != nil {
+		version = r.MasterVersion.String()
+	}
+	caps := make([]string, 0, len(r.Requested))
+	for _, c := range r.Requested {
+		caps = append(caps, c.String())
+	}
+	return fmt.Sprintf("framework %s subscribed to master version %s, heartbeat interval %v, capabilities [%s]",
+		r.FrameworkID, version, r.HeartbeatInterval, strings.Join(caps, ","))
+}
+
+// NewReport returns a report of the given SUBSCRIBED event, which was received following a SUBSCRIBE call
+// with the given framework info. The header, which may be nil, is that of the SUBSCRIBE response; it's
+// consulted for the version of the master if versionHeader isn't empty and the event doesn't report the
+// version.
+func NewReport(info *mesos.FrameworkInfo, e *scheduler.Event_Subscribed, header http.Header, versionHeader string) *Report {
+	r := &Report{
+		FrameworkID: e.GetFrameworkID().GetValue(),
+		MasterInfo:  e.GetMasterInfo(),
+		Header:      header,
+	}
+	if hb := e.GetHeartbeatIntervalSeconds(); hb > 0 {
+		r.HeartbeatInterval = time.Duration(hb * float64(time.Second))
+	}
+	version := r.MasterInfo.GetVersion()
+	if version == "" && versionHeader != "" {
+		version = header.Get(versionHeader)
+	}
+	if v, err := features.ParseVersion(version); err == nil {
+		r.MasterVersion = &v
+	}
+	for _, c := range info.GetCapabilities() {
+		t := c.GetType()
+		r.Requested = append(r.Requested, t)
+		if r.MasterVersion == nil {
+			continue
+		}
+		if min, ok := minVersions[t]; ok && !r.MasterVersion.AtLeast(min.Major, min.Minor) {
+			r.Unsupported = append(r.Unsupported, Unsupported{Capability: t, MinVersion: min})
+		}
+	}
+	return r
+}
+
+// Caller returns a Caller that invokes f with a Report of each subscription that's made via the given
+// Caller, upon the decoding of the SUBSCRIBED event of the subscription. f is invoked from the goroutine
+// that decodes the subscription's events, prior to the delivery of the SUBSCRIBED event.
+func Caller(caller calls.Caller, f func(*Report), opts ...Option) calls.Caller {
+	var cfg config
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		resp, err := caller.Call(ctx, c)
+		if c.GetType() != scheduler.Call_SUBSCRIBE || resp == nil {
+			return resp, err
+		}
+		var (
+			info     = c.GetSubscribe().GetFrameworkInfo()
+			header   = httpcli.ResponseHeader(resp)
+			reported bool
+		)
+		return &mesos.ResponseWrapper{
+			Response: resp,
+			Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				err := resp.Decode(u)
+				if e, ok := u.(*scheduler.Event); ok && err == nil && !reported && e.GetType() == scheduler.Event_SUBSCRIBED {
+					reported = true
+					f(NewReport(info, e.GetSubscribed(), header, cfg.versionHeader))
+				}
+				return err
+			}),
+		}, err
+	})
+}
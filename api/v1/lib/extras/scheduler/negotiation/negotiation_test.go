@@ -0,0 +1,126 @@
+package negotiation
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/master/features"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func frameworkInfo(caps ...mesos.FrameworkInfo_Capability_Type) *mesos.FrameworkInfo {
+	info := &mesos.FrameworkInfo{User: "user", Name: "name"}
+	for _, c := range caps {
+		info.Capabilities = append(info.Capabilities, mesos.FrameworkInfo_Capability{Type: c})
+	}
+	return info
+}
+
+func subscribed(version string) *scheduler.Event_Subscribed {
+	hb := 15.0
+	e := &scheduler.Event_Subscribed{
+		FrameworkID:              &mesos.FrameworkID{Value: "fw"},
+		HeartbeatIntervalSeconds: &hb,
+	}
+	if version != "" {
+		e.MasterInfo = &mesos.MasterInfo{ID: "m", IP: 1, Version: &version}
+	}
+	return e
+}
+
+func TestNewReport(t *testing.T) {
+	info := frameworkInfo(
+		mesos.FrameworkInfo_Capability_PARTITION_AWARE,
+		mesos.FrameworkInfo_Capability_MULTI_ROLE,
+		mesos.FrameworkInfo_Capability_REGION_AWARE,
+	)
+	r := NewReport(info, subscribed("1.2.1"), nil, "")
+	if r.FrameworkID != "fw" || r.HeartbeatInterval != 15*time.Second {
+		t.Fatalf("unexpected report: %v", r)
+	}
+	if r.MasterVersion == nil || *r.MasterVersion != (features.Version{Major: 1, Minor: 2, Patch: 1}) {
+		t.Fatalf("unexpected master version: %v", r.MasterVersion)
+	}
+	want := []Unsupported{{Capability: mesos.FrameworkInfo_Capability_REGION_AWARE, MinVersion: features.Version{Major: 1, Minor: 5}}}
+	if !reflect.DeepEqual(r.Unsupported, want) {
+		t.Fatalf("expected unsupported %v instead of %v", want, r.Unsupported)
+	}
+	if len(r.Requested) != 3 || len(r.Warnings()) != 1 {
+		t.Fatalf("unexpected requested %v, warnings %q", r.Requested, r.Warnings())
+	}
+
+	// unknown version: nothing is reported as unsupported, but there's a warning
+	r = NewReport(info, subscribed(""), nil, "")
+	if r.MasterVersion != nil || len(r.Unsupported) != 0 || len(r.Warnings()) != 1 {
+		t.Fatalf("unexpected report for unknown version: %v, %v, %q", r.MasterVersion, r.Unsupported, r.Warnings())
+	}
+
+	// version from a header
+	r = NewReport(info, subscribed(""), http.Header{"X-Mesos-Version": []string{"1.5.0"}}, "X-Mesos-Version")
+	if r.MasterVersion == nil || len(r.Unsupported) != 0 || len(r.Warnings()) != 0 {
+		t.Fatalf("unexpected report for header version: %v, %v, %q", r.MasterVersion, r.Unsupported, r.Warnings())
+	}
+}
+
+func TestCaller(t *testing.T) {
+	var (
+		header = http.Header{"Version": []string{"1.0.0"}}
+		events = []*scheduler.Event{
+			{Type: scheduler.Event_SUBSCRIBED, Subscribed: subscribed("")},
+			{Type: scheduler.Event_HEARTBEAT},
+			{Type: scheduler.Event_SUBSCRIBED, Subscribed: subscribed("")},
+		}
+		caller = calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+			i := 0
+			return &httpcli.Response{
+				Closer: ioutil.NopCloser(nil),
+				Header: header,
+				Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+					*(u.(*scheduler.Event)) = *events[i]
+					i++
+					return nil
+				}),
+			}, nil
+		})
+		reports []*Report
+		c       = Caller(caller, func(r *Report) { reports = append(reports, r) }, VersionHeader("Version"))
+		ctx     = context.Background()
+	)
+
+	// calls other than SUBSCRIBE aren't reported
+	resp, err := c.Call(ctx, calls.Revive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e scheduler.Event
+	resp.Decode(&e)
+	if len(reports) != 0 {
+		t.Fatalf("unexpected reports: %v", reports)
+	}
+
+	resp, err = c.Call(ctx, calls.Subscribe(frameworkInfo(mesos.FrameworkInfo_Capability_PARTITION_AWARE)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range events {
+		if err := resp.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(reports) != 1 {
+		t.Fatalf("expected a single report instead of %v", reports)
+	}
+	r := reports[0]
+	if r.Header.Get("Version") != "1.0.0" || len(r.Unsupported) != 1 ||
+		r.Unsupported[0].Capability != mesos.FrameworkInfo_Capability_PARTITION_AWARE {
+		t.Fatalf("unexpected report: %v, unsupported %v", r, r.Unsupported)
+	}
+}
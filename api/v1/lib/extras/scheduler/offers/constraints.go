@@ -0,0 +1,122 @@
+package offers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+type (
+	// Placements reports the tasks that are placed upon agents, e.g. the non-terminal tasks of a
+	// tasks.Registry; the filter selects the tasks of interest (e.g. the replicas of a service), or all
+	// tasks if nil.
+	Placements interface {
+		Select(filter func(*mesos.TaskStatus) bool) []mesos.TaskStatus
+	}
+
+	// AttributeIndex records the attributes of agents, as learned from their offers, so that constraints
+	// may consult the attributes of the agents that tasks are already placed upon. Only text and scalar
+	// attributes are recorded. AttributeIndex funcs are safe to invoke concurrently.
+	AttributeIndex struct {
+		m      sync.RWMutex
+		agents map[mesos.AgentID]map[string]string
+	}
+)
+
+// NewAttributeIndex returns an empty AttributeIndex.
+func NewAttributeIndex() *AttributeIndex {
+	return &AttributeIndex{agents: make(map[mesos.AgentID]map[string]string)}
+}
+
+// attributeValue returns the value of a text, or scalar, attribute.
+func attributeValue(a *mesos.Attribute) (string, bool) {
+	switch a.GetType() {
+	case mesos.TEXT:
+		return a.GetText().GetValue(), true
+	case mesos.SCALAR:
+		return strconv.FormatFloat(a.GetScalar().GetValue(), 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// Add records the attributes of the agents of the given offers, replacing those previously recorded.
+func (x *AttributeIndex) Add(offers ...mesos.Offer) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	for i := range offers {
+		values := make(map[string]string, len(offers[i].Attributes))
+		for j := range offers[i].Attributes {
+			a := &offers[i].Attributes[j]
+			if v, ok := attributeValue(a); ok {
+				values[a.Name] = v
+			}
+		}
+		x.agents[offers[i].AgentID] = values
+	}
+}
+
+// Value returns the recorded value of the named attribute of the given agent.
+func (x *AttributeIndex) Value(agentID mesos.AgentID, name string) (v string, ok bool) {
+	x.m.RLock()
+	defer x.m.RUnlock()
+	v, ok = x.agents[agentID][name]
+	return
+}
+
+// Forget forgets the attributes of the given agent, e.g. once it's gone.
+func (x *AttributeIndex) Forget(agentID mesos.AgentID) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	delete(x.agents, agentID)
+}
+
+// EventRule returns a Rule that records the attributes of the agents of every OFFERS event.
+func (x *AttributeIndex) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil && e.GetType() == scheduler.Event_OFFERS {
+			x.Add(e.GetOffers().GetOffers()...)
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// Count returns the number of the given agents whose value of the named attribute is the given value;
+// agents are counted once per occurrence. Agents whose attributes aren't known aren't counted.
+func (x *AttributeIndex) Count(name, value string, agents ...mesos.AgentID) int {
+	x.m.RLock()
+	defer x.m.RUnlock()
+	n := 0
+	for _, id := range agents {
+		if v, ok := x.agents[id][name]; ok && v == value {
+			n++
+		}
+	}
+	return n
+}
+
+// MaxPerAttribute returns a Filter that accepts an offer if fewer than max of the selected tasks of the
+// given placements are placed upon agents whose value of the named attribute (e.g. "rack", or "zone")
+// is that of the offer's agent: i.e. an anti-affinity constraint, such that a workload survives the
+// failure of a rack (or zone) when max is one. Offers without the attribute are rejected. The filter
+// records the attributes of the offers that it evaluates; the tasks that are placed upon agents whose
+// attributes aren't known (e.g. agents that haven't been offered since a failover) aren't counted.
+func (x *AttributeIndex) MaxPerAttribute(name string, max int, p Placements, selector func(*mesos.TaskStatus) bool) Filter {
+	return FilterFunc(func(o *mesos.Offer) bool {
+		x.Add(*o)
+		value, ok := x.Value(o.AgentID, name)
+		if !ok {
+			return false
+		}
+		var agents []mesos.AgentID
+		for _, s := range p.Select(selector) {
+			if s.AgentID != nil {
+				agents = append(agents, *s.AgentID)
+			}
+		}
+		return x.Count(name, value, agents...) < max
+	})
+}
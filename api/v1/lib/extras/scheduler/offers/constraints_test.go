@@ -0,0 +1,58 @@
+package offers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+)
+
+func rackOffer(agent, rack string) *mesos.Offer {
+	o := &mesos.Offer{AgentID: mesos.AgentID{Value: agent}}
+	if rack != "" {
+		o.Attributes = []mesos.Attribute{{Name: "rack", Type: mesos.TEXT, Text: &mesos.Value_Text{Value: rack}}}
+	}
+	return o
+}
+
+func TestMaxPerAttribute(t *testing.T) {
+	var (
+		x        = offers.NewAttributeIndex()
+		registry = tasks.NewRegistry(nil)
+		web      = func(s *mesos.TaskStatus) bool { return strings.HasPrefix(s.TaskID.Value, "web-") }
+		f        = x.MaxPerAttribute("rack", 1, registry, web)
+	)
+	x.Add(*rackOffer("a1", "a"), *rackOffer("b1", "b"))
+	registry.Launched(
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "web-0"}, AgentID: mesos.AgentID{Value: "a1"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "db-0"}, AgentID: mesos.AgentID{Value: "b1"}},
+	)
+	for _, tc := range []struct {
+		offer *mesos.Offer
+		want  bool
+	}{
+		{rackOffer("a1", "a"), false},
+		{rackOffer("a2", "a"), false}, // another agent of the same rack
+		{rackOffer("b2", "b"), true},  // the rack only hosts tasks that aren't selected
+		{rackOffer("c1", ""), false},  // agents without the attribute are rejected
+	} {
+		if got := f.Accept(tc.offer); got != tc.want {
+			t.Errorf("expected %v for the offer of agent %s", tc.want, tc.offer.AgentID.Value)
+		}
+	}
+	if v, ok := x.Value(mesos.AgentID{Value: "a2"}, "rack"); !ok || v != "a" {
+		t.Fatalf("expected the filter to record the attributes of the offer: %q, %v", v, ok)
+	}
+
+	// terminated tasks no longer constrain placement
+	registry.Update(mesos.TaskStatus{TaskID: mesos.TaskID{Value: "web-0"}, State: mesos.TASK_FINISHED.Enum()})
+	if !f.Accept(rackOffer("a2", "a")) {
+		t.Fatal("expected the offer to be accepted")
+	}
+	x.Forget(mesos.AgentID{Value: "a2"})
+	if _, ok := x.Value(mesos.AgentID{Value: "a2"}, "rack"); ok {
+		t.Fatal("expected the agent to be forgotten")
+	}
+}
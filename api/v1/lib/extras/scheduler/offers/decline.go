@@ -0,0 +1,126 @@
+package offers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// DefaultDeclineWindow is a reasonable window within which to batch the declines of a burst of offers.
+const DefaultDeclineWindow = 100 * time.Millisecond
+
+// DeclineOption is a functional option for a DeclineBatcher; it returns an "undo" option when applied.
+type DeclineOption func(*DeclineBatcher) DeclineOption
+
+// DeclineBatcher coalesces DECLINE calls, issued within a small window of time, into as few DECLINE calls
+// as possible (see calls.CoalesceDeclines), reducing the load on the master of frameworks that decline
+// bursts of unusable offers. DeclineBatcher funcs are safe to invoke concurrently.
+type DeclineBatcher struct {
+	caller    calls.Caller
+	window    time.Duration
+	errorFunc func(error)
+	clock     mesostime.Clock
+
+	m       sync.Mutex
+	ctx     context.Context
+	pending []*scheduler.Call
+	timer   mesostime.Timer
+}
+
+// DeclineClock configures the clock that times the decline window; defaults to mesostime.SystemClock.
+func DeclineClock(c mesostime.Clock) DeclineOption {
+	return func(b *DeclineBatcher) DeclineOption {
+		old := b.clock
+		b.clock = c
+		return DeclineClock(old)
+	}
+}
+
+// NewDeclineBatcher returns a DeclineBatcher that sends batched declines via the given caller at most
+// window after the first decline of a batch. Errors encountered while sending a batch in the background
+// are reported to errorFunc, if not nil. A window of zero or less is replaced by DefaultDeclineWindow.
+func NewDeclineBatcher(caller calls.Caller, window time.Duration, errorFunc func(error), opts ...DeclineOption) *DeclineBatcher {
+	if window <= 0 {
+		window = DefaultDeclineWindow
+	}
+	b := &DeclineBatcher{caller: caller, window: window, errorFunc: errorFunc, clock: mesostime.SystemClock}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(b)
+		}
+	}
+	return b
+}
+
+// Caller returns a Caller that buffers DECLINE calls, returning a nil response and a nil error for each,
+// and that delegates all other calls to the batcher's caller. The context of the first decline of a batch
+// is the context with which the batch is sent.
+func (b *DeclineBatcher) Caller() calls.Caller {
+	return calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		if c.GetType() != scheduler.Call_DECLINE {
+			return b.caller.Call(ctx, c)
+		}
+		b.m.Lock()
+		defer b.m.Unlock()
+		if len(b.pending) == 0 {
+			b.ctx = ctx
+			b.timer = b.clock.AfterFunc(b.window, b.flushInBackground)
+		}
+		b.pending = append(b.pending, c)
+		return nil, nil
+	})
+}
+
+// Decline buffers a DECLINE of the given offers, to which the given options (e.g. calls.RefuseSeconds) are
+// applied; see Caller.
+func (b *DeclineBatcher) Decline(ctx context.Context, offerIDs []mesos.OfferID, opts ...scheduler.CallOpt) {
+	b.Caller().Call(ctx, calls.Decline(offerIDs...).With(opts...))
+}
+
+func (b *DeclineBatcher) flushInBackground() {
+	ctx, pending := b.take()
+	for _, err := range b.send(ctx, pending) {
+		if b.errorFunc != nil {
+			b.errorFunc(err)
+		}
+	}
+}
+
+// Flush immediately sends all buffered declines, returning the first error encountered (if any).
+func (b *DeclineBatcher) Flush(ctx context.Context) error {
+	_, pending := b.take()
+	if errs := b.send(ctx, pending); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (b *DeclineBatcher) take() (ctx context.Context, pending []*scheduler.Call) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	ctx, pending = b.ctx, b.pending
+	b.ctx, b.pending = nil, nil
+	return
+}
+
+func (b *DeclineBatcher) send(ctx context.Context, pending []*scheduler.Call) (errs []error) {
+	for _, c := range calls.CoalesceDeclines(pending...) {
+		resp, err := b.caller.Call(ctx, c)
+		if resp != nil {
+			resp.Close()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return
+}
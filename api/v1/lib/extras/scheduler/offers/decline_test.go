@@ -0,0 +1,55 @@
+package offers_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestDeclineBatcher(t *testing.T) {
+	var (
+		m     sync.Mutex
+		sent  []*scheduler.Call
+		ctx   = context.Background()
+		clock = mesostime.NewFakeClock(time.Unix(0, 0))
+	)
+	caller := calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+		m.Lock()
+		sent = append(sent, c)
+		m.Unlock()
+		return nil, nil
+	})
+	b := offers.NewDeclineBatcher(caller, 10*time.Millisecond, func(err error) { t.Error(err) }, offers.DeclineClock(clock))
+	for i := 0; i < 5; i++ {
+		b.Decline(ctx, []mesos.OfferID{{Value: strconv.Itoa(i)}})
+	}
+	b.Caller().Call(ctx, calls.Revive()) // revive is sent immediately
+
+	clock.Advance(9 * time.Millisecond)
+	m.Lock()
+	if len(sent) != 1 {
+		t.Fatalf("expected declines to be buffered until the window elapses: %v", sent)
+	}
+	m.Unlock()
+	clock.Advance(time.Millisecond)
+
+	m.Lock()
+	defer m.Unlock()
+	if len(sent) != 2 || sent[0].GetType() != scheduler.Call_REVIVE || sent[1].GetType() != scheduler.Call_DECLINE {
+		t.Fatalf("unexpected calls: %v", sent)
+	}
+	if n := len(sent[1].Decline.OfferIDs); n != 5 {
+		t.Fatalf("expected 5 declined offers instead of %d", n)
+	}
+	if err := b.Flush(ctx); err != nil || len(sent) != 2 {
+		t.Fatalf("expected no-op flush: %v, %v", err, sent)
+	}
+}
@@ -0,0 +1,428 @@
+package offers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+type (
+	// HoardOption is a functional option for a Hoard; it returns an "undo" option when applied.
+	HoardOption func(*Hoard) HoardOption
+
+	// Hoard holds offers for a limited duration, so that a scheduler may wait briefly for more offers
+	// (e.g. to bin-pack tasks more efficiently) before deciding which offers to use. Rescinded offers are
+	// dropped, and held offers are prioritized by the scheduled unavailability of their agents. Inverse
+	// offers are also tracked, until they're rescinded. Hoard funcs are safe to invoke concurrently.
+	Hoard struct {
+		hold  time.Duration
+		clock mesostime.Clock
+
+		m       sync.Mutex
+		held    map[mesos.OfferID]*heldOffer
+		planned map[mesos.OfferID]*Plan
+		inverse map[mesos.OfferID]mesos.InverseOffer
+		serial  uint64
+	}
+
+	// HoardSnapshot is a serializable (e.g. via encoding/json) view of the state of a Hoard, for debugging
+	// endpoints and support bundles.
+	HoardSnapshot struct {
+		Hold          time.Duration        `json:"hold"`
+		Held          []HeldOffer          `json:"held"`
+		Planned       []mesos.Offer        `json:"planned"`
+		InverseOffers []mesos.InverseOffer `json:"inverse_offers"`
+	}
+
+	// HeldOffer is an offer held by a Hoard, as reported by a HoardSnapshot.
+	HeldOffer struct {
+		Offer    mesos.Offer `json:"offer"`
+		Received time.Time   `json:"received"`
+	}
+
+	// Plan is an intent to use some set of offers, which are no longer held by the Hoard they were taken
+	// from. A plan is canceled if any of its offers are rescinded before the plan completes.
+	Plan struct {
+		hoard  *Hoard
+		offers []*heldOffer
+		ctx    context.Context
+		cancel context.CancelFunc
+		err    error // guarded by hoard.m
+		done   bool  // guarded by hoard.m
+	}
+
+	// RescindedError is reported by a Plan, one of the offers of which was rescinded.
+	RescindedError struct {
+		OfferID mesos.OfferID
+	}
+
+	heldOffer struct {
+		mesos.Offer
+		received time.Time
+		serial   uint64 // preserves the order in which offers were received
+	}
+)
+
+// HoardClock configures the clock that times the receipt of held offers (see Expire); defaults to
+// mesostime.SystemClock.
+func HoardClock(c mesostime.Clock) HoardOption {
+	return func(h *Hoard) HoardOption {
+		old := h.clock
+		h.clock = c
+		return HoardClock(old)
+	}
+}
+
+// NewHoard returns a Hoard that holds offers for (at most) the given duration.
+func NewHoard(hold time.Duration, opts ...HoardOption) *Hoard {
+	h := &Hoard{
+		hold:    hold,
+		clock:   mesostime.SystemClock,
+		held:    make(map[mesos.OfferID]*heldOffer),
+		planned: make(map[mesos.OfferID]*Plan),
+		inverse: make(map[mesos.OfferID]mesos.InverseOffer),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(h)
+		}
+	}
+	return h
+}
+
+// Add holds the given offers, as of now.
+func (h *Hoard) Add(offers ...mesos.Offer) {
+	now := h.clock.Now()
+	h.m.Lock()
+	defer h.m.Unlock()
+	for i := range offers {
+		h.serial++
+		h.held[offers[i].ID] = &heldOffer{Offer: offers[i], received: now, serial: h.serial}
+	}
+}
+
+// Rescind drops the offer with the given ID, returning true if the offer was either held or planned. A
+// plan that includes the offer is canceled and reports a *RescindedError; the plan's other offers are
+// held again (as of the time they were originally received), so that they may be used or declined.
+func (h *Hoard) Rescind(id mesos.OfferID) bool {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if p, ok := h.planned[id]; ok {
+		p.err = &RescindedError{OfferID: id}
+		p.finish()
+		for _, o := range p.offers {
+			if o.ID != id {
+				h.held[o.ID] = o
+			}
+		}
+		p.cancel()
+		return true
+	}
+	_, ok := h.held[id]
+	delete(h.held, id)
+	return ok
+}
+
+// AddInverse tracks the given inverse offers.
+func (h *Hoard) AddInverse(offers ...mesos.InverseOffer) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	for i := range offers {
+		h.inverse[offers[i].OfferID] = offers[i]
+	}
+}
+
+// RescindInverse stops tracking the inverse offer with the given ID, returning true if it was tracked.
+func (h *Hoard) RescindInverse(id mesos.OfferID) bool {
+	h.m.Lock()
+	defer h.m.Unlock()
+	_, ok := h.inverse[id]
+	delete(h.inverse, id)
+	return ok
+}
+
+// InverseOffers returns the inverse offers that are tracked.
+func (h *Hoard) InverseOffers() []mesos.InverseOffer {
+	h.m.Lock()
+	defer h.m.Unlock()
+	result := make([]mesos.InverseOffer, 0, len(h.inverse))
+	for _, o := range h.inverse {
+		result = append(result, o)
+	}
+	return result
+}
+
+// Len returns the number of held offers.
+func (h *Hoard) Len() int {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return len(h.held)
+}
+
+// Offers returns the held offers, in order of priority: offers from agents without scheduled
+// unavailability come first, followed by offers from agents whose unavailability begins furthest in the
+// future, with offers from agents that are currently unavailable last. Offers of the same priority are
+// ordered by the time at which they were received. The offers remain held.
+func (h *Hoard) Offers() Slice {
+	now := h.clock.Now()
+	h.m.Lock()
+	held := make([]*heldOffer, 0, len(h.held))
+	for _, o := range h.held {
+		held = append(held, o)
+	}
+	h.m.Unlock()
+
+	sort.Slice(held, func(i, j int) bool {
+		ai, aj := AvailableFor(&held[i].Offer, now), AvailableFor(&held[j].Offer, now)
+		if ai != aj {
+			return ai > aj
+		}
+		return held[i].serial < held[j].serial
+	})
+	result := make(Slice, 0, len(held))
+	for _, o := range held {
+		result = append(result, o.Offer)
+	}
+	return result
+}
+
+// Snapshot returns a view of the hoard's current state: held and planned offers are ordered by the time
+// at which they were received, inverse offers by ID.
+func (h *Hoard) Snapshot() HoardSnapshot {
+	h.m.Lock()
+	held := make([]*heldOffer, 0, len(h.held))
+	for _, o := range h.held {
+		held = append(held, o)
+	}
+	planned := make([]*heldOffer, 0, len(h.planned))
+	for id, p := range h.planned {
+		for _, o := range p.offers {
+			if o.ID == id {
+				planned = append(planned, o)
+			}
+		}
+	}
+	inverse := make([]mesos.InverseOffer, 0, len(h.inverse))
+	for _, o := range h.inverse {
+		inverse = append(inverse, o)
+	}
+	h.m.Unlock()
+
+	bySerial := func(s []*heldOffer) func(i, j int) bool {
+		return func(i, j int) bool { return s[i].serial < s[j].serial }
+	}
+	sort.Slice(held, bySerial(held))
+	sort.Slice(planned, bySerial(planned))
+	sort.Slice(inverse, func(i, j int) bool { return inverse[i].OfferID.Value < inverse[j].OfferID.Value })
+
+	snap := HoardSnapshot{
+		Hold:          h.hold,
+		Held:          make([]HeldOffer, 0, len(held)),
+		Planned:       make([]mesos.Offer, 0, len(planned)),
+		InverseOffers: inverse,
+	}
+	for _, o := range held {
+		snap.Held = append(snap.Held, HeldOffer{Offer: o.Offer, Received: o.received})
+	}
+	for _, o := range planned {
+		snap.Planned = append(snap.Planned, o.Offer)
+	}
+	return snap
+}
+
+// Take stops holding, and returns, those offers with the given IDs that were held; typically so that they
+// may be accepted.
+func (h *Hoard) Take(ids ...mesos.OfferID) Slice {
+	h.m.Lock()
+	defer h.m.Unlock()
+	var result Slice
+	for _, id := range ids {
+		if o, ok := h.held[id]; ok {
+			result = append(result, o.Offer)
+			delete(h.held, id)
+		}
+	}
+	return result
+}
+
+// Expire stops holding, and returns, the offers that have been held for at least the hold duration.
+func (h *Hoard) Expire() Slice {
+	now := h.clock.Now()
+	h.m.Lock()
+	var expired []*heldOffer
+	for id, o := range h.held {
+		if now.Sub(o.received) >= h.hold {
+			expired = append(expired, o)
+			delete(h.held, id)
+		}
+	}
+	h.m.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool { return expired[i].serial < expired[j].serial })
+	result := make(Slice, 0, len(expired))
+	for _, o := range expired {
+		result = append(result, o.Offer)
+	}
+	return result
+}
+
+// Decline expires offers (see Expire) and declines them, in a single call, via the given caller; the given
+// options (e.g. calls.RefuseSeconds) are applied to the DECLINE call.
+func (h *Hoard) Decline(ctx context.Context, caller calls.Caller, opts ...scheduler.CallOpt) error {
+	expired := h.Expire()
+	if len(expired) == 0 {
+		return nil
+	}
+	return calls.CallNoData(ctx, caller, calls.Decline(expired.IDs()...).With(opts...))
+}
+
+// EventRule returns a Rule that holds the offers of OFFERS events, tracks the inverse offers of
+// INVERSE_OFFERS events, and drops rescinded offers and inverse offers (canceling affected plans).
+func (h *Hoard) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil {
+			switch e.GetType() {
+			case scheduler.Event_OFFERS:
+				h.Add(e.GetOffers().GetOffers()...)
+			case scheduler.Event_RESCIND:
+				h.Rescind(e.GetRescind().OfferID)
+			case scheduler.Event_INVERSE_OFFERS:
+				h.AddInverse(e.GetInverseOffers().GetInverseOffers()...)
+			case scheduler.Event_RESCIND_INVERSE_OFFER:
+				h.RescindInverse(e.GetRescindInverseOffer().InverseOfferID)
+			}
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// ErrNotHeld is returned when planning the use of an offer that isn't held.
+var ErrNotHeld = errors.New("offer is not held")
+
+func (err *RescindedError) Error() string { return "offer " + err.OfferID.Value + " was rescinded" }
+
+// IsRescinded returns true if err is a *RescindedError.
+func IsRescinded(err error) bool {
+	_, ok := err.(*RescindedError)
+	return ok
+}
+
+// Plan takes the held offers with the given IDs, so that they may be used; ErrNotHeld is returned if any
+// of the offers isn't held, in which case no offers are taken. The context of the returned Plan is derived
+// from ctx and is canceled if any of the plan's offers are rescinded, interrupting an in-flight Launch.
+func (h *Hoard) Plan(ctx context.Context, ids ...mesos.OfferID) (*Plan, error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+	p := &Plan{hoard: h, offers: make([]*heldOffer, 0, len(ids))}
+	for _, id := range ids {
+		o, ok := h.held[id]
+		if !ok {
+			return nil, ErrNotHeld
+		}
+		p.offers = append(p.offers, o)
+	}
+	for _, o := range p.offers {
+		delete(h.held, o.ID)
+		h.planned[o.ID] = p
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return p, nil
+}
+
+// Context returns the context of the plan, which is canceled once the plan is canceled or completed.
+func (p *Plan) Context() context.Context { return p.ctx }
+
+// Offers returns the offers of the plan.
+func (p *Plan) Offers() Slice {
+	result := make(Slice, 0, len(p.offers))
+	for _, o := range p.offers {
+		result = append(result, o.Offer)
+	}
+	return result
+}
+
+// Err returns a *RescindedError if any of the plan's offers were rescinded, otherwise nil.
+func (p *Plan) Err() error {
+	p.hoard.m.Lock()
+	defer p.hoard.m.Unlock()
+	return p.err
+}
+
+// finish stops tracking the plan's offers; the hoard's lock must be held.
+func (p *Plan) finish() {
+	if p.done {
+		return
+	}
+	p.done = true
+	for _, o := range p.offers {
+		if p.hoard.planned[o.ID] == p {
+			delete(p.hoard.planned, o.ID)
+		}
+	}
+}
+
+// Cancel abandons the plan, returning its offers to the hoard unless the plan is already complete (or
+// canceled by a rescinded offer).
+func (p *Plan) Cancel() {
+	h := p.hoard
+	h.m.Lock()
+	if !p.done {
+		p.finish()
+		for _, o := range p.offers {
+			h.held[o.ID] = o
+		}
+	}
+	h.m.Unlock()
+	p.cancel()
+}
+
+// Launch completes the plan by sending an ACCEPT call (see calls.AcceptOffers) for the plan's offers and
+// the given operations, using the plan's context. A *RescindedError is returned if any of the plan's
+// offers are rescinded before, or while, the call is sent. The plan remains incomplete if the call cannot
+// be built.
+func (p *Plan) Launch(caller calls.Caller, ops ...mesos.Offer_Operation) error {
+	if err := p.Err(); err != nil {
+		return err
+	}
+	call, err := calls.AcceptOffers(p.Offers(), ops...)
+	if err != nil {
+		return err
+	}
+	err = calls.CallNoData(p.ctx, caller, call)
+
+	p.hoard.m.Lock()
+	p.finish()
+	if p.err != nil {
+		err = p.err
+	}
+	p.hoard.m.Unlock()
+	p.cancel()
+	return err
+}
+
+// AvailableFor returns the duration, as of the given time, until the scheduled unavailability of the
+// offer's agent begins: zero if the agent is currently unavailable, or else math.MaxInt64 if no
+// unavailability is scheduled (or if it has already ended).
+func AvailableFor(o *mesos.Offer, now time.Time) time.Duration {
+	u := o.GetUnavailability()
+	if u == nil {
+		return math.MaxInt64
+	}
+	start := time.Unix(0, u.Start.Nanoseconds)
+	if d := u.Duration; d != nil && !now.Before(start.Add(time.Duration(d.Nanoseconds))) {
+		return math.MaxInt64
+	}
+	if !now.Before(start) {
+		return 0
+	}
+	return start.Sub(now)
+}
@@ -0,0 +1,156 @@
+package offers_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestHoard(t *testing.T) {
+	var (
+		now   = time.Unix(1000, 0)
+		clock = mesostime.NewFakeClock(now)
+		h     = offers.NewHoard(time.Minute, offers.HoardClock(clock))
+		offer = func(id string, u *mesos.Unavailability) mesos.Offer {
+			return mesos.Offer{ID: mesos.OfferID{Value: id}, Unavailability: u}
+		}
+		unavailable = func(at, d time.Duration) *mesos.Unavailability {
+			u := &mesos.Unavailability{Start: mesos.TimeInfo{Nanoseconds: now.Add(at).UnixNano()}}
+			if d > 0 {
+				u.Duration = &mesos.DurationInfo{Nanoseconds: int64(d)}
+			}
+			return u
+		}
+		ids = func(s offers.Slice) (result []string) {
+			for i := range s {
+				result = append(result, s[i].ID.Value)
+			}
+			return
+		}
+	)
+	h.Add(
+		offer("soon", unavailable(time.Hour, 0)),
+		offer("now", unavailable(-time.Minute, 0)),
+		offer("none", nil),
+		offer("later", unavailable(2*time.Hour, 0)),
+		offer("over", unavailable(-time.Hour, time.Second)),
+	)
+	if got, want := ids(h.Offers()), []string{"none", "over", "later", "soon", "now"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v instead of %v", want, got)
+	}
+	if !h.Rescind(mesos.OfferID{Value: "now"}) || h.Rescind(mesos.OfferID{Value: "now"}) {
+		t.Fatal("unexpected rescind result")
+	}
+	if got := ids(h.Take(mesos.OfferID{Value: "soon"}, mesos.OfferID{Value: "bogus"})); !reflect.DeepEqual(got, []string{"soon"}) {
+		t.Fatalf("unexpected offers taken: %v", got)
+	}
+
+	clock.Advance(30 * time.Second)
+	h.Add(offer("fresh", nil))
+	if n := len(h.Expire()); n != 0 {
+		t.Fatalf("expected no expired offers instead of %d", n)
+	}
+	clock.Advance(30 * time.Second)
+	if got, want := ids(h.Expire()), []string{"none", "later", "over"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected expired %v instead of %v", want, got)
+	}
+	if n := h.Len(); n != 1 {
+		t.Fatalf("expected 1 held offer instead of %d", n)
+	}
+}
+
+func TestHoardPlan(t *testing.T) {
+	var (
+		h   = offers.NewHoard(time.Minute)
+		ctx = context.Background()
+		id  = func(s string) mesos.OfferID { return mesos.OfferID{Value: s} }
+	)
+	h.Add(mesos.Offer{ID: id("a")}, mesos.Offer{ID: id("b")})
+
+	if _, err := h.Plan(ctx, id("a"), id("bogus")); err != offers.ErrNotHeld {
+		t.Fatalf("expected ErrNotHeld instead of %v", err)
+	}
+	p, err := h.Plan(ctx, id("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Len() != 1 {
+		t.Fatal("expected planned offer to no longer be held")
+	}
+	p.Cancel()
+	if h.Len() != 2 {
+		t.Fatal("expected canceled plan to return its offer")
+	}
+
+	p, err = h.Plan(ctx, id("a"), id("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	caller := calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	go func() {
+		<-started
+		h.Rescind(id("b"))
+	}()
+	err = p.Launch(caller)
+	if !offers.IsRescinded(err) || err.(*offers.RescindedError).OfferID != id("b") {
+		t.Fatalf("expected rescinded error instead of %v", err)
+	}
+	p.Cancel()
+	if got := h.Offers(); len(got) != 1 || got[0].ID != id("a") {
+		t.Fatalf("expected the offer that wasn't rescinded to be held again instead of %v", got)
+	}
+
+	// likewise if the plan isn't being launched
+	h.Add(mesos.Offer{ID: id("c")})
+	if p, err = h.Plan(ctx, id("a"), id("c")); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Rescind(id("c")) || !offers.IsRescinded(p.Err()) || p.Context().Err() == nil {
+		t.Fatalf("expected the plan to be canceled: %v", p.Err())
+	}
+	if got := h.Take(id("a"), id("c")); len(got) != 1 || got[0].ID != id("a") {
+		t.Fatalf("expected the offer that wasn't rescinded to be held again instead of %v", got)
+	}
+}
+
+func TestHoardEventRule(t *testing.T) {
+	var (
+		h    = offers.NewHoard(time.Minute)
+		ctx  = context.Background()
+		rule = h.EventRule()
+		id   = mesos.OfferID{Value: "i"}
+	)
+	for _, e := range []*scheduler.Event{
+		{Type: scheduler.Event_INVERSE_OFFERS, InverseOffers: &scheduler.Event_InverseOffers{
+			InverseOffers: []mesos.InverseOffer{{OfferID: id}},
+		}},
+		{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{
+			Offers: []mesos.Offer{{ID: mesos.OfferID{Value: "o"}}},
+		}},
+	} {
+		rule.Eval(ctx, e, nil, eventrules.ChainIdentity)
+	}
+	if h.Len() != 1 || len(h.InverseOffers()) != 1 {
+		t.Fatal("expected an offer and an inverse offer")
+	}
+	rule.Eval(ctx, &scheduler.Event{
+		Type:                scheduler.Event_RESCIND_INVERSE_OFFER,
+		RescindInverseOffer: &scheduler.Event_RescindInverseOffer{InverseOfferID: id},
+	}, nil, eventrules.ChainIdentity)
+	if len(h.InverseOffers()) != 0 {
+		t.Fatal("expected inverse offer to be rescinded")
+	}
+}
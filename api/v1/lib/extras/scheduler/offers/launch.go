@@ -0,0 +1,352 @@
+package offers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+const (
+	// DefaultLaunchWindow is a reasonable window within which to batch the launches of a burst of tasks.
+	DefaultLaunchWindow = 10 * time.Millisecond
+
+	// DefaultMaxLaunchTasks is the default maximum number of tasks that are coalesced into a single
+	// ACCEPT call.
+	DefaultMaxLaunchTasks = 100
+
+	// DefaultMaxInFlight is the default maximum number of ACCEPT calls that a LaunchPlanner sends
+	// concurrently.
+	DefaultMaxInFlight = 4
+)
+
+type (
+	// Launch is a set of tasks to be launched using some set of offers, all from the same agent.
+	Launch struct {
+		Offers []mesos.Offer
+		Tasks  []mesos.TaskInfo
+	}
+
+	// LaunchOption is a functional option for a LaunchPlanner; it returns an "undo" option when applied.
+	LaunchOption func(*LaunchPlanner) LaunchOption
+
+	// LaunchPlanner coalesces task launches, issued within a small window of time, that are destined for
+	// the same agent into as few ACCEPT calls as possible (see PlanLaunches), improving the throughput of
+	// frameworks that launch many tasks per second. The number of concurrent ACCEPT calls is limited: once
+	// the limit is reached, and the number of buffered tasks reaches a threshold, launches block until
+	// some in-flight call completes. LaunchPlanner funcs are safe to invoke concurrently.
+	LaunchPlanner struct {
+		caller     calls.Caller
+		window     time.Duration
+		maxTasks   int
+		maxPending int
+		callOpts   []scheduler.CallOpt
+		errorFunc  func(error)
+		inflight   chan struct{}
+		clock      mesostime.Clock
+
+		m       sync.Mutex
+		ctx     context.Context
+		pending []Launch
+		tasks   int // the number of pending tasks
+		timer   mesostime.Timer
+	}
+
+	// LaunchError is reported for the tasks of an ACCEPT call that could not be generated, or sent.
+	LaunchError struct {
+		AgentID mesos.AgentID
+		TaskIDs []mesos.TaskID
+		Err     error
+	}
+)
+
+func (err *LaunchError) Error() string {
+	return "failed to launch " + strconv.Itoa(len(err.TaskIDs)) + " task(s) on agent " + err.AgentID.Value +
+		": " + err.Err.Error()
+}
+
+// Cause implements the causer interface of github.com/pkg/errors.
+func (err *LaunchError) Cause() error { return err.Err }
+
+// LaunchWindow configures the maximum duration for which launches are buffered; defaults to
+// DefaultLaunchWindow.
+func LaunchWindow(d time.Duration) LaunchOption {
+	return func(p *LaunchPlanner) LaunchOption {
+		old := p.window
+		p.window = d
+		return LaunchWindow(old)
+	}
+}
+
+// MaxLaunchTasks configures the maximum number of tasks per ACCEPT call; defaults to
+// DefaultMaxLaunchTasks.
+func MaxLaunchTasks(n int) LaunchOption {
+	return func(p *LaunchPlanner) LaunchOption {
+		old := p.maxTasks
+		p.maxTasks = n
+		return MaxLaunchTasks(old)
+	}
+}
+
+// MaxPendingTasks configures the number of buffered tasks upon which a launch immediately flushes the
+// buffer, blocking until the resulting ACCEPT calls have been sent; defaults to ten times the maximum
+// number of tasks per ACCEPT call.
+func MaxPendingTasks(n int) LaunchOption {
+	return func(p *LaunchPlanner) LaunchOption {
+		old := p.maxPending
+		p.maxPending = n
+		return MaxPendingTasks(old)
+	}
+}
+
+// LaunchCallOptions configures options (e.g. calls.Filters) that are applied to every ACCEPT call.
+func LaunchCallOptions(opts ...scheduler.CallOpt) LaunchOption {
+	return func(p *LaunchPlanner) LaunchOption {
+		old := p.callOpts
+		p.callOpts = opts
+		return LaunchCallOptions(old...)
+	}
+}
+
+// LaunchErrorFunc configures the func to which errors (each a *LaunchError) that are encountered while
+// sending a batch in the background are reported.
+func LaunchErrorFunc(f func(error)) LaunchOption {
+	return func(p *LaunchPlanner) LaunchOption {
+		old := p.errorFunc
+		p.errorFunc = f
+		return LaunchErrorFunc(old)
+	}
+}
+
+// LaunchClock configures the clock that times the launch window; defaults to mesostime.SystemClock.
+func LaunchClock(c mesostime.Clock) LaunchOption {
+	return func(p *LaunchPlanner) LaunchOption {
+		old := p.clock
+		p.clock = c
+		return LaunchClock(old)
+	}
+}
+
+// NewLaunchPlanner returns a LaunchPlanner that sends ACCEPT calls via the given caller, at most maxInFlight
+// at a time. A maxInFlight of zero or less is replaced by DefaultMaxInFlight.
+func NewLaunchPlanner(caller calls.Caller, maxInFlight int, opts ...LaunchOption) *LaunchPlanner {
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	p := &LaunchPlanner{
+		caller:   caller,
+		window:   DefaultLaunchWindow,
+		maxTasks: DefaultMaxLaunchTasks,
+		inflight: make(chan struct{}, maxInFlight),
+		clock:    mesostime.SystemClock,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	if p.window <= 0 {
+		p.window = DefaultLaunchWindow
+	}
+	if p.maxPending <= 0 {
+		p.maxPending = 10 * p.maxTasks
+	}
+	return p
+}
+
+// Launch buffers the launch of the given tasks, using the given offers, which must all be from the same
+// agent. The context of the first launch of a batch is the context with which the batch is sent. Launch
+// blocks, sending the buffered launches, if the number of buffered tasks reaches the configured threshold.
+func (p *LaunchPlanner) Launch(ctx context.Context, offers []mesos.Offer, tasks ...mesos.TaskInfo) {
+	p.m.Lock()
+	if len(p.pending) == 0 {
+		p.ctx = ctx
+		p.timer = p.clock.AfterFunc(p.window, p.flushInBackground)
+	}
+	p.pending = append(p.pending, Launch{Offers: offers, Tasks: tasks})
+	p.tasks += len(tasks)
+	full := p.tasks >= p.maxPending
+	p.m.Unlock()
+
+	if full {
+		p.flushInBackground()
+	}
+}
+
+func (p *LaunchPlanner) flushInBackground() {
+	ctx, pending := p.take()
+	for _, err := range p.send(ctx, pending) {
+		if p.errorFunc != nil {
+			p.errorFunc(err)
+		}
+	}
+}
+
+// Flush immediately sends all buffered launches, returning the first error encountered (if any).
+func (p *LaunchPlanner) Flush(ctx context.Context) error {
+	_, pending := p.take()
+	if errs := p.send(ctx, pending); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (p *LaunchPlanner) take() (ctx context.Context, pending []Launch) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	ctx, pending = p.ctx, p.pending
+	p.ctx, p.pending, p.tasks = nil, nil, 0
+	return
+}
+
+func (p *LaunchPlanner) send(ctx context.Context, pending []Launch) (errs []error) {
+	var (
+		m  sync.Mutex
+		wg sync.WaitGroup
+	)
+	report := func(l *Launch, err error) {
+		m.Lock()
+		defer m.Unlock()
+		errs = append(errs, launchError(l, err))
+	}
+	for _, l := range PlanLaunches(p.maxTasks, pending...) {
+		l := l
+		call, err := calls.AcceptOffers(l.Offers, calls.OpLaunch(l.Tasks...))
+		if err != nil {
+			report(&l, err)
+			continue
+		}
+		call = call.With(p.callOpts...)
+		select {
+		case p.inflight <- struct{}{}:
+		case <-ctx.Done():
+			report(&l, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-p.inflight
+				wg.Done()
+			}()
+			resp, err := p.caller.Call(ctx, call)
+			if resp != nil {
+				resp.Close()
+			}
+			if err != nil {
+				report(&l, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return
+}
+
+func launchError(l *Launch, err error) error {
+	lerr := &LaunchError{Err: err}
+	if len(l.Offers) > 0 {
+		lerr.AgentID = l.Offers[0].AgentID
+	}
+	for i := range l.Tasks {
+		lerr.TaskIDs = append(lerr.TaskIDs, l.Tasks[i].TaskID)
+	}
+	return lerr
+}
+
+// PlanLaunches coalesces the given launches into as few launches as possible, each of which may be sent as
+// a single ACCEPT call. Launches that share any offer are always coalesced, since an offer may be accepted
+// only once; otherwise launches destined for the same agent are coalesced so long as the resulting number
+// of tasks doesn't exceed maxTasks (if greater than zero). The resulting launches are ordered by the first
+// appearance of their agents among the given launches. The given launches are not modified.
+func PlanLaunches(maxTasks int, launches ...Launch) []Launch {
+	var (
+		agents  []mesos.AgentID
+		groups  = make(map[mesos.AgentID][]*launchGroup)
+		byOffer = make(map[mesos.OfferID]*launchGroup)
+	)
+	for i := range launches {
+		l := &launches[i]
+		var agentID mesos.AgentID
+		if len(l.Offers) > 0 {
+			agentID = l.Offers[0].AgentID
+		}
+		if _, ok := groups[agentID]; !ok {
+			agents = append(agents, agentID)
+		}
+		// merge all of the groups that share offers with this launch into the earliest of them
+		var g *launchGroup
+		for j := range l.Offers {
+			other, ok := byOffer[l.Offers[j].ID]
+			if !ok || other == g {
+				continue
+			}
+			if g == nil {
+				g = other
+				continue
+			}
+			if other.serial < g.serial {
+				g, other = other, g
+			}
+			g.add(byOffer, other.Offers, other.Tasks)
+			groups[agentID] = removeGroup(groups[agentID], other)
+		}
+		if g == nil {
+			g = &launchGroup{serial: i, offers: make(map[mesos.OfferID]struct{})}
+			groups[agentID] = append(groups[agentID], g)
+		}
+		g.add(byOffer, l.Offers, l.Tasks)
+	}
+
+	var result []Launch
+	for _, agentID := range agents {
+		current := -1
+		for _, g := range groups[agentID] {
+			if current >= 0 && (maxTasks <= 0 || len(result[current].Tasks)+len(g.Tasks) <= maxTasks) {
+				result[current].Offers = append(result[current].Offers, g.Offers...)
+				result[current].Tasks = append(result[current].Tasks, g.Tasks...)
+				continue
+			}
+			result = append(result, g.Launch)
+			current = len(result) - 1
+		}
+	}
+	return result
+}
+
+// launchGroup is a Launch that's the result of coalescing launches that share offers.
+type launchGroup struct {
+	Launch
+	serial int // the index of the earliest coalesced launch
+	offers map[mesos.OfferID]struct{}
+}
+
+// add coalesces the given offers and tasks into the group, updating the offer index.
+func (g *launchGroup) add(byOffer map[mesos.OfferID]*launchGroup, offers []mesos.Offer, tasks []mesos.TaskInfo) {
+	for i := range offers {
+		id := offers[i].ID
+		byOffer[id] = g
+		if _, ok := g.offers[id]; !ok {
+			g.offers[id] = struct{}{}
+			g.Offers = append(g.Offers, offers[i])
+		}
+	}
+	g.Tasks = append(g.Tasks, tasks...)
+}
+
+func removeGroup(groups []*launchGroup, g *launchGroup) []*launchGroup {
+	for i := range groups {
+		if groups[i] == g {
+			return append(groups[:i:i], groups[i+1:]...)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,180 @@
+package offers_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+var (
+	launchRole  = "a"
+	launchOffer = func(id, agent string) mesos.Offer {
+		return mesos.Offer{
+			ID:             mesos.OfferID{Value: id},
+			AgentID:        mesos.AgentID{Value: agent},
+			AllocationInfo: &mesos.Resource_AllocationInfo{Role: &launchRole},
+			Resources:      mesos.Resources{resources.NewCPUs(4).Resource}.Allocate(launchRole),
+		}
+	}
+	launchTask = func(id string) mesos.TaskInfo {
+		return mesos.TaskInfo{TaskID: mesos.TaskID{Value: id}, Resources: mesos.Resources{resources.NewCPUs(1).Resource}}
+	}
+)
+
+func TestPlanLaunches(t *testing.T) {
+	var (
+		a1 = launchOffer("a1", "a")
+		a2 = launchOffer("a2", "a")
+		a3 = launchOffer("a3", "a")
+		b1 = launchOffer("b1", "b")
+
+		describe = func(ls []offers.Launch) (result [][]string) {
+			for _, l := range ls {
+				var s []string
+				for _, o := range l.Offers {
+					s = append(s, o.ID.Value)
+				}
+				for _, t := range l.Tasks {
+					s = append(s, t.TaskID.Value)
+				}
+				result = append(result, s)
+			}
+			return
+		}
+		launches = []offers.Launch{
+			{Offers: []mesos.Offer{a1}, Tasks: []mesos.TaskInfo{launchTask("1")}},
+			{Offers: []mesos.Offer{b1}, Tasks: []mesos.TaskInfo{launchTask("2")}},
+			{Offers: []mesos.Offer{a2}, Tasks: []mesos.TaskInfo{launchTask("3")}},
+			{Offers: []mesos.Offer{a3}, Tasks: []mesos.TaskInfo{launchTask("4")}},
+			{Offers: []mesos.Offer{a3, a1}, Tasks: []mesos.TaskInfo{launchTask("5")}},
+		}
+	)
+	for ti, tc := range []struct {
+		maxTasks int
+		want     [][]string
+	}{
+		{0, [][]string{{"a1", "a3", "a2", "1", "4", "5", "3"}, {"b1", "2"}}},
+		{3, [][]string{{"a1", "a3", "1", "4", "5"}, {"a2", "3"}, {"b1", "2"}}},
+		{1, [][]string{{"a1", "a3", "1", "4", "5"}, {"a2", "3"}, {"b1", "2"}}}, // launches sharing offers aren't split
+	} {
+		if got := describe(offers.PlanLaunches(tc.maxTasks, launches...)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("test case %d: expected %v instead of %v", ti, tc.want, got)
+		}
+	}
+	if n := len(launches[0].Tasks); n != 1 {
+		t.Fatalf("launches were modified")
+	}
+}
+
+func TestLaunchPlanner(t *testing.T) {
+	var (
+		m        sync.Mutex
+		sent     []*scheduler.Call
+		inflight int
+		maxSeen  int
+		ctx      = context.Background()
+		injected = errors.New("injected")
+		release  = make(chan struct{})
+	)
+	caller := calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+		m.Lock()
+		sent = append(sent, c)
+		if inflight++; inflight > maxSeen {
+			maxSeen = inflight
+		}
+		m.Unlock()
+		<-release
+		m.Lock()
+		inflight--
+		m.Unlock()
+		if c.Accept.OfferIDs[0].Value == "c" {
+			return nil, injected
+		}
+		return nil, nil
+	})
+	p := offers.NewLaunchPlanner(caller, 2,
+		offers.LaunchWindow(time.Hour),
+		offers.LaunchCallOptions(calls.RefuseSeconds(time.Minute)),
+		offers.LaunchErrorFunc(func(err error) { t.Error(err) }),
+	)
+	for _, agent := range []string{"a", "b", "c"} {
+		p.Launch(ctx, []mesos.Offer{launchOffer(agent, agent)}, launchTask(agent+"1"))
+		p.Launch(ctx, []mesos.Offer{launchOffer(agent, agent)}, launchTask(agent+"2"))
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Flush(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for flush")
+	}
+	lerr, ok := err.(*offers.LaunchError)
+	if !ok || lerr.Err != injected || lerr.AgentID.Value != "c" || len(lerr.TaskIDs) != 2 {
+		t.Fatalf("unexpected flush error: %v", err)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if len(sent) != 3 || maxSeen > 2 {
+		t.Fatalf("expected 3 ACCEPT calls, at most 2 in-flight: %d, %d", len(sent), maxSeen)
+	}
+	for _, c := range sent {
+		if c.GetType() != scheduler.Call_ACCEPT || len(c.Accept.Operations) != 1 || len(c.Accept.Operations[0].Launch.TaskInfos) != 2 {
+			t.Fatalf("unexpected call: %v", c)
+		}
+		if c.Accept.GetFilters().GetRefuseSeconds() != 60 {
+			t.Fatalf("expected call options to be applied: %v", c)
+		}
+	}
+}
+
+func TestLaunchWindow(t *testing.T) {
+	var (
+		sent  int
+		clock = mesostime.NewFakeClock(time.Unix(0, 0))
+	)
+	caller := calls.CallerFunc(func(context.Context, *scheduler.Call) (mesos.Response, error) {
+		sent++
+		return nil, nil
+	})
+	p := offers.NewLaunchPlanner(caller, 1, offers.LaunchWindow(time.Second), offers.LaunchClock(clock))
+	p.Launch(context.Background(), []mesos.Offer{launchOffer("a", "a")}, launchTask("a1"))
+	clock.Advance(time.Second - time.Millisecond)
+	if sent != 0 {
+		t.Fatal("expected the launch to be buffered until the window elapses")
+	}
+	clock.Advance(time.Millisecond)
+	if sent != 1 || clock.Timers() != 0 {
+		t.Fatalf("expected the launch to be sent once the window elapses: %d", sent)
+	}
+}
+
+func TestLaunchPlannerBackPressure(t *testing.T) {
+	sent := make(chan *scheduler.Call, 10)
+	caller := calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+		sent <- c
+		return nil, nil
+	})
+	p := offers.NewLaunchPlanner(caller, 1, offers.LaunchWindow(time.Hour), offers.MaxPendingTasks(2))
+	p.Launch(context.Background(), []mesos.Offer{launchOffer("a", "a")}, launchTask("1"))
+	if len(sent) != 0 {
+		t.Fatal("expected launch to be buffered")
+	}
+	p.Launch(context.Background(), []mesos.Offer{launchOffer("b", "b")}, launchTask("2"))
+	if len(sent) != 2 {
+		t.Fatalf("expected the buffer to be flushed synchronously, instead of %d calls", len(sent))
+	}
+}
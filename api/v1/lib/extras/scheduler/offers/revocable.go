@@ -0,0 +1,77 @@
+package offers
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resourcefilters"
+)
+
+// Revocable offers are those that include revocable resources: e.g. the oversubscribed resources of
+// agents, that are allocated to tasks but unused, which are offered to frameworks with the
+// REVOCABLE_RESOURCES capability. The tasks that use revocable resources are best-effort: they may be
+// killed by QoS corrections (see tasks.IsPreempted) once the resources are used by their allocated tasks.
+// Launches shouldn't mix revocable and non-revocable resources unless the workload tolerates preemption.
+
+// ContainsRevocable returns a Filter that accepts offers that include revocable resources.
+func ContainsRevocable() Filter {
+	return FilterFunc(func(o *mesos.Offer) bool {
+		for i := range o.Resources {
+			if o.Resources[i].IsRevocable() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func nonRevocable(r *mesos.Resource) bool { return !r.IsRevocable() }
+
+// selectResources returns a shallow copy of the offer whose resources are those selected by the filter,
+// and true if any are.
+func selectResources(o *mesos.Offer, f resourcefilters.Filter) (mesos.Offer, bool) {
+	c := *o
+	c.Resources = resourcefilters.Select(f, o.Resources...)
+	return c, len(c.Resources) > 0
+}
+
+// Revocable returns the offers of the Slice that include revocable resources, each reduced to its
+// revocable resources.
+func (offers Slice) Revocable() Slice {
+	return offers.selectResources(resourcefilters.Revocable)
+}
+
+// NonRevocable returns the offers of the Slice that include non-revocable resources, each reduced to its
+// non-revocable resources.
+func (offers Slice) NonRevocable() Slice {
+	return offers.selectResources(nonRevocable)
+}
+
+func (offers Slice) selectResources(f resourcefilters.Filter) (result Slice) {
+	for i := range offers {
+		if o, ok := selectResources(&offers[i], f); ok {
+			result = append(result, o)
+		}
+	}
+	return
+}
+
+// Revocable returns the offers of the Index that include revocable resources, each reduced (as a shallow
+// copy) to its revocable resources.
+func (offers Index) Revocable() Index {
+	return offers.selectResources(resourcefilters.Revocable)
+}
+
+// NonRevocable returns the offers of the Index that include non-revocable resources, each reduced (as a
+// shallow copy) to its non-revocable resources.
+func (offers Index) NonRevocable() Index {
+	return offers.selectResources(nonRevocable)
+}
+
+func (offers Index) selectResources(f resourcefilters.Filter) Index {
+	result := make(Index)
+	for k, offer := range offers {
+		if o, ok := selectResources(offer, f); ok {
+			result[k] = &o
+		}
+	}
+	return result
+}
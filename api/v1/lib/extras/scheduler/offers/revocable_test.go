@@ -0,0 +1,65 @@
+package offers_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func revocable(r mesos.Resource) mesos.Resource {
+	r.Revocable = &mesos.Resource_RevocableInfo{}
+	return r
+}
+
+func TestRevocable(t *testing.T) {
+	var (
+		cpus = resources.NewCPUs(1).Resource
+		mem  = resources.NewMemory(64).Resource
+		s    = offers.Slice{
+			{ID: mesos.OfferID{Value: "regular"}, Resources: mesos.Resources{cpus, mem}},
+			{ID: mesos.OfferID{Value: "mixed"}, Resources: mesos.Resources{cpus, revocable(cpus), revocable(mem)}},
+			{ID: mesos.OfferID{Value: "oversubscribed"}, Resources: mesos.Resources{revocable(cpus)}},
+		}
+		contains = offers.ContainsRevocable()
+	)
+	for i, want := range []bool{false, true, true} {
+		if got := contains.Accept(&s[i]); got != want {
+			t.Errorf("offer %q: expected %v instead of %v", s[i].ID.Value, want, got)
+		}
+	}
+
+	rs := s.Revocable()
+	if len(rs) != 2 || rs[0].ID.Value != "mixed" || len(rs[0].Resources) != 2 || rs[1].ID.Value != "oversubscribed" {
+		t.Fatalf("unexpected revocable offers: %v", rs)
+	}
+	for _, o := range rs {
+		for i := range o.Resources {
+			if !o.Resources[i].IsRevocable() {
+				t.Fatalf("unexpected non-revocable resource: %v", o.Resources[i])
+			}
+		}
+	}
+	ns := s.NonRevocable()
+	if len(ns) != 2 || ns[0].ID.Value != "regular" || ns[1].ID.Value != "mixed" || len(ns[1].Resources) != 1 {
+		t.Fatalf("unexpected non-revocable offers: %v", ns)
+	}
+	if len(s[1].Resources) != 3 {
+		t.Fatalf("expected the original offer to be left intact: %v", s[1])
+	}
+
+	var (
+		x  = offers.NewIndex(s, nil)
+		id = func(v string) mesos.OfferID { return mesos.OfferID{Value: v} }
+	)
+	if ri := x.Revocable(); len(ri) != 2 || ri[id("regular")] != nil || len(ri[id("mixed")].Resources) != 2 {
+		t.Fatalf("unexpected revocable index: %v", ri)
+	}
+	if ni := x.NonRevocable(); len(ni) != 2 || ni[id("oversubscribed")] != nil || len(ni[id("mixed")].Resources) != 1 {
+		t.Fatalf("unexpected non-revocable index: %v", ni)
+	}
+	if len(x[id("mixed")].Resources) != 3 {
+		t.Fatalf("expected the indexed offer to be left intact: %v", x[id("mixed")])
+	}
+}
@@ -0,0 +1,334 @@
+// Package offerstats tracks the offers that a framework receives, declines, and uses, per role and per
+// agent, and detects offer starvation: the absence of viable offers for a role over some period of time.
+// Starvation frequently indicates a misconfiguration of quota, weights, or offer filters.
+package offerstats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	xmetrics "github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+type (
+	// Counts summarizes the fate of offers.
+	Counts struct {
+		Received  int
+		Declined  int
+		Used      int
+		Rescinded int
+	}
+
+	// Option is a functional configuration option for Stats; it returns an Option that acts as an
+	// "undo" if applied to the same Stats.
+	Option func(*Stats) Option
+
+	// Stats tracks offer statistics. Stats funcs are safe to invoke concurrently.
+	Stats struct {
+		clock       mesostime.Clock
+		defaultRole string
+		viable      offers.Filter
+		received    xmetrics.Counter
+		declined    xmetrics.Counter
+		used        xmetrics.Counter
+
+		m           sync.Mutex
+		started     time.Time
+		roles       map[string]*roleStats
+		agents      map[mesos.AgentID]*Counts
+		outstanding map[mesos.OfferID]origin
+	}
+
+	roleStats struct {
+		Counts
+		lastOffer  time.Time
+		lastViable time.Time
+	}
+
+	// origin identifies the role and agent of an outstanding offer.
+	origin struct {
+		role  string
+		agent mesos.AgentID
+	}
+)
+
+// Clock configures the clock that times offers, and the checks of Monitor; defaults to
+// mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(s *Stats) Option {
+		old := s.clock
+		s.clock = clock
+		return Clock(old)
+	}
+}
+
+// DefaultRole configures the role to which offers that lack allocation info are attributed; such are the
+// offers of frameworks that are not MULTI_ROLE. Defaults to "*".
+func DefaultRole(role string) Option {
+	return func(s *Stats) Option {
+		old := s.defaultRole
+		s.defaultRole = role
+		return DefaultRole(old)
+	}
+}
+
+// Viable configures the filter that determines whether an offer is useful to the framework; only
+// viable offers stave off starvation. By default all offers are viable.
+func Viable(f offers.Filter) Option {
+	return func(s *Stats) Option {
+		old := s.viable
+		s.viable = f
+		return Viable(old)
+	}
+}
+
+// Roles configures the roles that are monitored for starvation even before (or if never) an offer is
+// received for them.
+func Roles(roles ...string) Option {
+	return func(s *Stats) Option {
+		var added []string
+		for _, r := range roles {
+			if _, ok := s.roles[r]; !ok {
+				s.role(r)
+				added = append(added, r)
+			}
+		}
+		return func(s *Stats) Option {
+			for _, r := range added {
+				delete(s.roles, r)
+			}
+			return Roles(added...)
+		}
+	}
+}
+
+// Metrics configures counters that are incremented, with labels (role, agent ID), as offers are
+// received, declined, and used. Any of the counters may be nil.
+func Metrics(received, declined, used xmetrics.Counter) Option {
+	return func(s *Stats) Option {
+		oldR, oldD, oldU := s.received, s.declined, s.used
+		s.received, s.declined, s.used = received, declined, used
+		return Metrics(oldR, oldD, oldU)
+	}
+}
+
+// New returns a Stats object, configured by the given options.
+func New(opts ...Option) *Stats {
+	s := &Stats{
+		clock:       mesostime.SystemClock,
+		defaultRole: "*",
+		roles:       make(map[string]*roleStats),
+		agents:      make(map[mesos.AgentID]*Counts),
+		outstanding: make(map[mesos.OfferID]origin),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	s.started = s.clock.Now()
+	return s
+}
+
+// role returns the stats of the given role, creating them if needed. The caller must hold the lock,
+// unless the Stats are still being constructed.
+func (s *Stats) role(role string) *roleStats {
+	rs, ok := s.roles[role]
+	if !ok {
+		rs = &roleStats{}
+		s.roles[role] = rs
+	}
+	return rs
+}
+
+func (s *Stats) agent(id mesos.AgentID) *Counts {
+	c, ok := s.agents[id]
+	if !ok {
+		c = &Counts{}
+		s.agents[id] = c
+	}
+	return c
+}
+
+func (s *Stats) roleOf(o *mesos.Offer) string {
+	if ai := o.GetAllocationInfo(); ai != nil && ai.Role != nil {
+		return ai.GetRole()
+	}
+	return s.defaultRole
+}
+
+func count(c xmetrics.Counter, o origin) {
+	if c != nil {
+		c(o.role, o.agent.Value)
+	}
+}
+
+// Received records the receipt of the given offers.
+func (s *Stats) Received(offered ...mesos.Offer) {
+	now := s.clock.Now()
+	s.m.Lock()
+	defer s.m.Unlock()
+	for i := range offered {
+		o := origin{role: s.roleOf(&offered[i]), agent: offered[i].AgentID}
+		s.outstanding[offered[i].ID] = o
+		rs := s.role(o.role)
+		rs.Received++
+		rs.lastOffer = now
+		if s.viable == nil || s.viable.Accept(&offered[i]) {
+			rs.lastViable = now
+		}
+		s.agent(o.agent).Received++
+		count(s.received, o)
+	}
+}
+
+// settle removes the given offers from the set of outstanding offers, applying f to the counts of any
+// that are found. The caller must hold the lock.
+func (s *Stats) settle(ids []mesos.OfferID, f func(*Counts), c xmetrics.Counter) {
+	for _, id := range ids {
+		o, ok := s.outstanding[id]
+		if !ok {
+			continue
+		}
+		delete(s.outstanding, id)
+		f(&s.role(o.role).Counts)
+		f(s.agent(o.agent))
+		count(c, o)
+	}
+}
+
+// Declined records that the given offers were declined.
+func (s *Stats) Declined(ids ...mesos.OfferID) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.settle(ids, func(c *Counts) { c.Declined++ }, s.declined)
+}
+
+// Used records that the given offers were accepted with at least one operation.
+func (s *Stats) Used(ids ...mesos.OfferID) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.settle(ids, func(c *Counts) { c.Used++ }, s.used)
+}
+
+// Rescinded records that Mesos rescinded the given offers.
+func (s *Stats) Rescinded(ids ...mesos.OfferID) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.settle(ids, func(c *Counts) { c.Rescinded++ }, nil)
+}
+
+// Role returns the counts for the given role.
+func (s *Stats) Role(role string) Counts {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if rs, ok := s.roles[role]; ok {
+		return rs.Counts
+	}
+	return Counts{}
+}
+
+// Agent returns the counts for the given agent.
+func (s *Stats) Agent(id mesos.AgentID) Counts {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if c, ok := s.agents[id]; ok {
+		return *c
+	}
+	return Counts{}
+}
+
+// SinceLastOffer returns the time that's elapsed since an offer was last received for the given role
+// (or, if no offer has ever been received for the role, since the Stats were created).
+func (s *Stats) SinceLastOffer(role string) time.Duration {
+	now := s.clock.Now()
+	s.m.Lock()
+	defer s.m.Unlock()
+	if rs, ok := s.roles[role]; ok && !rs.lastOffer.IsZero() {
+		return now.Sub(rs.lastOffer)
+	}
+	return now.Sub(s.started)
+}
+
+// Starving returns, in sorted order, the known roles (see Roles) for which no viable offer has been
+// received within the given deadline.
+func (s *Stats) Starving(deadline time.Duration) (roles []string) {
+	now := s.clock.Now()
+	s.m.Lock()
+	defer s.m.Unlock()
+	for role, rs := range s.roles {
+		last := rs.lastViable
+		if last.IsZero() {
+			last = s.started
+		}
+		if now.Sub(last) > deadline {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return
+}
+
+// Monitor checks for starvation (see Starving) at the given interval until the context is canceled,
+// invoking starved for each role that's starving at the time of the check.
+func (s *Stats) Monitor(ctx context.Context, deadline, interval time.Duration, starved func(role string, since time.Duration)) {
+	t := s.clock.NewTimer(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C():
+			for _, role := range s.Starving(deadline) {
+				starved(role, s.SinceLastOffer(role))
+			}
+			t.Reset(interval)
+		}
+	}
+}
+
+// EventRule returns a Rule that records OFFERS and RESCIND events.
+func (s *Stats) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil {
+			switch e.GetType() {
+			case scheduler.Event_OFFERS:
+				s.Received(e.GetOffers().GetOffers()...)
+			case scheduler.Event_RESCIND:
+				s.Rescinded(e.GetRescind().OfferID)
+			}
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// CallRule returns a Rule that records the offers that are declined or used by successful ACCEPT and
+// DECLINE calls. An ACCEPT call w/o operations declines its offers. The Rule should precede the rule
+// that actually invokes the call, in the chain.
+func (s *Stats) CallRule() callrules.Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if err != nil {
+			return ctx, c, r, err
+		}
+		switch c.GetType() {
+		case scheduler.Call_ACCEPT:
+			if a := c.GetAccept(); len(a.GetOperations()) > 0 {
+				s.Used(a.GetOfferIDs()...)
+			} else {
+				s.Declined(a.GetOfferIDs()...)
+			}
+		case scheduler.Call_DECLINE:
+			s.Declined(c.GetDecline().GetOfferIDs()...)
+		}
+		return ctx, c, r, err
+	}
+}
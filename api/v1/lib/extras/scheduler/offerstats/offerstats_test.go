@@ -0,0 +1,100 @@
+package offerstats
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func offer(id, agent, role string) mesos.Offer {
+	o := mesos.Offer{ID: mesos.OfferID{Value: id}, AgentID: mesos.AgentID{Value: agent}, Hostname: agent}
+	if role != "" {
+		o.AllocationInfo = &mesos.Resource_AllocationInfo{Role: &role}
+	}
+	return o
+}
+
+func TestStats(t *testing.T) {
+	var (
+		clock    = mesostime.NewFakeClock(time.Unix(1000, 0))
+		received = map[string]int{}
+		s        = New(
+			Clock(clock),
+			Roles("idle"),
+			Viable(offers.ByHostname("a1")),
+			Metrics(func(labels ...string) { received[labels[0]+"/"+labels[1]]++ }, nil, nil),
+		)
+		ctx = context.Background()
+	)
+	e := &scheduler.Event{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{Offers: []mesos.Offer{
+		offer("1", "a1", "web"), offer("2", "a2", "web"), offer("3", "a1", ""),
+	}}}
+	s.EventRule().Eval(ctx, e, nil, eventrules.ChainIdentity)
+	s.EventRule().Eval(ctx, &scheduler.Event{Type: scheduler.Event_RESCIND, Rescind: &scheduler.Event_Rescind{
+		OfferID: mesos.OfferID{Value: "3"},
+	}}, nil, eventrules.ChainIdentity)
+
+	rule := s.CallRule()
+	for _, c := range []*scheduler.Call{
+		calls.Accept(calls.OfferOperations{calls.OpLaunch()}.WithOffers(mesos.OfferID{Value: "1"})),
+		calls.Accept(calls.OfferOperations{}.WithOffers(mesos.OfferID{Value: "2"})),
+		calls.Decline(mesos.OfferID{Value: "1"}), // already settled
+	} {
+		rule.Eval(ctx, c, nil, nil, callrules.ChainIdentity)
+	}
+
+	if c := s.Role("web"); c != (Counts{Received: 2, Used: 1, Declined: 1}) {
+		t.Errorf("unexpected web role counts: %+v", c)
+	}
+	if c := s.Role("*"); c != (Counts{Received: 1, Rescinded: 1}) {
+		t.Errorf("unexpected default role counts: %+v", c)
+	}
+	if c := s.Agent(mesos.AgentID{Value: "a1"}); c != (Counts{Received: 2, Used: 1, Rescinded: 1}) {
+		t.Errorf("unexpected agent counts: %+v", c)
+	}
+	if expected := map[string]int{"web/a1": 1, "web/a2": 1, "*/a1": 1}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected metrics %v instead of %v", expected, received)
+	}
+
+	clock.Advance(time.Minute)
+	if d := s.SinceLastOffer("web"); d != time.Minute {
+		t.Errorf("expected a minute since the last offer instead of %v", d)
+	}
+	if roles := s.Starving(30 * time.Second); !reflect.DeepEqual(roles, []string{"*", "idle", "web"}) {
+		t.Errorf("unexpected starving roles %v", roles)
+	}
+	// only viable offers stave off starvation
+	s.Received(offer("4", "a2", "web"), offer("5", "a1", ""))
+	if roles := s.Starving(30 * time.Second); !reflect.DeepEqual(roles, []string{"idle", "web"}) {
+		t.Errorf("unexpected starving roles %v", roles)
+	}
+
+	// the monitor checks for starvation at every interval
+	var (
+		starved      = make(chan string, 2)
+		mctx, cancel = context.WithCancel(ctx)
+		done         = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		s.Monitor(mctx, 30*time.Second, 10*time.Second, func(role string, _ time.Duration) { starved <- role })
+	}()
+	clock.WaitForTimers(1)
+	clock.Advance(10 * time.Second)
+	for _, want := range []string{"idle", "web"} {
+		if role := <-starved; role != want {
+			t.Errorf("expected starving role %q instead of %q", want, role)
+		}
+	}
+	cancel()
+	<-done
+}
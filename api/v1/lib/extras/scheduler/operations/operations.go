@@ -0,0 +1,294 @@
+// Package operations tracks the offer operations of a framework that request feedback (i.e. that specify an
+// operation ID), and cross-references them with the operations that are reported by the GET_OPERATIONS
+// calls of the master and agent APIs, in order to detect operations that were orphaned (known to Mesos,
+// though not to the framework; e.g. issued by a scheduler instance prior to a failover), lost (known to the
+// framework, though not to Mesos), or stuck (pending for too long).
+package operations
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	agentcalls "github.com/mesos/mesos-go/api/v1/lib/agent/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+type (
+	// Option is a functional configuration option for a Tracker; it returns an Option that acts as an
+	// "undo" if applied to the same Tracker.
+	Option func(*Tracker) Option
+
+	// Operation is the state of a tracked operation.
+	Operation struct {
+		ID                 string
+		Type               mesos.Offer_Operation_Type
+		State              mesos.OperationState // OPERATION_PENDING until a status update is received
+		AgentID            string               // as reported by a status update, if any
+		ResourceProviderID string               // as reported by a status update, if any
+		Accepted           time.Time
+		Updated            time.Time
+	}
+
+	// Tracker tracks the non-terminal operations of a framework. Its rules must be added to the call and
+	// event processing chains of the scheduler. Tracker funcs are safe to invoke concurrently.
+	Tracker struct {
+		clock mesostime.Clock
+
+		m   sync.Mutex
+		ops map[string]*Operation // by operation ID
+	}
+
+	// Report is the result of an Audit.
+	Report struct {
+		// Orphaned operations are non-terminal operations of the framework that are known to Mesos,
+		// but aren't tracked.
+		Orphaned []mesos.Operation
+		// Lost operations are tracked, but aren't known to Mesos; their status should be reconciled.
+		Lost []Operation
+		// Stuck operations are tracked, and non-terminal according to Mesos, but have been pending for
+		// longer than the audit threshold.
+		Stuck []Operation
+		// Finished operations are tracked, but are terminal according to Mesos: their final status
+		// updates were missed.
+		Finished []mesos.Operation
+	}
+)
+
+// Clock configures the clock that times the acceptance, and the updates, of operations; defaults to
+// mesostime.SystemClock.
+func Clock(c mesostime.Clock) Option {
+	return func(t *Tracker) Option {
+		old := t.clock
+		t.clock = c
+		return Clock(old)
+	}
+}
+
+// NewTracker returns a Tracker that doesn't track any operations.
+func NewTracker(opts ...Option) *Tracker {
+	t := &Tracker{clock: mesostime.SystemClock, ops: make(map[string]*Operation)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	return t
+}
+
+// IsTerminal returns true for the states of operations that won't change state any further.
+func IsTerminal(s mesos.OperationState) bool {
+	switch s {
+	case mesos.OPERATION_FINISHED, mesos.OPERATION_FAILED, mesos.OPERATION_ERROR, mesos.OPERATION_DROPPED,
+		mesos.OPERATION_GONE_BY_OPERATOR:
+		return true
+	}
+	return false
+}
+
+// Operations returns the tracked operations, sorted by ID.
+func (t *Tracker) Operations() []Operation {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.sorted()
+}
+
+func (t *Tracker) sorted() []Operation {
+	result := make([]Operation, 0, len(t.ops))
+	for _, op := range t.ops {
+		result = append(result, *op)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Forget stops tracking the operations with the given IDs.
+func (t *Tracker) Forget(ids ...string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for _, id := range ids {
+		delete(t.ops, id)
+	}
+}
+
+// Restore tracks the given operations, e.g. as returned by Operations of a previous instance of the
+// framework; tracked operations with the same IDs are replaced, and terminal operations are ignored.
+// Restored operations should be reconciled (see Reconcile).
+func (t *Tracker) Restore(ops ...Operation) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for i := range ops {
+		if op := ops[i]; op.ID != "" && !IsTerminal(op.State) {
+			t.ops[op.ID] = &op
+		}
+	}
+}
+
+// Reconcile returns a RECONCILE_OPERATIONS call for all tracked operations, or nil if there are none.
+func (t *Tracker) Reconcile() *scheduler.Call {
+	ops := t.Operations()
+	return (&Report{Lost: ops}).Reconcile()
+}
+
+// Update applies the given status to the tracked operation that it refers to; operations that reach a
+// terminal state are forgotten. Returns false if the operation isn't tracked.
+func (t *Tracker) Update(s *mesos.OperationStatus) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	op, ok := t.ops[s.GetOperationID().GetValue()]
+	if !ok {
+		return false
+	}
+	if IsTerminal(s.GetState()) {
+		delete(t.ops, op.ID)
+		return true
+	}
+	op.State, op.Updated = s.GetState(), t.clock.Now()
+	if id := s.GetAgentID().GetValue(); id != "" {
+		op.AgentID = id
+	}
+	if id := s.GetResourceProviderID().GetValue(); id != "" {
+		op.ResourceProviderID = id
+	}
+	return true
+}
+
+// CallRule returns a Rule that tracks the operations of successful ACCEPT calls that specify an
+// operation ID.
+func (t *Tracker) CallRule() callrules.Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if err == nil && c.GetType() == scheduler.Call_ACCEPT {
+			t.accepted(c.GetAccept().GetOperations())
+		}
+		return ctx, c, r, err
+	}
+}
+
+func (t *Tracker) accepted(ops []mesos.Offer_Operation) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	now := t.clock.Now()
+	for i := range ops {
+		if id := ops[i].GetID().GetValue(); id != "" {
+			t.ops[id] = &Operation{
+				ID:       id,
+				Type:     ops[i].GetType(),
+				State:    mesos.OPERATION_PENDING,
+				Accepted: now,
+				Updated:  now,
+			}
+		}
+	}
+}
+
+// EventRule returns a Rule that applies the statuses of UPDATE_OPERATION_STATUS events.
+func (t *Tracker) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if u := e.GetUpdateOperationStatus(); err == nil && u != nil {
+			t.Update(&u.Status)
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// Audit cross-references the tracked operations with the given operations, as reported by Mesos, of the
+// framework with the given ID (operations of other frameworks are ignored). Tracked operations that have
+// been pending for longer than stuckAfter are reported as stuck; a zero duration disables such detection.
+// The Tracker isn't modified: e.g. callers may Forget finished operations.
+//
+// The operations of an agent are known to the master only while the agent is registered; operations
+// reported by the agent API (see AgentOperations) should be included when auditing while agents are
+// failing over.
+func (t *Tracker) Audit(frameworkID string, ops []mesos.Operation, stuckAfter time.Duration) (r Report) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	var (
+		known = make(map[string]bool, len(ops))
+		seen  = make(map[string]bool, len(ops)) // by UUID: operations may be reported by master and agent
+	)
+	for i := range ops {
+		op := &ops[i]
+		if op.GetFrameworkID().GetValue() != frameworkID || seen[string(op.UUID.GetValue())] {
+			continue
+		}
+		seen[string(op.UUID.GetValue())] = true
+		id := op.Info.GetID().GetValue()
+		terminal := IsTerminal(op.LatestStatus.GetState())
+		if _, ok := t.ops[id]; !ok {
+			if !terminal {
+				r.Orphaned = append(r.Orphaned, *op)
+			}
+			continue
+		}
+		known[id] = true
+		if terminal {
+			r.Finished = append(r.Finished, *op)
+		}
+	}
+	now := t.clock.Now()
+	for _, op := range t.sorted() {
+		switch {
+		case !known[op.ID]:
+			r.Lost = append(r.Lost, op)
+		case stuckAfter > 0 && now.Sub(op.Accepted) > stuckAfter && !r.finished(op.ID):
+			r.Stuck = append(r.Stuck, op)
+		}
+	}
+	return
+}
+
+func (r *Report) finished(id string) bool {
+	for i := range r.Finished {
+		if r.Finished[i].Info.GetID().GetValue() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Reconcile returns a RECONCILE_OPERATIONS call for the lost and stuck operations of the report, or nil if
+// there are none.
+func (r *Report) Reconcile() *scheduler.Call {
+	var req []calls.ReconcileOperationRequest
+	for _, ops := range [][]Operation{r.Lost, r.Stuck} {
+		for _, op := range ops {
+			req = append(req, calls.ReconcileOperationRequest{
+				OperationID:        op.ID,
+				AgentID:            op.AgentID,
+				ResourceProviderID: op.ResourceProviderID,
+			})
+		}
+	}
+	if len(req) == 0 {
+		return nil
+	}
+	return calls.ReconcileOperations(req)
+}
+
+// MasterOperations issues a GET_OPERATIONS call via the given sender, and returns the operations that are
+// known to the master.
+func MasterOperations(ctx context.Context, sender mastercalls.Sender) ([]mesos.Operation, error) {
+	ops, err := mastercalls.SendGetOperations(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	return ops.Operations, nil
+}
+
+// AgentOperations issues a GET_OPERATIONS call via the given sender, and returns the operations that are
+// known to the agent.
+func AgentOperations(ctx context.Context, sender agentcalls.Sender) ([]mesos.Operation, error) {
+	ops, err := agentcalls.SendGetOperations(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	return ops.Operations, nil
+}
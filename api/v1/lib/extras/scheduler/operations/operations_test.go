@@ -0,0 +1,124 @@
+package operations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func op(fw, id, uuid string, st mesos.OperationState) mesos.Operation {
+	o := mesos.Operation{
+		FrameworkID:  &mesos.FrameworkID{Value: fw},
+		Info:         mesos.Offer_Operation{Type: mesos.Offer_Operation_CREATE},
+		LatestStatus: mesos.OperationStatus{State: st},
+		UUID:         mesos.UUID{Value: []byte(uuid)},
+	}
+	if id != "" {
+		o.Info.ID = &mesos.OperationID{Value: id}
+	}
+	return o
+}
+
+func TestTracker(t *testing.T) {
+	var (
+		clock  = mesostime.NewFakeClock(time.Unix(1000, 0))
+		tr     = NewTracker(Clock(clock))
+		ctx    = context.Background()
+		caller = tr.CallRule().Caller(calls.CallerFunc(func(context.Context, *scheduler.Call) (mesos.Response, error) {
+			return nil, nil
+		}))
+		accept = func(ids ...string) {
+			var ops []mesos.Offer_Operation
+			for _, id := range ids {
+				o := calls.OpCreate()
+				o.ID = &mesos.OperationID{Value: id}
+				ops = append(ops, o)
+			}
+			if _, err := caller.Call(ctx, calls.Accept(calls.OfferOperations(ops).WithOffers(mesos.OfferID{Value: "o"}))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		update = func(id string, st mesos.OperationState) {
+			err := tr.EventRule().HandleEvent(ctx, &scheduler.Event{
+				Type: scheduler.Event_UPDATE_OPERATION_STATUS,
+				UpdateOperationStatus: &scheduler.Event_UpdateOperationStatus{Status: mesos.OperationStatus{
+					OperationID: &mesos.OperationID{Value: id},
+					State:       st,
+					AgentID:     &mesos.AgentID{Value: "a1"},
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	)
+	accept("1", "2", "3", "4")
+	caller.Call(ctx, calls.Accept(calls.OfferOperations{calls.OpCreate()}.WithOffers(mesos.OfferID{Value: "o"})))
+	update("1", mesos.OPERATION_FINISHED)
+	update("2", mesos.OPERATION_RECOVERING)
+	if ops := tr.Operations(); len(ops) != 3 || ops[0].ID != "2" || ops[0].State != mesos.OPERATION_RECOVERING ||
+		ops[0].AgentID != "a1" || ops[1].State != mesos.OPERATION_PENDING {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+
+	clock.Advance(time.Minute)
+	r := tr.Audit("fw", []mesos.Operation{
+		op("fw", "2", "u2", mesos.OPERATION_RECOVERING),
+		op("fw", "2", "u2", mesos.OPERATION_RECOVERING), // reported by the agent as well
+		op("fw", "3", "u3", mesos.OPERATION_FINISHED),
+		op("fw", "old", "u5", mesos.OPERATION_PENDING),
+		op("fw", "", "u6", mesos.OPERATION_PENDING),
+		op("fw", "done", "u7", mesos.OPERATION_FINISHED),
+		op("other", "x", "u8", mesos.OPERATION_PENDING),
+	}, 30*time.Second)
+	if len(r.Orphaned) != 2 || r.Orphaned[0].Info.GetID().GetValue() != "old" {
+		t.Errorf("unexpected orphans %+v", r.Orphaned)
+	}
+	if len(r.Lost) != 1 || r.Lost[0].ID != "4" {
+		t.Errorf("unexpected lost operations %+v", r.Lost)
+	}
+	if len(r.Stuck) != 1 || r.Stuck[0].ID != "2" {
+		t.Errorf("unexpected stuck operations %+v", r.Stuck)
+	}
+	if len(r.Finished) != 1 || r.Finished[0].Info.GetID().GetValue() != "3" {
+		t.Errorf("unexpected finished operations %+v", r.Finished)
+	}
+	c := r.Reconcile()
+	if ops := c.GetReconcileOperations().GetOperations(); len(ops) != 2 || ops[0].OperationID.Value != "4" ||
+		ops[1].OperationID.Value != "2" || ops[1].GetAgentID().GetValue() != "a1" {
+		t.Errorf("unexpected reconciliation %v", c)
+	}
+	if r = tr.Audit("fw", nil, 0); len(r.Stuck) != 0 || len(r.Lost) != 3 {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if (&Report{}).Reconcile() != nil {
+		t.Error("expected no reconciliation for an empty report")
+	}
+}
+
+func TestMasterOperations(t *testing.T) {
+	sender := mastercalls.SenderFunc(func(_ context.Context, r mastercalls.Request) (mesos.Response, error) {
+		if r.Call().GetType() != master.Call_GET_OPERATIONS {
+			t.Fatalf("unexpected call %v", r.Call())
+		}
+		resp := master.Response{Type: master.Response_GET_OPERATIONS, GetOperations: &master.Response_GetOperations{
+			Operations: []mesos.Operation{op("fw", "1", "u1", mesos.OPERATION_PENDING)},
+		}}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = resp
+			return nil
+		})}, nil
+	})
+	ops, err := MasterOperations(context.Background(), sender)
+	if err != nil || len(ops) != 1 {
+		t.Fatalf("unexpected operations %+v, %v", ops, err)
+	}
+}
@@ -0,0 +1,154 @@
+package outbox
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	"github.com/mesos/mesos-go/api/v1/lib/recordio"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// compactEvery is the number of calls, popped from a FileQueue, upon which the file is rewritten.
+const compactEvery = 64
+
+// FileQueue is a Queue that persists calls to a file, as a RecordIO stream of protobuf encoded calls, so
+// that queued calls survive process restarts. Calls are appended to the file, and synced, as they're
+// pushed; the file is rewritten (atomically, as per store.NewFileSingleton) once the queue is empty, and
+// periodically as calls are popped; so a crash may cause some popped calls to be sent again.
+type FileQueue struct {
+	path   string
+	f      *os.File // for appending
+	calls  []*scheduler.Call
+	popped int // since the file was last rewritten
+}
+
+// NewFileQueue returns a FileQueue that persists calls to the file at the given path; calls that were
+// persisted to the file by a previous FileQueue are queued. A partially written call, at the end of the
+// file (e.g. following a crash), is discarded.
+func NewFileQueue(path string) (*FileQueue, error) {
+	q := &FileQueue{path: path}
+	f, err := os.Open(path)
+	if err == nil {
+		err = q.load(f)
+		f.Close()
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	if err == nil {
+		err = q.rewrite()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *FileQueue) load(r io.Reader) error {
+	fr := recordio.NewReader(r)
+	for {
+		b, err := fr.ReadFrame()
+		if err == io.EOF || err == framing.ErrorUnderrun {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c := new(scheduler.Call)
+		if err = c.Unmarshal(b); err != nil {
+			return err
+		}
+		q.calls = append(q.calls, c)
+	}
+}
+
+// rewrite atomically replaces the file with one that holds the queued calls, and reopens it for appending.
+func (q *FileQueue) rewrite() error {
+	if q.f != nil {
+		q.f.Close()
+		q.f = nil
+	}
+	f, err := ioutil.TempFile(filepath.Dir(q.path), "."+filepath.Base(q.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // noop after a successful rename
+	w := recordio.NewWriter(f)
+	for _, c := range q.calls {
+		var b []byte
+		if b, err = c.Marshal(); err != nil {
+			break
+		}
+		if err = w.WriteFrame(b); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), q.path)
+	}
+	if err != nil {
+		return err
+	}
+	q.popped = 0
+	q.f, err = os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND, 0)
+	return err
+}
+
+// Push implements Queue.
+func (q *FileQueue) Push(c *scheduler.Call) error {
+	b, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	if err = recordio.NewWriter(q.f).WriteFrame(b); err == nil {
+		err = q.f.Sync()
+	}
+	if err != nil {
+		q.rewrite() // discard a partially written call
+		return err
+	}
+	q.calls = append(q.calls, c)
+	return nil
+}
+
+// Peek implements Queue.
+func (q *FileQueue) Peek() (*scheduler.Call, error) {
+	if len(q.calls) == 0 {
+		return nil, nil
+	}
+	return q.calls[0], nil
+}
+
+// Pop implements Queue.
+func (q *FileQueue) Pop() error {
+	if len(q.calls) == 0 {
+		return nil
+	}
+	q.calls[0] = nil
+	q.calls = q.calls[1:]
+	if q.popped++; len(q.calls) == 0 || q.popped >= compactEvery {
+		return q.rewrite()
+	}
+	return nil
+}
+
+// Len implements Queue.
+func (q *FileQueue) Len() int { return len(q.calls) }
+
+// Close closes the file of the queue.
+func (q *FileQueue) Close() error {
+	if q.f == nil {
+		return nil
+	}
+	err := q.f.Close()
+	q.f = nil
+	return err
+}
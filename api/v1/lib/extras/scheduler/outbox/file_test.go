@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestFileQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	q, err := NewFileQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []string{"t1", "t2", "t3"} {
+		if err = q.Push(calls.Acknowledge("a", task, []byte(task))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = q.Pop(); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+
+	// popped calls that haven't been compacted away are queued again, as is every pushed call; a
+	// partially written call is discarded
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("100\npartial")
+	f.Close()
+	if q, err = NewFileQueue(path); err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Len(); n != 3 {
+		t.Fatalf("expected 3 queued calls instead of %d", n)
+	}
+	for _, task := range []string{"t1", "t2", "t3"} {
+		c, err := q.Peek()
+		if err != nil || c.GetAcknowledge().GetTaskID().Value != task {
+			t.Fatalf("expected the acknowledgement of %q instead of %v, %v", task, c, err)
+		}
+		if err = q.Pop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c, err := q.Peek(); c != nil || err != nil {
+		t.Fatalf("expected an empty queue instead of %v, %v", c, err)
+	}
+	q.Close()
+
+	// the file of an empty queue is compacted
+	if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+		t.Fatalf("expected an empty file: %v, %v", fi, err)
+	}
+}
@@ -0,0 +1,259 @@
+// Package outbox implements a write-ahead outbox for non-urgent calls (e.g. acknowledgements, reconciliation
+// requests, and declines): such calls are persisted to a Queue before being sent, and remain queued until
+// they're accepted by the master, so that a brief outage of the master (or a restart of the scheduler)
+// doesn't lose acknowledgements; which would otherwise cause agents to retry the unacknowledged status
+// updates, en masse, upon recovery. Queued calls are sent in order, and are drained whenever the scheduler
+// (re)subscribes. Delivery is at-least-once: the calls that an Outbox handles by default are idempotent.
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/correlation"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// DefaultTypes are the types of the calls that an Outbox handles by default.
+var DefaultTypes = []scheduler.Call_Type{
+	scheduler.Call_ACKNOWLEDGE,
+	scheduler.Call_ACKNOWLEDGE_OPERATION_STATUS,
+	scheduler.Call_RECONCILE,
+	scheduler.Call_RECONCILE_OPERATIONS,
+	scheduler.Call_DECLINE,
+}
+
+type (
+	// Queue is a FIFO queue of calls; e.g. a FileQueue, a MemoryQueue, or an adapter of an embedded
+	// database (such as bolt). An Outbox serializes its invocations of Queue funcs, which therefore needn't
+	// be safe to invoke concurrently.
+	Queue interface {
+		// Push appends the given call to the tail of the queue; once it returns, the call must survive
+		// whatever the queue is meant to survive (e.g. a process restart).
+		Push(*scheduler.Call) error
+		// Peek returns the call at the head of the queue; nil if the queue is empty.
+		Peek() (*scheduler.Call, error)
+		// Pop removes the call at the head of the queue.
+		Pop() error
+		// Len returns the number of queued calls.
+		Len() int
+	}
+
+	// Option is a functional configuration option for an Outbox; it returns an Option that acts as an
+	// "undo" if applied to the same Outbox.
+	Option func(*Outbox) Option
+
+	// Outbox persists calls of the configured types to a Queue before sending them. Outbox funcs are
+	// safe to invoke concurrently.
+	Outbox struct {
+		caller    calls.Caller
+		queue     Queue
+		types     map[scheduler.Call_Type]bool
+		retryable func(error) bool
+		errorFunc func(error)
+
+		m        sync.Mutex
+		draining bool
+	}
+)
+
+// Types configures the types of the calls that are persisted by an Outbox; defaults to DefaultTypes.
+// Calls of other types are sent directly, bypassing the queue.
+func Types(types ...scheduler.Call_Type) Option {
+	return func(o *Outbox) Option {
+		old := make([]scheduler.Call_Type, 0, len(o.types))
+		for t := range o.types {
+			old = append(old, t)
+		}
+		o.types = make(map[scheduler.Call_Type]bool, len(types))
+		for _, t := range types {
+			o.types[t] = true
+		}
+		return Types(old...)
+	}
+}
+
+// Retryable configures the func that decides whether a queued call, the sending of which failed with the
+// given error, remains queued (to be sent once the master recovers); otherwise the call is dropped and the
+// error is reported. Defaults to Temporary.
+func Retryable(f func(error) bool) Option {
+	return func(o *Outbox) Option {
+		old := o.retryable
+		o.retryable = f
+		return Retryable(old)
+	}
+}
+
+// ErrorFunc configures the func to which errors that aren't returned to the issuers of calls are reported:
+// those encountered while draining the queue, and those of dropped calls.
+func ErrorFunc(f func(error)) Option {
+	return func(o *Outbox) Option {
+		old := o.errorFunc
+		o.errorFunc = f
+		return ErrorFunc(old)
+	}
+}
+
+// New returns an Outbox that persists calls to the given queue, and that sends them via the given caller
+// (typically an httpsched caller, beneath any rules that set the framework ID of calls). Calls that are
+// already queued, e.g. by a previous instance of the scheduler, are sent upon the next Drain.
+func New(caller calls.Caller, queue Queue, opts ...Option) *Outbox {
+	o := &Outbox{caller: caller, queue: queue, retryable: Temporary}
+	Types(DefaultTypes...)(o)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// Temporary returns true for errors that aren't API errors (e.g. network errors), and for API errors that
+// are expected to clear once the master recovers (including the loss of the subscription, and redirection
+// to a newly elected master).
+func Temporary(err error) bool {
+	apiErr, ok := correlation.Cause(err).(*apierrors.Error)
+	if !ok {
+		return true
+	}
+	return apiErr.Temporary() || apiErr.SubscriptionLoss() || apierrors.CodeNotLeader.Matches(apiErr)
+}
+
+// Caller returns a Caller that persists calls of the configured types, returning a nil response and a nil
+// error for each once it's queued, and then drains the queue (unless some other goroutine is already
+// draining it). Calls of other types are delegated to the caller of the Outbox. Queued calls are copies:
+// the issuer of a call may reuse whatever it references (e.g. the UUID of a status update that's decoded
+// into a reused event, see controller.WithReusedEvents) once the call returns.
+func (o *Outbox) Caller() calls.Caller {
+	return calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		if !o.types[c.GetType()] {
+			return o.caller.Call(ctx, c)
+		}
+		c = proto.Clone(c).(*scheduler.Call)
+		o.m.Lock()
+		err := o.queue.Push(c)
+		drain := err == nil && !o.draining
+		o.draining = o.draining || drain
+		o.m.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if drain {
+			o.report(o.drain(ctx))
+		}
+		return nil, nil
+	})
+}
+
+// Drain sends the queued calls, in order, until the queue is empty; returns the first error that leaves a
+// call queued (see Retryable). Calls that are dropped are reported to the configured error func. If some
+// other goroutine is already draining the queue then Drain returns nil immediately.
+func (o *Outbox) Drain(ctx context.Context) error {
+	o.m.Lock()
+	if o.draining {
+		o.m.Unlock()
+		return nil
+	}
+	o.draining = true
+	o.m.Unlock()
+	return o.drain(ctx)
+}
+
+// drain sends queued calls; the draining flag must have been set by the caller, and is cleared upon
+// return, while the outbox is locked: a concurrent Push either is observed by drain, or else drains itself.
+func (o *Outbox) drain(ctx context.Context) error {
+	for {
+		o.m.Lock()
+		c, err := o.queue.Peek()
+		if err != nil || c == nil {
+			return o.stop(err)
+		}
+		o.m.Unlock()
+
+		resp, err := o.caller.Call(ctx, c)
+		if resp != nil {
+			resp.Close()
+		}
+		o.m.Lock()
+		if err != nil && o.retryable(err) {
+			return o.stop(err)
+		}
+		if perr := o.queue.Pop(); perr != nil {
+			return o.stop(perr)
+		}
+		o.m.Unlock()
+		o.report(err) // the call was dropped
+	}
+}
+
+// stop clears the draining flag and unlocks the outbox, which must be locked; returns err.
+func (o *Outbox) stop(err error) error {
+	o.draining = false
+	o.m.Unlock()
+	return err
+}
+
+// Len returns the number of queued calls; e.g. for metrics.
+func (o *Outbox) Len() int {
+	o.m.Lock()
+	defer o.m.Unlock()
+	return o.queue.Len()
+}
+
+// EventRule returns a Rule that drains the queue once a SUBSCRIBED event has been handled by the rest of
+// the chain. Errors encountered while draining are reported to the configured error func; they don't fail
+// the event.
+func (o *Outbox) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		ctx, e, err = ch(ctx, e, err)
+		if e.GetType() == scheduler.Event_SUBSCRIBED {
+			o.report(o.Drain(ctx))
+		}
+		return ctx, e, err
+	}
+}
+
+func (o *Outbox) report(err error) {
+	if err != nil && o.errorFunc != nil {
+		o.errorFunc(err)
+	}
+}
+
+// MemoryQueue is a Queue that doesn't survive process restarts; it bridges brief outages of the master.
+type MemoryQueue struct {
+	calls []*scheduler.Call
+}
+
+// NewMemoryQueue returns an empty MemoryQueue.
+func NewMemoryQueue() *MemoryQueue { return &MemoryQueue{} }
+
+// Push implements Queue.
+func (q *MemoryQueue) Push(c *scheduler.Call) error {
+	q.calls = append(q.calls, c)
+	return nil
+}
+
+// Peek implements Queue.
+func (q *MemoryQueue) Peek() (*scheduler.Call, error) {
+	if len(q.calls) == 0 {
+		return nil, nil
+	}
+	return q.calls[0], nil
+}
+
+// Pop implements Queue.
+func (q *MemoryQueue) Pop() error {
+	if len(q.calls) > 0 {
+		q.calls[0] = nil
+		q.calls = q.calls[1:]
+	}
+	return nil
+}
+
+// Len implements Queue.
+func (q *MemoryQueue) Len() int { return len(q.calls) }
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestOutbox(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		down     = true
+		sent     []scheduler.Call_Type
+		rejected = apierrors.CodeMalformedRequest.Error("bad")
+		reported []error
+		caller   = calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+			if down {
+				return nil, apierrors.CodeMesosUnavailable.Error("")
+			}
+			if c.GetType() == scheduler.Call_RECONCILE {
+				return nil, rejected
+			}
+			sent = append(sent, c.GetType())
+			return nil, nil
+		})
+		o = New(caller, NewMemoryQueue(), ErrorFunc(func(err error) { reported = append(reported, err) }))
+		c = o.Caller()
+	)
+	for _, call := range []*scheduler.Call{
+		calls.Acknowledge("a", "t", []byte("u")),
+		calls.Reconcile(calls.ReconcileTasks(nil)),
+		calls.Decline(mesos.OfferID{Value: "o"}),
+	} {
+		if resp, err := c.Call(ctx, call); resp != nil || err != nil {
+			t.Fatalf("expected the call to be queued: %v, %v", resp, err)
+		}
+	}
+	if _, err := c.Call(ctx, calls.Revive()); err == nil {
+		t.Fatal("expected calls of other types to bypass the queue")
+	}
+	if n := o.Len(); n != 3 || len(reported) != 3 {
+		t.Fatalf("expected 3 queued calls, and 3 reported errors, instead of %d, %v", n, reported)
+	}
+
+	// the queue is drained, in order, upon subscription; rejected calls are dropped
+	down, reported = false, nil
+	o.EventRule().HandleEvent(ctx, &scheduler.Event{Type: scheduler.Event_SUBSCRIBED})
+	if o.Len() != 0 || len(sent) != 2 || sent[0] != scheduler.Call_ACKNOWLEDGE || sent[1] != scheduler.Call_DECLINE {
+		t.Fatalf("expected the queue to be drained instead of %d, %v", o.Len(), sent)
+	}
+	if len(reported) != 1 || reported[0] != rejected {
+		t.Fatalf("expected the rejected call to be reported instead of %v", reported)
+	}
+
+	// while the master is up calls are sent immediately
+	if _, err := c.Call(ctx, calls.Acknowledge("a", "t2", []byte("u"))); err != nil || o.Len() != 0 || len(sent) != 3 {
+		t.Fatalf("expected the call to be sent: %v, %v", err, sent)
+	}
+}
+
+func TestOutboxCopiesCalls(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		down   = true
+		acked  []string
+		caller = calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+			if down {
+				return nil, apierrors.CodeMesosUnavailable.Error("")
+			}
+			acked = append(acked, string(c.GetAcknowledge().GetUUID()))
+			return nil, nil
+		})
+		o    = New(caller, NewMemoryQueue())
+		uuid = []byte("u1")
+	)
+	if _, err := o.Caller().Call(ctx, calls.Acknowledge("a", "t", uuid)); err != nil {
+		t.Fatal(err)
+	}
+	// e.g. the UUID of the next status update, decoded into the same buffer
+	copy(uuid, "u2")
+
+	down = false
+	if err := o.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(acked) != 1 || acked[0] != "u1" {
+		t.Fatalf("expected the queued UUID to be acknowledged instead of %q", acked)
+	}
+}
+
+func TestTemporary(t *testing.T) {
+	for i, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection refused"), true},
+		{apierrors.CodeMesosUnavailable.Error(""), true},
+		{apierrors.CodeUnsubscribed.Error(""), true},
+		{apierrors.CodeNotLeader.Error(""), true},
+		{apierrors.CodeMalformedRequest.Error(""), false},
+		{apierrors.CodeIncompatibleVersion.Error(""), false},
+	} {
+		if got := Temporary(tc.err); got != tc.want {
+			t.Errorf("test case %d: expected %t instead of %t", i, tc.want, got)
+		}
+	}
+}
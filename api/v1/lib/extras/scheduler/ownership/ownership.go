@@ -0,0 +1,241 @@
+// Package ownership multiplexes several logical applications over a single framework subscription: tasks
+// are stamped with a label that names their owner (i.e. application) upon launch, and an Index maps the
+// IDs of launched tasks to their owners, so that status updates may be routed to the owning application.
+// Mesos doesn't echo task labels in status updates, so an Index is populated from ACCEPT calls (see
+// CallRule), and may be rebuilt from the tasks reported by the master's operator API (see Recover).
+package ownership
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// DefaultLabel is the default key of the label that names the owner of a task.
+const DefaultLabel = "mesos-go/owner"
+
+type (
+	// Option is a functional configuration option for an Index; it returns an Option that acts as an
+	// "undo" if applied to the same Index.
+	Option func(*Index) Option
+
+	// Index maps the IDs of non-terminal tasks to their owners. Tasks are forgotten once they reach a
+	// terminal state. Index funcs are safe to invoke concurrently.
+	Index struct {
+		label string
+
+		m       sync.RWMutex
+		owners  map[mesos.TaskID]string
+		byOwner map[string]map[mesos.TaskID]struct{}
+	}
+
+	ownerKey struct{}
+)
+
+// Label configures the key of the label that names the owner of a task; defaults to DefaultLabel.
+func Label(key string) Option {
+	return func(ix *Index) Option {
+		old := ix.label
+		ix.label = key
+		return Label(old)
+	}
+}
+
+// NewIndex returns an empty Index.
+func NewIndex(opts ...Option) *Index {
+	ix := &Index{
+		label:   DefaultLabel,
+		owners:  make(map[mesos.TaskID]string),
+		byOwner: make(map[string]map[mesos.TaskID]struct{}),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(ix)
+		}
+	}
+	return ix
+}
+
+// Stamp labels the given tasks as owned by owner, overwriting any previous owner label. Tasks are
+// recorded by the Index once they've been launched, see CallRule.
+func (ix *Index) Stamp(owner string, ts ...*mesos.TaskInfo) {
+	for _, t := range ts {
+		if t.Labels == nil {
+			t.Labels = &mesos.Labels{}
+		}
+		found := false
+		for i := range t.Labels.Labels {
+			if t.Labels.Labels[i].Key == ix.label {
+				v := owner
+				t.Labels.Labels[i].Value = &v
+				found = true
+				break
+			}
+		}
+		if !found {
+			v := owner
+			t.Labels.Labels = append(t.Labels.Labels, mesos.Label{Key: ix.label, Value: &v})
+		}
+	}
+}
+
+// Extract returns the owner named by the given labels.
+func (ix *Index) Extract(labels *mesos.Labels) (owner string, ok bool) {
+	for _, l := range labels.GetLabels() {
+		if l.Key == ix.label {
+			return l.GetValue(), true
+		}
+	}
+	return "", false
+}
+
+// Record maps the task with the given ID to the given owner.
+func (ix *Index) Record(id mesos.TaskID, owner string) {
+	ix.m.Lock()
+	defer ix.m.Unlock()
+	ix.forget(id)
+	ix.owners[id] = owner
+	ids, ok := ix.byOwner[owner]
+	if !ok {
+		ids = make(map[mesos.TaskID]struct{})
+		ix.byOwner[owner] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+// Forget removes the task with the given ID from the index.
+func (ix *Index) Forget(id mesos.TaskID) {
+	ix.m.Lock()
+	defer ix.m.Unlock()
+	ix.forget(id)
+}
+
+// forget removes a task from the index, which must be locked.
+func (ix *Index) forget(id mesos.TaskID) {
+	owner, ok := ix.owners[id]
+	if !ok {
+		return
+	}
+	delete(ix.owners, id)
+	if ids := ix.byOwner[owner]; len(ids) > 1 {
+		delete(ids, id)
+	} else {
+		delete(ix.byOwner, owner)
+	}
+}
+
+// Owner returns the owner of the task with the given ID.
+func (ix *Index) Owner(id mesos.TaskID) (owner string, ok bool) {
+	ix.m.RLock()
+	defer ix.m.RUnlock()
+	owner, ok = ix.owners[id]
+	return
+}
+
+// Tasks returns the IDs of the tasks of the given owner, ordered by value.
+func (ix *Index) Tasks(owner string) []mesos.TaskID {
+	ix.m.RLock()
+	result := make([]mesos.TaskID, 0, len(ix.byOwner[owner]))
+	for id := range ix.byOwner[owner] {
+		result = append(result, id)
+	}
+	ix.m.RUnlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Value < result[j].Value })
+	return result
+}
+
+// Owners returns the sorted names of the owners of indexed tasks.
+func (ix *Index) Owners() []string {
+	ix.m.RLock()
+	result := make([]string, 0, len(ix.byOwner))
+	for owner := range ix.byOwner {
+		result = append(result, owner)
+	}
+	ix.m.RUnlock()
+	sort.Strings(result)
+	return result
+}
+
+// Recover records the owners of the given non-terminal tasks, e.g. as reported by the GET_TASKS call of
+// the master's operator API following a scheduler failover. Tasks without an owner label are ignored.
+func (ix *Index) Recover(ts ...mesos.Task) {
+	for i := range ts {
+		if tasks.IsTerminal(ts[i].GetState()) {
+			continue
+		}
+		if owner, ok := ix.Extract(ts[i].Labels); ok {
+			ix.Record(ts[i].TaskID, owner)
+		}
+	}
+}
+
+// CallRule returns a Rule that records the owners of the tasks that are launched by successful ACCEPT
+// calls; tasks without an owner label are ignored.
+func (ix *Index) CallRule() callrules.Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if err == nil && c.GetType() == scheduler.Call_ACCEPT {
+			// the mutator only reads the tasks of the call, it's safe to apply to the original
+			callrules.EachLaunchedTask(func(t *mesos.TaskInfo) {
+				if owner, ok := ix.Extract(t.Labels); ok {
+					ix.Record(t.TaskID, owner)
+				}
+			})(c)
+		}
+		return ctx, c, r, err
+	}
+}
+
+// EventRule returns a Rule that associates the owner of the task that's reported by an UPDATE event with
+// the context of the rest of the chain (see FromContext), and forgets tasks that have reached a terminal
+// state once the rest of the chain has been executed.
+func (ix *Index) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if e.GetType() != scheduler.Event_UPDATE || e.Update == nil {
+			return ch(ctx, e, err)
+		}
+		// the rest of the chain may drop, or replace, the event
+		status := e.GetUpdate().GetStatus()
+		id := status.TaskID
+		if owner, ok := ix.Owner(id); ok {
+			ctx = NewContext(ctx, owner)
+		}
+		ctx, e, err = ch(ctx, e, err)
+		if tasks.IsTerminal(status.GetState()) {
+			ix.Forget(id)
+		}
+		return ctx, e, err
+	}
+}
+
+// OwnedBy returns an EventPredicate that's true for UPDATE events that report a task of the given owner,
+// according to the context (see EventRule); events of other types are always true. For example, to
+// dispatch the status updates of an application to its handler:
+//
+//	eventrules.New(ix.EventRule(), eventrules.Filter(ownership.OwnedBy("app"), nil), appRule)
+func OwnedBy(owner string) eventrules.EventPredicate {
+	return func(ctx context.Context, e *scheduler.Event) bool {
+		if e.GetType() != scheduler.Event_UPDATE {
+			return true
+		}
+		o, ok := FromContext(ctx)
+		return ok && o == owner
+	}
+}
+
+// NewContext returns a context that carries the given owner.
+func NewContext(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// FromContext returns the owner carried by the context, if any.
+func FromContext(ctx context.Context) (owner string, ok bool) {
+	owner, ok = ctx.Value(ownerKey{}).(string)
+	return
+}
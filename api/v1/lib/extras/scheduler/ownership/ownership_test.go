@@ -0,0 +1,115 @@
+package ownership
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func task(id string) mesos.TaskInfo { return mesos.TaskInfo{TaskID: mesos.TaskID{Value: id}} }
+
+func update(id string, st mesos.TaskState) *scheduler.Event {
+	return &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{
+		Status: mesos.TaskStatus{TaskID: mesos.TaskID{Value: id}, State: st.Enum()},
+	}}
+}
+
+func TestIndex(t *testing.T) {
+	ix := NewIndex(Label("app"))
+	t1, t2, t3, t4 := task("1"), task("2"), task("3"), task("4")
+	ix.Stamp("web", &t1, &t2)
+	ix.Stamp("db", &t3)
+	ix.Stamp("web", &t3) // overwrites
+	if owner, ok := ix.Extract(t3.Labels); !ok || owner != "web" || len(t3.Labels.Labels) != 1 {
+		t.Fatalf("unexpected labels %v", t3.Labels.Format())
+	}
+	ix.Stamp("db", &t3)
+
+	var (
+		callErr error
+		rule    = ix.CallRule().Caller(calls.CallerFunc(func(context.Context, *scheduler.Call) (mesos.Response, error) {
+			return nil, callErr
+		}))
+		accept = func(ts ...mesos.TaskInfo) *scheduler.Call {
+			return calls.Accept(calls.OfferOperations{calls.OpLaunch(ts...)}.WithOffers(mesos.OfferID{Value: "o"}))
+		}
+	)
+	callErr = errors.New("failed")
+	rule.Call(context.Background(), accept(t1))
+	if _, ok := ix.Owner(t1.TaskID); ok {
+		t.Fatal("unexpected owner of a task that failed to launch")
+	}
+	callErr = nil
+	rule.Call(context.Background(), accept(t1, t2, t3, t4))
+	if got := ix.Owners(); !reflect.DeepEqual(got, []string{"db", "web"}) {
+		t.Fatalf("unexpected owners %v", got)
+	}
+	if got := ix.Tasks("web"); !reflect.DeepEqual(got, []mesos.TaskID{t1.TaskID, t2.TaskID}) {
+		t.Fatalf("unexpected tasks %v", got)
+	}
+	if _, ok := ix.Owner(t4.TaskID); ok {
+		t.Fatal("unexpected owner of an unlabeled task")
+	}
+
+	// status updates are dispatched to the owning application
+	var (
+		handled []string
+		app     = func(name string) eventrules.Rule {
+			return eventrules.New(
+				eventrules.Filter(OwnedBy(name), nil),
+				func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+					handled = append(handled, name+":"+e.GetUpdate().GetStatus().TaskID.Value)
+					return ch(ctx, e, err)
+				},
+			).Eval
+		}
+		web, db  = app("web"), app("db")
+		dispatch = eventrules.New(
+			ix.EventRule(),
+			func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+				web(ctx, e, err, eventrules.ChainIdentity)
+				db(ctx, e, err, eventrules.ChainIdentity)
+				return ch(ctx, e, err)
+			},
+		)
+	)
+	for _, e := range []*scheduler.Event{
+		update("1", mesos.TASK_RUNNING),
+		update("3", mesos.TASK_FINISHED),
+		update("4", mesos.TASK_RUNNING),
+	} {
+		dispatch.Eval(context.Background(), e, nil, eventrules.ChainIdentity)
+	}
+	if want := []string{"web:1", "db:3"}; !reflect.DeepEqual(handled, want) {
+		t.Fatalf("expected handled updates %v instead of %v", want, handled)
+	}
+	if got := ix.Owners(); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Fatalf("expected the terminal task to be forgotten, instead of owners %v", got)
+	}
+
+	// tasks are forgotten even if the rest of the chain drops the event
+	drop := eventrules.New(ix.EventRule(), func(ctx context.Context, _ *scheduler.Event, err error, _ eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		return ctx, nil, err
+	})
+	drop.Eval(context.Background(), update("1", mesos.TASK_KILLED), nil, eventrules.ChainIdentity)
+	if got := ix.Owners(); len(got) != 1 || len(ix.Tasks("web")) != 1 {
+		t.Fatalf("expected the killed task to be forgotten, instead of owners %v", got)
+	}
+
+	// the index is rebuilt from the tasks that the master reports
+	ix = NewIndex(Label("app"))
+	ix.Recover(
+		mesos.Task{TaskID: t1.TaskID, State: mesos.TASK_RUNNING.Enum(), Labels: t1.Labels},
+		mesos.Task{TaskID: t3.TaskID, State: mesos.TASK_FAILED.Enum(), Labels: t3.Labels},
+		mesos.Task{TaskID: t4.TaskID, State: mesos.TASK_RUNNING.Enum()},
+	)
+	if got := ix.Tasks("web"); !reflect.DeepEqual(got, []mesos.TaskID{t1.TaskID}) || len(ix.Owners()) != 1 {
+		t.Fatalf("unexpected recovered tasks %v, owners %v", got, ix.Owners())
+	}
+}
@@ -0,0 +1,194 @@
+// Package recording captures the calls issued by, and the events received by, a scheduler to a RecordIO
+// stream so that the event stream may be replayed offline: for debugging, or as a regression test that's
+// derived from a production capture.
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	"github.com/mesos/mesos-go/api/v1/lib/recordio"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+// Kind identifies the type of object captured by a Record.
+type Kind string
+
+const (
+	KindCall  = Kind("call")
+	KindEvent = Kind("event")
+)
+
+// Record is a single entry of a recording; each record occupies one RecordIO frame and is JSON encoded.
+type Record struct {
+	Time time.Time `json:"time"`
+	Kind Kind      `json:"kind"`
+	// Data is the protobuf encoding of a scheduler.Call or scheduler.Event, according to Kind.
+	Data []byte `json:"data"`
+}
+
+// Call decodes the call captured by the record.
+func (r *Record) Call() (*scheduler.Call, error) {
+	if r.Kind != KindCall {
+		return nil, fmt.Errorf("record of kind %q is not a call", r.Kind)
+	}
+	var c scheduler.Call
+	return &c, c.Unmarshal(r.Data)
+}
+
+// Event decodes the event captured by the record.
+func (r *Record) Event() (*scheduler.Event, error) {
+	if r.Kind != KindEvent {
+		return nil, fmt.Errorf("record of kind %q is not an event", r.Kind)
+	}
+	var e scheduler.Event
+	return &e, e.Unmarshal(r.Data)
+}
+
+// Recorder writes records to a RecordIO stream. Recorder funcs are safe to invoke concurrently.
+type Recorder struct {
+	clock func() time.Time
+
+	m   sync.Mutex
+	w   *recordio.Writer
+	err error
+}
+
+// NewRecorder returns a Recorder that writes to the given io.Writer.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{clock: time.Now, w: recordio.NewWriter(w)}
+}
+
+// Err returns the first error encountered while recording, if any. Once an error has been encountered
+// the recorder stops writing records.
+func (r *Recorder) Err() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(kind Kind, m interface {
+	Marshal() ([]byte, error)
+}) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(&Record{Time: r.clock(), Kind: kind, Data: data})
+	if err != nil {
+		return err
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.err == nil {
+		r.err = r.w.WriteFrame(b)
+	}
+	return r.err
+}
+
+// RecordCall appends the given call to the recording.
+func (r *Recorder) RecordCall(c *scheduler.Call) error { return r.record(KindCall, c) }
+
+// RecordEvent appends the given event to the recording.
+func (r *Recorder) RecordEvent(e *scheduler.Event) error { return r.record(KindEvent, e) }
+
+// Caller returns a Caller that records every call that it delegates to the given Caller, as well as every
+// event that's decoded from the responses of such calls. Recording errors never cause calls to fail;
+// check Err instead.
+func (r *Recorder) Caller(caller calls.Caller) calls.Caller {
+	return calls.CallerFunc(func(ctx context.Context, c *scheduler.Call) (mesos.Response, error) {
+		_ = r.RecordCall(c)
+		resp, err := caller.Call(ctx, c)
+		if resp != nil {
+			delegate := resp
+			resp = &mesos.ResponseWrapper{
+				Response: delegate,
+				Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+					err := delegate.Decode(u)
+					if e, ok := u.(*scheduler.Event); ok && err == nil {
+						_ = r.RecordEvent(e)
+					}
+					return err
+				}),
+			}
+		}
+		return resp, err
+	})
+}
+
+// Reader reads records from a RecordIO stream.
+type Reader struct {
+	r framing.Reader
+}
+
+// NewReader returns a Reader for the recording that's read from the given io.Reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: recordio.NewReader(r)}
+}
+
+// Next returns the next record of the recording, or else io.EOF once all records have been read.
+func (r *Reader) Next() (rec Record, err error) {
+	b, err := r.r.ReadFrame()
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(b, &rec)
+	return
+}
+
+// Replay feeds the events of the recording to the given handler, in order; recorded calls are skipped.
+// If speed is greater than zero then the timing of the recording is reproduced, scaled by speed: e.g. a
+// speed of 2 replays events twice as fast as they were recorded. Otherwise events are replayed as quickly
+// as the handler consumes them. Replay returns nil once the end of the recording is reached, otherwise the
+// first error returned by the handler (or encountered while reading the recording).
+func Replay(ctx context.Context, r io.Reader, handler events.Handler, speed float64) error {
+	var (
+		reader = NewReader(r)
+		last   time.Time
+	)
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Kind != KindEvent {
+			continue
+		}
+		e, err := rec.Event()
+		if err != nil {
+			return err
+		}
+		if speed > 0 && !last.IsZero() {
+			if d := time.Duration(float64(rec.Time.Sub(last)) / speed); d > 0 {
+				t := time.NewTimer(d)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				case <-t.C:
+				}
+			}
+		}
+		last = rec.Time
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err = handler.HandleEvent(ctx, e); err != nil {
+			return err
+		}
+	}
+}
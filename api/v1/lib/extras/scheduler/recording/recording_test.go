@@ -0,0 +1,87 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		rec    = NewRecorder(&buf)
+		ctx    = context.Background()
+		stream = []scheduler.Event{
+			{Type: scheduler.Event_SUBSCRIBED, Subscribed: &scheduler.Event_Subscribed{
+				FrameworkID: &mesos.FrameworkID{Value: "fw"},
+			}},
+			{Type: scheduler.Event_HEARTBEAT},
+		}
+		caller = calls.CallerFunc(func(_ context.Context, _ *scheduler.Call) (mesos.Response, error) {
+			i := 0
+			return &mesos.ResponseWrapper{
+				Closer: mesos.CloseFunc(func() error { return nil }),
+				Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+					if i >= len(stream) {
+						return io.EOF
+					}
+					*(u.(*scheduler.Event)) = stream[i]
+					i++
+					return nil
+				}),
+			}, nil
+		})
+	)
+	resp, err := rec.Caller(caller).Call(ctx, calls.Subscribe(&mesos.FrameworkInfo{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var e scheduler.Event
+		if err = resp.Decode(&e); err != nil {
+			break
+		}
+	}
+	if err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = rec.Err(); err != nil {
+		t.Fatalf("unexpected recording error: %v", err)
+	}
+
+	recording := buf.Bytes()
+	var kinds []Kind
+	for r := NewReader(bytes.NewReader(recording)); ; {
+		x, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		kinds = append(kinds, x.Kind)
+	}
+	if expected := []Kind{KindCall, KindEvent, KindEvent}; !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("expected records %v instead of %v", expected, kinds)
+	}
+
+	var replayed []scheduler.Event
+	err = Replay(ctx, bytes.NewReader(recording), events.HandlerFunc(func(_ context.Context, e *scheduler.Event) error {
+		replayed = append(replayed, *e)
+		return nil
+	}), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed, stream) {
+		t.Fatalf("expected replayed events %+v instead of %+v", stream, replayed)
+	}
+}
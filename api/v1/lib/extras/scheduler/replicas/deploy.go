@@ -0,0 +1,164 @@
+package replicas
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+)
+
+type (
+	// Strategy governs the replacement of the instances of a previous generation of the template by the
+	// instances of the current generation, see Deploy. An instance is available if its task is running
+	// and hasn't been reported as unhealthy; as per HealthGated, instances of the current generation may
+	// be required to be reported as healthy.
+	Strategy struct {
+		// MaxUnavailable is the number of replicas, below the desired number, that may be unavailable
+		// while the instances of the previous generation are killed. If both MaxUnavailable and MaxSurge
+		// are zero then MaxUnavailable is taken to be one.
+		MaxUnavailable int
+
+		// MaxSurge is the number of instances, above the desired number of replicas, that may be launched
+		// before the instances of the previous generation are killed.
+		MaxSurge int
+
+		// HealthGated, if true, requires instances of the current generation to be reported as healthy
+		// (by their health checks) before they're available.
+		HealthGated bool
+
+		// BlueGreen, if true, launches a complete set of instances of the current generation alongside
+		// those of the previous generation, which are killed altogether once every instance of the current
+		// generation is available: i.e. the current generation is promoted at once. MaxUnavailable and
+		// MaxSurge are ignored.
+		BlueGreen bool
+	}
+
+	// Deployment reports the progress of the most recent deployment.
+	Deployment struct {
+		Generation int
+		Strategy   Strategy
+		Updated    int // the instances of the current generation
+		Available  int // the available instances of the current generation
+		Outdated   int // the instances of previous generations
+		Done       bool
+	}
+)
+
+// RollingUpdate returns a Strategy that replaces instances a few at a time, as per the given bounds.
+func RollingUpdate(maxUnavailable, maxSurge int) Strategy {
+	return Strategy{MaxUnavailable: maxUnavailable, MaxSurge: maxSurge}
+}
+
+// BlueGreen returns a Strategy that promotes a complete set of instances, once they're all healthy.
+func BlueGreen() Strategy {
+	return Strategy{BlueGreen: true, HealthGated: true}
+}
+
+// Deploy replaces the template of the Controller: the instances of the previous template are replaced by
+// instances of the given template, as per the given strategy, as offers and status updates are received.
+// Deploying the previous template again (e.g. to roll back) replaces the instances of the given template
+// likewise. The strategy also governs the replacements of subsequent deployments, until replaced.
+func (c *Controller) Deploy(ctx context.Context, t *tasks.Template, s Strategy) error {
+	c.m.Lock()
+	c.template = t
+	c.strategy = s
+	c.generation++
+	killed := c.replace()
+	c.m.Unlock()
+	return c.kill(ctx, killed...)
+}
+
+// Deployment returns the progress of the most recent deployment.
+func (c *Controller) Deployment() Deployment {
+	c.m.Lock()
+	defer c.m.Unlock()
+	d := Deployment{Generation: c.generation, Strategy: c.strategy}
+	for _, inst := range c.instances {
+		if inst.Generation != c.generation {
+			d.Outdated++
+			continue
+		}
+		d.Updated++
+		if c.available(inst) {
+			d.Available++
+		}
+	}
+	d.Done = d.Outdated == 0 && d.Available == c.replicas
+	return d
+}
+
+// surge returns the number of instances that may be launched above the desired number of replicas; the
+// caller must hold the lock.
+func (c *Controller) surge() int {
+	if c.strategy.BlueGreen {
+		return c.replicas
+	}
+	return c.strategy.MaxSurge
+}
+
+// available returns true if the given instance is available, as per the strategy; the caller must hold
+// the lock.
+func (c *Controller) available(inst *Instance) bool {
+	if inst.State != mesos.TASK_RUNNING {
+		return false
+	}
+	if inst.Healthy != nil {
+		return *inst.Healthy
+	}
+	return !c.strategy.HealthGated || inst.Generation != c.generation
+}
+
+// replace removes, and returns, the instances of previous generations that may be killed as per the
+// strategy. Instances whose index has an available instance of the current generation are killed
+// first, then unavailable instances, and then the others in order of index. The caller must hold the
+// lock.
+func (c *Controller) replace() []Instance {
+	var (
+		outdated  []*Instance
+		available int
+		current   int
+		replaced  = make(map[int]bool)
+	)
+	for _, inst := range c.instances {
+		ok := c.available(inst)
+		if ok {
+			available++
+		}
+		if inst.Generation != c.generation {
+			outdated = append(outdated, inst)
+		} else if ok {
+			current++
+			replaced[inst.Index] = true
+		}
+	}
+	if len(outdated) == 0 || (c.strategy.BlueGreen && current < c.replicas) {
+		return nil
+	}
+	sort.Slice(outdated, func(i, j int) bool {
+		a, b := outdated[i], outdated[j]
+		if replaced[a.Index] != replaced[b.Index] {
+			return replaced[a.Index]
+		}
+		if x, y := c.available(a), c.available(b); x != y {
+			return !x
+		}
+		return a.Index < b.Index
+	})
+	unavailable := c.strategy.MaxUnavailable
+	if unavailable == 0 && c.strategy.MaxSurge == 0 {
+		unavailable = 1
+	}
+	var killed []Instance
+	for _, inst := range outdated {
+		if !c.strategy.BlueGreen && c.available(inst) {
+			if available-1 < c.replicas-unavailable {
+				continue
+			}
+			available--
+		}
+		killed = append(killed, *inst)
+		delete(c.instances, inst.TaskID)
+	}
+	return killed
+}
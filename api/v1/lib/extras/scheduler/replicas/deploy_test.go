@@ -0,0 +1,152 @@
+package replicas_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/replicas"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/sim"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// observer drives a controller, reporting the health of running tasks as per the healthy func (as the
+// health checks of an executor would, upon changes of health), and records the extremes of the instances
+// of the controller.
+type observer struct {
+	c       *replicas.Controller
+	healthy func(generation int) *bool
+	// the least number of running instances, and the greatest number of instances, once converged
+	minRunning, maxInstances int
+	converged                bool
+}
+
+func (o *observer) HandleEvent(ctx context.Context, e *scheduler.Event) error {
+	if err := o.c.HandleEvent(ctx, e); err != nil {
+		return err
+	}
+	for _, inst := range o.c.Instances() {
+		if inst.State != mesos.TASK_RUNNING || (inst.Healthy != nil && *inst.Healthy) {
+			continue
+		}
+		if h := o.healthy(inst.Generation); h != nil && (inst.Healthy == nil || *h) {
+			s := mesos.TaskStatus{TaskID: inst.TaskID, AgentID: &inst.AgentID, State: mesos.TASK_RUNNING.Enum(), Healthy: h}
+			if err := o.c.HandleEvent(ctx, &scheduler.Event{
+				Type:   scheduler.Event_UPDATE,
+				Update: &scheduler.Event_Update{Status: s},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	o.converged = o.converged || o.c.Converged()
+	if !o.converged {
+		return nil
+	}
+	var (
+		instances = o.c.Instances()
+		running   = 0
+	)
+	for _, inst := range instances {
+		if inst.State == mesos.TASK_RUNNING && (inst.Healthy == nil || *inst.Healthy) {
+			running++
+		}
+	}
+	if running < o.minRunning {
+		o.minRunning = running
+	}
+	if len(instances) > o.maxInstances {
+		o.maxInstances = len(instances)
+	}
+	return nil
+}
+
+func versioned(v string) *tasks.Template {
+	t := template()
+	t.Prototype.Command.Value = mesos.String("serve --version=" + v + " --port=${PORT0}")
+	return t
+}
+
+func deploy(t *testing.T, strategy replicas.Strategy, healthy func(int) *bool) (*observer, *sim.Report) {
+	var (
+		ctx = context.Background()
+		s   = cluster(3, sim.TaskDuration(nil))
+		o   = &observer{c: replicas.New(s, versioned("1"), 4), healthy: healthy, minRunning: 4}
+	)
+	if _, err := s.Run(ctx, o, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !o.converged {
+		t.Fatalf("expected the controller to converge: %v", o.c.Instances())
+	}
+	if err := o.c.Deploy(ctx, versioned("2"), strategy); err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.Run(ctx, o, 20*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o, report
+}
+
+func healthyAlways(int) *bool { return mesos.Bool(true) }
+
+func TestRollingUpdate(t *testing.T) {
+	for _, tc := range []struct {
+		strategy                 replicas.Strategy
+		minRunning, maxInstances int
+	}{
+		{replicas.RollingUpdate(1, 0), 3, 4},
+		{replicas.RollingUpdate(2, 0), 2, 4},
+		{replicas.RollingUpdate(0, 1), 4, 5},
+		{replicas.Strategy{MaxSurge: 1, HealthGated: true}, 4, 5},
+	} {
+		o, report := deploy(t, tc.strategy, healthyAlways)
+		d := o.c.Deployment()
+		if !d.Done || d.Generation != 1 || d.Updated != 4 || !o.c.Converged() {
+			t.Fatalf("%+v: expected the deployment to complete: %+v\n%v", tc.strategy, d, report)
+		}
+		if report.Killed != 4 || report.Running != 4 {
+			t.Fatalf("%+v: expected the instances to be replaced: %v", tc.strategy, report)
+		}
+		if o.minRunning != tc.minRunning || o.maxInstances != tc.maxInstances {
+			t.Fatalf("%+v: expected between %d and %d instances, instead of %d and %d", tc.strategy,
+				tc.minRunning, tc.maxInstances, o.minRunning, o.maxInstances)
+		}
+	}
+}
+
+func TestHealthGated(t *testing.T) {
+	// the instances of the new generation never become healthy
+	o, report := deploy(t, replicas.Strategy{MaxUnavailable: 1, HealthGated: true}, func(g int) *bool {
+		return mesos.Bool(g == 0)
+	})
+	d := o.c.Deployment()
+	if d.Done || d.Updated != 1 || d.Available != 0 || d.Outdated != 3 {
+		t.Fatalf("expected the deployment to stall: %+v", d)
+	}
+	if report.Killed != 1 || o.minRunning != 3 {
+		t.Fatalf("expected a single instance to be replaced: %v", report)
+	}
+}
+
+func TestBlueGreen(t *testing.T) {
+	var delayed int
+	o, report := deploy(t, replicas.BlueGreen(), func(g int) *bool {
+		if g == 1 {
+			// health checks of the new generation fail at first
+			if delayed++; delayed <= 2 {
+				return mesos.Bool(false)
+			}
+		}
+		return mesos.Bool(true)
+	})
+	if d := o.c.Deployment(); !d.Done {
+		t.Fatalf("expected the deployment to complete: %+v", d)
+	}
+	if report.Killed != 4 || o.maxInstances != 8 || o.minRunning != 4 {
+		t.Fatalf("expected the new instances to be promoted at once: %d, %d\n%v", o.minRunning, o.maxInstances, report)
+	}
+}
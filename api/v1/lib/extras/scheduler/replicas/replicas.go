@@ -0,0 +1,442 @@
+// Package replicas runs a desired number of instances (replicas) of a task template: a Controller
+// launches instances upon the offers that hold them, replaces instances whose tasks terminate (or that a
+// relaunch policy gives up on), and kills surplus instances when scaled down. It's composed of the building
+// blocks of the other extras/scheduler packages: templates and the registry of the tasks package, the
+// offer filters and launch planner of the offers package, and explicit reconciliation of the tracked tasks
+// upon (re)subscription.
+package replicas
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// DefaultRefuseSeconds is the period for which the resources of unused offers aren't re-offered.
+const DefaultRefuseSeconds = 5 * time.Second
+
+type (
+	// Instance is a replica: the most recently launched task of an index in [0, replicas), of some
+	// generation of the template (see Deploy). During a deployment an index may have an instance of the
+	// previous generation as well as one of the current generation.
+	Instance struct {
+		Index      int
+		Generation int
+		TaskID     mesos.TaskID
+		AgentID    mesos.AgentID
+		Hostname   string
+		State      mesos.TaskState
+		// Healthy is the health that was most recently reported for the task, if any.
+		Healthy *bool
+	}
+
+	// Placement decides whether the instance of the given index may be launched upon the given offer,
+	// given the instances that are already placed (including those that are placed upon the preceding
+	// offers of the same event); e.g. in order to spread instances across agents, or racks.
+	Placement func(o *mesos.Offer, index int, placed []Instance) bool
+
+	// Option is a functional option for a Controller; it returns an "undo" option when applied.
+	Option func(*Controller) Option
+
+	// Controller converges the instances of a template toward the desired number of replicas. A
+	// Controller is driven by the events of a subscription, see HandleEvent; Controller funcs are safe to
+	// invoke concurrently.
+	Controller struct {
+		caller    calls.Caller
+		template  *tasks.Template
+		registry  *tasks.Registry
+		planner   *offers.LaunchPlanner
+		filter    offers.Filter
+		placement Placement
+		policy    tasks.RelaunchPolicy
+		refuse    time.Duration
+
+		m          sync.Mutex
+		replicas   int
+		instances  map[mesos.TaskID]*Instance
+		launches   int
+		generation int
+		strategy   Strategy
+	}
+)
+
+// WithRegistry configures the registry that tracks the launched tasks, e.g. one that's shared with other
+// components of the framework; by default the Controller has a registry of its own.
+func WithRegistry(r *tasks.Registry) Option {
+	return func(c *Controller) Option {
+		old := c.registry
+		c.registry = r
+		return WithRegistry(old)
+	}
+}
+
+// WithPlanner configures a LaunchPlanner that coalesces the launches of instances. By default every offer
+// that's used is accepted via a separate ACCEPT call.
+func WithPlanner(p *offers.LaunchPlanner) Option {
+	return func(c *Controller) Option {
+		old := c.planner
+		c.planner = p
+		return WithPlanner(old)
+	}
+}
+
+// WithFilter configures a filter of the offers that instances may be launched upon; the others are
+// declined.
+func WithFilter(f offers.Filter) Option {
+	return func(c *Controller) Option {
+		old := c.filter
+		c.filter = f
+		return WithFilter(old)
+	}
+}
+
+// WithPlacement configures the placement of instances; by default an instance may be launched upon any
+// offer that holds it.
+func WithPlacement(p Placement) Option {
+	return func(c *Controller) Option {
+		old := c.placement
+		c.placement = p
+		return WithPlacement(old)
+	}
+}
+
+// WithRelaunchPolicy configures the policy that decides whether an instance, whose task is reported in a
+// non-terminal state, is replaced (in which case the task is killed); defaults to
+// tasks.DefaultRelaunchPolicy. Instances whose tasks reach a terminal state are always replaced.
+func WithRelaunchPolicy(p tasks.RelaunchPolicy) Option {
+	return func(c *Controller) Option {
+		old := c.policy
+		c.policy = p
+		return WithRelaunchPolicy(old)
+	}
+}
+
+// RefuseSeconds configures the period for which the resources of unused offers (and of the unused
+// resources of accepted offers) aren't re-offered; defaults to DefaultRefuseSeconds.
+func RefuseSeconds(d time.Duration) Option {
+	return func(c *Controller) Option {
+		old := c.refuse
+		c.refuse = d
+		return RefuseSeconds(old)
+	}
+}
+
+// MaxPerAgent returns a Placement that places at most n instances upon any one agent.
+func MaxPerAgent(n int) Placement {
+	return func(o *mesos.Offer, _ int, placed []Instance) bool {
+		k := 0
+		for i := range placed {
+			if placed[i].AgentID == o.AgentID {
+				k++
+			}
+		}
+		return k < n
+	}
+}
+
+// MaxPerAttribute returns a Placement that places at most n instances upon the agents that share a value
+// of the named attribute (e.g. "rack"), as recorded by the given index: an anti-affinity constraint. The
+// placement records the attributes of the offers that it evaluates; offers without the attribute aren't
+// used. Instances of every generation are counted.
+func MaxPerAttribute(x *offers.AttributeIndex, name string, n int) Placement {
+	return func(o *mesos.Offer, _ int, placed []Instance) bool {
+		x.Add(*o)
+		value, ok := x.Value(o.AgentID, name)
+		if !ok {
+			return false
+		}
+		agents := make([]mesos.AgentID, len(placed))
+		for i := range placed {
+			agents[i] = placed[i].AgentID
+		}
+		return x.Count(name, value, agents...) < n
+	}
+}
+
+// All returns a Placement that places an instance upon an offer only if all of the given placements do.
+func All(ps ...Placement) Placement {
+	return func(o *mesos.Offer, index int, placed []Instance) bool {
+		for _, p := range ps {
+			if !p(o, index, placed) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// New returns a Controller that runs the given number of replicas of the given template, via the given
+// caller. The task IDs of instances default to the name of the template's task, suffixed by the index of
+// the instance and a sequence number of the launch (so that replacements are distinguished); the
+// sequence number is available to the placeholders of the template as ${LAUNCH}, and the generation of
+// the template as ${GENERATION}.
+func New(caller calls.Caller, t *tasks.Template, replicas int, opts ...Option) *Controller {
+	c := &Controller{
+		caller:    caller,
+		template:  t,
+		policy:    tasks.DefaultRelaunchPolicy,
+		refuse:    DefaultRefuseSeconds,
+		replicas:  replicas,
+		instances: make(map[mesos.TaskID]*Instance),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	if c.registry == nil {
+		c.registry = tasks.NewRegistry(nil)
+	}
+	return c
+}
+
+// Registry returns the registry of the tasks that the Controller has launched.
+func (c *Controller) Registry() *tasks.Registry { return c.registry }
+
+// HandleEvent implements events.Handler for Controller: instances are reconciled upon SUBSCRIBED, launched
+// upon OFFERS, and replaced as per UPDATE events. Status updates aren't acknowledged; see
+// controller.AckStatusUpdates.
+func (c *Controller) HandleEvent(ctx context.Context, e *scheduler.Event) error {
+	switch e.GetType() {
+	case scheduler.Event_SUBSCRIBED:
+		return c.Reconcile(ctx)
+	case scheduler.Event_OFFERS:
+		return c.handleOffers(ctx, e.GetOffers().GetOffers())
+	case scheduler.Event_UPDATE:
+		return c.handleUpdate(ctx, e.GetUpdate().GetStatus())
+	}
+	return nil
+}
+
+// Reconcile requests explicit reconciliation of the tracked tasks, see tasks.Registry.ReconcileTasks;
+// tasks that the master doesn't know of (e.g. because their launch failed) are reported as lost, and so
+// their instances are replaced.
+func (c *Controller) Reconcile(ctx context.Context) error {
+	return calls.CallNoData(ctx, c.caller, calls.Reconcile(c.registry.ReconcileTasks()))
+}
+
+// Scale changes the desired number of replicas; the tasks of surplus instances (of the highest indexes)
+// are killed.
+func (c *Controller) Scale(ctx context.Context, replicas int) error {
+	c.m.Lock()
+	c.replicas = replicas
+	var surplus []Instance
+	for id, inst := range c.instances {
+		if inst.Index >= replicas {
+			surplus = append(surplus, *inst)
+			delete(c.instances, id)
+		}
+	}
+	surplus = append(surplus, c.replace()...)
+	c.m.Unlock()
+	return c.kill(ctx, surplus...)
+}
+
+// Replicas returns the desired number of replicas.
+func (c *Controller) Replicas() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.replicas
+}
+
+// Instances returns the current instances, ordered by index (and then generation).
+func (c *Controller) Instances() []Instance {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.placed()
+}
+
+// Converged returns true if an instance of the current generation of every index is running, and there
+// are no other instances.
+func (c *Controller) Converged() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	running := 0
+	for _, inst := range c.instances {
+		if inst.State == mesos.TASK_RUNNING && inst.Generation == c.generation {
+			running++
+		}
+	}
+	return running == c.replicas && len(c.instances) == c.replicas
+}
+
+// placed returns the current instances, ordered by index; the caller must hold the lock.
+func (c *Controller) placed() []Instance {
+	result := make([]Instance, 0, len(c.instances))
+	for _, inst := range c.instances {
+		result = append(result, *inst)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Index != result[j].Index {
+			return result[i].Index < result[j].Index
+		}
+		return result[i].Generation < result[j].Generation
+	})
+	return result
+}
+
+// missing returns the indexes that lack an instance of the current generation, as many as may be
+// launched as per the surge of the strategy: indexes that lack any instance come first, and then those
+// with an instance of a previous generation, each in order. The caller must hold the lock.
+func (c *Controller) missing() []int {
+	have := make(map[int]int, len(c.instances)) // 1: of a previous generation, 2: of the current one
+	for _, inst := range c.instances {
+		if inst.Generation == c.generation {
+			have[inst.Index] = 2
+		} else if have[inst.Index] == 0 {
+			have[inst.Index] = 1
+		}
+	}
+	var result, outdated []int
+	for i := 0; i < c.replicas; i++ {
+		switch have[i] {
+		case 0:
+			result = append(result, i)
+		case 1:
+			outdated = append(outdated, i)
+		}
+	}
+	result = append(result, outdated...)
+	if n := c.replicas + c.surge() - len(c.instances); n < len(result) {
+		if n < 0 {
+			n = 0
+		}
+		result = result[:n]
+	}
+	return result
+}
+
+// instantiate returns the next launch of the instance of the given index upon the given offer; the caller
+// must hold the lock.
+func (c *Controller) instantiate(o *mesos.Offer, index int, available mesos.Resources) (mesos.TaskInfo, mesos.Resources, error) {
+	t := *c.template
+	t.Vars = make(map[string]string, len(c.template.Vars)+2)
+	for k, v := range c.template.Vars {
+		t.Vars[k] = v
+	}
+	t.Vars["LAUNCH"] = strconv.Itoa(c.launches)
+	t.Vars["GENERATION"] = strconv.Itoa(c.generation)
+	if t.Prototype.TaskID.Value == "" {
+		t.Prototype.TaskID.Value = t.Prototype.Name + "-${INDEX}-${LAUNCH}"
+	}
+	task, remaining, err := t.Instantiate(o, index, available)
+	if err == nil {
+		c.launches++
+	}
+	return task, remaining, err
+}
+
+func (c *Controller) handleOffers(ctx context.Context, os []mesos.Offer) (err error) {
+	type launch struct {
+		offer mesos.Offer
+		tasks []mesos.TaskInfo
+	}
+	var (
+		launches []launch
+		unused   []mesos.OfferID
+	)
+	c.m.Lock()
+	missing := c.missing()
+	for i := range os {
+		var (
+			o         = &os[i]
+			launched  []mesos.TaskInfo
+			remaining = mesos.Resources(o.Resources)
+		)
+		if len(missing) > 0 && (c.filter == nil || c.filter.Accept(o)) {
+			for j := 0; j < len(missing); {
+				index := missing[j]
+				if c.placement != nil && !c.placement(o, index, c.placed()) {
+					j++
+					continue
+				}
+				task, rs, ierr := c.instantiate(o, index, remaining)
+				if ierr != nil {
+					if ierr != tasks.ErrInsufficientResources && err == nil {
+						err = ierr
+					}
+					break
+				}
+				remaining = rs
+				launched = append(launched, task)
+				c.instances[task.TaskID] = &Instance{
+					Index:      index,
+					Generation: c.generation,
+					TaskID:     task.TaskID,
+					AgentID:    task.AgentID,
+					Hostname:   o.Hostname,
+					State:      mesos.TASK_STAGING,
+				}
+				missing = append(missing[:j], missing[j+1:]...)
+			}
+		}
+		if len(launched) == 0 {
+			unused = append(unused, o.ID)
+			continue
+		}
+		launches = append(launches, launch{offer: *o, tasks: launched})
+	}
+	c.m.Unlock()
+
+	for _, l := range launches {
+		c.registry.Launched(l.tasks...)
+		if c.planner != nil {
+			c.planner.Launch(ctx, []mesos.Offer{l.offer}, l.tasks...)
+			continue
+		}
+		accept := calls.Accept(calls.OfferOperations{calls.OpLaunch(l.tasks...)}.WithOffers(l.offer.ID)).
+			With(calls.RefuseSeconds(c.refuse))
+		if cerr := calls.CallNoData(ctx, c.caller, accept); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if len(unused) > 0 {
+		decline := calls.Decline(unused...).With(calls.RefuseSeconds(c.refuse))
+		if cerr := calls.CallNoData(ctx, c.caller, decline); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (c *Controller) handleUpdate(ctx context.Context, s mesos.TaskStatus) error {
+	c.registry.Update(s)
+	c.m.Lock()
+	inst, ok := c.instances[s.TaskID]
+	if !ok {
+		c.m.Unlock()
+		return nil
+	}
+	inst.State = s.GetState()
+	if s.Healthy != nil {
+		healthy := *s.Healthy
+		inst.Healthy = &healthy
+	}
+	var killed []Instance
+	if tasks.IsTerminal(inst.State) {
+		delete(c.instances, s.TaskID)
+	} else if c.policy.Decide(&s) == tasks.ActionRelaunch {
+		delete(c.instances, s.TaskID)
+		killed = append(killed, *inst)
+	}
+	killed = append(killed, c.replace()...)
+	c.m.Unlock()
+	return c.kill(ctx, killed...)
+}
+
+func (c *Controller) kill(ctx context.Context, instances ...Instance) (err error) {
+	for _, inst := range instances {
+		if cerr := calls.CallNoData(ctx, c.caller, calls.Kill(inst.TaskID.Value, inst.AgentID.Value)); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package replicas_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/replicas"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/sim"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func cluster(agents int, opts ...sim.Option) *sim.Simulator {
+	var rs mesos.Resources
+	rs.Add(
+		resources.NewCPUs(4).Resource,
+		resources.NewMemory(4096).Resource,
+		resources.Build().Name(resources.NamePorts).Ranges(resources.BuildRanges().Span(31000, 31009).Ranges).Resource,
+	)
+	return sim.New([]sim.Profile{{Name: "agent", Count: agents, Resources: rs}}, opts...)
+}
+
+func template() *tasks.Template {
+	return &tasks.Template{
+		Prototype: mesos.TaskInfo{
+			Name:      "web",
+			Resources: mesos.Resources{resources.NewCPUs(1).Resource, resources.NewMemory(512).Resource},
+			Command:   &mesos.CommandInfo{Value: mesos.String("serve --port=${PORT0}")},
+		},
+		Ports: 1,
+	}
+}
+
+func TestController(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = cluster(3, sim.TaskDuration(nil))
+		c   = replicas.New(s, template(), 5, replicas.WithPlacement(replicas.MaxPerAgent(2)))
+	)
+	report, err := s.Run(ctx, c, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Converged() || report.Running != 5 {
+		t.Fatalf("expected 5 running instances: %v\n%v", c.Instances(), report)
+	}
+	perAgent := map[mesos.AgentID]int{}
+	for i, inst := range c.Instances() {
+		if inst.Index != i || inst.State != mesos.TASK_RUNNING {
+			t.Fatalf("unexpected instance: %+v", inst)
+		}
+		if perAgent[inst.AgentID]++; perAgent[inst.AgentID] > 2 {
+			t.Fatalf("expected at most 2 instances per agent: %v", c.Instances())
+		}
+	}
+	if id := c.Instances()[0].TaskID.Value; id != "web-0-0" {
+		t.Fatalf("unexpected task ID: %q", id)
+	}
+	if n := c.Registry().Len(); n != 5 {
+		t.Fatalf("expected 5 tracked tasks instead of %d", n)
+	}
+
+	if err = c.Scale(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if report, err = s.Run(ctx, c, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Converged() || report.Running != 2 || report.Killed != 3 {
+		t.Fatalf("expected surplus instances to be killed: %v\n%v", c.Instances(), report)
+	}
+	if n := c.Registry().Len(); n != 2 {
+		t.Fatalf("expected 2 tracked tasks instead of %d", n)
+	}
+}
+
+func TestControllerReplaces(t *testing.T) {
+	var (
+		ctx = context.Background()
+		// tasks terminate every few seconds, and some of them fail
+		s = cluster(2, sim.FailureRate(0.5), sim.TaskDuration(func(mesos.TaskInfo) time.Duration { return 3 * time.Second }))
+		c = replicas.New(s, template(), 4)
+	)
+	report, err := s.Run(ctx, c, 20*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Failed == 0 || report.Finished == 0 {
+		t.Fatalf("expected tasks to fail, and to finish: %v", report)
+	}
+	if report.Running != 4 || len(c.Instances()) != 4 {
+		t.Fatalf("expected terminated instances to be replaced: %v\n%v", c.Instances(), report)
+	}
+	if n := c.Registry().Len(); n != 4 {
+		t.Fatalf("expected 4 tracked tasks instead of %d", n)
+	}
+}
+
+func TestAntiAffinity(t *testing.T) {
+	var rs mesos.Resources
+	rs.Add(resources.NewCPUs(4).Resource, resources.NewMemory(4096).Resource)
+	var (
+		ctx = context.Background()
+		s   = sim.New([]sim.Profile{
+			{Name: "a", Count: 2, Resources: rs, Attributes: map[string]string{"rack": "a"}},
+			{Name: "b", Count: 2, Resources: rs, Attributes: map[string]string{"rack": "b"}},
+			{Name: "none", Count: 1, Resources: rs},
+		}, sim.TaskDuration(nil))
+		x    = offers.NewAttributeIndex()
+		tmpl = template()
+	)
+	tmpl.Ports = 0
+	tmpl.Prototype.Command.Value = mesos.String("serve")
+	c := replicas.New(s, tmpl, 3, replicas.WithPlacement(replicas.All(
+		replicas.MaxPerAttribute(x, "rack", 1),
+		replicas.MaxPerAgent(1),
+	)))
+	if _, err := s.Run(ctx, c, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	instances := c.Instances()
+	if len(instances) != 2 {
+		t.Fatalf("expected an instance per rack: %v", instances)
+	}
+	racks := map[string]bool{}
+	for _, inst := range instances {
+		rack, ok := x.Value(inst.AgentID, "rack")
+		if !ok || racks[rack] {
+			t.Fatalf("expected instances on distinct racks: %v", instances)
+		}
+		racks[rack] = true
+	}
+}
@@ -0,0 +1,305 @@
+// Package reservations converges the dynamic reservations of a framework toward a declared set: a Manager
+// plans RESERVE operations for the unreserved resources of offers, until the declared amounts are reserved,
+// and UNRESERVE operations for the offered resources of surplus (or undeclared) reservations. Plans may be
+// inspected without being applied, e.g. for a dry run. Reservations are reserved via the reservation
+// refinement format (see Resource.Reservations), and so frameworks require the RESERVATION_REFINEMENT
+// capability.
+package reservations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// ErrNotReconciled is returned when planning before the Manager has been reconciled with the master.
+var ErrNotReconciled = errors.New("reservations have not been reconciled")
+
+// epsilon is the smallest amount that's planned: Mesos scalars have a precision of three decimal places.
+const epsilon = 0.0005
+
+type (
+	// Reservation is a declared reservation: the given (cluster-wide) amounts of scalar resources,
+	// reserved for the given role, by the given principal, with the given labels. Reservations are
+	// identified by their role, principal, and labels (regardless of order).
+	Reservation struct {
+		Role      string
+		Principal string
+		Labels    *mesos.Labels
+		Amounts   map[string]float64 // by resource name
+	}
+
+	// Plan is a set of operations, for the resources of a single offer, that converge the reservations
+	// toward the declared set.
+	Plan struct {
+		AgentID   mesos.AgentID
+		Reserve   mesos.Resources
+		Unreserve mesos.Resources
+	}
+
+	// Manager plans the reservation of declared resources, and the unreservation of surplus resources.
+	// Only reservations of the Manager's principal are unreserved. Manager funcs are safe to invoke
+	// concurrently.
+	Manager struct {
+		principal string
+
+		m          sync.Mutex
+		declared   []Reservation
+		reserved   map[string]map[string]float64 // amounts by resource name, by reservation key
+		reconciled bool
+	}
+)
+
+// NewManager returns a Manager of the reservations of the given principal, which should be the principal
+// of the framework. Reservations should be declared, and the Manager should be reconciled, before
+// planning.
+func NewManager(principal string) *Manager {
+	return &Manager{principal: principal, reserved: make(map[string]map[string]float64)}
+}
+
+// Declare replaces the declared set of reservations. The principal of reservations that don't specify
+// one defaults to that of the Manager; an error is returned (and the declared set is unchanged) for any
+// other principal, since Mesos only permits a framework to reserve resources for its own principal.
+func (m *Manager) Declare(rs ...Reservation) error {
+	declared := make([]Reservation, len(rs))
+	for i := range rs {
+		declared[i] = rs[i]
+		switch declared[i].Principal {
+		case "":
+			declared[i].Principal = m.principal
+		case m.principal:
+		default:
+			return fmt.Errorf("reservation for role %q has principal %q, instead of %q",
+				rs[i].Role, rs[i].Principal, m.principal)
+		}
+	}
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.declared = declared
+	return nil
+}
+
+// Reconcile issues a GET_AGENTS call via the given sender, and determines the amounts that are reserved
+// (by the Manager's principal) from the total resources of every agent. Frameworks should reconcile upon
+// every (re)subscription, and periodically, since offers only report resources that aren't in use.
+func (m *Manager) Reconcile(ctx context.Context, sender mastercalls.Sender) error {
+	agents, err := mastercalls.SendGetAgents(ctx, sender)
+	if err != nil {
+		return err
+	}
+	reserved := make(map[string]map[string]float64)
+	for _, a := range agents.Agents {
+		for i := range a.TotalResources {
+			res := &a.TotalResources[i]
+			if k, ok := m.keyOf(res); ok && res.GetScalar() != nil {
+				add(reserved, k, res.Name, res.GetScalar().GetValue())
+			}
+		}
+	}
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.reserved, m.reconciled = reserved, true
+	return nil
+}
+
+// Plan returns the operations that converge the reservations toward the declared set, using the
+// resources of the given offer; the plan doesn't affect the state of the Manager, see Commit.
+func (m *Manager) Plan(o *mesos.Offer) (Plan, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	p := Plan{AgentID: o.AgentID}
+	if !m.reconciled {
+		return p, ErrNotReconciled
+	}
+
+	// deficit is positive for amounts that remain to be reserved, negative for surplus amounts
+	var (
+		deficit  = make(map[string]map[string]float64)
+		declared = make(map[string]bool, len(m.declared))
+	)
+	for k, amounts := range m.reserved {
+		for name, x := range amounts {
+			add(deficit, k, name, -x)
+		}
+	}
+	for i := range m.declared {
+		k := key(m.declared[i].Role, m.declared[i].Principal, m.declared[i].Labels)
+		declared[k] = true
+		for name, x := range m.declared[i].Amounts {
+			add(deficit, k, name, x)
+		}
+	}
+
+	for i := range o.Resources {
+		r := &o.Resources[i]
+		if r.GetScalar() == nil || r.IsPersistentVolume() || r.IsRevocable() {
+			continue
+		}
+		if k, ok := m.keyOf(r); ok {
+			surplus := -deficit[k][r.Name]
+			if !declared[k] {
+				surplus = r.GetScalar().GetValue() // undeclared reservations are unreserved entirely
+			}
+			if surplus >= epsilon {
+				x := min(surplus, r.GetScalar().GetValue())
+				if x < r.GetScalar().GetValue() && !divisible(r) {
+					continue
+				}
+				p.Unreserve = append(p.Unreserve, amount(r, x))
+				add(deficit, k, r.Name, x)
+			}
+			continue
+		}
+		if !r.IsUnreserved() {
+			continue
+		}
+		available := r.GetScalar().GetValue()
+		for j := range m.declared {
+			d := &m.declared[j]
+			if available < epsilon {
+				break
+			}
+			if role := r.GetAllocationInfo().GetRole(); role != "" && role != d.Role {
+				continue // the resource isn't allocated to the role of the reservation
+			}
+			k := key(d.Role, d.Principal, d.Labels)
+			if want := deficit[k][r.Name]; want >= epsilon {
+				x := min(want, available)
+				if x < available && !divisible(r) {
+					continue
+				}
+				res := amount(r, x)
+				res.Reservations = append(res.Reservations, mesos.Resource_ReservationInfo{
+					Type:      mesos.Resource_ReservationInfo_DYNAMIC.Enum(),
+					Role:      proto.String(d.Role),
+					Principal: proto.String(d.Principal),
+					Labels:    d.Labels,
+				})
+				p.Reserve = append(p.Reserve, res)
+				add(deficit, k, r.Name, -x)
+				available -= x
+			}
+		}
+	}
+	return p, nil
+}
+
+// Commit records the effect of the given plan, once its operations have been accepted; subsequent plans
+// account for it. Operations that fail are accounted for by the next reconciliation.
+func (m *Manager) Commit(p Plan) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	for i := range p.Reserve {
+		if k, ok := m.keyOf(&p.Reserve[i]); ok {
+			add(m.reserved, k, p.Reserve[i].Name, p.Reserve[i].GetScalar().GetValue())
+		}
+	}
+	for i := range p.Unreserve {
+		if k, ok := m.keyOf(&p.Unreserve[i]); ok {
+			add(m.reserved, k, p.Unreserve[i].Name, -p.Unreserve[i].GetScalar().GetValue())
+		}
+	}
+}
+
+// Empty returns true if the plan has no operations.
+func (p *Plan) Empty() bool { return len(p.Reserve) == 0 && len(p.Unreserve) == 0 }
+
+// Operations returns the UNRESERVE and RESERVE operations of the plan, in that order.
+func (p *Plan) Operations() (ops []mesos.Offer_Operation) {
+	if len(p.Unreserve) > 0 {
+		ops = append(ops, calls.OpUnreserve(p.Unreserve...))
+	}
+	if len(p.Reserve) > 0 {
+		ops = append(ops, calls.OpReserve(p.Reserve...))
+	}
+	return
+}
+
+// String describes the plan; e.g. as the output of a dry run.
+func (p *Plan) String() string {
+	var b bytes.Buffer
+	b.WriteString("agent " + p.AgentID.Value + ":")
+	if p.Empty() {
+		b.WriteString(" no changes")
+	}
+	describe := func(verb string, rs mesos.Resources) {
+		for i := range rs {
+			ri := rs[i].Reservations[len(rs[i].Reservations)-1]
+			fmt.Fprintf(&b, "\n  %s %s:%s for role %q, principal %q", verb, rs[i].Name,
+				strconv.FormatFloat(rs[i].GetScalar().GetValue(), 'f', -1, 64), ri.GetRole(), ri.GetPrincipal())
+			if ri.Labels != nil {
+				b.WriteString(", labels " + ri.Labels.Format())
+			}
+		}
+	}
+	describe("unreserve", p.Unreserve)
+	describe("reserve", p.Reserve)
+	return b.String()
+}
+
+// keyOf returns the key of the reservation of the given resource, if the resource is dynamically reserved
+// by the Manager's principal.
+func (m *Manager) keyOf(r *mesos.Resource) (string, bool) {
+	rs := r.GetReservations()
+	if len(rs) == 0 {
+		return "", false
+	}
+	ri := &rs[len(rs)-1]
+	if ri.GetType() != mesos.Resource_ReservationInfo_DYNAMIC || ri.GetPrincipal() != m.principal {
+		return "", false
+	}
+	return key(ri.GetRole(), ri.GetPrincipal(), ri.Labels), true
+}
+
+// key identifies a reservation by its role, principal, and (sorted) labels.
+func key(role, principal string, labels *mesos.Labels) string {
+	ls := make([]string, 0, len(labels.GetLabels()))
+	for _, l := range labels.GetLabels() {
+		ls = append(ls, strconv.Quote(l.Key)+"="+strconv.Quote(l.GetValue()))
+	}
+	sort.Strings(ls)
+	k := strconv.Quote(role) + "/" + strconv.Quote(principal)
+	for _, l := range ls {
+		k += "," + l
+	}
+	return k
+}
+
+func add(m map[string]map[string]float64, k, name string, x float64) {
+	amounts, ok := m[k]
+	if !ok {
+		amounts = make(map[string]float64)
+		m[k] = amounts
+	}
+	amounts[name] = (mesos.QuantityOf(amounts[name]) + mesos.QuantityOf(x)).Float64()
+}
+
+// divisible returns false for disks that must be consumed whole.
+func divisible(r *mesos.Resource) bool {
+	return !r.IsDisk(mesos.Resource_DiskInfo_Source_MOUNT) &&
+		!r.IsDisk(mesos.Resource_DiskInfo_Source_BLOCK) &&
+		!r.IsDisk(mesos.Resource_DiskInfo_Source_RAW)
+}
+
+// amount returns a copy of the given scalar resource, with the given value.
+func amount(r *mesos.Resource, x float64) mesos.Resource {
+	res := *proto.Clone(r).(*mesos.Resource)
+	res.Scalar.Value = x
+	return res
+}
+
+func min(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,122 @@
+package reservations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func reserved(r *resources.Builder, role, principal string, labels *mesos.Labels) mesos.Resource {
+	res := r.Resource
+	res.Reservations = []mesos.Resource_ReservationInfo{{
+		Type:      mesos.Resource_ReservationInfo_DYNAMIC.Enum(),
+		Role:      proto.String(role),
+		Principal: proto.String(principal),
+		Labels:    labels,
+	}}
+	return res
+}
+
+func agents(rs ...mesos.Resource) calls.Sender {
+	return calls.SenderFunc(func(_ context.Context, _ calls.Request) (mesos.Response, error) {
+		resp := master.Response{Type: master.Response_GET_AGENTS, GetAgents: &master.Response_GetAgents{
+			Agents: []master.Response_GetAgents_Agent{{
+				AgentInfo:      mesos.AgentInfo{ID: &mesos.AgentID{Value: "a1"}},
+				TotalResources: rs,
+			}},
+		}}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*(u.(*master.Response)) = resp
+			return nil
+		})}, nil
+	})
+}
+
+func TestManager(t *testing.T) {
+	var (
+		m      = NewManager("fw")
+		labels = &mesos.Labels{Labels: []mesos.Label{{Key: "app", Value: proto.String("db")}}}
+		offer  = func(rs ...mesos.Resource) *mesos.Offer {
+			return &mesos.Offer{AgentID: mesos.AgentID{Value: "a1"}, Resources: rs}
+		}
+	)
+	if err := m.Declare(Reservation{Role: "db", Principal: "other"}); err == nil {
+		t.Fatal("expected error for a reservation of another principal")
+	}
+	err := m.Declare(Reservation{Role: "db", Labels: labels, Amounts: map[string]float64{"cpus": 4, "mem": 1024}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Plan(offer()); err != ErrNotReconciled {
+		t.Fatalf("expected ErrNotReconciled instead of %v", err)
+	}
+
+	// 1 CPU is already reserved; surplus undeclared reservations are unreserved, others are ignored
+	err = m.Reconcile(context.Background(), agents(
+		reserved(resources.NewCPUs(1), "db", "fw", labels),
+		reserved(resources.NewCPUs(1), "db", "someone-else", nil),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := offer(
+		resources.NewCPUs(2).Resource,
+		resources.NewMemory(4096).Resource,
+		reserved(resources.NewCPUs(0.5), "web", "fw", nil),
+		reserved(resources.NewCPUs(1), "db", "someone-else", nil),
+	)
+	p, err := m.Plan(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Reserve) != 2 || len(p.Unreserve) != 1 {
+		t.Fatalf("unexpected plan %v", p.String())
+	}
+	if r := p.Reserve[0]; r.Name != "cpus" || r.GetScalar().GetValue() != 2 || r.ReservationRole() != "db" ||
+		!r.Reservations[0].Labels.Equivalent(labels) {
+		t.Fatalf("unexpected reservation %v", r)
+	}
+	if r := p.Reserve[1]; r.Name != "mem" || r.GetScalar().GetValue() != 1024 {
+		t.Fatalf("unexpected reservation %v", r)
+	}
+	if r := p.Unreserve[0]; r.ReservationRole() != "web" || r.GetScalar().GetValue() != 0.5 {
+		t.Fatalf("unexpected unreservation %v", r)
+	}
+	if s := p.String(); !strings.Contains(s, `reserve cpus:2 for role "db", principal "fw", labels app=db`) {
+		t.Fatalf("unexpected plan description %q", s)
+	}
+	ops := p.Operations()
+	if len(ops) != 2 || ops[0].GetType() != mesos.Offer_Operation_UNRESERVE || ops[1].GetType() != mesos.Offer_Operation_RESERVE {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+
+	// a dry run doesn't affect subsequent plans, a committed plan does
+	if p2, _ := m.Plan(o); len(p2.Reserve) != 2 {
+		t.Fatalf("unexpected plan %v", p2.String())
+	}
+	m.Commit(p)
+	p, _ = m.Plan(offer(resources.NewCPUs(8).Resource, reserved(resources.NewMemory(1024), "db", "fw", labels)))
+	if len(p.Reserve) != 1 || p.Reserve[0].GetScalar().GetValue() != 1 || len(p.Unreserve) != 0 {
+		t.Fatalf("unexpected plan %v", p.String())
+	}
+
+	// shrinking the declaration unreserves the surplus
+	m.Commit(p)
+	if err = m.Declare(Reservation{Role: "db", Labels: labels, Amounts: map[string]float64{"cpus": 4, "mem": 512}}); err != nil {
+		t.Fatal(err)
+	}
+	p, _ = m.Plan(offer(reserved(resources.NewMemory(1024), "db", "fw", labels)))
+	if len(p.Unreserve) != 1 || p.Unreserve[0].GetScalar().GetValue() != 512 || len(p.Reserve) != 0 {
+		t.Fatalf("unexpected plan %v", p.String())
+	}
+	if p, _ = m.Plan(offer()); !p.Empty() || p.String() != "agent a1: no changes" {
+		t.Fatalf("unexpected plan %v", p.String())
+	}
+}
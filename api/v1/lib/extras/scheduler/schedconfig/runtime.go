@@ -0,0 +1,137 @@
+package schedconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/backoff"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// Reloader tracks the Runtime settings of a scheduler, which may be reloaded (e.g. upon SIGHUP, see Watch)
+// while the scheduler remains subscribed. Settings other than Runtime require a restart: they're loaded,
+// and validated, but otherwise ignored by Reload. Reloader funcs are safe to invoke concurrently.
+type Reloader struct {
+	load func() (Config, error)
+
+	reload   sync.Mutex // serializes reloads, and so the invocation of handlers
+	m        sync.Mutex
+	current  Runtime
+	handlers []func(prev, next Runtime)
+}
+
+// Validate returns an error if the runtime settings are inconsistent.
+func (r Runtime) Validate() error {
+	switch {
+	case r.RefuseSeconds < 0:
+		return errors.New("refuse seconds must not be negative")
+	case r.RateLimit.Burst < 0:
+		return errors.New("rate limit burst must not be negative")
+	case r.RateLimit.Burst > 0 && r.RateLimit.MinWait <= 0:
+		return errors.New("rate limit requires a positive min wait")
+	case r.Backoff.Max > 0 && r.Backoff.Max < r.Backoff.Min:
+		return errors.New("max backoff must not be less than min backoff")
+	}
+	return nil
+}
+
+// DeclineOpt returns an option (e.g. for offers.Hoard.Decline) that applies the configured refusal
+// filter to DECLINE calls; it's a no-op if RefuseSeconds is zero, in which case Mesos applies its default.
+func (r Runtime) DeclineOpt() scheduler.CallOpt {
+	if r.RefuseSeconds <= 0 {
+		return func(*scheduler.Call) {}
+	}
+	return calls.RefuseSeconds(time.Duration(r.RefuseSeconds))
+}
+
+// Reconfigure applies the rate limit to the given Limiter; a zero burst disables rate limiting, see
+// backoff.Limiter.Reconfigure.
+func (rl RateLimit) Reconfigure(lim *backoff.Limiter) error {
+	return lim.Reconfigure(rl.Burst, time.Duration(rl.MinWait), time.Duration(rl.MaxWait))
+}
+
+// NewReloader returns a Reloader that tracks the runtime settings of the given configuration; Reload
+// invokes the load func, e.g.
+//
+//	r := schedconfig.NewReloader(config, func() (schedconfig.Config, error) {
+//		return schedconfig.Load(path, schedconfig.DefaultEnvPrefix)
+//	})
+func NewReloader(c Config, load func() (Config, error)) *Reloader {
+	return &Reloader{load: load, current: c.Runtime}
+}
+
+// Runtime returns the current runtime settings. Schedulers should read these as they're needed (e.g. for
+// every DECLINE, or every subscription attempt) rather than caching them.
+func (r *Reloader) Runtime() Runtime {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.current
+}
+
+// OnChange registers a func that's invoked, with the previous and next settings, whenever the runtime
+// settings change. Handlers are invoked in the order in which they were registered, sequentially.
+func (r *Reloader) OnChange(f func(prev, next Runtime)) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.handlers = append(r.handlers, f)
+}
+
+// Reload loads the configuration and applies its runtime settings. If the configuration cannot be loaded
+// then the current settings are kept and the error is returned.
+func (r *Reloader) Reload() error {
+	c, err := r.load()
+	if err != nil {
+		return err
+	}
+	return r.Set(c.Runtime)
+}
+
+// Set validates and applies the given runtime settings, e.g. as received via an administrative endpoint.
+func (r *Reloader) Set(next Runtime) error {
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	r.reload.Lock()
+	defer r.reload.Unlock()
+
+	r.m.Lock()
+	prev := r.current
+	r.current = next
+	handlers := r.handlers
+	r.m.Unlock()
+
+	if prev == next {
+		return nil
+	}
+	for _, f := range handlers {
+		f(prev, next)
+	}
+	return nil
+}
+
+// Watch reloads the configuration whenever the process receives one of the given signals (SIGHUP if none
+// are given), until the context is done. Reload errors are reported to errorFunc, which may be nil.
+func (r *Reloader) Watch(ctx context.Context, errorFunc func(error), signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ch:
+			if err := r.Reload(); err != nil && errorFunc != nil {
+				errorFunc(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
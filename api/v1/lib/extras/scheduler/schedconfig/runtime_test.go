@@ -0,0 +1,149 @@
+package schedconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/backoff"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestReloader(t *testing.T) {
+	var (
+		next    = Default()
+		loadErr error
+		load    = func() (Config, error) { return next, loadErr }
+		r       = NewReloader(Default(), load)
+		changes []Runtime
+	)
+	r.OnChange(func(prev, next Runtime) {
+		changes = append(changes, next)
+	})
+
+	// unchanged settings don't invoke handlers
+	if err := r.Reload(); err != nil || len(changes) != 0 {
+		t.Fatalf("unexpected reload: %v, %+v", err, changes)
+	}
+
+	next.Runtime.RefuseSeconds = Duration(time.Minute)
+	next.Runtime.RateLimit = RateLimit{Burst: 2, MinWait: Duration(time.Second)}
+	if err := r.Reload(); err != nil || len(changes) != 1 || changes[0] != next.Runtime {
+		t.Fatalf("unexpected reload: %v, %+v", err, changes)
+	}
+	if rt := r.Runtime(); rt != next.Runtime {
+		t.Fatalf("unexpected runtime settings %+v", rt)
+	}
+	call := calls.Decline()
+	call.With(r.Runtime().DeclineOpt())
+	if f := call.GetDecline().GetFilters(); f.GetRefuseSeconds() != 60 {
+		t.Fatalf("unexpected decline filters %+v", f)
+	}
+
+	// errors and illegal settings keep the current settings
+	loadErr = errors.New("load failed")
+	if err := r.Reload(); err != loadErr {
+		t.Fatalf("expected load error instead of %v", err)
+	}
+	if err := r.Set(Runtime{RateLimit: RateLimit{Burst: 1}}); err == nil {
+		t.Fatal("expected error for rate limit without min wait")
+	}
+	if rt := r.Runtime(); rt != next.Runtime || len(changes) != 1 {
+		t.Fatalf("unexpected runtime settings %+v", rt)
+	}
+
+	// SIGHUP triggers a reload
+	loadErr = nil
+	next.Runtime.LogLevel = 2
+	reloaded := make(chan struct{})
+	r.OnChange(func(_, _ Runtime) { close(reloaded) })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, nil)
+
+	// guard against the default action of SIGHUP (termination) until the watcher has subscribed to it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for done := false; !done; {
+		if err := self.Signal(syscall.SIGHUP); err != nil {
+			t.Skip("cannot signal self:", err)
+		}
+		select {
+		case <-reloaded:
+			done = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for reload")
+		}
+	}
+	if rt := r.Runtime(); rt.LogLevel != 2 {
+		t.Fatalf("unexpected runtime settings %+v", rt)
+	}
+}
+
+func TestRateLimitReconfigure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lim, err := backoff.NewLimiter(ctx, 1, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-lim.C()
+
+	// a zero burst disables rate limiting
+	if err = (RateLimit{}).Reconfigure(lim); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-lim.C():
+		case <-time.After(time.Second):
+			t.Fatalf("expected unlimited token %d", i)
+		}
+	}
+	if err = (RateLimit{Burst: 2, MinWait: Duration(time.Hour), MaxWait: Duration(time.Hour)}).Reconfigure(lim); err != nil {
+		t.Fatal(err)
+	}
+	<-lim.C()
+	if n := lim.Burst(); n != 2 {
+		t.Fatalf("expected a burst of 2 instead of %d", n)
+	}
+}
+
+func TestRuntimeFromEnv(t *testing.T) {
+	env := map[string]string{
+		"TEST_REFUSE_SECONDS":   "30s",
+		"TEST_LOG_LEVEL":        "1",
+		"TEST_RATE_LIMIT_BURST": "x",
+	}
+	c := Default()
+	if err := c.FromEnv("TEST_", func(k string) string { return env[k] }); err == nil {
+		t.Fatal("expected error for illegal burst")
+	}
+	env["TEST_RATE_LIMIT_BURST"] = "3"
+	env["TEST_RATE_LIMIT_MIN_WAIT"] = "1s"
+	if err := c.FromEnv("TEST_", func(k string) string { return env[k] }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := Runtime{
+		RefuseSeconds: Duration(30 * time.Second),
+		LogLevel:      1,
+		RateLimit:     RateLimit{Burst: 3, MinWait: Duration(time.Second)},
+	}
+	if c.Runtime != want {
+		t.Fatalf("unexpected runtime settings %+v", c.Runtime)
+	}
+}
@@ -0,0 +1,432 @@
+// Package schedconfig loads the settings that are common to most schedulers (framework registration,
+// master endpoints, TLS, and authentication) from a JSON file plus environment variable overrides, into
+// typed structs from which a FrameworkInfo and an httpcli.Client are built. Runtime settings may be
+// reloaded while the scheduler is subscribed, see Reloader.
+//
+// YAML files are not supported, since that would require a third-party dependency; JSON documents are
+// valid YAML, so a file that's shared with YAML-based tooling should be written in the JSON subset.
+package schedconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables that override file settings, by default.
+const DefaultEnvPrefix = "SCHEDULER_"
+
+type (
+	// Config is the complete scheduler configuration.
+	Config struct {
+		Framework Framework `json:"framework"`
+		Master    Master    `json:"master"`
+		TLS       TLS       `json:"tls"`
+		Auth      Auth      `json:"auth"`
+		Runtime   Runtime   `json:"runtime"`
+	}
+
+	// Framework describes how the framework registers with the master; see mesos.FrameworkInfo.
+	Framework struct {
+		Name            string            `json:"name"`
+		User            string            `json:"user"`
+		Role            string            `json:"role,omitempty"`
+		Roles           []string          `json:"roles,omitempty"`
+		Principal       string            `json:"principal,omitempty"`
+		Hostname        string            `json:"hostname,omitempty"`
+		WebUIURL        string            `json:"webui_url,omitempty"`
+		FailoverTimeout Duration          `json:"failover_timeout,omitempty"`
+		Checkpoint      bool              `json:"checkpoint,omitempty"`
+		Capabilities    []string          `json:"capabilities,omitempty"`
+		Labels          map[string]string `json:"labels,omitempty"`
+	}
+
+	// Master describes the scheduler API endpoint of the Mesos master.
+	Master struct {
+		URL     string   `json:"url"`
+		Codec   string   `json:"codec,omitempty"` // "protobuf" (the default) or "json"
+		Timeout Duration `json:"timeout,omitempty"`
+	}
+
+	// TLS configures HTTPS connections to the master. TLS is enabled when any of its fields are set.
+	TLS struct {
+		CAFile             string `json:"ca_file,omitempty"`
+		CertFile           string `json:"cert_file,omitempty"`
+		KeyFile            string `json:"key_file,omitempty"`
+		ServerName         string `json:"server_name,omitempty"`
+		InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	}
+
+	// Auth configures HTTP Basic authentication with the master. The password is read from a file so
+	// that it needn't be stored in the configuration file, or in the environment; the file is read
+	// again whenever it changes (see httpcli.FileCredentials).
+	Auth struct {
+		Username     string `json:"username,omitempty"`
+		PasswordFile string `json:"password_file,omitempty"`
+	}
+
+	// Runtime holds the settings that may be changed without re-subscribing, see Reloader. Zero values
+	// leave the corresponding behavior to the scheduler (or to Mesos).
+	Runtime struct {
+		RefuseSeconds Duration  `json:"refuse_seconds,omitempty"` // filter duration of DECLINE calls
+		LogLevel      int       `json:"log_level,omitempty"`
+		RateLimit     RateLimit `json:"rate_limit"`
+		Backoff       Backoff   `json:"backoff"`
+	}
+
+	// RateLimit configures a backoff.Limiter, see RateLimit.Reconfigure. Rate limiting is disabled when
+	// the burst is zero.
+	RateLimit struct {
+		Burst   int      `json:"burst,omitempty"`
+		MinWait Duration `json:"min_wait,omitempty"`
+		MaxWait Duration `json:"max_wait,omitempty"`
+	}
+
+	// Backoff configures the backoff between subsequent attempts of an operation, e.g. subscription.
+	Backoff struct {
+		Min Duration `json:"min,omitempty"`
+		Max Duration `json:"max,omitempty"`
+	}
+
+	// Duration is a time.Duration that's encoded in JSON as a string: either a Go duration (e.g. "1m30s")
+	// or a Mesos duration (e.g. "1.5mins"). A JSON number is interpreted as a number of seconds.
+	Duration time.Duration
+)
+
+// Default returns the default configuration.
+func Default() Config {
+	return Config{
+		Framework: Framework{
+			Name: "mesos-go-scheduler",
+			Role: "*",
+		},
+		Master: Master{
+			URL:     "http://:5050/api/v1/scheduler",
+			Codec:   "protobuf",
+			Timeout: Duration(20 * time.Second),
+		},
+	}
+}
+
+// Load returns the default configuration, overridden by the JSON file at the given path (if not empty),
+// overridden by environment variables with the given prefix (see FromEnv). The result is validated.
+func Load(path, envPrefix string) (Config, error) {
+	c := Default()
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := c.FromEnv(envPrefix, os.Getenv); err != nil {
+		return Config{}, err
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, c); err != nil {
+		return fmt.Errorf("failed to parse %q: %v", path, err)
+	}
+	return nil
+}
+
+// FromEnv overrides the configuration with the values of environment variables, as reported by getenv.
+// Variable names consist of the prefix followed by one of: NAME, USER, ROLE, ROLES (comma separated),
+// PRINCIPAL, HOSTNAME, WEBUI_URL, FAILOVER_TIMEOUT, CHECKPOINT, CAPABILITIES (comma separated),
+// MASTER_URL, CODEC, TIMEOUT, TLS_CA_FILE, TLS_CERT_FILE, TLS_KEY_FILE, TLS_SERVER_NAME,
+// TLS_INSECURE_SKIP_VERIFY, AUTH_USERNAME, AUTH_PASSWORD_FILE, REFUSE_SECONDS, LOG_LEVEL,
+// RATE_LIMIT_BURST, RATE_LIMIT_MIN_WAIT, RATE_LIMIT_MAX_WAIT, BACKOFF_MIN, BACKOFF_MAX. Variables that are
+// unset, or empty, are ignored.
+func (c *Config) FromEnv(prefix string, getenv func(string) string) error {
+	var errs []string
+	str := func(name string, dst *string) {
+		if v := getenv(prefix + name); v != "" {
+			*dst = v
+		}
+	}
+	list := func(name string, dst *[]string) {
+		if v := getenv(prefix + name); v != "" {
+			*dst = strings.Split(v, ",")
+		}
+	}
+	boolean := func(name string, dst *bool) {
+		if v := getenv(prefix + name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, prefix+name+": "+err.Error())
+				return
+			}
+			*dst = b
+		}
+	}
+	integer := func(name string, dst *int) {
+		if v := getenv(prefix + name); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, prefix+name+": "+err.Error())
+				return
+			}
+			*dst = i
+		}
+	}
+	duration := func(name string, dst *Duration) {
+		if v := getenv(prefix + name); v != "" {
+			d, err := parseDuration(v)
+			if err != nil {
+				errs = append(errs, prefix+name+": "+err.Error())
+				return
+			}
+			*dst = d
+		}
+	}
+	f := &c.Framework
+	str("NAME", &f.Name)
+	str("USER", &f.User)
+	str("ROLE", &f.Role)
+	list("ROLES", &f.Roles)
+	str("PRINCIPAL", &f.Principal)
+	str("HOSTNAME", &f.Hostname)
+	str("WEBUI_URL", &f.WebUIURL)
+	duration("FAILOVER_TIMEOUT", &f.FailoverTimeout)
+	boolean("CHECKPOINT", &f.Checkpoint)
+	list("CAPABILITIES", &f.Capabilities)
+	str("MASTER_URL", &c.Master.URL)
+	str("CODEC", &c.Master.Codec)
+	duration("TIMEOUT", &c.Master.Timeout)
+	str("TLS_CA_FILE", &c.TLS.CAFile)
+	str("TLS_CERT_FILE", &c.TLS.CertFile)
+	str("TLS_KEY_FILE", &c.TLS.KeyFile)
+	str("TLS_SERVER_NAME", &c.TLS.ServerName)
+	boolean("TLS_INSECURE_SKIP_VERIFY", &c.TLS.InsecureSkipVerify)
+	str("AUTH_USERNAME", &c.Auth.Username)
+	str("AUTH_PASSWORD_FILE", &c.Auth.PasswordFile)
+	r := &c.Runtime
+	duration("REFUSE_SECONDS", &r.RefuseSeconds)
+	integer("LOG_LEVEL", &r.LogLevel)
+	integer("RATE_LIMIT_BURST", &r.RateLimit.Burst)
+	duration("RATE_LIMIT_MIN_WAIT", &r.RateLimit.MinWait)
+	duration("RATE_LIMIT_MAX_WAIT", &r.RateLimit.MaxWait)
+	duration("BACKOFF_MIN", &r.Backoff.Min)
+	duration("BACKOFF_MAX", &r.Backoff.Max)
+	if len(errs) > 0 {
+		return errors.New("illegal configuration in process environment: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Validate returns an error if the configuration is incomplete or inconsistent.
+func (c *Config) Validate() error {
+	switch {
+	case c.Framework.Name == "":
+		return errors.New("framework name is required")
+	case c.Master.URL == "":
+		return errors.New("master URL is required")
+	case len(c.Framework.Roles) > 0 && !c.hasCapability(mesos.FrameworkInfo_Capability_MULTI_ROLE):
+		return errors.New("framework roles require the MULTI_ROLE capability")
+	case (c.TLS.CertFile == "") != (c.TLS.KeyFile == ""):
+		return errors.New("TLS cert and key files must be specified together")
+	case c.Auth.PasswordFile != "" && c.Auth.Username == "":
+		return errors.New("a password file requires a username")
+	}
+	if _, err := c.codec(); err != nil {
+		return err
+	}
+	if _, err := c.capabilities(); err != nil {
+		return err
+	}
+	return c.Runtime.Validate()
+}
+
+func (c *Config) hasCapability(t mesos.FrameworkInfo_Capability_Type) bool {
+	caps, _ := c.capabilities()
+	for _, x := range caps {
+		if x.GetType() == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) capabilities() (result []mesos.FrameworkInfo_Capability, err error) {
+	for _, name := range c.Framework.Capabilities {
+		v, ok := mesos.FrameworkInfo_Capability_Type_value[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok || v == int32(mesos.FrameworkInfo_Capability_UNKNOWN) {
+			return nil, fmt.Errorf("unknown framework capability %q", name)
+		}
+		result = append(result, mesos.FrameworkInfo_Capability{Type: mesos.FrameworkInfo_Capability_Type(v)})
+	}
+	return
+}
+
+func (c *Config) codec() (codec httpcli.Opt, err error) {
+	switch c.Master.Codec {
+	case "", "protobuf":
+		codec = httpcli.Codec(codecs.ByMediaType[codecs.MediaTypeProtobuf])
+	case "json":
+		codec = httpcli.Codec(codecs.ByMediaType[codecs.MediaTypeJSON])
+	default:
+		err = fmt.Errorf("unsupported codec %q", c.Master.Codec)
+	}
+	return
+}
+
+// FrameworkInfo returns the FrameworkInfo with which the framework registers. Labels are sorted by key.
+func (c *Config) FrameworkInfo() (*mesos.FrameworkInfo, error) {
+	caps, err := c.capabilities()
+	if err != nil {
+		return nil, err
+	}
+	f := &c.Framework
+	info := &mesos.FrameworkInfo{
+		User:         f.User,
+		Name:         f.Name,
+		Capabilities: caps,
+	}
+	if len(f.Roles) > 0 {
+		info.Roles = append([]string(nil), f.Roles...)
+	} else if f.Role != "" {
+		role := f.Role
+		info.Role = &role
+	}
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	info.Principal = optional(f.Principal)
+	info.Hostname = optional(f.Hostname)
+	info.WebUiURL = optional(f.WebUIURL)
+	if f.Checkpoint {
+		info.Checkpoint = &f.Checkpoint
+	}
+	if f.FailoverTimeout > 0 {
+		seconds := time.Duration(f.FailoverTimeout).Seconds()
+		info.FailoverTimeout = &seconds
+	}
+	if len(f.Labels) > 0 {
+		keys := make([]string, 0, len(f.Labels))
+		for k := range f.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		info.Labels = &mesos.Labels{}
+		for _, k := range keys {
+			info.Labels.Labels = append(info.Labels.Labels, mesos.Label{Key: k, Value: optional(f.Labels[k])})
+		}
+	}
+	return info, nil
+}
+
+// TLSConfig returns the TLS configuration for connections to the master, or else nil if TLS isn't
+// configured.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	t := &c.TLS
+	if *t == (TLS{}) {
+		return nil, nil
+	}
+	tc := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %q", t.CAFile)
+		}
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+// Client returns an httpcli.Client for the scheduler API of the master; additional options are applied
+// last. The client is typically wrapped by httpsched.NewCaller.
+func (c *Config) Client(opts ...httpcli.Opt) (*httpcli.Client, error) {
+	codec, err := c.codec()
+	if err != nil {
+		return nil, err
+	}
+	var configOpts []httpcli.ConfigOpt
+	if c.Master.Timeout > 0 {
+		configOpts = append(configOpts, httpcli.Timeout(time.Duration(c.Master.Timeout)))
+	}
+	tc, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tc != nil {
+		configOpts = append(configOpts, httpcli.TLSConfig(tc))
+	}
+	if c.Auth.PasswordFile != "" {
+		configOpts = append(configOpts, httpcli.BasicAuthProvider(httpcli.FileCredentials(c.Auth.Username, c.Auth.PasswordFile)))
+	} else if c.Auth.Username != "" {
+		configOpts = append(configOpts, httpcli.BasicAuth(c.Auth.Username, ""))
+	}
+	return httpcli.New(append([]httpcli.Opt{
+		httpcli.Endpoint(c.Master.URL),
+		codec,
+		httpcli.Do(httpcli.With(configOpts...)),
+	}, opts...)...), nil
+}
+
+func parseDuration(s string) (Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return Duration(d), nil
+	}
+	d, err := mesostime.ParseDuration(s)
+	return Duration(d), err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(b, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	x, err := parseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = x
+	return nil
+}
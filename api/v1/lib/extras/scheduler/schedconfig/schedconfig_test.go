@@ -0,0 +1,102 @@
+package schedconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schedconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"framework": {
+			"name": "web", "user": "root", "roles": ["a", "b"],
+			"failover_timeout": "1.5mins", "checkpoint": true,
+			"capabilities": ["multi_role", "PARTITION_AWARE"],
+			"labels": {"team": "x", "env": "prod"}
+		},
+		"master": {"url": "http://master:5050/api/v1/scheduler", "timeout": 5}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"TEST_PRINCIPAL":     "web-principal",
+		"TEST_CODEC":         "json",
+		"TEST_AUTH_USERNAME": "user",
+	}
+	c := Default()
+	if err = c.loadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.FromEnv("TEST_", func(k string) string { return env[k] }); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Duration(c.Master.Timeout); d != 5*time.Second {
+		t.Errorf("unexpected timeout %v", d)
+	}
+
+	info, err := c.FrameworkInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "web" || info.GetPrincipal() != "web-principal" || info.Role != nil || len(info.Roles) != 2 {
+		t.Errorf("unexpected framework info %+v", info)
+	}
+	if info.GetFailoverTimeout() != 90 || !info.GetCheckpoint() {
+		t.Errorf("unexpected failover settings %+v", info)
+	}
+	if len(info.Capabilities) != 2 || info.Capabilities[0].GetType() != mesos.FrameworkInfo_Capability_MULTI_ROLE {
+		t.Errorf("unexpected capabilities %+v", info.Capabilities)
+	}
+	if labels := info.GetLabels().GetLabels(); len(labels) != 2 || labels[0].Key != "env" {
+		t.Errorf("expected sorted labels instead of %+v", labels)
+	}
+	cli, err := c.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep := cli.Endpoint(); ep != c.Master.URL {
+		t.Errorf("unexpected client endpoint %q", ep)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for ti, mod := range []func(*Config){
+		func(c *Config) { c.Framework.Name = "" },
+		func(c *Config) { c.Master.URL = "" },
+		func(c *Config) { c.Framework.Roles = []string{"a"} },
+		func(c *Config) { c.Framework.Capabilities = []string{"bogus"} },
+		func(c *Config) { c.Master.Codec = "xml" },
+		func(c *Config) { c.TLS.CertFile = "cert.pem" },
+		func(c *Config) { c.Auth.PasswordFile = "secret" },
+	} {
+		c := Default()
+		mod(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("test case %d failed: expected validation error", ti)
+		}
+	}
+	c := Default()
+	if err := c.FromEnv("", func(k string) string {
+		if k == "CHECKPOINT" {
+			return "maybe"
+		}
+		return ""
+	}); err == nil {
+		t.Error("expected error for illegal boolean")
+	}
+}
@@ -0,0 +1,116 @@
+package schedmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+)
+
+// ExpvarSink generates metrics that are published as expvar maps, named Prefix + the name of the metric.
+// The entries of a map are keyed by the comma-separated label values of a recording. Because expvar
+// names are global, metrics of the same name (and Prefix) that are generated more than once per process
+// (e.g. by the Metrics of a resubscribed scheduler) share the map that was published first: their
+// recordings accumulate. The process panics if the name of a metric is that of some other expvar.Var.
+type ExpvarSink struct {
+	Prefix string
+}
+
+var _ = Sink(&ExpvarSink{})
+
+// Counter implements Sink.
+func (s *ExpvarSink) Counter(name, _ string, _ ...string) metrics.Counter {
+	m := publish(s.Prefix + name)
+	return func(values ...string) { m.Add(key(values), 1) }
+}
+
+// Adder implements Sink.
+func (s *ExpvarSink) Adder(name, _ string, _ ...string) metrics.Adder {
+	m := publish(s.Prefix + name)
+	return func(x float64, values ...string) { m.AddFloat(key(values), x) }
+}
+
+// Watcher implements Sink; every histogram is published as a JSON object with "count", "sum", and
+// (cumulative) "buckets" fields.
+func (s *ExpvarSink) Watcher(name, _ string, buckets []float64, _ ...string) metrics.Watcher {
+	var (
+		m   = publish(s.Prefix + name)
+		mu  sync.Mutex
+		hs  = make(map[string]*histogram)
+		bkt = append([]float64(nil), buckets...)
+	)
+	sort.Float64s(bkt)
+	return func(x float64, values ...string) {
+		k := key(values)
+		mu.Lock()
+		h, ok := hs[k]
+		if !ok {
+			if h, ok = m.Get(k).(*histogram); !ok {
+				h = &histogram{bounds: bkt, counts: make([]uint64, len(bkt))}
+				m.Set(k, h)
+			}
+			hs[k] = h
+		}
+		mu.Unlock()
+		h.observe(x)
+	}
+}
+
+// publishing serializes publish, so that concurrently generated metrics of the same name share a map
+var publishing sync.Mutex
+
+// publish returns the expvar map of the given name, publishing it unless it's already published.
+func publish(name string) *expvar.Map {
+	publishing.Lock()
+	defer publishing.Unlock()
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		return m
+	}
+	return expvar.NewMap(name)
+}
+
+func key(values []string) string { return strings.Join(values, ",") }
+
+type histogram struct {
+	bounds []float64
+
+	mu     sync.Mutex
+	counts []uint64 // counts[i] is the number of observations <= bounds[i]
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(x float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += x
+	for i, b := range h.bounds {
+		if x <= b {
+			h.counts[i]++
+		}
+	}
+}
+
+// String implements expvar.Var.
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	type bucket struct {
+		LE    float64 `json:"le"`
+		Count uint64  `json:"count"`
+	}
+	v := struct {
+		Count   uint64   `json:"count"`
+		Sum     float64  `json:"sum"`
+		Buckets []bucket `json:"buckets"`
+	}{Count: h.count, Sum: h.sum}
+	for i, b := range h.bounds {
+		v.Buckets = append(v.Buckets, bucket{LE: b, Count: h.counts[i]})
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,67 @@
+package schedmetrics
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusSink generates metrics that are registered with a Prometheus Registerer.
+type PrometheusSink struct {
+	Registerer prometheus.Registerer // defaults to prometheus.DefaultRegisterer
+	Namespace  string
+	Subsystem  string
+}
+
+var _ = Sink(&PrometheusSink{})
+
+// register registers c, unless an identical collector was registered already (e.g. by a previous
+// invocation of New for the same sink) in which case that collector is returned instead, so that its
+// metrics are shared.
+func (s *PrometheusSink) register(c prometheus.Collector) prometheus.Collector {
+	r := s.Registerer
+	if r == nil {
+		r = prometheus.DefaultRegisterer
+	}
+	if err := r.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+// Counter implements Sink.
+func (s *PrometheusSink) Counter(name, help string, labels ...string) metrics.Counter {
+	m := s.counterVec(name, help, labels)
+	return func(values ...string) { m.WithLabelValues(values...).Inc() }
+}
+
+// Adder implements Sink.
+func (s *PrometheusSink) Adder(name, help string, labels ...string) metrics.Adder {
+	m := s.counterVec(name, help, labels)
+	return func(x float64, values ...string) { m.WithLabelValues(values...).Add(x) }
+}
+
+func (s *PrometheusSink) counterVec(name, help string, labels []string) *prometheus.CounterVec {
+	m := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: s.Namespace,
+		Subsystem: s.Subsystem,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	return s.register(m).(*prometheus.CounterVec)
+}
+
+// Watcher implements Sink.
+func (s *PrometheusSink) Watcher(name, help string, buckets []float64, labels ...string) metrics.Watcher {
+	m := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: s.Namespace,
+		Subsystem: s.Subsystem,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}, labels)
+	m = s.register(m).(*prometheus.HistogramVec)
+	return func(x float64, values ...string) { m.WithLabelValues(values...).Observe(x) }
+}
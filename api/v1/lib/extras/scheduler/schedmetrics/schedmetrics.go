@@ -0,0 +1,125 @@
+// Package schedmetrics records the metrics of a scheduler: counts, errors, and latencies of calls and events
+// (by type), offer sizes, declined offers, and reconnects. Metrics are exported by a Sink; Prometheus and
+// expvar sinks are provided.
+package schedmetrics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+var (
+	// LatencyBuckets are the buckets of the call and event latency histograms, in microseconds.
+	LatencyBuckets = []float64{100, 250, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000, 250000, 1000000}
+	// ResourceBuckets are the buckets of the offered scalar resources histogram.
+	ResourceBuckets = []float64{0.5, 1, 2, 4, 8, 16, 32, 64, 128, 256, 1024, 4096, 16384, 65536, 262144}
+)
+
+// Sink generates the (possibly labeled) metrics of a scheduler. The label names of a metric are given
+// when it's generated; the label values are given each time that it's recorded.
+type Sink interface {
+	Counter(name, help string, labels ...string) metrics.Counter
+	Adder(name, help string, labels ...string) metrics.Adder
+	// Watcher generates a histogram with the given (upper-bound) buckets.
+	Watcher(name, help string, buckets []float64, labels ...string) metrics.Watcher
+}
+
+// Metrics are the metrics of a scheduler.
+type Metrics struct {
+	CallCount      metrics.Counter // by call type
+	CallErrors     metrics.Counter // by call type
+	CallLatency    metrics.Watcher // by call type, in microseconds
+	EventCount     metrics.Counter // by event type
+	EventErrors    metrics.Counter // by event type
+	EventLatency   metrics.Watcher // by event type, in microseconds
+	OffersReceived metrics.Adder
+	OffersDeclined metrics.Adder
+	OfferResources metrics.Watcher // scalar resources per offer, by resource name
+	Reconnects     metrics.Counter
+
+	subscribed int32 // atomic; 1 once the first SUBSCRIBED event has been observed
+}
+
+// New returns Metrics that are exported by the given sink.
+func New(sink Sink) *Metrics {
+	return &Metrics{
+		CallCount:      sink.Counter("call_count", "The number of outgoing calls.", "type"),
+		CallErrors:     sink.Counter("call_error_count", "The number of errors for outgoing calls.", "type"),
+		CallLatency:    sink.Watcher("call_latency_microseconds", "Time to execute calls, by type.", LatencyBuckets, "type"),
+		EventCount:     sink.Counter("event_count", "The number of events received.", "type"),
+		EventErrors:    sink.Counter("event_error_count", "The number of event processing errors.", "type"),
+		EventLatency:   sink.Watcher("event_latency_microseconds", "Time to process events, by type.", LatencyBuckets, "type"),
+		OffersReceived: sink.Adder("offers_received", "The number of individual offers received."),
+		OffersDeclined: sink.Adder("offers_declined", "The number of offers declined."),
+		OfferResources: sink.Watcher("offer_resources", "Scalar resources per offer, by name.", ResourceBuckets, "name"),
+		Reconnects:     sink.Counter("reconnects", "The number of subscriptions, after the first."),
+	}
+}
+
+// CallRule returns a rule that records the count, errors, and latency of every call, as well as the
+// number of offers that are declined; the latency of a call includes the evaluation of the rest of the
+// chain. The clock, if nil, defaults to time.Now.
+func (m *Metrics) CallRule(clock func() time.Time) callrules.Rule {
+	if clock == nil {
+		clock = time.Now
+	}
+	rule := callrules.Metrics(metrics.NewHarness(m.CallCount, m.CallErrors, m.CallLatency, clock), nil)
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		if c.GetType() != scheduler.Call_DECLINE {
+			return rule(ctx, c, r, err, ch)
+		}
+		declined := len(c.GetDecline().GetOfferIDs())
+		return rule(ctx, c, r, err, func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error) (context.Context, *scheduler.Call, mesos.Response, error) {
+			ctx, c, r, err = ch(ctx, c, r, err)
+			if err == nil {
+				m.OffersDeclined.Int(declined)
+			}
+			return ctx, c, r, err
+		})
+	}
+}
+
+// EventRule returns a rule that records the count, errors, and latency of every event, as well as the
+// number and size of offers, and reconnects; the latency of an event includes the evaluation of the rest
+// of the chain. The clock, if nil, defaults to time.Now.
+func (m *Metrics) EventRule(clock func() time.Time) eventrules.Rule {
+	if clock == nil {
+		clock = time.Now
+	}
+	rule := eventrules.Metrics(metrics.NewHarness(m.EventCount, m.EventErrors, m.EventLatency, clock), nil)
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		switch e.GetType() {
+		case scheduler.Event_SUBSCRIBED:
+			if !atomic.CompareAndSwapInt32(&m.subscribed, 0, 1) {
+				m.Reconnects()
+			}
+		case scheduler.Event_OFFERS:
+			offers := e.GetOffers().GetOffers()
+			m.OffersReceived.Int(len(offers))
+			for i := range offers {
+				m.observeOffer(&offers[i])
+			}
+		}
+		return rule(ctx, e, err, ch)
+	}
+}
+
+func (m *Metrics) observeOffer(o *mesos.Offer) {
+	sums := make(map[string]mesos.ResourceQuantity)
+	for i := range o.Resources {
+		r := &o.Resources[i]
+		if r.GetType() == mesos.SCALAR {
+			sums[r.GetName()] += r.GetScalar().Quantity()
+		}
+	}
+	for name, v := range sums {
+		m.OfferResources(v.Float64(), name)
+	}
+}
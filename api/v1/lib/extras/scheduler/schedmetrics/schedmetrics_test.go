@@ -0,0 +1,164 @@
+package schedmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/metrics"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// testSink records metrics in memory, keyed by name and label values.
+type testSink map[string]float64
+
+func (s testSink) Counter(name, _ string, _ ...string) metrics.Counter {
+	return func(values ...string) { s[name+"/"+key(values)]++ }
+}
+
+func (s testSink) Adder(name, _ string, _ ...string) metrics.Adder {
+	return func(x float64, values ...string) { s[name+"/"+key(values)] += x }
+}
+
+func (s testSink) Watcher(name, _ string, _ []float64, _ ...string) metrics.Watcher {
+	return func(x float64, values ...string) { s[name+"/"+key(values)] += x }
+}
+
+func offers() *scheduler.Event {
+	rs := mesos.Resources{resources.NewCPUs(1).Resource, resources.NewCPUs(2).Resource, resources.NewMemory(64).Resource}
+	return &scheduler.Event{
+		Type: scheduler.Event_OFFERS,
+		Offers: &scheduler.Event_Offers{Offers: []mesos.Offer{
+			{ID: mesos.OfferID{Value: "o1"}, Resources: rs},
+			{ID: mesos.OfferID{Value: "o2"}, Resources: rs},
+		}},
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var (
+		sink = testSink{}
+		m    = New(sink)
+		call = m.CallRule(nil)
+		ev   = m.EventRule(nil)
+		ctx  = context.Background()
+	)
+	for _, e := range []*scheduler.Event{
+		{Type: scheduler.Event_SUBSCRIBED},
+		offers(),
+		{Type: scheduler.Event_SUBSCRIBED},
+		{Type: scheduler.Event_SUBSCRIBED},
+	} {
+		ev.Eval(ctx, e, nil, eventrules.ChainIdentity)
+	}
+	call.Eval(ctx, calls.Decline(mesos.OfferID{Value: "o1"}, mesos.OfferID{Value: "o2"}), nil, nil, callrules.ChainIdentity)
+	callrules.New(call, callrules.Fail(errors.New("injected"))).Eval(ctx, calls.Revive(), nil, nil, callrules.ChainIdentity)
+	for k, want := range map[string]float64{
+		"event_count/subscribed":  3,
+		"event_count/offers":      1,
+		"reconnects/":             2,
+		"offers_received/":        2,
+		"offer_resources/cpus":    6,
+		"offer_resources/mem":     128,
+		"call_count/decline":      1,
+		"offers_declined/":        2,
+		"call_count/revive":       1,
+		"call_error_count/revive": 1,
+	} {
+		if got := sink[k]; got != want {
+			t.Errorf("expected %s = %v instead of %v", k, want, got)
+		}
+	}
+}
+
+func TestPrometheusSink(t *testing.T) {
+	var (
+		r      = prometheus.NewRegistry()
+		sink   = &PrometheusSink{Registerer: r, Subsystem: "test"}
+		record = func() {
+			New(sink).EventRule(nil).Eval(context.Background(), offers(), nil, eventrules.ChainIdentity)
+		}
+	)
+	record()
+	record() // metrics that are generated again share the collectors of the first
+	families, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, f := range families {
+		found[f.GetName()] = true
+		if f.GetName() == "test_offers_received" {
+			if v := f.GetMetric()[0].GetCounter().GetValue(); v != 4 {
+				t.Errorf("expected 4 offers received instead of %v", v)
+			}
+		}
+	}
+	for _, name := range []string{"test_event_count", "test_event_latency_microseconds", "test_offers_received", "test_offer_resources"} {
+		if !found[name] {
+			t.Errorf("expected metric family %q", name)
+		}
+	}
+}
+
+// expvarTests makes the expvar names of every run of TestExpvarSink unique, e.g. given -count=2
+var expvarTests int
+
+func TestExpvarSink(t *testing.T) {
+	expvarTests++
+	var (
+		prefix = fmt.Sprintf("schedmetrics_test%d_", expvarTests)
+		record = func() {
+			m := New(&ExpvarSink{Prefix: prefix})
+			m.EventRule(func() time.Time { return time.Unix(0, 0) }).Eval(context.Background(), offers(), nil, eventrules.ChainIdentity)
+		}
+	)
+	record()
+
+	if v := expvar.Get(prefix + "offers_received").String(); v != `{"": 2}` {
+		t.Errorf("unexpected offers_received %s", v)
+	}
+	var h struct {
+		Count   uint64
+		Sum     float64
+		Buckets []struct {
+			LE    float64
+			Count uint64
+		}
+	}
+	v := expvar.Get(prefix + "offer_resources").(*expvar.Map).Get("mem").String()
+	if err := json.Unmarshal([]byte(v), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Count != 2 || h.Sum != 128 || len(h.Buckets) != len(ResourceBuckets) {
+		t.Fatalf("unexpected histogram %s", v)
+	}
+	for _, b := range h.Buckets {
+		if want := map[bool]uint64{true: 2, false: 0}[b.LE >= 64]; b.Count != want {
+			t.Errorf("expected %d observations <= %v instead of %d", want, b.LE, b.Count)
+		}
+	}
+	if !strings.Contains(expvar.Get(prefix+"event_count").String(), `"offers": 1`) {
+		t.Errorf("unexpected event_count %v", expvar.Get(prefix+"event_count"))
+	}
+
+	// metrics that are generated again, with the same prefix, share the published maps
+	record()
+	if v := expvar.Get(prefix + "offers_received").String(); v != `{"": 4}` {
+		t.Errorf("unexpected offers_received %s", v)
+	}
+	if v := expvar.Get(prefix + "offer_resources").(*expvar.Map).Get("mem").String(); !strings.Contains(v, `"count":4`) {
+		t.Errorf("unexpected histogram %s", v)
+	}
+}
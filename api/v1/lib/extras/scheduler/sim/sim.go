@@ -0,0 +1,631 @@
+// Package sim evaluates the placement logic of frameworks (i.e. their event handlers) against synthetic
+// clusters, entirely in-process and in simulated time: no Mesos master, nor agent, is required. A cluster
+// is described by agent profiles (see Profile); a Simulator offers the unallocated resources of its agents
+// to a handler, launches the tasks of the ACCEPT calls that the handler issues, accounting for their
+// resources, and reports status updates as tasks run and complete. A Report summarizes the outcome: e.g.
+// the utilization of the cluster, and the spread of tasks across agents.
+//
+// Unlike the simulated master of the bench package, which generates load, a Simulator aims for fidelity
+// of resource accounting and determinism: the same cluster, options, and handler yield the same report.
+package sim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/mesostest/gen"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// ErrSubscribe is returned for SUBSCRIBE calls: the handler that's driven by Run is implicitly subscribed.
+var ErrSubscribe = errors.New("sim: subscriptions are implied by Run")
+
+// DefaultRefuseSeconds is the period for which declined, or unused, resources aren't re-offered unless
+// the call specifies otherwise; as per Mesos.
+const DefaultRefuseSeconds = 5.0
+
+type (
+	// Profile describes agents of a synthetic cluster.
+	Profile struct {
+		Name       string // hostnames of the agents are derived from the name; e.g. "large-0.sim"
+		Count      int    // the number of agents
+		Resources  mesos.Resources
+		Attributes map[string]string
+	}
+
+	// Option is a functional configuration option for a Simulator; it returns an Option that acts as an
+	// "undo" if applied to the same Simulator.
+	Option func(*Simulator) Option
+
+	// Simulator is a calls.Caller that simulates a Mesos master, and the agents of a synthetic cluster,
+	// for a single framework. Only the launch operations of ACCEPT calls are supported: other operations
+	// (e.g. reservations) are ignored. Offers are outstanding until they're accepted or declined: they're
+	// neither rescinded nor do they expire. Simulator funcs are safe to invoke concurrently.
+	Simulator struct {
+		step         time.Duration
+		seed         int64
+		taskDuration func(mesos.TaskInfo) time.Duration
+		failureRate  float64
+
+		m          sync.Mutex
+		gen        *gen.Generator
+		random     *rand.Rand
+		start, now time.Time
+		clock      *mesostime.FakeClock // set to now, without the lock held, upon every step
+		agents     []*agent
+		offers     map[string]*offer
+		running    []*task // in order of launch
+		queue      []*scheduler.Event
+		subscribed bool
+		suppressed bool
+		stats      stats
+	}
+
+	agent struct {
+		id          mesos.AgentID
+		hostname    string
+		profile     string
+		attributes  map[string]string
+		total       mesos.Resources
+		allocated   mesos.Resources // to running tasks
+		offered     mesos.Resources // by outstanding offers
+		refuseUntil time.Time
+		launched    int
+		samples     map[string]float64 // sums of the sampled utilization, by resource name
+	}
+
+	offer struct {
+		id        string
+		agent     *agent
+		resources mesos.Resources
+	}
+
+	task struct {
+		id        mesos.TaskID
+		agent     *agent
+		resources mesos.Resources
+		ends      time.Time // zero for tasks that run until they're killed
+	}
+
+	// Report summarizes a simulation. Utilizations are the means, over the steps of the simulation, of the
+	// fraction of each scalar resource (e.g. "cpus", and "mem") that's allocated to tasks.
+	Report struct {
+		Elapsed     time.Duration // simulated time
+		Offers      int
+		Declined    int // offers
+		Launched    int // tasks
+		Finished    int
+		Failed      int
+		Killed      int
+		Rejected    int // tasks that weren't launched: e.g. because of insufficient, or invalid, offers
+		Running     int // tasks that are running at the end of the simulation
+		Utilization map[string]float64
+		Agents      []AgentReport // in the order of the profiles of the cluster
+	}
+
+	// AgentReport summarizes the simulation of an agent.
+	AgentReport struct {
+		Hostname    string
+		Profile     string
+		Launched    int // tasks
+		Utilization map[string]float64
+	}
+
+	stats struct {
+		offers, declined                             int
+		launched, finished, failed, killed, rejected int
+		samples                                      int
+		utilization                                  map[string]float64 // sums of the sampled utilization
+	}
+)
+
+// Step configures the interval by which simulated time advances; status updates of tasks that complete,
+// and offers of resources that become available, are generated at each step. Defaults to one second.
+func Step(d time.Duration) Option {
+	return func(s *Simulator) Option {
+		old := s.step
+		s.step = d
+		return Step(old)
+	}
+}
+
+// Seed configures the seed of the generator of IDs, and of task failures; defaults to 1.
+func Seed(seed int64) Option {
+	return func(s *Simulator) Option {
+		old := s.seed
+		s.seed = seed
+		return Seed(old)
+	}
+}
+
+// TaskDuration configures the func that decides the (simulated) duration for which a launched task runs
+// before it completes; tasks for which the func returns zero, or all tasks if the func is nil, run until
+// they're killed. Defaults to one minute for all tasks.
+func TaskDuration(f func(mesos.TaskInfo) time.Duration) Option {
+	return func(s *Simulator) Option {
+		old := s.taskDuration
+		s.taskDuration = f
+		return TaskDuration(old)
+	}
+}
+
+// FailureRate configures the probability, in [0, 1], that a task fails (i.e. TASK_FAILED) rather than
+// finishes (i.e. TASK_FINISHED) upon completion; defaults to zero.
+func FailureRate(p float64) Option {
+	return func(s *Simulator) Option {
+		old := s.failureRate
+		s.failureRate = p
+		return FailureRate(old)
+	}
+}
+
+// New returns a Simulator of a cluster with the agents of the given profiles.
+func New(profiles []Profile, opts ...Option) *Simulator {
+	s := &Simulator{
+		step:         time.Second,
+		seed:         1,
+		taskDuration: func(mesos.TaskInfo) time.Duration { return time.Minute },
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	if s.step <= 0 {
+		s.step = time.Second
+	}
+	s.gen = gen.New(s.seed)
+	s.random = rand.New(rand.NewSource(s.seed))
+	s.start = time.Unix(1500000000, 0)
+	s.now = s.start
+	s.clock = mesostime.NewFakeClock(s.start)
+	s.offers = make(map[string]*offer)
+	s.stats.utilization = make(map[string]float64)
+	for _, p := range profiles {
+		for i := 0; i < p.Count; i++ {
+			s.agents = append(s.agents, &agent{
+				id:         s.gen.AgentID(),
+				hostname:   fmt.Sprintf("%s-%d.sim", p.Name, i),
+				profile:    p.Name,
+				attributes: p.Attributes,
+				total:      p.Resources.Clone(),
+				samples:    make(map[string]float64),
+			})
+		}
+	}
+	return s
+}
+
+// Run drives the given handler, as a subscribed framework, for the given (simulated) duration; events are
+// delivered serially, and the calls that the handler issues to the Simulator are expected to be issued
+// before the handler returns. Run may be invoked repeatedly: simulated time, and the state of the cluster,
+// carry over. The report of the simulation is returned, along with the first error that's returned by the
+// handler, if any, which ends the simulation.
+func (s *Simulator) Run(ctx context.Context, h events.Handler, d time.Duration) (*Report, error) {
+	s.m.Lock()
+	var (
+		end     = s.now.Add(d)
+		advance = s.subscribed // the current step was simulated by a previous run
+	)
+	if !s.subscribed {
+		s.subscribed = true
+		s.queue = append(s.queue, s.gen.Subscribed())
+	}
+	s.m.Unlock()
+	for {
+		s.m.Lock()
+		if advance {
+			s.now = s.now.Add(s.step)
+		}
+		now := s.now
+		s.m.Unlock()
+		s.clock.Set(now) // timers' funcs may invoke the Simulator
+
+		s.m.Lock()
+		advance = true
+		s.tick()
+		done := !s.now.Before(end)
+		s.m.Unlock()
+
+		if err := s.deliver(ctx, h); err != nil {
+			return s.Report(), err
+		}
+		if done {
+			return s.Report(), nil
+		}
+	}
+}
+
+// Now returns the current (simulated) time; e.g. for the clocks of the components of a handler.
+func (s *Simulator) Now() time.Time {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.now
+}
+
+// Clock returns a Clock of the simulated time, for the components of a handler: its timers fire as the
+// simulation advances, in between the steps of the simulation.
+func (s *Simulator) Clock() mesostime.Clock { return s.clock }
+
+// Report returns the report of the simulation thus far.
+func (s *Simulator) Report() *Report {
+	s.m.Lock()
+	defer s.m.Unlock()
+	mean := func(sums map[string]float64) map[string]float64 {
+		m := make(map[string]float64, len(sums))
+		for name, sum := range sums {
+			m[name] = sum / float64(s.stats.samples)
+		}
+		return m
+	}
+	r := &Report{
+		Elapsed:     s.now.Sub(s.start),
+		Offers:      s.stats.offers,
+		Declined:    s.stats.declined,
+		Launched:    s.stats.launched,
+		Finished:    s.stats.finished,
+		Failed:      s.stats.failed,
+		Killed:      s.stats.killed,
+		Rejected:    s.stats.rejected,
+		Running:     len(s.running),
+		Utilization: mean(s.stats.utilization),
+		Agents:      make([]AgentReport, 0, len(s.agents)),
+	}
+	for _, a := range s.agents {
+		r.Agents = append(r.Agents, AgentReport{
+			Hostname:    a.hostname,
+			Profile:     a.profile,
+			Launched:    a.launched,
+			Utilization: mean(a.samples),
+		})
+	}
+	return r
+}
+
+// String returns a human-readable, tabular, rendering of the report.
+func (r *Report) String() string {
+	var (
+		buf   bytes.Buffer
+		w     = tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+		names []string
+	)
+	for name := range r.Utilization {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	utilization := func(u map[string]float64) string {
+		var b bytes.Buffer
+		for _, name := range names {
+			fmt.Fprintf(&b, "\t%.1f%%", 100*u[name])
+		}
+		return b.String()
+	}
+	fmt.Fprintf(w, "elapsed %v: offers %d (declined %d), tasks launched %d, finished %d, failed %d, killed %d, rejected %d, running %d\n",
+		r.Elapsed, r.Offers, r.Declined, r.Launched, r.Finished, r.Failed, r.Killed, r.Rejected, r.Running)
+	fmt.Fprintf(w, "\t\ttasks\t%s\n", strings.Join(names, "\t"))
+	fmt.Fprintf(w, "cluster\t\t%d%s\n", r.Launched, utilization(r.Utilization))
+	for _, a := range r.Agents {
+		fmt.Fprintf(w, "%s\t%s\t%d%s\n", a.Hostname, a.Profile, a.Launched, utilization(a.Utilization))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// deliver invokes the handler for the queued events, including those that are queued as a consequence of
+// the calls of the handler, until there are none.
+func (s *Simulator) deliver(ctx context.Context, h events.Handler) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.m.Lock()
+		if len(s.queue) == 0 {
+			s.m.Unlock()
+			return nil
+		}
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		s.m.Unlock()
+
+		if err := h.HandleEvent(ctx, e); err != nil {
+			return err
+		}
+	}
+}
+
+// tick completes the tasks whose durations have elapsed, samples the utilization of the cluster, and
+// offers the resources that are available.
+func (s *Simulator) tick() {
+	running := s.running[:0]
+	for _, t := range s.running {
+		if t.ends.IsZero() || s.now.Before(t.ends) {
+			running = append(running, t)
+			continue
+		}
+		state := mesos.TASK_FINISHED
+		if s.failureRate > 0 && s.random.Float64() < s.failureRate {
+			state = mesos.TASK_FAILED
+			s.stats.failed++
+		} else {
+			s.stats.finished++
+		}
+		t.agent.allocated.Subtract(t.resources...)
+		s.update(t.id, t.agent.id, state)
+	}
+	s.running = running
+
+	s.sample()
+
+	if s.suppressed {
+		return
+	}
+	var offers []mesos.Offer
+	for _, a := range s.agents {
+		if s.now.Before(a.refuseUntil) {
+			continue
+		}
+		available := a.total.Minus(a.allocated...).Minus(a.offered...)
+		if len(available) == 0 {
+			continue
+		}
+		o := mesos.Offer{
+			ID:          s.gen.OfferID(),
+			FrameworkID: s.gen.FrameworkID(),
+			AgentID:     a.id,
+			Hostname:    a.hostname,
+			Resources:   available,
+		}
+		if len(a.attributes) > 0 {
+			gen.WithAttributes(a.attributes)(&o)
+		}
+		a.offered.Add(available...)
+		s.offers[o.ID.Value] = &offer{id: o.ID.Value, agent: a, resources: available.Clone()}
+		offers = append(offers, o)
+	}
+	if len(offers) > 0 {
+		s.stats.offers += len(offers)
+		s.queue = append(s.queue, gen.OffersEvent(offers...))
+	}
+}
+
+func (s *Simulator) sample() {
+	s.stats.samples++
+	totals := make(map[string][2]float64)
+	for _, a := range s.agents {
+		for name, typ := range resources.TypesOf(a.total...) {
+			if typ != mesos.SCALAR {
+				continue
+			}
+			var (
+				total, _     = name.Sum(a.total...)
+				allocated, _ = name.Sum(a.allocated...)
+				t            = total.GetScalar().GetValue()
+				u            = allocated.GetScalar().GetValue()
+			)
+			if t > 0 {
+				a.samples[string(name)] += u / t
+			}
+			x := totals[string(name)]
+			totals[string(name)] = [2]float64{x[0] + u, x[1] + t}
+		}
+	}
+	for name, x := range totals {
+		if x[1] > 0 {
+			s.stats.utilization[name] += x[0] / x[1]
+		}
+	}
+}
+
+func (s *Simulator) update(id mesos.TaskID, agentID mesos.AgentID, state mesos.TaskState, opts ...gen.StatusOpt) {
+	s.queue = append(s.queue, gen.UpdateEvent(s.gen.TaskStatus(id, agentID, state, opts...)))
+}
+
+// Call implements calls.Caller for Simulator. The events that result from a call are delivered after the
+// handler that issued the call returns.
+func (s *Simulator) Call(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	switch c.GetType() {
+	case scheduler.Call_SUBSCRIBE:
+		return nil, ErrSubscribe
+	case scheduler.Call_ACCEPT:
+		s.accept(c.GetAccept())
+	case scheduler.Call_DECLINE:
+		d := c.GetDecline()
+		s.stats.declined += len(d.GetOfferIDs())
+		for _, id := range d.GetOfferIDs() {
+			if o, ok := s.offers[id.Value]; ok {
+				s.release(o)
+				s.refuse(o.agent, d.GetFilters())
+			}
+		}
+	case scheduler.Call_KILL:
+		s.kill(c.GetKill().GetTaskID())
+	case scheduler.Call_RECONCILE:
+		s.reconcile(c.GetReconcile().GetTasks())
+	case scheduler.Call_REVIVE:
+		s.suppressed = false
+		for _, a := range s.agents {
+			a.refuseUntil = time.Time{}
+		}
+	case scheduler.Call_SUPPRESS:
+		s.suppressed = true
+	}
+	return nil, nil
+}
+
+// accept launches the tasks of the operations of the call, if the offers are valid: i.e. outstanding, and of
+// the same agent. Resources of the offers that aren't used by the tasks are filtered as per the call.
+func (s *Simulator) accept(a *scheduler.Call_Accept) {
+	var (
+		offers    []*offer
+		agent     *agent
+		available mesos.Resources
+		valid     = len(a.GetOfferIDs()) > 0
+	)
+	for _, id := range a.GetOfferIDs() {
+		o, ok := s.offers[id.Value]
+		if !ok || (agent != nil && o.agent != agent) {
+			valid = false
+			continue
+		}
+		agent = o.agent
+		offers = append(offers, o)
+		available.Add(o.resources...)
+	}
+	var launches [][]mesos.TaskInfo
+	for _, op := range a.GetOperations() {
+		switch op.GetType() {
+		case mesos.Offer_Operation_LAUNCH:
+			for _, t := range op.GetLaunch().GetTaskInfos() {
+				launches = append(launches, []mesos.TaskInfo{t})
+			}
+		case mesos.Offer_Operation_LAUNCH_GROUP:
+			launches = append(launches, op.GetLaunchGroup().GetTaskGroup().Tasks)
+		}
+	}
+	if !valid {
+		for _, group := range launches {
+			for _, t := range group {
+				s.stats.rejected++
+				s.update(t.TaskID, t.AgentID, mesos.TASK_DROPPED,
+					gen.WithReason(mesos.SOURCE_MASTER, mesos.REASON_INVALID_OFFERS))
+			}
+		}
+		for _, o := range offers {
+			s.release(o)
+		}
+		return
+	}
+	for _, o := range offers {
+		s.release(o)
+	}
+	for _, group := range launches {
+		available = s.launch(agent, available, group)
+	}
+	s.refuse(agent, a.GetFilters())
+}
+
+// launch launches the tasks of a group, all or none, if the available resources suffice; it returns the
+// resources that remain available.
+func (s *Simulator) launch(a *agent, available mesos.Resources, group []mesos.TaskInfo) mesos.Resources {
+	var wants mesos.Resources
+	for i, t := range group {
+		wants.Add(t.Resources...)
+		if t.Executor != nil && i == 0 {
+			wants.Add(t.Executor.Resources...)
+		}
+	}
+	reason := ""
+	switch {
+	case len(group) == 0:
+		return available
+	case !resources.ContainsAll(available, wants):
+		reason = "insufficient resources: wants " + wants.String() + ", offered " + available.String()
+	default:
+		for _, t := range group {
+			if s.find(t.TaskID) != nil {
+				reason = "task " + t.TaskID.Value + " is already running"
+			}
+		}
+	}
+	if reason != "" {
+		for _, t := range group {
+			s.stats.rejected++
+			s.update(t.TaskID, a.id, mesos.TASK_ERROR,
+				gen.WithReason(mesos.SOURCE_MASTER, mesos.REASON_TASK_INVALID), gen.WithMessage(reason))
+		}
+		return available
+	}
+	available.Subtract(wants...)
+	a.allocated.Add(wants...)
+	a.launched += len(group)
+	for i, t := range group {
+		rs := mesos.Resources(nil).Plus(t.Resources...)
+		if t.Executor != nil && i == 0 {
+			rs.Add(t.Executor.Resources...)
+		}
+		tk := &task{id: t.TaskID, agent: a, resources: rs}
+		if s.taskDuration != nil {
+			if d := s.taskDuration(t); d > 0 {
+				tk.ends = s.now.Add(d)
+			}
+		}
+		s.running = append(s.running, tk)
+		s.stats.launched++
+		s.update(t.TaskID, a.id, mesos.TASK_RUNNING)
+	}
+	return available
+}
+
+// release returns the resources of an offer to its agent.
+func (s *Simulator) release(o *offer) {
+	delete(s.offers, o.id)
+	o.agent.offered.Subtract(o.resources...)
+}
+
+// refuse stops the resources of an agent from being offered for the period specified by the filters.
+func (s *Simulator) refuse(a *agent, f *mesos.Filters) {
+	refuse := DefaultRefuseSeconds
+	if f != nil && f.RefuseSeconds != nil {
+		refuse = f.GetRefuseSeconds()
+	}
+	if until := s.now.Add(time.Duration(refuse * float64(time.Second))); until.After(a.refuseUntil) {
+		a.refuseUntil = until
+	}
+}
+
+func (s *Simulator) find(id mesos.TaskID) *task {
+	for _, t := range s.running {
+		if t.id.Value == id.Value {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) kill(id mesos.TaskID) {
+	for i, t := range s.running {
+		if t.id.Value == id.Value {
+			s.running = append(s.running[:i], s.running[i+1:]...)
+			t.agent.allocated.Subtract(t.resources...)
+			s.stats.killed++
+			s.update(t.id, t.agent.id, mesos.TASK_KILLED)
+			return
+		}
+	}
+	s.update(id, mesos.AgentID{}, mesos.TASK_LOST, gen.Reconciliation(), unknownAgent)
+}
+
+// reconcile reports the states of the given tasks, or of all running tasks if none are given; the states
+// of unknown tasks are reported as TASK_LOST.
+func (s *Simulator) reconcile(tasks []scheduler.Call_Reconcile_Task) {
+	if len(tasks) == 0 {
+		for _, t := range s.running {
+			s.update(t.id, t.agent.id, mesos.TASK_RUNNING, gen.Reconciliation())
+		}
+		return
+	}
+	for _, rt := range tasks {
+		if t := s.find(rt.TaskID); t != nil {
+			s.update(t.id, t.agent.id, mesos.TASK_RUNNING, gen.Reconciliation())
+		} else {
+			s.update(rt.TaskID, mesos.AgentID{}, mesos.TASK_LOST, gen.Reconciliation(), unknownAgent)
+		}
+	}
+}
+
+// unknownAgent clears the agent of the status of a task that isn't known to the simulated master.
+func unknownAgent(s *mesos.TaskStatus) { s.AgentID = nil }
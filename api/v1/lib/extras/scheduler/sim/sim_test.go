@@ -0,0 +1,213 @@
+package sim_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/sim"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+func agentResources(cpus, mem float64) mesos.Resources {
+	var rs mesos.Resources
+	return rs.Add(resources.NewCPUs(cpus).Resource, resources.NewMemory(mem).Resource)
+}
+
+// firstFit launches tasks of one cpu, until the wanted number of tasks is running, upon the first offers
+// that fit them.
+type firstFit struct {
+	caller  *sim.Simulator
+	wanted  int
+	tasks   int
+	updates map[string][]mesos.TaskState
+	// oversized is launched along with the first task, and requires more resources than any agent has
+	oversized bool
+}
+
+func (f *firstFit) HandleEvent(ctx context.Context, e *scheduler.Event) error {
+	switch e.GetType() {
+	case scheduler.Event_OFFERS:
+		for _, o := range e.GetOffers().GetOffers() {
+			var (
+				remaining = mesos.Resources(o.Resources)
+				task      = agentResources(1, 1024)
+				tasks     []mesos.TaskInfo
+			)
+			if !f.oversized {
+				f.oversized = true
+				tasks = append(tasks, mesos.TaskInfo{
+					TaskID:    mesos.TaskID{Value: "oversized"},
+					AgentID:   o.AgentID,
+					Resources: agentResources(100, 1024),
+				})
+			}
+			for f.tasks < f.wanted && resources.ContainsAll(remaining, task) {
+				tasks = append(tasks, mesos.TaskInfo{
+					TaskID:    mesos.TaskID{Value: fmt.Sprintf("t-%d", f.tasks)},
+					AgentID:   o.AgentID,
+					Resources: task,
+				})
+				remaining.Subtract(task...)
+				f.tasks++
+			}
+			accept := calls.Accept(calls.OfferOperations{calls.OpLaunch(tasks...)}.WithOffers(o.ID)).
+				With(calls.RefuseSeconds(0))
+			if _, err := f.caller.Call(ctx, accept); err != nil {
+				return err
+			}
+		}
+	case scheduler.Event_UPDATE:
+		s := e.GetUpdate().GetStatus()
+		f.updates[s.TaskID.Value] = append(f.updates[s.TaskID.Value], s.GetState())
+	}
+	return nil
+}
+
+func TestPlacement(t *testing.T) {
+	profiles := []sim.Profile{
+		{Name: "large", Count: 2, Resources: agentResources(4, 8192), Attributes: map[string]string{"rack": "a"}},
+		{Name: "small", Count: 2, Resources: agentResources(1, 1024)},
+	}
+	run := func() (*sim.Report, *firstFit) {
+		var (
+			s = sim.New(profiles, sim.TaskDuration(nil))
+			h = &firstFit{caller: s, wanted: 12, updates: map[string][]mesos.TaskState{}}
+		)
+		report, err := s.Run(context.Background(), h, 10*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report, h
+	}
+	report, h := run()
+	t.Log(report)
+
+	if report.Launched != 10 || report.Running != 10 || report.Rejected != 1 {
+		t.Fatalf("expected the capacity of the cluster to be allocated: %v", report)
+	}
+	if report.Elapsed != 10*time.Second {
+		t.Fatalf("unexpected elapsed time: %v", report.Elapsed)
+	}
+	// resources are allocated during the first step, after their utilization is sampled
+	if u := report.Utilization["cpus"]; u != 10.0/11 {
+		t.Fatalf("unexpected cpu utilization: %v", u)
+	}
+	for i, want := range []int{4, 4, 1, 1} {
+		if a := report.Agents[i]; a.Launched != want {
+			t.Errorf("expected %d tasks on agent %s, got %d", want, a.Hostname, a.Launched)
+		}
+	}
+	if s := h.updates["oversized"]; len(s) != 1 || s[0] != mesos.TASK_ERROR {
+		t.Fatalf("expected the oversized task to be rejected: %v", s)
+	}
+	if s := h.updates["t-0"]; len(s) != 1 || s[0] != mesos.TASK_RUNNING {
+		t.Fatalf("expected the task to be running: %v", s)
+	}
+	if _, ok := h.updates["t-10"]; ok {
+		t.Fatalf("unexpected launch beyond the capacity of the cluster")
+	}
+
+	// simulations are deterministic
+	if again, _ := run(); !reflect.DeepEqual(report, again) {
+		t.Fatalf("expected identical reports:\n%v\n%v", report, again)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = sim.New([]sim.Profile{{Name: "agent", Count: 1, Resources: agentResources(1, 1024)}},
+			sim.TaskDuration(func(mesos.TaskInfo) time.Duration { return 2 * time.Second }))
+		h = &firstFit{caller: s, wanted: 3, oversized: true, updates: map[string][]mesos.TaskState{}}
+	)
+	if _, err := s.Call(ctx, calls.Subscribe(&mesos.FrameworkInfo{})); err != sim.ErrSubscribe {
+		t.Fatalf("expected ErrSubscribe, got %v", err)
+	}
+	var fired []time.Time
+	s.Clock().AfterFunc(2500*time.Millisecond, func() { fired = append(fired, s.Now()) })
+	report, err := s.Run(ctx, h, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start := s.Now().Add(-5 * time.Second); len(fired) != 1 || !fired[0].Equal(start.Add(3*time.Second)) {
+		t.Fatalf("expected the timer to fire at the step that follows its deadline: %v", fired)
+	}
+	if report.Launched != 3 || report.Finished != 2 || report.Running != 1 {
+		t.Fatalf("expected tasks to be launched as resources are released: %v", report)
+	}
+	for _, id := range []string{"t-0", "t-1"} {
+		if s := h.updates[id]; !reflect.DeepEqual(s, []mesos.TaskState{mesos.TASK_RUNNING, mesos.TASK_FINISHED}) {
+			t.Fatalf("unexpected updates of %s: %v", id, s)
+		}
+	}
+
+	for _, c := range []*scheduler.Call{
+		calls.Kill("t-2", ""),
+		calls.Kill("unknown", ""),
+		calls.Reconcile(calls.ReconcileTasks(map[string]string{"t-0": ""})),
+	} {
+		if _, err := s.Call(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if report, err = s.Run(ctx, h, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if report.Killed != 1 || report.Running != 0 || report.Elapsed != 6*time.Second {
+		t.Fatalf("expected the task to be killed: %v", report)
+	}
+	for id, want := range map[string][]mesos.TaskState{
+		"t-2":     {mesos.TASK_RUNNING, mesos.TASK_KILLED},
+		"unknown": {mesos.TASK_LOST},
+		"t-0":     {mesos.TASK_RUNNING, mesos.TASK_FINISHED, mesos.TASK_LOST},
+	} {
+		if s := h.updates[id]; !reflect.DeepEqual(s, want) {
+			t.Errorf("expected updates %v of %s, got %v", want, id, s)
+		}
+	}
+}
+
+func TestDecline(t *testing.T) {
+	var (
+		ctx = context.Background()
+		s   = sim.New([]sim.Profile{{Name: "agent", Count: 1, Resources: agentResources(1, 1024)}})
+		h   events.HandlerFunc
+	)
+	h = func(ctx context.Context, e *scheduler.Event) error {
+		for _, o := range e.GetOffers().GetOffers() {
+			if _, err := s.Call(ctx, calls.Decline(o.ID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	report, err := s.Run(ctx, h, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// declined resources are filtered for DefaultRefuseSeconds
+	if report.Offers != 3 || report.Declined != 3 {
+		t.Fatalf("expected declined resources to be filtered: %v", report)
+	}
+
+	// suppressed frameworks aren't offered resources, until they revive
+	if _, err = s.Call(ctx, calls.Suppress()); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ = s.Run(ctx, h, 10*time.Second); report.Offers != 3 {
+		t.Fatalf("unexpected offers while suppressed: %v", report)
+	}
+	if _, err = s.Call(ctx, calls.Revive()); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ = s.Run(ctx, h, time.Second); report.Offers != 4 {
+		t.Fatalf("expected an offer upon revival: %v", report)
+	}
+}
@@ -0,0 +1,101 @@
+// Package snapshot combines the views of the state of a scheduler's task registry and offer hoard into a
+// single serializable Snapshot, and serves it as JSON for debugging endpoints and support bundles.
+package snapshot
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+type (
+	// Option is a functional configuration option for a Snapshotter; it returns an Option that acts as an
+	// "undo" if applied to the same Snapshotter.
+	Option func(*Snapshotter) Option
+
+	// Snapshotter takes snapshots of the state of the components that it's configured with. Snapshotter
+	// funcs are safe to invoke concurrently with the use of those components.
+	Snapshotter struct {
+		clock  mesostime.Clock
+		tasks  *tasks.Registry
+		offers *offers.Hoard
+	}
+
+	// Snapshot is a point-in-time view of the state of a scheduler; components that weren't configured
+	// are omitted. The Mesos messages of a snapshot are protobufs, and may be extracted and encoded as such.
+	Snapshot struct {
+		Time   time.Time             `json:"time"`
+		Tasks  *tasks.Snapshot       `json:"tasks,omitempty"`
+		Offers *offers.HoardSnapshot `json:"offers,omitempty"`
+	}
+)
+
+// Tasks configures the task registry of a Snapshotter.
+func Tasks(r *tasks.Registry) Option {
+	return func(s *Snapshotter) Option {
+		old := s.tasks
+		s.tasks = r
+		return Tasks(old)
+	}
+}
+
+// Offers configures the offer hoard of a Snapshotter.
+func Offers(h *offers.Hoard) Option {
+	return func(s *Snapshotter) Option {
+		old := s.offers
+		s.offers = h
+		return Offers(old)
+	}
+}
+
+// Clock configures the clock that times snapshots; defaults to mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(s *Snapshotter) Option {
+		old := s.clock
+		s.clock = clock
+		return Clock(old)
+	}
+}
+
+// New returns a Snapshotter configured with the given options.
+func New(opts ...Option) *Snapshotter {
+	s := &Snapshotter{clock: mesostime.SystemClock}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	return s
+}
+
+// Take returns a snapshot of the current state of the configured components. Each component is
+// snapshotted atomically, but not all components at the same instant.
+func (s *Snapshotter) Take() Snapshot {
+	snap := Snapshot{Time: s.clock.Now()}
+	if s.tasks != nil {
+		t := s.tasks.Snapshot()
+		snap.Tasks = &t
+	}
+	if s.offers != nil {
+		o := s.offers.Snapshot()
+		snap.Offers = &o
+	}
+	return snap
+}
+
+// Handler returns an http.Handler that responds to every request with a JSON encoded snapshot.
+func (s *Snapshotter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		b, err := json.MarshalIndent(s.Take(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(b, '\n'))
+	})
+}
@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/offers"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/tasks"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestSnapshot(t *testing.T) {
+	var (
+		now   = time.Unix(1000, 0).UTC()
+		clock = mesostime.NewFakeClock(now)
+		reg   = tasks.NewRegistry(&mesos.FrameworkInfo{})
+		hoard = offers.NewHoard(time.Minute, offers.HoardClock(clock))
+		offer = func(id string) mesos.Offer { return mesos.Offer{ID: mesos.OfferID{Value: id}} }
+	)
+	reg.Launched(
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "2"}, AgentID: mesos.AgentID{Value: "a"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "1"}, AgentID: mesos.AgentID{Value: "a"}},
+	)
+	hoard.Add(offer("b"), offer("a"), offer("c"))
+	hoard.AddInverse(mesos.InverseOffer{OfferID: mesos.OfferID{Value: "i"}})
+	p, err := hoard.Plan(context.Background(), mesos.OfferID{Value: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Cancel()
+
+	// unconfigured components are omitted
+	if snap := New(Clock(clock)).Take(); snap.Tasks != nil || snap.Offers != nil || !snap.Time.Equal(now) {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+
+	s := New(Clock(clock), Tasks(reg), Offers(hoard))
+	snap := s.Take()
+	if ts := snap.Tasks.Tasks; len(ts) != 2 || ts[0].TaskID.Value != "1" || ts[1].TaskID.Value != "2" {
+		t.Fatalf("unexpected tasks %+v", ts)
+	}
+	o := snap.Offers
+	if o.Hold != time.Minute || len(o.Held) != 2 || o.Held[0].Offer.ID.Value != "b" || o.Held[1].Offer.ID.Value != "a" {
+		t.Fatalf("unexpected held offers %+v", o.Held)
+	}
+	if !o.Held[0].Received.Equal(now) {
+		t.Errorf("expected offer received at %v instead of %v", now, o.Held[0].Received)
+	}
+	if len(o.Planned) != 1 || o.Planned[0].ID.Value != "c" {
+		t.Fatalf("unexpected planned offers %+v", o.Planned)
+	}
+	if len(o.InverseOffers) != 1 || o.InverseOffers[0].OfferID.Value != "i" {
+		t.Fatalf("unexpected inverse offers %+v", o.InverseOffers)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/snapshot", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	var decoded Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Tasks.Tasks) != 2 || len(decoded.Offers.Held) != 2 || decoded.Offers.Held[1].Offer.ID.Value != "a" {
+		t.Fatalf("unexpected decoded snapshot %+v", decoded)
+	}
+}
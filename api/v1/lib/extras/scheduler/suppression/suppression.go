@@ -0,0 +1,232 @@
+// Package suppression tracks the roles for which a framework has suppressed offers: both as the master sees
+// it (the effective state, as established by successful SUBSCRIBE, SUPPRESS, REVIVE, and UPDATE_FRAMEWORK
+// calls) and as the framework wishes it to be (the desired state). The two diverge when the framework
+// re-subscribes, since SUBSCRIBE resets the suppressed roles of the framework; a mismatch is a common cause
+// of "no offers" incidents, see Tracker.Mismatched and ResyncOnSubscribed.
+package suppression
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+type (
+	// Option is a functional configuration option for a Tracker; it returns an Option that acts as an
+	// "undo" if applied to the same Tracker.
+	Option func(*Tracker) Option
+
+	// Tracker tracks the suppressed roles of a framework. Its rules must be added to the call and event
+	// processing chains of the scheduler. Tracker funcs are safe to invoke concurrently.
+	Tracker struct {
+		resync func() calls.Caller
+
+		m          sync.Mutex
+		roles      []string // of the framework
+		effective  set
+		desired    set
+		subscribed int // the number of SUBSCRIBED events
+	}
+
+	// State reports the suppression state of a framework.
+	State struct {
+		Roles      []string `json:"roles"`
+		Suppressed []string `json:"suppressed"` // as the master sees it
+		Desired    []string `json:"desired"`
+	}
+
+	set map[string]struct{}
+)
+
+// ResyncOnSubscribed configures the Tracker's EventRule to resynchronize the suppressed roles upon every
+// SUBSCRIBED event that follows a re-subscription: roles that should be suppressed are suppressed, and all
+// other roles are revived (which also clears any offer filters). The calls are sent via the caller that's
+// returned by the given func, which should include the Tracker's CallRule. Disabled by default.
+func ResyncOnSubscribed(callerLookup func() calls.Caller) Option {
+	return func(t *Tracker) Option {
+		old := t.resync
+		t.resync = callerLookup
+		return ResyncOnSubscribed(old)
+	}
+}
+
+// NewTracker returns a Tracker for a framework that subscribes with the given info (which determines the
+// roles of a framework that suppresses, or revives, all of its roles).
+func NewTracker(info *mesos.FrameworkInfo, opts ...Option) *Tracker {
+	t := &Tracker{
+		roles:     frameworkRoles(info),
+		effective: set{},
+		desired:   set{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	return t
+}
+
+func frameworkRoles(info *mesos.FrameworkInfo) []string {
+	if roles := info.GetRoles(); len(roles) > 0 {
+		return append([]string(nil), roles...)
+	}
+	if info.Role != nil {
+		return []string{info.GetRole()}
+	}
+	return []string{"*"}
+}
+
+// State returns the current suppression state; roles are sorted.
+func (t *Tracker) State() State {
+	t.m.Lock()
+	defer t.m.Unlock()
+	roles := append([]string(nil), t.roles...)
+	sort.Strings(roles)
+	return State{Roles: roles, Suppressed: t.effective.sorted(), Desired: t.desired.sorted()}
+}
+
+// Suppressed returns the sorted roles that the master considers to be suppressed.
+func (t *Tracker) Suppressed() []string {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.effective.sorted()
+}
+
+// IsSuppressed returns true if the master considers the given role to be suppressed.
+func (t *Tracker) IsSuppressed(role string) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	_, ok := t.effective[role]
+	return ok
+}
+
+// Mismatched returns the sorted roles for which the desired suppression state differs from that of the
+// master.
+func (t *Tracker) Mismatched() (roles []string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for _, r := range t.roles {
+		_, e := t.effective[r]
+		_, d := t.desired[r]
+		if e != d {
+			roles = append(roles, r)
+		}
+	}
+	sort.Strings(roles)
+	return
+}
+
+// CallRule returns a Rule that records the effect of successful SUBSCRIBE, SUPPRESS, REVIVE, and
+// UPDATE_FRAMEWORK calls. SUPPRESS and REVIVE calls determine the desired state as well; SUBSCRIBE and
+// UPDATE_FRAMEWORK calls determine it only upon the first subscription.
+func (t *Tracker) CallRule() callrules.Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		ctx, c, r, err = ch(ctx, c, r, err)
+		if err == nil {
+			t.called(c)
+		}
+		return ctx, c, r, err
+	}
+}
+
+func (t *Tracker) called(c *scheduler.Call) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	switch c.GetType() {
+	case scheduler.Call_SUBSCRIBE:
+		s := c.GetSubscribe()
+		t.roles = frameworkRoles(s.GetFrameworkInfo())
+		t.effective = newSet(s.SuppressedRoles...)
+		if t.subscribed == 0 {
+			t.desired = newSet(s.SuppressedRoles...)
+		}
+	case scheduler.Call_UPDATE_FRAMEWORK:
+		u := c.GetUpdateFramework()
+		t.roles = frameworkRoles(&u.FrameworkInfo)
+		t.effective = newSet(u.SuppressedRoles...)
+		t.desired = newSet(u.SuppressedRoles...)
+	case scheduler.Call_SUPPRESS:
+		roles := c.GetSuppress().GetRoles()
+		if len(roles) == 0 {
+			roles = t.roles
+		}
+		t.effective.add(roles...)
+		t.desired.add(roles...)
+	case scheduler.Call_REVIVE:
+		roles := c.GetRevive().GetRoles()
+		if len(roles) == 0 {
+			roles = t.roles
+		}
+		t.effective.remove(roles...)
+		t.desired.remove(roles...)
+	}
+}
+
+// EventRule returns a Rule that counts SUBSCRIBED events and, if so configured, resynchronizes the
+// suppression state upon re-subscription (see ResyncOnSubscribed). Errors that occur while resynchronizing
+// are propagated along the chain.
+func (t *Tracker) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if e.GetType() != scheduler.Event_SUBSCRIBED {
+			return ch(ctx, e, err)
+		}
+		t.m.Lock()
+		t.subscribed++
+		resubscribed := t.subscribed > 1
+		var suppress, revive []string
+		for _, r := range t.roles {
+			if _, ok := t.desired[r]; ok {
+				if _, ok := t.effective[r]; !ok {
+					suppress = append(suppress, r)
+				}
+			} else {
+				revive = append(revive, r)
+			}
+		}
+		t.m.Unlock()
+
+		if resubscribed && t.resync != nil {
+			caller := t.resync()
+			if len(suppress) > 0 {
+				err = eventrules.Error2(err, calls.CallNoData(ctx, caller, calls.SuppressWith(suppress)))
+			}
+			if len(revive) > 0 {
+				err = eventrules.Error2(err, calls.CallNoData(ctx, caller, calls.ReviveWith(revive)))
+			}
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+func newSet(xs ...string) set {
+	s := make(set, len(xs))
+	s.add(xs...)
+	return s
+}
+
+func (s set) add(xs ...string) {
+	for _, x := range xs {
+		s[x] = struct{}{}
+	}
+}
+
+func (s set) remove(xs ...string) {
+	for _, x := range xs {
+		delete(s, x)
+	}
+}
+
+func (s set) sorted() []string {
+	result := make([]string, 0, len(s))
+	for x := range s {
+		result = append(result, x)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,76 @@
+package suppression
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestTracker(t *testing.T) {
+	var (
+		info   = &mesos.FrameworkInfo{Roles: []string{"a", "b", "c"}}
+		sent   []*scheduler.Call
+		caller calls.Caller
+		tr     = NewTracker(info, ResyncOnSubscribed(func() calls.Caller { return caller }))
+		ctx    = context.Background()
+	)
+	caller = tr.CallRule().Caller(calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+		sent = append(sent, c)
+		return nil, nil
+	}))
+	subscribe := func() {
+		c := calls.Subscribe(info)
+		c.Subscribe.SuppressedRoles = []string{"a"}
+		if _, err := caller.Call(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.EventRule().HandleEvent(ctx, &scheduler.Event{Type: scheduler.Event_SUBSCRIBED}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(suppressed, mismatched []string) {
+		t.Helper()
+		if s := tr.Suppressed(); !reflect.DeepEqual(s, suppressed) {
+			t.Errorf("expected suppressed roles %v instead of %v", suppressed, s)
+		}
+		if m := tr.Mismatched(); !reflect.DeepEqual(m, mismatched) {
+			t.Errorf("expected mismatched roles %v instead of %v", mismatched, m)
+		}
+	}
+
+	subscribe()
+	expect([]string{"a"}, nil)
+	if len(sent) != 1 {
+		t.Fatalf("unexpected calls upon initial subscription: %v", sent)
+	}
+
+	caller.Call(ctx, calls.Suppress())
+	expect([]string{"a", "b", "c"}, nil)
+	caller.Call(ctx, calls.ReviveWith([]string{"a", "c"}))
+	expect([]string{"b"}, nil)
+	if !tr.IsSuppressed("b") || tr.IsSuppressed("a") {
+		t.Fatalf("unexpected state %+v", tr.State())
+	}
+
+	// upon resubscription "a" is revived and "b" is suppressed again
+	sent = nil
+	subscribe()
+	expect([]string{"b"}, nil)
+	if len(sent) != 3 || sent[1].GetType() != scheduler.Call_SUPPRESS || sent[2].GetType() != scheduler.Call_REVIVE ||
+		!reflect.DeepEqual(sent[1].GetSuppress().GetRoles(), []string{"b"}) ||
+		!reflect.DeepEqual(sent[2].GetRevive().GetRoles(), []string{"a", "c"}) {
+		t.Fatalf("unexpected calls upon resubscription: %v", sent)
+	}
+
+	// without resync the mismatch remains
+	ResyncOnSubscribed(nil)(tr)
+	subscribe()
+	expect([]string{"a"}, []string{"a", "b"})
+	if s := tr.State(); !reflect.DeepEqual(s.Desired, []string{"b"}) {
+		t.Fatalf("unexpected state %+v", s)
+	}
+}
@@ -0,0 +1,293 @@
+package tasks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// Action is the recommended response of a framework to a task status update.
+type Action int
+
+const (
+	// ActionNone indicates that the framework need not do anything: the task is running, or else has
+	// completed (normally, or otherwise) in a manner that a relaunch is not expected to remedy.
+	ActionNone Action = iota
+	// ActionWait indicates that the task may yet recover (e.g. its agent is temporarily partitioned
+	// from the master). Frameworks should reconcile the task at some later point in time.
+	ActionWait
+	// ActionRelaunch indicates that the task is no longer running and is never expected to return;
+	// frameworks should launch a replacement.
+	ActionRelaunch
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionNone:
+		return "none"
+	case ActionWait:
+		return "wait"
+	case ActionRelaunch:
+		return "relaunch"
+	default:
+		return "unknown"
+	}
+}
+
+// RelaunchPolicy decides how a framework should respond to a task status update.
+type RelaunchPolicy interface {
+	Decide(*mesos.TaskStatus) Action
+}
+
+// RelaunchPolicyFunc is the functional adaptation of RelaunchPolicy.
+type RelaunchPolicyFunc func(*mesos.TaskStatus) Action
+
+// Decide implements RelaunchPolicy for RelaunchPolicyFunc.
+func (f RelaunchPolicyFunc) Decide(s *mesos.TaskStatus) Action { return f(s) }
+
+// StatePolicy maps task states to actions; states that are not present in the map yield ActionNone.
+type StatePolicy map[mesos.TaskState]Action
+
+// Decide implements RelaunchPolicy for StatePolicy.
+func (p StatePolicy) Decide(s *mesos.TaskStatus) Action { return p[s.GetState()] }
+
+var _ = RelaunchPolicy(StatePolicy(nil))
+
+// DefaultRelaunchPolicy relaunches tasks that failed or were lost, waits on tasks that are unreachable
+// or in an unknown state, and otherwise does nothing. TASK_ERROR indicates a task description problem
+// and so relaunching such a task is not expected to succeed. TASK_KILLING yields ActionNone: the kill is
+// in progress and the task's fate is decided by the terminal update that follows.
+var DefaultRelaunchPolicy = StatePolicy{
+	mesos.TASK_FAILED:           ActionRelaunch,
+	mesos.TASK_LOST:             ActionRelaunch,
+	mesos.TASK_DROPPED:          ActionRelaunch,
+	mesos.TASK_GONE:             ActionRelaunch,
+	mesos.TASK_GONE_BY_OPERATOR: ActionRelaunch,
+	mesos.TASK_UNREACHABLE:      ActionWait,
+	mesos.TASK_UNKNOWN:          ActionWait,
+}
+
+// UnreachableTimeout returns a policy that decorates the given policy: tasks that have been unreachable
+// for longer than the specified timeout are relaunched. The age of a TASK_UNREACHABLE status is determined
+// from TaskStatus.UnreachableTime, relative to the given clock; if the status doesn't report an unreachable
+// time then the decorated policy decides.
+func UnreachableTimeout(p RelaunchPolicy, timeout time.Duration, clock mesostime.Clock) RelaunchPolicy {
+	return RelaunchPolicyFunc(func(s *mesos.TaskStatus) Action {
+		if s.GetState() == mesos.TASK_UNREACHABLE && s.UnreachableTime != nil {
+			since := time.Unix(0, s.UnreachableTime.GetNanoseconds())
+			if clock.Now().Sub(since) > timeout {
+				return ActionRelaunch
+			}
+		}
+		return p.Decide(s)
+	})
+}
+
+const (
+	// DefaultRelaunchMinDelay is the default delay of the first relaunch of a task; see RelaunchDelay.
+	DefaultRelaunchMinDelay = time.Second
+	// DefaultRelaunchMaxDelay is the default upper bound of the delay of relaunches; see RelaunchDelay.
+	DefaultRelaunchMaxDelay = 5 * time.Minute
+	// DefaultRelaunchResetAfter is the default duration for which a task must run before its retries are
+	// reset; see ResetAfter.
+	DefaultRelaunchResetAfter = 10 * time.Minute
+)
+
+type (
+	// RelaunchBackoff is a RelaunchPolicy that decorates another policy: it bounds the number of times that
+	// a task is relaunched, and delays its relaunches exponentially, so that failing tasks aren't relaunched
+	// into crash loops. Retries are reset once a task has been running for a while (see ResetAfter), or
+	// else has finished. The statuses of tasks must be recorded by Update, e.g. by way of a Registry that's
+	// configured with Backoff, before relaunches are decided.
+	// RelaunchBackoff funcs are safe to invoke concurrently.
+	RelaunchBackoff struct {
+		policy     RelaunchPolicy
+		maxRetries int
+		minDelay   time.Duration
+		maxDelay   time.Duration
+		resetAfter time.Duration
+		key        func(mesos.TaskID) string
+		clock      mesostime.Clock
+
+		m     sync.Mutex
+		tasks map[string]*backoffState
+	}
+
+	// RelaunchBackoffOption is a functional option for a RelaunchBackoff; it returns an "undo" option when
+	// applied.
+	RelaunchBackoffOption func(*RelaunchBackoff) RelaunchBackoffOption
+
+	// RelaunchBudget reports the relaunches of a task.
+	RelaunchBudget struct {
+		Failures  int       // the number of failures that called for relaunches, since the last reset
+		Remaining int       // the number of relaunches that remain; -1 if unbounded
+		NotBefore time.Time // the earliest time at which the task should be relaunched; zero if unconstrained
+	}
+
+	backoffState struct {
+		retries      int
+		runningSince time.Time
+		notBefore    time.Time
+	}
+)
+
+// MaxRetries bounds the number of times that a task is relaunched, since its retries were last reset;
+// subsequent failures yield ActionNone. Zero (the default) leaves relaunches unbounded.
+func MaxRetries(n int) RelaunchBackoffOption {
+	return func(b *RelaunchBackoff) RelaunchBackoffOption {
+		old := b.maxRetries
+		b.maxRetries = n
+		return MaxRetries(old)
+	}
+}
+
+// RelaunchDelay configures the delay of the first relaunch of a task, which doubles with every subsequent
+// relaunch up to the given maximum; defaults to DefaultRelaunchMinDelay, and DefaultRelaunchMaxDelay.
+func RelaunchDelay(min, max time.Duration) RelaunchBackoffOption {
+	return func(b *RelaunchBackoff) RelaunchBackoffOption {
+		oldMin, oldMax := b.minDelay, b.maxDelay
+		b.minDelay, b.maxDelay = min, max
+		return RelaunchDelay(oldMin, oldMax)
+	}
+}
+
+// ResetAfter configures the duration for which a task must have been TASK_RUNNING, before it fails, for
+// its retries to be reset; defaults to DefaultRelaunchResetAfter. Zero disables resets, but for those of
+// finished tasks.
+func ResetAfter(d time.Duration) RelaunchBackoffOption {
+	return func(b *RelaunchBackoff) RelaunchBackoffOption {
+		old := b.resetAfter
+		b.resetAfter = d
+		return ResetAfter(old)
+	}
+}
+
+// RelaunchKey configures the func that identifies the task that's replaced by a relaunch, e.g. by
+// stripping a generated suffix from the IDs of relaunched tasks; by default tasks are identified by ID,
+// for frameworks that relaunch tasks with the IDs of the tasks that they replace.
+func RelaunchKey(f func(mesos.TaskID) string) RelaunchBackoffOption {
+	return func(b *RelaunchBackoff) RelaunchBackoffOption {
+		old := b.key
+		b.key = f
+		return RelaunchKey(old)
+	}
+}
+
+// RelaunchClock configures the clock that times the statuses that are recorded, and the delays of
+// relaunches; defaults to mesostime.SystemClock.
+func RelaunchClock(c mesostime.Clock) RelaunchBackoffOption {
+	return func(b *RelaunchBackoff) RelaunchBackoffOption {
+		old := b.clock
+		b.clock = c
+		return RelaunchClock(old)
+	}
+}
+
+// NewRelaunchBackoff returns a RelaunchBackoff that decorates the given policy.
+func NewRelaunchBackoff(p RelaunchPolicy, opts ...RelaunchBackoffOption) *RelaunchBackoff {
+	b := &RelaunchBackoff{
+		policy:     p,
+		minDelay:   DefaultRelaunchMinDelay,
+		maxDelay:   DefaultRelaunchMaxDelay,
+		resetAfter: DefaultRelaunchResetAfter,
+		key:        func(id mesos.TaskID) string { return id.Value },
+		clock:      mesostime.SystemClock,
+		tasks:      make(map[string]*backoffState),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(b)
+		}
+	}
+	return b
+}
+
+// Update records the given status, which should be recorded only once: a status that the decorated
+// policy decides to relaunch counts as a retry, and schedules the relaunch. Tasks that are otherwise
+// terminal (e.g. finished, or killed) are forgotten.
+func (b *RelaunchBackoff) Update(s *mesos.TaskStatus) {
+	var (
+		k      = b.key(s.TaskID)
+		action = b.policy.Decide(s)
+	)
+	b.m.Lock()
+	defer b.m.Unlock()
+	now := b.clock.Now()
+	st, ok := b.tasks[k]
+	switch {
+	case action == ActionRelaunch:
+		if !ok {
+			st = &backoffState{}
+			b.tasks[k] = st
+		}
+		b.reset(st, now)
+		st.retries++
+		st.runningSince = time.Time{}
+		st.notBefore = now.Add(b.delay(st.retries))
+	case IsTerminal(s.GetState()) && action == ActionNone:
+		delete(b.tasks, k)
+	case ok && s.GetState() == mesos.TASK_RUNNING && st.runningSince.IsZero():
+		st.runningSince = now
+	}
+}
+
+// Decide implements RelaunchPolicy for RelaunchBackoff: it yields the action of the decorated policy,
+// unless the task's relaunches are exhausted (ActionNone). See Budget for the delay of a relaunch.
+func (b *RelaunchBackoff) Decide(s *mesos.TaskStatus) Action {
+	action := b.policy.Decide(s)
+	if action == ActionRelaunch && b.maxRetries > 0 && b.Budget(s.TaskID).Failures > b.maxRetries {
+		return ActionNone
+	}
+	return action
+}
+
+// Budget returns the relaunch budget of the given task, as of the current time.
+func (b *RelaunchBackoff) Budget(id mesos.TaskID) RelaunchBudget {
+	b.m.Lock()
+	defer b.m.Unlock()
+	var r RelaunchBudget
+	if st, ok := b.tasks[b.key(id)]; ok {
+		now := b.clock.Now()
+		b.reset(st, now)
+		r.Failures = st.retries
+		if now.Before(st.notBefore) {
+			r.NotBefore = st.notBefore
+		}
+	}
+	switch {
+	case b.maxRetries <= 0:
+		r.Remaining = -1
+	case r.Failures < b.maxRetries:
+		r.Remaining = b.maxRetries - r.Failures
+	}
+	return r
+}
+
+// Forget forgets the relaunches of the given task, e.g. once the framework gives up on it.
+func (b *RelaunchBackoff) Forget(id mesos.TaskID) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	delete(b.tasks, b.key(id))
+}
+
+// reset resets the retries of a task that's been running for at least the reset window.
+func (b *RelaunchBackoff) reset(st *backoffState, now time.Time) {
+	if b.resetAfter > 0 && !st.runningSince.IsZero() && now.Sub(st.runningSince) >= b.resetAfter {
+		st.retries, st.notBefore = 0, time.Time{}
+	}
+}
+
+func (b *RelaunchBackoff) delay(retries int) time.Duration {
+	d := b.minDelay
+	for i := 1; i < retries && d < b.maxDelay; i++ {
+		d *= 2
+	}
+	if d > b.maxDelay {
+		d = b.maxDelay
+	}
+	return d
+}
+
+var _ = RelaunchPolicy(&RelaunchBackoff{})
@@ -0,0 +1,319 @@
+package tasks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	. "github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	master "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// IsTerminal returns true if a task in the given state will never transition to another state. TASK_LOST
+// is considered terminal for frameworks that are not partition-aware; partition-aware frameworks never
+// receive TASK_LOST.
+func IsTerminal(s mesos.TaskState) bool {
+	switch s {
+	case mesos.TASK_FINISHED,
+		mesos.TASK_FAILED,
+		mesos.TASK_KILLED,
+		mesos.TASK_ERROR,
+		mesos.TASK_LOST,
+		mesos.TASK_DROPPED,
+		mesos.TASK_GONE,
+		mesos.TASK_GONE_BY_OPERATOR:
+		return true
+	}
+	return false
+}
+
+// IsPartitioned returns true for the states that are only ever reported to PARTITION_AWARE frameworks:
+// TASK_UNREACHABLE, TASK_DROPPED, TASK_GONE, TASK_GONE_BY_OPERATOR, and TASK_UNKNOWN.
+func IsPartitioned(s mesos.TaskState) bool {
+	switch s {
+	case mesos.TASK_UNREACHABLE,
+		mesos.TASK_DROPPED,
+		mesos.TASK_GONE,
+		mesos.TASK_GONE_BY_OPERATOR,
+		mesos.TASK_UNKNOWN:
+		return true
+	}
+	return false
+}
+
+// IsPreempted returns true if the given status reports that the task was killed by a QoS correction of
+// its agent: i.e. the task used revocable resources that were reclaimed for the tasks that they're
+// allocated to. The master reports such tasks as TASK_LOST (TASK_GONE for partition-aware frameworks)
+// with REASON_CONTAINER_PREEMPTED; unlike other lost tasks, the agent remains available and the task may
+// be relaunched right away, ideally upon non-revocable resources, or revocable resources of other agents.
+func IsPreempted(s *mesos.TaskStatus) bool {
+	return IsTerminal(s.GetState()) && s.GetReason() == mesos.REASON_CONTAINER_PREEMPTED
+}
+
+// Registry tracks the most recently observed status of each non-terminal task of a framework. Tasks are
+// forgotten once they reach a terminal state. Registry funcs are safe to invoke concurrently.
+type Registry struct {
+	partitionAware bool
+	killingState   bool
+	preemptionFunc func(mesos.TaskStatus)
+	backoff        *RelaunchBackoff
+
+	m     sync.RWMutex
+	tasks map[mesos.TaskID]mesos.TaskStatus
+}
+
+// RegistryOption is a functional option for a Registry; it returns an "undo" option when applied.
+type RegistryOption func(*Registry) RegistryOption
+
+// PreemptionFunc returns an option that configures a func that's invoked with every status, recorded by
+// Update, that reports the preemption of a task (see IsPreempted): e.g. so that a best-effort framework
+// may relaunch the task upon non-revocable resources, or back off from the revocable resources of the
+// task's agent. The func is invoked after the status is recorded, without holding the registry lock, so
+// it may invoke other Registry funcs.
+func PreemptionFunc(f func(mesos.TaskStatus)) RegistryOption {
+	return func(r *Registry) RegistryOption {
+		old := r.preemptionFunc
+		r.preemptionFunc = f
+		return PreemptionFunc(old)
+	}
+}
+
+// Backoff returns an option that records, in the given RelaunchBackoff, the statuses of tasks that are
+// recorded by Update: but for redelivered terminal statuses, of tasks that are no longer tracked.
+func Backoff(b *RelaunchBackoff) RegistryOption {
+	return func(r *Registry) RegistryOption {
+		old := r.backoff
+		r.backoff = b
+		return Backoff(old)
+	}
+}
+
+// NewRegistry returns an empty Registry for a framework that subscribes with the given info.
+func NewRegistry(info *mesos.FrameworkInfo, opts ...RegistryOption) *Registry {
+	r := &Registry{
+		partitionAware: calls.HasCapability(info, mesos.FrameworkInfo_Capability_PARTITION_AWARE),
+		killingState:   calls.HasCapability(info, mesos.FrameworkInfo_Capability_TASK_KILLING_STATE),
+		tasks:          make(map[mesos.TaskID]mesos.TaskStatus),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(r)
+		}
+	}
+	return r
+}
+
+// PartitionAware returns true if the registry was created for a PARTITION_AWARE framework.
+func (r *Registry) PartitionAware() bool { return r.partitionAware }
+
+// KillingState returns true if the registry was created for a framework with the TASK_KILLING_STATE
+// capability. Only such frameworks are sent TASK_KILLING updates; for other frameworks a task that's
+// being killed continues to be reported as TASK_RUNNING until it reaches a terminal state.
+func (r *Registry) KillingState() bool { return r.killingState }
+
+// Launched records the given tasks in TASK_STAGING state, timestamped with the current time. Frameworks
+// should invoke this func for each task that's been successfully submitted to Mesos via an ACCEPT call,
+// so that such tasks are included in subsequent reconciliation requests.
+func (r *Registry) Launched(tasks ...mesos.TaskInfo) {
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i := range tasks {
+		agentID := tasks[i].AgentID
+		r.tasks[tasks[i].TaskID] = mesos.TaskStatus{
+			TaskID:    tasks[i].TaskID,
+			State:     mesos.TASK_STAGING.Enum(),
+			AgentID:   &agentID,
+			Timestamp: &now,
+		}
+	}
+}
+
+// Update records the given task status, returning the previously recorded status (if any). A status that
+// reports a terminal state removes the task from the registry. A status that reports the preemption of a
+// tracked task is subsequently passed to the registry's PreemptionFunc, if any; and the status of a
+// tracked (or non-terminal) task to its Backoff, if any.
+func (r *Registry) Update(s mesos.TaskStatus) (prev mesos.TaskStatus, found bool) {
+	prev, found = r.update(s)
+	if b := r.backoff; b != nil && (found || !IsTerminal(s.GetState())) {
+		b.Update(&s)
+	}
+	if f := r.preemptionFunc; f != nil && found && IsPreempted(&s) {
+		f(s)
+	}
+	return
+}
+
+func (r *Registry) update(s mesos.TaskStatus) (prev mesos.TaskStatus, found bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	prev, found = r.tasks[s.TaskID]
+	if IsTerminal(s.GetState()) {
+		delete(r.tasks, s.TaskID)
+	} else {
+		if s.AgentID == nil && found {
+			// preserve the agent so that reconciliation requests remain as specific as possible
+			s.AgentID = prev.AgentID
+		}
+		r.tasks[s.TaskID] = s
+	}
+	return
+}
+
+// Get returns the most recently recorded status for the task with the given ID.
+func (r *Registry) Get(id mesos.TaskID) (s mesos.TaskStatus, ok bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	s, ok = r.tasks[id]
+	return
+}
+
+// Len returns the number of tracked tasks.
+func (r *Registry) Len() int {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return len(r.tasks)
+}
+
+// Select returns the most recently recorded status of every task for which the filter returns true.
+// A nil filter selects all tasks.
+func (r *Registry) Select(f func(*mesos.TaskStatus) bool) (result []mesos.TaskStatus) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, s := range r.tasks {
+		if f == nil || f(&s) {
+			result = append(result, s)
+		}
+	}
+	return
+}
+
+// Snapshot is a serializable (e.g. via encoding/json) view of the state of a Registry, for debugging
+// endpoints and support bundles.
+type Snapshot struct {
+	PartitionAware bool               `json:"partition_aware"`
+	KillingState   bool               `json:"killing_state"`
+	Tasks          []mesos.TaskStatus `json:"tasks"`
+}
+
+// Snapshot returns a view of the registry's current state; its tasks are ordered by ID.
+func (r *Registry) Snapshot() Snapshot {
+	tasks := r.Select(nil)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].TaskID.Value < tasks[j].TaskID.Value })
+	if tasks == nil {
+		tasks = []mesos.TaskStatus{}
+	}
+	return Snapshot{
+		PartitionAware: r.partitionAware,
+		KillingState:   r.killingState,
+		Tasks:          tasks,
+	}
+}
+
+// Restore records the tasks of the given snapshot, e.g. as taken by a previous instance of the framework;
+// the recorded statuses of tasks that are already tracked are replaced. The capabilities of the snapshot
+// are ignored: those of the registry are retained. Restored tasks should be reconciled (see ReconcileTasks).
+func (r *Registry) Restore(s Snapshot) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for _, t := range s.Tasks {
+		if !IsTerminal(t.GetState()) {
+			r.tasks[t.TaskID] = t
+		}
+	}
+}
+
+// InState returns a filter func, for use with Select, that selects tasks in any of the given states.
+func InState(states ...mesos.TaskState) func(*mesos.TaskStatus) bool {
+	return func(s *mesos.TaskStatus) bool {
+		st := s.GetState()
+		for _, x := range states {
+			if x == st {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnAgent returns a filter func, for use with Select, that selects tasks running on the given agent.
+func OnAgent(id mesos.AgentID) func(*mesos.TaskStatus) bool {
+	return func(s *mesos.TaskStatus) bool {
+		return s.AgentID != nil && *s.AgentID == id
+	}
+}
+
+// Unreachable returns the status of every task that's been reported as TASK_UNREACHABLE.
+func (r *Registry) Unreachable() []mesos.TaskStatus {
+	return r.Select(InState(mesos.TASK_UNREACHABLE))
+}
+
+// Killing returns the status of every task that's been reported as TASK_KILLING: a kill is in
+// progress, and the executor is expected to report a terminal state once the task's kill policy grace
+// period has expired (or earlier). Such tasks aren't stuck; see Stuck.
+func (r *Registry) Killing() []mesos.TaskStatus {
+	return r.Select(InState(mesos.TASK_KILLING))
+}
+
+// Stuck returns the status of every task that's been in TASK_STAGING or TASK_STARTING state for longer
+// than the given timeout, as of the given time, according to the timestamp of the most recently recorded
+// status; a status without a timestamp is never considered stuck. Frameworks typically kill (and then
+// replace) stuck tasks. Tasks in TASK_KILLING state are deliberately excluded: the kill is already in
+// progress and re-issuing it serves no purpose (unless the framework wishes to shorten the grace
+// period by way of a KILL call that overrides the kill policy).
+func (r *Registry) Stuck(timeout time.Duration, now time.Time) []mesos.TaskStatus {
+	transitional := InState(mesos.TASK_STAGING, mesos.TASK_STARTING)
+	return r.Select(func(s *mesos.TaskStatus) bool {
+		if !transitional(s) || s.Timestamp == nil {
+			return false
+		}
+		since := time.Unix(0, int64(s.GetTimestamp()*float64(time.Second)))
+		return now.Sub(since) > timeout
+	})
+}
+
+// ReconcileTasks returns a ReconcileOpt that requests explicit reconciliation of all tracked tasks,
+// including those that are unreachable, in an unknown state, or being killed. If there are no tracked
+// tasks then the option requests implicit reconciliation. Note that the master reports a task that's
+// being killed as TASK_KILLING only to frameworks with the TASK_KILLING_STATE capability; others observe
+// TASK_RUNNING, which Update records as-is.
+func (r *Registry) ReconcileTasks() scheduler.ReconcileOpt {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	m := make(map[string]string, len(r.tasks))
+	for id, s := range r.tasks {
+		m[id.Value] = s.GetAgentID().GetValue()
+	}
+	return calls.ReconcileTasks(m)
+}
+
+// Track returns a Rule that records the status reported by every UPDATE event in the given registry.
+func Track(r *Registry) Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, chain Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil && e.GetType() == scheduler.Event_UPDATE {
+			r.Update(e.GetUpdate().GetStatus())
+		}
+		return chain(ctx, e, err)
+	}
+}
+
+// MarkAgentGone instructs the master, via the operator API, that the given agent is permanently gone.
+// Upon success the IDs of the tracked tasks that were running on the agent are returned: Mesos will
+// report such tasks as TASK_GONE_BY_OPERATOR (or TASK_LOST for frameworks that are not partition-aware),
+// at which point the framework may relaunch them.
+func (r *Registry) MarkAgentGone(ctx context.Context, sender master.Sender, id mesos.AgentID) ([]mesos.TaskID, error) {
+	err := master.SendNoData(ctx, sender, master.NonStreaming(master.MarkAgentGone(id)))
+	if err != nil {
+		return nil, err
+	}
+	statuses := r.Select(OnAgent(id))
+	result := make([]mesos.TaskID, len(statuses))
+	for i := range statuses {
+		result[i] = statuses[i].TaskID
+	}
+	return result, nil
+}
@@ -0,0 +1,258 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func status(id, agent string, st mesos.TaskState) mesos.TaskStatus {
+	s := mesos.TaskStatus{TaskID: mesos.TaskID{Value: id}, State: st.Enum()}
+	if agent != "" {
+		s.AgentID = &mesos.AgentID{Value: agent}
+	}
+	return s
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(&mesos.FrameworkInfo{Capabilities: []mesos.FrameworkInfo_Capability{
+		{Type: mesos.FrameworkInfo_Capability_PARTITION_AWARE},
+	}})
+	if !r.PartitionAware() {
+		t.Fatalf("expected partition-aware registry")
+	}
+	r.Launched(
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "1"}, AgentID: mesos.AgentID{Value: "a"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "2"}, AgentID: mesos.AgentID{Value: "b"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "3"}, AgentID: mesos.AgentID{Value: "b"}},
+	)
+	if n := r.Len(); n != 3 {
+		t.Fatalf("expected 3 tasks instead of %d", n)
+	}
+
+	// status updates are consumed via an event rule
+	rule := Track(r)
+	for _, s := range []mesos.TaskStatus{
+		status("1", "", mesos.TASK_UNREACHABLE),
+		status("2", "b", mesos.TASK_RUNNING),
+		status("3", "b", mesos.TASK_GONE),
+	} {
+		e := &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: s}}
+		rule.Eval(context.Background(), e, nil, eventrules.ChainIdentity)
+	}
+
+	if n := r.Len(); n != 2 {
+		t.Fatalf("expected 2 tasks instead of %d", n)
+	}
+	unreachable := r.Unreachable()
+	if len(unreachable) != 1 || unreachable[0].TaskID.Value != "1" {
+		t.Fatalf("unexpected unreachable tasks: %+v", unreachable)
+	}
+	if id := unreachable[0].GetAgentID().GetValue(); id != "a" {
+		t.Errorf("expected agent ID to be preserved, instead of %q", id)
+	}
+
+	var cr scheduler.Call_Reconcile
+	cr.With(r.ReconcileTasks())
+	if n := len(cr.Tasks); n != 2 {
+		t.Errorf("expected 2 tasks to reconcile instead of %d", n)
+	}
+
+	var sent []*master.Call
+	sender := mastercalls.SenderFunc(func(_ context.Context, req mastercalls.Request) (_ mesos.Response, _ error) {
+		sent = append(sent, req.Call())
+		return
+	})
+	gone, err := r.MarkAgentGone(context.Background(), sender, mesos.AgentID{Value: "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if len(sent) != 1 || sent[0].GetType() != master.Call_MARK_AGENT_GONE {
+		t.Errorf("expected a MARK_AGENT_GONE call instead of %+v", sent)
+	}
+	if len(gone) != 1 || gone[0].Value != "2" {
+		t.Errorf("unexpected tasks on gone agent: %+v", gone)
+	}
+}
+
+func TestRegistryKillingAndStuck(t *testing.T) {
+	r := NewRegistry(&mesos.FrameworkInfo{Capabilities: []mesos.FrameworkInfo_Capability{
+		{Type: mesos.FrameworkInfo_Capability_TASK_KILLING_STATE},
+	}})
+	if !r.KillingState() || r.PartitionAware() {
+		t.Fatalf("unexpected registry capabilities")
+	}
+	r.Launched(
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "1"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "2"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "3"}},
+	)
+	old := float64(time.Now().Add(-time.Hour).Unix())
+	for _, s := range []mesos.TaskStatus{
+		status("2", "", mesos.TASK_STARTING),
+		status("3", "", mesos.TASK_KILLING),
+	} {
+		s.Timestamp = &old
+		r.Update(s)
+	}
+	killing := r.Killing()
+	if len(killing) != 1 || killing[0].TaskID.Value != "3" {
+		t.Fatalf("unexpected killing tasks: %+v", killing)
+	}
+	if stuck := r.Stuck(time.Minute, time.Now()); len(stuck) != 1 || stuck[0].TaskID.Value != "2" {
+		t.Fatalf("unexpected stuck tasks: %+v", stuck)
+	}
+	if stuck := r.Stuck(time.Minute, time.Now().Add(time.Hour)); len(stuck) != 2 {
+		t.Fatalf("expected launched task to be stuck: %+v", stuck)
+	}
+	if a := DefaultRelaunchPolicy.Decide(&killing[0]); a != ActionNone {
+		t.Errorf("expected no action for a task being killed instead of %v", a)
+	}
+}
+
+func TestRegistryPreemption(t *testing.T) {
+	var (
+		preempted []mesos.TaskStatus
+		r         *Registry
+	)
+	r = NewRegistry(&mesos.FrameworkInfo{}, nil, PreemptionFunc(func(s mesos.TaskStatus) {
+		if r.Len() != 1 {
+			t.Errorf("expected the preempted task to be forgotten")
+		}
+		preempted = append(preempted, s)
+	}))
+	r.Launched(
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "1"}},
+		mesos.TaskInfo{TaskID: mesos.TaskID{Value: "2"}},
+	)
+	lost := func(id string, reason mesos.TaskStatus_Reason) mesos.TaskStatus {
+		s := status(id, "a", mesos.TASK_LOST)
+		s.Reason = reason.Enum()
+		return s
+	}
+	for _, s := range []mesos.TaskStatus{
+		lost("1", mesos.REASON_CONTAINER_PREEMPTED),
+		lost("1", mesos.REASON_CONTAINER_PREEMPTED), // duplicate, the task's no longer tracked
+		lost("3", mesos.REASON_CONTAINER_PREEMPTED), // unknown task
+	} {
+		r.Update(s)
+	}
+	if len(preempted) != 1 || preempted[0].TaskID.Value != "1" {
+		t.Fatalf("unexpected preempted tasks: %+v", preempted)
+	}
+	for _, tc := range []struct {
+		s    mesos.TaskStatus
+		want bool
+	}{
+		{lost("1", mesos.REASON_CONTAINER_PREEMPTED), true},
+		{lost("1", mesos.REASON_AGENT_REMOVED), false},
+		{status("1", "a", mesos.TASK_RUNNING), false},
+	} {
+		if got := IsPreempted(&tc.s); got != tc.want {
+			t.Errorf("expected IsPreempted(%v) == %v", tc.s, tc.want)
+		}
+	}
+}
+
+func TestRelaunchPolicy(t *testing.T) {
+	var (
+		now    = time.Unix(1000, 0)
+		clock  = mesostime.NewFakeClock(now)
+		policy = UnreachableTimeout(DefaultRelaunchPolicy, time.Minute, clock)
+		recent = status("1", "", mesos.TASK_UNREACHABLE)
+		stale  = status("1", "", mesos.TASK_UNREACHABLE)
+	)
+	recent.UnreachableTime = &mesos.TimeInfo{Nanoseconds: now.Add(-time.Second).UnixNano()}
+	stale.UnreachableTime = &mesos.TimeInfo{Nanoseconds: now.Add(-time.Hour).UnixNano()}
+
+	for ti, tc := range []struct {
+		status mesos.TaskStatus
+		wants  Action
+	}{
+		{status("1", "", mesos.TASK_RUNNING), ActionNone},
+		{status("1", "", mesos.TASK_FINISHED), ActionNone},
+		{status("1", "", mesos.TASK_ERROR), ActionNone},
+		{status("1", "", mesos.TASK_FAILED), ActionRelaunch},
+		{status("1", "", mesos.TASK_GONE), ActionRelaunch},
+		{status("1", "", mesos.TASK_GONE_BY_OPERATOR), ActionRelaunch},
+		{status("1", "", mesos.TASK_UNREACHABLE), ActionWait},
+		{recent, ActionWait},
+		{stale, ActionRelaunch},
+	} {
+		if a := policy.Decide(&tc.status); a != tc.wants {
+			t.Errorf("test case %d failed: expected %v instead of %v", ti, tc.wants, a)
+		}
+	}
+}
+
+func TestRelaunchBackoff(t *testing.T) {
+	var (
+		clock = mesostime.NewFakeClock(time.Unix(1000, 0))
+		b     = NewRelaunchBackoff(DefaultRelaunchPolicy,
+			MaxRetries(2),
+			RelaunchDelay(time.Second, 3*time.Second),
+			ResetAfter(time.Minute),
+			RelaunchClock(clock),
+			RelaunchKey(func(id mesos.TaskID) string { return strings.SplitN(id.Value, "-", 2)[0] }),
+		)
+		r      = NewRegistry(&mesos.FrameworkInfo{}, Backoff(b))
+		update = func(id string, st mesos.TaskState) Action {
+			s := status(id, "a1", st)
+			r.Update(s)
+			return b.Decide(&s)
+		}
+		budget = func(id string, failures, remaining int, delay time.Duration) {
+			t.Helper()
+			got := b.Budget(mesos.TaskID{Value: id})
+			want := RelaunchBudget{Failures: failures, Remaining: remaining}
+			if delay > 0 {
+				want.NotBefore = clock.Now().Add(delay)
+			}
+			if got != want {
+				t.Fatalf("expected the budget of %s to be %+v instead of %+v", id, want, got)
+			}
+		}
+	)
+	budget("web-1", 0, 2, 0)
+	update("web-1", mesos.TASK_RUNNING)
+	if a := update("web-1", mesos.TASK_FAILED); a != ActionRelaunch {
+		t.Fatalf("expected ActionRelaunch instead of %v", a)
+	}
+	budget("web-1", 1, 1, time.Second)
+	update("web-1", mesos.TASK_FAILED) // redelivered
+	budget("web-2", 1, 1, time.Second)
+
+	r.Launched(mesos.TaskInfo{TaskID: mesos.TaskID{Value: "web-2"}})
+	if a := update("web-2", mesos.TASK_FAILED); a != ActionRelaunch {
+		t.Fatalf("expected ActionRelaunch instead of %v", a)
+	}
+	budget("web-3", 2, 0, 2*time.Second)
+	r.Launched(mesos.TaskInfo{TaskID: mesos.TaskID{Value: "web-3"}})
+	if a := update("web-3", mesos.TASK_FAILED); a != ActionNone {
+		t.Fatalf("expected the relaunches to be exhausted: %v", a)
+	}
+	budget("web-3", 3, 0, 3*time.Second)
+	b.Forget(mesos.TaskID{Value: "web-3"})
+	budget("web-4", 0, 2, 0)
+
+	// retries are reset once a task has been running for a while, or else once it's finished
+	update("db-1", mesos.TASK_RUNNING)
+	update("db-1", mesos.TASK_FAILED)
+	update("db-2", mesos.TASK_RUNNING)
+	budget("db-2", 1, 1, time.Second)
+	clock.Advance(time.Minute)
+	budget("db-2", 0, 2, 0)
+	update("db-2", mesos.TASK_FAILED)
+	budget("db-3", 1, 1, time.Second)
+	update("db-3", mesos.TASK_RUNNING)
+	update("db-3", mesos.TASK_FINISHED)
+	budget("db-3", 0, 2, 0)
+}
@@ -0,0 +1,320 @@
+package tasks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+// ErrInsufficientResources is returned when the resources that remain of an offer can't hold an instance
+// of a Template.
+var ErrInsufficientResources = errors.New("tasks: insufficient resources for an instance of the template")
+
+// Template is the prototype of the tasks of a replicated service: the string fields of the prototype
+// (e.g. the name and ID of the task, the command, its arguments and environment, labels, and docker
+// parameters) may contain placeholders of the form ${NAME} that are resolved when an instance of the
+// template is launched upon an offer. Placeholders are resolved against the variables:
+//
+//	INDEX            the index of the instance
+//	HOST             the hostname of the offer
+//	AGENT_ID         the ID of the agent of the offer
+//	PORT0 ... PORTn  the ports that are allocated from the offer, see Ports
+//	PORT             the same as PORT0
+//	PORTS            the allocated ports, separated by commas
+//
+// along with the variables of Vars; the variables above take precedence. The sequence $${ yields a
+// literal ${. A placeholder of an undefined variable is an error.
+type Template struct {
+	// Prototype is the task that's instantiated. The resources of the prototype needn't include ports;
+	// the task ID defaults to the name of the task, suffixed by the index of the instance.
+	Prototype mesos.TaskInfo
+
+	// Ports is the number of ports to allocate from the "ports" resources of an offer; lower ports are
+	// allocated first. The docker port mappings, network port mappings, and discovery ports of the
+	// prototype whose (host) port is zero are assigned the allocated ports, in order, and so are
+	// allocated ports as well.
+	Ports int
+
+	// Vars are additional variables that placeholders may refer to.
+	Vars map[string]string
+
+	// Environment, if true, exposes the variables above (excluding Vars) to the command of the task as
+	// environment variables.
+	Environment bool
+}
+
+// Instantiate returns the instance of the template, of the given index, that's launched upon the given
+// offer along with the resources that remain available thereafter. The instance is allocated resources
+// from those that are available, which are usually the resources of the offer that remain after the
+// instances that precede it; nil is taken to mean all of the resources of the offer.
+func (t *Template) Instantiate(offer *mesos.Offer, index int, available mesos.Resources) (mesos.TaskInfo, mesos.Resources, error) {
+	if available == nil {
+		available = offer.GetResources()
+	}
+	task := t.Prototype.Clone()
+	found := resources.Find(task.Resources, available...)
+	if found == nil && len(task.Resources) > 0 {
+		return mesos.TaskInfo{}, available, ErrInsufficientResources
+	}
+	remaining := available.Clone()
+	remaining.Subtract(found...)
+
+	ports, allocated, ok := allocatePorts(t.portCount(task), remaining)
+	if !ok {
+		return mesos.TaskInfo{}, available, ErrInsufficientResources
+	}
+	remaining.Subtract(allocated...)
+
+	vars := make(map[string]string, len(t.Vars)+len(ports)+5)
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+	portNames := make([]string, len(ports))
+	for i, p := range ports {
+		portNames[i] = strconv.FormatUint(p, 10)
+		vars["PORT"+strconv.Itoa(i)] = portNames[i]
+	}
+	if len(ports) > 0 {
+		vars["PORT"] = portNames[0]
+	}
+	vars["PORTS"] = strings.Join(portNames, ",")
+	vars["INDEX"] = strconv.Itoa(index)
+	vars["HOST"] = offer.GetHostname()
+	vars["AGENT_ID"] = offer.GetAgentID().Value
+
+	x := expander{vars: vars}
+	x.walk(reflect.ValueOf(task).Elem())
+	if x.err != nil {
+		return mesos.TaskInfo{}, available, x.err
+	}
+	assignPorts(task, ports)
+
+	if task.TaskID.Value == "" {
+		task.TaskID.Value = task.Name + "-" + vars["INDEX"]
+	}
+	task.AgentID = offer.GetAgentID()
+	task.Resources = append(found, allocated...)
+
+	if t.Environment && task.Command != nil {
+		if task.Command.Environment == nil {
+			task.Command.Environment = &mesos.Environment{}
+		}
+		names := []string{"INDEX", "HOST", "AGENT_ID", "PORT", "PORTS"}
+		for i := range ports {
+			names = append(names, "PORT"+strconv.Itoa(i))
+		}
+		for _, name := range names {
+			if v, ok := vars[name]; ok {
+				task.Command.Environment.Variables = append(task.Command.Environment.Variables,
+					mesos.Environment_Variable{Name: name, Value: mesos.String(v)})
+			}
+		}
+	}
+	return *task, remaining, nil
+}
+
+// Pack returns as many as n instances of the template, of successive indexes beginning with first, that
+// the given offer holds; fewer are returned if the resources of the offer are exhausted.
+func (t *Template) Pack(offer *mesos.Offer, first, n int) ([]mesos.TaskInfo, error) {
+	var (
+		tasks     []mesos.TaskInfo
+		remaining = mesos.Resources(offer.GetResources())
+	)
+	for i := 0; i < n; i++ {
+		task, rs, err := t.Instantiate(offer, first+i, remaining)
+		if err == ErrInsufficientResources {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		remaining = rs
+	}
+	return tasks, nil
+}
+
+// portCount returns the number of ports that are allocated to the given instance of the template.
+func (t *Template) portCount(task *mesos.TaskInfo) int {
+	n := t.Ports
+	count := func(k int) {
+		if k > n {
+			n = k
+		}
+	}
+	if docker := task.GetContainer().GetDocker(); docker != nil {
+		k := 0
+		for _, m := range docker.PortMappings {
+			if m.HostPort == 0 {
+				k++
+			}
+		}
+		count(k)
+	}
+	for _, ni := range task.GetContainer().GetNetworkInfos() {
+		k := 0
+		for _, m := range ni.PortMappings {
+			if m.HostPort == 0 {
+				k++
+			}
+		}
+		count(k)
+	}
+	k := 0
+	for _, p := range task.GetDiscovery().GetPorts().GetPorts() {
+		if p.Number == 0 {
+			k++
+		}
+	}
+	count(k)
+	return n
+}
+
+// assignPorts assigns the allocated ports to the (host) ports of the task that are zero, in order.
+func assignPorts(task *mesos.TaskInfo, ports []uint64) {
+	if docker := task.GetContainer().GetDocker(); docker != nil {
+		k := 0
+		for i := range docker.PortMappings {
+			if m := &docker.PortMappings[i]; m.HostPort == 0 {
+				m.HostPort = uint32(ports[k])
+				k++
+			}
+		}
+	}
+	for _, ni := range task.GetContainer().GetNetworkInfos() {
+		k := 0
+		for i := range ni.PortMappings {
+			if m := &ni.PortMappings[i]; m.HostPort == 0 {
+				m.HostPort = uint32(ports[k])
+				k++
+			}
+		}
+	}
+	if dp := task.GetDiscovery().GetPorts(); dp != nil {
+		k := 0
+		for i := range dp.Ports {
+			if p := &dp.Ports[i]; p.Number == 0 {
+				p.Number = uint32(ports[k])
+				k++
+			}
+		}
+	}
+}
+
+// allocatePorts allocates the lowest n ports of the "ports" resources of rs; the allocated ports are
+// returned along with the resources that hold them (which retain the roles, reservations, and
+// allocations of the resources that they're allocated from).
+func allocatePorts(n int, rs mesos.Resources) ([]uint64, mesos.Resources, bool) {
+	var (
+		ports     []uint64
+		allocated mesos.Resources
+	)
+	for i := range rs {
+		if len(ports) == n {
+			break
+		}
+		r := rs[i]
+		if !resources.NamePorts.Filter(&r) {
+			continue
+		}
+		var taken []uint64
+		mesos.Ranges(r.GetRanges().GetRange()).Clone().Sort().Squash().Each(func(p uint64) bool {
+			taken = append(taken, p)
+			return len(ports)+len(taken) < n
+		})
+		if len(taken) == 0 {
+			continue
+		}
+		ports = append(ports, taken...)
+		r.Ranges = &mesos.Value_Ranges{Range: mesos.NewRanges(taken...)}
+		allocated.Add1(r)
+	}
+	return ports, allocated, len(ports) == n
+}
+
+// expander resolves the placeholders of the string fields of a (protobuf) message; the first error that's
+// encountered is retained.
+type expander struct {
+	vars map[string]string
+	err  error
+}
+
+func (x *expander) walk(v reflect.Value) {
+	if x.err != nil {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			s, err := expand(v.String(), x.vars)
+			if err != nil {
+				x.err = err
+				return
+			}
+			v.SetString(s)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			x.walk(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			x.walk(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return // bytes are opaque
+		}
+		for i := 0; i < v.Len(); i++ {
+			x.walk(v.Index(i))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			x.walk(e)
+			v.SetMapIndex(k, e)
+		}
+	}
+}
+
+// expand resolves the placeholders of s against the given variables.
+func expand(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b bytes.Buffer
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			// escaped: s[:i] retains one of the $
+			b.WriteString(s[:i])
+			b.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+		j := strings.IndexByte(s[i+2:], '}')
+		if j < 0 {
+			return "", fmt.Errorf("tasks: unterminated placeholder in %q", s)
+		}
+		name := s[i+2 : i+2+j]
+		v, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("tasks: undefined variable %q", name)
+		}
+		b.WriteString(s[:i])
+		b.WriteString(v)
+		s = s[i+3+j:]
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
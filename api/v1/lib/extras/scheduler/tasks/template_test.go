@@ -0,0 +1,132 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func templateOffer() *mesos.Offer {
+	var rs mesos.Resources
+	rs.Add(
+		resources.NewCPUs(2).Resource,
+		resources.NewMemory(1024).Resource,
+		resources.Build().Name(resources.NamePorts).Ranges(resources.BuildRanges().Span(31000, 31002).Ranges).Resource,
+	)
+	return &mesos.Offer{
+		ID:        mesos.OfferID{Value: "o"},
+		AgentID:   mesos.AgentID{Value: "a"},
+		Hostname:  "host-a",
+		Resources: rs,
+	}
+}
+
+func TestTemplateInstantiate(t *testing.T) {
+	var (
+		offer = templateOffer()
+		tmpl  = Template{
+			Prototype: mesos.TaskInfo{
+				Name:      "web",
+				Resources: mesos.Resources{resources.NewCPUs(1).Resource},
+				Command: &mesos.CommandInfo{
+					Value:     mesos.String("serve --listen=${HOST}:${PORT0} --admin=${PORT1} --zone=${ZONE}"),
+					Arguments: []string{"$${HOST}"},
+				},
+				Labels: &mesos.Labels{Labels: []mesos.Label{{Key: "instance", Value: mesos.String("web-${INDEX}")}}},
+				Discovery: &mesos.DiscoveryInfo{
+					Name:  mesos.String("web"),
+					Ports: &mesos.Ports{Ports: []mesos.Port{{Number: 0, Name: mesos.String("http")}}},
+				},
+			},
+			Ports:       2,
+			Vars:        map[string]string{"ZONE": "z1", "HOST": "overridden"},
+			Environment: true,
+		}
+	)
+	task, remaining, err := tmpl.Instantiate(offer, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.TaskID.Value != "web-3" || task.AgentID.Value != "a" {
+		t.Fatalf("unexpected task and agent IDs: %v, %v", task.TaskID, task.AgentID)
+	}
+	if v := task.Command.GetValue(); v != "serve --listen=host-a:31000 --admin=31001 --zone=z1" {
+		t.Fatalf("unexpected command: %q", v)
+	}
+	if a := task.Command.Arguments; !reflect.DeepEqual(a, []string{"${HOST}"}) {
+		t.Fatalf("expected an escaped placeholder: %q", a)
+	}
+	if v := task.Labels.Labels[0].GetValue(); v != "web-3" {
+		t.Fatalf("unexpected label: %q", v)
+	}
+	if n := task.Discovery.Ports.Ports[0].Number; n != 31000 {
+		t.Fatalf("expected the discovery port to be assigned: %d", n)
+	}
+	env := map[string]string{}
+	for _, v := range task.Command.Environment.Variables {
+		env[v.Name] = v.GetValue()
+	}
+	if want := map[string]string{
+		"INDEX": "3", "HOST": "host-a", "AGENT_ID": "a", "PORT": "31000", "PORT0": "31000", "PORT1": "31001",
+		"PORTS": "31000,31001",
+	}; !reflect.DeepEqual(env, want) {
+		t.Fatalf("unexpected environment: %v", env)
+	}
+	if ports, _ := resources.Ports(task.Resources...); !ports.Equivalent(mesos.NewRanges(31000, 31001)) {
+		t.Fatalf("unexpected ports: %v", ports)
+	}
+	if cpus, _ := resources.CPUs(remaining...); cpus != 1 {
+		t.Fatalf("unexpected remaining cpus: %v", cpus)
+	}
+	if ports, _ := resources.Ports(remaining...); !ports.Equivalent(mesos.NewRanges(31002)) {
+		t.Fatalf("unexpected remaining ports: %v", ports)
+	}
+	// the prototype isn't modified
+	if v := tmpl.Prototype.Command.GetValue(); v != "serve --listen=${HOST}:${PORT0} --admin=${PORT1} --zone=${ZONE}" {
+		t.Fatalf("unexpected prototype command: %q", v)
+	}
+	if ports, _ := resources.Ports(offer.Resources...); !ports.Equivalent(mesos.NewRanges(31000, 31001, 31002)) {
+		t.Fatalf("unexpected offered ports: %v", ports)
+	}
+
+	tmpl.Prototype.Command.Value = mesos.String("${UNDEFINED}")
+	if _, _, err = tmpl.Instantiate(offer, 0, nil); err == nil {
+		t.Fatal("expected an error for an undefined variable")
+	}
+}
+
+func TestTemplatePack(t *testing.T) {
+	tmpl := Template{
+		Prototype: mesos.TaskInfo{
+			Name:      "db",
+			TaskID:    mesos.TaskID{Value: "db.${INDEX}.${PORT}"},
+			Resources: mesos.Resources{resources.NewMemory(256).Resource},
+			Container: &mesos.ContainerInfo{
+				Type: mesos.ContainerInfo_DOCKER.Enum(),
+				Docker: &mesos.ContainerInfo_DockerInfo{
+					Image:        "db",
+					PortMappings: []mesos.ContainerInfo_DockerInfo_PortMapping{{ContainerPort: 5432}},
+				},
+			},
+		},
+	}
+	tasks, err := tmpl.Pack(templateOffer(), 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// instances are limited by the ports of the offer, rather than its memory
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks instead of %d", len(tasks))
+	}
+	for i, want := range []string{"db.1.31000", "db.2.31001", "db.3.31002"} {
+		task := tasks[i]
+		if task.TaskID.Value != want {
+			t.Errorf("expected task ID %q instead of %q", want, task.TaskID.Value)
+		}
+		if p := task.Container.Docker.PortMappings[0].HostPort; p != uint32(31000+i) {
+			t.Errorf("unexpected host port of %q: %d", want, p)
+		}
+	}
+}
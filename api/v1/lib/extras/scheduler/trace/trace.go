@@ -0,0 +1,315 @@
+// Package trace maintains an in-memory ring buffer of the most recent calls issued by, and events received
+// by, a scheduler: a summary (type, time, key IDs, outcome) of each, which is cheap enough to keep always
+// on, and which may be dumped on demand (e.g. by a panic handler, or a debug HTTP endpoint) so that
+// crashes may be diagnosed without verbose logging. For a complete capture of calls and events, which may
+// be replayed, see the recording package.
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/callrules"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// Kind identifies the type of object that's summarized by an Entry.
+type Kind string
+
+const (
+	KindCall  = Kind("call")
+	KindEvent = Kind("event")
+)
+
+// DefaultSize is the default number of entries that a Buffer retains.
+const DefaultSize = 256
+
+// maxIDs bounds the number of IDs of an Entry; e.g. OFFERS events may report many offers.
+const maxIDs = 8
+
+// Entry summarizes a call or an event.
+type Entry struct {
+	Seq      uint64        `json:"seq"` // the number of entries that preceded this one
+	Time     time.Time     `json:"time"`
+	Kind     Kind          `json:"kind"`
+	Type     string        `json:"type"`               // e.g. "ACCEPT" or "UPDATE"
+	IDs      []string      `json:"ids,omitempty"`      // key IDs, in "kind:value" form, e.g. "task:web-1"
+	Detail   string        `json:"detail,omitempty"`   // e.g. the state reported by a status update
+	Duration time.Duration `json:"duration,omitempty"` // of a call
+	Error    string        `json:"error,omitempty"`    // of a call, or of the handling of an event
+}
+
+func (e *Entry) String() string {
+	s := fmt.Sprintf("#%d %s %s %s", e.Seq, e.Time.Format(time.RFC3339Nano), e.Kind, e.Type)
+	if len(e.IDs) > 0 {
+		s += " " + strings.Join(e.IDs, " ")
+	}
+	if e.Detail != "" {
+		s += " (" + e.Detail + ")"
+	}
+	if e.Duration > 0 {
+		s += " in " + e.Duration.String()
+	}
+	if e.Error != "" {
+		s += ": error: " + e.Error
+	}
+	return s
+}
+
+type (
+	// Option is a functional configuration option for a Buffer; it returns an Option that acts as an
+	// "undo" if applied to the same Buffer.
+	Option func(*Buffer) Option
+
+	// Buffer is a ring buffer of the most recent entries. Buffer funcs are safe to invoke concurrently.
+	Buffer struct {
+		clock mesostime.Clock
+
+		m       sync.Mutex
+		entries []Entry
+		seq     uint64 // the number of entries ever added
+	}
+)
+
+// Clock configures the clock that times entries; defaults to mesostime.SystemClock.
+func Clock(clock mesostime.Clock) Option {
+	return func(b *Buffer) Option {
+		old := b.clock
+		b.clock = clock
+		return Clock(old)
+	}
+}
+
+// New returns a Buffer that retains the given number of entries; see DefaultSize.
+func New(size int, opts ...Option) *Buffer {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	b := &Buffer{clock: mesostime.SystemClock, entries: make([]Entry, size)}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(b)
+		}
+	}
+	return b
+}
+
+// Add adds the given entry, replacing the oldest entry if the buffer is full. The sequence number of
+// the entry is assigned, as is its time (unless already set).
+func (b *Buffer) Add(e Entry) {
+	if e.Time.IsZero() {
+		e.Time = b.clock.Now()
+	}
+	b.m.Lock()
+	defer b.m.Unlock()
+	e.Seq = b.seq
+	b.entries[b.seq%uint64(len(b.entries))] = e
+	b.seq++
+}
+
+// Entries returns the retained entries, oldest first.
+func (b *Buffer) Entries() []Entry {
+	b.m.Lock()
+	defer b.m.Unlock()
+	var (
+		size  = uint64(len(b.entries))
+		first uint64
+	)
+	if b.seq > size {
+		first = b.seq - size
+	}
+	result := make([]Entry, 0, b.seq-first)
+	for seq := first; seq < b.seq; seq++ {
+		result = append(result, b.entries[seq%size])
+	}
+	return result
+}
+
+// Dump writes the retained entries to w, oldest first, one per line.
+func (b *Buffer) Dump(w io.Writer) error {
+	for _, e := range b.Entries() {
+		if _, err := fmt.Fprintln(w, e.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpOnPanic dumps the retained entries to w upon a panic, then resumes panicking. It must be deferred
+// directly, e.g. `defer buf.DumpOnPanic(os.Stderr)`, in the goroutine that may panic.
+func (b *Buffer) DumpOnPanic(w io.Writer) {
+	if r := recover(); r != nil {
+		fmt.Fprintf(w, "panic: %v; the most recent calls and events follow:\n", r)
+		b.Dump(w)
+		panic(r)
+	}
+}
+
+// Handler returns an http.Handler that responds to every request with the JSON encoded entries, oldest
+// first; or, if the "format" query parameter is "text", as per Dump.
+func (b *Buffer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			b.Dump(w)
+			return
+		}
+		body, err := json.MarshalIndent(b.Entries(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(body, '\n'))
+	})
+}
+
+// CallRule returns a Rule that adds an entry for each call, once the rest of the chain has executed.
+func (b *Buffer) CallRule() callrules.Rule {
+	return func(ctx context.Context, c *scheduler.Call, r mesos.Response, err error, ch callrules.Chain) (context.Context, *scheduler.Call, mesos.Response, error) {
+		start := b.clock.Now()
+		ctx, c, r, err = ch(ctx, c, r, err)
+		e := callEntry(c)
+		e.Time, e.Duration = start, b.clock.Now().Sub(start)
+		if err != nil {
+			e.Error = err.Error()
+		}
+		b.Add(e)
+		return ctx, c, r, err
+	}
+}
+
+// EventRule returns a Rule that adds an entry for each event, once the rest of the chain has executed.
+func (b *Buffer) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		received := b.clock.Now()
+		ctx, e, err = ch(ctx, e, err)
+		x := eventEntry(e)
+		x.Time = received
+		if err != nil {
+			x.Error = err.Error()
+		}
+		b.Add(x)
+		return ctx, e, err
+	}
+}
+
+// ids accumulates the key IDs of an entry, up to maxIDs.
+type ids struct {
+	list    []string
+	dropped int
+}
+
+func (x *ids) add(kind, value string) {
+	if value == "" {
+		return
+	}
+	if len(x.list) == maxIDs {
+		x.dropped++
+		return
+	}
+	x.list = append(x.list, kind+":"+value)
+}
+
+func (x *ids) result() []string {
+	if x.dropped > 0 {
+		return append(x.list, fmt.Sprintf("(+%d more)", x.dropped))
+	}
+	return x.list
+}
+
+func callEntry(c *scheduler.Call) Entry {
+	var (
+		x ids
+		e = Entry{Kind: KindCall, Type: c.GetType().String()}
+	)
+	switch c.GetType() {
+	case scheduler.Call_ACCEPT:
+		for _, id := range c.GetAccept().GetOfferIDs() {
+			x.add("offer", id.Value)
+		}
+		ops := c.GetAccept().GetOperations()
+		for i := range ops {
+			x.add("operation", ops[i].GetID().GetValue())
+			for _, t := range ops[i].GetLaunch().GetTaskInfos() {
+				x.add("task", t.TaskID.Value)
+			}
+			for _, t := range ops[i].GetLaunchGroup().GetTaskGroup().Tasks {
+				x.add("task", t.TaskID.Value)
+			}
+		}
+		e.Detail = fmt.Sprintf("%d operations", len(ops))
+	case scheduler.Call_DECLINE:
+		for _, id := range c.GetDecline().GetOfferIDs() {
+			x.add("offer", id.Value)
+		}
+	case scheduler.Call_KILL:
+		x.add("task", c.GetKill().GetTaskID().Value)
+		x.add("agent", c.GetKill().GetAgentID().GetValue())
+	case scheduler.Call_ACKNOWLEDGE:
+		x.add("task", c.GetAcknowledge().GetTaskID().Value)
+		x.add("agent", c.GetAcknowledge().GetAgentID().Value)
+	case scheduler.Call_ACKNOWLEDGE_OPERATION_STATUS:
+		x.add("operation", c.GetAcknowledgeOperationStatus().GetOperationID().Value)
+	case scheduler.Call_RECONCILE:
+		e.Detail = fmt.Sprintf("%d tasks", len(c.GetReconcile().GetTasks()))
+	case scheduler.Call_SHUTDOWN:
+		x.add("executor", c.GetShutdown().GetExecutorID().Value)
+		x.add("agent", c.GetShutdown().GetAgentID().Value)
+	case scheduler.Call_REVIVE:
+		e.Detail = strings.Join(c.GetRevive().GetRoles(), ",")
+	case scheduler.Call_SUPPRESS:
+		e.Detail = strings.Join(c.GetSuppress().GetRoles(), ",")
+	}
+	e.IDs = x.result()
+	return e
+}
+
+func eventEntry(ev *scheduler.Event) Entry {
+	var (
+		x ids
+		e = Entry{Kind: KindEvent, Type: ev.GetType().String()}
+	)
+	switch ev.GetType() {
+	case scheduler.Event_SUBSCRIBED:
+		x.add("framework", ev.GetSubscribed().GetFrameworkID().GetValue())
+	case scheduler.Event_OFFERS:
+		offers := ev.GetOffers().GetOffers()
+		for i := range offers {
+			x.add("offer", offers[i].ID.Value)
+		}
+		e.Detail = fmt.Sprintf("%d offers", len(offers))
+	case scheduler.Event_RESCIND:
+		x.add("offer", ev.GetRescind().GetOfferID().Value)
+	case scheduler.Event_UPDATE:
+		if u := ev.GetUpdate(); u != nil {
+			x.add("task", u.Status.TaskID.Value)
+			x.add("agent", u.Status.GetAgentID().GetValue())
+			e.Detail = u.Status.GetState().String()
+			if reason := u.Status.Reason; reason != nil {
+				e.Detail += ", " + reason.String()
+			}
+		}
+	case scheduler.Event_UPDATE_OPERATION_STATUS:
+		if u := ev.GetUpdateOperationStatus(); u != nil {
+			x.add("operation", u.Status.GetOperationID().GetValue())
+			e.Detail = u.Status.GetState().String()
+		}
+	case scheduler.Event_FAILURE:
+		x.add("agent", ev.GetFailure().GetAgentID().GetValue())
+		x.add("executor", ev.GetFailure().GetExecutorID().GetValue())
+	case scheduler.Event_ERROR:
+		e.Detail = ev.GetError().GetMessage()
+	}
+	e.IDs = x.result()
+	return e
+}
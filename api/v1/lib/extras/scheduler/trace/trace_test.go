@@ -0,0 +1,116 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// ticking is a clock that advances by a second whenever its time is observed.
+type ticking struct{ *mesostime.FakeClock }
+
+func (c ticking) Now() time.Time {
+	c.Advance(time.Second)
+	return c.FakeClock.Now()
+}
+
+func TestBuffer(t *testing.T) {
+	b := New(3, Clock(ticking{mesostime.NewFakeClock(time.Unix(0, 0))}))
+	if got := b.Entries(); len(got) != 0 {
+		t.Fatalf("expected no entries instead of %v", got)
+	}
+	for _, typ := range []string{"A", "B", "C", "D", "E"} {
+		b.Add(Entry{Kind: KindCall, Type: typ})
+	}
+	var types []string
+	for _, e := range b.Entries() {
+		types = append(types, e.Type)
+	}
+	if !reflect.DeepEqual(types, []string{"C", "D", "E"}) {
+		t.Fatalf("expected the 3 most recent entries instead of %v", types)
+	}
+	if e := b.Entries()[0]; e.Seq != 2 || !e.Time.Equal(time.Unix(3, 0)) {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	var buf bytes.Buffer
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the panic to resume instead of %v", r)
+			}
+		}()
+		defer b.DumpOnPanic(&buf)
+		panic("boom")
+	}()
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 4 || !strings.HasPrefix(lines[1], "#2 ") {
+		t.Fatalf("unexpected dump %q", buf.String())
+	}
+}
+
+func TestRules(t *testing.T) {
+	var (
+		b      = New(0)
+		ctx    = context.Background()
+		failed = errors.New("failed")
+		caller = b.CallRule().Caller(calls.CallerFunc(func(_ context.Context, c *scheduler.Call) (mesos.Response, error) {
+			if c.GetType() == scheduler.Call_KILL {
+				return nil, failed
+			}
+			return nil, nil
+		}))
+		task = mesos.TaskInfo{TaskID: mesos.TaskID{Value: "t1"}}
+	)
+	caller.Call(ctx, calls.Accept(calls.OfferOperations{calls.OpLaunch(task)}.WithOffers(mesos.OfferID{Value: "o1"})))
+	caller.Call(ctx, calls.Kill("t1", "a1"))
+
+	offers := make([]mesos.Offer, 10)
+	for i := range offers {
+		offers[i].ID.Value = string('a' + rune(i))
+	}
+	handler := b.EventRule()
+	handler.HandleEvent(ctx, &scheduler.Event{Type: scheduler.Event_OFFERS, Offers: &scheduler.Event_Offers{Offers: offers}})
+	handler.HandleEvent(ctx, &scheduler.Event{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{
+		Status: mesos.TaskStatus{TaskID: task.TaskID, State: mesos.TASK_FAILED.Enum(), Reason: mesos.REASON_CONTAINER_LIMITATION.Enum()},
+	}})
+
+	entries := b.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	for i, want := range []Entry{
+		{Kind: KindCall, Type: "ACCEPT", IDs: []string{"offer:o1", "task:t1"}, Detail: "1 operations"},
+		{Kind: KindCall, Type: "KILL", IDs: []string{"task:t1", "agent:a1"}, Error: "failed"},
+		{Kind: KindEvent, Type: "OFFERS", IDs: []string{"offer:a", "offer:b", "offer:c", "offer:d", "offer:e", "offer:f", "offer:g", "offer:h", "(+2 more)"}, Detail: "10 offers"},
+		{Kind: KindEvent, Type: "UPDATE", IDs: []string{"task:t1"}, Detail: "TASK_FAILED, REASON_CONTAINER_LIMITATION"},
+	} {
+		got := entries[i]
+		got.Seq, got.Time, got.Duration = 0, time.Time{}, 0
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("entry %d: expected %+v instead of %+v", i, want, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/trace", nil))
+	var decoded []Entry
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil || len(decoded) != 4 || decoded[3].Detail != entries[3].Detail {
+		t.Fatalf("unexpected response %q: %v", rec.Body.String(), err)
+	}
+	rec = httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/trace?format=text", nil))
+	if !strings.Contains(rec.Body.String(), "call KILL task:t1 agent:a1") || !strings.Contains(rec.Body.String(), "error: failed") {
+		t.Fatalf("unexpected response %q", rec.Body.String())
+	}
+}
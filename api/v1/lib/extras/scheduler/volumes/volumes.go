@@ -0,0 +1,477 @@
+// Package volumes manages the lifecycle of the persistent volumes of a framework: a Manager tracks the
+// desired volumes of each role, generates the CREATE operations that create them from the (reserved) disk
+// resources of matching offers, and the DESTROY operations that destroy volumes that are no longer desired.
+// Operation feedback (UPDATE_OPERATION_STATUS events) reports the outcome of operations, and offers confirm
+// the existence of volumes; following a (re)subscription the Manager is reconciled with the volumes that
+// the master reports, so that orphaned volumes are eventually destroyed.
+package volumes
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/extras/scheduler/eventrules"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	mastercalls "github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/resourcefilters"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+// State is the lifecycle state of a persistent volume.
+type State int
+
+const (
+	// Pending volumes are desired, but don't exist.
+	Pending State = iota
+	// Creating volumes are the subject of a CREATE operation that's yet to complete.
+	Creating
+	// Created volumes exist, and are desired.
+	Created
+	// Orphaned volumes exist, but aren't desired: they're destroyed via the next offer of their agent.
+	Orphaned
+	// Destroying volumes are the subject of a DESTROY operation that's yet to complete.
+	Destroying
+)
+
+func (s State) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Creating:
+		return "creating"
+	case Created:
+		return "created"
+	case Orphaned:
+		return "orphaned"
+	case Destroying:
+		return "destroying"
+	default:
+		return "unknown"
+	}
+}
+
+type (
+	// Volume is a desired persistent volume. Volumes are identified by their role and name, from which
+	// (along with the principal of the Manager) the persistence ID is derived; see resources.PersistenceID.
+	Volume struct {
+		Role          string
+		Name          string
+		Size          float64 // in megabytes; MOUNT disks are consumed whole, and so may be larger
+		ContainerPath string
+		Mode          mesos.Volume_Mode
+		AgentID       *mesos.AgentID // optional, restricts the volume to the given agent
+	}
+
+	// Status reports the state of a tracked volume.
+	Status struct {
+		ID          string // persistence ID
+		Role        string
+		Name        string // empty for orphaned volumes that weren't declared
+		State       State
+		AgentID     string // the agent on which the volume exists, or is being created
+		OperationID string // of the operation that's yet to complete, if any
+		Err         error  // of the last failed operation, if any
+	}
+
+	// Option is a functional configuration option for a Manager; it returns an Option that acts as an
+	// "undo" if applied to the same Manager.
+	Option func(*Manager) Option
+
+	// Manager converges the persistent volumes of a framework toward the declared set. Manager funcs are
+	// safe to invoke concurrently.
+	Manager struct {
+		principal string
+		feedback  bool
+		opIDs     func(persistenceID string) string
+
+		m       sync.Mutex
+		volumes map[string]*volume // by persistence ID
+		ops     map[string]string  // persistence IDs, by operation ID
+		serial  uint64
+	}
+
+	volume struct {
+		Volume
+		desired  bool
+		state    State
+		agentID  *mesos.AgentID
+		opID     string
+		resource *mesos.Resource // the volume, as last observed
+		err      error
+	}
+
+	// OperationError is reported by the Status of a volume for which an operation failed.
+	OperationError struct {
+		OperationID string
+		State       mesos.OperationState
+		Message     string
+	}
+)
+
+func (err *OperationError) Error() string {
+	return "operation " + err.OperationID + " " + err.State.String() + ": " + err.Message
+}
+
+// OperationFeedback configures whether operations are assigned IDs, and so generate UPDATE_OPERATION_STATUS
+// events; defaults to true. Older versions of Mesos support operation feedback only for the resources of
+// resource providers: with feedback disabled, created volumes are confirmed by offers and reconciliation.
+func OperationFeedback(b bool) Option {
+	return func(m *Manager) Option {
+		old := m.feedback
+		m.feedback = b
+		return OperationFeedback(old)
+	}
+}
+
+// OperationIDs configures the generator of the IDs of operations (when feedback is enabled), which is
+// invoked with the persistence ID of the volume and must return a unique ID; e.g. to embed the identity of
+// a framework instance into the IDs. Defaults to IDs of the form "volumes.<persistence ID>.<serial>".
+func OperationIDs(f func(persistenceID string) string) Option {
+	return func(m *Manager) Option {
+		old := m.opIDs
+		m.opIDs = f
+		return OperationIDs(old)
+	}
+}
+
+// NewManager returns a Manager for the volumes that are created with the given principal, which should be
+// the principal of the framework.
+func NewManager(principal string, opts ...Option) *Manager {
+	m := &Manager{
+		principal: principal,
+		feedback:  true,
+		volumes:   make(map[string]*volume),
+		ops:       make(map[string]string),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	return m
+}
+
+// ID returns the persistence ID of the given volume.
+func (m *Manager) ID(v Volume) string { return resources.PersistenceID(m.principal, v.Role, v.Name) }
+
+// Declare adds the given volumes to the desired set, or updates the specification of volumes that are
+// already desired (which doesn't affect volumes that already exist). A tracked orphan with the same
+// persistence ID is adopted.
+func (m *Manager) Declare(vs ...Volume) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	for _, v := range vs {
+		id := m.ID(v)
+		t, ok := m.volumes[id]
+		if !ok {
+			t = &volume{state: Pending}
+			m.volumes[id] = t
+		}
+		t.Volume, t.desired = v, true
+		if t.state == Orphaned {
+			t.state = Created
+		}
+	}
+}
+
+// Retire removes the volume with the given role and name from the desired set: an existing volume is
+// destroyed via the next offer of its agent.
+func (m *Manager) Retire(role, name string) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	id := resources.PersistenceID(m.principal, role, name)
+	t, ok := m.volumes[id]
+	if !ok {
+		return
+	}
+	t.desired = false
+	switch t.state {
+	case Pending:
+		delete(m.volumes, id)
+	case Created:
+		t.state = Orphaned
+	}
+}
+
+// Operations returns the CREATE and DESTROY operations that converge the volumes of the offer's agent
+// toward the desired set, using the offer's resources; typically these are accepted along with other
+// operations (e.g. calls.OpLaunch). The affected volumes are considered to be Creating, or Destroying,
+// until the operations complete: a framework that declines to accept the operations should Reconcile.
+func (m *Manager) Operations(o *mesos.Offer) (ops []mesos.Offer_Operation) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	var (
+		ours      = resourcefilters.PersistentVolumesOf(m.principal)
+		available []mesos.Resource
+	)
+	for i := range o.Resources {
+		r := &o.Resources[i]
+		if !r.IsPersistentVolume() {
+			if resources.NameDisk.Filter(r) && r.IsReserved("") {
+				available = append(available, *proto.Clone(r).(*mesos.Resource))
+			}
+			continue
+		}
+		if !ours.Accepts(r) {
+			continue
+		}
+		id := r.GetDisk().GetPersistence().GetID()
+		t, ok := m.volumes[id]
+		if !ok {
+			t = &volume{Volume: Volume{Role: r.ReservationRole()}, state: Orphaned}
+			m.volumes[id] = t
+		}
+		agentID, res := o.AgentID, *r
+		t.agentID, t.resource = &agentID, &res
+		switch {
+		case t.desired && t.state != Destroying:
+			m.settle(t, Created)
+		case !t.desired && t.state != Destroying:
+			ops = append(ops, m.operation(t, id, Destroying, calls.OpDestroy(*r)))
+		}
+	}
+
+	for _, id := range m.pending() {
+		t := m.volumes[id]
+		if t.AgentID != nil && *t.AgentID != o.AgentID {
+			continue
+		}
+		vol, ok := m.allocate(t.Volume, id, available)
+		if !ok {
+			continue
+		}
+		agentID := o.AgentID
+		t.agentID, t.resource = &agentID, &vol
+		ops = append(ops, m.operation(t, id, Creating, calls.OpCreate(vol)))
+	}
+	return
+}
+
+// pending returns the sorted persistence IDs of pending volumes; the manager must be locked.
+func (m *Manager) pending() (ids []string) {
+	for id, t := range m.volumes {
+		if t.state == Pending && t.desired {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return
+}
+
+// allocate carves a volume out of the available disk resources, which are consumed accordingly.
+func (m *Manager) allocate(v Volume, id string, available []mesos.Resource) (mesos.Resource, bool) {
+	for i := range available {
+		r := &available[i]
+		if !r.IsReserved(v.Role) || r.GetScalar().GetValue() < v.Size {
+			continue
+		}
+		vol := *proto.Clone(r).(*mesos.Resource)
+		switch {
+		case r.IsDisk(mesos.Resource_DiskInfo_Source_MOUNT):
+			r.Scalar.Value = 0 // MOUNT disks cannot be split
+		case r.IsDisk(mesos.Resource_DiskInfo_Source_BLOCK), r.IsDisk(mesos.Resource_DiskInfo_Source_RAW):
+			continue // not a file system
+		default:
+			vol.Scalar.Value = v.Size
+			r.Scalar.Value -= v.Size
+		}
+		if vol.Disk == nil {
+			vol.Disk = &mesos.Resource_DiskInfo{}
+		}
+		vol.Disk.Persistence = &mesos.Resource_DiskInfo_Persistence{ID: id}
+		if m.principal != "" {
+			principal := m.principal
+			vol.Disk.Persistence.Principal = &principal
+		}
+		if v.ContainerPath != "" {
+			vol.Disk.Volume = &mesos.Volume{ContainerPath: v.ContainerPath, Mode: v.Mode.Enum()}
+		}
+		return vol, true
+	}
+	return mesos.Resource{}, false
+}
+
+// operation transitions a volume to the given state, assigning an ID to the operation if feedback is
+// enabled; the manager must be locked.
+func (m *Manager) operation(t *volume, id string, s State, op mesos.Offer_Operation) mesos.Offer_Operation {
+	m.settle(t, s)
+	if m.feedback {
+		if m.opIDs != nil {
+			t.opID = m.opIDs(id)
+		} else {
+			m.serial++
+			t.opID = "volumes." + id + "." + strconv.FormatUint(m.serial, 10)
+		}
+		m.ops[t.opID] = id
+		op.ID = &mesos.OperationID{Value: t.opID}
+	}
+	return op
+}
+
+// settle transitions a volume to the given state, clearing any operation; the manager must be locked.
+func (m *Manager) settle(t *volume, s State) {
+	if t.opID != "" {
+		delete(m.ops, t.opID)
+		t.opID = ""
+	}
+	t.state = s
+}
+
+// Update applies the given operation status, returning false if the operation isn't one of the Manager's.
+func (m *Manager) Update(s *mesos.OperationStatus) bool {
+	m.m.Lock()
+	defer m.m.Unlock()
+	opID := s.GetOperationID().GetValue()
+	id, ok := m.ops[opID]
+	if !ok {
+		return false
+	}
+	t := m.volumes[id]
+	switch s.GetState() {
+	case mesos.OPERATION_FINISHED:
+		t.err = nil
+		if t.state == Creating {
+			m.settle(t, Created)
+			if !t.desired {
+				t.state = Orphaned
+			}
+		} else {
+			m.settle(t, Pending)
+			if !t.desired {
+				delete(m.volumes, id)
+			}
+			t.agentID, t.resource = nil, nil
+		}
+	case mesos.OPERATION_FAILED, mesos.OPERATION_ERROR, mesos.OPERATION_DROPPED, mesos.OPERATION_GONE_BY_OPERATOR:
+		t.err = &OperationError{OperationID: opID, State: s.GetState(), Message: s.GetMessage()}
+		if t.state == Creating {
+			m.settle(t, Pending)
+			t.agentID, t.resource = nil, nil
+			if !t.desired {
+				delete(m.volumes, id)
+			}
+		} else {
+			m.settle(t, Orphaned) // retried via the next offer
+		}
+	}
+	return true
+}
+
+// EventRule returns a Rule that applies the status updates of the Manager's operations; it doesn't
+// acknowledge them, see controller.AckOperationUpdates.
+func (m *Manager) EventRule() eventrules.Rule {
+	return func(ctx context.Context, e *scheduler.Event, err error, ch eventrules.Chain) (context.Context, *scheduler.Event, error) {
+		if err == nil && e.GetType() == scheduler.Event_UPDATE_OPERATION_STATUS {
+			m.Update(&e.GetUpdateOperationStatus().Status)
+		}
+		return ch(ctx, e, err)
+	}
+}
+
+// Reconcile issues a GET_AGENTS call via the given sender, and reconciles the tracked volumes with the
+// volumes (created by the Manager's principal) that the master reports: unknown volumes are orphans, and
+// tracked volumes that don't exist are (re)created if they're desired. Frameworks should reconcile upon
+// every (re)subscription, since operations that were in flight may have been dropped.
+func (m *Manager) Reconcile(ctx context.Context, sender mastercalls.Sender) error {
+	agents, err := mastercalls.SendGetAgents(ctx, sender)
+	if err != nil {
+		return err
+	}
+	m.reconcile(agents.Agents)
+	return nil
+}
+
+func (m *Manager) reconcile(agents []master.Response_GetAgents_Agent) {
+	type found struct {
+		agentID  mesos.AgentID
+		resource *mesos.Resource
+	}
+	var (
+		ours     = resourcefilters.PersistentVolumesOf(m.principal)
+		existing = make(map[string]found)
+	)
+	for i := range agents {
+		a := &agents[i]
+		if a.AgentInfo.ID == nil {
+			continue
+		}
+		for j := range a.TotalResources {
+			if r := &a.TotalResources[j]; ours.Accepts(r) {
+				existing[r.GetDisk().GetPersistence().GetID()] = found{*a.AgentInfo.ID, r}
+			}
+		}
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+	for id, f := range existing {
+		t, ok := m.volumes[id]
+		if !ok {
+			t = &volume{Volume: Volume{Role: f.resource.ReservationRole()}, state: Orphaned}
+			m.volumes[id] = t
+		}
+		agentID, res := f.agentID, *f.resource
+		t.agentID, t.resource = &agentID, &res
+		if t.desired {
+			m.settle(t, Created)
+		} else {
+			m.settle(t, Orphaned)
+		}
+	}
+	for id, t := range m.volumes {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		if !t.desired {
+			m.settle(t, Pending)
+			delete(m.volumes, id)
+			continue
+		}
+		m.settle(t, Pending)
+		t.agentID, t.resource = nil, nil
+	}
+}
+
+// Status returns the status of every tracked volume, ordered by persistence ID.
+func (m *Manager) Status() []Status {
+	m.m.Lock()
+	defer m.m.Unlock()
+	result := make([]Status, 0, len(m.volumes))
+	for id, t := range m.volumes {
+		s := Status{
+			ID:          id,
+			Role:        t.Role,
+			Name:        t.Name,
+			State:       t.state,
+			AgentID:     t.agentID.GetValue(),
+			OperationID: t.opID,
+			Err:         t.err,
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// ErrUnknownVolume is returned by Resource for volumes that aren't tracked, or haven't been created.
+var ErrUnknownVolume = errors.New("unknown volume")
+
+// Resource returns the resource of the created volume with the given role and name, e.g. so that it may
+// be included in the resources of a task.
+func (m *Manager) Resource(role, name string) (mesos.Resource, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	t, ok := m.volumes[resources.PersistenceID(m.principal, role, name)]
+	if !ok || t.state != Created || t.resource == nil {
+		return mesos.Resource{}, ErrUnknownVolume
+	}
+	return *t.resource, nil
+}
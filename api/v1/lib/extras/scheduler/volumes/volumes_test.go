@@ -0,0 +1,168 @@
+package volumes
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/master/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func state(t *testing.T, m *Manager, id string) Status {
+	for _, s := range m.Status() {
+		if s.ID == id {
+			return s
+		}
+	}
+	t.Fatalf("volume %q isn't tracked", id)
+	return Status{}
+}
+
+func finished(opID string, st mesos.OperationState) *mesos.OperationStatus {
+	return &mesos.OperationStatus{OperationID: &mesos.OperationID{Value: opID}, State: st}
+}
+
+func TestManager(t *testing.T) {
+	var (
+		m     = NewManager("fw")
+		data  = Volume{Role: "db", Name: "data", Size: 100, ContainerPath: "data", Mode: mesos.RW}
+		logs  = Volume{Role: "db", Name: "logs", Size: 50, ContainerPath: "logs", Mode: mesos.RW}
+		other = Volume{Role: "web", Name: "cache", Size: 10, ContainerPath: "cache", Mode: mesos.RW}
+		offer = func(rs ...mesos.Resource) *mesos.Offer {
+			return &mesos.Offer{AgentID: mesos.AgentID{Value: "a1"}, Resources: rs}
+		}
+	)
+	m.Declare(data, logs, other)
+
+	// only disks reserved for the volume's role are used; unreserved disks are ignored
+	ops := m.Operations(offer(
+		resources.NewDisk(1000).Resource,
+		resources.NewDisk(120).Role("db").Resource,
+	))
+	if len(ops) != 1 || ops[0].GetType() != mesos.Offer_Operation_CREATE {
+		t.Fatalf("expected a single CREATE operation instead of %+v", ops)
+	}
+	vol := ops[0].GetCreate().Volumes[0]
+	if vol.GetScalar().GetValue() != 100 || vol.GetDisk().GetPersistence().GetID() != m.ID(data) ||
+		vol.GetDisk().GetPersistence().GetPrincipal() != "fw" || vol.GetDisk().GetVolume().GetContainerPath() != "data" {
+		t.Fatalf("unexpected volume %v", vol)
+	}
+	createData := ops[0].GetID().GetValue()
+	if s := state(t, m, m.ID(data)); s.State != Creating || s.AgentID != "a1" || s.OperationID != createData {
+		t.Fatalf("unexpected status %+v", s)
+	}
+	if s := state(t, m, m.ID(logs)); s.State != Pending {
+		t.Fatalf("expected pending volume (insufficient disk) instead of %+v", s)
+	}
+
+	// operation feedback
+	if !m.Update(finished(createData, mesos.OPERATION_FINISHED)) {
+		t.Fatal("expected the operation to be recognized")
+	}
+	if m.Update(finished("unknown", mesos.OPERATION_FINISHED)) {
+		t.Fatal("unexpected recognition of an unknown operation")
+	}
+	if s := state(t, m, m.ID(data)); s.State != Created || s.OperationID != "" {
+		t.Fatalf("unexpected status %+v", s)
+	}
+	if r, err := m.Resource("db", "data"); err != nil || r.GetDisk().GetPersistence().GetID() != m.ID(data) {
+		t.Fatalf("unexpected resource %v, %v", r, err)
+	}
+
+	// failures are retried via subsequent offers
+	ops = m.Operations(offer(resources.NewDisk(60).Role("db").Resource))
+	if len(ops) != 1 {
+		t.Fatalf("expected a single CREATE operation instead of %+v", ops)
+	}
+	m.Update(finished(ops[0].GetID().GetValue(), mesos.OPERATION_FAILED))
+	if s := state(t, m, m.ID(logs)); s.State != Pending || s.Err == nil {
+		t.Fatalf("expected a failed, pending volume instead of %+v", s)
+	}
+
+	// retired volumes are destroyed via an offer of them, as are unknown orphans
+	m.Retire("db", "data")
+	if s := state(t, m, m.ID(data)); s.State != Orphaned {
+		t.Fatalf("unexpected status %+v", s)
+	}
+	orphan := resources.NewDisk(5).Role("db").Persistence("fw.db.old", "fw").Resource
+	foreign := resources.NewDisk(5).Role("db").Persistence("x", "someone-else").Resource
+	ops = m.Operations(offer(vol, orphan, foreign))
+	if len(ops) != 2 || ops[0].GetType() != mesos.Offer_Operation_DESTROY || ops[1].GetType() != mesos.Offer_Operation_DESTROY {
+		t.Fatalf("expected two DESTROY operations instead of %+v", ops)
+	}
+	for i := range ops {
+		m.Update(finished(ops[i].GetID().GetValue(), mesos.OPERATION_FINISHED))
+	}
+	for _, s := range m.Status() {
+		if s.ID == m.ID(data) || s.ID == "fw.db.old" || s.ID == "x" {
+			t.Fatalf("unexpected status of a destroyed volume %+v", s)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	var (
+		m      = NewManager("fw", OperationFeedback(false))
+		data   = Volume{Role: "db", Name: "data", Size: 100}
+		logs   = Volume{Role: "db", Name: "logs", Size: 50}
+		sender = calls.SenderFunc(func(_ context.Context, r calls.Request) (mesos.Response, error) {
+			resp := master.Response{Type: master.Response_GET_AGENTS, GetAgents: &master.Response_GetAgents{
+				Agents: []master.Response_GetAgents_Agent{{
+					AgentInfo: mesos.AgentInfo{ID: &mesos.AgentID{Value: "a2"}},
+					TotalResources: []mesos.Resource{
+						resources.NewDisk(100).Role("db").Persistence(m.ID(data), "fw").Resource,
+						resources.NewDisk(10).Role("db").Persistence("fw.db.old", "fw").Resource,
+					},
+				}},
+			}}
+			return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+				*(u.(*master.Response)) = resp
+				return nil
+			})}, nil
+		})
+	)
+	m.Declare(data, logs)
+	ops := m.Operations(&mesos.Offer{AgentID: mesos.AgentID{Value: "a1"}, Resources: []mesos.Resource{
+		resources.NewDisk(50).Role("db").Resource,
+	}})
+	if len(ops) != 1 || ops[0].ID != nil {
+		t.Fatalf("expected a single CREATE operation, without feedback, instead of %+v", ops)
+	}
+	if err := m.Reconcile(context.Background(), sender); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]State{
+		m.ID(data):  Created,
+		m.ID(logs):  Pending, // the CREATE operation was lost
+		"fw.db.old": Orphaned,
+	} {
+		if s := state(t, m, id); s.State != want {
+			t.Errorf("expected volume %q to be %v instead of %v", id, want, s.State)
+		}
+	}
+	if s := state(t, m, m.ID(data)); s.AgentID != "a2" {
+		t.Errorf("unexpected agent of reconciled volume %+v", s)
+	}
+}
+
+func TestOperationIDs(t *testing.T) {
+	var (
+		n    int
+		m    = NewManager("fw", OperationIDs(func(id string) string { n++; return "shard-3/" + id + "/" + strconv.Itoa(n) }))
+		data = Volume{Role: "db", Name: "data", Size: 100}
+	)
+	m.Declare(data)
+	ops := m.Operations(&mesos.Offer{AgentID: mesos.AgentID{Value: "a1"}, Resources: []mesos.Resource{
+		resources.NewDisk(100).Role("db").Resource,
+	}})
+	if len(ops) != 1 || ops[0].GetID().GetValue() != "shard-3/"+m.ID(data)+"/1" {
+		t.Fatalf("expected a generated operation ID instead of %+v", ops)
+	}
+	if !m.Update(finished(ops[0].GetID().GetValue(), mesos.OPERATION_FINISHED)) {
+		t.Fatal("expected the operation to be recognized")
+	}
+}
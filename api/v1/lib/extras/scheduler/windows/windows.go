@@ -0,0 +1,171 @@
+// Package windows helps frameworks to build, and validate, the commands, containers, and volumes of tasks
+// that are launched on Windows agents; which differ from those of POSIX agents in ways that otherwise only
+// surface as task failures: shell commands are executed by cmd.exe (rather than /bin/sh), executables
+// require an extension, paths are rooted at drive letters, and Linux-specific container settings are
+// rejected. The funcs of this package interpret paths as per Windows, regardless of the operating system
+// of the scheduler.
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// OS is the name of the operating system of Windows agents, as per runtime.GOOS.
+const OS = "windows"
+
+// ErrUserUnsupported is returned for commands that specify a user: Windows agents don't support launching
+// tasks as another user.
+var ErrUserUnsupported = errors.New("windows agents don't support running commands as another user")
+
+// IsAbs returns true if the given path is an absolute Windows path: either rooted at a drive letter (e.g.
+// `C:\data`, or `C:/data`) or a UNC path (e.g. `\\server\share`). Paths that are rooted without a drive
+// letter (e.g. `\data`, or `/data`) are relative to the current drive, and therefore aren't absolute.
+func IsAbs(path string) bool {
+	if len(path) >= 3 && isLetter(path[0]) && path[1] == ':' && isSeparator(path[2]) {
+		return true
+	}
+	return len(path) > 2 && isSeparator(path[0]) && isSeparator(path[1]) && !isSeparator(path[2])
+}
+
+func isLetter(c byte) bool    { return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') }
+func isSeparator(c byte) bool { return c == '\\' || c == '/' }
+
+// Executable returns the name of an executable file as required by Windows: with an ".exe" extension
+// unless the name already has an extension.
+func Executable(name string) string {
+	base := name[strings.LastIndexAny(name, `\/`)+1:]
+	if strings.Contains(base, ".") {
+		return name
+	}
+	return name + ".exe"
+}
+
+// Command returns a CommandInfo that executes the given executable (e.g. an absolute path, or a file
+// fetched into the sandbox, such as `.\executor.exe`) with the given arguments, without a shell; which,
+// unlike a shell command, is executed identically by Windows and POSIX agents. As per Mesos, the first
+// argument is the executable itself.
+func Command(executable string, args ...string) *mesos.CommandInfo {
+	return &mesos.CommandInfo{
+		Shell:     proto.Bool(false),
+		Value:     proto.String(executable),
+		Arguments: append([]string{executable}, args...),
+	}
+}
+
+// ShellCommand returns a CommandInfo that executes the given command via cmd.exe: i.e. it's subject to
+// the syntax of cmd.exe (e.g. `&&`, `%VAR%`, and `^` escapes), rather than that of a POSIX shell.
+func ShellCommand(command string) *mesos.CommandInfo {
+	return &mesos.CommandInfo{
+		Shell: proto.Bool(true),
+		Value: proto.String(command),
+	}
+}
+
+// Volume returns a volume that mounts the given absolute host path at the given container path, which is
+// either absolute or else relative to the sandbox; the paths are validated as per ValidateVolume.
+func Volume(hostPath, containerPath string, mode mesos.Volume_Mode) (mesos.Volume, error) {
+	v := mesos.Volume{
+		ContainerPath: containerPath,
+		HostPath:      proto.String(hostPath),
+		Mode:          mode.Enum(),
+	}
+	return v, ValidateVolume(v)
+}
+
+// ValidateVolume returns an error if the paths of the given volume aren't valid Windows paths: the host
+// path, if any, must be absolute; the container path must either be absolute or else relative to the
+// sandbox (without escaping it). Paths that are rooted without a drive letter, as POSIX paths are, are
+// rejected.
+func ValidateVolume(v mesos.Volume) error {
+	if p := v.GetHostPath(); p != "" && !IsAbs(p) {
+		return fmt.Errorf("host path %q of volume isn't an absolute windows path (e.g. C:\\data)", p)
+	}
+	p := v.ContainerPath
+	switch {
+	case p == "":
+		return errors.New("volume has no container path")
+	case IsAbs(p):
+		return nil
+	case isSeparator(p[0]):
+		return fmt.Errorf("container path %q of volume has no drive letter", p)
+	case len(p) >= 2 && p[1] == ':':
+		return fmt.Errorf("container path %q of volume is relative to a drive", p)
+	}
+	for _, elem := range strings.FieldsFunc(p, func(r rune) bool { return r < 0x80 && isSeparator(byte(r)) }) {
+		if elem == ".." {
+			return fmt.Errorf("container path %q of volume escapes the sandbox", p)
+		}
+	}
+	return nil
+}
+
+// ValidateCommand returns an error if the given command relies upon features that Windows agents don't
+// support: such as running as another user, or executing files by POSIX paths (e.g. `./executor`, or
+// `/usr/bin/env`).
+func ValidateCommand(c *mesos.CommandInfo) error {
+	if c == nil {
+		return nil
+	}
+	if c.User != nil {
+		return ErrUserUnsupported
+	}
+	v := c.GetValue()
+	if c.GetShell() {
+		v = strings.TrimSpace(v)
+	}
+	switch {
+	case strings.HasPrefix(v, "./") || strings.HasPrefix(v, "../"):
+		return fmt.Errorf("command %q executes a file by a POSIX path; use a `.\\` prefix instead", v)
+	case strings.HasPrefix(v, "/"):
+		return fmt.Errorf("command %q executes a file by a POSIX path; windows paths begin with a drive letter", v)
+	}
+	return nil
+}
+
+// ValidateContainer returns an error if the given container relies upon features that Windows agents don't
+// support: Linux (namespace, capability, and rlimit) settings, and container images of the Mesos
+// containerizer. The volumes of the container are validated as per ValidateVolume.
+func ValidateContainer(c *mesos.ContainerInfo) error {
+	switch {
+	case c == nil:
+		return nil
+	case c.LinuxInfo != nil:
+		return errors.New("windows agents don't support linux container settings")
+	case c.RlimitInfo != nil:
+		return errors.New("windows agents don't support rlimits")
+	case c.GetType() == mesos.ContainerInfo_MESOS && c.GetMesos().GetImage() != nil:
+		return errors.New("the mesos containerizer of windows agents doesn't support container images")
+	}
+	for _, v := range c.Volumes {
+		if v.Image != nil {
+			return errors.New("windows agents don't support image volumes")
+		}
+		if err := ValidateVolume(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateTask returns an error if the command, container, or executor of the given task relies upon
+// features that Windows agents don't support; see ValidateCommand and ValidateContainer.
+func ValidateTask(t mesos.TaskInfo) error {
+	err := ValidateCommand(t.Command)
+	if err == nil {
+		err = ValidateContainer(t.Container)
+	}
+	if err == nil && t.Executor != nil {
+		if err = ValidateCommand(t.Executor.Command); err == nil {
+			err = ValidateContainer(t.Executor.Container)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("task %q: %v", t.TaskID.Value, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package windows
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+func TestIsAbs(t *testing.T) {
+	for path, expected := range map[string]bool{
+		`C:\data`:        true,
+		`c:/data`:        true,
+		`\\server\share`: true,
+		`C:data`:         false,
+		`\data`:          false,
+		`/data`:          false,
+		`data`:           false,
+		`\\\data`:        false,
+	} {
+		if IsAbs(path) != expected {
+			t.Errorf("expected IsAbs(%q) to be %v", path, expected)
+		}
+	}
+}
+
+func TestExecutable(t *testing.T) {
+	for name, expected := range map[string]string{
+		"executor":          "executor.exe",
+		"executor.exe":      "executor.exe",
+		"run.bat":           "run.bat",
+		`C:\v1.2\executor`:  `C:\v1.2\executor.exe`,
+		`.\bin.d/executor`:  `.\bin.d/executor.exe`,
+		`C:\bin\tool.1.cmd`: `C:\bin\tool.1.cmd`,
+	} {
+		if actual := Executable(name); actual != expected {
+			t.Errorf("expected Executable(%q) to be %q instead of %q", name, expected, actual)
+		}
+	}
+}
+
+func TestValidateVolume(t *testing.T) {
+	for _, tc := range []struct {
+		host, container string
+		valid           bool
+	}{
+		{`C:\data`, `C:\data`, true},
+		{`C:\data`, `data\cache`, true},
+		{`\\server\share`, `data`, true},
+		{`/data`, `data`, false},
+		{`C:\data`, `/data`, false},
+		{`C:\data`, `C:data`, false},
+		{`C:\data`, `..\data`, false},
+		{`C:\data`, `data/../../x`, false},
+		{`C:\data`, ``, false},
+	} {
+		_, err := Volume(tc.host, tc.container, mesos.RO)
+		if (err == nil) != tc.valid {
+			t.Errorf("unexpected validation of %q:%q: %v", tc.host, tc.container, err)
+		}
+	}
+}
+
+func TestValidateTask(t *testing.T) {
+	valid := mesos.TaskInfo{
+		TaskID:  mesos.TaskID{Value: "t1"},
+		Command: Command(`.\executor.exe`, "-v"),
+		Container: &mesos.ContainerInfo{
+			Type: mesos.ContainerInfo_DOCKER.Enum(),
+		},
+	}
+	if err := ValidateTask(valid); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if args := valid.Command.Arguments; len(args) != 2 || args[0] != `.\executor.exe` {
+		t.Fatalf("unexpected arguments %q", args)
+	}
+	for name, mutate := range map[string]func(*mesos.TaskInfo){
+		"posix relative": func(t *mesos.TaskInfo) { t.Command = Command("./executor") },
+		"posix absolute": func(t *mesos.TaskInfo) { t.Command = ShellCommand(" /usr/bin/env true") },
+		"user":           func(t *mesos.TaskInfo) { t.Command.User = proto.String("nobody") },
+		"linux":          func(t *mesos.TaskInfo) { t.Container.LinuxInfo = &mesos.LinuxInfo{} },
+		"image": func(t *mesos.TaskInfo) {
+			t.Container = &mesos.ContainerInfo{
+				Type:  mesos.ContainerInfo_MESOS.Enum(),
+				Mesos: &mesos.ContainerInfo_MesosInfo{Image: &mesos.Image{}},
+			}
+		},
+		"volume": func(t *mesos.TaskInfo) {
+			t.Container.Volumes = []mesos.Volume{{ContainerPath: "/data", Mode: mesos.RW.Enum()}}
+		},
+		"executor": func(t *mesos.TaskInfo) {
+			t.Executor = &mesos.ExecutorInfo{Command: Command("/opt/executor")}
+		},
+	} {
+		task := valid
+		task.Command = proto.Clone(valid.Command).(*mesos.CommandInfo)
+		task.Container = proto.Clone(valid.Container).(*mesos.ContainerInfo)
+		mutate(&task)
+		if err := ValidateTask(task); err == nil {
+			t.Errorf("expected an error for the %s task", name)
+		}
+	}
+}
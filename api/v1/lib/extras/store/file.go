@@ -0,0 +1,50 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// NewFileSingleton returns a Singleton that persists its value in the file at the given path, so that the
+// value survives process restarts. Get returns ErrNotFound if the file doesn't exist. Set replaces the file
+// atomically (by writing to a temporary file in the same directory that's then renamed) so that a crash
+// never leaves a partially written value behind.
+func NewFileSingleton(path string) Singleton {
+	var m sync.Mutex
+	return &SingletonAdapter{
+		func() (string, error) {
+			m.Lock()
+			defer m.Unlock()
+			b, err := ioutil.ReadFile(path)
+			if os.IsNotExist(err) {
+				return "", ErrNotFound
+			}
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+		func(s string) error {
+			m.Lock()
+			defer m.Unlock()
+			f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+			if err != nil {
+				return err
+			}
+			defer os.Remove(f.Name()) // noop after a successful rename
+			_, err = f.WriteString(s)
+			if err == nil {
+				err = f.Sync()
+			}
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+			return os.Rename(f.Name(), path)
+		},
+	}
+}
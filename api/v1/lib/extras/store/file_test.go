@@ -0,0 +1,42 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSingleton(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "value")
+	s := NewFileSingleton(path)
+	if _, err := s.Get(); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound instead of %v", err)
+	}
+	for _, v := range []string{"foo", "bar", ""} {
+		if err := s.Set(v); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// a new singleton for the same path should observe the same value
+		got, err := NewFileSingleton(path).Get()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != v {
+			t.Fatalf("expected %q instead of %q", v, got)
+		}
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected temporary files to be cleaned up, found %d files", len(files))
+	}
+}
@@ -0,0 +1,3 @@
+package mesos
+
+//go:generate go run extras/gen/clone.go extras/gen/gen.go -type AgentInfo:AgentInfo -type Attribute:Attribute -type CheckInfo:CheckInfo -type CommandInfo:CommandInfo -type ContainerInfo:ContainerInfo -type DiscoveryInfo:DiscoveryInfo -type Environment:Environment -type ExecutorInfo:ExecutorInfo -type Filters:Filters -type FrameworkInfo:FrameworkInfo -type HealthCheck:HealthCheck -type InverseOffer:InverseOffer -type KillPolicy:KillPolicy -type Labels:Labels -type Offer:Offer -type Offer_Operation:Offer_Operation -type Resource:Resource -type Task:Task -type TaskGroupInfo:TaskGroupInfo -type TaskInfo:TaskInfo -type TaskStatus:TaskStatus -type URL:URL -type Value:Value -type Volume:Volume -output clone_generated.go
@@ -4,6 +4,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // Code is a Mesos HTTP v1 API response status code
@@ -59,10 +61,87 @@ var (
 	}
 )
 
+// Reason is the cause of an error, as recognized from the (text/plain) details of an error response; see
+// ReasonTable.
+type Reason int
+
+const (
+	ReasonUnknown                Reason = iota // the details weren't recognized
+	ReasonFrameworkNotSubscribed               // the framework's subscription isn't (or is no longer) established
+	ReasonFrameworkNotFound                    // the master doesn't know of the framework
+	ReasonFrameworkRemoved                     // the framework has been torn down, and may not resubscribe
+	ReasonStreamIDMismatch                     // the Mesos-Stream-Id of the call isn't that of the subscription
+	ReasonUnknownTask                          // the call concerns a task that's unknown to the master, or agent
+	ReasonUnknownOffer                         // the call concerns an offer that's unknown to the master (e.g. rescinded)
+	ReasonNotAuthorized                        // the principal of the call isn't authorized to perform it
+	ReasonInvalidCall                          // the call failed validation
+)
+
+var reasonNames = map[Reason]string{
+	ReasonUnknown:                "unknown",
+	ReasonFrameworkNotSubscribed: "framework not subscribed",
+	ReasonFrameworkNotFound:      "framework not found",
+	ReasonFrameworkRemoved:       "framework removed",
+	ReasonStreamIDMismatch:       "stream ID mismatch",
+	ReasonUnknownTask:            "unknown task",
+	ReasonUnknownOffer:           "unknown offer",
+	ReasonNotAuthorized:          "not authorized",
+	ReasonInvalidCall:            "invalid call",
+}
+
+func (r Reason) String() string {
+	if s, ok := reasonNames[r]; ok {
+		return s
+	}
+	return "Reason(" + strconv.Itoa(int(r)) + ")"
+}
+
+// ReasonTable maps the phrases of the error details reported by Mesos masters and agents to the reasons
+// that they indicate. Phrases are matched (case-insensitively) against details in the order of the table,
+// so more specific phrases precede more general ones. It's represented as a public variable so that clients
+// can program additional phrases (e.g. those of future Mesos releases) without hacking the code of the
+// mesos-go library directly.
+var ReasonTable = []struct {
+	Phrase string
+	Reason Reason
+}{
+	{"Framework is not subscribed", ReasonFrameworkNotSubscribed},
+	{"Framework cannot be found", ReasonFrameworkNotFound},
+	{"Framework has been removed", ReasonFrameworkRemoved},
+	{"Framework has been torn down", ReasonFrameworkRemoved},
+	{"didn't match the stream ID", ReasonStreamIDMismatch},
+	{"Mesos-Stream-Id", ReasonStreamIDMismatch},
+	{"Cannot kill unknown task", ReasonUnknownTask},
+	{"unknown task", ReasonUnknownTask},
+	{"Offer is no longer valid", ReasonUnknownOffer},
+	{"unknown offer", ReasonUnknownOffer},
+	{"Not authorized", ReasonNotAuthorized},
+	{"Failed to validate", ReasonInvalidCall},
+}
+
+// ParseReason returns the reason indicated by the given error details, or else ReasonUnknown.
+func ParseReason(details string) Reason {
+	details = strings.ToLower(details)
+	for _, x := range ReasonTable {
+		if strings.Contains(details, strings.ToLower(x.Phrase)) {
+			return x.Reason
+		}
+	}
+	return ReasonUnknown
+}
+
+// Matches returns true if the given error is an API error with a matching reason.
+func (r Reason) Matches(err error) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.reason == r
+}
+
 // Error captures HTTP v1 API error codes and messages generated by Mesos.
 type Error struct {
 	code    Code   // code is the HTTP response status code generated by Mesos
 	message string // message briefly summarizes the nature of the error, possibly includes details from Mesos
+	details string // details is the (possibly truncated) body of the response, as generated by Mesos
+	reason  Reason // reason is recognized from the details
 }
 
 // IsError returns true for all HTTP status codes that are not considered informational or successful.
@@ -104,6 +183,8 @@ func (code Code) Error(details string) error {
 	err := &Error{
 		code:    code,
 		message: ErrorTable[code],
+		details: details,
+		reason:  ParseReason(details),
 	}
 	if details != "" {
 		err.message = err.message + ": " + details
@@ -114,6 +195,16 @@ func (code Code) Error(details string) error {
 // Error implements error interface
 func (e *Error) Error() string { return e.message }
 
+// Code returns the HTTP response status code generated by Mesos.
+func (e *Error) Code() Code { return e.code }
+
+// Details returns the raw details of the error, i.e. the body of the response, as generated by Mesos;
+// bodies larger than MaxSizeDetails are truncated.
+func (e *Error) Details() string { return e.details }
+
+// Reason returns the cause of the error, as recognized from its details; see ReasonTable.
+func (e *Error) Reason() Reason { return e.reason }
+
 // Temporary returns true if the error is a temporary condition that should eventually clear.
 func (e *Error) Temporary() bool {
 	switch e.code {
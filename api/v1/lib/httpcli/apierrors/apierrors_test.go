@@ -20,7 +20,11 @@ func TestFromResponse(t *testing.T) {
 		},
 		{
 			&http.Response{StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString("missing framework id"))},
-			&Error{400, ErrorTable[CodeMalformedRequest] + ": missing framework id"},
+			&Error{400, ErrorTable[CodeMalformedRequest] + ": missing framework id", "missing framework id", ReasonUnknown},
+		},
+		{
+			&http.Response{StatusCode: 403, Body: ioutil.NopCloser(bytes.NewBufferString("Framework is not subscribed"))},
+			&Error{403, ErrorTable[CodeUnsubscribed] + ": Framework is not subscribed", "Framework is not subscribed", ReasonFrameworkNotSubscribed},
 		},
 	} {
 		rr := FromResponse(tt.r)
@@ -64,3 +68,35 @@ func TestError(t *testing.T) {
 		}
 	}
 }
+
+func TestReason(t *testing.T) {
+	for _, tt := range []struct {
+		code    Code
+		details string
+		reason  Reason
+	}{
+		{400, "", ReasonUnknown},
+		{400, "something else entirely", ReasonUnknown},
+		{403, "Framework is not subscribed", ReasonFrameworkNotSubscribed},
+		{403, "Framework has been removed", ReasonFrameworkRemoved},
+		{400, "Failed to validate scheduler::Call: Cannot kill unknown task 'web-0'", ReasonUnknownTask},
+		{400, "Failed to validate scheduler::Call: Expecting 'framework_id' to be present", ReasonInvalidCall},
+		{400, "The stream ID 'abc' included in this request didn't match the stream ID currently associated with framework ID 'f1'", ReasonStreamIDMismatch},
+		{403, "not AUTHORIZED to kill tasks", ReasonNotAuthorized},
+	} {
+		err := tt.code.Error(tt.details)
+		if !tt.reason.Matches(err) {
+			t.Errorf("expected reason %v to match that of the error %q", tt.reason, err)
+		}
+		apierr := err.(*Error)
+		if apierr.Reason() != tt.reason || apierr.Code() != tt.code || apierr.Details() != tt.details {
+			t.Errorf("unexpected error %#v", apierr)
+		}
+	}
+	if ReasonUnknownTask.Matches(nil) {
+		t.Errorf("expected a nil error not to match a reason")
+	}
+	if s := ReasonUnknownTask.String(); s != "unknown task" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
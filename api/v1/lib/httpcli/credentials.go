@@ -0,0 +1,108 @@
+package httpcli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Credentials are the username and password of HTTP Basic authentication.
+	Credentials struct {
+		Username string
+		Password string
+	}
+
+	// CredentialProvider is consulted for credentials prior to every request, so that implementations
+	// may pick up rotated secrets without the need to rebuild the client.
+	CredentialProvider interface {
+		Credentials() (Credentials, error)
+	}
+
+	// CredentialProviderFunc is the functional adaptation of CredentialProvider.
+	CredentialProviderFunc func() (Credentials, error)
+
+	fileCredentials struct {
+		username string
+		path     string
+
+		m        sync.Mutex
+		modTime  time.Time
+		size     int64
+		password string
+	}
+)
+
+var _ = CredentialProvider(CredentialProviderFunc(nil))
+
+// Credentials implements CredentialProvider for CredentialProviderFunc.
+func (f CredentialProviderFunc) Credentials() (Credentials, error) { return f() }
+
+// StaticCredentials returns a CredentialProvider that always returns the given username and password.
+func StaticCredentials(username, password string) CredentialProvider {
+	c := Credentials{Username: username, Password: password}
+	return CredentialProviderFunc(func() (Credentials, error) { return c, nil })
+}
+
+// FileCredentials returns a CredentialProvider for the given username, and the password that's stored
+// in the file at the given path (trailing newlines are trimmed). The file is read again whenever its
+// modification time or size changes, so that a secret that's rotated on disk (e.g. one that's mounted by
+// Vault or Kubernetes) is picked up by the next request. If the file cannot be read then the most
+// recently read password is returned along with the error.
+func FileCredentials(username, path string) CredentialProvider {
+	return &fileCredentials{username: username, path: path}
+}
+
+func (fc *fileCredentials) Credentials() (Credentials, error) {
+	fc.m.Lock()
+	defer fc.m.Unlock()
+	result := Credentials{Username: fc.username, Password: fc.password}
+	fi, err := os.Stat(fc.path)
+	if err != nil {
+		return result, err
+	}
+	if fi.ModTime().Equal(fc.modTime) && fi.Size() == fc.size {
+		return result, nil
+	}
+	b, err := ioutil.ReadFile(fc.path)
+	if err != nil {
+		return result, err
+	}
+	fc.modTime, fc.size = fi.ModTime(), fi.Size()
+	fc.password = strings.TrimRight(string(b), "\r\n")
+	result.Password = fc.password
+	return result, nil
+}
+
+// BasicAuthProvider generates a functional config option that sets HTTP Basic authentication for a
+// Client, consulting the given provider for credentials prior to every request. A request fails if the
+// provider returns an error.
+func BasicAuthProvider(p CredentialProvider) ConfigOpt {
+	return WrapRoundTripper(func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			creds, err := p.Credentials()
+			if err != nil {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+				return nil, err
+			}
+			// according to the stdlib we're not supposed to mutate the original Request, so we copy
+			// here (including headers).
+			var h http.Header
+			if req.Header != nil {
+				h = make(http.Header, len(req.Header))
+				for k, v := range req.Header {
+					h[k] = append(make([]string, 0, len(v)), v...)
+				}
+			}
+			clonedReq := *req
+			clonedReq.Header = h
+			clonedReq.SetBasicAuth(creds.Username, creds.Password)
+			return rt.RoundTrip(&clonedReq)
+		})
+	})
+}
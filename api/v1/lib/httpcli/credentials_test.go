@@ -0,0 +1,56 @@
+package httpcli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpcli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		u, p, _ := req.BasicAuth()
+		seen = append(seen, u+":"+p)
+	}))
+	defer srv.Close()
+
+	var (
+		p  = FileCredentials("user", path)
+		do = With(BasicAuthProvider(p))
+	)
+	if _, err := p.Credentials(); err == nil {
+		t.Fatal("expected error for missing password file")
+	}
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	if _, err := do(req); err == nil {
+		t.Fatal("expected request to fail w/o credentials")
+	}
+	for i, password := range []string{"first\n", "second-secret"} {
+		if err = ioutil.WriteFile(path, []byte(password), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// ensure that the modification time changes on filesystems with coarse timestamps
+		ts := time.Now().Add(time.Duration(i) * time.Second)
+		os.Chtimes(path, ts, ts)
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		res, err := do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if len(seen) != 2 || seen[0] != "user:first" || seen[1] != "user:second-secret" {
+		t.Fatalf("unexpected credentials %v", seen)
+	}
+}
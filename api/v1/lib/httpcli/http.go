@@ -9,11 +9,13 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mesos/mesos-go/api/v1/lib"
 	"github.com/mesos/mesos-go/api/v1/lib/client"
+	"github.com/mesos/mesos-go/api/v1/lib/correlation"
 	logger "github.com/mesos/mesos-go/api/v1/lib/debug"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
@@ -36,6 +38,10 @@ func (pe ProtocolError) Error() string { return string(pe) }
 const (
 	debug             = logger.Logger(false)
 	mediaTypeRecordIO = encoding.MediaType("application/recordio")
+
+	// headers that negotiate the compression of frames, see AcceptCompression
+	headerMessageAcceptEncoding  = "Message-Accept-Encoding"
+	headerMessageContentEncoding = "Message-Content-Encoding"
 )
 
 // DoFunc sends an HTTP request and returns an HTTP response.
@@ -63,6 +69,27 @@ type Response struct {
 	Header http.Header
 }
 
+// ResponseHeader returns the HTTP headers of the given response, if it's a *Response, or else if it wraps
+// one: via a *mesos.ResponseWrapper, or a decorator that implements Unwrap() mesos.Response. Returns nil
+// if the headers aren't available.
+func ResponseHeader(resp mesos.Response) http.Header {
+	for resp != nil {
+		switch r := resp.(type) {
+		case *Response:
+			return r.Header
+		case *mesos.ResponseWrapper:
+			resp = r.Response
+		case interface {
+			Unwrap() mesos.Response
+		}:
+			resp = r.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
 // ErrorMapperFunc generates an error for the given response.
 type ErrorMapperFunc func(*http.Response) error
 
@@ -72,20 +99,31 @@ type ResponseHandler func(*http.Response, client.ResponseClass, error) (mesos.Re
 
 // A Client is a Mesos HTTP APIs client.
 type Client struct {
-	url              string
-	do               DoFunc
-	header           http.Header
-	codec            encoding.Codec
-	errorMapper      ErrorMapperFunc
-	requestOpts      []RequestOpt
-	buildRequestFunc func(client.Request, client.ResponseClass, ...RequestOpt) (*http.Request, error)
-	handleResponse   ResponseHandler
+	url               string
+	do                DoFunc
+	header            http.Header
+	userAgent         string
+	headerFuncs       map[string]func() string
+	correlation       string // name of the correlation ID header
+	codec             encoding.Codec
+	fallbackCodecs    []encoding.Codec
+	compressions      []string // names of the frame compressions accepted for responses, in order of preference
+	compressRequests  string   // name of the frame compression of requests
+	errorMapper       ErrorMapperFunc
+	requestOpts       []RequestOpt
+	buildRequestFunc  func(client.Request, client.ResponseClass, ...RequestOpt) (*http.Request, error)
+	handleResponse    ResponseHandler
+	callTimeout       time.Duration // see CallTimeout
+	streamIdleTimeout time.Duration // see StreamIdleTimeout
 }
 
 var (
 	DefaultCodec   = codecs.ByMediaType[codecs.MediaTypeProtobuf]
 	DefaultHeaders = http.Header{}
 
+	// DefaultUserAgent identifies this library, and its version, in the User-Agent header of requests.
+	DefaultUserAgent = "mesos-go/" + mesos.Version
+
 	// DefaultConfigOpt represents the default client config options.
 	DefaultConfigOpt = []ConfigOpt{
 		Transport(func(t *http.Transport) {
@@ -106,6 +144,7 @@ func New(opts ...Opt) *Client {
 		codec:       DefaultCodec,
 		do:          With(DefaultConfigOpt...),
 		header:      cloneHeaders(DefaultHeaders),
+		userAgent:   DefaultUserAgent,
 		errorMapper: DefaultErrorMapper,
 	}
 	c.buildRequestFunc = c.buildRequest
@@ -171,18 +210,22 @@ func (c *Client) Mesos(opts ...RequestOpt) mesos.Client {
 	})
 }
 
-func prepareForResponse(rc client.ResponseClass, codec encoding.Codec) (RequestOpts, error) {
+func prepareForResponse(rc client.ResponseClass, codec encoding.Codec, fallbacks ...encoding.Codec) (RequestOpts, error) {
 	// We need to tell Mesos both the content-type and message-content-type that we're expecting, otherwise
 	// the server may give us validation problems, or else send back a vague content-type (w/o a
 	// message-content-type). In order to communicate these things we need to understand the desired response
 	// type from the perspective of the caller --> client.ResponseClass.
+	types := []encoding.MediaType{codec.Type}
+	for _, fc := range fallbacks {
+		types = append(types, fc.Type)
+	}
 	var accept RequestOpts
 	switch rc {
 	case client.ResponseClassSingleton, client.ResponseClassAuto, client.ResponseClassNoData:
-		accept = append(accept, Header("Accept", codec.Type.ContentType()))
+		accept = append(accept, Header("Accept", encoding.Accept(types...)))
 	case client.ResponseClassStreaming:
 		accept = append(accept, Header("Accept", mediaTypeRecordIO.ContentType()))
-		accept = append(accept, Header("Message-Accept", codec.Type.ContentType()))
+		accept = append(accept, Header("Message-Accept", encoding.Accept(types...)))
 	default:
 		return nil, ProtocolError(fmt.Sprintf("illegal response class requested: %v", rc))
 	}
@@ -195,7 +238,12 @@ func (c *Client) buildRequest(cr client.Request, rc client.ResponseClass, opt ..
 	if crs, ok := cr.(client.RequestStreaming); ok {
 		return c.buildRequestStream(crs.Marshaler, rc, opt...)
 	}
-	accept, err := prepareForResponse(rc, c.codec)
+	accept, err := prepareForResponse(rc, c.codec, c.fallbackCodecs...)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := c.compressSink(encoding.SinkWriter)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +251,7 @@ func (c *Client) buildRequest(cr client.Request, rc client.ResponseClass, opt ..
 	//TODO(jdef): use a pool to allocate these (and reduce garbage)?
 	// .. or else, use a pipe (like streaming does) to avoid the intermediate buffer?
 	var body bytes.Buffer
-	if err := c.codec.NewEncoder(encoding.SinkWriter(&body)).Encode(cr.Marshaler()); err != nil {
+	if err := c.codec.NewEncoder(sink(&body)).Encode(cr.Marshaler()); err != nil {
 		return nil, err
 	}
 
@@ -216,6 +264,9 @@ func (c *Client) buildRequest(cr client.Request, rc client.ResponseClass, opt ..
 	return helper.
 		withOptions(c.requestOpts, opt).
 		withHeaders(c.header).
+		withIdentity(c.userAgent, c.headerFuncs).
+		withCorrelation(c.correlation).
+		withCompression(c.compressions, c.compressRequests).
 		withHeader("Content-Type", c.codec.Type.ContentType()).
 		withHeader("Accept", c.codec.Type.ContentType()).
 		withOptions(accept).
@@ -223,14 +274,20 @@ func (c *Client) buildRequest(cr client.Request, rc client.ResponseClass, opt ..
 }
 
 func (c *Client) buildRequestStream(f func() encoding.Marshaler, rc client.ResponseClass, opt ...RequestOpt) (*http.Request, error) {
-	accept, err := prepareForResponse(rc, c.codec)
+	accept, err := prepareForResponse(rc, c.codec, c.fallbackCodecs...)
 	if err != nil {
 		return nil, err
 	}
 
+	sink, err := c.compressSink(func(w io.Writer) encoding.Sink {
+		return func() framing.Writer { return recordio.NewWriter(w) }
+	})
+	if err != nil {
+		return nil, err
+	}
 	var (
 		pr, pw = io.Pipe()
-		enc    = c.codec.NewEncoder(func() framing.Writer { return recordio.NewWriter(pw) })
+		enc    = c.codec.NewEncoder(sink(pw))
 	)
 	req, err := http.NewRequest("POST", c.url, pr)
 	if err != nil {
@@ -263,42 +320,65 @@ func (c *Client) buildRequestStream(f func() encoding.Marshaler, rc client.Respo
 	return helper.
 		withOptions(c.requestOpts, opt).
 		withHeaders(c.header).
+		withIdentity(c.userAgent, c.headerFuncs).
+		withCorrelation(c.correlation).
+		withCompression(c.compressions, c.compressRequests).
 		withHeader("Content-Type", mediaTypeRecordIO.ContentType()).
 		withHeader("Message-Content-Type", c.codec.Type.ContentType()).
 		withOptions(accept).
 		Request, nil
 }
 
-func validateSuccessfulResponse(codec encoding.Codec, res *http.Response, rc client.ResponseClass) error {
+// validateSuccessfulResponse returns the codec, either the primary codec or else one of the fallbacks,
+// that matches the content type of the response.
+func validateSuccessfulResponse(res *http.Response, rc client.ResponseClass, codec encoding.Codec, fallbacks ...encoding.Codec) (encoding.Codec, error) {
 	switch res.StatusCode {
 	case http.StatusOK:
 		ct := res.Header.Get("Content-Type")
 		switch rc {
 		case client.ResponseClassNoData:
 			if ct != "" {
-				return ProtocolError(fmt.Sprintf("unexpected content type: %q", ct))
+				return codec, ProtocolError(fmt.Sprintf("unexpected content type: %q", ct))
 			}
 		case client.ResponseClassSingleton, client.ResponseClassAuto:
-			if ct != codec.Type.ContentType() {
-				return ProtocolError(fmt.Sprintf("unexpected content type: %q", ct))
+			if rc == client.ResponseClassAuto && mediaTypeRecordIO.Matches(ct) {
+				// a RecordIO stream, e.g. of events, whose messages may be encoded by any of our codecs
+				ct = res.Header.Get("Message-Content-Type")
+			}
+			if c, ok := codecFor(ct, codec, fallbacks); ok {
+				return c, nil
 			}
+			return codec, ProtocolError(fmt.Sprintf("unexpected content type: %q", ct))
 		case client.ResponseClassStreaming:
-			if ct != mediaTypeRecordIO.ContentType() {
-				return ProtocolError(fmt.Sprintf("unexpected content type: %q", ct))
+			if !mediaTypeRecordIO.Matches(ct) {
+				return codec, ProtocolError(fmt.Sprintf("unexpected content type: %q", ct))
 			}
 			ct = res.Header.Get("Message-Content-Type")
-			if ct != codec.Type.ContentType() {
-				return ProtocolError(fmt.Sprintf("unexpected message content type: %q", ct))
+			if c, ok := codecFor(ct, codec, fallbacks); ok {
+				return c, nil
 			}
+			return codec, ProtocolError(fmt.Sprintf("unexpected message content type: %q", ct))
 		default:
-			return ProtocolError(fmt.Sprintf("unsupported response-class: %q", rc))
+			return codec, ProtocolError(fmt.Sprintf("unsupported response-class: %v", rc))
 		}
 
 	case http.StatusAccepted:
 		// nothing to validate, we're not expecting any response entity in this case.
 		// TODO(jdef) perhaps check Content-Length == 0 here?
 	}
-	return nil
+	return codec, nil
+}
+
+func codecFor(contentType string, codec encoding.Codec, fallbacks []encoding.Codec) (encoding.Codec, bool) {
+	if codec.Type.Matches(contentType) {
+		return codec, true
+	}
+	for _, fc := range fallbacks {
+		if fc.Type.Matches(contentType) {
+			return fc, true
+		}
+	}
+	return codec, false
 }
 
 func newSourceFactory(rc client.ResponseClass, knownLen bool) encoding.SourceFactoryFunc {
@@ -322,10 +402,48 @@ func newSourceFactory(rc client.ResponseClass, knownLen bool) encoding.SourceFac
 	}
 }
 
+// knownLength returns true if the response is a singleton of known length; RecordIO streams are never
+// singletons, even if their length is known (e.g. fully buffered by a proxy).
+func knownLength(res *http.Response) bool {
+	return res.ContentLength > -1 && !mediaTypeRecordIO.Matches(res.Header.Get("Content-Type"))
+}
+
 func recordIOSourceFactory(r io.Reader) encoding.Source {
 	return func() framing.Reader { return recordio.NewReader(r) }
 }
 
+// compressSink returns a factory of sinks, as generated by the given factory, that compress frames if the
+// client is configured to compress requests.
+func (c *Client) compressSink(f func(io.Writer) encoding.Sink) (func(io.Writer) encoding.Sink, error) {
+	if c.compressRequests == "" {
+		return f, nil
+	}
+	comp, ok := framing.LookupCompression(c.compressRequests)
+	if !ok {
+		return nil, ProtocolError(fmt.Sprintf("unsupported request compression: %q", c.compressRequests))
+	}
+	return func(w io.Writer) encoding.Sink {
+		sink := f(w)
+		return func() framing.Writer { return framing.CompressWriter(sink(), comp.NewCompressor()) }
+	}, nil
+}
+
+// decompressSource returns a Source that decompresses the frames of the given Source, as per the
+// Message-Content-Encoding header of the response (if any).
+func decompressSource(res *http.Response, src encoding.Source) (encoding.Source, error) {
+	name := res.Header.Get(headerMessageContentEncoding)
+	if name == "" || name == "identity" {
+		return src, nil
+	}
+	comp, ok := framing.LookupCompression(name)
+	if !ok {
+		return nil, ProtocolError(fmt.Sprintf("unsupported message content encoding: %q", name))
+	}
+	return func() framing.Reader {
+		return framing.DecompressReader(src(), comp.NewCompressor(), framing.DefaultMaxDecompressedSize)
+	}, nil
+}
+
 // HandleResponse parses an HTTP response from a Mesos service endpoint, transforming the
 // raw HTTP response into a mesos.Response.
 func (c *Client) HandleResponse(res *http.Response, rc client.ResponseClass, err error) (mesos.Response, error) {
@@ -344,7 +462,7 @@ func (c *Client) HandleResponse(res *http.Response, rc client.ResponseClass, err
 		return result, err
 	}
 
-	err = validateSuccessfulResponse(c.codec, res, rc)
+	codec, err := validateSuccessfulResponse(res, rc, c.codec, c.fallbackCodecs...)
 	if err != nil {
 		res.Body.Close()
 		return nil, err
@@ -354,7 +472,7 @@ func (c *Client) HandleResponse(res *http.Response, rc client.ResponseClass, err
 	case http.StatusOK:
 		debug.Log("request OK, decoding response")
 
-		sf := newSourceFactory(rc, res.ContentLength > -1)
+		sf := newSourceFactory(rc, knownLength(res))
 		if sf == nil {
 			if rc != client.ResponseClassNoData {
 				panic("nil Source for response that expected data")
@@ -366,7 +484,12 @@ func (c *Client) HandleResponse(res *http.Response, rc client.ResponseClass, err
 			return nil, err
 		}
 
-		result.Decoder = c.codec.NewDecoder(sf.NewSource(res.Body))
+		src, err := decompressSource(res, sf.NewSource(res.Body))
+		if err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+		result.Decoder = codec.NewDecoder(src)
 
 	case http.StatusAccepted:
 		debug.Log("request Accepted")
@@ -407,7 +530,12 @@ func (c *Client) Send(cr client.Request, rc client.ResponseClass, opt ...Request
 	)
 	hreq, err = c.buildRequestFunc(cr, rc, opt...)
 	if err == nil {
+		var x *exchange
+		hreq, x = c.withTimeouts(hreq)
 		hres, err = c.do(hreq)
+		if x != nil {
+			hres, err = x.received(hres, rc, err)
+		}
 		res, err = c.handleResponse(hres, rc, err)
 	}
 	return
@@ -458,6 +586,44 @@ func Codec(codec encoding.Codec) Opt {
 	}
 }
 
+// FallbackCodecs returns an Opt that configures the codecs, in order of preference, with which a Client
+// will decode responses that aren't encoded by the Client's primary Codec. Fallback codecs are advertised,
+// with successively lower quality values, in the Accept (or Message-Accept) header of each request.
+// Requests are always encoded with the primary Codec.
+func FallbackCodecs(codecs ...encoding.Codec) Opt {
+	return func(c *Client) Opt {
+		old := c.fallbackCodecs
+		c.fallbackCodecs = codecs
+		return FallbackCodecs(old...)
+	}
+}
+
+// AcceptCompression returns an Opt that configures the names of the frame compressions (see
+// framing.RegisterCompression), in order of preference, that a Client accepts for the frames of responses.
+// They're advertised in the Message-Accept-Encoding header of each request; a server that compresses the
+// frames of its response is expected to name the compression in the Message-Content-Encoding header of
+// the response. Mesos itself doesn't compress frames, but a proxy (e.g. one that relays event streams over
+// WAN links) may. Responses that name an unregistered compression yield a ProtocolError.
+func AcceptCompression(names ...string) Opt {
+	return func(c *Client) Opt {
+		old := c.compressions
+		c.compressions = names
+		return AcceptCompression(old...)
+	}
+}
+
+// CompressRequests returns an Opt that configures a Client to compress the frames of requests using the
+// named frame compression, which is named in the Message-Content-Encoding header of each request.
+// Requests should only be compressed if the server is known to support the compression. Disabled by
+// default; an empty name disables compression.
+func CompressRequests(name string) Opt {
+	return func(c *Client) Opt {
+		old := c.compressRequests
+		c.compressRequests = name
+		return CompressRequests(old)
+	}
+}
+
 // DefaultHeader returns an Opt that adds a header to an Client's headers.
 func DefaultHeader(k, v string) Opt {
 	return func(c *Client) Opt {
@@ -475,6 +641,59 @@ func DefaultHeader(k, v string) Opt {
 	}
 }
 
+// UserAgent returns an Opt that identifies the framework (or tool), e.g. "my-framework/1.2", in the
+// User-Agent header of every request; the library's DefaultUserAgent is appended. An empty product
+// restores the DefaultUserAgent. A User-Agent that's configured via DefaultHeader takes precedence.
+func UserAgent(product string) Opt {
+	return func(c *Client) Opt {
+		old := c.userAgent
+		c.userAgent = DefaultUserAgent
+		if product != "" {
+			c.userAgent = product + " " + DefaultUserAgent
+		}
+		return func(c *Client) Opt {
+			c.userAgent = old
+			return UserAgent(product)
+		}
+	}
+}
+
+// HeaderFunc returns an Opt that sets a header, to the value generated by f, on every request; e.g. to
+// set a unique X-Request-Id. A nil f removes a previously configured header func. Static headers may be
+// configured via DefaultHeader.
+func HeaderFunc(k string, f func() string) Opt {
+	return func(c *Client) Opt {
+		var (
+			old, found = c.headerFuncs[k]
+			funcs      = make(map[string]func() string, len(c.headerFuncs)+1)
+		)
+		for k, v := range c.headerFuncs {
+			funcs[k] = v
+		}
+		if f != nil {
+			funcs[k] = f
+		} else {
+			delete(funcs, k)
+		}
+		c.headerFuncs = funcs
+		if found {
+			return HeaderFunc(k, old)
+		}
+		return HeaderFunc(k, nil)
+	}
+}
+
+// CorrelationHeader returns an Opt that sets a header, of the given name, to the correlation ID that's
+// carried by the context of each request (see package correlation, and Context); requests without such an
+// ID are unaffected. An empty name disables the header.
+func CorrelationHeader(name string) Opt {
+	return func(c *Client) Opt {
+		old := c.correlation
+		c.correlation = name
+		return CorrelationHeader(old)
+	}
+}
+
 // HandleResponse returns a functional config option to set the HTTP response handler of the client.
 func HandleResponse(f ResponseHandler) Opt {
 	return func(c *Client) Opt {
@@ -554,7 +773,7 @@ func With(opt ...ConfigOpt) DoFunc {
 }
 
 // Timeout returns an ConfigOpt that sets a Config's response header timeout, tls handshake timeout,
-// and dialer timeout.
+// and dialer timeout. See ConnectTimeout, CallTimeout, and StreamIdleTimeout for finer-grained control.
 func Timeout(d time.Duration) ConfigOpt {
 	return func(c *Config) {
 		c.transport.ResponseHeaderTimeout = d
@@ -563,6 +782,16 @@ func Timeout(d time.Duration) ConfigOpt {
 	}
 }
 
+// ResponseHeaderTimeout returns a ConfigOpt that sets a Config's response header timeout; zero means no
+// timeout. Long-poll calls, like the agent's WAIT_CONTAINER, aren't answered until some (possibly distant)
+// event occurs and so should be issued via a client that's configured without a response header timeout;
+// the context of such calls may be used to impose a deadline instead.
+func ResponseHeaderTimeout(d time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.transport.ResponseHeaderTimeout = d
+	}
+}
+
 // RoundTripper returns a ConfigOpt that sets a Config's round-tripper.
 func RoundTripper(rt http.RoundTripper) ConfigOpt {
 	return func(c *Config) {
@@ -618,6 +847,38 @@ func (r *HTTPRequestHelper) withHeaders(hh http.Header) *HTTPRequestHelper {
 	return r
 }
 
+// withIdentity sets the User-Agent header, unless it's been set already (e.g. via DefaultHeader), and the
+// headers that are generated by headerFuncs.
+func (r *HTTPRequestHelper) withIdentity(userAgent string, headerFuncs map[string]func() string) *HTTPRequestHelper {
+	if userAgent != "" && r.Header.Get("User-Agent") == "" {
+		r.Header.Set("User-Agent", userAgent)
+	}
+	for k, f := range headerFuncs {
+		r.Header.Set(k, f())
+	}
+	return r
+}
+
+func (r *HTTPRequestHelper) withCorrelation(header string) *HTTPRequestHelper {
+	if id, ok := correlation.FromContext(r.Context()); ok {
+		debug.Log("request correlation ID: " + string(id))
+		if header != "" {
+			r.Header.Set(header, string(id))
+		}
+	}
+	return r
+}
+
+func (r *HTTPRequestHelper) withCompression(accept []string, compression string) *HTTPRequestHelper {
+	if len(accept) > 0 {
+		r.Header.Set(headerMessageAcceptEncoding, strings.Join(accept, ", "))
+	}
+	if compression != "" {
+		r.Header.Set(headerMessageContentEncoding, compression)
+	}
+	return r
+}
+
 func (r *HTTPRequestHelper) withHeader(key, value string) *HTTPRequestHelper {
 	r.Header.Set(key, value)
 	return r
@@ -1,11 +1,19 @@
 package httpcli
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"testing"
 
 	"github.com/mesos/mesos-go/api/v1/lib/client"
+	"github.com/mesos/mesos-go/api/v1/lib/correlation"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
 )
 
 func TestPrepareForResponse(t *testing.T) {
@@ -82,3 +90,183 @@ func TestNewSourceFactory(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateSuccessfulResponse(t *testing.T) {
+	var (
+		proto = encoding.Codec{Name: "protobuf", Type: encoding.MediaType("application/x-protobuf")}
+		json  = encoding.Codec{Name: "json", Type: encoding.MediaType("application/json")}
+	)
+	for ti, tc := range []struct {
+		rc         client.ResponseClass
+		header     []string
+		fallbacks  []encoding.Codec
+		wantsCodec string
+		wantsErr   bool
+	}{
+		{client.ResponseClassSingleton, []string{"Content-Type", "application/x-protobuf"}, nil, "protobuf", false},
+		{client.ResponseClassSingleton, []string{"Content-Type", "application/x-protobuf; charset=utf-8"}, nil, "protobuf", false},
+		{client.ResponseClassSingleton, []string{"Content-Type", "application/json"}, nil, "protobuf", true},
+		{client.ResponseClassAuto, []string{"Content-Type", "application/json;charset=UTF-8"}, []encoding.Codec{json}, "json", false},
+		{client.ResponseClassStreaming, []string{"Content-Type", "application/recordio", "Message-Content-Type", "application/json"}, []encoding.Codec{json}, "json", false},
+		{client.ResponseClassStreaming, []string{"Content-Type", "application/json", "Message-Content-Type", "application/json"}, []encoding.Codec{json}, "protobuf", true},
+		{client.ResponseClassNoData, nil, nil, "protobuf", false},
+	} {
+		res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+		for i := 0; i < len(tc.header); i += 2 {
+			res.Header.Set(tc.header[i], tc.header[i+1])
+		}
+		codec, err := validateSuccessfulResponse(res, tc.rc, proto, tc.fallbacks...)
+		if (err != nil) != tc.wantsErr {
+			t.Errorf("test case %d failed: unexpected error %v", ti, err)
+		}
+		if codec.Name != tc.wantsCodec {
+			t.Errorf("test case %d failed: expected codec %q instead of %q", ti, tc.wantsCodec, codec.Name)
+		}
+	}
+
+	opts, err := prepareForResponse(client.ResponseClassSingleton, proto, json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := http.Request{Header: http.Header{}}
+	opts.Apply(&req)
+	if v := req.Header.Get("Accept"); v != "application/x-protobuf, application/json;q=0.9" {
+		t.Fatalf("unexpected Accept header %q", v)
+	}
+}
+
+func TestIdentityHeaders(t *testing.T) {
+	var (
+		n   int
+		cli = New(
+			Endpoint("http://localhost:5050/api/v1/scheduler"),
+			UserAgent("my-framework/1.0"),
+			DefaultHeader("X-Team", "infra"),
+			HeaderFunc("X-Request-Id", func() string { n++; return "req-" + strconv.Itoa(n) }),
+		)
+		call = &scheduler.Call{Type: scheduler.Call_REVIVE}
+	)
+	for i, want := range []string{"req-1", "req-2"} {
+		req, err := cli.buildRequest(client.RequestSingleton(call), client.ResponseClassNoData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ua := req.Header.Get("User-Agent"); ua != "my-framework/1.0 "+DefaultUserAgent {
+			t.Errorf("test case %d failed: unexpected user agent %q", i, ua)
+		}
+		if v := req.Header.Get("X-Team"); v != "infra" {
+			t.Errorf("test case %d failed: unexpected X-Team %q", i, v)
+		}
+		if v := req.Header.Get("X-Request-Id"); v != want {
+			t.Errorf("test case %d failed: expected X-Request-Id %q instead of %q", i, want, v)
+		}
+	}
+
+	// undo
+	cli.With(UserAgent(""), HeaderFunc("X-Request-Id", nil))
+	req, err := cli.buildRequest(client.RequestSingleton(call), client.ResponseClassNoData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ua := req.Header.Get("User-Agent"); ua != DefaultUserAgent {
+		t.Errorf("unexpected user agent %q", ua)
+	}
+	if _, ok := req.Header["X-Request-Id"]; ok {
+		t.Errorf("unexpected X-Request-Id header")
+	}
+
+	// a User-Agent that's set via DefaultHeader isn't overwritten, by requests or by streams
+	cli.With(UserAgent("my-framework/1.0"), DefaultHeader("User-Agent", "legacy/0.1"))
+	if req, err = cli.buildRequest(client.RequestSingleton(call), client.ResponseClassNoData); err != nil {
+		t.Fatal(err)
+	}
+	if ua := req.Header.Get("User-Agent"); ua != "legacy/0.1" {
+		t.Errorf("unexpected user agent %q", ua)
+	}
+	if req, err = cli.buildRequestStream(func() encoding.Marshaler { return nil }, client.ResponseClassNoData); err != nil {
+		t.Fatal(err)
+	}
+	if ua := req.Header.Get("User-Agent"); ua != "legacy/0.1" {
+		t.Errorf("unexpected user agent %q of a stream", ua)
+	}
+}
+
+func TestCorrelationHeader(t *testing.T) {
+	var (
+		cli  = New(Endpoint("http://localhost:5050/api/v1/scheduler"), CorrelationHeader(correlation.DefaultHeader))
+		call = &scheduler.Call{Type: scheduler.Call_REVIVE}
+		ctx  = correlation.WithID(context.Background(), "abc")
+	)
+	req, err := cli.buildRequest(client.RequestSingleton(call), client.ResponseClassNoData, Context(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := req.Header.Get(correlation.DefaultHeader); v != "abc" {
+		t.Fatalf("expected correlation header %q instead of %q", "abc", v)
+	}
+	req, err = cli.buildRequest(client.RequestSingleton(call), client.ResponseClassNoData, Context(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := req.Header[correlation.DefaultHeader]; ok {
+		t.Fatal("unexpected correlation header")
+	}
+}
+
+func TestCompression(t *testing.T) {
+	var (
+		cli  = New(Endpoint("http://localhost:5050/api/v1/scheduler"), AcceptCompression("gzip"), CompressRequests("gzip"))
+		call = &scheduler.Call{Type: scheduler.Call_REVIVE}
+	)
+	req, err := cli.buildRequest(client.RequestSingleton(call), client.ResponseClassSingleton)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := req.Header.Get(headerMessageAcceptEncoding); v != "gzip" {
+		t.Fatalf("unexpected %s %q", headerMessageAcceptEncoding, v)
+	}
+	if v := req.Header.Get(headerMessageContentEncoding); v != "gzip" {
+		t.Fatalf("unexpected %s %q", headerMessageContentEncoding, v)
+	}
+	compressed, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := framing.Gzip.NewCompressor().Decompress(nil, compressed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got scheduler.Call
+	if err = got.Unmarshal(decompressed); err != nil || got.GetType() != scheduler.Call_REVIVE {
+		t.Fatalf("unexpected request body (%v, %v)", got, err)
+	}
+
+	// the compressed body is echoed as the response
+	res := &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: int64(len(compressed)),
+		Header: http.Header{
+			"Content-Type":               []string{codecs.MediaTypeProtobuf.ContentType()},
+			headerMessageContentEncoding: []string{"gzip"},
+		},
+		Body: ioutil.NopCloser(bytes.NewReader(compressed)),
+	}
+	resp, err := cli.HandleResponse(res, client.ResponseClassSingleton, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = scheduler.Call{}
+	if err = resp.Decode(&got); err != nil || got.GetType() != scheduler.Call_REVIVE {
+		t.Fatalf("unexpected response (%v, %v)", got, err)
+	}
+
+	res.Header.Set(headerMessageContentEncoding, "bogus")
+	res.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	if _, err = cli.HandleResponse(res, client.ResponseClassSingleton, nil); err == nil {
+		t.Fatal("expected an error for an unsupported compression")
+	}
+	cli.With(CompressRequests("bogus"))
+	if _, err = cli.buildRequest(client.RequestSingleton(call), client.ResponseClassSingleton); err == nil {
+		t.Fatal("expected an error for an unsupported request compression")
+	}
+}
@@ -0,0 +1,107 @@
+package httpagent
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// DefaultPath is the path of the operator API endpoint of an agent.
+const DefaultPath = "/api/v1"
+
+// ErrNoAgentAddress is returned when an agent's endpoint can't be derived because neither a hostname nor
+// an IP address of the agent is known.
+var ErrNoAgentAddress = errors.New("unknown agent address")
+
+type (
+	endpoint struct {
+		scheme   string
+		path     string
+		preferIP bool
+	}
+
+	// EndpointOpt is a functional option for the derivation of an agent's endpoint; it returns an "undo"
+	// option when applied.
+	EndpointOpt func(*endpoint) EndpointOpt
+)
+
+// PreferIP derives endpoints from the IP address of an agent, if it's known, rather than its hostname;
+// e.g. for agents whose hostnames aren't resolvable by the framework, as is often the case for agents
+// that run in containers with bridged networking. Only an Offer.URL reports the IP address of an agent.
+func PreferIP(b bool) EndpointOpt {
+	return func(e *endpoint) EndpointOpt {
+		old := e.preferIP
+		e.preferIP = b
+		return PreferIP(old)
+	}
+}
+
+// Scheme overrides the scheme of derived endpoints; e.g. "https" for agents that are only reachable via
+// a TLS-terminating proxy. Otherwise the scheme of the Offer.URL, if any, is used, or else "http".
+func Scheme(s string) EndpointOpt {
+	return func(e *endpoint) EndpointOpt {
+		old := e.scheme
+		e.scheme = s
+		return Scheme(old)
+	}
+}
+
+// Path overrides the path of derived endpoints; defaults to DefaultPath.
+func Path(p string) EndpointOpt {
+	return func(e *endpoint) EndpointOpt {
+		old := e.path
+		e.path = p
+		return Path(old)
+	}
+}
+
+// OfferEndpoint returns the endpoint of the operator API of the agent that made the offer. The endpoint is
+// derived from the URL of the offer, if it's specified (as it is by Mesos 1.0 and later); otherwise from
+// the hostname of the offer and the default agent port. The path of the offer's URL isn't used: it's the
+// path of the agent's libprocess actor (e.g. "/slave(1)"), not that of the operator API.
+func OfferEndpoint(o *mesos.Offer, opts ...EndpointOpt) (string, error) {
+	if u := o.GetURL(); u != nil {
+		addr := u.GetAddress()
+		return endpointOf(u.GetScheme(), addr.GetHostname(), addr.GetIP(), addr.GetPort(), opts)
+	}
+	return endpointOf("", o.GetHostname(), "", mesos.Default_AgentInfo_Port, opts)
+}
+
+// AgentEndpoint returns the endpoint of the operator API of the described agent.
+func AgentEndpoint(a *mesos.AgentInfo, opts ...EndpointOpt) (string, error) {
+	return endpointOf("", a.GetHostname(), "", a.GetPort(), opts)
+}
+
+func endpointOf(scheme, hostname, ip string, port int32, opts []EndpointOpt) (string, error) {
+	e := endpoint{path: DefaultPath}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&e)
+		}
+	}
+	host := hostname
+	if host == "" || (e.preferIP && ip != "") {
+		host = ip
+	}
+	if host == "" {
+		return "", ErrNoAgentAddress
+	}
+	switch {
+	case e.scheme != "":
+		scheme = e.scheme
+	case scheme == "":
+		scheme = "http"
+	}
+	if port <= 0 {
+		port = mesos.Default_AgentInfo_Port
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(port))),
+		Path:   e.path,
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,63 @@
+package httpagent
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+func TestOfferEndpoint(t *testing.T) {
+	var (
+		hostname = "agent.internal"
+		ip       = "10.0.0.1"
+		ip6      = "fd00::1"
+		path     = "/slave(1)"
+		withURL  = func(hostname, ip *string, port int32) *mesos.Offer {
+			return &mesos.Offer{
+				Hostname: "offer.host",
+				URL: &mesos.URL{
+					Scheme:  "https",
+					Address: mesos.Address{Hostname: hostname, IP: ip, Port: port},
+					Path:    &path,
+				},
+			}
+		}
+	)
+	for i, tc := range []struct {
+		offer *mesos.Offer
+		opts  []EndpointOpt
+		want  string
+	}{
+		{withURL(&hostname, &ip, 5052), nil, "https://agent.internal:5052/api/v1"},
+		{withURL(&hostname, &ip, 5052), []EndpointOpt{PreferIP(true)}, "https://10.0.0.1:5052/api/v1"},
+		{withURL(nil, &ip, 5052), nil, "https://10.0.0.1:5052/api/v1"},
+		{withURL(nil, &ip6, 5051), nil, "https://[fd00::1]:5051/api/v1"},
+		{withURL(&hostname, nil, 5052), []EndpointOpt{PreferIP(true), Scheme("http"), Path("/")}, "http://agent.internal:5052/"},
+		{&mesos.Offer{Hostname: "offer.host"}, nil, "http://offer.host:5051/api/v1"},
+	} {
+		got, err := OfferEndpoint(tc.offer, tc.opts...)
+		if err != nil {
+			t.Errorf("test case %d: unexpected error: %v", i, err)
+		} else if got != tc.want {
+			t.Errorf("test case %d: expected %q instead of %q", i, tc.want, got)
+		}
+	}
+	if _, err := OfferEndpoint(withURL(nil, nil, 5051)); err != ErrNoAgentAddress {
+		t.Fatalf("expected ErrNoAgentAddress instead of %v", err)
+	}
+}
+
+func TestAgentEndpoint(t *testing.T) {
+	port := int32(5052)
+	for i, tc := range []struct {
+		info *mesos.AgentInfo
+		want string
+	}{
+		{&mesos.AgentInfo{Hostname: "a"}, "http://a:5051/api/v1"},
+		{&mesos.AgentInfo{Hostname: "a", Port: &port}, "http://a:5052/api/v1"},
+	} {
+		if got, err := AgentEndpoint(tc.info); err != nil || got != tc.want {
+			t.Errorf("test case %d: expected %q instead of (%q, %v)", i, tc.want, got, err)
+		}
+	}
+}
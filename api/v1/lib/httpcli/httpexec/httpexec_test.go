@@ -0,0 +1,124 @@
+package httpexec
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	"github.com/mesos/mesos-go/api/v1/lib/executor"
+	"github.com/mesos/mesos-go/api/v1/lib/executor/calls"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
+	"github.com/mesos/mesos-go/api/v1/lib/recordio"
+)
+
+// jsonAgent emulates the executor API of an agent that speaks JSON; the events of a subscription are
+// framed as RecordIO, with the given response headers.
+func jsonAgent(t *testing.T, header http.Header, knownLength bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		var call executor.Call
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if call.GetExecutorID().Value != "e" || call.GetFrameworkID().Value != "f" {
+			t.Errorf("unexpected call %v", call)
+		}
+		if call.GetType() != executor.Call_SUBSCRIBE {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		var (
+			body    []byte
+			framing = recordio.NewWriter(writerFunc(func(p []byte) (int, error) {
+				body = append(body, p...)
+				return len(p), nil
+			}))
+		)
+		for _, e := range []executor.Event{
+			{Type: executor.Event_SUBSCRIBED, Subscribed: &executor.Event_Subscribed{
+				ExecutorInfo:  mesos.ExecutorInfo{ExecutorID: mesos.ExecutorID{Value: "e"}},
+				FrameworkInfo: mesos.FrameworkInfo{Name: "framework"},
+				AgentInfo:     mesos.AgentInfo{Hostname: "agent"},
+			}},
+			{Type: executor.Event_MESSAGE, Message: &executor.Event_Message{Data: []byte("hello")}},
+		} {
+			b, err := json.Marshal(&e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			framing.WriteFrame(b)
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		if knownLength {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+		if !knownLength {
+			w.(http.Flusher).Flush() // chunked, like a never-ending event stream
+		}
+	}))
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestJSON(t *testing.T) {
+	for i, tc := range []struct {
+		header      http.Header
+		knownLength bool
+	}{
+		// agents respond with the media type of the events
+		{http.Header{"Content-Type": {"application/json"}}, false},
+		// as streamed by agents that honor a RecordIO Accept header, or (re)framed by a proxy
+		{http.Header{"Content-Type": {"application/recordio"}, "Message-Content-Type": {"application/json"}}, false},
+		{http.Header{"Content-Type": {"application/recordio"}, "Message-Content-Type": {"application/json"}}, true},
+	} {
+		agent := jsonAgent(t, tc.header, tc.knownLength)
+		var (
+			cli = httpcli.New(
+				httpcli.Endpoint(agent.URL),
+				httpcli.Codec(codecs.ByMediaType[codecs.MediaTypeJSON]),
+			)
+			sender = calls.SenderWith(NewSender(cli.Send), calls.Framework("f"), calls.Executor("e"))
+			ctx    = context.Background()
+		)
+		resp, err := sender.Send(ctx, calls.NonStreaming(calls.Subscribe(nil, nil)))
+		if err != nil {
+			t.Fatalf("test case %d: %v", i, err)
+		}
+		var e executor.Event
+		if err = resp.Decode(&e); err != nil || e.GetType() != executor.Event_SUBSCRIBED || e.GetSubscribed().AgentInfo.Hostname != "agent" {
+			t.Fatalf("test case %d: unexpected event %v, %v", i, e, err)
+		}
+		if err = resp.Decode(&e); err != nil || string(e.GetMessage().GetData()) != "hello" {
+			t.Fatalf("test case %d: unexpected event %v, %v", i, e, err)
+		}
+		if err = resp.Decode(&e); err != io.EOF {
+			t.Fatalf("test case %d: expected EOF instead of %v", i, err)
+		}
+		resp.Close()
+
+		resp, err = sender.Send(ctx, calls.NonStreaming(calls.Message([]byte("hi"))))
+		if err != nil {
+			t.Fatalf("test case %d: %v", i, err)
+		}
+		if resp != nil {
+			resp.Close()
+		}
+		agent.Close()
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+	"github.com/mesos/mesos-go/api/v1/lib/retry"
 	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
 	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
 )
@@ -40,6 +41,8 @@ type (
 		allowReconnect    bool // feature flag
 		listener          func(Notification)
 		candidateSelector CandidateSelector
+		budget            *retry.Budget // bounds the retries of subscriptions, nil if unbounded
+		observer          *Observer     // tracks the connection state, nil if unobserved
 	}
 
 	// Caller is the public interface a framework scheduler's should consume
@@ -56,6 +59,8 @@ type (
 		// invoking f(). Changes made to the Client by the temporary option are reverted before this
 		// func returns.
 		WithTemporary(opt httpcli.Opt, f func() error) error
+		// retryBudget returns the budget that bounds the retries of subscriptions, or nil.
+		retryBudget() *retry.Budget
 	}
 
 	// Option is a functional configuration option type
@@ -131,6 +136,20 @@ func AllowReconnection(v bool) Option {
 	}
 }
 
+// RetryBudget is a functional option that bounds the retries (following redirects) of subscriptions by
+// the given budget, in addition to MaxRedirects; the budget may be shared with other subsystems so that
+// retries are bounded globally. Subscription attempts that are rejected by the budget fail with the
+// error of the budget; e.g. retry.ErrExhausted.
+func RetryBudget(b *retry.Budget) Option {
+	return func(c *client) Option {
+		old := c.budget
+		c.budget = b
+		return RetryBudget(old)
+	}
+}
+
+func (cli *client) retryBudget() *retry.Budget { return cli.budget }
+
 func EndpointCandidates(cs CandidateSelector) Option {
 	return func(c *client) Option {
 		old := c.candidateSelector
@@ -230,6 +249,9 @@ type streamIDResponse struct {
 
 func (sr *streamIDResponse) streamID() string { return sr.mesosStreamID }
 
+// Unwrap returns the decorated response; see httpcli.ResponseHeader.
+func (sr *streamIDResponse) Unwrap() mesos.Response { return sr.Response }
+
 func tryExtractStreamID(hres *http.Response, resp mesos.Response) mesos.Response {
 	if hres.StatusCode != 200 {
 		return resp
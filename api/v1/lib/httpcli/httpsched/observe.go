@@ -0,0 +1,177 @@
+package httpsched
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// ConnectionState is the state of the connection of a Caller with the master:
+// IDLE -> CONNECTING -> SUBSCRIBED -> DISCONNECTED -> CONNECTING -> ...
+type ConnectionState uint8
+
+const (
+	StateIdle         ConnectionState = iota // no subscription has been attempted
+	StateConnecting                          // a SUBSCRIBE call is in progress
+	StateSubscribed                          // the subscription stream is established
+	StateDisconnected                        // the subscription attempt failed, or else the stream ended
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateIdle:
+		return "IDLE"
+	case StateConnecting:
+		return "CONNECTING"
+	case StateSubscribed:
+		return "SUBSCRIBED"
+	case StateDisconnected:
+		return "DISCONNECTED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// The reasons of transitions; see Transition.
+const (
+	ReasonSubscribe          = "subscribe"           // -> CONNECTING
+	ReasonSubscribed         = "subscribed"          // -> SUBSCRIBED
+	ReasonSubscribeFailed    = "subscribe failed"    // CONNECTING -> DISCONNECTED
+	ReasonStreamClosed       = "stream closed"       // the master closed the stream (io.EOF)
+	ReasonStreamError        = "stream error"        // e.g. a reset connection, or an undecodable event
+	ReasonErrorEvent         = "error event"         // the master sent an ERROR event
+	ReasonSubscriptionClosed = "subscription closed" // the framework closed the subscription response
+	ReasonSubscriptionLost   = "subscription lost"   // a call was rejected because the framework isn't subscribed
+	ReasonLeaderChanged      = "leader changed"      // a call was redirected to another master
+)
+
+type (
+	// Transition is a change of the ConnectionState of a Caller.
+	Transition struct {
+		From, To ConnectionState
+		At       time.Time
+		Reason   string // one of the Reason constants
+		Err      error  // the error that caused a transition to DISCONNECTED, if any
+		StreamID string // the Mesos-Stream-Id of the subscription, if SUBSCRIBED
+	}
+
+	// Observer tracks the ConnectionState of a Caller (see Observe), e.g. for the sake of metrics or of
+	// health endpoints. It's safe for concurrent use.
+	Observer struct {
+		clock mesostime.Clock
+		funcs []func(Transition)
+
+		m    sync.RWMutex
+		last Transition
+	}
+)
+
+// NewObserver returns an Observer, in the IDLE state, that invokes the given funcs upon every transition.
+// The funcs are invoked synchronously, in the order of the transitions, while the Caller is locked: they
+// must not block, nor invoke the Caller.
+func NewObserver(funcs ...func(Transition)) *Observer {
+	return NewObserverWith(mesostime.SystemClock, funcs...)
+}
+
+// NewObserverWith is like NewObserver, except that transitions are timed by the given clock; e.g. a
+// mesostime.FakeClock, in tests.
+func NewObserverWith(clock mesostime.Clock, funcs ...func(Transition)) *Observer {
+	return &Observer{clock: clock, funcs: funcs, last: Transition{At: clock.Now()}}
+}
+
+// Observe returns an Option that reports the transitions of the ConnectionState of a Caller to the given
+// Observer; a nil Observer disables the reports.
+func Observe(o *Observer) Option {
+	return func(c *client) Option {
+		old := c.observer
+		c.observer = o
+		return Observe(old)
+	}
+}
+
+// State returns the most recent transition of the Observer; the To field of which is the current state.
+func (o *Observer) State() Transition {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return o.last
+}
+
+// transition records the transition to the given state, unless that's the current state.
+func (o *Observer) transition(to ConnectionState, reason string, err error, streamID string) {
+	if o == nil {
+		return
+	}
+	o.m.Lock()
+	if o.last.To == to {
+		o.m.Unlock()
+		return
+	}
+	t := Transition{From: o.last.To, To: to, At: o.clock.Now(), Reason: reason, Err: err, StreamID: streamID}
+	o.last = t
+	o.m.Unlock()
+
+	for _, f := range o.funcs {
+		f(t)
+	}
+}
+
+// disconnection records the cause of the end of a subscription; the first cause that's reported wins.
+type disconnection struct {
+	m      sync.Mutex
+	reason string
+	err    error
+}
+
+func (d *disconnection) set(reason string, err error) {
+	if d == nil {
+		return
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.reason == "" {
+		d.reason, d.err = reason, err
+	}
+}
+
+func (d *disconnection) get() (string, error) {
+	if d == nil {
+		return ReasonSubscriptionClosed, nil
+	}
+	d.m.Lock()
+	defer d.m.Unlock()
+	if d.reason == "" {
+		return ReasonSubscriptionClosed, nil
+	}
+	return d.reason, d.err
+}
+
+// recordDisconnection decorates the subscription response, recording the cause of the disconnection
+// (if any) upon each event that's decoded; see DisconnectionDetector.
+func recordDisconnection(resp mesos.Response, d *disconnection) mesos.Response {
+	return &mesos.ResponseWrapper{
+		Response: resp,
+		Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			err := resp.Decode(u)
+			if _, ok := encoding.IsUnknownEvent(err); ok {
+				return err
+			}
+			switch {
+			case err == io.EOF:
+				d.set(ReasonStreamClosed, err)
+			case err != nil:
+				d.set(ReasonStreamError, err)
+			default:
+				if e, ok := u.(*scheduler.Event); ok && e.GetType() == scheduler.Event_ERROR {
+					d.set(ReasonErrorEvent, errors.New(e.GetError().GetMessage()))
+				}
+			}
+			return err
+		}),
+	}
+}
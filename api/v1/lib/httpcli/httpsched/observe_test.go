@@ -0,0 +1,83 @@
+package httpsched
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestObserver(t *testing.T) {
+	var (
+		transitions []Transition
+		clock       = mesostime.NewFakeClock(time.Unix(1000, 0))
+		observer    = NewObserverWith(clock, func(tr Transition) { transitions = append(transitions, tr) })
+		failure     = errors.New("connection refused")
+		state       = &state{
+			client:      &client{},
+			fn:          disconnectedPhase(mustSubscribe),
+			notifyQueue: make(chan Notification, 10),
+		}
+		subscribe = func(streamID string, err error) {
+			state.call = &stateCall{Call: &scheduler.Call{Type: scheduler.Call_SUBSCRIBE}}
+			state.setPhase(mustSubscribe0(context.Background(), state,
+				func(_ context.Context, _ callerInternal, cl *stateCall) (string, context.CancelFunc) {
+					cl.err = err
+					if err == nil {
+						cl.resp = &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+							u.(*scheduler.Event).Type = scheduler.Event_ERROR
+							u.(*scheduler.Event).Error = &scheduler.Event_Error{Message: "Framework has been removed"}
+							return nil
+						})}
+					}
+					return streamID, func() {}
+				}))
+		}
+	)
+	Observe(observer)(state.client)
+	if s := observer.State(); s.To != StateIdle || !s.At.Equal(clock.Now()) {
+		t.Fatalf("expected the observer to be IDLE initially: %+v", s)
+	}
+
+	subscribe("", failure)
+	clock.Advance(time.Second)
+	subscribe("1", nil)
+	if s := observer.State(); s.To != StateSubscribed || s.StreamID != "1" {
+		t.Fatalf("expected the observer to be SUBSCRIBED: %+v", s)
+	}
+	if err := state.call.resp.Decode(&scheduler.Event{}); err != nil {
+		t.Fatal(err)
+	}
+
+	start, later := time.Unix(1000, 0), time.Unix(1001, 0)
+	for i, want := range []Transition{
+		{From: StateIdle, To: StateConnecting, At: start, Reason: ReasonSubscribe},
+		{From: StateConnecting, To: StateDisconnected, At: start, Reason: ReasonSubscribeFailed, Err: failure},
+		{From: StateDisconnected, To: StateConnecting, At: later, Reason: ReasonSubscribe},
+		{From: StateConnecting, To: StateSubscribed, At: later, Reason: ReasonSubscribed, StreamID: "1"},
+		{From: StateSubscribed, To: StateDisconnected, At: later, Reason: ReasonErrorEvent},
+	} {
+		if i >= len(transitions) {
+			t.Fatalf("expected transition %d: %+v", i, want)
+		}
+		got := transitions[i]
+		if got.From != want.From || got.To != want.To || got.Reason != want.Reason || got.StreamID != want.StreamID ||
+			(want.Err != nil && got.Err != want.Err) || !got.At.Equal(want.At) {
+			t.Errorf("unexpected transition %d: %+v", i, got)
+		}
+	}
+	if n := len(transitions); n != 5 {
+		t.Fatalf("expected 5 transitions instead of %d", n)
+	}
+	if err := transitions[4].Err; err == nil || err.Error() != "Framework has been removed" {
+		t.Fatalf("expected the message of the ERROR event: %v", err)
+	}
+	if s := StateDisconnected.String(); s != "DISCONNECTED" {
+		t.Fatalf("unexpected name %q", s)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/mesos/mesos-go/api/v1/lib"
 	"github.com/mesos/mesos-go/api/v1/lib/backoff"
+	"github.com/mesos/mesos-go/api/v1/lib/correlation"
 	"github.com/mesos/mesos-go/api/v1/lib/encoding"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli"
 	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
@@ -40,13 +41,14 @@ type (
 		notifyBusy  int32
 		notifyQueue chan Notification
 
-		m            sync.Mutex   // m guards the following state:
-		fn           phase        // fn is the next state function to execute
-		caller       calls.Caller // caller is (maybe) used by a state function to execute a call
-		call         *stateCall   // upon executation of fn, this is the most recent call that's been issued
-		callCounter  uint64       // index of the most recent call we've issued
-		disconnector func()       // disconnector cancels a subscription
-		streamID     string       // most recent subscription stream ID returned by mesos
+		m             sync.Mutex     // m guards the following state:
+		fn            phase          // fn is the next state function to execute
+		caller        calls.Caller   // caller is (maybe) used by a state function to execute a call
+		call          *stateCall     // upon executation of fn, this is the most recent call that's been issued
+		callCounter   uint64         // index of the most recent call we've issued
+		disconnector  func()         // disconnector cancels a subscription
+		disconnection *disconnection // disconnection records the cause of the end of the subscription
+		streamID      string         // most recent subscription stream ID returned by mesos
 	}
 
 	stateCall struct {
@@ -77,7 +79,8 @@ func (f connectedPhase) exec(ctx context.Context, s *state) phase    { return f(
 // are expected to invoke the `disconnect` callback in order to initiate the disconnection.
 //
 // The default implementation will transition to a disconnected state when:
-//   - an error occurs while decoding an object from the subscription stream
+//   - an error occurs while decoding an object from the subscription stream (other than an
+//     *encoding.UnknownEvent, see encoding.Typed)
 //   - mesos reports an ERROR-type scheduler.Event object via the subscription stream
 //   - an object on the stream does not decode to a *scheduler.Event (sanity check)
 //
@@ -98,6 +101,11 @@ var DisconnectionDetector = func(disconnect func()) mesos.ResponseDecorator {
 func disconnectionDecoder(decoder encoding.Decoder, disconnect func()) encoding.Decoder {
 	return encoding.DecoderFunc(func(u encoding.Unmarshaler) (err error) {
 		err = decoder.Decode(u)
+		if _, ok := encoding.IsUnknownEvent(err); ok {
+			// events of types that are unknown to this library (e.g. of a newer version of Mesos) are
+			// skipped by consumers of the stream, which remains connected.
+			return
+		}
 		if err != nil {
 			disconnect()
 			return
@@ -153,6 +161,11 @@ func doSubscribe(ctx context.Context, ci callerInternal, call *stateCall) (mesos
 			call.resp = nil
 		}
 	)
+	var (
+		budget = ci.retryBudget()
+		start  = time.Now()
+	)
+	budget.Deposit()
 	ctx, cancel = context.WithCancel(ctx)
 	for attempt := 0; ; attempt++ {
 		// execute the call, save the result in resp, err
@@ -178,6 +191,13 @@ func doSubscribe(ctx context.Context, ci callerInternal, call *stateCall) (mesos
 			mesosStreamID = ""
 			return
 		}
+		if err := budget.Retry(attempt+1, time.Since(start)); err != nil {
+			call.err = err
+			clearResponse()
+			cancel()
+			mesosStreamID = ""
+			return
+		}
 
 		u, err := url.Parse(nmr.newLeaderURL)
 		if err != nil {
@@ -191,6 +211,10 @@ func doSubscribe(ctx context.Context, ci callerInternal, call *stateCall) (mesos
 		subscribeCaller.requestOpts = append(subscribeOptions[:], func(req *http.Request) {
 			req.URL = u
 		})
+		if debug {
+			id, _ := correlation.FromContext(ctx)
+			log.Printf("retrying subscription via %v (attempt %d, correlation ID %q)", u, attempt+1, id)
+		}
 
 		// back off before retrying the subscription attempt
 		select {
@@ -230,9 +254,11 @@ func mustSubscribe0(ctx context.Context, state *state, doSubscribe func(context.
 		return disconnectedPhase(mustSubscribe)
 	}
 
+	state.observe(StateConnecting, ReasonSubscribe, nil, "")
 	mesosStreamID, cancel := doSubscribe(ctx, state.client, state.call)
 	if mesosStreamID == "" {
 		cancel()
+		state.observe(StateDisconnected, ReasonSubscribeFailed, state.call.err, "")
 		return disconnectedPhase(mustSubscribe)
 	}
 
@@ -262,10 +288,11 @@ func mustSubscribe0(ctx context.Context, state *state, doSubscribe func(context.
 
 	// wrap the response: any errors processing the subscription stream should result in a
 	// transition to a disconnected state ASAP.
+	disconnection := new(disconnection)
 	state.call.resp = DisconnectionDetector(func() func() {
 		var disconnectOnce sync.Once
 		return func() { disconnectOnce.Do(transitionToDisconnected) }
-	}()).Decorate(state.call.resp)
+	}()).Decorate(recordDisconnection(state.call.resp, disconnection))
 
 	// (e) else prepare callerTemporary w/ special header, return anyCall since we're now subscribed
 	state.caller = &callerTemporary{
@@ -278,7 +305,9 @@ func mustSubscribe0(ctx context.Context, state *state, doSubscribe func(context.
 	// disconnector probably must be goroutine-safe because it mutates a response that may
 	// be concurrently streaming data to a decoder.
 	state.disconnector = cancel
+	state.disconnection = disconnection
 	state.streamID = mesosStreamID
+	state.observe(StateSubscribed, ReasonSubscribed, nil, mesosStreamID)
 	return connectedPhase(anyCall)
 }
 
@@ -322,7 +351,7 @@ func anyCall(ctx context.Context, state *state) phase {
 
 	// (b) execute call, save the result in resp, err.
 	// Release the state lock before issuing a potentially blocking non-SUBSCRIBE call.
-	call, caller, disconnector := state.call, state.caller, state.disconnector // pre-unlock state capture
+	call, caller, disconnector, disconnection := state.call, state.caller, state.disconnector, state.disconnection // pre-unlock state capture
 
 	state.m.Unlock()
 	defer state.m.Lock()
@@ -333,12 +362,14 @@ func anyCall(ctx context.Context, state *state) phase {
 
 	if errorIndicatesSubscriptionLoss(call.err) {
 		// properly transition back to a disconnected state if mesos thinks that we're unsubscribed
+		disconnection.set(ReasonSubscriptionLost, call.err)
 		disconnector()
 		return disconnectedPhase(mustSubscribe)
 	}
 
 	if nmr, ok := call.resp.(*noMasterResponse); ok {
 		// properly transition back to a disconnected state if there's been a leadership change
+		disconnection.set(ReasonLeaderChanged, nmr.clientErr)
 		disconnector()
 		call.err = nmr.clientErr
 		return disconnectedPhase(mustSubscribe)
@@ -383,7 +414,11 @@ func (state *state) Call(ctx context.Context, oemCall *scheduler.Call) (resp mes
 	defer func() {
 		state.flushNotify()
 		if debug && err != nil {
-			log.Print(*oemCall, err)
+			if id, ok := correlation.FromContext(ctx); ok {
+				log.Print(*oemCall, err, " correlation ID ", id)
+			} else {
+				log.Print(*oemCall, err)
+			}
 		}
 	}()
 
@@ -418,6 +453,8 @@ func (state *state) setPhase(p phase) bool {
 	}
 	if d2 {
 		// connected -> disconnected
+		reason, err := state.disconnection.get()
+		state.observe(StateDisconnected, reason, err, "")
 		state.sendNotify(Notification{Type: NotificationDisconnected})
 		return true
 	}
@@ -427,3 +464,11 @@ func (state *state) setPhase(p phase) bool {
 }
 
 var withoutNotification = Notification{}
+
+// observe reports a transition of the connection state to the observer of the client, if any.
+// requires that the caller is holding the state lock.
+func (state *state) observe(to ConnectionState, reason string, err error, streamID string) {
+	if state.client != nil {
+		state.client.observer.transition(to, reason, err, streamID)
+	}
+}
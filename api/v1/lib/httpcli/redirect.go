@@ -0,0 +1,122 @@
+package httpcli
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/client"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+)
+
+// DefaultMaxRedirects is the number of redirects that a Redirector follows, per call, by default.
+const DefaultMaxRedirects = 3
+
+// Redirector decorates a Client, following the redirects that a non-leading Mesos master generates in
+// response to operator API calls. The (host of the) leading master is cached: subsequent calls are sent
+// directly to the leader until such time as a call fails, at which point the cache is invalidated and
+// the next call is sent to the endpoint of the Client. Calls that fail for reasons other than a redirect
+// are not retried since it can't be known whether the master acted upon them.
+//
+// Send is compatible with the ClientFunc types of the httpmaster and httpagent packages:
+//
+//	sender := httpmaster.NewSender(httpcli.NewRedirector(cli, 0).Send)
+//
+// Redirector funcs are safe to invoke concurrently, provided that the configuration of the Client is not
+// modified concurrently.
+type Redirector struct {
+	client       *Client
+	maxRedirects int
+
+	m      sync.Mutex
+	leader string // host[:port] of the leading master, if known
+}
+
+// NewRedirector returns a Redirector that sends calls via the given Client, following at most
+// maxRedirects redirects per call; if maxRedirects is not positive then DefaultMaxRedirects applies.
+func NewRedirector(c *Client, maxRedirects int) *Redirector {
+	if maxRedirects <= 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+	return &Redirector{client: c, maxRedirects: maxRedirects}
+}
+
+// Leader returns the host[:port] of the cached leader, or else an empty string.
+func (r *Redirector) Leader() string {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.leader
+}
+
+// Invalidate clears the cached leader.
+func (r *Redirector) Invalidate() { r.setLeader("") }
+
+func (r *Redirector) setLeader(host string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.leader = host
+}
+
+// Send implements the same contract as Client.Send. Streaming requests cannot be replayed and so a
+// redirect received in response to a streaming request updates the cached leader, but is otherwise
+// returned as an error.
+func (r *Redirector) Send(cr client.Request, rc client.ResponseClass, opt ...RequestOpt) (mesos.Response, error) {
+	_, streaming := cr.(client.RequestStreaming)
+	for attempt := 0; ; attempt++ {
+		leader := r.Leader()
+		opts := opt
+		if leader != "" {
+			opts = append(opt[:len(opt):len(opt)], pinHost(leader))
+		}
+		resp, err := r.client.Send(cr, rc, opts...)
+		if err == nil {
+			return resp, nil
+		}
+		if !apierrors.CodeNotLeader.Matches(err) {
+			if leader != "" {
+				r.Invalidate()
+			}
+			return resp, err
+		}
+		host, ok := redirectHost(resp)
+		if resp != nil {
+			resp.Close()
+		}
+		if !ok {
+			r.Invalidate()
+			return nil, err
+		}
+		r.setLeader(host)
+		if streaming || attempt >= r.maxRedirects {
+			return nil, err
+		}
+	}
+}
+
+// redirectHost extracts the host[:port] of the master that's named by the Location header of a
+// redirect response; Mesos generates locations of the form "//host:port/path".
+func redirectHost(resp mesos.Response) (string, bool) {
+	res, ok := resp.(*Response)
+	if !ok {
+		return "", false
+	}
+	location := res.Header.Get("Location")
+	if location == "" {
+		return "", false
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	return u.Host, true
+}
+
+// pinHost returns a RequestOpt that sends a request to the given host[:port] instead of the host of the
+// Client endpoint.
+func pinHost(host string) RequestOpt {
+	return func(req *http.Request) {
+		req.URL.Host = host
+		req.Host = host
+	}
+}
@@ -0,0 +1,69 @@
+package httpcli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib/client"
+	"github.com/mesos/mesos-go/api/v1/lib/httpcli/apierrors"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+)
+
+func TestRedirector(t *testing.T) {
+	var leaderCalls, followerCalls int32
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&leaderCalls, 1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer leader.Close()
+
+	leaderURL, _ := url.Parse(leader.URL)
+	follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&followerCalls, 1)
+		w.Header().Set("Location", "//"+leaderURL.Host+req.URL.Path)
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	}))
+	defer follower.Close()
+
+	var (
+		r    = NewRedirector(New(Endpoint(follower.URL+"/api/v1"), Do(With())), 0)
+		call = client.RequestSingleton(&master.Call{Type: master.Call_MARK_AGENT_GONE})
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := r.Send(call, client.ResponseClassNoData); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if n := atomic.LoadInt32(&followerCalls); n != 1 {
+		t.Errorf("expected the leader to be cached after the first redirect, follower calls = %d", n)
+	}
+	if n := atomic.LoadInt32(&leaderCalls); n != 2 {
+		t.Errorf("expected 2 leader calls instead of %d", n)
+	}
+	if h := r.Leader(); h != leaderURL.Host {
+		t.Errorf("expected cached leader %q instead of %q", leaderURL.Host, h)
+	}
+
+	// a failure invalidates the cache
+	leader.Close()
+	if _, err := r.Send(call, client.ResponseClassNoData); err == nil {
+		t.Fatal("expected error from closed leader")
+	}
+	if h := r.Leader(); h != "" {
+		t.Errorf("expected leader cache to be invalidated, instead of %q", h)
+	}
+
+	// redirect loops are bounded
+	loop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Location", "//"+req.Host+req.URL.Path)
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	}))
+	defer loop.Close()
+	r = NewRedirector(New(Endpoint(loop.URL+"/api/v1"), Do(With())), 2)
+	if _, err := r.Send(call, client.ResponseClassNoData); !apierrors.CodeNotLeader.Matches(err) {
+		t.Fatalf("expected not-leader error instead of %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package httpcli
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/client"
+)
+
+// timeoutError is reported upon the expiry of a timeout of a Client; it implements net.Error.
+type timeoutError string
+
+func (e timeoutError) Error() string   { return string(e) }
+func (e timeoutError) Timeout() bool   { return true }
+func (e timeoutError) Temporary() bool { return true }
+
+var (
+	// ErrCallTimeout is returned by calls, and by the reads of their responses, that exceed the
+	// CallTimeout of a Client.
+	ErrCallTimeout error = timeoutError("mesos call timed out")
+
+	// ErrStreamIdleTimeout is returned by the reads of streaming responses that exceed the
+	// StreamIdleTimeout of a Client.
+	ErrStreamIdleTimeout error = timeoutError("mesos response stream timed out while idle")
+)
+
+// ConnectTimeout returns a ConfigOpt that sets a Config's dialer timeout, and TLS handshake timeout; zero
+// means no timeout. Unlike Timeout it doesn't bound the wait for response headers, which may be configured
+// via ResponseHeaderTimeout or else bounded per call via CallTimeout.
+func ConnectTimeout(d time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.transport.TLSHandshakeTimeout = d
+		c.dialer.Timeout = d
+	}
+}
+
+// CallTimeout returns an Opt that bounds the duration of each call: from the sending of its request,
+// through the reading of its (non-streaming) response; zero (the default) means no timeout. The responses
+// of streaming calls (e.g. SUBSCRIBE) are bounded only until their headers are received, thereafter the
+// StreamIdleTimeout applies. Expiry cancels the request, and yields ErrCallTimeout.
+func CallTimeout(d time.Duration) Opt {
+	return func(c *Client) Opt {
+		old := c.callTimeout
+		c.callTimeout = d
+		return CallTimeout(old)
+	}
+}
+
+// StreamIdleTimeout returns an Opt that bounds the time that reads of streaming responses (e.g. the events
+// of a subscription) may wait for data; zero (the default) means no timeout. It should exceed the interval
+// of the heartbeats of the server, if any. Expiry cancels the request, and yields ErrStreamIdleTimeout.
+func StreamIdleTimeout(d time.Duration) Opt {
+	return func(c *Client) Opt {
+		old := c.streamIdleTimeout
+		c.streamIdleTimeout = d
+		return StreamIdleTimeout(old)
+	}
+}
+
+// exchange enforces the timeouts of a single call by canceling the context of its request.
+type exchange struct {
+	cancel context.CancelFunc
+	idle   time.Duration
+
+	m        sync.Mutex
+	timer    *time.Timer
+	armed    bool
+	deadline time.Time
+	err      error // reported upon expiry
+	expired  error // the error of the timeout that expired, if any
+}
+
+// withTimeouts returns the request, bound to a context that's canceled upon the expiry of the timeouts of
+// the Client, and the exchange that enforces them; or else the unmodified request, and a nil exchange, if
+// no timeouts are configured.
+func (c *Client) withTimeouts(req *http.Request) (*http.Request, *exchange) {
+	if c.callTimeout <= 0 && c.streamIdleTimeout <= 0 {
+		return req, nil
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	x := &exchange{cancel: cancel, idle: c.streamIdleTimeout}
+	if c.callTimeout > 0 {
+		x.arm(c.callTimeout, ErrCallTimeout)
+	}
+	return req.WithContext(ctx), x
+}
+
+// arm (re)starts the timer of the exchange; upon expiry the request is canceled, and err is reported.
+func (x *exchange) arm(d time.Duration, err error) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	x.armed, x.deadline, x.err = true, time.Now().Add(d), err
+	if x.timer == nil {
+		x.timer = time.AfterFunc(d, x.expire)
+	} else {
+		x.timer.Reset(d)
+	}
+}
+
+func (x *exchange) disarm() {
+	x.m.Lock()
+	defer x.m.Unlock()
+	x.armed = false
+	if x.timer != nil {
+		x.timer.Stop()
+	}
+}
+
+func (x *exchange) expire() {
+	x.m.Lock()
+	defer x.m.Unlock()
+	// a timer that fires after its exchange is disarmed, or re-armed, is stale
+	if x.armed && x.expired == nil && !time.Now().Before(x.deadline) {
+		x.expired = x.err
+		x.cancel()
+	}
+}
+
+// failure returns the error of the expired timeout in lieu of err (caused by the cancelation), if any.
+func (x *exchange) failure(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	x.m.Lock()
+	defer x.m.Unlock()
+	if x.expired != nil {
+		return x.expired
+	}
+	return err
+}
+
+// received rebinds the timeouts of the exchange to the body of the response, if any: the call timeout
+// continues to bound non-streaming responses, whereas streaming responses are bounded by the idle timeout.
+func (x *exchange) received(res *http.Response, rc client.ResponseClass, err error) (*http.Response, error) {
+	if res == nil || res.Body == nil {
+		x.close()
+		return res, x.failure(err)
+	}
+	body := &timeoutBody{ReadCloser: res.Body, x: x}
+	if err == nil && isStreaming(res, rc) {
+		x.disarm()
+		body.idle = x.idle
+	}
+	res.Body = body
+	return res, x.failure(err)
+}
+
+func (x *exchange) close() {
+	x.disarm()
+	x.cancel()
+}
+
+// timeoutBody is the body of a response that's subject to the timeouts of its exchange.
+type timeoutBody struct {
+	io.ReadCloser
+	x    *exchange
+	idle time.Duration // arms the exchange for the duration of each Read, if positive
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	if b.idle > 0 {
+		b.x.arm(b.idle, ErrStreamIdleTimeout)
+		defer b.x.disarm()
+	}
+	n, err := b.ReadCloser.Read(p)
+	return n, b.x.failure(err)
+}
+
+func (b *timeoutBody) Close() error {
+	defer b.x.close()
+	return b.ReadCloser.Close()
+}
+
+// isStreaming returns true if the (successful) response is a stream of objects, e.g. of events.
+func isStreaming(res *http.Response, rc client.ResponseClass) bool {
+	if res.StatusCode != http.StatusOK {
+		return false
+	}
+	switch rc {
+	case client.ResponseClassStreaming:
+		return true
+	case client.ResponseClassAuto:
+		return !knownLength(res)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,101 @@
+package httpcli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib/client"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	"github.com/mesos/mesos-go/api/v1/lib/recordio"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+func TestTimeouts(t *testing.T) {
+	var (
+		heartbeat, _ = (&scheduler.Event{Type: scheduler.Event_HEARTBEAT}).Marshal()
+		done         = make(chan struct{})
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-headers":
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		case "/slow-body":
+			w.Header().Set("Content-Type", codecs.MediaTypeProtobuf.ContentType())
+			w.Header().Set("Content-Length", strconv.Itoa(len(heartbeat)))
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		case "/stream":
+			// heartbeats, for longer than the call timeout, and then silence
+			w.Header().Set("Content-Type", mediaTypeRecordIO.ContentType())
+			w.Header().Set("Message-Content-Type", codecs.MediaTypeProtobuf.ContentType())
+			w.WriteHeader(http.StatusOK)
+			rw := recordio.NewWriter(w)
+			for i := 0; i < 5; i++ {
+				rw.WriteFrame(heartbeat)
+				w.(http.Flusher).Flush()
+				time.Sleep(30 * time.Millisecond)
+			}
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	send := func(path string, rc client.ResponseClass) (*scheduler.Event, error) {
+		cli := New(Endpoint(ts.URL+path), CallTimeout(100*time.Millisecond), StreamIdleTimeout(100*time.Millisecond))
+		resp, err := cli.Send(client.RequestSingleton(&scheduler.Call{Type: scheduler.Call_SUBSCRIBE}), rc)
+		if resp != nil {
+			defer resp.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		var e scheduler.Event
+		for {
+			if err = resp.Decode(&e); err != nil || rc != client.ResponseClassAuto {
+				return &e, err
+			}
+		}
+	}
+
+	start := time.Now()
+	if _, err := send("/slow-headers", client.ResponseClassSingleton); err != ErrCallTimeout {
+		t.Fatalf("expected ErrCallTimeout instead of %v", err)
+	}
+	if _, err := send("/slow-body", client.ResponseClassSingleton); err != ErrCallTimeout {
+		t.Fatalf("expected ErrCallTimeout instead of %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the calls to be canceled promptly, instead of after %v", elapsed)
+	}
+
+	start = time.Now()
+	e, err := send("/stream", client.ResponseClassAuto)
+	if err != ErrStreamIdleTimeout {
+		t.Fatalf("expected ErrStreamIdleTimeout instead of %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("expected the call timeout not to bound the stream: %v", elapsed)
+	}
+	if e.GetType() != scheduler.Event_HEARTBEAT {
+		t.Fatalf("expected the heartbeats of the stream to be read: %v", e)
+	}
+	if te, ok := err.(interface {
+		Timeout() bool
+	}); !ok || !te.Timeout() {
+		t.Fatalf("expected a timeout error: %v", err)
+	}
+}
@@ -325,7 +325,8 @@ func RemoveQuota(role string) *master.Call {
 	}
 }
 
-// MarkAgentGone removes the quota for a particular role.
+// MarkAgentGone marks an agent as permanently gone; tasks running on the agent are transitioned to
+// TASK_GONE_BY_OPERATOR (partition-aware frameworks) or TASK_LOST (all other frameworks).
 func MarkAgentGone(id mesos.AgentID) *master.Call {
 	return &master.Call{
 		Type: master.Call_MARK_AGENT_GONE,
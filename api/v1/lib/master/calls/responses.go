@@ -0,0 +1,51 @@
+package calls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+)
+
+// SendGetAgents is a convenience func that executes a GET_AGENTS call using the provided Sender, and
+// returns the decoded response.
+func SendGetAgents(ctx context.Context, sender Sender) (*master.Response_GetAgents, error) {
+	r, err := decodeResponse(ctx, sender, GetAgents())
+	if err != nil {
+		return nil, err
+	}
+	if r.GetGetAgents() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", master.Call_GET_AGENTS, r.GetType())
+	}
+	return r.GetGetAgents(), nil
+}
+
+// SendGetOperations is a convenience func that executes a GET_OPERATIONS call using the provided Sender,
+// and returns the decoded response.
+func SendGetOperations(ctx context.Context, sender Sender) (*master.Response_GetOperations, error) {
+	r, err := decodeResponse(ctx, sender, GetOperations())
+	if err != nil {
+		return nil, err
+	}
+	if r.GetGetOperations() == nil {
+		return nil, fmt.Errorf("unexpected response to %v: %v", master.Call_GET_OPERATIONS, r.GetType())
+	}
+	return r.GetGetOperations(), nil
+}
+
+// decodeResponse executes the given (non-streaming) call, and decodes its response; which is closed
+// before returning.
+func decodeResponse(ctx context.Context, sender Sender, c *master.Call) (*master.Response, error) {
+	resp, err := sender.Send(ctx, NonStreaming(c))
+	if resp != nil {
+		defer resp.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r master.Response
+	if err = resp.Decode(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
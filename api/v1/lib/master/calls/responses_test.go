@@ -0,0 +1,63 @@
+package calls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+)
+
+func TestSendGetAgents(t *testing.T) {
+	var (
+		response master.Response
+		closed   int
+		sender   = SenderFunc(func(_ context.Context, r Request) (mesos.Response, error) {
+			if r.Call().GetType() != master.Call_GET_AGENTS {
+				t.Fatalf("unexpected call %v", r.Call())
+			}
+			return &mesos.ResponseWrapper{
+				Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+					*u.(*master.Response) = response
+					return nil
+				}),
+				Closer: mesos.CloseFunc(func() error { closed++; return nil }),
+			}, nil
+		})
+	)
+	response = master.Response{Type: master.Response_GET_HEALTH}
+	if _, err := SendGetAgents(context.Background(), sender); err == nil {
+		t.Fatal("expected an error for an unexpected response")
+	}
+	response = master.Response{
+		Type:      master.Response_GET_AGENTS,
+		GetAgents: &master.Response_GetAgents{Agents: make([]master.Response_GetAgents_Agent, 2)},
+	}
+	agents, err := SendGetAgents(context.Background(), sender)
+	if err != nil || len(agents.Agents) != 2 {
+		t.Fatalf("unexpected response (%v, %v)", agents, err)
+	}
+	if closed != 2 {
+		t.Fatalf("expected every response to be closed: %d", closed)
+	}
+}
+
+func TestSendGetOperations(t *testing.T) {
+	sender := SenderFunc(func(_ context.Context, r Request) (mesos.Response, error) {
+		if r.Call().GetType() != master.Call_GET_OPERATIONS {
+			t.Fatalf("unexpected call %v", r.Call())
+		}
+		return &mesos.ResponseWrapper{Decoder: encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			*u.(*master.Response) = master.Response{
+				Type:          master.Response_GET_OPERATIONS,
+				GetOperations: &master.Response_GetOperations{Operations: make([]mesos.Operation, 1)},
+			}
+			return nil
+		})}, nil
+	})
+	ops, err := SendGetOperations(context.Background(), sender)
+	if err != nil || len(ops.Operations) != 1 {
+		t.Fatalf("unexpected response (%v, %v)", ops, err)
+	}
+}
@@ -0,0 +1,301 @@
+// Package gen generates realistic Mesos fixtures (offers, task status updates, scheduler and master events)
+// for use by table-driven tests and fuzzers. The IDs, UUIDs, and resources that are generated resemble
+// those produced by a real Mesos master. Generators are deterministic for a given seed.
+package gen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/master"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+type (
+	// Generator produces fixtures; all of the objects that it generates belong to the same (generated)
+	// framework, and are "managed" by the same (generated) master. A Generator is not safe for concurrent use.
+	Generator struct {
+		random      *rand.Rand
+		masterID    string
+		frameworkID mesos.FrameworkID
+		now         time.Time
+		agents      int
+		offers      int
+		tasks       int
+	}
+
+	// OfferOpt is a functional option for a generated Offer.
+	OfferOpt func(*mesos.Offer)
+
+	// StatusOpt is a functional option for a generated TaskStatus.
+	StatusOpt func(*mesos.TaskStatus)
+)
+
+// New returns a Generator that's seeded with the given value.
+func New(seed int64) *Generator {
+	g := &Generator{
+		random: rand.New(rand.NewSource(seed)),
+		now:    time.Unix(1500000000, 0),
+	}
+	g.masterID = uuidString(g.UUID())
+	g.frameworkID = mesos.FrameworkID{Value: g.masterID + "-0000"}
+	return g
+}
+
+// UUID returns a randomly generated (version 4) UUID, in binary form, suitable for TaskStatus.UUID.
+func (g *Generator) UUID() []byte {
+	b := make([]byte, 16)
+	g.random.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return b
+}
+
+func uuidString(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// FrameworkID returns the ID of the generated framework.
+func (g *Generator) FrameworkID() mesos.FrameworkID { return g.frameworkID }
+
+// AgentID returns a new agent ID, formatted like those assigned by the master.
+func (g *Generator) AgentID() mesos.AgentID {
+	id := mesos.AgentID{Value: fmt.Sprintf("%s-S%d", g.masterID, g.agents)}
+	g.agents++
+	return id
+}
+
+// OfferID returns a new offer ID, formatted like those assigned by the master.
+func (g *Generator) OfferID() mesos.OfferID {
+	id := mesos.OfferID{Value: fmt.Sprintf("%s-O%d", g.masterID, g.offers)}
+	g.offers++
+	return id
+}
+
+// TaskID returns a new, unique, task ID.
+func (g *Generator) TaskID() mesos.TaskID {
+	id := mesos.TaskID{Value: fmt.Sprintf("task-%d", g.tasks)}
+	g.tasks++
+	return id
+}
+
+// Timestamp returns a monotonically increasing timestamp, in seconds since the epoch; successive values
+// differ by a random interval of up to one second.
+func (g *Generator) Timestamp() float64 {
+	g.now = g.now.Add(time.Duration(g.random.Int63n(int64(time.Second))))
+	return float64(g.now.UnixNano()) / float64(time.Second)
+}
+
+// Resources returns a random, agent-sized, set of unreserved cpus, mem, disk, and ports resources.
+func (g *Generator) Resources() mesos.Resources {
+	var (
+		port = uint64(31000 + g.random.Intn(1000))
+		rs   mesos.Resources
+	)
+	return rs.Add(
+		resources.NewCPUs(float64(1+g.random.Intn(32))).Resource,
+		resources.NewMemory(float64(1024*(1+g.random.Intn(64)))).Resource,
+		resources.NewDisk(float64(1024*(1+g.random.Intn(512)))).Resource,
+		resources.Build().Name(resources.NamePorts).Ranges(resources.BuildRanges().Span(port, port+999).Ranges).Resource,
+	)
+}
+
+// Offer returns an offer for the generated framework, from a new agent, with random resources.
+func (g *Generator) Offer(opts ...OfferOpt) mesos.Offer {
+	agentID := g.AgentID()
+	o := mesos.Offer{
+		ID:          g.OfferID(),
+		FrameworkID: g.frameworkID,
+		AgentID:     agentID,
+		Hostname:    fmt.Sprintf("agent-%d.example.com", g.agents-1),
+		Resources:   g.Resources(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// Offers returns n generated offers.
+func (g *Generator) Offers(n int, opts ...OfferOpt) []mesos.Offer {
+	result := make([]mesos.Offer, n)
+	for i := range result {
+		result[i] = g.Offer(opts...)
+	}
+	return result
+}
+
+// WithResources overrides the resources of a generated offer.
+func WithResources(rs ...mesos.Resource) OfferOpt {
+	return func(o *mesos.Offer) { o.Resources = rs }
+}
+
+// WithAttributes sets the text attributes of a generated offer; attributes are sorted by name.
+func WithAttributes(kv map[string]string) OfferOpt {
+	names := make([]string, 0, len(kv))
+	for k := range kv {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return func(o *mesos.Offer) {
+		o.Attributes = nil
+		for _, k := range names {
+			o.Attributes = append(o.Attributes, mesos.Attribute{
+				Name: k,
+				Type: mesos.TEXT,
+				Text: &mesos.Value_Text{Value: kv[k]},
+			})
+		}
+	}
+}
+
+// TaskStatus returns a status update, as generated by an agent, for the given task. The status has a UUID
+// (and so must be acknowledged) and a timestamp.
+func (g *Generator) TaskStatus(taskID mesos.TaskID, agentID mesos.AgentID, state mesos.TaskState, opts ...StatusOpt) mesos.TaskStatus {
+	ts := g.Timestamp()
+	s := mesos.TaskStatus{
+		TaskID:    taskID,
+		State:     state.Enum(),
+		Source:    mesos.SOURCE_EXECUTOR.Enum(),
+		AgentID:   &agentID,
+		Timestamp: &ts,
+		UUID:      g.UUID(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&s)
+		}
+	}
+	return s
+}
+
+// WithReason sets the source and reason of a generated status.
+func WithReason(source mesos.TaskStatus_Source, reason mesos.TaskStatus_Reason) StatusOpt {
+	return func(s *mesos.TaskStatus) {
+		s.Source = source.Enum()
+		s.Reason = reason.Enum()
+	}
+}
+
+// WithMessage sets the message of a generated status.
+func WithMessage(msg string) StatusOpt {
+	return func(s *mesos.TaskStatus) { s.Message = &msg }
+}
+
+// Reconciliation marks a generated status as the result of reconciliation: such updates are generated by
+// the master and have no UUID.
+func Reconciliation() StatusOpt {
+	return func(s *mesos.TaskStatus) {
+		s.Source = mesos.SOURCE_MASTER.Enum()
+		s.Reason = mesos.REASON_RECONCILIATION.Enum()
+		s.UUID = nil
+	}
+}
+
+// Subscribed returns a scheduler SUBSCRIBED event for the generated framework.
+func (g *Generator) Subscribed() *scheduler.Event {
+	var (
+		heartbeat   = 15.0
+		frameworkID = g.frameworkID
+	)
+	return &scheduler.Event{
+		Type: scheduler.Event_SUBSCRIBED,
+		Subscribed: &scheduler.Event_Subscribed{
+			FrameworkID:              &frameworkID,
+			HeartbeatIntervalSeconds: &heartbeat,
+		},
+	}
+}
+
+// OffersEvent returns a scheduler OFFERS event for the given offers.
+func OffersEvent(offers ...mesos.Offer) *scheduler.Event {
+	return &scheduler.Event{
+		Type:   scheduler.Event_OFFERS,
+		Offers: &scheduler.Event_Offers{Offers: offers},
+	}
+}
+
+// UpdateEvent returns a scheduler UPDATE event for the given status.
+func UpdateEvent(s mesos.TaskStatus) *scheduler.Event {
+	return &scheduler.Event{
+		Type:   scheduler.Event_UPDATE,
+		Update: &scheduler.Event_Update{Status: s},
+	}
+}
+
+// Task returns a task of the generated framework, running on the given agent, in the given state.
+func (g *Generator) Task(agentID mesos.AgentID, state mesos.TaskState) mesos.Task {
+	var (
+		id = g.TaskID()
+		rs mesos.Resources
+	)
+	return mesos.Task{
+		Name:        id.Value,
+		TaskID:      id,
+		FrameworkID: g.frameworkID,
+		AgentID:     agentID,
+		State:       state.Enum(),
+		Resources: rs.Add(
+			resources.NewCPUs(0.1).Resource,
+			resources.NewMemory(32).Resource,
+		),
+		Statuses: []mesos.TaskStatus{g.TaskStatus(id, agentID, state)},
+	}
+}
+
+// TaskAdded returns a master TASK_ADDED event for the given task.
+func TaskAdded(t mesos.Task) *master.Event {
+	return &master.Event{
+		Type:      master.Event_TASK_ADDED,
+		TaskAdded: &master.Event_TaskAdded{Task: t},
+	}
+}
+
+// TaskUpdated returns a master TASK_UPDATED event for the given status.
+func (g *Generator) TaskUpdated(s mesos.TaskStatus) *master.Event {
+	return &master.Event{
+		Type: master.Event_TASK_UPDATED,
+		TaskUpdated: &master.Event_TaskUpdated{
+			FrameworkID: g.frameworkID,
+			Status:      s,
+			State:       s.State,
+		},
+	}
+}
+
+// AgentAdded returns a master AGENT_ADDED event for a new agent, with random resources.
+func (g *Generator) AgentAdded() *master.Event {
+	var (
+		id = g.AgentID()
+		rs = g.Resources()
+	)
+	return &master.Event{
+		Type: master.Event_AGENT_ADDED,
+		AgentAdded: &master.Event_AgentAdded{
+			Agent: master.Response_GetAgents_Agent{
+				AgentInfo: mesos.AgentInfo{
+					ID:        &id,
+					Hostname:  fmt.Sprintf("agent-%d.example.com", g.agents-1),
+					Resources: rs,
+				},
+				Active:         true,
+				Version:        "1.10.0",
+				TotalResources: rs,
+			},
+		},
+	}
+}
+
+// AgentRemoved returns a master AGENT_REMOVED event for the given agent.
+func AgentRemoved(id mesos.AgentID) *master.Event {
+	return &master.Event{
+		Type:         master.Event_AGENT_REMOVED,
+		AgentRemoved: &master.Event_AgentRemoved{AgentID: id},
+	}
+}
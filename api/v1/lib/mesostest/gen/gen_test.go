@@ -0,0 +1,81 @@
+package gen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func TestDeterministic(t *testing.T) {
+	a, b := New(1), New(1)
+	if x, y := a.Offers(3), b.Offers(3); !reflect.DeepEqual(x, y) {
+		t.Fatalf("expected identical offers for the same seed:\n%+v\n%+v", x, y)
+	}
+	if x, y := New(2).Offer(), New(1).Offer(); reflect.DeepEqual(x, y) {
+		t.Fatal("expected different offers for different seeds")
+	}
+}
+
+func TestOffers(t *testing.T) {
+	g := New(1)
+	offers := g.Offers(5, WithAttributes(map[string]string{"rack": "a", "zone": "b"}))
+	ids := map[mesos.OfferID]struct{}{}
+	agents := map[mesos.AgentID]struct{}{}
+	for i := range offers {
+		o := &offers[i]
+		ids[o.ID] = struct{}{}
+		agents[o.AgentID] = struct{}{}
+		if o.FrameworkID != g.FrameworkID() {
+			t.Errorf("unexpected framework ID %v", o.FrameworkID)
+		}
+		if err := resources.Validate(o.Resources...); err != nil {
+			t.Errorf("invalid resources %v: %v", o.Resources, err)
+		}
+		if len(o.Attributes) != 2 || o.Attributes[0].Name != "rack" {
+			t.Errorf("unexpected attributes %v", o.Attributes)
+		}
+		// generated fixtures must survive a protobuf round trip
+		b, err := o.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var o2 mesos.Offer
+		if err = o2.Unmarshal(b); err != nil {
+			t.Fatal(err)
+		}
+		if !o.Equal(&o2) {
+			t.Errorf("offer changed after protobuf round trip: %v != %v", o, o2)
+		}
+	}
+	if len(ids) != len(offers) || len(agents) != len(offers) {
+		t.Errorf("expected unique offer and agent IDs")
+	}
+}
+
+func TestTaskStatus(t *testing.T) {
+	var (
+		g     = New(1)
+		id    = g.TaskID()
+		agent = g.AgentID()
+		s1    = g.TaskStatus(id, agent, mesos.TASK_RUNNING)
+		s2    = g.TaskStatus(id, agent, mesos.TASK_FINISHED, WithMessage("done"))
+		s3    = g.TaskStatus(id, agent, mesos.TASK_FINISHED, Reconciliation())
+	)
+	if len(s1.UUID) != 16 || s1.UUID[6]>>4 != 4 || reflect.DeepEqual(s1.UUID, s2.UUID) {
+		t.Errorf("expected unique version 4 UUIDs")
+	}
+	if s2.GetTimestamp() < s1.GetTimestamp() {
+		t.Errorf("expected monotonically increasing timestamps")
+	}
+	if s2.GetMessage() != "done" {
+		t.Errorf("unexpected message %q", s2.GetMessage())
+	}
+	if s3.UUID != nil || s3.GetReason() != mesos.REASON_RECONCILIATION || s3.GetSource() != mesos.SOURCE_MASTER {
+		t.Errorf("unexpected reconciliation status %v", s3)
+	}
+	if e := UpdateEvent(s1); e.GetUpdate().Status.GetState() != mesos.TASK_RUNNING {
+		t.Errorf("unexpected update event %v", e)
+	}
+}
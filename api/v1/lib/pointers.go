@@ -0,0 +1,10 @@
+package mesos
+
+// Bool returns a pointer to a copy of the given value; e.g. for the optional fields of the API types.
+func Bool(v bool) *bool { return &v }
+
+// String returns a pointer to a copy of the given value; e.g. for the optional fields of the API types.
+func String(v string) *string { return &v }
+
+// Float64 returns a pointer to a copy of the given value; e.g. for the optional fields of the API types.
+func Float64(v float64) *float64 { return &v }
@@ -0,0 +1,144 @@
+package mesos
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ResourceQuantity is an amount of a scalar resource, in the fixed point representation that mesos uses
+// for scalar math (see fixedpoint.go): i.e. in thousandths. Quantities may be added and subtracted with the
+// usual operators, without the drift that accumulates when floating point amounts are added and subtracted
+// repeatedly. The zero value is a quantity of zero.
+type ResourceQuantity int64
+
+// ErrInvalidQuantity is returned by ParseQuantity for strings that aren't quantities.
+var ErrInvalidQuantity = errors.New("invalid resource quantity")
+
+// QuantityOf returns the quantity of the given floating point amount, rounded to three decimal digits.
+func QuantityOf(f float64) ResourceQuantity {
+	return ResourceQuantity(convertToFixed64(f))
+}
+
+// Quantity returns the quantity of the scalar value.
+func (left *Value_Scalar) Quantity() ResourceQuantity {
+	return QuantityOf(left.GetValue())
+}
+
+// Float64 returns the floating point amount of the quantity.
+func (q ResourceQuantity) Float64() float64 {
+	return convertToFloat64(int64(q))
+}
+
+// Scalar returns a scalar value of the quantity.
+func (q ResourceQuantity) Scalar() *Value_Scalar {
+	return &Value_Scalar{Value: q.Float64()}
+}
+
+// String returns the decimal representation of the quantity, e.g. "1.5", which is accepted by ParseQuantity.
+func (q ResourceQuantity) String() string {
+	sign, u := "", uint64(q)
+	if q < 0 {
+		sign, u = "-", uint64(-q)
+	}
+	s := sign + strconv.FormatUint(u/1000, 10)
+	if frac := u % 1000; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%03d", frac), "0")
+	}
+	return s
+}
+
+// byteUnits are the units of ParseQuantity and FormatBytes, in the number of bytes per unit.
+var byteUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// megabyte is the unit of the "mem" and "disk" resources.
+const megabyte = 1 << 20
+
+// FormatBytes returns the representation of the quantity as an amount of megabytes (the unit of the "mem"
+// and "disk" resources) in the largest unit that represents it exactly, e.g. "1536MB" or "2GB", which is
+// accepted by ParseQuantity.
+func (q ResourceQuantity) FormatBytes() string {
+	if q == 0 {
+		return "0B"
+	}
+	sign, thousandths := "", big.NewInt(int64(q))
+	if q < 0 {
+		sign = "-"
+		thousandths.Neg(thousandths)
+	}
+	for _, unit := range byteUnits {
+		// q / 1000 * megabyte / unit.bytes
+		n := new(big.Int).Mul(thousandths, big.NewInt(megabyte))
+		d := big.NewInt(1000 * unit.bytes)
+		if x, r := n.QuoRem(n, d, new(big.Int)); r.Sign() == 0 {
+			return sign + x.String() + unit.suffix
+		}
+	}
+	return q.String() + "MB" // a fraction of a byte
+}
+
+// ParseQuantity parses a decimal quantity, e.g. "1.5", that's rounded to three decimal digits. Quantities
+// may have a byte unit suffix (B, KB, MB, GB, or TB, each of which is 1024 of the previous), in which case
+// the quantity is converted to megabytes (the unit of the "mem" and "disk" resources): e.g. "1.5GB" is a
+// quantity of 1536.
+func ParseQuantity(s string) (ResourceQuantity, error) {
+	var (
+		num  = strings.TrimSpace(s)
+		unit = int64(megabyte)
+	)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, unit = strings.TrimSuffix(num, u.suffix), u.bytes
+			break
+		}
+	}
+	if !isDecimal(num) {
+		return 0, fmt.Errorf("%v: %q", ErrInvalidQuantity, s)
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return 0, fmt.Errorf("%v: %q", ErrInvalidQuantity, s)
+	}
+	// r * unit / megabyte, in thousandths
+	r.Mul(r, new(big.Rat).SetFrac(big.NewInt(1000*unit), big.NewInt(megabyte)))
+	x, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		// round half away from zero, as per round64
+		x.Add(x, big.NewInt(int64(r.Sign())))
+	}
+	if !x.IsInt64() || x.Int64() == math.MinInt64 {
+		return 0, fmt.Errorf("%v: %q is out of range", ErrInvalidQuantity, s)
+	}
+	return ResourceQuantity(x.Int64()), nil
+}
+
+// isDecimal returns true if s is an optionally signed decimal number, e.g. "-1.5", "2", or ".5".
+func isDecimal(s string) bool {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	digits, dot := 0, false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
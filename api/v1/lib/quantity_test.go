@@ -0,0 +1,85 @@
+package mesos_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+func TestResourceQuantity(t *testing.T) {
+	// adding 0.1 repeatedly drifts in floating point, but not in fixed point
+	var (
+		f float64
+		q mesos.ResourceQuantity
+	)
+	for i := 0; i < 10; i++ {
+		f += 0.1
+		q += mesos.QuantityOf(0.1)
+	}
+	if f == 1 {
+		t.Fatal("expected floating point drift")
+	}
+	if q != mesos.QuantityOf(1) || q.Float64() != 1 || q.Scalar().Compare(scalar(1)) != 0 {
+		t.Fatalf("expected a quantity of 1 instead of %v", q)
+	}
+	if q = scalar(0.3).Quantity() - scalar(0.1).Quantity(); q.Float64() != 0.2 {
+		t.Fatalf("expected a quantity of 0.2 instead of %v", q)
+	}
+	if q = mesos.QuantityOf(1.0006); q != 1001 {
+		t.Fatalf("expected rounding to three decimal digits instead of %d", q)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	for i, tc := range []struct {
+		s       string
+		want    mesos.ResourceQuantity
+		str     string
+		bytes   string
+		invalid bool
+	}{
+		{s: "0", want: 0, str: "0", bytes: "0B"},
+		{s: "1.5", want: 1500, str: "1.5", bytes: "1536KB"},
+		{s: " -0.25 ", want: -250, str: "-0.25", bytes: "-256KB"},
+		{s: ".5", want: 500, str: "0.5", bytes: "512KB"},
+		{s: "2.0005", want: 2001, str: "2.001", bytes: "2.001MB"},
+		{s: "1536MB", want: 1536000, str: "1536", bytes: "1536MB"},
+		{s: "1.5GB", want: 1536000, str: "1536", bytes: "1536MB"},
+		{s: "2GB", want: 2048000, str: "2048", bytes: "2GB"},
+		{s: "1TB", want: 1048576000, str: "1048576", bytes: "1TB"},
+		{s: "512KB", want: 500, str: "0.5", bytes: "512KB"},
+		{s: "1048576B", want: 1000, str: "1", bytes: "1MB"},
+		{s: "1B", want: 0, str: "0", bytes: "0B"},
+		{s: "", invalid: true},
+		{s: "MB", invalid: true},
+		{s: "1e3", invalid: true},
+		{s: "1/2", invalid: true},
+		{s: "1.5.0", invalid: true},
+		{s: "1PB", invalid: true},
+		{s: "99999999999999999999", invalid: true},
+	} {
+		q, err := mesos.ParseQuantity(tc.s)
+		if tc.invalid {
+			if err == nil {
+				t.Errorf("test case %d: expected an error for %q", i, tc.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %d: unexpected error %v", i, err)
+			continue
+		}
+		if q != tc.want {
+			t.Errorf("test case %d: expected %d instead of %d", i, tc.want, q)
+		}
+		if s := q.String(); s != tc.str {
+			t.Errorf("test case %d: expected %q instead of %q", i, tc.str, s)
+		}
+		if b := q.FormatBytes(); b != tc.bytes {
+			t.Errorf("test case %d: expected %q instead of %q", i, tc.bytes, b)
+		}
+		if x, err := mesos.ParseQuantity(q.FormatBytes()); err != nil || x != q {
+			t.Errorf("test case %d: %q doesn't round trip: %v, %v", i, q.FormatBytes(), x, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package mesos
 
 import (
+	"math"
 	"sort"
 )
 
@@ -86,7 +87,7 @@ func (rs Ranges) Squash() Ranges {
 	squashed := Ranges{rs[0]}
 	for i := 1; i < len(rs); i++ {
 		switch max := squashed[len(squashed)-1].End; {
-		case 1+max < rs[i].Begin: // no overlap nor continuity: push
+		case max < math.MaxUint64 && 1+max < rs[i].Begin: // no overlap nor continuity: push
 			squashed = append(squashed, rs[i])
 		case max <= rs[i].End: // overlap or continuity: squash
 			squashed[len(squashed)-1].End = rs[i].End
@@ -233,6 +234,125 @@ func (rs Ranges) Min() uint64 { return rs[0].Begin }
 // Max returns the maximum number in Ranges. It will panic on empty Ranges.
 func (rs Ranges) Max() uint64 { return rs[len(rs)-1].End }
 
+// Normalize returns a copy of the Ranges in compact normal form: ranges whose bounds are reversed (e.g.
+// [10, 7]) are flipped, and the result is sorted and squashed. Normalize returns nil for empty Ranges.
+// The set algebra funcs of Ranges (Union, Intersect, Subtract, Contains, Includes) accept Ranges that
+// aren't normalized, and return normalized Ranges.
+func (rs Ranges) Normalize() Ranges {
+	x := rs.Clone()
+	for i := range x {
+		if x[i].Begin > x[i].End {
+			x[i].Begin, x[i].End = x[i].End, x[i].Begin
+		}
+	}
+	return x.Sort().Squash()
+}
+
+// normalized returns true if rs is sorted, squashed, and has no reversed bounds.
+func (rs Ranges) normalized() bool {
+	for i := range rs {
+		if rs[i].Begin > rs[i].End || (i > 0 && (rs[i-1].End == math.MaxUint64 || rs[i-1].End+1 >= rs[i].Begin)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normal returns rs if it's normalized already, or else a normalized copy of rs.
+func (rs Ranges) normal() Ranges {
+	if rs.normalized() {
+		return rs
+	}
+	return rs.Normalize()
+}
+
+// Union returns the numbers that are in either rs or right.
+func (rs Ranges) Union(right Ranges) Ranges {
+	x := make(Ranges, 0, len(rs)+len(right))
+	return append(append(x, rs...), right...).Normalize()
+}
+
+// Intersect returns the numbers that are in both rs and right.
+func (rs Ranges) Intersect(right Ranges) Ranges {
+	var (
+		a, b = rs.normal(), right.normal()
+		x    Ranges
+	)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		lo, hi := a[i].Begin, a[i].End
+		if b[j].Begin > lo {
+			lo = b[j].Begin
+		}
+		if b[j].End < hi {
+			hi = b[j].End
+		}
+		if lo <= hi {
+			x = append(x, Value_Range{lo, hi})
+		}
+		if a[i].End < b[j].End {
+			i++
+		} else {
+			j++
+		}
+	}
+	return x
+}
+
+// Subtract returns the numbers of rs that aren't in right.
+func (rs Ranges) Subtract(right Ranges) Ranges {
+	var (
+		a, b = rs.normal(), right.normal()
+		x    Ranges
+		j    int
+	)
+	for _, r := range a {
+		for j < len(b) && b[j].End < r.Begin {
+			j++
+		}
+		begin, covered := r.Begin, false
+		for ; j < len(b) && b[j].Begin <= r.End; j++ {
+			if b[j].Begin > begin {
+				x = append(x, Value_Range{begin, b[j].Begin - 1})
+			}
+			if b[j].End >= r.End {
+				// b[j] may overlap the next range of a too
+				covered = true
+				break
+			}
+			begin = b[j].End + 1
+		}
+		if !covered {
+			x = append(x, Value_Range{begin, r.End})
+		}
+	}
+	return x
+}
+
+// Contains returns true if n is in rs.
+func (rs Ranges) Contains(n uint64) bool {
+	return rs.normal().Search(n) >= 0
+}
+
+// Includes returns true if every number of right is in rs.
+func (rs Ranges) Includes(right Ranges) bool {
+	return len(right.Subtract(rs)) == 0
+}
+
+// Each invokes f for each number in rs, in order, until f returns false. rs is normalized first, so that
+// each number is visited once.
+func (rs Ranges) Each(f func(uint64) bool) {
+	for _, r := range rs.normal() {
+		for n := r.Begin; ; n++ {
+			if !f(n) {
+				return
+			}
+			if n == r.End {
+				break
+			}
+		}
+	}
+}
+
 // resource returns a *Resource with the given name and Ranges.
 func (rs Ranges) resource(name string) Resource {
 	vr := make([]Value_Range, len(rs))
@@ -1,6 +1,7 @@
 package mesos
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -160,3 +161,79 @@ func TestRanges_MinMax(t *testing.T) {
 		}
 	}
 }
+
+func TestRanges_Algebra(t *testing.T) {
+	t.Parallel()
+
+	const max = math.MaxUint64
+	for i, tt := range []struct {
+		a, b                       Ranges
+		union, intersect, subtract Ranges
+	}{
+		{nil, nil, nil, nil, nil},
+		{Ranges{{0, 10}}, nil, Ranges{{0, 10}}, nil, Ranges{{0, 10}}},
+		{nil, Ranges{{0, 10}}, Ranges{{0, 10}}, nil, nil},
+		{Ranges{{0, 10}}, Ranges{{0, 10}}, Ranges{{0, 10}}, Ranges{{0, 10}}, nil},
+		{Ranges{{0, 10}}, Ranges{{11, 20}}, Ranges{{0, 20}}, nil, Ranges{{0, 10}}},
+		{Ranges{{0, 10}}, Ranges{{5, 20}}, Ranges{{0, 20}}, Ranges{{5, 10}}, Ranges{{0, 4}}},
+		{Ranges{{0, 20}}, Ranges{{5, 10}}, Ranges{{0, 20}}, Ranges{{5, 10}}, Ranges{{0, 4}, {11, 20}}},
+		{Ranges{{0, 5}, {10, 15}, {20, 25}}, Ranges{{3, 12}, {14, 21}},
+			Ranges{{0, 25}}, Ranges{{3, 5}, {10, 12}, {14, 15}, {20, 21}}, Ranges{{0, 2}, {13, 13}, {22, 25}}},
+		// not normalized
+		{Ranges{{20, 25}, {10, 0}, {5, 12}}, Ranges{{3, 3}, {2, 1}},
+			Ranges{{0, 12}, {20, 25}}, Ranges{{1, 3}}, Ranges{{0, 0}, {4, 12}, {20, 25}}},
+		{Ranges{{0, max}}, Ranges{{max, max}}, Ranges{{0, max}}, Ranges{{max, max}}, Ranges{{0, max - 1}}},
+		{Ranges{{max - 1, max}}, Ranges{{0, 0}, {max, max}}, Ranges{{0, 0}, {max - 1, max}}, Ranges{{max, max}}, Ranges{{max - 1, max - 1}}},
+	} {
+		if got := tt.a.Union(tt.b); !equivRanges(got, tt.union) {
+			t.Errorf("test #%d: Union(%v, %v): got: %v, want: %v", i, tt.a, tt.b, got, tt.union)
+		}
+		if got := tt.a.Intersect(tt.b); !equivRanges(got, tt.intersect) {
+			t.Errorf("test #%d: Intersect(%v, %v): got: %v, want: %v", i, tt.a, tt.b, got, tt.intersect)
+		}
+		if got := tt.a.Subtract(tt.b); !equivRanges(got, tt.subtract) {
+			t.Errorf("test #%d: Subtract(%v, %v): got: %v, want: %v", i, tt.a, tt.b, got, tt.subtract)
+		}
+		if got, want := tt.a.Includes(tt.b), len(tt.b.Subtract(tt.a)) == 0; got != want {
+			t.Errorf("test #%d: Includes(%v, %v): got: %t, want: %t", i, tt.a, tt.b, got, want)
+		}
+	}
+}
+
+func equivRanges(a, b Ranges) bool {
+	return len(a) == 0 && len(b) == 0 || reflect.DeepEqual(a, b)
+}
+
+func TestRanges_ContainsEach(t *testing.T) {
+	t.Parallel()
+
+	rs := Ranges{{8, 9}, {3, 1}, {2, 4}}
+	for n, want := range map[uint64]bool{0: false, 1: true, 4: true, 5: false, 8: true, 9: true, 10: false} {
+		if got := rs.Contains(n); got != want {
+			t.Errorf("Contains(%v, %d): got: %t, want: %t", rs, n, got, want)
+		}
+	}
+	if !rs.Includes(Ranges{{2, 3}, {9, 9}}) || rs.Includes(Ranges{{4, 8}}) {
+		t.Errorf("unexpected Includes(%v)", rs)
+	}
+
+	var ns []uint64
+	rs.Each(func(n uint64) bool { ns = append(ns, n); return true })
+	if want := []uint64{1, 2, 3, 4, 8, 9}; !reflect.DeepEqual(ns, want) {
+		t.Errorf("Each(%v): got: %v, want: %v", rs, ns, want)
+	}
+	ns = nil
+	rs.Each(func(n uint64) bool { ns = append(ns, n); return n < 3 })
+	if want := []uint64{1, 2, 3}; !reflect.DeepEqual(ns, want) {
+		t.Errorf("Each(%v) that stops: got: %v, want: %v", rs, ns, want)
+	}
+	ns = nil
+	Ranges{{math.MaxUint64, math.MaxUint64}}.Each(func(n uint64) bool { ns = append(ns, n); return true })
+	if len(ns) != 1 {
+		t.Errorf("Each of max: got: %v", ns)
+	}
+
+	if got, want := (Ranges{{5, 3}, {0, 0}, {1, 2}}).Normalize(), (Ranges{{0, 5}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize: got: %v, want: %v", got, want)
+	}
+}
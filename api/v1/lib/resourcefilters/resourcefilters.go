@@ -98,3 +98,19 @@ func ReservedByRole(role string) Filter {
 
 // New concatenates the given filters
 func New(filters ...Filter) Filters { return Filters(filters) }
+
+// DiskSource returns a filter that accepts disk resources that have a source of the given type.
+func DiskSource(t mesos.Resource_DiskInfo_Source_Type) Filter {
+	return Filter(func(r *mesos.Resource) bool {
+		return r.IsDisk(t)
+	})
+}
+
+// PersistentVolumesOf returns a filter that accepts persistent volumes that were created by the given
+// principal.
+func PersistentVolumesOf(principal string) Filter {
+	return Filter(func(r *mesos.Resource) bool {
+		p := r.GetDisk().GetPersistence()
+		return p != nil && p.GetPrincipal() == principal
+	})
+}
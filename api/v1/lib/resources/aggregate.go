@@ -37,6 +37,12 @@ func Ports(resources ...mesos.Resource) (mesos.Ranges, bool) {
 	return mesos.Ranges(v.GetRanges().GetRange()), ok
 }
 
+// Set returns the union of the items of the SET resources of the given name.
+func (n Name) Set(resources ...mesos.Resource) (mesos.Set, bool) {
+	v, ok := n.Sum(resources...)
+	return mesos.Set(v.GetSet().GetItem()), ok
+}
+
 func TypesOf(resources ...mesos.Resource) map[Name]mesos.Value_Type {
 	m := map[Name]mesos.Value_Type{}
 	for i := range resources {
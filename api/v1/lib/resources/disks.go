@@ -0,0 +1,101 @@
+package resources
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resourcefilters"
+)
+
+// NewPathDisk returns a builder for a disk resource of the given size that's backed by a PATH source.
+func NewPathDisk(size float64, root string) *Builder {
+	return NewDisk(size).Disk("", "").DiskSource(root, mesos.Resource_DiskInfo_Source_PATH)
+}
+
+// NewMountDisk returns a builder for a disk resource of the given size that's backed by a MOUNT source.
+func NewMountDisk(size float64, root string) *Builder {
+	return NewDisk(size).Disk("", "").DiskSource(root, mesos.Resource_DiskInfo_Source_MOUNT)
+}
+
+// NewBlockDisk returns a builder for a disk resource of the given size that's backed by a BLOCK source.
+func NewBlockDisk(size float64) *Builder {
+	return NewDisk(size).Disk("", "").DiskSource("", mesos.Resource_DiskInfo_Source_BLOCK)
+}
+
+// NewRawDisk returns a builder for a disk resource of the given size that's backed by a RAW source.
+func NewRawDisk(size float64) *Builder {
+	return NewDisk(size).Disk("", "").DiskSource("", mesos.Resource_DiskInfo_Source_RAW)
+}
+
+// Persistence marks a disk resource as a persistent volume with the given ID, created by the given
+// principal (which may be empty). Any existing source of the disk is retained.
+func (rb *Builder) Persistence(id, principal string) *Builder {
+	if rb.Resource.Disk == nil {
+		rb.Resource.Disk = &mesos.Resource_DiskInfo{}
+	}
+	rb.Resource.Disk.Persistence = &mesos.Resource_DiskInfo_Persistence{ID: id}
+	if principal != "" {
+		rb.Resource.Disk.Persistence.Principal = &principal
+	}
+	return rb
+}
+
+// Volume sets the path at which a disk resource is mounted within a container.
+func (rb *Builder) Volume(containerPath string, mode mesos.Volume_Mode) *Builder {
+	if rb.Resource.Disk == nil {
+		rb.Resource.Disk = &mesos.Resource_DiskInfo{}
+	}
+	rb.Resource.Disk.Volume = &mesos.Volume{ContainerPath: containerPath, Mode: mode.Enum()}
+	return rb
+}
+
+// SelectMountDisks returns the MOUNT disks, of at least the given size, that are found among the given
+// resources; volumes that are already persistent are excluded. MOUNT disks may not be split, so the
+// result is ordered by ascending size: the first disk is the best fit.
+func SelectMountDisks(size float64, resources ...mesos.Resource) mesos.Resources {
+	var (
+		filter = resourcefilters.New(
+			NameDisk.Filter,
+			resourcefilters.DiskSource(mesos.Resource_DiskInfo_Source_MOUNT),
+			func(r *mesos.Resource) bool {
+				return !r.IsPersistentVolume() && r.GetScalar().GetValue() >= size
+			},
+		)
+		result mesos.Resources
+	)
+	for i := range resources {
+		// intentionally avoid Select, which would merge identical disks
+		if filter.Accepts(&resources[i]) {
+			result = append(result, resources[i])
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].GetScalar().GetValue() < result[j].GetScalar().GetValue()
+	})
+	return result
+}
+
+// FindMountDisk returns the smallest MOUNT disk, of at least the given size, that's found among the
+// given resources; returns false if there's no such disk.
+func FindMountDisk(size float64, resources ...mesos.Resource) (mesos.Resource, bool) {
+	if disks := SelectMountDisks(size, resources...); len(disks) > 0 {
+		return disks[0], true
+	}
+	return mesos.Resource{}, false
+}
+
+// PersistenceID returns an ID for a persistent volume that's unique to the given principal, role, and
+// volume name. Persistence IDs need only be unique per role on each agent, but frameworks that share a
+// role (or that fail over to a new framework ID) should avoid reusing each other's volumes; the
+// principal is the stable identity to rely upon. Characters that Mesos rejects in IDs (e.g. the
+// separators of hierarchical roles), as well as the "." that separates the components of the ID, are
+// escaped.
+func PersistenceID(principal, role, name string) string {
+	parts := []string{principal, role, name}
+	for i := range parts {
+		parts[i] = strings.Replace(url.PathEscape(parts[i]), ".", "%2E", -1)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,79 @@
+package resources_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resourcefilters"
+	rez "github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+func TestSelectMountDisks(t *testing.T) {
+	var (
+		small      = rez.NewMountDisk(100, "/mnt/a").Resource
+		large      = rez.NewMountDisk(1000, "/mnt/b").Resource
+		medium     = rez.NewMountDisk(500, "/mnt/c").Resource
+		persistent = rez.NewMountDisk(2000, "/mnt/d").Persistence("vol", "me").Resource
+		path       = rez.NewPathDisk(5000, "/mnt/e").Resource
+		rs         = mesos.Resources{
+			rez.NewCPUs(1).Resource, large, path, small, persistent, medium, rez.NewDisk(8000).Resource,
+		}
+	)
+	for ti, tc := range []struct {
+		size  float64
+		roots []string
+	}{
+		{0, []string{"/mnt/a", "/mnt/c", "/mnt/b"}},
+		{200, []string{"/mnt/c", "/mnt/b"}},
+		{1000, []string{"/mnt/b"}},
+		{1001, nil},
+	} {
+		disks := rez.SelectMountDisks(tc.size, rs...)
+		if len(disks) != len(tc.roots) {
+			t.Errorf("test case %d failed: expected %d disks instead of %v", ti, len(tc.roots), disks)
+			continue
+		}
+		for i := range disks {
+			if root := disks[i].GetDisk().GetSource().GetMount().GetRoot(); root != tc.roots[i] {
+				t.Errorf("test case %d failed: expected disk %d to be %q instead of %q", ti, i, tc.roots[i], root)
+			}
+		}
+		d, ok := rez.FindMountDisk(tc.size, rs...)
+		if ok != (len(tc.roots) > 0) || (ok && !d.Equivalent(disks[0])) {
+			t.Errorf("test case %d failed: unexpected best fit %v (%v)", ti, d, ok)
+		}
+	}
+}
+
+func TestPersistenceID(t *testing.T) {
+	for ti, tc := range []struct {
+		principal, role, name, expected string
+	}{
+		{"fw", "web", "data", "fw.web.data"},
+		{"fw", "eng/web", "data", "fw.eng%2Fweb.data"},
+		{"f.w", "web", "data", "f%2Ew.web.data"},
+		{"fw", "web", "da\\ta", "fw.web.da%5Cta"},
+	} {
+		if id := rez.PersistenceID(tc.principal, tc.role, tc.name); id != tc.expected {
+			t.Errorf("test case %d failed: expected %q instead of %q", ti, tc.expected, id)
+		}
+	}
+	if rez.PersistenceID("a.b", "c", "d") == rez.PersistenceID("a", "b.c", "d") {
+		t.Error("expected distinct persistence IDs")
+	}
+}
+
+func TestPersistentVolumesOf(t *testing.T) {
+	var (
+		mine   = rez.NewMountDisk(10, "/mnt/a").Persistence("a", "me").Resource
+		theirs = rez.NewMountDisk(10, "/mnt/b").Persistence("b", "them").Resource
+		plain  = rez.NewMountDisk(10, "/mnt/c").Resource
+	)
+	found := resourcefilters.Select(resourcefilters.PersistentVolumesOf("me"), mine, theirs, plain)
+	if len(found) != 1 || found[0].GetDisk().GetPersistence().GetID() != "a" {
+		t.Fatalf("unexpected persistent volumes %v", found)
+	}
+	if !mine.IsDisk(mesos.Resource_DiskInfo_Source_MOUNT) {
+		t.Fatal("expected persistence to retain the disk source")
+	}
+}
@@ -0,0 +1,76 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// ErrInsufficientGPUs is returned by SplitGPUs when the resources do not contain the requested number of GPUs.
+var ErrInsufficientGPUs = errors.New("insufficient gpus")
+
+// WantsGPUs returns true if any of the given resources are "gpus".
+func WantsGPUs(resources ...mesos.Resource) bool {
+	for i := range resources {
+		if resources[i].GetName() == NameGPUs.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateGPUs returns an error if any "gpus" resource isn't a non-negative, whole, scalar: Mesos allocates
+// GPUs as indivisible units.
+func ValidateGPUs(resources ...mesos.Resource) error {
+	for i := range resources {
+		r := &resources[i]
+		if r.GetName() != NameGPUs.String() {
+			continue
+		}
+		if r.GetType() != mesos.SCALAR {
+			return fmt.Errorf("gpus resource must be a scalar, not %v", r.GetType())
+		}
+		if v := r.GetScalar().GetValue(); v < 0 || v != math.Trunc(v) {
+			return fmt.Errorf("gpus resource must be a non-negative whole number, not %v", v)
+		}
+	}
+	return nil
+}
+
+// SplitGPUs takes n whole GPUs from the given resources, returning the taken GPUs as well as the remaining
+// resources. Taken GPUs retain the metadata (e.g. reservations) of the resources they're taken from.
+// Resources other than "gpus" are always returned among the remaining resources. An error is returned if
+// the resources contain fewer than n GPUs, or if any "gpus" resources fail ValidateGPUs.
+func SplitGPUs(n uint, resources ...mesos.Resource) (taken, remaining mesos.Resources, err error) {
+	if err = ValidateGPUs(resources...); err != nil {
+		return
+	}
+	need := float64(n)
+	for i := range resources {
+		r := resources[i]
+		if need == 0 || r.GetName() != NameGPUs.String() {
+			remaining = append(remaining, r)
+			continue
+		}
+		v := r.GetScalar().GetValue()
+		take := math.Min(need, v)
+		need -= take
+
+		t := proto.Clone(&r).(*mesos.Resource)
+		t.Scalar = &mesos.Value_Scalar{Value: take}
+		taken = append(taken, *t)
+
+		if v > take {
+			r = *proto.Clone(&r).(*mesos.Resource)
+			r.Scalar = &mesos.Value_Scalar{Value: v - take}
+			remaining = append(remaining, r)
+		}
+	}
+	if need > 0 {
+		return nil, nil, ErrInsufficientGPUs
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package resources_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	rez "github.com/mesos/mesos-go/api/v1/lib/resources"
+	. "github.com/mesos/mesos-go/api/v1/lib/resourcetest"
+)
+
+func TestSplitGPUs(t *testing.T) {
+	rs := Resources(
+		Resource(Name("cpus"), ValueScalar(2)),
+		Resource(Name("gpus"), ValueScalar(2), Role("role1")),
+		Resource(Name("gpus"), ValueScalar(2)),
+	)
+	for ti, tc := range []struct {
+		n                 uint
+		taken, remaining  uint64
+		wantsErr, wantsOK bool
+	}{
+		{0, 0, 4, false, true},
+		{1, 1, 3, false, true},
+		{3, 3, 1, false, true},
+		{4, 4, 0, false, true},
+		{5, 0, 0, true, false},
+	} {
+		taken, remaining, err := rez.SplitGPUs(tc.n, rs...)
+		if (err != nil) != tc.wantsErr {
+			t.Errorf("test case %d failed: unexpected error %v", ti, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if n, _ := rez.GPUs(taken...); n != tc.taken {
+			t.Errorf("test case %d failed: expected %d taken gpus instead of %d", ti, tc.taken, n)
+		}
+		if n, _ := rez.GPUs(remaining...); n != tc.remaining {
+			t.Errorf("test case %d failed: expected %d remaining gpus instead of %d", ti, tc.remaining, n)
+		}
+		if cpus, _ := rez.CPUs(remaining...); cpus != 2 {
+			t.Errorf("test case %d failed: expected cpus to remain", ti)
+		}
+	}
+	// taken gpus retain the reservation of the resources they're taken from
+	taken, _, _ := rez.SplitGPUs(1, rs...)
+	if len(taken) != 1 || taken[0].GetRole() != "role1" {
+		t.Errorf("unexpected taken gpus %v", taken)
+	}
+}
+
+func TestValidateGPUs(t *testing.T) {
+	for ti, tc := range []struct {
+		r        mesos.Resource
+		wantsErr bool
+	}{
+		{Resource(Name("gpus"), ValueScalar(1)), false},
+		{Resource(Name("gpus"), ValueScalar(1.5)), true},
+		{Resource(Name("gpus"), ValueScalar(-1)), true},
+		{Resource(Name("gpus"), ValueSet("a")), true},
+		{Resource(Name("cpus"), ValueScalar(0.5)), false},
+	} {
+		if err := rez.ValidateGPUs(tc.r); (err != nil) != tc.wantsErr {
+			t.Errorf("test case %d failed: wantsErr=%v, got %v", ti, tc.wantsErr, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package retry bounds the retries of failed operations with a Budget, which may be shared by multiple
+// subsystems (e.g. httpsched's subscription redirects, and callrules.Retry) so that the global retry
+// pressure that a framework exerts upon Mesos is bounded, and observed, in one place.
+package retry
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+// Errors returned by Budget.Retry when a retry isn't permitted.
+var (
+	ErrMaxAttempts = errors.New("retry budget: max attempts exceeded")
+	ErrMaxElapsed  = errors.New("retry budget: max elapsed time exceeded")
+	ErrExhausted   = errors.New("retry budget: exhausted")
+)
+
+// windowBuckets is the number of buckets that the window of a ratio-based budget is divided into.
+const windowBuckets = 10
+
+type (
+	// Budget determines whether failed operations may be retried. A retry must satisfy each of the limits
+	// of the budget, all of which are disabled by default:
+	//   - a maximum number of attempts per operation (see MaxAttempts);
+	//   - a maximum elapsed time per operation (see MaxElapsed);
+	//   - a ratio-based limit, a la Finagle's RetryBudget, on the number of retries relative to the number
+	//     of operations in some trailing window of time (see Ratio).
+	// A nil *Budget permits every retry. A Budget is safe for concurrent use, see With.
+	Budget struct {
+		maxAttempts int
+		maxElapsed  time.Duration
+		ratio       float64
+		minPerSec   float64
+		ttl         time.Duration
+		clock       mesostime.Clock
+
+		m       sync.Mutex
+		buckets [windowBuckets]bucket
+
+		deposits uint64 // atomic
+		retries  uint64 // atomic
+		rejected uint64 // atomic
+	}
+
+	bucket struct {
+		epoch       int64 // the index of the time slice that's counted by the bucket
+		deposits    int64
+		withdrawals int64
+	}
+
+	// Stats are the cumulative counts of the operations and retries that a Budget has observed.
+	Stats struct {
+		Deposits uint64 // operations, see Deposit
+		Retries  uint64 // retries that were permitted
+		Rejected uint64 // retries that weren't permitted
+	}
+
+	// Option is a functional option for a Budget; it returns an "undo" option when applied.
+	Option func(*Budget) Option
+)
+
+// MaxAttempts limits the number of attempts per operation, including the first; zero disables the limit.
+func MaxAttempts(n int) Option {
+	return func(b *Budget) Option {
+		old := b.maxAttempts
+		b.maxAttempts = n
+		return MaxAttempts(old)
+	}
+}
+
+// MaxElapsed limits the time that may elapse, since the first attempt of an operation, before a retry of
+// the operation; zero disables the limit.
+func MaxElapsed(d time.Duration) Option {
+	return func(b *Budget) Option {
+		old := b.maxElapsed
+		b.maxElapsed = d
+		return MaxElapsed(old)
+	}
+}
+
+// Ratio limits the number of retries within a trailing window of ttl to minPerSec retries per second,
+// plus the given ratio of the operations that were deposited within the window; e.g. a ratio of 0.2
+// permits retries of up to 20% of operations. A non-positive ttl disables the limit.
+func Ratio(ratio, minPerSec float64, ttl time.Duration) Option {
+	return func(b *Budget) Option {
+		oldRatio, oldMin, oldTTL := b.ratio, b.minPerSec, b.ttl
+		b.ratio, b.minPerSec, b.ttl = ratio, minPerSec, ttl
+		b.buckets = [windowBuckets]bucket{}
+		return Ratio(oldRatio, oldMin, oldTTL)
+	}
+}
+
+// Clock configures the clock that times the window of Ratio; defaults to mesostime.SystemClock.
+func Clock(c mesostime.Clock) Option {
+	return func(b *Budget) Option {
+		old := b.clock
+		b.clock = c
+		return Clock(old)
+	}
+}
+
+// New returns a Budget with the given limits.
+func New(opts ...Option) *Budget {
+	b := &Budget{clock: mesostime.SystemClock}
+	b.With(opts...)
+	return b
+}
+
+// With applies the given options to the Budget and returns the last "undo" option. Options must not be
+// applied concurrently with the use of the Budget.
+func (b *Budget) With(opts ...Option) (undo Option) {
+	for _, opt := range opts {
+		if opt != nil {
+			undo = opt(b)
+		}
+	}
+	return
+}
+
+// Deposit records an operation (i.e. the first attempt of it), which contributes to the ratio-based
+// limit of the budget.
+func (b *Budget) Deposit() {
+	if b == nil {
+		return
+	}
+	atomic.AddUint64(&b.deposits, 1)
+	if b.ttl <= 0 {
+		return
+	}
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.current().deposits++
+}
+
+// Retry returns nil if an operation, which has been attempted the given number of times over the given
+// duration, may be retried; the retry is then withdrawn from the budget. Otherwise it returns the error
+// of the limit that would be exceeded: ErrMaxAttempts, ErrMaxElapsed, or ErrExhausted.
+func (b *Budget) Retry(attempts int, elapsed time.Duration) error {
+	if b == nil {
+		return nil
+	}
+	err := b.withdraw(attempts, elapsed)
+	if err != nil {
+		atomic.AddUint64(&b.rejected, 1)
+	} else {
+		atomic.AddUint64(&b.retries, 1)
+	}
+	return err
+}
+
+func (b *Budget) withdraw(attempts int, elapsed time.Duration) error {
+	if b.maxAttempts > 0 && attempts >= b.maxAttempts {
+		return ErrMaxAttempts
+	}
+	if b.maxElapsed > 0 && elapsed >= b.maxElapsed {
+		return ErrMaxElapsed
+	}
+	if b.ttl <= 0 {
+		return nil
+	}
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.balance() < 1 {
+		return ErrExhausted
+	}
+	b.current().withdrawals++
+	return nil
+}
+
+// Stats returns the cumulative counts of the budget.
+func (b *Budget) Stats() Stats {
+	if b == nil {
+		return Stats{}
+	}
+	return Stats{
+		Deposits: atomic.LoadUint64(&b.deposits),
+		Retries:  atomic.LoadUint64(&b.retries),
+		Rejected: atomic.LoadUint64(&b.rejected),
+	}
+}
+
+// Balance returns the number of retries that the ratio-based limit of the budget currently permits; it's
+// -1 if the limit is disabled.
+func (b *Budget) Balance() int {
+	if b == nil || b.ttl <= 0 {
+		return -1
+	}
+	b.m.Lock()
+	defer b.m.Unlock()
+	if x := b.balance(); x > 0 {
+		return int(x)
+	}
+	return 0
+}
+
+// current returns the bucket of the current time slice, recycling a stale bucket as needed; the budget
+// must be locked.
+func (b *Budget) current() *bucket {
+	epoch := b.epoch()
+	bk := &b.buckets[epoch%windowBuckets]
+	if bk.epoch != epoch {
+		*bk = bucket{epoch: epoch}
+	}
+	return bk
+}
+
+// balance returns the number of retries that are permitted within the window; the budget must be locked.
+func (b *Budget) balance() float64 {
+	var (
+		epoch                 = b.epoch()
+		deposits, withdrawals int64
+	)
+	for i := range b.buckets {
+		if bk := &b.buckets[i]; epoch-bk.epoch < windowBuckets {
+			deposits += bk.deposits
+			withdrawals += bk.withdrawals
+		}
+	}
+	return b.minPerSec*b.ttl.Seconds() + b.ratio*float64(deposits) - float64(withdrawals)
+}
+
+func (b *Budget) epoch() int64 {
+	slice := int64(b.ttl / windowBuckets)
+	if slice <= 0 {
+		slice = 1
+	}
+	return b.clock.Now().UnixNano() / slice
+}
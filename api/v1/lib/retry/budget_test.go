@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	mesostime "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestBudgetLimits(t *testing.T) {
+	var b *Budget
+	if err := b.Retry(100, time.Hour); err != nil {
+		t.Fatalf("expected a nil budget to permit retries instead of %v", err)
+	}
+
+	b = New(MaxAttempts(3), MaxElapsed(time.Minute))
+	for i, tc := range []struct {
+		attempts int
+		elapsed  time.Duration
+		want     error
+	}{
+		{1, 0, nil},
+		{2, time.Second, nil},
+		{3, time.Second, ErrMaxAttempts},
+		{1, time.Minute, ErrMaxElapsed},
+	} {
+		if err := b.Retry(tc.attempts, tc.elapsed); err != tc.want {
+			t.Errorf("test case %d: expected %v instead of %v", i, tc.want, err)
+		}
+	}
+	if s := b.Stats(); s.Retries != 2 || s.Rejected != 2 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if n := b.Balance(); n != -1 {
+		t.Fatalf("expected a disabled ratio instead of balance %d", n)
+	}
+}
+
+func TestBudgetRatio(t *testing.T) {
+	clock := mesostime.NewFakeClock(time.Unix(1000, 0))
+	b := New(Ratio(0.5, 0.1, 10*time.Second), Clock(clock)) // 1 retry for free, plus half of the deposits
+
+	if err := b.Retry(1, 0); err != nil {
+		t.Fatalf("expected a free retry instead of %v", err)
+	}
+	if err := b.Retry(1, 0); err != ErrExhausted {
+		t.Fatalf("expected ErrExhausted instead of %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		b.Deposit()
+	}
+	if n := b.Balance(); n != 2 {
+		t.Fatalf("expected a balance of 2 instead of %d", n)
+	}
+	b.Retry(1, 0)
+	b.Retry(1, 0)
+	if err := b.Retry(1, 0); err != ErrExhausted {
+		t.Fatalf("expected ErrExhausted instead of %v", err)
+	}
+
+	// once the window elapses the deposits and withdrawals are forgotten
+	clock.Advance(11 * time.Second)
+	if n := b.Balance(); n != 1 {
+		t.Fatalf("expected a balance of 1 instead of %d", n)
+	}
+	if s := b.Stats(); s.Deposits != 4 || s.Retries != 3 || s.Rejected != 2 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
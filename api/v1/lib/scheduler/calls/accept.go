@@ -0,0 +1,137 @@
+package calls
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// AcceptOffers returns an ACCEPT call for the given offers and operations, having first prepared the
+// operations so that the master won't reject them:
+//
+//   - resources of the operations that lack an AllocationInfo are allocated to the role to which the
+//     offers are allocated (offers to MULTI_ROLE frameworks are always allocated to some role);
+//   - the resources consumed by LAUNCH and LAUNCH_GROUP operations, including those of executors that
+//     appear for the first time within the call, must be contained by the offered resources as
+//     transformed by any preceding RESERVE and UNRESERVE operations.
+//
+// Containment isn't checked for operations that follow any other type of operation (e.g. CREATE), since
+// the effect of such operations upon the offered resources isn't modeled. An error is returned if the
+// offers don't all originate from the same agent, if they're allocated to different roles, if an
+// operation's resources are allocated to some other role, or if the resources required by the operations
+// aren't contained by the offers. The given operations are not modified.
+// Callers are expected to fill in the FrameworkID and Filters.
+func AcceptOffers(offers []mesos.Offer, ops ...mesos.Offer_Operation) (*scheduler.Call, error) {
+	if len(offers) == 0 {
+		return nil, errInvalidCall("no offers to accept")
+	}
+	role, err := AllocationRole(offers...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		agentID   = offers[0].AgentID
+		offerIDs  = make([]mesos.OfferID, 0, len(offers))
+		remaining mesos.Resources
+	)
+	for i := range offers {
+		o := &offers[i]
+		if o.AgentID != agentID {
+			return nil, errInvalidCall("offers from multiple agents may not be accepted together")
+		}
+		offerIDs = append(offerIDs, o.ID)
+		remaining.Add(o.Resources...)
+	}
+
+	var (
+		prepared  = make([]mesos.Offer_Operation, 0, len(ops))
+		executors = make(map[mesos.ExecutorID]struct{})
+		modeled   = true
+	)
+	for i := range ops {
+		op := proto.Clone(&ops[i]).(*mesos.Offer_Operation)
+		if err = allocateAll(role, operationResources(op)); err != nil {
+			return nil, err
+		}
+		if modeled {
+			if modeled, err = consume(&remaining, op, executors); err != nil {
+				return nil, err
+			}
+		}
+		prepared = append(prepared, *op)
+	}
+	return &scheduler.Call{
+		Type: scheduler.Call_ACCEPT,
+		Accept: &scheduler.Call_Accept{
+			OfferIDs:   offerIDs,
+			Operations: prepared,
+		},
+	}, nil
+}
+
+// consume subtracts the resources required by the operation from the remaining offered resources, adding
+// back those that the operation produces; it returns false if the effect of the operation isn't modeled.
+func consume(remaining *mesos.Resources, op *mesos.Offer_Operation, executors map[mesos.ExecutorID]struct{}) (bool, error) {
+	var (
+		required mesos.Resources
+		produced mesos.Resources
+	)
+	executor := func(ei *mesos.ExecutorInfo) {
+		if ei == nil {
+			return
+		}
+		if _, ok := executors[ei.ExecutorID]; !ok {
+			executors[ei.ExecutorID] = struct{}{}
+			required.Add(ei.Resources...)
+		}
+	}
+	switch op.GetType() {
+	case mesos.Offer_Operation_LAUNCH:
+		for i := range op.Launch.GetTaskInfos() {
+			t := &op.Launch.TaskInfos[i]
+			required.Add(t.Resources...)
+			executor(t.Executor)
+		}
+	case mesos.Offer_Operation_LAUNCH_GROUP:
+		if lg := op.LaunchGroup; lg != nil {
+			executor(&lg.Executor)
+			for i := range lg.TaskGroup.Tasks {
+				required.Add(lg.TaskGroup.Tasks[i].Resources...)
+			}
+		}
+	case mesos.Offer_Operation_RESERVE:
+		if op.Reserve == nil || !refined(op.Reserve.Resources) {
+			return false, nil
+		}
+		produced = mesos.Resources(op.Reserve.Resources).Clone()
+		if required = op.Reserve.Source; len(required) == 0 {
+			required = produced.PopReservation()
+		}
+	case mesos.Offer_Operation_UNRESERVE:
+		if op.Unreserve == nil || !refined(op.Unreserve.Resources) {
+			return false, nil
+		}
+		required = mesos.Resources(op.Unreserve.Resources).Clone()
+		produced = required.PopReservation()
+	default:
+		return false, nil
+	}
+	if !resources.ContainsAll(*remaining, required) {
+		return false, errInvalidCall("total resources " + required.String() + " required by " +
+			op.GetType().String() + " operation are not contained in offered resources " + remaining.String())
+	}
+	remaining.Subtract(required...)
+	remaining.Add(produced...)
+	return true, nil
+}
+
+// refined returns true if all of the resources are reserved, using the "reservation refinement" format.
+func refined(rs []mesos.Resource) bool {
+	for i := range rs {
+		if len(rs[i].Reservations) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package calls_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestAcceptOffers(t *testing.T) {
+	var (
+		role  = "a"
+		offer = func(id, agent string, rs ...mesos.Resource) mesos.Offer {
+			return mesos.Offer{
+				ID:             mesos.OfferID{Value: id},
+				AgentID:        mesos.AgentID{Value: agent},
+				AllocationInfo: &mesos.Resource_AllocationInfo{Role: &role},
+				Resources:      mesos.Resources(rs).Allocate(role),
+			}
+		}
+		task = func(rs ...mesos.Resource) mesos.TaskInfo {
+			return mesos.TaskInfo{TaskID: mesos.TaskID{Value: "t"}, Resources: rs}
+		}
+		cpus = func(x float64) mesos.Resource { return resources.NewCPUs(x).Resource }
+		o1   = offer("1", "s1", cpus(1), resources.NewMemory(64).Resource)
+		o2   = offer("2", "s1", cpus(1))
+	)
+
+	launch := calls.OpLaunch(task(cpus(2), resources.NewMemory(32).Resource))
+	call, err := calls.AcceptOffers([]mesos.Offer{o1, o2}, launch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(call.Accept.OfferIDs); n != 2 {
+		t.Fatalf("expected 2 offer IDs instead of %d", n)
+	}
+	for _, r := range call.Accept.Operations[0].Launch.TaskInfos[0].Resources {
+		if r.GetAllocationInfo().GetRole() != role {
+			t.Fatalf("expected resource to be allocated to %q: %v", role, r)
+		}
+	}
+	if launch.Launch.TaskInfos[0].Resources[0].AllocationInfo != nil {
+		t.Fatal("operation was modified")
+	}
+
+	for ti, tc := range []struct {
+		offers []mesos.Offer
+		ops    []mesos.Offer_Operation
+	}{
+		{nil, nil},
+		{[]mesos.Offer{o1, offer("3", "s2", cpus(1))}, nil},
+		{[]mesos.Offer{o1, o2}, []mesos.Offer_Operation{calls.OpLaunch(task(cpus(3)))}},
+		{[]mesos.Offer{o1}, []mesos.Offer_Operation{calls.OpLaunch(task(cpus(1)), task(cpus(1)))}},
+		{[]mesos.Offer{o1}, []mesos.Offer_Operation{calls.OpLaunch(task(mesos.Resources{cpus(1)}.Allocate("b")...))}},
+	} {
+		if _, err := calls.AcceptOffers(tc.offers, tc.ops...); err == nil {
+			t.Errorf("test case %d failed: expected an error", ti)
+		}
+	}
+
+	var (
+		reservation = mesos.Resource_ReservationInfo{Type: mesos.Resource_ReservationInfo_DYNAMIC.Enum(), Role: &role}
+		reserved    = mesos.Resources{cpus(1)}.PushReservation(reservation)
+	)
+	_, err = calls.AcceptOffers([]mesos.Offer{o1, o2}, calls.OpReserve(reserved...), calls.OpLaunch(task(reserved...), task(cpus(1))))
+	if err != nil {
+		t.Fatalf("unexpected error launching reserved resources: %v", err)
+	}
+	_, err = calls.AcceptOffers([]mesos.Offer{o1, o2}, calls.OpReserve(reserved...), calls.OpLaunch(task(cpus(2))))
+	if err == nil {
+		t.Fatal("expected an error launching reserved resources as unreserved")
+	}
+}
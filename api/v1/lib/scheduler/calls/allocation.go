@@ -0,0 +1,147 @@
+package calls
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// AllocationRole returns the role to which the given offers are allocated, or else "" if the offers lack
+// AllocationInfo (i.e. they were made to a framework that isn't MULTI_ROLE). An error is returned if the
+// offers are allocated to different roles.
+func AllocationRole(offers ...mesos.Offer) (string, error) {
+	if len(offers) == 0 {
+		return "", nil
+	}
+	role := offers[0].GetAllocationInfo().GetRole()
+	for i := range offers[1:] {
+		if r := offers[i+1].GetAllocationInfo().GetRole(); r != role {
+			return "", errInvalidCall("offers allocated to roles " + role + " and " + r + " may not be used together")
+		}
+	}
+	return role, nil
+}
+
+// AllocateTasks returns copies of the given tasks, the resources of which (including those of their
+// executors) have been allocated to the given role; resources that are already allocated must be
+// allocated to that same role, otherwise an error is returned. The tasks are returned unchanged if role
+// is "". Resources drawn from offers made to a MULTI_ROLE framework must be allocated to the offers' role
+// (see AllocationRole).
+func AllocateTasks(role string, tasks ...mesos.TaskInfo) ([]mesos.TaskInfo, error) {
+	result := make([]mesos.TaskInfo, 0, len(tasks))
+	for i := range tasks {
+		t := proto.Clone(&tasks[i]).(*mesos.TaskInfo)
+		if err := allocateAll(role, taskResources(t)); err != nil {
+			return nil, err
+		}
+		result = append(result, *t)
+	}
+	return result, nil
+}
+
+// AllocateOperations returns copies of the given offer operations, the resources of which (including
+// those of tasks and executors) have been allocated to the given role; see AllocateTasks.
+func AllocateOperations(role string, ops ...mesos.Offer_Operation) ([]mesos.Offer_Operation, error) {
+	result := make([]mesos.Offer_Operation, 0, len(ops))
+	for i := range ops {
+		op := proto.Clone(&ops[i]).(*mesos.Offer_Operation)
+		if err := allocateAll(role, operationResources(op)); err != nil {
+			return nil, err
+		}
+		result = append(result, *op)
+	}
+	return result, nil
+}
+
+func allocateAll(role string, rs []*mesos.Resource) error {
+	if role == "" {
+		return nil
+	}
+	for _, r := range rs {
+		if err := allocate(r, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func taskResources(t *mesos.TaskInfo) (result []*mesos.Resource) {
+	for i := range t.Resources {
+		result = append(result, &t.Resources[i])
+	}
+	if t.Executor != nil {
+		for i := range t.Executor.Resources {
+			result = append(result, &t.Executor.Resources[i])
+		}
+	}
+	return
+}
+
+// operationResources returns (references to) the resources of an operation that must be allocated to the
+// role of the offers from which they're drawn.
+func operationResources(op *mesos.Offer_Operation) (result []*mesos.Resource) {
+	add := func(rs []mesos.Resource) {
+		for i := range rs {
+			result = append(result, &rs[i])
+		}
+	}
+	switch op.GetType() {
+	case mesos.Offer_Operation_LAUNCH:
+		for i := range op.Launch.GetTaskInfos() {
+			result = append(result, taskResources(&op.Launch.TaskInfos[i])...)
+		}
+	case mesos.Offer_Operation_LAUNCH_GROUP:
+		if lg := op.LaunchGroup; lg != nil {
+			add(lg.Executor.Resources)
+			for i := range lg.TaskGroup.Tasks {
+				add(lg.TaskGroup.Tasks[i].Resources)
+			}
+		}
+	case mesos.Offer_Operation_RESERVE:
+		if op.Reserve != nil {
+			add(op.Reserve.Source)
+			add(op.Reserve.Resources)
+		}
+	case mesos.Offer_Operation_UNRESERVE:
+		if op.Unreserve != nil {
+			add(op.Unreserve.Resources)
+		}
+	case mesos.Offer_Operation_CREATE:
+		if op.Create != nil {
+			add(op.Create.Volumes)
+		}
+	case mesos.Offer_Operation_DESTROY:
+		if op.Destroy != nil {
+			add(op.Destroy.Volumes)
+		}
+	case mesos.Offer_Operation_GROW_VOLUME:
+		if gv := op.GrowVolume; gv != nil {
+			result = append(result, &gv.Volume, &gv.Addition)
+		}
+	case mesos.Offer_Operation_SHRINK_VOLUME:
+		if sv := op.ShrinkVolume; sv != nil {
+			result = append(result, &sv.Volume)
+		}
+	case mesos.Offer_Operation_CREATE_DISK:
+		if cd := op.CreateDisk; cd != nil {
+			result = append(result, &cd.Source)
+		}
+	case mesos.Offer_Operation_DESTROY_DISK:
+		if dd := op.DestroyDisk; dd != nil {
+			result = append(result, &dd.Source)
+		}
+	}
+	return
+}
+
+// allocate sets the AllocationInfo of a resource that lacks one, otherwise checks that the resource is
+// allocated to the given role.
+func allocate(r *mesos.Resource, role string) error {
+	if r.AllocationInfo == nil {
+		r.Allocate(role)
+		return nil
+	}
+	if r2 := r.AllocationInfo.GetRole(); r2 != role {
+		return errInvalidCall("resource " + r.String() + " is allocated to role " + r2 + ", not to " + role)
+	}
+	return nil
+}
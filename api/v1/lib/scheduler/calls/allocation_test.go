@@ -0,0 +1,69 @@
+package calls_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestAllocationRole(t *testing.T) {
+	offer := func(role string) (o mesos.Offer) {
+		if role != "" {
+			o.AllocationInfo = &mesos.Resource_AllocationInfo{Role: &role}
+		}
+		return
+	}
+	for ti, tc := range []struct {
+		offers   []mesos.Offer
+		wantRole string
+		wantErr  bool
+	}{
+		{nil, "", false},
+		{[]mesos.Offer{offer("")}, "", false},
+		{[]mesos.Offer{offer("a"), offer("a")}, "a", false},
+		{[]mesos.Offer{offer("a"), offer("b")}, "", true},
+		{[]mesos.Offer{offer("a"), offer("")}, "", true},
+	} {
+		role, err := calls.AllocationRole(tc.offers...)
+		if role != tc.wantRole || (err != nil) != tc.wantErr {
+			t.Errorf("test case %d failed: unexpected result %q, %v", ti, role, err)
+		}
+	}
+}
+
+func TestAllocateTasks(t *testing.T) {
+	task := mesos.TaskInfo{
+		Resources: mesos.Resources{resources.NewCPUs(1).Resource},
+		Executor: &mesos.ExecutorInfo{
+			Resources: mesos.Resources{resources.NewMemory(32).Resource},
+		},
+	}
+	tasks, err := calls.AllocateTasks("a", task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range append(tasks[0].Resources, tasks[0].Executor.Resources...) {
+		if r.GetAllocationInfo().GetRole() != "a" {
+			t.Fatalf("resource was not allocated: %v", r)
+		}
+	}
+	if task.Resources[0].AllocationInfo != nil || task.Executor.Resources[0].AllocationInfo != nil {
+		t.Fatal("task was modified")
+	}
+	if _, err = calls.AllocateTasks("b", tasks...); err == nil {
+		t.Fatal("expected an error for resources allocated to another role")
+	}
+
+	ops, err := calls.AllocateOperations("a", calls.OpLaunchGroup(*task.Executor, task), calls.OpCreateDisk(resources.NewDisk(1).Resource, mesos.Resource_DiskInfo_Source_MOUNT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := ops[0].LaunchGroup.Executor.Resources[0]; r.GetAllocationInfo().GetRole() != "a" {
+		t.Fatalf("executor resource was not allocated: %v", r)
+	}
+	if r := ops[1].CreateDisk.Source; r.GetAllocationInfo().GetRole() != "a" {
+		t.Fatalf("disk resource was not allocated: %v", r)
+	}
+}
@@ -239,6 +239,12 @@ func OpDestroyDisk(src mesos.Resource) mesos.Offer_Operation {
 	}
 }
 
+// Teardown returns a teardown call, which removes the framework and kills all of its tasks.
+// Callers are expected to fill in the FrameworkID.
+func Teardown() *scheduler.Call {
+	return &scheduler.Call{Type: scheduler.Call_TEARDOWN}
+}
+
 // Revive returns a revive call.
 // Callers are expected to fill in the FrameworkID.
 func Revive() *scheduler.Call {
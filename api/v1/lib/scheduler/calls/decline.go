@@ -0,0 +1,77 @@
+package calls
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// CoalesceDeclines merges DECLINE calls that share the same framework ID and filters into a single
+// DECLINE call, the offer IDs of which are the (de-duplicated) union of the offer IDs of the merged
+// calls. A merged call takes the position of the first of its constituents, unless it has no offer IDs, in
+// which case it's dropped; calls of other types are returned, unchanged, in their original order. The
+// given calls are not modified.
+func CoalesceDeclines(cs ...*scheduler.Call) []*scheduler.Call {
+	type group struct {
+		call    *scheduler.Call
+		offered map[mesos.OfferID]struct{}
+	}
+	var (
+		result = make([]*scheduler.Call, 0, len(cs))
+		merged = make(map[string]*group)
+		empty  = make(map[*scheduler.Call]bool) // merged calls, by whether they lack offer IDs
+	)
+	for _, c := range cs {
+		if c.GetType() != scheduler.Call_DECLINE || c.Decline == nil {
+			result = append(result, c)
+			continue
+		}
+		key, ok := declineKey(c)
+		if !ok {
+			result = append(result, c)
+			continue
+		}
+		g := merged[key]
+		if g == nil {
+			g = &group{
+				call: &scheduler.Call{
+					Type:        c.Type,
+					FrameworkID: c.FrameworkID,
+					Decline: &scheduler.Call_Decline{
+						Filters: c.Decline.Filters,
+					},
+				},
+				offered: make(map[mesos.OfferID]struct{}),
+			}
+			merged[key] = g
+			result = append(result, g.call)
+		}
+		for _, id := range c.Decline.OfferIDs {
+			if _, ok := g.offered[id]; ok {
+				continue
+			}
+			g.offered[id] = struct{}{}
+			g.call.Decline.OfferIDs = append(g.call.Decline.OfferIDs, id)
+		}
+		empty[g.call] = len(g.offered) == 0
+	}
+	kept := result[:0]
+	for _, c := range result {
+		if !empty[c] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// declineKey returns a key that's the same for DECLINE calls that may be merged.
+func declineKey(c *scheduler.Call) (string, bool) {
+	var filters []byte
+	if f := c.Decline.Filters; f != nil {
+		b, err := f.Marshal()
+		if err != nil {
+			return "", false
+		}
+		filters = b
+	}
+	return c.GetFrameworkID().GetValue() + "\x00" + string(filters), true
+}
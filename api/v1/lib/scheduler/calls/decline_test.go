@@ -0,0 +1,56 @@
+package calls_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestCoalesceDeclines(t *testing.T) {
+	ids := func(v ...string) (result []mesos.OfferID) {
+		for _, s := range v {
+			result = append(result, mesos.OfferID{Value: s})
+		}
+		return
+	}
+	var (
+		d1     = calls.Decline(ids("a", "b")...)
+		d2     = calls.Decline(ids("c", "a")...)
+		d3     = calls.Decline(ids("d")...).With(calls.RefuseSeconds(time.Minute))
+		d4     = calls.Decline(ids("e")...).With(calls.RefuseSeconds(time.Minute))
+		revive = calls.Revive()
+	)
+	got := calls.CoalesceDeclines(d1, revive, d3, d2, d4)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 calls instead of %d: %v", len(got), got)
+	}
+	if got[1] != revive {
+		t.Fatalf("expected revive call to retain its position: %v", got)
+	}
+	for i, want := range [][]mesos.OfferID{ids("a", "b", "c"), nil, ids("d", "e")} {
+		if got[i].GetType() != scheduler.Call_DECLINE {
+			continue
+		}
+		if !reflect.DeepEqual(got[i].Decline.OfferIDs, want) {
+			t.Errorf("call %d: expected offers %v instead of %v", i, want, got[i].Decline.OfferIDs)
+		}
+	}
+	if got[2].Decline.Filters.GetRefuseSeconds() != 60 {
+		t.Errorf("expected filters to be retained: %v", got[2])
+	}
+	if n := len(d1.Decline.OfferIDs); n != 2 {
+		t.Errorf("input call was modified: %v", d1)
+	}
+
+	// offers are de-duplicated within (rather than across) filters, and calls without offers are dropped
+	got = calls.CoalesceDeclines(calls.Decline().With(calls.RefuseSeconds(time.Hour)), d3, d1,
+		calls.Decline(ids("a")...).With(calls.RefuseSeconds(time.Minute)))
+	if len(got) != 2 || !reflect.DeepEqual(got[0].Decline.OfferIDs, ids("d", "a")) ||
+		!reflect.DeepEqual(got[1].Decline.OfferIDs, ids("a", "b")) {
+		t.Fatalf("unexpected calls %v", got)
+	}
+}
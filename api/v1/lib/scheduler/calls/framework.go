@@ -0,0 +1,119 @@
+package calls
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+)
+
+// FrameworkChange is a field of a FrameworkInfo that differs between two versions of the info.
+type FrameworkChange struct {
+	Field    string // the name of the field, as per mesos.proto; e.g. "failover_timeout"
+	Old, New string // the values of the field, formatted for humans
+	// Immutable is true if Mesos doesn't allow the field to be changed once the framework has registered.
+	Immutable bool
+}
+
+func (c FrameworkChange) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// immutableFrameworkFields are the fields of a FrameworkInfo that Mesos doesn't allow to change upon
+// re-subscription, nor via UPDATE_FRAMEWORK.
+var immutableFrameworkFields = map[string]bool{
+	"id":         true,
+	"user":       true,
+	"principal":  true,
+	"checkpoint": true,
+}
+
+// DiffFrameworkInfo returns the fields (in the order of mesos.proto) that differ between the info with
+// which a framework is registered and an updated info; e.g. before re-subscribing with, or issuing an
+// UPDATE_FRAMEWORK call for, the updated info. Roles and capabilities are compared as sets. IDs are only
+// compared if both infos specify one, since the ID is assigned by Mesos upon the first subscription.
+func DiffFrameworkInfo(registered, updated *mesos.FrameworkInfo) (changes []FrameworkChange) {
+	diff := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FrameworkChange{
+				Field:     field,
+				Old:       old,
+				New:       new,
+				Immutable: immutableFrameworkFields[field],
+			})
+		}
+	}
+	diff("user", registered.GetUser(), updated.GetUser())
+	diff("name", registered.GetName(), updated.GetName())
+	if registered.GetID() != nil && updated.GetID() != nil {
+		diff("id", registered.GetID().GetValue(), updated.GetID().GetValue())
+	}
+	diff("failover_timeout", fmt.Sprint(registered.GetFailoverTimeout()), fmt.Sprint(updated.GetFailoverTimeout()))
+	diff("checkpoint", fmt.Sprint(registered.GetCheckpoint()), fmt.Sprint(updated.GetCheckpoint()))
+	diff("role", registered.GetRole(), updated.GetRole())
+	diff("roles", formatSet(registered.GetRoles()), formatSet(updated.GetRoles()))
+	diff("hostname", registered.GetHostname(), updated.GetHostname())
+	diff("principal", registered.GetPrincipal(), updated.GetPrincipal())
+	diff("webui_url", registered.GetWebUiURL(), updated.GetWebUiURL())
+	diff("capabilities", formatCapabilities(registered), formatCapabilities(updated))
+	if !registered.GetLabels().Equal(updated.GetLabels()) {
+		diff("labels", registered.GetLabels().String(), updated.GetLabels().String())
+	}
+	if !equalOfferFilters(registered.GetOfferFilters(), updated.GetOfferFilters()) {
+		diff("offer_filters", fmt.Sprint(registered.GetOfferFilters()), fmt.Sprint(updated.GetOfferFilters()))
+	}
+	return changes
+}
+
+// ValidateFrameworkUpdate returns an error if the updated info changes a field of the info with which a
+// framework is registered that Mesos doesn't allow to change: the ID, user, principal, or checkpoint
+// fields. Mesos rejects such re-subscriptions, and UPDATE_FRAMEWORK calls, with errors that don't name
+// the offending field.
+func ValidateFrameworkUpdate(registered, updated *mesos.FrameworkInfo) error {
+	var immutable []string
+	for _, c := range DiffFrameworkInfo(registered, updated) {
+		if c.Immutable {
+			immutable = append(immutable, c.String())
+		}
+	}
+	if len(immutable) > 0 {
+		return errInvalidCall("immutable framework info fields changed: " + strings.Join(immutable, ", "))
+	}
+	return nil
+}
+
+func formatSet(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	// de-duplicate, since sets are compared
+	j := 0
+	for i := range sorted {
+		if i == 0 || sorted[i] != sorted[j-1] {
+			sorted[j] = sorted[i]
+			j++
+		}
+	}
+	return strings.Join(sorted[:j], ",")
+}
+
+func formatCapabilities(info *mesos.FrameworkInfo) string {
+	names := make([]string, 0, len(info.GetCapabilities()))
+	for _, c := range info.GetCapabilities() {
+		names = append(names, c.GetType().String())
+	}
+	return formatSet(names)
+}
+
+func equalOfferFilters(a, b map[string]mesos.OfferFilters) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for role, f := range a {
+		g, ok := b[role]
+		if !ok || !f.Equal(&g) {
+			return false
+		}
+	}
+	return true
+}
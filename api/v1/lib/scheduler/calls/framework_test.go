@@ -0,0 +1,54 @@
+package calls_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestDiffFrameworkInfo(t *testing.T) {
+	registered := &mesos.FrameworkInfo{
+		User:         "root",
+		Name:         "fw",
+		ID:           &mesos.FrameworkID{Value: "fw-1"},
+		Roles:        []string{"a", "b"},
+		Capabilities: []mesos.FrameworkInfo_Capability{{Type: mesos.FrameworkInfo_Capability_MULTI_ROLE}},
+		Principal:    proto.String("p1"),
+	}
+	same := proto.Clone(registered).(*mesos.FrameworkInfo)
+	same.Roles = []string{"b", "a"}
+	same.ID = nil
+	if changes := calls.DiffFrameworkInfo(registered, same); len(changes) != 0 {
+		t.Fatalf("expected no changes instead of %v", changes)
+	}
+	if err := calls.ValidateFrameworkUpdate(registered, same); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	updated := proto.Clone(registered).(*mesos.FrameworkInfo)
+	updated.Name = "fw2"
+	updated.Roles = append(updated.Roles, "c")
+	updated.Checkpoint = proto.Bool(true)
+	updated.Principal = proto.String("p2")
+	updated.Labels = &mesos.Labels{Labels: []mesos.Label{{Key: "k"}}}
+
+	var fields, immutable []string
+	for _, c := range calls.DiffFrameworkInfo(registered, updated) {
+		fields = append(fields, c.Field)
+		if c.Immutable {
+			immutable = append(immutable, c.Field)
+		}
+	}
+	if want := []string{"name", "checkpoint", "roles", "principal", "labels"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("expected changes of %v instead of %v", want, fields)
+	}
+	if want := []string{"checkpoint", "principal"}; !reflect.DeepEqual(immutable, want) {
+		t.Fatalf("expected immutable changes of %v instead of %v", want, immutable)
+	}
+	if err := calls.ValidateFrameworkUpdate(registered, updated); err == nil {
+		t.Fatal("expected an error for changes of immutable fields")
+	}
+}
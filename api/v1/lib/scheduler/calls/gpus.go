@@ -0,0 +1,29 @@
+package calls
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+)
+
+// ValidateGPUTasks checks tasks that are about to be launched by a framework (subscribed with the given
+// info) for GPU requests that Mesos is known to reject: tasks, or their executors, that request GPUs
+// when the framework lacks the GPU_RESOURCES capability, or that request fractional GPUs.
+func ValidateGPUTasks(info *mesos.FrameworkInfo, tasks ...mesos.TaskInfo) error {
+	capable := HasCapability(info, mesos.FrameworkInfo_Capability_GPU_RESOURCES)
+	for i := range tasks {
+		rs := tasks[i].Resources
+		if ei := tasks[i].Executor; ei != nil {
+			rs = append(append([]mesos.Resource(nil), rs...), ei.Resources...)
+		}
+		if !resources.WantsGPUs(rs...) {
+			continue
+		}
+		if !capable {
+			return errInvalidCall("task " + tasks[i].TaskID.Value + " requests gpus but the framework lacks the GPU_RESOURCES capability")
+		}
+		if err := resources.ValidateGPUs(rs...); err != nil {
+			return errInvalidCall("task " + tasks[i].TaskID.Value + ": " + err.Error())
+		}
+	}
+	return nil
+}
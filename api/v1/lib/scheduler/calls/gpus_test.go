@@ -0,0 +1,40 @@
+package calls_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestValidateGPUTasks(t *testing.T) {
+	var (
+		gpus      = resources.NewGPUs(1).Resource
+		halfGPU   = resources.Build().Name(resources.NameGPUs).Scalar(0.5).Resource
+		cpus      = resources.NewCPUs(1).Resource
+		capable   = &mesos.FrameworkInfo{Capabilities: []mesos.FrameworkInfo_Capability{{Type: mesos.FrameworkInfo_Capability_GPU_RESOURCES}}}
+		incapable = &mesos.FrameworkInfo{}
+		task      = func(rs ...mesos.Resource) mesos.TaskInfo {
+			return mesos.TaskInfo{TaskID: mesos.TaskID{Value: "t"}, Resources: rs}
+		}
+		withExecutor = mesos.TaskInfo{Resources: []mesos.Resource{cpus}, Executor: &mesos.ExecutorInfo{Resources: []mesos.Resource{gpus}}}
+	)
+	for ti, tc := range []struct {
+		info     *mesos.FrameworkInfo
+		task     mesos.TaskInfo
+		wantsErr bool
+	}{
+		{incapable, task(cpus), false},
+		{incapable, task(cpus, gpus), true},
+		{incapable, withExecutor, true},
+		{capable, task(cpus, gpus), false},
+		{capable, withExecutor, false},
+		{capable, task(halfGPU), true},
+	} {
+		err := calls.ValidateGPUTasks(tc.info, tc.task)
+		if (err != nil) != tc.wantsErr {
+			t.Errorf("test case %d failed: wantsErr=%v, got %v", ti, tc.wantsErr, err)
+		}
+	}
+}
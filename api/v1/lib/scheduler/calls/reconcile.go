@@ -0,0 +1,59 @@
+package calls
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// CoalesceReconciles merges RECONCILE calls that share the same framework ID into a single RECONCILE
+// call: an implicit reconciliation (that specifies no tasks) supersedes explicit ones, otherwise the tasks
+// of the merged call are the (de-duplicated, by task ID) union of the tasks of the merged calls. A merged
+// call takes the position of the first of its constituents; calls of other types are returned, unchanged,
+// in their original order. The given calls are not modified.
+func CoalesceReconciles(cs ...*scheduler.Call) []*scheduler.Call {
+	type merge struct {
+		call     *scheduler.Call
+		implicit bool
+		tasks    map[mesos.TaskID]int // indexes of merged tasks, by ID
+	}
+	var (
+		result = make([]*scheduler.Call, 0, len(cs))
+		merged = make(map[string]*merge)
+	)
+	for _, c := range cs {
+		if c.GetType() != scheduler.Call_RECONCILE {
+			result = append(result, c)
+			continue
+		}
+		key := c.GetFrameworkID().GetValue()
+		m := merged[key]
+		if m == nil {
+			m = &merge{
+				call: &scheduler.Call{
+					Type:        c.Type,
+					FrameworkID: c.FrameworkID,
+					Reconcile:   &scheduler.Call_Reconcile{},
+				},
+				tasks: make(map[mesos.TaskID]int),
+			}
+			merged[key] = m
+			result = append(result, m.call)
+		}
+		tasks := c.GetReconcile().GetTasks()
+		if m.implicit = m.implicit || len(tasks) == 0; m.implicit {
+			m.call.Reconcile.Tasks = nil
+			continue
+		}
+		for _, t := range tasks {
+			i, ok := m.tasks[t.TaskID]
+			if !ok {
+				m.tasks[t.TaskID] = len(m.call.Reconcile.Tasks)
+				m.call.Reconcile.Tasks = append(m.call.Reconcile.Tasks, t)
+			} else if m.call.Reconcile.Tasks[i].AgentID == nil {
+				// the agent of a task makes reconciliation more specific
+				m.call.Reconcile.Tasks[i].AgentID = t.AgentID
+			}
+		}
+	}
+	return result
+}
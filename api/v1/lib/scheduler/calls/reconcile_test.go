@@ -0,0 +1,42 @@
+package calls_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestCoalesceReconciles(t *testing.T) {
+	var (
+		r1     = calls.Reconcile(calls.ReconcileTasks(map[string]string{"t1": ""}))
+		r2     = calls.Reconcile(calls.ReconcileTasks(map[string]string{"t1": "a1"}))
+		r3     = calls.Reconcile(calls.ReconcileTasks(map[string]string{"t2": "a2"}))
+		other  = calls.Reconcile(calls.ReconcileTasks(map[string]string{"t3": ""})).With(calls.Framework("other"))
+		revive = calls.Revive()
+	)
+	got := calls.CoalesceReconciles(r1, revive, r2, other, r3)
+	if len(got) != 3 || got[1] != revive {
+		t.Fatalf("unexpected calls %v", got)
+	}
+	tasks := map[string]string{}
+	for _, task := range got[0].GetReconcile().GetTasks() {
+		tasks[task.TaskID.Value] = task.GetAgentID().GetValue()
+	}
+	if !reflect.DeepEqual(tasks, map[string]string{"t1": "a1", "t2": "a2"}) {
+		t.Fatalf("unexpected tasks %v", tasks)
+	}
+	if id := got[2].GetFrameworkID().GetValue(); id != "other" || len(got[2].GetReconcile().GetTasks()) != 1 {
+		t.Fatalf("expected the call of another framework to be retained: %v", got[2])
+	}
+	if r1.GetReconcile().GetTasks()[0].AgentID != nil {
+		t.Fatalf("input call was modified: %v", r1)
+	}
+
+	// implicit reconciliation supersedes explicit reconciliation
+	got = calls.CoalesceReconciles(r1, calls.Reconcile(), r3)
+	if len(got) != 1 || got[0].GetType() != scheduler.Call_RECONCILE || len(got[0].GetReconcile().GetTasks()) != 0 {
+		t.Fatalf("expected a single implicit reconciliation instead of %v", got)
+	}
+}
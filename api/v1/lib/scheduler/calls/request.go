@@ -0,0 +1,64 @@
+package calls
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// ResourceRequest returns a request for the given resources on the agent with the given ID, or on any
+// agent if agentID is "". Note that the built-in allocator of the master ignores REQUEST calls: requests
+// are only honored by allocator modules that implement them.
+func ResourceRequest(agentID string, rs ...mesos.Resource) mesos.Request {
+	return mesos.Request{
+		AgentID:   optionalAgentID(agentID),
+		Resources: rs,
+	}
+}
+
+// RequestBuilder accumulates resource requests, merging the requests for the same agent. The zero value
+// is ready to use.
+type RequestBuilder struct {
+	agents  []string // in order of first request
+	byAgent map[string]mesos.Resources
+}
+
+// Add requests the given resources on the agent with the given ID, or on any agent if agentID is "".
+func (b *RequestBuilder) Add(agentID string, rs ...mesos.Resource) *RequestBuilder {
+	if b.byAgent == nil {
+		b.byAgent = make(map[string]mesos.Resources)
+	}
+	existing, ok := b.byAgent[agentID]
+	if !ok {
+		b.agents = append(b.agents, agentID)
+	}
+	b.byAgent[agentID] = append(existing, rs...)
+	return b
+}
+
+// Requests returns the accumulated requests, one per agent, in the order in which agents were first
+// added to the builder.
+func (b *RequestBuilder) Requests() []mesos.Request {
+	result := make([]mesos.Request, 0, len(b.agents))
+	for _, agentID := range b.agents {
+		var merged mesos.Resources
+		merged.Add(b.byAgent[agentID]...)
+		result = append(result, ResourceRequest(agentID, merged...))
+	}
+	return result
+}
+
+// Call returns a REQUEST call for the accumulated requests; an error is returned if there are no
+// requests, or if any of the requested resources are invalid.
+// Callers are expected to fill in the FrameworkID.
+func (b *RequestBuilder) Call() (*scheduler.Call, error) {
+	if len(b.agents) == 0 {
+		return nil, errInvalidCall("no resources requested")
+	}
+	for _, agentID := range b.agents {
+		if err := resources.Validate(b.byAgent[agentID]...); err != nil {
+			return nil, err
+		}
+	}
+	return Request(b.Requests()...), nil
+}
@@ -0,0 +1,43 @@
+package calls_test
+
+import (
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/resources"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	var b calls.RequestBuilder
+	if _, err := b.Call(); err == nil {
+		t.Fatal("expected an error for an empty request")
+	}
+	b.Add("a1", resources.NewCPUs(1).Resource).
+		Add("", resources.NewMemory(64).Resource).
+		Add("a1", resources.NewCPUs(2).Resource)
+
+	call, err := b.Call()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.GetType() != scheduler.Call_REQUEST {
+		t.Fatalf("unexpected call type %v", call.GetType())
+	}
+	rs := call.GetRequest().GetRequests()
+	if len(rs) != 2 {
+		t.Fatalf("expected 2 requests instead of %d", len(rs))
+	}
+	if rs[0].GetAgentID().GetValue() != "a1" || rs[1].AgentID != nil {
+		t.Fatalf("unexpected agents: %v", rs)
+	}
+	if cpus, ok := resources.CPUs(rs[0].Resources...); !ok || cpus != 3 {
+		t.Fatalf("expected merged cpus of 3 instead of %v", cpus)
+	}
+
+	b.Add("a2", mesos.Resource{Name: "cpus", Type: mesos.SCALAR.Enum(), Scalar: &mesos.Value_Scalar{Value: -1}})
+	if _, err = b.Call(); err == nil {
+		t.Fatal("expected an error for invalid resources")
+	}
+}
@@ -0,0 +1,144 @@
+package calls
+
+import (
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// SubscribeOpt is a functional option that modifies the parameters of a SUBSCRIBE call.
+type SubscribeOpt func(*scheduler.Call_Subscribe)
+
+// SubscribeWith returns a subscribe call for the given framework info after applying the given options
+// to a copy of it; the caller's FrameworkInfo is never modified. The call's FrameworkID is automatically
+// filled in from the info specification. An error is returned if the resulting call fails validation,
+// see ValidateSubscribe.
+func SubscribeWith(info mesos.FrameworkInfo, opts ...SubscribeOpt) (*scheduler.Call, error) {
+	if len(info.Capabilities) > 0 {
+		info.Capabilities = append([]mesos.FrameworkInfo_Capability(nil), info.Capabilities...)
+	}
+	if len(info.Roles) > 0 {
+		info.Roles = append([]string(nil), info.Roles...)
+	}
+	call := Subscribe(&info)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(call.Subscribe)
+		}
+	}
+	if err := ValidateSubscribe(call.Subscribe); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// Capabilities returns a SubscribeOpt that advertises the given framework capabilities. Capabilities that
+// are already present in the FrameworkInfo are not duplicated.
+func Capabilities(types ...mesos.FrameworkInfo_Capability_Type) SubscribeOpt {
+	return func(s *scheduler.Call_Subscribe) {
+		for _, t := range types {
+			if !HasCapability(s.FrameworkInfo, t) {
+				s.FrameworkInfo.Capabilities = append(s.FrameworkInfo.Capabilities, mesos.FrameworkInfo_Capability{Type: t})
+			}
+		}
+	}
+}
+
+// PartitionAware advertises the PARTITION_AWARE capability: the framework is prepared to handle
+// TASK_UNREACHABLE, TASK_DROPPED, TASK_GONE, TASK_GONE_BY_OPERATOR, and TASK_UNKNOWN states.
+func PartitionAware() SubscribeOpt {
+	return Capabilities(mesos.FrameworkInfo_Capability_PARTITION_AWARE)
+}
+
+// GPUResources advertises the GPU_RESOURCES capability: the framework will receive offers from agents w/ GPUs.
+func GPUResources() SubscribeOpt {
+	return Capabilities(mesos.FrameworkInfo_Capability_GPU_RESOURCES)
+}
+
+// TaskKillingState advertises the TASK_KILLING_STATE capability: the framework may receive TASK_KILLING
+// status updates while a task is being killed.
+func TaskKillingState() SubscribeOpt {
+	return Capabilities(mesos.FrameworkInfo_Capability_TASK_KILLING_STATE)
+}
+
+// RegionAware advertises the REGION_AWARE capability: the framework may receive offers for agents
+// whose region differs from that of the master.
+func RegionAware() SubscribeOpt {
+	return Capabilities(mesos.FrameworkInfo_Capability_REGION_AWARE)
+}
+
+// MultiRole advertises the MULTI_ROLE capability and subscribes the framework to the given roles. The
+// deprecated FrameworkInfo.Role field is cleared since Mesos rejects frameworks that set both.
+func MultiRole(roles ...string) SubscribeOpt {
+	return func(s *scheduler.Call_Subscribe) {
+		Capabilities(mesos.FrameworkInfo_Capability_MULTI_ROLE)(s)
+		s.FrameworkInfo.Role = nil
+		s.FrameworkInfo.Roles = roles
+	}
+}
+
+// SuppressedRoles sets the roles for which the framework does not initially wish to be offered resources.
+func SuppressedRoles(roles ...string) SubscribeOpt {
+	return func(s *scheduler.Call_Subscribe) {
+		s.SuppressedRoles = roles
+	}
+}
+
+// HasCapability returns true if the given framework info advertises a capability of the given type.
+func HasCapability(info *mesos.FrameworkInfo, t mesos.FrameworkInfo_Capability_Type) bool {
+	for _, c := range info.GetCapabilities() {
+		if c.GetType() == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSubscribe checks the parameters of a SUBSCRIBE call for combinations that Mesos is known to
+// reject:
+//   - the FrameworkInfo is missing
+//   - a capability is UNKNOWN, or is specified more than once
+//   - FrameworkInfo.Roles is specified without the MULTI_ROLE capability
+//   - the MULTI_ROLE capability is specified along with the deprecated FrameworkInfo.Role field
+//   - a role is specified more than once
+//   - a suppressed role is not one of the framework's roles
+func ValidateSubscribe(s *scheduler.Call_Subscribe) error {
+	info := s.GetFrameworkInfo()
+	if info == nil {
+		return errInvalidCall("missing framework info")
+	}
+	seen := make(map[mesos.FrameworkInfo_Capability_Type]struct{}, len(info.Capabilities))
+	for _, c := range info.Capabilities {
+		t := c.GetType()
+		if t == mesos.FrameworkInfo_Capability_UNKNOWN {
+			return errInvalidCall("unknown framework capability")
+		}
+		if _, ok := seen[t]; ok {
+			return errInvalidCall("duplicate framework capability " + t.String())
+		}
+		seen[t] = struct{}{}
+	}
+	_, multiRole := seen[mesos.FrameworkInfo_Capability_MULTI_ROLE]
+	if !multiRole && len(info.Roles) > 0 {
+		return errInvalidCall("framework roles require the MULTI_ROLE capability")
+	}
+	if multiRole && info.Role != nil {
+		return errInvalidCall("framework role may not be set along with the MULTI_ROLE capability")
+	}
+	roles := make(map[string]struct{}, len(info.Roles)+1)
+	if multiRole {
+		for _, r := range info.Roles {
+			if _, ok := roles[r]; ok {
+				return errInvalidCall("duplicate framework role " + r)
+			}
+			roles[r] = struct{}{}
+		}
+	} else {
+		roles[info.GetRole()] = struct{}{}
+	}
+	for _, r := range s.SuppressedRoles {
+		if _, ok := roles[r]; !ok {
+			return errInvalidCall("suppressed role " + r + " is not a framework role")
+		}
+	}
+	return nil
+}
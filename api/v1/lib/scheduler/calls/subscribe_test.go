@@ -0,0 +1,68 @@
+package calls_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/calls"
+)
+
+func TestSubscribeWith(t *testing.T) {
+	var (
+		role      = "x"
+		withRole  = mesos.FrameworkInfo{Role: &role}
+		withRoles = mesos.FrameworkInfo{Roles: []string{"x"}}
+		withCaps  = mesos.FrameworkInfo{Capabilities: []mesos.FrameworkInfo_Capability{
+			{Type: mesos.FrameworkInfo_Capability_GPU_RESOURCES},
+		}}
+	)
+	for ti, tc := range []struct {
+		info      mesos.FrameworkInfo
+		opts      []calls.SubscribeOpt
+		wantsCaps []mesos.FrameworkInfo_Capability_Type
+		wantsErr  bool
+	}{
+		{mesos.FrameworkInfo{}, nil, nil, false},
+		{mesos.FrameworkInfo{}, []calls.SubscribeOpt{calls.PartitionAware(), calls.RegionAware()}, []mesos.FrameworkInfo_Capability_Type{
+			mesos.FrameworkInfo_Capability_PARTITION_AWARE, mesos.FrameworkInfo_Capability_REGION_AWARE}, false},
+		{withCaps, []calls.SubscribeOpt{calls.GPUResources(), calls.TaskKillingState()}, []mesos.FrameworkInfo_Capability_Type{
+			mesos.FrameworkInfo_Capability_GPU_RESOURCES, mesos.FrameworkInfo_Capability_TASK_KILLING_STATE}, false},
+		{withRole, []calls.SubscribeOpt{calls.MultiRole("a", "b"), calls.SuppressedRoles("b")}, []mesos.FrameworkInfo_Capability_Type{
+			mesos.FrameworkInfo_Capability_MULTI_ROLE}, false},
+		{withRole, []calls.SubscribeOpt{calls.SuppressedRoles("x")}, nil, false},
+		{withRole, []calls.SubscribeOpt{calls.SuppressedRoles("y")}, nil, true},
+		{withRoles, nil, nil, true},
+		{withRole, []calls.SubscribeOpt{calls.Capabilities(mesos.FrameworkInfo_Capability_MULTI_ROLE)}, nil, true},
+		{mesos.FrameworkInfo{}, []calls.SubscribeOpt{calls.MultiRole("a", "a")}, nil, true},
+		{mesos.FrameworkInfo{}, []calls.SubscribeOpt{calls.Capabilities(mesos.FrameworkInfo_Capability_UNKNOWN)}, nil, true},
+		{mesos.FrameworkInfo{Capabilities: append(withCaps.Capabilities, withCaps.Capabilities...)}, nil, nil, true},
+	} {
+		call, err := calls.SubscribeWith(tc.info, tc.opts...)
+		if tc.wantsErr {
+			if err == nil {
+				t.Errorf("test case %d failed: expected error instead of %+v", ti, call)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %d failed: unexpected error %+v", ti, err)
+			continue
+		}
+		if call.GetType() != scheduler.Call_SUBSCRIBE {
+			t.Errorf("test case %d failed: unexpected call type %v", ti, call.GetType())
+		}
+		var caps []mesos.FrameworkInfo_Capability_Type
+		for _, c := range call.GetSubscribe().GetFrameworkInfo().GetCapabilities() {
+			caps = append(caps, c.GetType())
+		}
+		if !reflect.DeepEqual(tc.wantsCaps, caps) {
+			t.Errorf("test case %d failed: expected capabilities %v instead of %v", ti, tc.wantsCaps, caps)
+		}
+	}
+	// the original framework info should never be modified
+	if len(withCaps.Capabilities) != 1 || withRole.Role == nil {
+		t.Errorf("unexpected modification of framework info")
+	}
+}
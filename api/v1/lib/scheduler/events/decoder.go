@@ -0,0 +1,118 @@
+package events
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/codecs"
+	pbenc "github.com/mesos/mesos-go/api/v1/lib/encoding/proto"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// ProtobufCodec is the protobuf codec of codecs.ByMediaType, except that it decodes events using
+// NewProtobufDecoder. It's an opt-in alternative for busy frameworks; e.g. httpcli.Codec(ProtobufCodec).
+var ProtobufCodec = encoding.Codec{
+	Name:       codecs.NameProtobuf,
+	Type:       codecs.MediaTypeProtobuf,
+	NewEncoder: pbenc.NewEncoder,
+	NewDecoder: NewProtobufDecoder,
+}
+
+// NewProtobufDecoder returns a Decoder of protobuf messages, read from the given Source, with a fast path
+// for the hottest type of event: UPDATE. The Update (and the AgentID, ExecutorID, and UUID of its status)
+// that was allocated for an UPDATE is reused, rather than discarded, by the next UPDATE that's decoded
+// into the same Event; even if the Event was reset in the meantime, as it is by an Iterator. The fast
+// path only pays off if the same Event is decoded into, time and again: see Iterator.ReuseEvents. Other
+// messages, and events that aren't UPDATEs (including events of unknown types), are decoded in the same
+// manner as by encoding/proto.
+func NewProtobufDecoder(s encoding.Source) encoding.Decoder {
+	var (
+		r     = s()
+		last  *scheduler.Event        // the Event that an UPDATE was most recently decoded into
+		spare *scheduler.Event_Update // the Update of last, recycled if last is decoded into again
+	)
+	return encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+		// Note: the frame is reused by the reader, see framing.NewDecoder; the generated unmarshaling
+		// code copies byte slices and strings.
+		frame, err := r.ReadFrame()
+		if err != nil {
+			return err
+		}
+		if e, ok := u.(*scheduler.Event); ok && eventType(frame) == scheduler.Event_UPDATE {
+			if e == last && e.Update == nil {
+				e.Update = spare
+			}
+			err = unmarshalUpdate(e, frame)
+			last, spare = e, e.Update
+			return err
+		}
+		if err = proto.Unmarshal(frame, u.(proto.Message)); err != nil {
+			return err
+		}
+		return encoding.CheckType(u, frame)
+	})
+}
+
+// eventType returns the type of the encoded event, if it's the leading field (as it is when the event
+// is serialized by Mesos); otherwise it returns Event_UNKNOWN.
+func eventType(frame []byte) scheduler.Event_Type {
+	const typeKey = 1<<3 | proto.WireVarint // field 1, varint
+	if len(frame) < 2 || frame[0] != typeKey {
+		return scheduler.Event_UNKNOWN
+	}
+	x, n := proto.DecodeVarint(frame[1:])
+	if n == 0 {
+		return scheduler.Event_UNKNOWN
+	}
+	return scheduler.Event_Type(x)
+}
+
+// unmarshalUpdate resets the event, retaining the allocations of a previous UPDATE, and then unmarshals
+// the frame. Retained sub-messages and buffers are discarded if the frame doesn't populate them, so that
+// the result is indistinguishable from that of proto.Unmarshal.
+func unmarshalUpdate(e *scheduler.Event, frame []byte) error {
+	update := e.Update
+	*e = scheduler.Event{}
+	if update == nil {
+		return e.Unmarshal(frame)
+	}
+	var (
+		s          = &update.Status
+		agentID    = s.AgentID
+		executorID = s.ExecutorID
+		uuid       = s.UUID
+	)
+	*update = scheduler.Event_Update{}
+	if agentID != nil {
+		*agentID = mesos.AgentID{}
+		s.AgentID = agentID
+	}
+	if executorID != nil {
+		*executorID = mesos.ExecutorID{}
+		s.ExecutorID = executorID
+	}
+	if uuid != nil {
+		s.UUID = uuid[:0]
+	}
+	e.Update = update
+
+	err := e.Unmarshal(frame)
+
+	// TaskID, AgentID, and ExecutorID values are never empty, and neither are UUIDs: an empty value means
+	// that the field was absent from the frame.
+	s = &e.Update.Status
+	if s.TaskID.Value == "" {
+		e.Update = nil
+		return err
+	}
+	if s.AgentID == agentID && agentID != nil && agentID.Value == "" {
+		s.AgentID = nil
+	}
+	if s.ExecutorID == executorID && executorID != nil && executorID.Value == "" {
+		s.ExecutorID = nil
+	}
+	if uuid != nil && len(s.UUID) == 0 {
+		s.UUID = nil
+	}
+	return err
+}
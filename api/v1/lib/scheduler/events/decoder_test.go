@@ -0,0 +1,151 @@
+package events_test
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	pbenc "github.com/mesos/mesos-go/api/v1/lib/encoding/proto"
+	"github.com/mesos/mesos-go/api/v1/lib/mesostest/gen"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+// frames returns a Source that yields the given events, encoded as protobuf, repeatedly forever if loop
+// is true.
+func frames(t testing.TB, loop bool, es ...*scheduler.Event) encoding.Source {
+	var encoded [][]byte
+	for _, e := range es {
+		b, err := proto.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded = append(encoded, b)
+	}
+	return func() framing.Reader {
+		i := 0
+		return framing.ReaderFunc(func() ([]byte, error) {
+			if i == len(encoded) {
+				if !loop {
+					return nil, io.EOF
+				}
+				i = 0
+			}
+			i++
+			return encoded[i-1], nil
+		})
+	}
+}
+
+func updateFixtures() []*scheduler.Event {
+	var (
+		g        = gen.New(1)
+		agentID  = g.AgentID()
+		reconcil = g.TaskStatus(g.TaskID(), agentID, mesos.TASK_RUNNING, gen.Reconciliation())
+		orphan   = g.TaskStatus(g.TaskID(), agentID, mesos.TASK_LOST, gen.WithMessage("gone"))
+	)
+	orphan.AgentID = nil
+	orphan.ExecutorID = &mesos.ExecutorID{Value: "e"}
+	return []*scheduler.Event{
+		gen.UpdateEvent(g.TaskStatus(g.TaskID(), agentID, mesos.TASK_RUNNING)),
+		gen.UpdateEvent(reconcil),
+		gen.UpdateEvent(orphan),
+		gen.OffersEvent(g.Offers(2)...),
+		gen.UpdateEvent(g.TaskStatus(g.TaskID(), agentID, mesos.TASK_FINISHED)),
+		gen.UpdateEvent(reconcil),
+	}
+}
+
+func TestProtobufDecoder(t *testing.T) {
+	var (
+		fixtures = updateFixtures()
+		source   = frames(t, false, fixtures...)
+		it       = events.IteratorFor(events.NewProtobufDecoder(source)).ReuseEvents(true)
+		want     = events.IteratorFor(pbenc.NewDecoder(source))
+		ctx      = context.Background()
+	)
+	for i := range fixtures {
+		e, err := it.Next(ctx)
+		if err != nil {
+			t.Fatalf("event %d: unexpected error: %v", i, err)
+		}
+		w, err := want.Next(ctx)
+		if err != nil {
+			t.Fatalf("event %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(e, w) {
+			t.Fatalf("event %d: expected %v instead of %v", i, w, e)
+		}
+	}
+	if _, err := it.Next(ctx); err != io.EOF {
+		t.Fatalf("expected EOF instead of %v", err)
+	}
+}
+
+// response decorates a decoder with a no-op Close, like the mesos.Response of a subscription: so that the
+// iterators under test watch their (cancelable) contexts, as they do in controller.Run.
+type response struct {
+	encoding.Decoder
+}
+
+func (response) Close() error { return nil }
+
+func TestProtobufDecoderAllocs(t *testing.T) {
+	cancelable, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		fixtures = updateFixtures()[:1]
+		allocs   = func(dec func(encoding.Source) encoding.Decoder, reuse, watched bool) float64 {
+			var (
+				d   = dec(frames(t, true, fixtures...))
+				it  = events.IteratorFor(d).ReuseEvents(reuse)
+				ctx = context.Background()
+			)
+			if watched {
+				it, ctx = events.IteratorFor(response{d}).ReuseEvents(reuse), cancelable
+			}
+			defer it.Close()
+			it.Next(ctx) // warm up
+			return testing.AllocsPerRun(100, func() { it.Next(ctx) })
+		}
+		slow      = allocs(pbenc.NewDecoder, false, true)
+		fast      = allocs(events.NewProtobufDecoder, true, true)
+		unwatched = allocs(events.NewProtobufDecoder, true, false)
+	)
+	if fast >= slow {
+		t.Fatalf("expected fewer than %v allocations per UPDATE instead of %v", slow, fast)
+	}
+	// the watcher of a cancelable context doesn't allocate per event
+	if fast > unwatched {
+		t.Fatalf("expected no more than %v allocations per watched UPDATE instead of %v", unwatched, fast)
+	}
+}
+
+func BenchmarkProtobufDecoder(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, bc := range []struct {
+		name  string
+		dec   func(encoding.Source) encoding.Decoder
+		reuse bool
+	}{
+		{"default", pbenc.NewDecoder, false},
+		{"fast", events.NewProtobufDecoder, true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			it := events.IteratorFor(response{bc.dec(frames(b, true, updateFixtures()[0]))}).ReuseEvents(bc.reuse)
+			defer it.Close()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := it.Next(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+)
+
+// ErrClosed is returned by the Next func of an Iterator that's been closed.
+var ErrClosed = errors.New("event iterator closed")
+
+// Iterator yields the events of a subscription, one at a time, to the goroutine that invokes Next: there
+// is no background reader and events are not buffered. An Iterator is not safe for concurrent use.
+type Iterator struct {
+	decoder encoding.Decoder
+	closer  io.Closer
+	err     error
+	reuse   bool
+	event   scheduler.Event // decoded into, by every call to Next, if reuse is true
+
+	watched context.Context // the context whose cancellation closes closer, if any
+	stop    chan struct{}   // closed upon termination, releasing the watcher of watched
+}
+
+// NewIterator returns an Iterator that decodes events using the given decoder. The closer, if not nil, is
+// closed in order to interrupt a read that's blocked when the context of Next is canceled; it's typically
+// the subscription's mesos.Response (see IteratorFor).
+func NewIterator(d encoding.Decoder, c io.Closer) *Iterator {
+	return &Iterator{decoder: d, closer: c}
+}
+
+// IteratorFor returns an Iterator of the events decoded by d; if d is also an io.Closer (as is a
+// mesos.Response) then it's used to interrupt blocked reads.
+func IteratorFor(d encoding.Decoder) *Iterator {
+	c, _ := d.(io.Closer)
+	return NewIterator(d, c)
+}
+
+// ReuseEvents configures whether every call to Next decodes into (and returns) the same Event, rather
+// than allocating a new Event for each. Reuse avoids the bulk of the garbage that's generated by busy
+// subscriptions, especially when combined with NewProtobufDecoder, but an event (and anything that it
+// references, e.g. the UUID of a status update) is only valid until the following call to Next: consumers
+// must copy whatever they retain. Returns the receiver.
+func (it *Iterator) ReuseEvents(b bool) *Iterator {
+	it.reuse = b
+	return it
+}
+
+// Next blocks until the next event has been decoded, or else until ctx is done. If ctx is done before Next
+// is invoked then ctx.Err() is returned and the iterator remains usable, unless ctx is the watched context.
+// The first cancelable context given to Next is watched: upon its cancellation the iterator's closer (if
+// any) is closed, interrupting a blocked read, and the iterator is terminated with ctx.Err(). The
+// cancellation of other contexts, or of any context absent a closer, is only observed upon completion of
+// the pending read; callers should pass the same context to every call. Once the iterator is terminated,
+// by a decoding error, by cancellation, or by Close, every call to Next returns the same error. Events of
+// unknown types are returned as an *encoding.UnknownEvent error, which doesn't terminate the iterator:
+// such events may be skipped.
+func (it *Iterator) Next(ctx context.Context) (*scheduler.Event, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	if it.watched == nil && it.closer != nil && ctx.Done() != nil {
+		it.watch(ctx)
+	}
+	if err := it.canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	e := &it.event
+	if it.reuse {
+		// decoders (e.g. of JSON) needn't clear the fields that are absent from a message
+		*e = scheduler.Event{}
+	} else {
+		e = new(scheduler.Event)
+	}
+	err := it.decoder.Decode(e)
+	if err != nil {
+		if cerr := it.canceled(ctx); cerr != nil {
+			err = cerr
+		}
+	}
+
+	switch {
+	case isUnknownEvent(err):
+		return nil, err
+	case err != nil:
+		it.terminate(err)
+	default:
+		return e, nil
+	}
+	return nil, it.err
+}
+
+// Close terminates the iterator, releasing the watcher of its context, and closes its closer (if any).
+func (it *Iterator) Close() error {
+	it.terminate(ErrClosed)
+	if it.closer != nil {
+		return it.closer.Close()
+	}
+	return nil
+}
+
+// watch starts the goroutine that closes the closer upon the cancellation of ctx; the goroutine exits
+// upon the termination of the iterator.
+func (it *Iterator) watch(ctx context.Context) {
+	it.watched, it.stop = ctx, make(chan struct{})
+	go func(closer io.Closer, stop <-chan struct{}) {
+		select {
+		case <-ctx.Done():
+			closer.Close() // unblock the pending read, if any; the error is uninteresting
+		case <-stop:
+		}
+	}(it.closer, it.stop)
+}
+
+// canceled returns the error of ctx, or else of the watched context, if either is done. The cancellation
+// of the watched context terminates the iterator, since its closer has been (or is being) closed.
+func (it *Iterator) canceled(ctx context.Context) error {
+	if it.watched != nil {
+		if err := it.watched.Err(); err != nil {
+			it.terminate(err)
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (it *Iterator) terminate(err error) {
+	if it.err != nil {
+		return
+	}
+	it.err = err
+	if it.stop != nil {
+		close(it.stop)
+	}
+}
+
+func isUnknownEvent(err error) bool {
+	_, ok := encoding.IsUnknownEvent(err)
+	return ok
+}
@@ -0,0 +1,203 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mesos/mesos-go/api/v1/lib"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding"
+	"github.com/mesos/mesos-go/api/v1/lib/encoding/framing"
+	jsonenc "github.com/mesos/mesos-go/api/v1/lib/encoding/json"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler"
+	"github.com/mesos/mesos-go/api/v1/lib/scheduler/events"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func TestIterator(t *testing.T) {
+	var (
+		n       = 0
+		failure = errors.New("failure")
+		d       = encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			n++
+			if n > 2 {
+				return failure
+			}
+			u.(*scheduler.Event).Type = scheduler.Event_HEARTBEAT
+			return nil
+		})
+		it  = events.NewIterator(d, nil)
+		ctx = context.Background()
+	)
+	for i := 0; i < 2; i++ {
+		e, err := it.Next(ctx)
+		if err != nil || e.GetType() != scheduler.Event_HEARTBEAT {
+			t.Fatalf("unexpected result: %v, %v", e, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := it.Next(ctx); err != failure {
+			t.Fatalf("expected failure instead of %v", err)
+		}
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 reads instead of %d", n)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := events.NewIterator(d, nil).Next(canceled); err != context.Canceled {
+		t.Fatalf("expected context.Canceled instead of %v", err)
+	}
+}
+
+func TestIteratorUnknownEvent(t *testing.T) {
+	var (
+		n = 0
+		d = encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			n++
+			if n == 1 {
+				return &encoding.UnknownEvent{Type: "FUTURE"}
+			}
+			u.(*scheduler.Event).Type = scheduler.Event_HEARTBEAT
+			return nil
+		})
+		it  = events.NewIterator(d, nil)
+		ctx = context.Background()
+	)
+	if _, err := it.Next(ctx); err == nil {
+		t.Fatal("expected an unknown event")
+	} else if _, ok := encoding.IsUnknownEvent(err); !ok {
+		t.Fatalf("expected an unknown event instead of %v", err)
+	}
+	// unknown events don't terminate the iterator
+	if e, err := it.Next(ctx); err != nil || e.GetType() != scheduler.Event_HEARTBEAT {
+		t.Fatalf("unexpected result: %v, %v", e, err)
+	}
+}
+
+func TestIteratorInterrupt(t *testing.T) {
+	var (
+		closed = make(chan struct{})
+		d      = encoding.DecoderFunc(func(encoding.Unmarshaler) error {
+			<-closed
+			return io.ErrUnexpectedEOF
+		})
+		it          = events.NewIterator(d, closerFunc(func() error { close(closed); return nil }))
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	for i := 0; i < 2; i++ {
+		if _, err := it.Next(ctx); err != context.Canceled {
+			t.Fatalf("expected context.Canceled instead of %v", err)
+		}
+	}
+}
+
+func TestIteratorWatcher(t *testing.T) {
+	var (
+		d = encoding.DecoderFunc(func(u encoding.Unmarshaler) error {
+			u.(*scheduler.Event).Type = scheduler.Event_HEARTBEAT
+			return nil
+		})
+		it          = events.NewIterator(d, closerFunc(func() error { return nil }))
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	// a single watcher, for the lifetime of the iterator, regardless of the number of events
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		if _, err := it.Next(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := runtime.NumGoroutine(); n > before+1 {
+		t.Fatalf("expected at most %d goroutines instead of %d", before+1, n)
+	}
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := it.Next(ctx); err != events.ErrClosed {
+		t.Fatalf("expected ErrClosed instead of %v", err)
+	}
+	waitForGoroutines(t, before)
+
+	// the watcher closes the closer upon cancellation, between reads, too
+	var (
+		watched = make(chan struct{})
+		it2     = events.NewIterator(d, closerFunc(func() error { close(watched); return nil }))
+	)
+	if _, err := it2.Next(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-watched
+	for i := 0; i < 2; i++ {
+		if _, err := it2.Next(ctx); err != context.Canceled {
+			t.Fatalf("expected context.Canceled instead of %v", err)
+		}
+	}
+	waitForGoroutines(t, before)
+}
+
+func waitForGoroutines(t *testing.T, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at most %d goroutines instead of %d", n, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestIteratorReuseEventsJSON(t *testing.T) {
+	var (
+		encoded [][]byte
+		status  = mesos.TaskStatus{TaskID: mesos.TaskID{Value: "t1"}, State: mesos.TASK_RUNNING.Enum(), UUID: []byte("u1")}
+	)
+	for _, e := range []*scheduler.Event{
+		{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: status}},
+		{Type: scheduler.Event_MESSAGE, Message: &scheduler.Event_Message{Data: []byte("hello")}},
+		{Type: scheduler.Event_UPDATE, Update: &scheduler.Event_Update{Status: mesos.TaskStatus{
+			TaskID: mesos.TaskID{Value: "t2"}, State: mesos.TASK_LOST.Enum(),
+		}}},
+	} {
+		b, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded = append(encoded, b)
+	}
+	var (
+		d = jsonenc.NewDecoder(func() framing.Reader {
+			return framing.ReaderFunc(func() ([]byte, error) {
+				if len(encoded) == 0 {
+					return nil, io.EOF
+				}
+				b := encoded[0]
+				encoded = encoded[1:]
+				return b, nil
+			})
+		})
+		it  = events.NewIterator(d, nil).ReuseEvents(true)
+		ctx = context.Background()
+	)
+	if e, err := it.Next(ctx); err != nil || string(e.GetUpdate().GetStatus().UUID) != "u1" {
+		t.Fatalf("unexpected result: %v, %v", e, err)
+	}
+	// the fields of the previous event don't linger
+	if e, err := it.Next(ctx); err != nil || e.Update != nil || string(e.GetMessage().GetData()) != "hello" {
+		t.Fatalf("unexpected result: %v, %v", e, err)
+	}
+	if e, err := it.Next(ctx); err != nil || e.Message != nil || e.GetUpdate().GetStatus().UUID != nil {
+		t.Fatalf("unexpected result: %v, %v", e, err)
+	}
+}
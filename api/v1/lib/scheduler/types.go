@@ -0,0 +1,13 @@
+package scheduler
+
+// The following implement encoding.Typed, so that decoders recognize messages of types that are unknown
+// to this version of the library.
+
+func (m *Event) TypeNames() map[int32]string { return Event_Type_name }
+func (m *Event) TypeValue() int32            { return int32(m.GetType()) }
+
+func (m *Response) TypeNames() map[int32]string { return Response_Type_name }
+func (m *Response) TypeValue() int32            { return int32(m.GetType()) }
+
+func (m *Call) TypeNames() map[int32]string { return Call_Type_name }
+func (m *Call) TypeValue() int32            { return int32(m.GetType()) }
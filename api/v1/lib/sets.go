@@ -0,0 +1,133 @@
+package mesos
+
+import (
+	"sort"
+)
+
+// Set represents the items of a SET value, e.g. of a custom resource. Funcs of Set never modify the
+// receiving Set (or their arguments) and return Sets in normal form: sorted, without duplicates.
+type Set []string
+
+// NewSet returns a Set of the given items, in normal form.
+func NewSet(items ...string) Set {
+	return Set(items).Normalize()
+}
+
+// Normalize returns a sorted copy of the Set, without duplicates. Normalize returns nil for empty Sets.
+func (s Set) Normalize() Set {
+	if len(s) == 0 {
+		return nil
+	}
+	x := append(Set(nil), s...)
+	sort.Strings(x)
+	n := 1
+	for i := 1; i < len(x); i++ {
+		if x[i] != x[n-1] {
+			x[n] = x[i]
+			n++
+		}
+	}
+	return x[:n]
+}
+
+// normalized returns true if s is sorted, without duplicates.
+func (s Set) normalized() bool {
+	for i := 1; i < len(s); i++ {
+		if s[i-1] >= s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normal returns s if it's normalized already, or else a normalized copy of s.
+func (s Set) normal() Set {
+	if s.normalized() {
+		return s
+	}
+	return s.Normalize()
+}
+
+// Contains returns true if the item is in s.
+func (s Set) Contains(item string) bool {
+	s = s.normal()
+	i := sort.SearchStrings(s, item)
+	return i < len(s) && s[i] == item
+}
+
+// Includes returns true if every item of right is in s.
+func (s Set) Includes(right Set) bool {
+	return len(right.Subtract(s)) == 0
+}
+
+// Union returns the items that are in either s or right.
+func (s Set) Union(right Set) Set {
+	x := make(Set, 0, len(s)+len(right))
+	return append(append(x, s...), right...).Normalize()
+}
+
+// Insert returns the union of s and the given items.
+func (s Set) Insert(items ...string) Set {
+	return s.Union(items)
+}
+
+// Intersect returns the items that are in both s and right.
+func (s Set) Intersect(right Set) Set {
+	var (
+		a, b = s.normal(), right.normal()
+		x    Set
+	)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			x = append(x, a[i])
+			i++
+			j++
+		}
+	}
+	return x
+}
+
+// Subtract returns the items of s that aren't in right, i.e. the difference of s and right.
+func (s Set) Subtract(right Set) Set {
+	var (
+		a, b = s.normal(), right.normal()
+		x    Set
+		j    int
+	)
+	for _, item := range a {
+		for j < len(b) && b[j] < item {
+			j++
+		}
+		if j == len(b) || b[j] != item {
+			x = append(x, item)
+		}
+	}
+	return x
+}
+
+// Remove returns the items of s other than the given items.
+func (s Set) Remove(items ...string) Set {
+	return s.Subtract(items)
+}
+
+// Value returns a SET value of the items of s, or nil if s is empty.
+func (s Set) Value() *Value_Set {
+	if len(s) == 0 {
+		return nil
+	}
+	return &Value_Set{Item: append([]string(nil), s...)}
+}
+
+// Resource returns a SET resource with the given name and the items of s.
+func (s Set) Resource(name string) Resource {
+	return Resource{
+		Name: name,
+		Type: SET.Enum(),
+		Set:  &Value_Set{Item: append([]string(nil), s...)},
+	}
+}
@@ -0,0 +1,69 @@
+package mesos
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSet_Algebra(t *testing.T) {
+	t.Parallel()
+
+	for i, tt := range []struct {
+		a, b                       Set
+		union, intersect, subtract Set
+	}{
+		{nil, nil, nil, nil, nil},
+		{Set{"a"}, nil, Set{"a"}, nil, Set{"a"}},
+		{nil, Set{"a"}, Set{"a"}, nil, nil},
+		{Set{"a"}, Set{"a"}, Set{"a"}, Set{"a"}, nil},
+		{Set{"a", "b"}, Set{"b", "c"}, Set{"a", "b", "c"}, Set{"b"}, Set{"a"}},
+		// not normalized
+		{Set{"c", "a", "c", "b"}, Set{"b", "b", "d"}, Set{"a", "b", "c", "d"}, Set{"b"}, Set{"a", "c"}},
+	} {
+		pre := append(Set(nil), tt.a...)
+		if got := tt.a.Union(tt.b); !reflect.DeepEqual(got, tt.union) {
+			t.Errorf("test #%d: Union(%v, %v): got: %v, want: %v", i, tt.a, tt.b, got, tt.union)
+		}
+		if got := tt.a.Intersect(tt.b); !reflect.DeepEqual(got, tt.intersect) {
+			t.Errorf("test #%d: Intersect(%v, %v): got: %v, want: %v", i, tt.a, tt.b, got, tt.intersect)
+		}
+		if got := tt.a.Subtract(tt.b); !reflect.DeepEqual(got, tt.subtract) {
+			t.Errorf("test #%d: Subtract(%v, %v): got: %v, want: %v", i, tt.a, tt.b, got, tt.subtract)
+		}
+		if !reflect.DeepEqual(pre, tt.a) {
+			t.Errorf("test #%d: %v was modified: %v", i, pre, tt.a)
+		}
+	}
+}
+
+func TestSet_Items(t *testing.T) {
+	t.Parallel()
+
+	s := NewSet("gpu1", "gpu0", "gpu0")
+	if want := (Set{"gpu0", "gpu1"}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("NewSet: got: %v, want: %v", s, want)
+	}
+	if !s.Contains("gpu1") || s.Contains("gpu2") || !(Set{"b", "a"}).Contains("a") {
+		t.Errorf("unexpected Contains(%v)", s)
+	}
+	if !s.Includes(Set{"gpu1"}) || !s.Includes(nil) || s.Includes(Set{"gpu1", "gpu2"}) {
+		t.Errorf("unexpected Includes(%v)", s)
+	}
+	if got, want := s.Insert("gpu2", "gpu0"), (Set{"gpu0", "gpu1", "gpu2"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Insert: got: %v, want: %v", got, want)
+	}
+	if got, want := s.Remove("gpu0", "gpu3"), (Set{"gpu1"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Remove: got: %v, want: %v", got, want)
+	}
+	if s.Remove(s...).Value() != nil {
+		t.Errorf("expected a nil value for an empty set")
+	}
+
+	r := s.Resource("devices")
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !r.GetSet().Contains("gpu0") || r.GetSet().Contains("gpu2") {
+		t.Errorf("unexpected Contains(%v)", r.GetSet())
+	}
+}
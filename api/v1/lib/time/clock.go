@@ -0,0 +1,211 @@
+package time
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Clock is a source of the current time, and of timers, for time-based components: it allows tests to
+	// substitute a FakeClock, which only advances when told to, for the system clock.
+	Clock interface {
+		// Now returns the current time.
+		Now() time.Time
+		// NewTimer returns a Timer that sends the current time on its chan once the duration has elapsed.
+		NewTimer(time.Duration) Timer
+		// AfterFunc returns a Timer that invokes the func once the duration has elapsed; the chan of the
+		// Timer is nil.
+		AfterFunc(time.Duration, func()) Timer
+	}
+
+	// Timer is the equivalent of a time.Timer, as returned by a Clock.
+	Timer interface {
+		// C returns the chan on which the time is sent when the timer fires.
+		C() <-chan time.Time
+		// Stop prevents the timer from firing; see time.Timer.Stop.
+		Stop() bool
+		// Reset changes the timer to fire after the duration; see time.Timer.Reset.
+		Reset(time.Duration) bool
+	}
+
+	systemClock struct{}
+	systemTimer struct{ *time.Timer }
+)
+
+// SystemClock is the Clock that's backed by the time package.
+var SystemClock Clock = systemClock{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+type (
+	// FakeClock is a Clock whose time only changes upon Advance (or Set): timers fire, in order of their
+	// deadlines, as the time passes them. The funcs of AfterFunc timers are invoked synchronously by
+	// Advance, so that their effects are observable once it returns. FakeClock funcs are safe to invoke
+	// concurrently.
+	FakeClock struct {
+		m       sync.Mutex
+		changed *sync.Cond // signaled whenever the set of pending timers changes
+		now     time.Time
+		timers  []*fakeTimer // pending, in no particular order
+	}
+
+	fakeTimer struct {
+		clock *FakeClock
+		when  time.Time
+		c     chan time.Time // nil for AfterFunc timers
+		f     func()
+	}
+)
+
+// NewFakeClock returns a FakeClock whose current time is now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.m)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+// NewTimer implements Clock; a timer of a non-positive duration fires immediately.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// AfterFunc implements Clock; the func of a timer of a non-positive duration is invoked immediately, by
+// the calling goroutine.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the time of the clock forward by the given duration, firing the timers that are due.
+func (c *FakeClock) Advance(d time.Duration) { c.Set(c.Now().Add(d)) }
+
+// Set changes the time of the clock, firing the timers that are due at the given time. Timers fire in
+// order of their deadlines, each at its deadline: i.e. the time of the clock, as observed by the func of a
+// fired timer, is the deadline of that timer. Timers that are scheduled by such funcs also fire if due.
+func (c *FakeClock) Set(now time.Time) {
+	c.m.Lock()
+	for {
+		t := c.next(now)
+		if t == nil {
+			break
+		}
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		fired := c.now
+		c.m.Unlock()
+		t.fire(fired)
+		c.m.Lock()
+	}
+	c.now = now
+	c.m.Unlock()
+}
+
+// Timers returns the number of timers that have yet to fire.
+func (c *FakeClock) Timers() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers blocks until there are at least n timers that have yet to fire; e.g. so that a test may
+// wait for a goroutine to schedule a timer before advancing the clock.
+func (c *FakeClock) WaitForTimers(n int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+// next removes, and returns, the earliest timer that's due at the given time; nil if there's none. The
+// lock must be held.
+func (c *FakeClock) next(now time.Time) *fakeTimer {
+	i := -1
+	for j, t := range c.timers {
+		if !t.when.After(now) && (i < 0 || t.when.Before(c.timers[i].when)) {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil
+	}
+	t := c.timers[i]
+	c.remove(i)
+	return t
+}
+
+// remove removes the i'th pending timer. The lock must be held.
+func (c *FakeClock) remove(i int) {
+	last := len(c.timers) - 1
+	c.timers[i] = c.timers[last]
+	c.timers[last] = nil
+	c.timers = c.timers[:last]
+	c.changed.Broadcast()
+}
+
+// stop removes the timer from the pending timers, returning true if it was pending. The lock must be held.
+func (c *FakeClock) stop(t *fakeTimer) bool {
+	for i := range c.timers {
+		if c.timers[i] == t {
+			c.remove(i)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.m.Lock()
+	defer t.clock.m.Unlock()
+	return t.clock.stop(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.m.Lock()
+	active := c.stop(t)
+	t.when = c.now.Add(d)
+	if d > 0 {
+		c.timers = append(c.timers, t)
+		c.changed.Broadcast()
+		c.m.Unlock()
+		return active
+	}
+	now := c.now
+	c.m.Unlock()
+	t.fire(now)
+	return active
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	if t.f != nil {
+		t.f()
+		return
+	}
+	// like a time.Timer, the chan only buffers a single time
+	select {
+	case t.c <- now:
+	default:
+	}
+}
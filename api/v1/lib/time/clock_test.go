@@ -0,0 +1,72 @@
+package time_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/mesos/mesos-go/api/v1/lib/time"
+)
+
+func TestFakeClock(t *testing.T) {
+	var (
+		start = time.Unix(1000, 0)
+		c     = NewFakeClock(start)
+		fired []string
+		t1    = c.NewTimer(time.Second)
+		t2    = c.AfterFunc(2*time.Second, func() { fired = append(fired, "t2") })
+		_     = c.AfterFunc(3*time.Second, func() { fired = append(fired, "t3") })
+	)
+	if n := c.Timers(); n != 3 {
+		t.Fatalf("expected 3 timers instead of %d", n)
+	}
+	c.Advance(time.Second)
+	select {
+	case now := <-t1.C():
+		if !now.Equal(start.Add(time.Second)) {
+			t.Fatalf("unexpected time %v", now)
+		}
+	default:
+		t.Fatal("expected t1 to fire")
+	}
+	if t1.Stop() {
+		t.Fatal("expected Stop of a fired timer to return false")
+	}
+	if !t2.Stop() || len(fired) != 0 {
+		t.Fatalf("expected t2 to be stopped before firing: %v", fired)
+	}
+
+	// timers that are scheduled by fired timers also fire, in order, if they're due
+	c.AfterFunc(time.Second, func() {
+		fired = append(fired, "t4")
+		c.AfterFunc(time.Second/2, func() { fired = append(fired, "t5") })
+	})
+	c.Advance(5 * time.Second)
+	if len(fired) != 3 || fired[0] != "t4" || fired[1] != "t5" || fired[2] != "t3" {
+		t.Fatalf("unexpected timers fired: %v", fired)
+	}
+	if now := c.Now(); !now.Equal(start.Add(6 * time.Second)) {
+		t.Fatalf("unexpected time %v", now)
+	}
+
+	// a reset timer fires anew; one of a non-positive duration fires immediately
+	if t1.Reset(time.Second) || c.Timers() != 1 {
+		t.Fatal("expected t1 to be rescheduled")
+	}
+	if !t1.Reset(0) {
+		t.Fatal("expected Reset of a pending timer to return true")
+	}
+	select {
+	case <-t1.C():
+	default:
+		t.Fatal("expected t1 to fire immediately")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-c.NewTimer(time.Minute).C()
+	}()
+	c.WaitForTimers(1)
+	c.Advance(time.Minute)
+	<-done
+}
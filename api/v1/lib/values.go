@@ -47,88 +47,46 @@ func (left *Value_Set) Compare(right *Value_Set) int {
 }
 
 func (left *Value_Set) Add(right *Value_Set) *Value_Set {
-	lefty := left.GetItem()
-	righty := right.GetItem()
-	c := len(lefty) + len(righty)
-	if c == 0 {
-		return nil
-	}
-	m := make(map[string]struct{}, c)
-	for _, v := range lefty {
-		m[v] = struct{}{}
-	}
-	for _, v := range righty {
-		m[v] = struct{}{}
-	}
-	x := make([]string, 0, len(m))
-	for v := range m {
-		x = append(x, v)
-	}
-	return &Value_Set{Item: x}
+	return Set(left.GetItem()).Union(right.GetItem()).Value()
 }
 
 func (left *Value_Set) Subtract(right *Value_Set) *Value_Set {
-	// for each item in right, remove it from left
-	lefty := left.GetItem()
-	righty := right.GetItem()
-	if c := len(lefty); c == 0 {
-		return nil
-	} else if len(righty) == 0 {
-		x := make([]string, c)
-		copy(x, lefty)
-		return &Value_Set{Item: x}
-	}
+	return Set(left.GetItem()).Subtract(right.GetItem()).Value()
+}
 
-	a := make(map[string]struct{}, len(lefty))
-	for _, x := range lefty {
-		a[x] = struct{}{}
-	}
-	for _, x := range righty {
-		delete(a, x)
-	}
-	if len(a) == 0 {
-		return nil
-	}
-	i := 0
-	for k := range a {
-		lefty[i] = k
-		i++
+// Contains returns true if the item is in the set.
+func (left *Value_Set) Contains(item string) bool {
+	for _, x := range left.GetItem() {
+		if x == item {
+			return true
+		}
 	}
-	return &Value_Set{Item: lefty[:len(a)]}
+	return false
 }
 
 func (left *Value_Ranges) Add(right *Value_Ranges) *Value_Ranges {
-	a, b := Ranges(left.GetRange()), Ranges(right.GetRange())
-	c := len(a) + len(b)
-	if c == 0 {
+	x := Ranges(left.GetRange()).Union(right.GetRange())
+	if len(x) == 0 {
 		return nil
 	}
-	x := make(Ranges, c)
-	if len(a) > 0 {
-		copy(x, a)
-	}
-	if len(b) > 0 {
-		copy(x[len(a):], b)
-	}
-	return &Value_Ranges{
-		Range: x.Sort().Squash(),
-	}
+	return &Value_Ranges{Range: x}
 }
 
 func (left *Value_Ranges) Subtract(right *Value_Ranges) *Value_Ranges {
-	a, b := Ranges(left.GetRange()), Ranges(right.GetRange())
-	if len(a) > 1 {
-		x := make(Ranges, len(a))
-		copy(x, a)
-		a = x.Sort().Squash()
-	}
-	for _, r := range b {
-		a = a.Remove(r)
+	x := Ranges(left.GetRange()).Subtract(right.GetRange())
+	if len(x) == 0 {
+		return nil
 	}
-	if len(a) == 0 {
+	return &Value_Ranges{Range: x}
+}
+
+// Intersect returns the ranges that are in both left and right, or nil if there are none.
+func (left *Value_Ranges) Intersect(right *Value_Ranges) *Value_Ranges {
+	x := Ranges(left.GetRange()).Intersect(right.GetRange())
+	if len(x) == 0 {
 		return nil
 	}
-	return &Value_Ranges{Range: a}
+	return &Value_Ranges{Range: x}
 }
 
 func (left *Value_Scalar) Add(right *Value_Scalar) *Value_Scalar {
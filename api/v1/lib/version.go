@@ -0,0 +1,5 @@
+package mesos
+
+// Version is the version of this library; it's reported, for example, in the User-Agent header of HTTP
+// API requests. This is a variable so that it can be set at link time.
+var Version = "0.0.11"